@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/platform"
+	"github.com/spf13/cobra"
+)
+
+// newConfigCmd groups the config subcommands: config validate
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Work with the configuration",
+		Args:  usageArgs(cobra.NoArgs),
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "validate",
+		Short: "Check every config source; exits 1 on problems",
+		Long: `Check config.json, ~/.config/claude-notifications/config.toml, the project's
+.claude-notifications.toml and CLAUDE_NOTIFICATIONS_* overrides; exits 1 on
+problems.`,
+		Args: usageArgs(cobra.NoArgs),
+		Run: func(cmd *cobra.Command, args []string) {
+			runConfigValidate()
+		},
+	})
+	return cmd
+}
+
+// runConfigValidate reports problems in every config source
+func runConfigValidate() {
+	cwd, _ := os.Getwd()
+	if !validateConfig(os.Stdout, getPluginRoot(), cwd, os.Environ()) {
+		os.Exit(1)
+	}
+}
+
+// validateConfig checks every config source, including the project config
+// for cwd, then the merged result, and reports each problem with the file
+// and key it comes from. It returns false if there were problems.
+func validateConfig(w io.Writer, pluginRoot, cwd string, environ []string) bool {
+	ok := true
+	report := func(source string, errs []error) {
+		if len(errs) == 0 {
+			fmt.Fprintf(w, "✓ %s\n", source)
+			return
+		}
+		ok = false
+		fmt.Fprintf(w, "✗ %s\n", source)
+		for _, err := range errs {
+			fmt.Fprintf(w, "    %v\n", err)
+		}
+	}
+
+	var files []string
+	if path, err := config.GetStableConfigPath(); err == nil {
+		files = append(files, path)
+	}
+	userPath, err := config.FindUserConfigFile()
+	if err != nil {
+		report("user config", []error{err})
+	} else if userPath != "" {
+		files = append(files, userPath)
+	}
+	projectPath := config.FindProjectConfig(cwd)
+	if projectPath != "" {
+		files = append(files, projectPath)
+	}
+	found := false
+	for _, path := range files {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		found = true
+		check := config.CheckFile
+		if path == projectPath {
+			check = config.CheckProjectFile
+		}
+		errs := check(path)
+		for i, err := range errs {
+			// Listed under the file already
+			errs[i] = errors.New(strings.TrimPrefix(err.Error(), path+": "))
+		}
+		report(path, errs)
+	}
+	if !found {
+		dir, _ := config.GetUserConfigDir()
+		fmt.Fprintf(w, "- no config file (create %s/config.toml to customize)\n", dir)
+	}
+
+	names, errs := config.ApplyEnv(config.DefaultConfig(), environ)
+	if len(names) > 0 || len(errs) > 0 {
+		source := "environment"
+		if len(names) > 0 {
+			source = fmt.Sprintf("environment (%d overrides)", len(names))
+		}
+		report(source, errs)
+	}
+
+	// Validate the merged config; load warnings repeat the problems above
+	cfg, _ := config.LoadForProject(pluginRoot, cwd)
+	if err := cfg.Validate(); err != nil {
+		report("merged config", []error{err})
+	} else {
+		report("merged config", nil)
+	}
+	// Run the commands and keyring lookups a notification would
+	if errs := cfg.ResolveSecrets(platform.ResolveSecret); len(errs) > 0 {
+		report("secrets", errs)
+	}
+	return ok
+}
@@ -0,0 +1,6 @@
+//go:build !windows
+
+package main
+
+// detachConsole is a no-op: only Windows gives a background task a console
+func detachConsole() {}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package main
+
+import "syscall"
+
+var procFreeConsole = syscall.NewLazyDLL("kernel32.dll").NewProc("FreeConsole")
+
+// detachConsole closes the console window Windows opens for a task
+// started at logon; output after this is discarded, the log file remains
+func detachConsole() {
+	_, _, _ = procFreeConsole.Call()
+}
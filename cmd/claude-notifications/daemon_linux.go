@@ -3,23 +3,355 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/777genius/claude-notifications/internal/config"
 	"github.com/777genius/claude-notifications/internal/daemon"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/platform"
+	"github.com/777genius/claude-notifications/internal/sessionname"
+	"github.com/777genius/claude-notifications/internal/sessions"
+	"github.com/spf13/cobra"
 )
 
-// runDaemon runs the notification daemon server on Linux
-func runDaemon() {
+// controlOptions are the flags of the daemon control subcommands
+type controlOptions struct {
+	json           bool
+	notificationID uint32
+	target         string
+	folder         string
+}
+
+// newDaemonCmd runs the notification daemon server on Linux, or sends it a
+// control request: daemon [--idle-timeout <d>] [--drain-timeout <d>] | status|sessions|focus|mute|unmute|stop
+func newDaemonCmd() *cobra.Command {
+	cfg := daemon.DefaultServerConfig()
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Run the notification daemon (Linux only)",
+		Long: `Run the notification daemon, for click-to-focus support on desktop
+notifications. Hooks start it on demand; the subcommands talk to the
+running daemon.`,
+		Example: `  # Run the daemon in the foreground (Linux only, started automatically)
+  claude-notifications daemon`,
+		Args: usageArgs(cobra.NoArgs),
+		Run: func(cmd *cobra.Command, args []string) {
+			serveDaemon(cfg)
+		},
+	}
+	cmd.Flags().DurationVar(&cfg.IdleTimeout, "idle-timeout", cfg.IdleTimeout, "exit after this long without requests (0 = never)")
+	cmd.Flags().DurationVar(&cfg.DrainTimeout, "drain-timeout", cfg.DrainTimeout, "on shutdown, wait this long for notifications being delivered")
+
+	var opts controlOptions
+	control := func(use, short string, args cobra.PositionalArgs) *cobra.Command {
+		c := &cobra.Command{
+			Use:   use,
+			Short: short,
+			Args:  usageArgs(args),
+			RunE: func(c *cobra.Command, args []string) error {
+				return controlDaemon(c.Name(), args, opts)
+			},
+		}
+		c.Flags().BoolVar(&opts.json, "json", false, "print the daemon's JSON response")
+		return c
+	}
+	focus := control("focus [session]", "Focus a session's terminal", cobra.MaximumNArgs(1))
+	focus.Long = "Focus a session's terminal; or that of --notification <id>, or --target <terminal> [--folder <project>]."
+	focus.ValidArgsFunction = completeSessions
+	focus.Flags().Uint32Var(&opts.notificationID, "notification", 0, "the terminal of this notification ID")
+	focus.Flags().StringVar(&opts.target, "target", "", "this terminal (e.g. kitty, code)")
+	focus.Flags().StringVar(&opts.folder, "folder", "", "the window for this project folder (with --target)")
+	mute := control("mute [30m]", "Turn do-not-disturb on, for a duration or until unmuted", cobra.MaximumNArgs(1))
+	var tokenOnly bool
+	dashboardCmd := &cobra.Command{
+		Use:   "dashboard",
+		Short: "Print the dashboard's URL, with its access token",
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(c *cobra.Command, args []string) error {
+			return printDashboardURL(tokenOnly)
+		},
+	}
+	dashboardCmd.Flags().BoolVar(&tokenOnly, "token", false, "print only the token, for scripts")
+
+	cmd.AddCommand(
+		control("status", "Show the running daemon's uptime, notifications and mute state", cobra.NoArgs),
+		control("sessions", "List sessions known to the daemon, with their IDs", cobra.NoArgs),
+		focus,
+		mute,
+		control("unmute", "Turn do-not-disturb off", cobra.NoArgs),
+		control("stop", "Stop the daemon", cobra.NoArgs),
+		dashboardCmd,
+	)
+	return cmd
+}
+
+// serveDaemon runs the daemon server until it is stopped or idle
+func serveDaemon(cfg daemon.ServerConfig) {
+	cfg.PluginRoot = getPluginRoot()
+
+	// Log to stderr (the journal under systemd) and the shared log file,
+	// since a daemon started on demand by a hook has no stderr
 	log.SetFlags(log.Ltime | log.Lmicroseconds)
+	if _, err := logging.InitLogger(logDir(cfg.PluginRoot)); err == nil {
+		defer logging.Close()
+		logging.SetPrefix("daemon")
+		if c, _ := config.LoadWithWarnings(cfg.PluginRoot); c != nil {
+			logging.Configure(c.Logging.Options())
+		}
+		log.SetOutput(io.MultiWriter(os.Stderr, logging.StdLogWriter()))
+	}
 	log.Println("[INFO] Starting notification daemon...")
 
-	cfg := daemon.DefaultServerConfig()
 	server, err := daemon.NewServer(cfg)
 	if err != nil {
 		log.Fatalf("[ERROR] Failed to create daemon server: %v", err)
 	}
 
 	if err := server.Run(); err != nil {
+		if errors.Is(err, daemon.ErrAlreadyRunning) {
+			log.Printf("[ERROR] %v; stop it with: claude-notifications daemon stop", err)
+			os.Exit(1)
+		}
 		log.Fatalf("[ERROR] Daemon server error: %v", err)
 	}
 }
+
+// printDashboardURL prints the URL of the dashboard the daemon serves, or
+// its token alone. The token changes whenever the daemon starts.
+func printDashboardURL(tokenOnly bool) error {
+	data, err := os.ReadFile(daemon.GetDashboardURLPath())
+	if os.IsNotExist(err) {
+		return fmt.Errorf("the daemon is not serving the dashboard; set \"dashboard\": {\"enabled\": true} and restart it")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read dashboard URL: %w", err)
+	}
+	dashboardURL := strings.TrimSpace(string(data))
+	if tokenOnly {
+		u, err := url.Parse(dashboardURL)
+		if err != nil {
+			return fmt.Errorf("invalid dashboard URL: %w", err)
+		}
+		dashboardURL = u.Query().Get("token")
+	}
+	fmt.Println(dashboardURL)
+	return nil
+}
+
+// handOffHook hands a hook to the daemon, starting it when needed, and
+// reports whether the daemon took it. The hook is handled in this process
+// when async is off, outside a desktop session (e.g. over SSH, where
+// notifications are forwarded instead), or when the daemon is unavailable.
+func handOffHook(pluginRoot, hookEvent string, payload []byte) bool {
+	if !json.Valid(payload) || platform.IsSSHSession() ||
+		(os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "") {
+		return false
+	}
+	var hook struct {
+		CWD string `json:"cwd"`
+	}
+	_ = json.Unmarshal(payload, &hook)
+	if cfg, _ := config.LoadForProject(pluginRoot, hook.CWD); !cfg.IsAsyncEnabled() {
+		return false
+	}
+
+	started := time.Now()
+	dir, _ := os.Getwd()
+	var ahead int
+	err := daemon.WithDaemon(func(client *daemon.Client) error {
+		var err error
+		ahead, err = client.HandleHook(&daemon.HookRequest{
+			Event:   hookEvent,
+			Payload: payload,
+			Dir:     dir,
+			Env:     daemon.HookEnv(),
+		})
+		return err
+	})
+	if errors.Is(err, daemon.ErrDaemonNotAvailable) || errors.Is(err, daemon.ErrDaemonNotRunning) {
+		logging.Debug("Daemon not available, handling %s hook here: %v", hookEvent, err)
+		return false
+	}
+	if err != nil {
+		logging.Warn("Failed to hand %s hook to the daemon, handling it here: %v", hookEvent, err)
+		return false
+	}
+	logging.Debug("Handed %s hook to the daemon in %v (%d of the session ahead)", hookEvent, time.Since(started).Round(time.Microsecond), ahead)
+	return true
+}
+
+// controlDaemon sends one control request to the running daemon and
+// prints the answer; --json prints the response payload instead
+func controlDaemon(action string, args []string, opts controlOptions) error {
+	client, err := daemon.NewClient()
+	if err != nil {
+		return err
+	}
+
+	var result interface{}
+	switch action {
+	case "status":
+		st, err := client.Status()
+		if err != nil {
+			return err
+		}
+		result = st
+		if !opts.json {
+			printDaemonStatus(st)
+		}
+	case "sessions":
+		list, err := client.ListSessions()
+		if err != nil {
+			return err
+		}
+		result = list
+		if !opts.json {
+			printDaemonSessions(list, time.Now())
+		}
+	case "focus":
+		req := &daemon.FocusRequest{NotificationID: opts.notificationID, Target: opts.target, Folder: opts.folder}
+		if len(args) > 0 {
+			req.SessionID = args[0]
+		}
+		resp, err := client.Focus(req)
+		if err != nil {
+			return err
+		}
+		result = resp
+		if !opts.json {
+			fmt.Printf("Focused %s\n", resp.Target)
+		}
+	case "mute":
+		var d time.Duration
+		if len(args) > 0 {
+			if d, err = time.ParseDuration(args[0]); err != nil || d <= 0 {
+				return fmt.Errorf("invalid duration %q (e.g. 30m, 2h)", args[0])
+			}
+		}
+		resp, err := client.Mute(d)
+		if err != nil {
+			return err
+		}
+		result = resp
+		if !opts.json {
+			printMute(resp)
+		}
+	case "unmute":
+		resp, err := client.Unmute()
+		if err != nil {
+			return err
+		}
+		result = resp
+		if !opts.json {
+			printMute(resp)
+		}
+	case "stop":
+		if err := client.Stop(); err != nil {
+			return err
+		}
+		result = map[string]bool{"stopped": true}
+		if !opts.json {
+			fmt.Println("Daemon stopped")
+		}
+	default:
+		return fmt.Errorf("unknown daemon action: %s (use status, sessions, focus, mute, unmute or stop)", action)
+	}
+
+	if opts.json {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	return nil
+}
+
+// printDaemonStatus prints the daemon status for people
+func printDaemonStatus(st *daemon.StatusResponse) {
+	fmt.Printf("Daemon:        running (pid %d, protocol %s)\n", st.PID, st.Version)
+	fmt.Printf("Uptime:        %s\n", sessions.FormatDuration(time.Duration(st.Uptime)*time.Second))
+	last := "never"
+	if st.LastNotification != nil {
+		last = sessions.FormatDuration(time.Since(*st.LastNotification)) + " ago"
+	}
+	fmt.Printf("Notifications: %d sent, last %s, %d clickable\n", st.NotificationsSent, last, st.ActiveNotifications)
+	fmt.Printf("Muted:         %s\n", muteState(st.Muted, st.MutedReason, st.MutedUntil))
+}
+
+// printDaemonSessions prints one line per session, like "sessions"
+func printDaemonSessions(list []daemon.SessionInfo, now time.Time) {
+	if len(list) == 0 {
+		fmt.Println("No active sessions")
+		return
+	}
+	fmt.Printf("%-22s %-36s %-20s %-24s %s\n", "SESSION", "ID", "PROJECT", "TERMINAL", "RUNNING")
+	for _, s := range list {
+		terminal := s.Terminal
+		if terminal == "" {
+			terminal = "-"
+		}
+		if s.TerminalPID != 0 {
+			terminal += fmt.Sprintf(" (%d)", s.TerminalPID)
+		}
+		if s.TmuxPane != "" {
+			terminal += " " + s.TmuxPane
+		}
+		fmt.Printf("%-22s %-36s %-20s %-24s %s\n",
+			sessionname.GenerateSessionLabel(s.ID), s.ID, s.Project, terminal,
+			sessions.FormatDuration(now.Sub(s.StartedAt)))
+	}
+}
+
+// printMute prints the do-not-disturb state after mute or unmute
+func printMute(resp *daemon.MuteResponse) {
+	fmt.Printf("Muted: %s\n", muteState(resp.Muted, resp.Reason, resp.Until))
+}
+
+// muteState describes the do-not-disturb state
+func muteState(muted bool, reason string, until *time.Time) string {
+	if !muted {
+		return "no"
+	}
+	state := "yes (" + reason
+	if until != nil {
+		state += ", until " + until.Local().Format("15:04")
+	}
+	return state + ")"
+}
+
+// focusSession focuses the terminal of a session from the tray: through the
+// running daemon, which knows its multiplexer pane and pinned window, or
+// else the focus chain
+func focusSession(s sessions.Session) error {
+	if daemon.IsDaemonRunning() {
+		client, err := daemon.NewClient()
+		if err == nil {
+			if _, err = client.Focus(&daemon.FocusRequest{SessionID: s.ID}); err == nil {
+				return nil
+			}
+		}
+		logging.Debug("Daemon could not focus session %s, using the focus chain: %v", s.ID, err)
+	}
+	return daemon.TryFocus(s.Terminal, filepath.Base(s.CWD))
+}
+
+// dismissSession closes the notifications a running daemon still shows for
+// a session and returns how many it closed
+func dismissSession(sessionID string) (int, error) {
+	if !daemon.IsDaemonRunning() {
+		return 0, nil
+	}
+	client, err := daemon.NewClient()
+	if err != nil {
+		return 0, err
+	}
+	return client.Dismiss(sessionID)
+}
@@ -5,11 +5,47 @@ package main
 import (
 	"fmt"
 	"os"
+
+	"github.com/777genius/claude-notifications/internal/daemon"
+	"github.com/777genius/claude-notifications/internal/platform"
+	"github.com/777genius/claude-notifications/internal/sessions"
+	"github.com/spf13/cobra"
 )
 
-// runDaemon is a stub for non-Linux platforms
-func runDaemon() {
-	fmt.Fprintln(os.Stderr, "Error: notification daemon is only available on Linux")
-	fmt.Fprintln(os.Stderr, "On macOS, click-to-focus uses terminal-notifier instead.")
-	os.Exit(1)
+// handOffHook reports that the hook must be handled in this process: there
+// is no daemon to take it outside Linux
+func handOffHook(pluginRoot, hookEvent string, payload []byte) bool {
+	return false
+}
+
+// newDaemonCmd is a stub for non-Linux platforms
+func newDaemonCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:                "daemon",
+		Short:              "Run the notification daemon (Linux only)",
+		DisableFlagParsing: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Fprintln(os.Stderr, "Error: notification daemon is only available on Linux")
+			fmt.Fprintln(os.Stderr, "On macOS, click-to-focus uses terminal-notifier instead.")
+			os.Exit(1)
+		},
+	}
+}
+
+// focusSession focuses the window of a session from the tray, like
+// click-to-focus: by bundle ID on macOS, by terminal name on Windows
+func focusSession(s sessions.Session) error {
+	target := s.Terminal
+	if platform.IsMacOS() {
+		if target = daemon.GetMacBundleID(s.Terminal); target == "" {
+			target = "com.apple.Terminal"
+		}
+	}
+	return focusWindow(target, s.CWD)
+}
+
+// dismissSession closes nothing: only the Linux daemon can close the
+// notifications it showed
+func dismissSession(sessionID string) (int, error) {
+	return 0, nil
 }
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/platform"
+	"github.com/777genius/claude-notifications/internal/seal"
+	"github.com/spf13/cobra"
+)
+
+// keyEnv holds the decryption key on the receiving side
+const keyEnv = "CLAUDE_NOTIFICATIONS_KEY"
+
+// newDecryptCmd reads encrypted notifications:
+// decrypt [text...] [--key KEY] [--json] | decrypt --new-key
+func newDecryptCmd() *cobra.Command {
+	var (
+		key    string
+		asJSON bool
+		newKey bool
+	)
+	cmd := &cobra.Command{
+		Use:   "decrypt [text...]",
+		Short: "Read notifications encrypted for webhook and MQTT backends",
+		Long: `Decrypt the notifications of backends with an encrypt.key, as received
+from the ntfy server, MQTT broker or webhook service. The text may be a bare
+"cnseal1:..." envelope or a whole payload containing one; without
+arguments, each line of standard input is read as it arrives.
+
+The key comes from --key, else ` + keyEnv + `, else the encrypt.key
+settings of the local config. --new-key prints a new key to put in both
+places.`,
+		Example: `  # Create a key for encrypt.key
+  claude-notifications decrypt --new-key
+
+  # Read an ntfy topic as it is published
+  ntfy subscribe my-claude 'claude-notifications decrypt "$m"'
+
+  # Read an MQTT topic
+  mosquitto_sub -t 'claude-notifications/#' | claude-notifications decrypt`,
+		Args: usageArgs(cobra.ArbitraryArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if newKey {
+				k, err := seal.NewKey()
+				if err != nil {
+					return err
+				}
+				fmt.Println(k)
+				return nil
+			}
+			keys, err := decryptKeys(key)
+			if err != nil {
+				return err
+			}
+			if len(args) > 0 {
+				return decryptText(os.Stdout, strings.Join(args, " "), keys, asJSON)
+			}
+			return decryptLines(os.Stdout, os.Stdin, keys, asJSON)
+		},
+	}
+	cmd.Flags().StringVar(&key, "key", "", "base64 key (default: $"+keyEnv+" or encrypt.key from the config)")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print notifications as JSON lines")
+	cmd.Flags().BoolVar(&newKey, "new-key", false, "print a new random key and exit")
+	return cmd
+}
+
+// decryptKeys returns the keys to try: the flag, else the environment,
+// else every encrypt.key of the config
+func decryptKeys(flag string) ([][]byte, error) {
+	var encoded []string
+	switch {
+	case flag != "":
+		encoded = []string{flag}
+	case os.Getenv(keyEnv) != "":
+		encoded = []string{os.Getenv(keyEnv)}
+	default:
+		if cfg, err := config.LoadFromPluginRoot(getPluginRoot()); err == nil {
+			encoded = append(encoded, cfg.Notifications.Webhook.Encrypt.Key, cfg.Notifications.MQTT.Encrypt.Key)
+			for _, w := range cfg.Notifications.Webhooks {
+				encoded = append(encoded, w.Encrypt.Key)
+			}
+		}
+	}
+
+	var keys [][]byte
+	for _, value := range encoded {
+		s, err := config.ResolveSecretRef(value, platform.ResolveSecret)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt key: %w", err)
+		}
+		if s == "" {
+			continue
+		}
+		key, err := seal.ParseKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no key: pass --key, set %s or set encrypt.key in the config", keyEnv)
+	}
+	return keys, nil
+}
+
+// decryptText prints the notifications sealed in text
+func decryptText(w io.Writer, text string, keys [][]byte, asJSON bool) error {
+	envelopes := seal.Find(text)
+	if len(envelopes) == 0 {
+		return fmt.Errorf("no encrypted notification found")
+	}
+	for _, envelope := range envelopes {
+		n, err := openWithKeys(envelope, keys)
+		if err != nil {
+			return err
+		}
+		printSealed(w, n, asJSON)
+	}
+	return nil
+}
+
+// decryptLines prints the notifications of each line of r as it is read.
+// Lines without one are skipped and lines that fail are reported.
+func decryptLines(w io.Writer, r io.Reader, keys [][]byte, asJSON bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	failed := false
+	for scanner.Scan() {
+		for _, envelope := range seal.Find(scanner.Text()) {
+			n, err := openWithKeys(envelope, keys)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				failed = true
+				continue
+			}
+			printSealed(w, n, asJSON)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if failed {
+		return errors.New("some notifications could not be decrypted")
+	}
+	return nil
+}
+
+// openWithKeys opens an envelope with the first key that fits
+func openWithKeys(envelope string, keys [][]byte) (seal.Notification, error) {
+	var err error
+	for _, key := range keys {
+		var n seal.Notification
+		if n, err = seal.Open(key, envelope); err == nil {
+			return n, nil
+		}
+	}
+	return seal.Notification{}, err
+}
+
+// printSealed prints a notification as "time  title · project (branch)"
+// followed by its message
+func printSealed(w io.Writer, n seal.Notification, asJSON bool) {
+	if asJSON {
+		_ = json.NewEncoder(w).Encode(n)
+		return
+	}
+	header := n.Time.Local().Format("2006-01-02 15:04") + "  " + n.Title
+	if n.Project != "" {
+		header += " · " + n.Project
+		if n.Branch != "" {
+			header += " (" + n.Branch + ")"
+		}
+	}
+	fmt.Fprintln(w, header)
+	fmt.Fprintln(w, n.Message)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/dnd"
+	"github.com/777genius/claude-notifications/internal/hooks"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/spf13/cobra"
+)
+
+// newDNDCmd shows or changes the do-not-disturb state:
+// dnd [status], dnd on, dnd off, dnd until <30m|07:30>
+func newDNDCmd() *cobra.Command {
+	action := func(name string) func(cmd *cobra.Command, args []string) {
+		return func(cmd *cobra.Command, args []string) {
+			runDND(name, args)
+		}
+	}
+	cmd := &cobra.Command{
+		Use:   "dnd",
+		Short: "Show or change the do-not-disturb state",
+		Args:  usageArgs(cobra.NoArgs),
+		Run:   action("status"),
+	}
+	cmd.AddCommand(
+		&cobra.Command{Use: "status", Short: "Show the do-not-disturb state (default)", Args: usageArgs(cobra.NoArgs), Run: action("status")},
+		&cobra.Command{Use: "on", Short: "Turn do-not-disturb on until turned off", Args: usageArgs(cobra.NoArgs), Run: action("on")},
+		&cobra.Command{Use: "off", Short: "Turn do-not-disturb off and deliver the digest", Args: usageArgs(cobra.NoArgs), Run: action("off")},
+		&cobra.Command{
+			Use:     "until <30m|07:30>",
+			Short:   "Turn do-not-disturb on for a duration or until a time",
+			Example: "  claude-notifications dnd until 1h\n  claude-notifications dnd until 07:30",
+			Args:    usageArgs(cobra.ExactArgs(1)),
+			Run:     action("until"),
+		},
+	)
+	return cmd
+}
+
+// runDND runs a do-not-disturb action with its arguments, then prints the state
+func runDND(action string, args []string) {
+	pluginRoot := getPluginRoot()
+	if _, err := logging.InitLogger(logDir(pluginRoot)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logging.Close()
+
+	cfg, err := config.LoadFromPluginRoot(pluginRoot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	logging.Configure(cfg.Logging.Options())
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	mgr := dnd.NewManager(dir, cfg.Notifications.DND)
+	now := time.Now()
+
+	switch action {
+	case "status":
+	case "on":
+		err = mgr.On(time.Time{})
+	case "until":
+		var until time.Time
+		if until, err = dnd.ParseUntil(args[0], now); err == nil {
+			err = mgr.On(until)
+		}
+	case "off":
+		if err = mgr.Off(now); err == nil {
+			deliverDNDDigest(pluginRoot)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown dnd action: %s (use on, off, until, or status)\n", action)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	printDNDStatus(mgr.Status(now))
+}
+
+// deliverDNDDigest sends the summary of notifications held back during DND
+func deliverDNDDigest(pluginRoot string) {
+	handler, err := hooks.NewHandler(pluginRoot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: digest not delivered: %v\n", err)
+		return
+	}
+	if n := handler.SendDNDDigest(); n > 0 {
+		fmt.Printf("Delivered digest of %d held notification(s)\n", n)
+	}
+}
+
+// printDNDStatus prints whether do-not-disturb is active and until when
+func printDNDStatus(st dnd.Status) {
+	if !st.Active {
+		fmt.Println("Do-not-disturb: off")
+		return
+	}
+	until := "until turned off"
+	if !st.Until.IsZero() {
+		until = "until " + st.Until.Format("Mon 15:04")
+	}
+	fmt.Printf("Do-not-disturb: on (%s) %s\n", st.Reason, until)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+
+	"github.com/777genius/claude-notifications/internal/doctor"
+	"github.com/spf13/cobra"
+)
+
+// newDoctorCmd diagnoses the notification setup:
+// doctor [--no-notify] [--no-focus] [--probe]
+func newDoctorCmd() *cobra.Command {
+	var noNotify, noFocus, probe bool
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check config, hooks, notification backend and focus tools",
+		Long: `Check config, hooks, notification backend and focus tools. Sends a test
+notification and focuses the terminal; exits 1 if a check fails.`,
+		Args: usageArgs(cobra.NoArgs),
+		Run: func(cmd *cobra.Command, args []string) {
+			runDoctor(!noNotify, !noFocus, probe)
+		},
+	}
+	cmd.Flags().BoolVar(&noNotify, "no-notify", false, "don't send a test notification")
+	cmd.Flags().BoolVar(&noFocus, "no-focus", false, "don't try to focus the terminal")
+	cmd.Flags().BoolVar(&probe, "probe", false, "try every focus method and relearn which one to try first")
+	return cmd
+}
+
+// runDoctor diagnoses the notification setup and exits non-zero if a check fails
+func runDoctor(notify, focus, probe bool) {
+	home, _ := os.UserHomeDir()
+	cwd, _ := os.Getwd()
+	results := doctor.Run(doctor.Options{
+		PluginRoot: getPluginRoot(),
+		Home:       home,
+		CWD:        cwd,
+		Notify:     notify,
+		Focus:      focus,
+		Probe:      probe,
+	})
+
+	doctor.Print(os.Stdout, results)
+	if doctor.Failed(results) {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/escalation"
+	"github.com/777genius/claude-notifications/internal/sessionname"
+	"github.com/777genius/claude-notifications/internal/sessions"
+	"github.com/spf13/cobra"
+)
+
+// newFocusCmd focuses a session's terminal, for key bindings:
+// focus [session] [--project <name>]
+func newFocusCmd() *cobra.Command {
+	var project string
+	cmd := &cobra.Command{
+		Use:   "focus [session]",
+		Short: "Focus the terminal of the session that needs you, or of a given session or project",
+		Long: `Focus the terminal of a session, like clicking its notification. Without
+arguments, the session that needs you most: an idle session with the latest
+notification. Bind it, or focus-last and ack, to a hotkey or Stream Deck key.`,
+		Example: `  claude-notifications focus --project api
+  claude-notifications focus 06ddb8f7`,
+		Args:              usageArgs(cobra.MaximumNArgs(1)),
+		ValidArgsFunction: completeSessions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFocus(args, project)
+		},
+	}
+	cmd.Flags().StringVar(&project, "project", "", "a session of this project (folder name or path)")
+	_ = cmd.RegisterFlagCompletionFunc("project", completeProjects)
+	return cmd
+}
+
+// newFocusLastCmd focuses the session that needs attention: focus-last
+func newFocusLastCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "focus-last",
+		Short: "Focus the terminal of the session with the latest notification",
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFocus(nil, "")
+		},
+	}
+}
+
+// newAckCmd acknowledges a session's notifications: ack [session] [--project <name>]
+func newAckCmd() *cobra.Command {
+	var project string
+	cmd := &cobra.Command{
+		Use:               "ack [session]",
+		Short:             "Acknowledge the latest notification: close it and cancel its escalation",
+		Args:              usageArgs(cobra.MaximumNArgs(1)),
+		ValidArgsFunction: completeSessions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAck(args, project)
+		},
+	}
+	cmd.Flags().StringVar(&project, "project", "", "a session of this project (folder name or path)")
+	_ = cmd.RegisterFlagCompletionFunc("project", completeProjects)
+	return cmd
+}
+
+// newFocusPendingCmd focuses the sessions waiting for the user in turn:
+// focus-pending
+func newFocusPendingCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "focus-pending",
+		Short: "Focus the oldest session with an unacknowledged notification; repeat to cycle through them",
+		Long: `Focus the terminal of the session that has waited longest for you: idle,
+with a notification you have not acknowledged. Pressed again within a
+minute, focus the next waiting session, wrapping around. Replying to a
+session, or running ack, takes it off the list.`,
+		Args: usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFocusPending()
+		},
+	}
+}
+
+// pendingCycle is how soon a focus-pending must follow the last one to move
+// on to the next waiting session rather than start over at the oldest
+const pendingCycle = time.Minute
+
+// pendingCursor is the session focus-pending focused last, in a file next
+// to the config
+type pendingCursor struct {
+	Session string    `json:"session"`
+	At      time.Time `json:"at"`
+}
+
+// runFocus focuses the terminal of the picked session. Like a click, that
+// acknowledges its notification, so a pending escalation is cancelled.
+func runFocus(args []string, project string) error {
+	v, err := loadSessionView()
+	if err != nil {
+		return err
+	}
+	s, err := v.pick(args, project)
+	if err != nil {
+		return err
+	}
+	if err := focusSession(s); err != nil {
+		return fmt.Errorf("failed to focus %s: %w", describeSession(s), err)
+	}
+	if err := v.acknowledge(s); err != nil {
+		return err
+	}
+	fmt.Printf("Focused %s\n", describeSession(s))
+	return nil
+}
+
+// runFocusPending focuses the next session waiting for the user, without
+// acknowledging it: it stays in the cycle until the user replies or acks
+func runFocusPending() error {
+	v, err := loadSessionView()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	last := v.cursor(now)
+	s, ok := sessions.Next(v.pending(), last)
+	if !ok {
+		fmt.Println("No sessions waiting for you")
+		return nil
+	}
+	// Saved first, so the next press moves on even if this window is gone
+	if err := v.saveCursor(pendingCursor{Session: s.ID, At: now}); err != nil {
+		return err
+	}
+	if err := focusSession(s); err != nil {
+		return fmt.Errorf("failed to focus %s: %w", describeSession(s), err)
+	}
+	fmt.Printf("Focused %s\n", describeSession(s))
+	return nil
+}
+
+// runAck closes the notifications of the picked session still on screen
+// and cancels its pending escalation. Without arguments right after
+// focus-pending, it acknowledges the session focus-pending brought up.
+func runAck(args []string, project string) error {
+	v, err := loadSessionView()
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 && project == "" {
+		if last := v.cursor(time.Now()); last != "" {
+			args = []string{last}
+		}
+	}
+	s, err := v.pick(args, project)
+	if err != nil {
+		return err
+	}
+	if err := v.acknowledge(s); err != nil {
+		return err
+	}
+	closed, err := dismissSession(s.ID)
+	if err != nil {
+		return fmt.Errorf("failed to close the notifications of %s: %w", describeSession(s), err)
+	}
+	fmt.Printf("Acknowledged %s", describeSession(s))
+	if closed > 0 {
+		fmt.Printf(", closed %d notification(s)", closed)
+	}
+	fmt.Println()
+	return nil
+}
+
+// sessionView is what the focus commands know about running sessions
+type sessionView struct {
+	dir      string
+	active   []sessions.Session
+	notified map[string]time.Time // Last notification per session ID
+	acks     *sessions.Marks
+}
+
+// loadSessionView reads the running sessions and their last notifications
+func loadSessionView() (*sessionView, error) {
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	active, err := sessions.NewRegistry(dir).Active(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	notified, err := sessions.NewNotified(dir).Load()
+	if err != nil {
+		return nil, err
+	}
+	return &sessionView{dir: dir, active: active, notified: notified, acks: sessions.NewAcks(dir)}, nil
+}
+
+// pick returns the session named by args (ID, label, or the ID's first 8
+// or more characters), else the one of project that needs attention most,
+// else the one of any project
+func (v *sessionView) pick(args []string, project string) (sessions.Session, error) {
+	if len(v.active) == 0 {
+		return sessions.Session{}, fmt.Errorf("no active sessions")
+	}
+	for _, s := range sessions.ByAttention(v.active, v.notified) {
+		switch {
+		case len(args) > 0:
+			if s.ID == args[0] || sessionname.GenerateSessionLabel(s.ID) == args[0] ||
+				(len(args[0]) >= 8 && strings.HasPrefix(s.ID, args[0])) {
+				return s, nil
+			}
+		case project != "":
+			if strings.EqualFold(s.Project(), project) || s.CWD == project {
+				return s, nil
+			}
+		default:
+			return s, nil
+		}
+	}
+	if len(args) > 0 {
+		return sessions.Session{}, fmt.Errorf("no active session %q (see: claude-notifications sessions)", args[0])
+	}
+	return sessions.Session{}, fmt.Errorf("no active session in project %q", project)
+}
+
+// pending returns the sessions waiting for the user, oldest notification
+// first
+func (v *sessionView) pending() []sessions.Session {
+	acked, _ := v.acks.Load()
+	return sessions.Pending(v.active, v.notified, acked)
+}
+
+// acknowledge records that the user saw the notifications of a session and
+// cancels its pending escalation
+func (v *sessionView) acknowledge(s sessions.Session) error {
+	if err := v.acks.Mark(s.ID, time.Now()); err != nil {
+		return err
+	}
+	return escalation.NewStore(v.dir).Cancel(s.ID)
+}
+
+// cursor returns the session focus-pending focused last, if within
+// pendingCycle of now ("" = start over)
+func (v *sessionView) cursor(now time.Time) string {
+	var c pendingCursor
+	data, err := os.ReadFile(filepath.Join(v.dir, "focus-pending.json"))
+	if err != nil || json.Unmarshal(data, &c) != nil || now.Sub(c.At) > pendingCycle {
+		return ""
+	}
+	return c.Session
+}
+
+// saveCursor remembers the session focus-pending focused
+func (v *sessionView) saveCursor(c pendingCursor) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to serialize focus-pending state: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(v.dir, "focus-pending.json"), data, 0600); err != nil {
+		return fmt.Errorf("failed to write focus-pending state: %w", err)
+	}
+	return nil
+}
+
+// describeSession names a session for messages: "bold 06ddb8f7 (api)"
+func describeSession(s sessions.Session) string {
+	return fmt.Sprintf("%s (%s)", sessionname.GenerateSessionLabel(s.ID), s.Project())
+}
+
+// completeProjects completes the projects of running sessions
+func completeProjects(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	active, _ := sessions.NewRegistry(dir).Active(time.Now())
+	seen := make(map[string]bool)
+	var projects []string
+	for _, s := range active {
+		if p := s.Project(); p != "" && !seen[p] {
+			seen[p] = true
+			projects = append(projects, p)
+		}
+	}
+	return projects, cobra.ShellCompDirectiveNoFileComp
+}
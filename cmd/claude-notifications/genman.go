@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+// newGenManCmd writes a man page per command: gen-man [dir]
+func newGenManCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "gen-man [dir]",
+		Short: "Write man pages for every command",
+		Long: `Write a man page per command, e.g. claude-notifications-history.1, to dir
+(default: man). Install them with: cp man/*.1 ~/.local/share/man/man1/`,
+		Args: usageArgs(cobra.MaximumNArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := "man"
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+			header := &doc.GenManHeader{
+				Title:   "CLAUDE-NOTIFICATIONS",
+				Section: "1",
+				Source:  "claude-notifications " + version,
+				Manual:  "claude-notifications manual",
+			}
+			if err := doc.GenManTree(cmd.Root(), header, dir); err != nil {
+				return err
+			}
+			fmt.Printf("Man pages written to %s\n", dir)
+			return nil
+		},
+	}
+}
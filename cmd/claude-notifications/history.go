@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/history"
+	"github.com/spf13/cobra"
+)
+
+// historyMessageLength caps the message shown per history line, in characters
+const historyMessageLength = 80
+
+// newHistoryCmd lists recorded notification deliveries:
+// history [--project name|path] [--since 2h|2026-03-14] [--event status] [--limit N] [--json]
+func newHistoryCmd() *cobra.Command {
+	var filter history.Filter
+	var since string
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "List delivered notifications, newest last",
+		Example: `  # See what fired in the last two hours
+  claude-notifications history --since 2h`,
+		Args: usageArgs(cobra.NoArgs),
+		Run: func(cmd *cobra.Command, args []string) {
+			runHistory(filter, since, asJSON)
+		},
+	}
+	cmd.Flags().StringVar(&filter.Project, "project", "", "only notifications for this project folder name or path")
+	cmd.Flags().StringVar(&since, "since", "", "only notifications since a duration ago (2h) or a date (2026-03-14)")
+	cmd.Flags().StringVar(&filter.Event, "event", "", "only notifications for this status, e.g. task_complete")
+	cmd.Flags().IntVar(&filter.Limit, "limit", 50, "show at most the newest N entries (0 = all)")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print entries as JSON lines")
+	_ = cmd.RegisterFlagCompletionFunc("event", completeStatuses)
+	return cmd
+}
+
+// runHistory prints the deliveries matching filter, since a duration ago or a date
+func runHistory(filter history.Filter, since string, asJSON bool) {
+	if since != "" {
+		t, err := history.ParseSince(since, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		filter.Since = t
+	}
+
+	cfg, err := config.LoadFromPluginRoot(getPluginRoot())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	store := history.NewStore(dir, cfg.Notifications.History.MaxEntries)
+
+	entries, err := store.Query(filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range entries {
+			_ = enc.Encode(e)
+		}
+		return
+	}
+	if len(entries) == 0 {
+		if !cfg.IsHistoryEnabled() {
+			fmt.Println("Notification history is disabled (history.enabled is false)")
+		} else {
+			fmt.Println("No notifications found")
+		}
+		return
+	}
+	printHistory(os.Stdout, entries)
+}
+
+// completeStatuses completes notification statuses, e.g. task_complete
+func completeStatuses(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	statuses := make([]string, 0, len(config.DefaultConfig().Statuses))
+	for name := range config.DefaultConfig().Statuses {
+		statuses = append(statuses, name)
+	}
+	sort.Strings(statuses)
+	return statuses, cobra.ShellCompDirectiveNoFileComp
+}
+
+// printHistory prints one line per delivery attempt
+func printHistory(w io.Writer, entries []history.Entry) {
+	for _, e := range entries {
+		result := e.Result
+		if e.Error != "" {
+			result += " (" + e.Error + ")"
+		}
+		project := e.Project
+		if project == "" {
+			project = "-"
+		}
+		fmt.Fprintf(w, "%s  %-14s %-10s %-12s %s\n", e.Time.Local().Format("2006-01-02 15:04"), e.Event, e.Backend, project, result)
+		fmt.Fprintf(w, "    %s: %s\n", e.Title, oneLine(e.Message, historyMessageLength))
+	}
+}
+
+// oneLine collapses whitespace and shortens s to limit characters
+func oneLine(s string, limit int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/doctor"
+	"github.com/777genius/claude-notifications/internal/hooks"
+	"github.com/777genius/claude-notifications/internal/setup"
+	"github.com/spf13/cobra"
+)
+
+// newInitCmd walks through first-time setup: init [--yes]
+func newInitCmd() *cobra.Command {
+	var yes bool
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Set up notifications: pick backends, write the config, install hooks",
+		Long: `Set up notifications step by step. Detects the platform, desktop, terminal
+and focus tools, asks which backends to enable, writes the user config file,
+installs the Claude Code hooks unless the plugin already runs them, and sends
+a test notification. --yes takes every default without asking.`,
+		Args: usageArgs(cobra.NoArgs),
+		Run: func(cmd *cobra.Command, args []string) {
+			runInit(setup.NewPrompter(os.Stdin, os.Stdout, yes))
+		},
+	}
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "accept every default without asking")
+	return cmd
+}
+
+// runInit runs the setup wizard
+func runInit(p *setup.Prompter) {
+	env := setup.Detect()
+	fmt.Printf("Platform:    %s\n", env.Describe())
+	fmt.Printf("Terminal:    %s\n", env.Terminal)
+	focus := doctor.CheckFocusTools(env.FocusTools)
+	fmt.Printf("Focus tools: %s\n", focus.Detail)
+	if focus.Fix != "" {
+		fmt.Printf("    %s\n", focus.Fix)
+	}
+	fmt.Println()
+
+	path, err := config.FindUserConfigFile()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if path == "" {
+		dir, err := config.GetUserConfigDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		path = filepath.Join(dir, "config.toml")
+	}
+
+	write := true
+	if _, err := os.Stat(path); err == nil {
+		write = p.Confirm(fmt.Sprintf("%s exists. Replace it (the old one is kept as .bak)?", path), false)
+	}
+	if !write {
+		fmt.Println("Keeping the existing config.")
+	} else {
+		answers := setup.Interview(p, env)
+		if err := setup.WriteConfig(path, answers.Values()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Wrote %s\n", path)
+		for _, err := range config.CheckFile(path) {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	fmt.Println()
+
+	home, _ := os.UserHomeDir()
+	cwd, _ := os.Getwd()
+	if hooksCheck := doctor.CheckHooks(doctor.SettingsPaths(home, cwd)); hooksCheck.Status == doctor.StatusOK {
+		fmt.Printf("Hooks: %s\n", hooksCheck.Detail)
+	} else if p.Confirm("Claude Code does not run the notification hooks yet. Install them in ~/.claude/settings.json?", true) {
+		runInstallHooks(hookSettingsPath(false), false)
+	} else {
+		fmt.Println("Skipped. Install the plugin or run: claude-notifications install-hooks")
+	}
+	fmt.Println()
+
+	if p.Confirm("Send a test notification?", true) {
+		handler, err := hooks.NewHandler(getPluginRoot())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		results, err := handler.SendTest(hooks.TestOptions{CWD: cwd})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if printTestResults(results) {
+			fmt.Printf("Some backends failed; check their settings in %s\n", path)
+		}
+		fmt.Println()
+	}
+	fmt.Println("Done. Run \"claude-notifications doctor\" any time to check the setup.")
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/hookinstall"
+	"github.com/spf13/cobra"
+)
+
+// hookScopeFlags adds the --user/--project flags shared by install-hooks
+// and uninstall-hooks; project reports --project
+func hookScopeFlags(cmd *cobra.Command, project *bool) {
+	user := cmd.Flags().Bool("user", false, "edit ~/.claude/settings.json (default)")
+	cmd.Flags().BoolVar(project, "project", false, "edit .claude/settings.json in the current directory")
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if *user && *project {
+			return usageError{errors.New("use either --user or --project")}
+		}
+		return nil
+	}
+}
+
+// newInstallHooksCmd adds hooks to Claude Code settings:
+// install-hooks [--user|--project] [--tools]
+func newInstallHooksCmd() *cobra.Command {
+	var project, toolsOnly bool
+	cmd := &cobra.Command{
+		Use:   "install-hooks",
+		Short: "Add hooks running this binary to Claude Code settings",
+		Long: `Add hooks running this binary to Claude Code settings, to use instead of the
+plugin. --user edits ~/.claude/settings.json (default), --project edits
+./.claude/settings.json; --tools adds only the notifications.tools,
+notifications.budget and notifications.progress hooks, next to the plugin.`,
+		Args: usageArgs(cobra.NoArgs),
+		Run: func(cmd *cobra.Command, args []string) {
+			runInstallHooks(hookSettingsPath(project), toolsOnly)
+		},
+	}
+	hookScopeFlags(cmd, &project)
+	cmd.Flags().BoolVar(&toolsOnly, "tools", false, "install only the hooks for notifications.tools, notifications.budget and notifications.progress, next to the plugin")
+	return cmd
+}
+
+// newUninstallHooksCmd removes those hooks again: uninstall-hooks [--user|--project]
+func newUninstallHooksCmd() *cobra.Command {
+	var project bool
+	cmd := &cobra.Command{
+		Use:   "uninstall-hooks",
+		Short: "Remove the hooks added by install-hooks",
+		Args:  usageArgs(cobra.NoArgs),
+		Run: func(cmd *cobra.Command, args []string) {
+			runUninstallHooks(hookSettingsPath(project))
+		},
+	}
+	hookScopeFlags(cmd, &project)
+	return cmd
+}
+
+// hookSettingsPath returns the settings file to edit: the user's, or the
+// project's in the current directory
+func hookSettingsPath(project bool) string {
+	scope := hookinstall.ScopeUser
+	if project {
+		scope = hookinstall.ScopeProject
+	}
+	home, err := os.UserHomeDir()
+	if err != nil && scope == hookinstall.ScopeUser {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	cwd, _ := os.Getwd()
+	path, err := hookinstall.SettingsPath(scope, home, cwd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return path
+}
+
+// runInstallHooks adds hooks running this binary to the settings file at
+// path; with toolsOnly, only those for notifications.tools,
+// notifications.budget and notifications.progress
+func runInstallHooks(path string, toolsOnly bool) {
+	// Tools announced by notifications.tools need their own matchers, a
+	// budget and progress are checked after every tool
+	cfg, _ := config.LoadFromPluginRoot(getPluginRoot())
+	tools := cfg.Notifications.Tools
+	toolEvents := hookinstall.ToolEvents(tools.Matcher(), tools.When != "after", tools.When == "after" || tools.When == "both")
+	if cfg.Notifications.Budget.Enabled() || cfg.Notifications.Progress.Enabled {
+		toolEvents = hookinstall.WithAllTools(toolEvents)
+	}
+	if toolsOnly && len(toolEvents) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no tools to install hooks for; set notifications.tools.notify, notifications.budget or notifications.progress first")
+		os.Exit(1)
+	}
+	events := toolEvents
+	if !toolsOnly {
+		events = append(append([]hookinstall.Event{}, hookinstall.Events...), toolEvents...)
+	}
+
+	changed, err := hookinstall.InstallEvents(path, currentBinary(), events)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if !changed {
+		fmt.Printf("Hooks already installed in %s\n", path)
+	} else {
+		var names []string
+		for _, e := range events {
+			name := e.Name
+			if e.Matcher != "" {
+				name += " (" + e.Matcher + ")"
+			}
+			names = append(names, name)
+		}
+		fmt.Printf("Installed hooks for %s in %s\n", strings.Join(names, ", "), path)
+		fmt.Println("Restart Claude Code to load them.")
+	}
+
+	home, _ := os.UserHomeDir()
+	if toolsOnly {
+		return
+	}
+	if userPath, err := hookinstall.SettingsPath(hookinstall.ScopeUser, home, ""); err == nil && hookinstall.PluginEnabled(userPath) {
+		fmt.Println("Warning: the claude-notifications-go plugin is enabled too, so every notification would fire twice.")
+		fmt.Println("Disable the plugin in Claude Code (/plugin) or run: claude-notifications uninstall-hooks")
+	}
+}
+
+// runUninstallHooks removes hooks running this binary from the settings file at path
+func runUninstallHooks(path string) {
+	changed, err := hookinstall.Uninstall(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if changed {
+		fmt.Printf("Removed hooks from %s\n", path)
+	} else {
+		fmt.Printf("No claude-notifications hooks in %s\n", path)
+	}
+}
+
+// currentBinary returns the absolute path of this binary, with symlinks resolved
+func currentBinary() string {
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: cannot locate the claude-notifications binary: %v\n", err)
+		os.Exit(1)
+	}
+	return exe
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/notifier"
+	"github.com/777genius/claude-notifications/internal/platform"
+	"github.com/777genius/claude-notifications/internal/remote"
+	"github.com/spf13/cobra"
+)
+
+// newListenCmd shows forwarded notifications: listen [--background] [host:port]
+func newListenCmd() *cobra.Command {
+	var background bool
+	cmd := &cobra.Command{
+		Use:   "listen [host:port | unix:path]",
+		Short: "Show notifications forwarded from remote (SSH) sessions and containers",
+		Long: `Show notifications forwarded from remote (SSH) sessions and containers as
+desktop notifications until interrupted. The default address comes from
+remote.address (127.0.0.1:9876); unix:<path> listens on a Unix socket,
+e.g. one mounted into dev containers.`,
+		Example: `  # Run locally, then ssh to the remote host with a reverse tunnel
+  claude-notifications listen
+  ssh -R 9876:127.0.0.1:9876 user@remote-host
+
+  # Listen on a socket to mount into containers
+  claude-notifications listen unix:$HOME/.claude/claude-notifications-go/listen.sock`,
+		Args: usageArgs(cobra.MaximumNArgs(1)),
+		Run: func(cmd *cobra.Command, args []string) {
+			address := ""
+			if len(args) > 0 {
+				address = args[0]
+			}
+			runListener(address, background)
+		},
+	}
+	cmd.Flags().BoolVar(&background, "background", false, "detach from the console window (Windows logon task)")
+	return cmd
+}
+
+// runListener shows notifications forwarded from remote sessions as local
+// desktop notifications until interrupted. Config changes are applied
+// without a restart, except for the listen address and token.
+func runListener(address string, background bool) {
+	if background {
+		detachConsole()
+	}
+
+	pluginRoot := getPluginRoot()
+	if _, err := logging.InitLogger(logDir(pluginRoot)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logging.Close()
+
+	var (
+		mu sync.Mutex
+		n  *notifier.Notifier
+	)
+	watcher, warnings := config.NewWatcher(pluginRoot, func(r config.Reload) {
+		logReload(r)
+		if r.Err != nil || len(r.Changes) == 0 {
+			return
+		}
+		logging.Configure(r.Config.Logging.Options())
+		mu.Lock()
+		n.Close()
+		n = notifier.New(listenerConfig(r.Config))
+		mu.Unlock()
+	})
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", w)
+		logging.Warn("%v", w)
+	}
+	cfg := watcher.Config()
+	logging.Configure(cfg.Logging.Options())
+	if address == "" {
+		address = cfg.Notifications.Remote.Address
+	}
+	token, err := config.ResolveSecretRef(cfg.Notifications.Remote.Token, platform.ResolveSecret)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: remote.token: %v\n", err)
+		os.Exit(1)
+	}
+	// Any local user can connect to a TCP port, even on 127.0.0.1
+	if network, _ := remote.Network(address); network == "tcp" && token == "" {
+		fmt.Fprintf(os.Stderr, "Error: remote.token is required to listen on %s, since any user on this machine can connect to it; set remote.token or listen on unix:<path>\n", address)
+		logging.Error("Refusing to listen on %s without remote.token", address)
+		os.Exit(1)
+	}
+
+	n = notifier.New(listenerConfig(cfg))
+	defer func() {
+		mu.Lock()
+		n.Close()
+		mu.Unlock()
+	}()
+
+	server, err := remote.Listen(address, token, func(req *remote.Request) error {
+		logging.Debug("Forwarded notification: status=%s session=%s source=%s", req.Status, req.SessionID, req.Source)
+		mu.Lock()
+		defer mu.Unlock()
+		// The remote cwd does not exist locally, so there is no window to focus
+		return n.SendDesktopWithOptions(analyzer.Status(req.Status), req.Message, req.SessionID, "", forwardedOptions(watcher.Config(), req))
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go watcher.Run(done)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		for sig := range sigCh {
+			if sig == syscall.SIGHUP {
+				watcher.Reload()
+				continue
+			}
+			_ = server.Close()
+			return
+		}
+	}()
+
+	fmt.Printf("Listening for forwarded notifications on %s\n", server.Addr())
+	if err := server.Serve(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// listenerConfig returns cfg for showing forwarded notifications: the
+// listener never forwards again, even when it runs inside SSH itself
+func listenerConfig(cfg *config.Config) *config.Config {
+	c := *cfg
+	c.Notifications.Remote.Enabled = false
+	return &c
+}
+
+// forwardedOptions names the container or machine a forwarded
+// notification comes from in its title, e.g. "✅ Completed · api-devcontainer"
+func forwardedOptions(cfg *config.Config, req *remote.Request) notifier.Options {
+	if req.Source == "" {
+		return notifier.Options{}
+	}
+	info, ok := cfg.GetStatusInfo(req.Status)
+	if !ok {
+		return notifier.Options{}
+	}
+	return notifier.Options{Title: info.Title + " \u00B7 " + req.Source}
+}
+
+// logReload reports the outcome of a config reload, one line per changed key
+func logReload(r config.Reload) {
+	for _, w := range r.Warnings {
+		logging.Warn("Config: %v", w)
+	}
+	if r.Err != nil {
+		fmt.Fprintf(os.Stderr, "Config reload failed, keeping the current config: %v\n", r.Err)
+		logging.Error("Config reload failed, keeping the current config: %v", r.Err)
+		return
+	}
+	for _, c := range r.Changes {
+		fmt.Printf("Config changed: %s\n", c)
+		logging.Info("Config changed: %s", c)
+		if c.Key == "notifications.remote.address" || c.Key == "notifications.remote.token" {
+			fmt.Printf("  (restart listen to apply %s)\n", c.Key)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/spf13/cobra"
+)
+
+// newLogsCmd prints the log file: logs [-n N] [--follow] [--path]
+func newLogsCmd() *cobra.Command {
+	var lines int
+	var follow, showPath bool
+	cmd := &cobra.Command{
+		Use:   "logs",
+		Short: "Print the end of the log file",
+		Long:  "Print the end of the log file shared by hooks, the daemon and the listener.",
+		Args:  usageArgs(cobra.NoArgs),
+		Run: func(cmd *cobra.Command, args []string) {
+			runLogs(lines, follow, showPath)
+		},
+	}
+	cmd.Flags().IntVarP(&lines, "lines", "n", 50, "print the last N lines")
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "keep printing lines as they are written, until Ctrl+C")
+	cmd.Flags().BoolVar(&showPath, "path", false, "print the log file path and exit")
+	return cmd
+}
+
+// runLogs prints the last lines of the log file, and with follow the
+// lines written after them
+func runLogs(lines int, follow, showPath bool) {
+	path := filepath.Join(logDir(getPluginRoot()), logging.FileName)
+	if showPath {
+		fmt.Println(path)
+		return
+	}
+
+	tail, size, err := logging.Tail(path, lines)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to read log file: %v\n", err)
+		os.Exit(1)
+	}
+	for _, line := range tail {
+		fmt.Println(line)
+	}
+	if !follow {
+		if size == 0 {
+			fmt.Fprintf(os.Stderr, "No log entries yet in %s\n", path)
+		}
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := logging.Follow(ctx, path, size, os.Stdout, 500*time.Millisecond); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to follow log file: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -1,14 +1,20 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/daemon"
 	"github.com/777genius/claude-notifications/internal/errorhandler"
 	"github.com/777genius/claude-notifications/internal/hooks"
 	"github.com/777genius/claude-notifications/internal/logging"
 	"github.com/777genius/claude-notifications/internal/notifier"
+	"github.com/777genius/claude-notifications/internal/platform"
+	"github.com/spf13/cobra"
 )
 
 const version = "1.27.0"
@@ -23,44 +29,121 @@ func main() {
 	// Add global panic recovery
 	defer errorhandler.HandlePanic()
 
-	if len(os.Args) < 2 {
-		printUsage()
-		os.Exit(1)
+	os.Exit(execute(os.Args[1:]))
+}
+
+// hookEvents are the hook names handle-hook completes
+var hookEvents = []string{
+	"PreToolUse", "PostToolUse", "Notification", "Stop", "SubagentStop",
+	"SessionStart", "SessionEnd", "UserPromptSubmit",
+}
+
+// newHandleHookCmd handles a hook event read from stdin:
+// handle-hook [--dry-run] [--trace] [--sync] <HookName>
+func newHandleHookCmd() *cobra.Command {
+	var dryRun, trace, sync bool
+	cmd := &cobra.Command{
+		Use:   "handle-hook <HookName>",
+		Short: "Handle a Claude Code hook event",
+		Long: `Handle a Claude Code hook event, reading its JSON payload from stdin.
+
+HookName: PreToolUse, PostToolUse, Stop, SubagentStop, Notification,
+SessionStart, SessionEnd, UserPromptSubmit.
+
+On Linux the hook hands the event to the daemon (starting it when needed)
+and returns at once; the daemon delivers the notifications. --sync
+handles it in this process instead, like --trace and --dry-run do.
+
+--trace prints every decision to stderr; --dry-run also sends nothing and
+leaves cooldowns and sessions untouched.`,
+		Example: `  # Handle PreToolUse hook
+  echo '{"session_id":"test","tool_name":"ExitPlanMode"}' | claude-notifications handle-hook PreToolUse
+
+  # See why a Stop hook did or didn't notify, without sending anything
+  echo '{"session_id":"test","transcript_path":"/path/to/transcript.jsonl"}' | claude-notifications handle-hook --dry-run Stop`,
+		Args:      usageArgs(cobra.ExactArgs(1)),
+		ValidArgs: hookEvents,
+		Run: func(cmd *cobra.Command, args []string) {
+			handleHook(args[0], dryRun, trace || dryRun, sync)
+		},
 	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print what would be sent where, without sending or writing state")
+	cmd.Flags().BoolVar(&trace, "trace", false, "print every decision taken on the hook to stderr")
+	cmd.Flags().BoolVar(&sync, "sync", false, "handle the hook in this process instead of handing it to the daemon")
+	return cmd
+}
 
-	command := os.Args[1]
+// newFocusWindowCmd is run by click-to-focus: focus-window <bundleID> <cwd>
+func newFocusWindowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "focus-window <bundleID> <cwd>",
+		Short: "Focus the window of a project (internal, used by click-to-focus)",
+		Long: `Focus the window of a project in the app identified by bundleID
+(internal, used by click-to-focus). On Windows, pass a terminal name
+(e.g. vscode) instead of a bundle ID.`,
+		Hidden: true,
+		Args:   usageArgs(cobra.ExactArgs(2)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := focusWindow(args[0], args[1]); err != nil {
+				return fmt.Errorf("focus-window: %w", err)
+			}
+			return nil
+		},
+	}
+}
 
-	switch command {
-	case "handle-hook":
-		if len(os.Args) < 3 {
-			fmt.Fprintf(os.Stderr, "Error: hook event name required\n")
-			printUsage()
-			os.Exit(1)
-		}
-		handleHook(os.Args[2])
-	case "focus-window":
-		if len(os.Args) < 4 {
-			fmt.Fprintf(os.Stderr, "Error: focus-window requires bundleID and cwd arguments\n")
-			os.Exit(1)
-		}
-		if err := notifier.FocusAppWindow(os.Args[2], os.Args[3]); err != nil {
-			fmt.Fprintf(os.Stderr, "focus-window: %v\n", err)
-			os.Exit(1)
-		}
-	case "daemon", "--daemon":
-		runDaemon()
-	case "version", "--version", "-v":
-		fmt.Printf("claude-notifications v%s\n", version)
-	case "help", "--help", "-h":
-		printUsage()
-	default:
-		fmt.Fprintf(os.Stderr, "Error: unknown command: %s\n", command)
-		printUsage()
-		os.Exit(1)
+// newEscalateCmd is started by the hook: escalate <session> <id>
+func newEscalateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:    "escalate <session> <id>",
+		Short:  "Send a notification to the escalation backends when it is due (internal, started by the hook)",
+		Hidden: true,
+		Args:   usageArgs(cobra.ExactArgs(2)),
+		Run: func(cmd *cobra.Command, args []string) {
+			runWorker("Escalation", func(h *hooks.Handler) error { return h.Escalate(args[0], args[1]) })
+		},
+	}
+}
+
+// newWatchCmd is started by the UserPromptSubmit hook: watch <session> <id>
+func newWatchCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:    "watch <session> <id>",
+		Short:  "Send heartbeats and stall alerts while a session works on its prompt (internal, started by the hook)",
+		Hidden: true,
+		Args:   usageArgs(cobra.ExactArgs(2)),
+		Run: func(cmd *cobra.Command, args []string) {
+			runWorker("Watcher", func(h *hooks.Handler) error { return h.WatchPrompt(args[0], args[1]) })
+		},
 	}
 }
 
-func handleHook(hookEvent string) {
+// focusWindow raises the window for cwd in the app identified by bundleID.
+// On macOS, when the Accessibility-based raise fails, the daemon focus chain
+// (AppleScript, then open -b) at least brings the app to the front.
+// On Windows, bundleID is a terminal name and the Win32 focus chain is used directly.
+func focusWindow(bundleID, cwd string) error {
+	// Terminals declared in the config, for the Windows and macOS fallbacks
+	cfg, _ := config.LoadForProject(getPluginRoot(), cwd)
+	daemon.SetTerminals(cfg.Notifications.Desktop.Terminals)
+
+	if platform.IsWindows() {
+		return daemon.TryFocus(bundleID, filepath.Base(cwd))
+	}
+
+	err := notifier.FocusAppWindow(bundleID, cwd)
+	if err == nil || !platform.IsMacOS() {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "focus-window: %v, falling back to focus chain\n", err)
+	return daemon.TryFocus(bundleID, filepath.Base(cwd))
+}
+
+// handleHook handles a hook event read from stdin, or hands it to the
+// daemon unless sync or trace is set. With trace, the handler's decisions
+// go to stderr; Claude Code reads stdout.
+func handleHook(hookEvent string, dryRun, trace, sync bool) {
 	// Add panic recovery for this function
 	defer errorhandler.HandlePanic()
 
@@ -68,12 +151,22 @@ func handleHook(hookEvent string) {
 	pluginRoot := getPluginRoot()
 
 	// Initialize logger
-	if _, err := logging.InitLogger(pluginRoot); err != nil {
+	if _, err := logging.InitLogger(logDir(pluginRoot)); err != nil {
 		errorhandler.HandleCriticalError(err, "Failed to initialize logger")
 		os.Exit(1)
 	}
 	defer logging.Close()
 
+	// The payload goes to the daemon, or to the handler when it can't take it
+	payload, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		errorhandler.HandleCriticalError(err, "Failed to read hook payload")
+		os.Exit(1)
+	}
+	if !sync && !trace && handOffHook(pluginRoot, hookEvent, payload) {
+		return
+	}
+
 	// Create handler
 	handler, err := hooks.NewHandler(pluginRoot)
 	if err != nil {
@@ -81,13 +174,55 @@ func handleHook(hookEvent string) {
 		os.Exit(1)
 	}
 
+	if trace {
+		handler.SetTrace(os.Stderr)
+	}
+	handler.SetDryRun(dryRun)
+
 	// Handle hook
-	if err := handler.HandleHook(hookEvent, os.Stdin); err != nil {
+	if err := handler.HandleHook(hookEvent, bytes.NewReader(payload)); err != nil {
 		errorhandler.HandleCriticalError(err, "Failed to handle hook")
 		os.Exit(1)
 	}
 }
 
+// runWorker runs a worker started detached by a hook, such as an
+// escalation waiting for its notification to be acknowledged, with the
+// hook handler and the shared log
+func runWorker(name string, run func(h *hooks.Handler) error) {
+	defer errorhandler.HandlePanic()
+
+	pluginRoot := getPluginRoot()
+	if _, err := logging.InitLogger(logDir(pluginRoot)); err != nil {
+		os.Exit(1)
+	}
+	defer logging.Close()
+
+	handler, err := hooks.NewHandler(pluginRoot)
+	if err != nil {
+		logging.Error("%s: %v", name, err)
+		os.Exit(1)
+	}
+	if err := run(handler); err != nil {
+		logging.Error("%s: %v", name, err)
+		os.Exit(1)
+	}
+}
+
+// logDir returns the directory of the log file shared by all commands:
+// the stable config directory, which survives plugin updates, or
+// pluginRoot when it cannot be created
+func logDir(pluginRoot string) string {
+	dir, err := config.GetStableConfigDir()
+	if err == nil {
+		err = os.MkdirAll(dir, 0755)
+	}
+	if err != nil {
+		return pluginRoot
+	}
+	return dir
+}
+
 func getPluginRoot() string {
 	// Try CLAUDE_PLUGIN_ROOT environment variable first
 	if root := os.Getenv("CLAUDE_PLUGIN_ROOT"); root != "" {
@@ -113,39 +248,3 @@ func getPluginRoot() string {
 	}
 	return cwd
 }
-
-func printUsage() {
-	fmt.Println("claude-notifications - Smart notifications for Claude Code")
-	fmt.Println()
-	fmt.Printf("Version: %s\n", version)
-	fmt.Println()
-	fmt.Println("Usage:")
-	fmt.Println("  claude-notifications handle-hook <HookName>")
-	fmt.Println("  claude-notifications daemon")
-	fmt.Println("  claude-notifications version")
-	fmt.Println("  claude-notifications help")
-	fmt.Println()
-	fmt.Println("Commands:")
-	fmt.Println("  handle-hook <HookName>  Handle a Claude Code hook event")
-	fmt.Println("                          HookName: PreToolUse, Stop, SubagentStop, Notification")
-	fmt.Println("  daemon                  Run the notification daemon (Linux only)")
-	fmt.Println("                          For click-to-focus support on desktop notifications")
-	fmt.Println("  focus-window <bundleID> <cwd>")
-	fmt.Println("                          Focus specific VS Code window (internal, used by click-to-focus)")
-	fmt.Println("  version                 Show version information")
-	fmt.Println("  help                    Show this help message")
-	fmt.Println()
-	fmt.Println("Examples:")
-	fmt.Println("  # Handle PreToolUse hook (reads JSON from stdin)")
-	fmt.Println("  echo '{\"session_id\":\"test\",\"tool_name\":\"ExitPlanMode\"}' | claude-notifications handle-hook PreToolUse")
-	fmt.Println()
-	fmt.Println("  # Handle Stop hook")
-	fmt.Println("  echo '{\"session_id\":\"test\",\"transcript_path\":\"/path/to/transcript.jsonl\"}' | claude-notifications handle-hook Stop")
-	fmt.Println()
-	fmt.Println("  # Run notification daemon (Linux only, started automatically)")
-	fmt.Println("  claude-notifications daemon")
-	fmt.Println()
-	fmt.Println("Environment Variables:")
-	fmt.Println("  CLAUDE_PLUGIN_ROOT  Plugin root directory (auto-detected if not set)")
-	fmt.Println()
-}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/platform"
+	"github.com/777genius/claude-notifications/internal/sessions"
+	"github.com/spf13/cobra"
+)
+
+// newPromptSegmentCmd prints a shell prompt segment when sessions of the
+// current repository wait for the user: prompt-segment [--all] [--symbol 🔔]
+func newPromptSegmentCmd() *cobra.Command {
+	var (
+		all    bool
+		symbol string
+	)
+	cmd := &cobra.Command{
+		Use:   "prompt-segment",
+		Short: "Print a shell prompt segment when sessions in the current repository wait for you",
+		Long: `Print e.g. "🔔 2" when Claude sessions working in the current git
+repository (or directory, outside one) finished or asked something and were
+not acknowledged yet, and nothing otherwise. It only reads the session files
+the hooks keep up to date, without loading the config or asking the daemon,
+so it is fast enough to run on every prompt.`,
+		Example: `  # starship.toml
+  [custom.claude]
+  command = "claude-notifications prompt-segment"
+  when = true
+  format = "[$output]($style) "
+
+  # ~/.zshrc
+  setopt PROMPT_SUBST
+  PROMPT='$(claude-notifications prompt-segment)'$PROMPT`,
+		Args: usageArgs(cobra.NoArgs),
+		Run: func(cmd *cobra.Command, args []string) {
+			// A prompt shows nothing rather than an error on every command
+			if n := promptPending(all); n > 0 {
+				fmt.Printf("%s %d\n", symbol, n)
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "count waiting sessions of every repository")
+	cmd.Flags().StringVar(&symbol, "symbol", "🔔", "text before the number of waiting sessions")
+	return cmd
+}
+
+// promptPending returns the number of sessions waiting for the user in the
+// repository of the working directory, or in any with all
+func promptPending(all bool) int {
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		return 0
+	}
+	active, err := sessions.NewRegistry(dir).Active(time.Now())
+	if err != nil || len(active) == 0 {
+		return 0
+	}
+	if !all {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return 0
+		}
+		root := platform.GetGitContext(cwd).Root
+		if root == "" {
+			root = cwd
+		}
+		if active = sessions.Within(active, root); len(active) == 0 {
+			return 0
+		}
+	}
+	notified, _ := sessions.NewNotified(dir).Load()
+	acked, _ := sessions.NewAcks(dir).Load()
+	return len(sessions.Pending(active, notified, acked))
+}
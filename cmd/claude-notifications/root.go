@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// rootExample is shown by "claude-notifications help"; each command has its own
+const rootExample = `  # Find out why notifications don't appear
+  claude-notifications doctor
+
+  # Complete commands and flags in bash
+  source <(claude-notifications completion bash)`
+
+// usageError is a command line the commands cannot run, e.g. an unknown flag
+type usageError struct{ error }
+
+func (e usageError) Unwrap() error { return e.error }
+
+// newRootCmd builds the command tree
+func newRootCmd() *cobra.Command {
+	root := &cobra.Command{
+		Use:     "claude-notifications",
+		Short:   "Smart notifications for Claude Code",
+		Example: rootExample,
+		Version: version,
+		// Errors are printed once by execute; a failed command is not a usage problem
+		SilenceErrors:     true,
+		SilenceUsage:      true,
+		DisableAutoGenTag: true,
+	}
+	root.SetVersionTemplate("claude-notifications v{{.Version}}\n")
+	root.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageError{err}
+	})
+
+	root.AddCommand(
+		newHandleHookCmd(),
+		newDaemonCmd(),
+		newServiceCmd(),
+		newDNDCmd(),
+		newHistoryCmd(),
+		newSessionsCmd(),
+		newFocusCmd(),
+		newFocusLastCmd(),
+		newFocusPendingCmd(),
+		newAckCmd(),
+		newLogsCmd(),
+		newStatusCmd(),
+		newStatusbarCmd(),
+		newTmuxStatusCmd(),
+		newPromptSegmentCmd(),
+		newInitCmd(),
+		newDoctorCmd(),
+		newTestCmd(),
+		newConfigCmd(),
+		newInstallHooksCmd(),
+		newUninstallHooksCmd(),
+		newListenCmd(),
+		newDecryptCmd(),
+		newFocusWindowCmd(),
+		newEscalateCmd(),
+		newWatchCmd(),
+		newTrayCmd(),
+		newGenManCmd(),
+		&cobra.Command{
+			Use:   "version",
+			Short: "Show version information",
+			Args:  cobra.NoArgs,
+			Run: func(cmd *cobra.Command, args []string) {
+				fmt.Printf("claude-notifications v%s\n", version)
+			},
+		},
+	)
+	return root
+}
+
+// execute runs the command line args and returns the exit status: 1 when
+// a command fails, 2 for an invalid command line
+func execute(args []string) int {
+	// The hook starts the daemon with the flag it used before subcommands
+	if len(args) > 0 && args[0] == "--daemon" {
+		args = append([]string{"daemon"}, args[1:]...)
+	}
+
+	root := newRootCmd()
+	root.SetArgs(args)
+	cmd, err := root.ExecuteC()
+	if err == nil {
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	if errors.As(err, &usageError{}) {
+		fmt.Fprintf(os.Stderr, "Run '%s --help' for usage\n", cmd.CommandPath())
+		return 2
+	}
+	return 1
+}
+
+// usageArgs wraps a positional argument check so a wrong number of
+// arguments exits like any other invalid command line
+func usageArgs(check cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := check(cmd, args); err != nil {
+			return usageError{err}
+		}
+		return nil
+	}
+}
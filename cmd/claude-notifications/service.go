@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/777genius/claude-notifications/internal/notifier"
+	"github.com/777genius/claude-notifications/internal/service"
+	"github.com/spf13/cobra"
+)
+
+// newServiceCmd manages the daemon (Linux) or the remote listener (macOS,
+// Windows) as a per-user service:
+// service install [--socket] | uninstall | start | stop | restart | status
+func newServiceCmd() *cobra.Command {
+	var socket bool
+	action := func(name string) func(cmd *cobra.Command, args []string) {
+		return func(cmd *cobra.Command, args []string) {
+			runService(name, socket)
+		}
+	}
+	cmd := &cobra.Command{
+		Use:   "service",
+		Short: "Run the daemon or listener as a per-user service",
+		Args:  usageArgs(cobra.NoArgs),
+		Run:   action("status"),
+	}
+	install := &cobra.Command{
+		Use:   "install",
+		Short: "Install and start the service",
+		Long: `Run the daemon as a systemd user service, started on login; on macOS and
+Windows, run listen as a launchd agent or logon task.`,
+		Args: usageArgs(cobra.NoArgs),
+		Run:  action("install"),
+	}
+	install.Flags().BoolVar(&socket, "socket", false, "start the daemon on the first notification (systemd socket activation)")
+	cmd.AddCommand(
+		install,
+		&cobra.Command{Use: "uninstall", Short: "Stop and remove the service", Args: usageArgs(cobra.NoArgs), Run: action("uninstall")},
+		&cobra.Command{Use: "start", Short: "Start the service", Args: usageArgs(cobra.NoArgs), Run: action("start")},
+		&cobra.Command{Use: "stop", Short: "Stop the service", Args: usageArgs(cobra.NoArgs), Run: action("stop")},
+		&cobra.Command{Use: "restart", Short: "Restart the service", Args: usageArgs(cobra.NoArgs), Run: action("restart")},
+		&cobra.Command{Use: "status", Short: "Show whether the service is installed, enabled and running (default)", Args: usageArgs(cobra.NoArgs), Run: action("status")},
+	)
+	return cmd
+}
+
+// runService runs one service action and exits 1 if it fails
+func runService(action string, socket bool) {
+	if err := serviceAction(action, socket); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if errors.Is(err, service.ErrUnsupported) {
+			fmt.Fprintln(os.Stderr, "Hooks start the daemon on demand instead.")
+		}
+		os.Exit(1)
+	}
+}
+
+// serviceAction runs one service subcommand
+func serviceAction(action string, socket bool) error {
+	switch action {
+	case "install":
+		opts := service.Options{
+			Binary:     currentBinary(),
+			PluginRoot: os.Getenv("CLAUDE_PLUGIN_ROOT"),
+			Socket:     socket,
+		}
+		// A daemon started on demand holds the socket the service needs
+		_ = notifier.StopDaemon()
+		if err := service.Install(opts); err != nil {
+			return err
+		}
+		st, err := service.GetStatus()
+		if err != nil {
+			return err
+		}
+		fmt.Println("Service installed and started")
+		st.Print(os.Stdout)
+		return nil
+	case "uninstall":
+		if err := service.Uninstall(); err != nil {
+			return err
+		}
+		fmt.Println("Service uninstalled")
+		return nil
+	case "start":
+		if err := service.Start(); err != nil {
+			return err
+		}
+		fmt.Println("Service started")
+		return nil
+	case "stop":
+		if err := service.Stop(); err != nil {
+			return err
+		}
+		fmt.Println("Service stopped")
+		return nil
+	case "restart":
+		if err := service.Restart(); err != nil {
+			return err
+		}
+		fmt.Println("Service restarted")
+		return nil
+	case "status":
+		st, err := service.GetStatus()
+		if err != nil {
+			return err
+		}
+		st.Print(os.Stdout)
+		return nil
+	default:
+		return fmt.Errorf("unknown service action: %s (use install, uninstall, start, stop, restart or status)", action)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/sessionname"
+	"github.com/777genius/claude-notifications/internal/sessions"
+	"github.com/spf13/cobra"
+)
+
+// newSessionsCmd lists running sessions: sessions
+func newSessionsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "sessions",
+		Short: "List running Claude Code sessions with project and terminal",
+		Args:  usageArgs(cobra.NoArgs),
+		Run: func(cmd *cobra.Command, args []string) {
+			runSessions()
+		},
+	}
+}
+
+// completeSessions completes the IDs of running sessions, described by project
+func completeSessions(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	dir, err := config.GetStableConfigDir()
+	if err != nil || len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	active, _ := sessions.NewRegistry(dir).Active(time.Now())
+	ids := make([]string, 0, len(active))
+	for _, s := range active {
+		ids = append(ids, s.ID+"\t"+s.Project())
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
+
+// runSessions lists running Claude Code sessions with their project and terminal
+func runSessions() {
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	now := time.Now()
+	active, err := sessions.NewRegistry(dir).Active(now)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(active) == 0 {
+		fmt.Println("No active sessions")
+		return
+	}
+	printSessions(os.Stdout, active, now)
+}
+
+// printSessions prints one line per session
+func printSessions(w io.Writer, active []sessions.Session, now time.Time) {
+	fmt.Fprintf(w, "%-22s %-20s %-16s %-10s %s\n", "SESSION", "PROJECT", "TERMINAL", "RUNNING", "LAST ACTIVITY")
+	for _, s := range active {
+		terminal := s.Terminal
+		if terminal == "" {
+			terminal = "-"
+		}
+		fmt.Fprintf(w, "%-22s %-20s %-16s %-10s %s ago\n",
+			sessionname.GenerateSessionLabel(s.ID),
+			s.Project(),
+			terminal,
+			sessions.FormatDuration(now.Sub(s.StartedAt)),
+			sessions.FormatDuration(now.Sub(s.LastActivity)))
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/status"
+	"github.com/spf13/cobra"
+)
+
+// newStatusCmd reports the state of the notification setup: status [--json]
+func newStatusCmd() *cobra.Command {
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the daemon, enabled backends, last notification and hook installation",
+		Long: `Show the daemon, enabled backends, last notification, focus tools, queues
+and hook installation; exits 1 on problems.`,
+		Args: usageArgs(cobra.NoArgs),
+		Run: func(cmd *cobra.Command, args []string) {
+			runStatus(asJSON)
+		},
+	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the report as JSON, for scripts and status bars")
+	return cmd
+}
+
+// runStatus reports the state of the notification setup and exits 1 when
+// something needs attention
+func runStatus(asJSON bool) {
+	home, _ := os.UserHomeDir()
+	cwd, _ := os.Getwd()
+	now := time.Now()
+	report := status.Gather(status.Options{
+		Version:    version,
+		PluginRoot: getPluginRoot(),
+		Home:       home,
+		CWD:        cwd,
+	}, now)
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		status.Print(os.Stdout, report, now)
+	}
+	if !report.Healthy {
+		os.Exit(1)
+	}
+}
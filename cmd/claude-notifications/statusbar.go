@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/dnd"
+	"github.com/777genius/claude-notifications/internal/statusbar"
+	"github.com/spf13/cobra"
+)
+
+// newStatusbarCmd prints the sessions for a status bar:
+// statusbar [--format text|waybar|polybar] [--follow [--interval 1s]]
+func newStatusbarCmd() *cobra.Command {
+	var (
+		format   string
+		follow   bool
+		interval time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "statusbar",
+		Short: "Print the sessions waiting for you and working, for Waybar, Polybar and other status bars",
+		Long: `Print one line for a status bar: the number of sessions waiting for you,
+a spinner with the number of sessions working, and do-not-disturb. With
+--follow, print a new line whenever it changes, for bars that read a
+script's output continuously.`,
+		Example: `  claude-notifications statusbar --format waybar --follow
+  claude-notifications statusbar --format polybar --follow`,
+		Args: usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runStatusbar(format, follow, interval)
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", "text", "output format: "+strings.Join(statusbar.Formats, ", "))
+	cmd.Flags().BoolVar(&follow, "follow", false, "keep running and print a line whenever the state changes")
+	cmd.Flags().DurationVar(&interval, "interval", time.Second, "how often --follow checks the sessions")
+	_ = cmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(statusbar.Formats, cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
+
+// newTmuxStatusCmd prints a segment for the tmux status line: tmux-status
+func newTmuxStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "tmux-status",
+		Short: "Print a tmux status-line segment, e.g. \"3 running / 1 waiting\"",
+		Example: `  # In ~/.tmux.conf
+  set -g status-right '#(claude-notifications tmux-status) %H:%M'`,
+		Args: usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runStatusbar("tmux", false, time.Second)
+		},
+	}
+}
+
+// runStatusbar prints the status bar line once, or with follow each time
+// it changes until the bar stops reading
+func runStatusbar(format string, follow bool, interval time.Duration) error {
+	if interval <= 0 {
+		return usageError{fmt.Errorf("invalid interval %v", interval)}
+	}
+	if !slices.Contains(statusbar.Formats, format) {
+		return usageError{fmt.Errorf("invalid format %q (use %s)", format, strings.Join(statusbar.Formats, ", "))}
+	}
+	cfg, err := config.LoadFromPluginRoot(getPluginRoot())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		return err
+	}
+	mgr := dnd.NewManager(dir, cfg.Notifications.DND)
+	command, err := os.Executable()
+	if err != nil {
+		command = "claude-notifications"
+	}
+
+	render := func(now time.Time, frame int) (string, error) {
+		v, err := loadSessionView()
+		if err != nil {
+			return "", err
+		}
+		return statusbar.Render(format, statusbarState(v, mgr, now), now, frame, command)
+	}
+	if !follow {
+		// Bars that run the command on an interval see the spinner turn
+		now := time.Now()
+		line, err := render(now, int(now.Unix()))
+		if err != nil {
+			return err
+		}
+		fmt.Println(line)
+		return nil
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last := ""
+	for frame := 0; ; frame++ {
+		line, err := render(time.Now(), frame)
+		if err != nil {
+			return err
+		}
+		if line != last || frame == 0 {
+			if _, err := fmt.Println(line); err != nil {
+				return nil // The bar closed the pipe
+			}
+			last = line
+		}
+		<-ticker.C
+	}
+}
+
+// statusbarState describes the running sessions for the bar
+func statusbarState(v *sessionView, mgr *dnd.Manager, now time.Time) statusbar.State {
+	pending := make(map[string]bool)
+	for _, s := range v.pending() {
+		pending[s.ID] = true
+	}
+	st := statusbar.State{Muted: mgr.Status(now).Active}
+	for _, s := range v.active {
+		sess := statusbar.Session{Project: s.Project(), Working: s.Working(), Pending: pending[s.ID], Since: s.LastActivity}
+		switch {
+		case sess.Working:
+			sess.Since = s.PromptAt
+		case sess.Pending:
+			sess.Since = v.notified[s.ID]
+		}
+		st.Sessions = append(st.Sessions, sess)
+	}
+	return st
+}
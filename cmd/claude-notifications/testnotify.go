@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/hooks"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/plugin"
+	"github.com/spf13/cobra"
+)
+
+// newTestCmd sends a test notification:
+// test [--backend name]... [--event stop] [--project name] [--cwd dir] [--message text]
+func newTestCmd() *cobra.Command {
+	var opts hooks.TestOptions
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "Send a test notification and report each backend's time and errors",
+		Example: `  # Check a new ntfy setup without waiting for Claude
+  claude-notifications test --backend ntfy --event stop --project foo`,
+		Args: usageArgs(cobra.NoArgs),
+		Run: func(cmd *cobra.Command, args []string) {
+			runTest(opts)
+		},
+	}
+	cmd.Flags().StringSliceVar(&opts.Backends, "backend", nil, "send only to this backend or webhook preset, e.g. desktop or ntfy (repeatable; default: all enabled)")
+	cmd.Flags().StringVar(&opts.Event, "event", "stop", "event or status to simulate, e.g. stop, question, plan, permission, error")
+	cmd.Flags().StringVar(&opts.Project, "project", "", "project name shown in the notification (default: folder of --cwd)")
+	cmd.Flags().StringVar(&opts.CWD, "cwd", "", "project directory, for project configs and route globs (default: current directory)")
+	cmd.Flags().StringVar(&opts.Message, "message", "", "notification body (default: a sample message)")
+	_ = cmd.RegisterFlagCompletionFunc("backend", completeBackends)
+	_ = cmd.RegisterFlagCompletionFunc("event", completeTestEvents)
+	return cmd
+}
+
+// runTest sends a synthesized notification and reports each backend
+func runTest(opts hooks.TestOptions) {
+	var backends []string
+	for _, name := range opts.Backends {
+		if name = strings.TrimSpace(name); name != "" {
+			backends = append(backends, name)
+		}
+	}
+	opts.Backends = backends
+
+	dir, err := filepath.Abs(opts.CWD)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	pluginRoot := getPluginRoot()
+	if _, err := logging.InitLogger(logDir(pluginRoot)); err == nil {
+		defer logging.Close()
+	}
+
+	handler, err := hooks.NewHandler(pluginRoot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	opts.CWD = dir
+	results, err := handler.SendTest(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if printTestResults(results) {
+		os.Exit(1)
+	}
+}
+
+// printTestResults prints each backend's time and result as a table and
+// reports whether any delivery failed
+func printTestResults(results []hooks.TestResult) (failed bool) {
+	fmt.Printf("%-16s %-8s %s\n", "BACKEND", "TIME", "RESULT")
+	for _, r := range results {
+		elapsed, result := "-", "ok"
+		switch {
+		case r.Skipped != "":
+			result = "skipped: " + r.Skipped
+		case r.Err != nil:
+			elapsed, result = formatElapsed(r.Duration), "failed: "+r.Err.Error()
+			failed = true
+		default:
+			elapsed = formatElapsed(r.Duration)
+		}
+		fmt.Printf("%-16s %-8s %s\n", r.Backend, elapsed, result)
+	}
+	return failed
+}
+
+// completeBackends completes the enabled backends and webhook presets
+func completeBackends(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, _ := config.LoadFromPluginRoot(getPluginRoot())
+	if cfg == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	if cfg.IsDesktopEnabled() {
+		names = append(names, "desktop")
+	}
+	if cfg.IsWebhookEnabled() {
+		names = append(names, "webhook", cfg.Notifications.Webhook.Preset)
+	}
+	for i, w := range cfg.Notifications.Webhooks {
+		if w.Enabled {
+			names = append(names, cfg.ExtraWebhookName(i), w.Preset)
+		}
+	}
+	if cfg.IsEmailEnabled() {
+		names = append(names, "email")
+	}
+	if cfg.IsSpeechEnabled() {
+		names = append(names, "speech")
+	}
+	if cfg.IsMQTTEnabled() {
+		names = append(names, "mqtt")
+	}
+	if cfg.ArePluginsEnabled() {
+		plugins, _ := plugin.Discover(cfg)
+		for _, p := range plugins {
+			names = append(names, p.Name())
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeTestEvents completes the events and statuses test simulates
+func completeTestEvents(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	statuses, _ := completeStatuses(cmd, args, toComplete)
+	events := append([]string{"stop", "subagentstop", "notification", "permission", "plan", "review", "limit", "error", "tool", "budget", "heartbeat", "stall", "progress"}, statuses...)
+	return events, cobra.ShellCompDirectiveNoFileComp
+}
+
+// formatElapsed shows a delivery time, e.g. "250ms" or "1.8s"
+func formatElapsed(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/dnd"
+	"github.com/777genius/claude-notifications/internal/history"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/notifier"
+	"github.com/777genius/claude-notifications/internal/platform"
+	"github.com/777genius/claude-notifications/internal/sessions"
+	"github.com/777genius/claude-notifications/internal/tray"
+	"github.com/spf13/cobra"
+)
+
+// newTrayCmd shows the menu bar / system tray icon: tray [--refresh 5s]
+func newTrayCmd() *cobra.Command {
+	var refresh time.Duration
+	cmd := &cobra.Command{
+		Use:   "tray",
+		Short: "Show active sessions, the last notification and do-not-disturb in the system tray",
+		Long: `Show a menu bar (macOS) or system tray (Linux, Windows) icon with the
+number of active sessions and the last notification, and menu items to
+toggle do-not-disturb, open the history, focus a session and quit.
+It runs until Quit is clicked; start it at login to keep it around.`,
+		Args: usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTray(refresh)
+		},
+	}
+	cmd.Flags().DurationVar(&refresh, "refresh", tray.DefaultRefresh, "how often the sessions and last notification are reloaded")
+	return cmd
+}
+
+// runTray shows the tray icon until Quit is clicked
+func runTray(refresh time.Duration) error {
+	pluginRoot := getPluginRoot()
+	if _, err := logging.InitLogger(logDir(pluginRoot)); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	defer logging.Close()
+	logging.SetPrefix("tray")
+
+	cfg, err := config.LoadFromPluginRoot(pluginRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	logging.Configure(cfg.Logging.Options())
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		return err
+	}
+
+	mgr := dnd.NewManager(dir, cfg.Notifications.DND)
+	opts := tray.Options{
+		Source: tray.Source{
+			Sessions: sessions.NewRegistry(dir),
+			DND:      mgr,
+		},
+		Actions: tray.Actions{
+			ToggleDND: func(on bool) error {
+				if on {
+					return mgr.On(time.Time{})
+				}
+				if err := mgr.Off(time.Now()); err != nil {
+					return err
+				}
+				deliverDNDDigest(pluginRoot)
+				return nil
+			},
+			Focus: focusSession,
+		},
+		Refresh: refresh,
+	}
+	if cfg.IsHistoryEnabled() {
+		store := history.NewStore(dir, cfg.Notifications.History.MaxEntries)
+		opts.Source.History = store
+		opts.Actions.OpenHistory = func() error { return openFile(store.Path()) }
+	}
+	if platform.IsLinux() {
+		opts.Source.Daemon = notifier.IsDaemonAvailable
+	}
+	if icon, err := os.ReadFile(cfg.Notifications.Desktop.AppIcon); err == nil {
+		opts.Icon = icon
+	}
+	return tray.Run(opts)
+}
+
+// openFile opens a file in its default application
+func openFile(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("no history yet: %w", err)
+	}
+	var cmd *exec.Cmd
+	switch {
+	case platform.IsMacOS():
+		cmd = exec.Command("open", path)
+	case platform.IsWindows():
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", path)
+	default:
+		cmd = exec.Command("xdg-open", path)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	go cmd.Wait()
+	return nil
+}
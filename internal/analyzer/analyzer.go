@@ -39,6 +39,11 @@ const (
 	StatusSessionLimitReached Status = "session_limit_reached"
 	StatusAPIError            Status = "api_error"
 	StatusAPIErrorOverloaded  Status = "api_error_overloaded"
+	StatusToolUse             Status = "tool_use"        // A tool from notifications.tools is about to run or has run
+	StatusBudgetExceeded      Status = "budget_exceeded" // The session went over notifications.budget
+	StatusStillWorking        Status = "still_working"   // Heartbeat of a session working on a prompt for a while
+	StatusSessionStalled      Status = "session_stalled" // A working session showed no activity for notifications.stall.after
+	StatusInProgress          Status = "in_progress"     // Progress of a session on its prompt, updated in place
 	StatusUnknown             Status = "unknown"
 )
 
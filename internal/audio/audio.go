@@ -242,7 +242,7 @@ func (p *Player) decodeAudio(soundPath string) ([]int16, uint32, int, error) {
 		return p.decodeWAV(f)
 	case ".flac":
 		return p.decodeFLAC(f)
-	case ".ogg":
+	case ".ogg", ".oga":
 		return p.decodeOGG(f)
 	case ".aiff", ".aif":
 		return p.decodeAIFF(f)
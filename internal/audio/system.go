@@ -0,0 +1,71 @@
+// ABOUTME: Sound playback through the platform's command-line players.
+// ABOUTME: paplay/pw-play/canberra-gtk-play/aplay on Linux, afplay on macOS, PlaySound on Windows.
+
+package audio
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/777genius/claude-notifications/internal/platform"
+)
+
+// lookPath finds a player command; replaced in tests
+var lookPath = exec.LookPath
+
+// PlaySystem plays soundPath with the first system player that is
+// installed, waiting until it finishes or ctx ends. Used when the built-in
+// player cannot open an audio device, or instead of it.
+func PlaySystem(ctx context.Context, soundPath string, volume float64) error {
+	commands := systemPlayerCommands(runtime.GOOS, soundPath, volume)
+	if len(commands) == 0 {
+		return fmt.Errorf("no system sound player for %s on %s", filepath.Ext(soundPath), runtime.GOOS)
+	}
+	for _, args := range commands {
+		if _, err := lookPath(args[0]); err != nil {
+			continue
+		}
+		if output, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput(); err != nil {
+			return fmt.Errorf("%s failed: %w, output: %s", args[0], err, strings.TrimSpace(string(output)))
+		}
+		return nil
+	}
+	names := make([]string, len(commands))
+	for i, args := range commands {
+		names[i] = args[0]
+	}
+	return fmt.Errorf("no system sound player installed (tried %s)", strings.Join(names, ", "))
+}
+
+// systemPlayerCommands returns the commands that can play soundPath on
+// goos, best first. volume is 0.0-1.0.
+func systemPlayerCommands(goos, soundPath string, volume float64) [][]string {
+	ext := strings.ToLower(filepath.Ext(soundPath))
+	switch goos {
+	case "darwin":
+		return [][]string{{"afplay", "-v", strconv.FormatFloat(volume, 'f', 2, 64), soundPath}}
+	case "windows":
+		// Media.SoundPlayer wraps PlaySound, which only plays WAV
+		if ext != ".wav" {
+			return nil
+		}
+		return [][]string{{"powershell", "-NoProfile", "-NonInteractive", "-Command",
+			"(New-Object Media.SoundPlayer " + platform.PSQuote(soundPath) + ").PlaySync()"}}
+	default:
+		commands := [][]string{
+			// PulseAudio volume is 0-65536
+			{"paplay", "--volume=" + strconv.Itoa(int(volume*65536)), soundPath},
+			{"pw-play", "--volume=" + strconv.FormatFloat(volume, 'f', 2, 64), soundPath},
+			{"canberra-gtk-play", "-f", soundPath},
+		}
+		if ext == ".wav" {
+			commands = append(commands, []string{"aplay", "-q", soundPath})
+		}
+		return commands
+	}
+}
@@ -0,0 +1,52 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSystemPlayerCommands(t *testing.T) {
+	tests := []struct {
+		name  string
+		goos  string
+		path  string
+		first []string
+		count int
+	}{
+		{"macOS", "darwin", "/s/done.mp3", []string{"afplay", "-v", "0.50", "/s/done.mp3"}, 1},
+		{"Linux", "linux", "/s/done.mp3", []string{"paplay", "--volume=32768", "/s/done.mp3"}, 3},
+		{"Linux WAV adds aplay", "linux", "/s/done.wav", []string{"paplay", "--volume=32768", "/s/done.wav"}, 4},
+		{"FreeBSD uses the Linux players", "freebsd", "/s/done.ogg", []string{"paplay", "--volume=32768", "/s/done.ogg"}, 3},
+		{"Windows WAV", "windows", `C:\It's\done.wav`, []string{"powershell", "-NoProfile", "-NonInteractive", "-Command",
+			`(New-Object Media.SoundPlayer 'C:\It''s\done.wav').PlaySync()`}, 1},
+		{"Windows plays only WAV", "windows", `C:\done.mp3`, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := systemPlayerCommands(tt.goos, tt.path, 0.5)
+			if len(got) != tt.count {
+				t.Fatalf("got %d commands %v, want %d", len(got), got, tt.count)
+			}
+			if tt.count > 0 && !reflect.DeepEqual(got[0], tt.first) {
+				t.Errorf("first command = %q, want %q", got[0], tt.first)
+			}
+		})
+	}
+}
+
+func TestPlaySystem_NoPlayerInstalled(t *testing.T) {
+	orig := lookPath
+	lookPath = func(string) (string, error) { return "", errors.New("not found") }
+	t.Cleanup(func() { lookPath = orig })
+
+	err := PlaySystem(context.Background(), "/s/done.wav", 1)
+	if err == nil {
+		t.Fatal("PlaySystem() should fail without a player")
+	}
+	if !strings.Contains(err.Error(), "no system sound player") {
+		t.Errorf("error = %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+// Package breaker keeps a circuit breaker per notification backend. Every
+// hook runs in a new process, so the failure counts live in a file next to
+// the config: after a number of consecutive failures a backend is skipped
+// for a backoff period instead of delaying each hook with another timeout.
+// When the period ends, one delivery probes the backend; a failed probe
+// doubles the backoff, a successful one closes the breaker.
+package breaker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	fileName = "breakers.json"
+
+	// probeWindow is how long the delivery probing a backend has before
+	// another hook may probe it again
+	probeWindow = 2 * time.Minute
+)
+
+// Settings tune when a breaker opens and for how long
+type Settings struct {
+	Failures   int           // Consecutive failures that open the breaker
+	Backoff    time.Duration // First open period
+	MaxBackoff time.Duration // Longest open period
+}
+
+// State is the failure record of one backend
+type State struct {
+	Failures    int           `json:"failures"` // Consecutive failures
+	LastError   string        `json:"last_error,omitempty"`
+	LastFailure time.Time     `json:"last_failure,omitempty"`
+	OpenUntil   time.Time     `json:"open_until,omitempty"` // Deliveries are skipped until then (zero = closed)
+	Backoff     time.Duration `json:"backoff,omitempty"`    // Length of the last open period (0 = never opened)
+}
+
+// Open reports whether deliveries to the backend are skipped at now
+func (s State) Open(now time.Time) bool {
+	return now.Before(s.OpenUntil)
+}
+
+// Tripped reports whether the breaker opened since the last success,
+// including while a probe is allowed through
+func (s State) Tripped() bool {
+	return s.Backoff > 0
+}
+
+// Store keeps the breaker states of all backends in one file in a directory
+type Store struct {
+	dir      string
+	settings Settings
+	mu       sync.Mutex // Serializes updates from concurrent backend goroutines
+}
+
+// NewStore creates a store keeping its file in dir
+func NewStore(dir string, settings Settings) *Store {
+	return &Store{dir: dir, settings: settings}
+}
+
+// Path returns the breaker file path
+func (s *Store) Path() string {
+	return filepath.Join(s.dir, fileName)
+}
+
+// Allow reports whether a delivery to backend may go ahead at now. Once the
+// open period of a tripped breaker has passed, the first caller is let
+// through as the probe and others are held off for probeWindow.
+func (s *Store) Allow(backend string, now time.Time) (bool, State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states, err := s.read()
+	if err != nil {
+		return true, State{}, err
+	}
+	st := states[backend]
+	if st.Open(now) {
+		return false, st, nil
+	}
+	if st.Tripped() {
+		st.OpenUntil = now.Add(probeWindow)
+		states[backend] = st
+		if err := s.write(states); err != nil {
+			return true, st, err
+		}
+	}
+	return true, st, nil
+}
+
+// Record updates the breaker of backend with the result of a delivery and
+// reports whether it opened (again)
+func (s *Store) Record(backend string, deliveryErr error, now time.Time) (opened bool, st State, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states, err := s.read()
+	if err != nil {
+		return false, State{}, err
+	}
+	st = states[backend]
+	if deliveryErr == nil {
+		if st.Failures == 0 && !st.Tripped() {
+			return false, State{}, nil
+		}
+		delete(states, backend)
+		return false, State{}, s.write(states)
+	}
+
+	st.Failures++
+	st.LastError = deliveryErr.Error()
+	st.LastFailure = now
+	if st.Failures >= s.settings.Failures {
+		st.Backoff = s.nextBackoff(st.Backoff)
+		st.OpenUntil = now.Add(st.Backoff)
+		opened = true
+	}
+	states[backend] = st
+	return opened, st, s.write(states)
+}
+
+// nextBackoff returns the open period following one of length last
+// (0 = the breaker was closed)
+func (s *Store) nextBackoff(last time.Duration) time.Duration {
+	next := s.settings.Backoff
+	if last > 0 {
+		next = 2 * last
+	}
+	if next > s.settings.MaxBackoff {
+		next = s.settings.MaxBackoff
+	}
+	return next
+}
+
+// States returns the failure records of all backends that failed since
+// their last success
+func (s *Store) States() (map[string]State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read()
+}
+
+// Reset closes the breakers of the named backends, or of all backends when
+// none are named
+func (s *Store) Reset(backends ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states, err := s.read()
+	if err != nil {
+		return err
+	}
+	if len(backends) == 0 {
+		states = map[string]State{}
+	}
+	for _, b := range backends {
+		delete(states, b)
+	}
+	return s.write(states)
+}
+
+// Names returns the backends in states in sorted order
+func Names(states map[string]State) []string {
+	names := make([]string, 0, len(states))
+	for name := range states {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// read loads the breaker file; a missing file means every breaker is closed
+func (s *Store) read() (map[string]State, error) {
+	states := map[string]State{}
+	data, err := os.ReadFile(s.Path())
+	if errors.Is(err, os.ErrNotExist) {
+		return states, nil
+	}
+	if err != nil {
+		return states, fmt.Errorf("failed to read circuit breakers: %w", err)
+	}
+	if err := json.Unmarshal(data, &states); err != nil {
+		return map[string]State{}, fmt.Errorf("failed to parse circuit breakers: %w", err)
+	}
+	return states, nil
+}
+
+// write replaces the breaker file atomically, removing it when every
+// breaker is closed
+func (s *Store) write(states map[string]State) error {
+	if len(states) == 0 {
+		if err := os.Remove(s.Path()); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to write circuit breakers: %w", err)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create circuit breaker directory: %w", err)
+	}
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize circuit breakers: %w", err)
+	}
+	tmp := fmt.Sprintf("%s.%d.tmp", s.Path(), os.Getpid())
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write circuit breakers: %w", err)
+	}
+	if err := os.Rename(tmp, s.Path()); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write circuit breakers: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package breaker
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+var testSettings = Settings{Failures: 3, Backoff: time.Minute, MaxBackoff: 3 * time.Minute}
+
+func allow(t *testing.T, s *Store, backend string, now time.Time) bool {
+	t.Helper()
+	ok, _, err := s.Allow(backend, now)
+	if err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	return ok
+}
+
+func record(t *testing.T, s *Store, backend string, err error, now time.Time) (bool, State) {
+	t.Helper()
+	opened, st, recordErr := s.Record(backend, err, now)
+	if recordErr != nil {
+		t.Fatalf("Record: %v", recordErr)
+	}
+	return opened, st
+}
+
+func TestStore_OpensAfterFailures(t *testing.T) {
+	s := NewStore(t.TempDir(), testSettings)
+	now := time.Now()
+	fail := errors.New("HTTP 503")
+
+	for i := 1; i < 3; i++ {
+		if opened, _ := record(t, s, "webhook", fail, now); opened {
+			t.Fatalf("opened after %d failures, want 3", i)
+		}
+	}
+	opened, st := record(t, s, "webhook", fail, now)
+	if !opened || st.Backoff != time.Minute || !st.OpenUntil.Equal(now.Add(time.Minute)) || st.LastError != "HTTP 503" {
+		t.Fatalf("third failure: opened %v, state %+v", opened, st)
+	}
+	if allow(t, s, "webhook", now.Add(30*time.Second)) {
+		t.Error("open breaker allowed a delivery")
+	}
+	if !allow(t, s, "email", now) {
+		t.Error("another backend's breaker was affected")
+	}
+}
+
+func TestStore_ProbeAndBackoff(t *testing.T) {
+	s := NewStore(t.TempDir(), testSettings)
+	now := time.Now()
+	fail := errors.New("timeout")
+	for i := 0; i < 3; i++ {
+		record(t, s, "mqtt", fail, now)
+	}
+
+	// After the pause one probe goes through; others wait for it
+	now = now.Add(time.Minute)
+	if !allow(t, s, "mqtt", now) {
+		t.Fatal("probe not allowed after the pause")
+	}
+	if allow(t, s, "mqtt", now.Add(time.Second)) {
+		t.Error("second probe allowed while the first is running")
+	}
+
+	// A failed probe doubles the pause, up to MaxBackoff
+	for _, want := range []time.Duration{2 * time.Minute, 3 * time.Minute, 3 * time.Minute} {
+		opened, st := record(t, s, "mqtt", fail, now)
+		if !opened || st.Backoff != want {
+			t.Fatalf("failed probe: opened %v, backoff %v; want %v", opened, st.Backoff, want)
+		}
+		now = st.OpenUntil
+		allow(t, s, "mqtt", now)
+	}
+
+	// A successful probe closes the breaker and removes the file
+	if opened, st := record(t, s, "mqtt", nil, now); opened || st.Failures != 0 {
+		t.Errorf("success: opened %v, state %+v", opened, st)
+	}
+	if _, err := os.Stat(s.Path()); !os.IsNotExist(err) {
+		t.Errorf("breaker file left behind: %v", err)
+	}
+	if ok, st, _ := s.Allow("mqtt", now); !ok || st.Tripped() {
+		t.Errorf("after success: allowed %v, state %+v", ok, st)
+	}
+}
+
+func TestStore_SuccessResetsFailures(t *testing.T) {
+	s := NewStore(t.TempDir(), testSettings)
+	now := time.Now()
+	record(t, s, "webhook", errors.New("x"), now)
+	record(t, s, "webhook", errors.New("x"), now)
+	record(t, s, "webhook", nil, now)
+	if opened, _ := record(t, s, "webhook", errors.New("x"), now); opened {
+		t.Error("failures before a success still counted")
+	}
+}
+
+func TestStore_StatesAndReset(t *testing.T) {
+	s := NewStore(t.TempDir(), testSettings)
+	now := time.Now()
+	for _, b := range []string{"webhook", "email", "urls[0]"} {
+		record(t, s, b, errors.New("down"), now)
+	}
+
+	if err := s.Reset("email"); err != nil {
+		t.Fatal(err)
+	}
+	states, err := s.States()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := Names(states); len(names) != 2 || names[0] != "urls[0]" || names[1] != "webhook" {
+		t.Errorf("names = %v", names)
+	}
+
+	if err := s.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if states, _ := s.States(); len(states) != 0 {
+		t.Errorf("states after Reset() = %v", states)
+	}
+}
+
+func TestStore_CorruptFile(t *testing.T) {
+	s := NewStore(t.TempDir(), testSettings)
+	if err := os.WriteFile(s.Path(), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _, err := s.Allow("webhook", time.Now()); !ok || err == nil {
+		t.Errorf("Allow = %v, %v; want allowed with an error", ok, err)
+	}
+}
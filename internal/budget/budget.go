@@ -0,0 +1,199 @@
+// Package budget adds up the tokens and cost of a session from its
+// transcript and tells when a configured budget is exceeded. Every hook
+// runs in a new process, so the totals live in a file per session next to
+// the config, with the transcript offset read so far: each hook only reads
+// what was appended since the last one.
+package budget
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/777genius/claude-notifications/pkg/jsonl"
+)
+
+// Limits are the budgets of a session (0 = none)
+type Limits struct {
+	Tokens  int
+	CostUSD float64
+}
+
+// Usage is what a session used so far
+type Usage struct {
+	Offset  int64   `json:"offset"` // Transcript bytes read
+	Tokens  int     `json:"tokens"`
+	CostUSD float64 `json:"cost_usd"`
+
+	// The lines of a streamed response repeat its usage, so the last
+	// response counted is replaced rather than added again
+	LastID     string  `json:"last_id,omitempty"`
+	LastTokens int     `json:"last_tokens,omitempty"`
+	LastCost   float64 `json:"last_cost,omitempty"`
+
+	AlertedTokens bool `json:"alerted_tokens,omitempty"`
+	AlertedCost   bool `json:"alerted_cost,omitempty"`
+}
+
+// Exceeded is a budget the session went over
+type Exceeded struct {
+	Kind  string  // "tokens" or "cost"
+	Limit float64 // Budget
+	Used  float64 // Tokens or dollars used
+}
+
+// Message describes the exceeded budget for a notification
+func (e Exceeded) Message() string {
+	if e.Kind == "cost" {
+		return fmt.Sprintf("Session cost $%.2f, over the budget of $%.2f", e.Used, e.Limit)
+	}
+	return fmt.Sprintf("Session used %s tokens, over the budget of %s", formatTokens(int(e.Used)), formatTokens(int(e.Limit)))
+}
+
+// Read adds the usage of the transcript lines appended since the last
+// read. A transcript shorter than the offset was replaced and is read
+// again from the start. A last line without newline is still being
+// written and is left for the next read.
+func (u *Usage) Read(transcriptPath string) error {
+	f, err := os.Open(transcriptPath)
+	if err != nil {
+		return fmt.Errorf("failed to open transcript: %w", err)
+	}
+	defer f.Close()
+
+	if info, err := f.Stat(); err == nil && info.Size() < u.Offset {
+		*u = Usage{AlertedTokens: u.AlertedTokens, AlertedCost: u.AlertedCost}
+	}
+	if _, err := f.Seek(u.Offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read transcript: %w", err)
+	}
+
+	r := bufio.NewReaderSize(f, 64*1024)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read transcript: %w", err)
+		}
+		u.Offset += int64(len(line))
+		u.add(line)
+	}
+}
+
+// add counts the usage of one transcript line
+func (u *Usage) add(line []byte) {
+	var msg jsonl.Message
+	if err := json.Unmarshal(line, &msg); err != nil || msg.Type != "assistant" {
+		return
+	}
+	usage := msg.Message.Usage
+	if usage == nil && msg.CostUSD == 0 {
+		return
+	}
+
+	tokens, cost := 0, msg.CostUSD
+	if usage != nil {
+		tokens = usage.Total()
+		if cost == 0 {
+			cost = EstimateCost(msg.Message.Model, *usage)
+		}
+	}
+	if id := msg.Message.ID; id != "" && id == u.LastID {
+		u.Tokens -= u.LastTokens
+		u.CostUSD -= u.LastCost
+	}
+	u.Tokens += tokens
+	u.CostUSD += cost
+	u.LastID, u.LastTokens, u.LastCost = msg.Message.ID, tokens, cost
+}
+
+// Exceeded returns the budgets in limits that u went over and has not
+// alerted yet, and marks them alerted
+func (u *Usage) Exceeded(limits Limits) []Exceeded {
+	var over []Exceeded
+	if limits.Tokens > 0 && u.Tokens > limits.Tokens && !u.AlertedTokens {
+		u.AlertedTokens = true
+		over = append(over, Exceeded{Kind: "tokens", Limit: float64(limits.Tokens), Used: float64(u.Tokens)})
+	}
+	if limits.CostUSD > 0 && u.CostUSD > limits.CostUSD && !u.AlertedCost {
+		u.AlertedCost = true
+		over = append(over, Exceeded{Kind: "cost", Limit: limits.CostUSD, Used: u.CostUSD})
+	}
+	return over
+}
+
+// Store keeps the usage of each session in a file in a directory
+type Store struct {
+	dir string
+}
+
+// NewStore creates a store keeping its files in dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// path returns the file holding the usage of a session
+func (s *Store) path(sessionID string) string {
+	return filepath.Join(s.dir, fmt.Sprintf("budget-%s.json", sessionID))
+}
+
+// Load returns the usage of a session counted so far (zero = none)
+func (s *Store) Load(sessionID string) (Usage, error) {
+	var u Usage
+	data, err := os.ReadFile(s.path(sessionID))
+	if errors.Is(err, os.ErrNotExist) {
+		return u, nil
+	}
+	if err != nil {
+		return u, fmt.Errorf("failed to read budget usage: %w", err)
+	}
+	if err := json.Unmarshal(data, &u); err != nil {
+		return Usage{}, fmt.Errorf("failed to parse budget usage: %w", err)
+	}
+	return u, nil
+}
+
+// Save stores the usage of a session
+func (s *Store) Save(sessionID string, u Usage) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create budget directory: %w", err)
+	}
+	data, err := json.Marshal(u)
+	if err != nil {
+		return fmt.Errorf("failed to serialize budget usage: %w", err)
+	}
+	tmp := fmt.Sprintf("%s.%d.tmp", s.path(sessionID), os.Getpid())
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write budget usage: %w", err)
+	}
+	if err := os.Rename(tmp, s.path(sessionID)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write budget usage: %w", err)
+	}
+	return nil
+}
+
+// Forget removes the usage of a session that ended
+func (s *Store) Forget(sessionID string) error {
+	if err := os.Remove(s.path(sessionID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove budget usage: %w", err)
+	}
+	return nil
+}
+
+// formatTokens shortens a token count: 950, 12.3k, 2.1M
+func formatTokens(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	}
+	return fmt.Sprint(n)
+}
@@ -0,0 +1,176 @@
+package budget
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/777genius/claude-notifications/pkg/jsonl"
+)
+
+// reply is an assistant transcript line with usage: 1000 input and 1000
+// output tokens of Sonnet cost $0.018
+func reply(id string) string {
+	return `{"type":"assistant","message":{"id":"` + id + `","model":"claude-sonnet-4-5-20250929","role":"assistant","content":[],"usage":{"input_tokens":1000,"output_tokens":1000}}}` + "\n"
+}
+
+func appendLines(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(strings.Join(lines, "")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestUsageRead_Incremental(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	appendLines(t, path,
+		`{"type":"user","message":{"role":"user","content":"Hi"}}`+"\n",
+		reply("msg_1"),
+		reply("msg_1"), // The same streamed response
+	)
+
+	var u Usage
+	if err := u.Read(path); err != nil {
+		t.Fatal(err)
+	}
+	if u.Tokens != 2000 || !near(u.CostUSD, 0.018) {
+		t.Errorf("after first read: %d tokens, $%v, want 2000 and $0.018", u.Tokens, u.CostUSD)
+	}
+
+	// A line still being written is left for the next read
+	partial := reply("msg_2")
+	appendLines(t, path, partial[:20])
+	if err := u.Read(path); err != nil {
+		t.Fatal(err)
+	}
+	if u.Tokens != 2000 {
+		t.Errorf("partial line counted: %d tokens", u.Tokens)
+	}
+	appendLines(t, path, partial[20:])
+	if err := u.Read(path); err != nil {
+		t.Fatal(err)
+	}
+	if u.Tokens != 4000 || !near(u.CostUSD, 0.036) {
+		t.Errorf("after second read: %d tokens, $%v, want 4000 and $0.036", u.Tokens, u.CostUSD)
+	}
+}
+
+func TestUsageRead_RecordedCost(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	appendLines(t, path, `{"type":"assistant","costUSD":0.5,"message":{"id":"msg_1","role":"assistant","content":[],"usage":{"input_tokens":10,"output_tokens":10}}}`+"\n")
+
+	var u Usage
+	if err := u.Read(path); err != nil {
+		t.Fatal(err)
+	}
+	if u.Tokens != 20 || u.CostUSD != 0.5 {
+		t.Errorf("got %d tokens, $%v, want 20 and the recorded $0.50", u.Tokens, u.CostUSD)
+	}
+}
+
+func TestUsageRead_ReplacedTranscript(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	appendLines(t, path, reply("msg_1"), reply("msg_2"))
+
+	u := Usage{AlertedTokens: true}
+	if err := u.Read(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(reply("msg_3")), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Read(path); err != nil {
+		t.Fatal(err)
+	}
+	if u.Tokens != 2000 || !u.AlertedTokens {
+		t.Errorf("after replacement: %+v, want 2000 tokens, still alerted", u)
+	}
+}
+
+func TestUsageExceeded(t *testing.T) {
+	u := Usage{Tokens: 2_100_000, CostUSD: 4}
+	limits := Limits{Tokens: 2_000_000, CostUSD: 5}
+
+	over := u.Exceeded(limits)
+	if len(over) != 1 || over[0].Kind != "tokens" {
+		t.Fatalf("Exceeded() = %+v, want the token budget", over)
+	}
+	if got := over[0].Message(); got != "Session used 2.1M tokens, over the budget of 2.0M" {
+		t.Errorf("Message() = %q", got)
+	}
+	if over := u.Exceeded(limits); len(over) != 0 {
+		t.Errorf("second Exceeded() = %+v, want nothing (already alerted)", over)
+	}
+
+	u.CostUSD = 5.25
+	over = u.Exceeded(limits)
+	if len(over) != 1 || over[0].Message() != "Session cost $5.25, over the budget of $5.00" {
+		t.Errorf("Exceeded() = %+v, want the cost budget", over)
+	}
+	if over := (&Usage{Tokens: 10}).Exceeded(Limits{}); len(over) != 0 {
+		t.Errorf("Exceeded(no limits) = %+v", over)
+	}
+}
+
+func TestStore(t *testing.T) {
+	s := NewStore(t.TempDir())
+
+	u, err := s.Load("abc")
+	if err != nil || u != (Usage{}) {
+		t.Fatalf("Load(new) = %+v, %v, want zero", u, err)
+	}
+	want := Usage{Offset: 42, Tokens: 1000, CostUSD: 0.5, LastID: "msg_1", AlertedCost: true}
+	if err := s.Save("abc", want); err != nil {
+		t.Fatal(err)
+	}
+	if u, err = s.Load("abc"); err != nil || u != want {
+		t.Errorf("Load() = %+v, %v, want %+v", u, err, want)
+	}
+	if err := s.Forget("abc"); err != nil {
+		t.Fatal(err)
+	}
+	if u, _ = s.Load("abc"); u != (Usage{}) {
+		t.Errorf("Load() after Forget = %+v, want zero", u)
+	}
+	if err := s.Forget("abc"); err != nil {
+		t.Errorf("Forget(missing) = %v", err)
+	}
+}
+
+func TestEstimateCost(t *testing.T) {
+	million := jsonl.Usage{InputTokens: 1_000_000}
+	tests := []struct {
+		model string
+		want  float64
+	}{
+		{"claude-opus-4-20250514", 15},
+		{"claude-opus-4-1-20250805", 15},
+		{"claude-opus-4-5-20251101", 5},
+		{"claude-sonnet-4-5-20250929", 3},
+		{"claude-3-5-haiku-20241022", 0.8},
+		{"claude-haiku-4-5-20251001", 1},
+		{"some-future-model", 3},
+	}
+	for _, tt := range tests {
+		if got := EstimateCost(tt.model, million); !near(got, tt.want) {
+			t.Errorf("EstimateCost(%s) = %v, want %v", tt.model, got, tt.want)
+		}
+	}
+
+	// Output at the output price, cache writes at 1.25x and reads at 0.1x input
+	u := jsonl.Usage{OutputTokens: 1_000_000, CacheCreationInputTokens: 1_000_000, CacheReadInputTokens: 1_000_000}
+	if got := EstimateCost("claude-sonnet-4-5", u); !near(got, 15+3.75+0.3) {
+		t.Errorf("EstimateCost(cache) = %v, want 19.05", got)
+	}
+}
@@ -0,0 +1,48 @@
+package budget
+
+import (
+	"strings"
+
+	"github.com/777genius/claude-notifications/pkg/jsonl"
+)
+
+// price is the list price of a model in dollars per million tokens. Cache
+// writes cost 1.25 times the input price, cache reads a tenth of it.
+type price struct {
+	match  string // Substring of the model ID
+	input  float64
+	output float64
+}
+
+// prices are checked in order; the first match wins. Newer models of a
+// family fall through to its last entry.
+var prices = []price{
+	{"opus-4-2025", 15, 75}, // Claude Opus 4
+	{"opus-4-1", 15, 75},
+	{"3-opus", 15, 75},
+	{"opus", 5, 25},
+	{"3-haiku", 0.25, 1.25},
+	{"3-5-haiku", 0.8, 4},
+	{"haiku", 1, 5},
+	{"sonnet", 3, 15},
+}
+
+// defaultPrice applies to models not in prices
+var defaultPrice = price{input: 3, output: 15}
+
+// EstimateCost returns the list price of a response of model with usage u,
+// for transcripts that do not record the cost
+func EstimateCost(model string, u jsonl.Usage) float64 {
+	p := defaultPrice
+	for _, candidate := range prices {
+		if strings.Contains(model, candidate.match) {
+			p = candidate
+			break
+		}
+	}
+	perToken := func(dollarsPerMillion float64) float64 { return dollarsPerMillion / 1_000_000 }
+	return float64(u.InputTokens)*perToken(p.input) +
+		float64(u.CacheCreationInputTokens)*perToken(1.25*p.input) +
+		float64(u.CacheReadInputTokens)*perToken(0.1*p.input) +
+		float64(u.OutputTokens)*perToken(p.output)
+}
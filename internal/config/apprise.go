@@ -0,0 +1,275 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/777genius/claude-notifications/internal/platform"
+)
+
+// serviceURLSchemes lists the Apprise URL schemes that map onto a webhook preset
+var serviceURLSchemes = []string{"ntfy", "ntfys", "tgram", "slack", "discord", "pover", "gotify", "gotifys", "matrix", "matrixs", "json", "jsons"}
+
+// ntfyPriorityNames are the priority names Apprise accepts in ntfy URLs
+var ntfyPriorityNames = map[string]int{"min": 1, "low": 2, "default": 3, "high": 4, "max": 5}
+
+// serviceURL is an Apprise-style URL split into its parts. Tokens such as
+// Telegram's "123:abc" are not valid hosts, so the URL is split by hand
+// instead of with url.Parse.
+type serviceURL struct {
+	scheme   string
+	user     string // Before ":" in the userinfo, or the whole userinfo
+	password string
+	hasPass  bool
+	host     string // Including the port
+	path     []string
+	query    url.Values
+}
+
+// splitServiceURL splits raw into scheme, userinfo, host, path segments and query
+func splitServiceURL(raw string) (serviceURL, error) {
+	var u serviceURL
+	scheme, rest, ok := strings.Cut(strings.TrimSpace(raw), "://")
+	if !ok || scheme == "" {
+		return u, fmt.Errorf("%q is not a service URL like ntfys://ntfy.sh/topic", raw)
+	}
+	u.scheme = strings.ToLower(scheme)
+
+	// "+" is literal, as in Apprise's "+Header=value" parameters, not a space
+	rest, rawQuery, _ := strings.Cut(rest, "?")
+	query, err := url.ParseQuery(strings.ReplaceAll(rawQuery, "+", "%2B"))
+	if err != nil {
+		return u, fmt.Errorf("invalid query: %w", err)
+	}
+	u.query = query
+
+	authority, path, _ := strings.Cut(rest, "/")
+	if at := strings.LastIndex(authority, "@"); at >= 0 {
+		user, password, hasPass := strings.Cut(authority[:at], ":")
+		if u.user, err = url.PathUnescape(user); err != nil {
+			return u, fmt.Errorf("invalid userinfo: %w", err)
+		}
+		if u.password, err = url.PathUnescape(password); err != nil {
+			return u, fmt.Errorf("invalid userinfo: %w", err)
+		}
+		u.hasPass = hasPass
+		authority = authority[at+1:]
+	}
+	u.host = authority
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+		segment, err := url.PathUnescape(segment)
+		if err != nil {
+			return u, fmt.Errorf("invalid path: %w", err)
+		}
+		u.path = append(u.path, segment)
+	}
+	return u, nil
+}
+
+// httpScheme returns "https" for the TLS variant of a scheme pair such as
+// ntfys, and "http" otherwise
+func (u serviceURL) httpScheme(tlsScheme string) string {
+	if u.scheme == tlsScheme {
+		return "https"
+	}
+	return "http"
+}
+
+// ParseServiceURL maps an Apprise-style service URL such as
+// "tgram://123:abc/-100" onto the webhook preset that delivers to the same
+// service. The result still needs applyDefaults.
+func ParseServiceURL(raw string) (WebhookConfig, error) {
+	u, err := splitServiceURL(raw)
+	if err != nil {
+		return WebhookConfig{}, err
+	}
+
+	w := WebhookConfig{Enabled: true}
+	switch u.scheme {
+	case "ntfy", "ntfys":
+		// ntfy://topic publishes to ntfy.sh, ntfy://host/topic to a server
+		w.Preset = "ntfy"
+		switch len(u.path) {
+		case 0:
+			if u.host == "" {
+				return w, fmt.Errorf("%s: topic is missing", u.scheme)
+			}
+			w.URL = "https://ntfy.sh/" + url.PathEscape(u.host)
+		case 1:
+			w.URL = u.httpScheme("ntfys") + "://" + u.host + "/" + url.PathEscape(u.path[0])
+		default:
+			return w, fmt.Errorf("%s: one topic per URL (got %d)", u.scheme, len(u.path))
+		}
+		if u.hasPass {
+			auth := base64.StdEncoding.EncodeToString([]byte(u.user + ":" + u.password))
+			w.Headers = map[string]string{"Authorization": "Basic " + auth}
+		} else {
+			w.Ntfy.Token = u.user
+		}
+		if p := u.query.Get("priority"); p != "" {
+			priority, ok := ntfyPriorityNames[strings.ToLower(p)]
+			if n, err := strconv.Atoi(p); err == nil {
+				priority, ok = n, true
+			}
+			if !ok {
+				return w, fmt.Errorf("%s: unknown priority %q (min, low, default, high or max)", u.scheme, p)
+			}
+			w.Ntfy.Priority = priority
+		}
+		if tags := u.query.Get("tags"); tags != "" {
+			w.Ntfy.Tags = strings.Split(tags, ",")
+		}
+
+	case "tgram":
+		// tgram://bot_token/chat_id
+		if u.host == "" || len(u.path) != 1 {
+			return w, fmt.Errorf("tgram: expected tgram://bot_token/chat_id")
+		}
+		w.Preset = "telegram"
+		w.Telegram.BotToken = u.host
+		w.ChatID = u.path[0]
+
+	case "slack":
+		// slack://tokenA/tokenB/tokenC[/#channel] for an incoming webhook,
+		// slack://xoxb-token/#channel for a bot
+		w.Preset = "slack"
+		switch {
+		case strings.HasPrefix(u.host, "xoxb-"):
+			if len(u.path) != 1 {
+				return w, fmt.Errorf("slack: expected slack://xoxb-token/#channel")
+			}
+			w.Slack.BotToken = u.host
+			w.Slack.Channel = u.path[0]
+		case len(u.path) == 2 || len(u.path) == 3:
+			w.URL = "https://hooks.slack.com/services/" + u.host + "/" + u.path[0] + "/" + u.path[1]
+			if len(u.path) == 3 {
+				w.Slack.Channel = u.path[2]
+			}
+		default:
+			return w, fmt.Errorf("slack: expected slack://tokenA/tokenB/tokenC or slack://xoxb-token/#channel")
+		}
+
+	case "discord":
+		// discord://webhook_id/webhook_token
+		if u.host == "" || len(u.path) != 1 {
+			return w, fmt.Errorf("discord: expected discord://webhook_id/webhook_token")
+		}
+		w.Preset = "discord"
+		w.URL = "https://discord.com/api/webhooks/" + u.host + "/" + u.path[0]
+
+	case "pover":
+		// pover://user_key@app_token[/device]
+		if u.user == "" || u.host == "" || len(u.path) > 1 {
+			return w, fmt.Errorf("pover: expected pover://user_key@app_token[/device]")
+		}
+		w.Preset = "pushover"
+		w.Pushover.UserKey = u.user
+		w.Pushover.AppToken = u.host
+		if len(u.path) == 1 {
+			w.Pushover.Device = u.path[0]
+		}
+
+	case "gotify", "gotifys":
+		// gotify://host[/path]/app_token
+		if u.host == "" || len(u.path) == 0 {
+			return w, fmt.Errorf("%s: expected %s://host/app_token", u.scheme, u.scheme)
+		}
+		w.Preset = "gotify"
+		last := len(u.path) - 1
+		w.URL = u.httpScheme("gotifys") + "://" + u.host + joinPath(u.path[:last])
+		w.Gotify.Token = u.path[last]
+
+	case "matrix", "matrixs":
+		// matrixs://access_token@host/!room_id
+		if u.hasPass {
+			return w, fmt.Errorf("%s: user and password login is not supported, use an access token: %s://token@host/!room_id", u.scheme, u.scheme)
+		}
+		if u.user == "" || u.host == "" || len(u.path) != 1 {
+			return w, fmt.Errorf("%s: expected %s://access_token@host/!room_id", u.scheme, u.scheme)
+		}
+		w.Preset = "matrix"
+		w.URL = u.httpScheme("matrixs") + "://" + u.host
+		w.Matrix.AccessToken = u.user
+		w.Matrix.RoomID = u.path[0]
+		if msgtype := u.query.Get("msgtype"); msgtype != "" {
+			w.Matrix.MsgType = "m." + strings.TrimPrefix(msgtype, "m.")
+		}
+
+	case "json", "jsons":
+		// json://host/path posts the plugin's JSON payload; "+Name=value"
+		// query parameters become request headers, as in Apprise
+		if u.host == "" {
+			return w, fmt.Errorf("%s: host is missing", u.scheme)
+		}
+		w.Preset = "custom"
+		w.URL = u.httpScheme("jsons") + "://" + u.host + joinPath(u.path)
+		for key, values := range u.query {
+			if name, ok := strings.CutPrefix(key, "+"); ok && name != "" {
+				if w.Headers == nil {
+					w.Headers = make(map[string]string)
+				}
+				w.Headers[name] = values[0]
+			}
+		}
+		if u.user != "" {
+			auth := base64.StdEncoding.EncodeToString([]byte(u.user + ":" + u.password))
+			w.Headers = withDefaultHeader(w.Headers, "Authorization", "Basic "+auth)
+		}
+
+	default:
+		return w, fmt.Errorf("unsupported service URL scheme %q (must be one of: %s)", u.scheme, strings.Join(serviceURLSchemes, ", "))
+	}
+	return w, nil
+}
+
+// joinPath returns segments as an escaped URL path with a leading slash ("" for none)
+func joinPath(segments []string) string {
+	var b strings.Builder
+	for _, s := range segments {
+		b.WriteString("/" + url.PathEscape(s))
+	}
+	return b.String()
+}
+
+// withDefaultHeader sets name to value in headers unless a "+Name=" query
+// parameter already set it
+func withDefaultHeader(headers map[string]string, name, value string) map[string]string {
+	if headers == nil {
+		headers = make(map[string]string)
+	}
+	for k := range headers {
+		if strings.EqualFold(k, name) {
+			return headers
+		}
+	}
+	headers[name] = value
+	return headers
+}
+
+// ServiceURLName returns the backend name of notifications.urls[i], used by rules
+func ServiceURLName(i int) string {
+	return fmt.Sprintf("urls[%d]", i)
+}
+
+// expandServiceURLs appends a webhook for each valid entry of
+// notifications.urls that has none yet; Validate reports the invalid ones
+func (c *Config) expandServiceURLs() {
+	expanded := make(map[string]bool)
+	for _, w := range c.Notifications.Webhooks {
+		expanded[w.Name] = true
+	}
+	for i, raw := range c.Notifications.URLs {
+		w, err := ParseServiceURL(platform.ExpandEnv(raw))
+		if err != nil || expanded[ServiceURLName(i)] {
+			continue
+		}
+		w.Name = ServiceURLName(i)
+		c.Notifications.Webhooks = append(c.Notifications.Webhooks, w)
+	}
+}
@@ -3,30 +3,71 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
 
+	"github.com/777genius/claude-notifications/internal/breaker"
 	"github.com/777genius/claude-notifications/internal/logging"
 	"github.com/777genius/claude-notifications/internal/platform"
+	"github.com/777genius/claude-notifications/internal/redact"
+	"github.com/777genius/claude-notifications/internal/script"
+	"github.com/777genius/claude-notifications/internal/seal"
 )
 
 // Config represents the plugin configuration
 type Config struct {
 	Notifications NotificationsConfig   `json:"notifications"`
 	Statuses      map[string]StatusInfo `json:"statuses"`
+	Logging       LoggingConfig         `json:"logging"`
+	Metrics       MetricsConfig         `json:"metrics"`
+	Dashboard     DashboardConfig       `json:"dashboard"`
+	API           APIConfig             `json:"api"`
 }
 
 // NotificationsConfig represents notification settings
 type NotificationsConfig struct {
-	Desktop                                     DesktopConfig    `json:"desktop"`
-	Webhook                                     WebhookConfig    `json:"webhook"`
-	SuppressQuestionAfterTaskCompleteSeconds    *int             `json:"suppressQuestionAfterTaskCompleteSeconds"`
-	SuppressQuestionAfterAnyNotificationSeconds *int             `json:"suppressQuestionAfterAnyNotificationSeconds"`
-	NotifyOnSubagentStop                        bool             `json:"notifyOnSubagentStop"`      // Send notifications when subagents (Task tool) complete, default: false
-	SuppressForSubagents                        *bool            `json:"suppressForSubagents"`      // Suppress notifications when transcript_path contains /subagents/, default: true
-	NotifyOnTextResponse                        *bool            `json:"notifyOnTextResponse"`      // Send notifications for text-only responses (no tools), default: true
-	RespectJudgeMode                            *bool            `json:"respectJudgeMode"`          // Honor CLAUDE_HOOK_JUDGE_MODE=true env var to suppress notifications, default: true
-	SuppressFilters                             []SuppressFilter `json:"suppressFilters,omitempty"` // Rules for suppressing notifications by status/branch/folder
+	Desktop                                     DesktopConfig           `json:"desktop"`
+	Webhook                                     WebhookConfig           `json:"webhook"`
+	Webhooks                                    []WebhookConfig         `json:"webhooks,omitempty"` // Additional webhook backends, each with its own preset and route
+	URLs                                        []string                `json:"urls,omitempty"`     // Apprise-style service URLs, each added as a webhook named "urls[N]"
+	Remote                                      RemoteConfig            `json:"remote"`
+	Email                                       EmailConfig             `json:"email"`
+	Speech                                      SpeechConfig            `json:"speech"`
+	MQTT                                        MQTTConfig              `json:"mqtt"`
+	Plugins                                     PluginsConfig           `json:"plugins"`
+	DND                                         DNDConfig               `json:"dnd"`
+	Presence                                    PresenceConfig          `json:"presence"`
+	Escalation                                  EscalationConfig        `json:"escalation"`
+	Digest                                      DigestConfig            `json:"digest"`
+	Heartbeat                                   HeartbeatConfig         `json:"heartbeat"`
+	Stall                                       StallConfig             `json:"stall"`
+	Progress                                    ProgressConfig          `json:"progress"`
+	History                                     HistoryConfig           `json:"history"`
+	Breaker                                     BreakerConfig           `json:"breaker"`
+	Redact                                      RedactConfig            `json:"redact"`
+	Tools                                       ToolsConfig             `json:"tools"`
+	TranscriptSummary                           TranscriptSummaryConfig `json:"transcriptSummary"`
+	SessionSummary                              SessionSummaryConfig    `json:"sessionSummary"`
+	Budget                                      BudgetConfig            `json:"budget"`
+	Content                                     ContentConfig           `json:"content"` // Title and body templates for every backend
+	SuppressQuestionAfterTaskCompleteSeconds    *int                    `json:"suppressQuestionAfterTaskCompleteSeconds"`
+	SuppressQuestionAfterAnyNotificationSeconds *int                    `json:"suppressQuestionAfterAnyNotificationSeconds"`
+	NotifyOnSubagentStop                        bool                    `json:"notifyOnSubagentStop"`      // Send notifications when subagents (Task tool) complete, default: false
+	SuppressForSubagents                        *bool                   `json:"suppressForSubagents"`      // Suppress notifications when transcript_path contains /subagents/, default: true
+	NotifyOnTextResponse                        *bool                   `json:"notifyOnTextResponse"`      // Send notifications for text-only responses (no tools), default: true
+	RespectJudgeMode                            *bool                   `json:"respectJudgeMode"`          // Honor CLAUDE_HOOK_JUDGE_MODE=true env var to suppress notifications, default: true
+	Async                                       *bool                   `json:"async"`                     // Linux: hand hooks to the daemon and return to Claude Code at once, default: true
+	SuppressFilters                             []SuppressFilter        `json:"suppressFilters,omitempty"` // Rules for suppressing notifications by status/branch/folder
+	Rules                                       []Rule                  `json:"rules,omitempty"`           // Match conditions and actions that filter or reshape notifications
+	Script                                      ScriptConfig            `json:"script"`                    // Starlark script run after the rules
 }
 
 // DesktopConfig represents desktop notification settings
@@ -36,22 +77,842 @@ type DesktopConfig struct {
 	TerminalBell     *bool   `json:"terminalBell"`     // Send BEL to /dev/tty for terminal tab indicators (default: true)
 	Volume           float64 `json:"volume"`           // Volume level 0.0-1.0, default 1.0 (full volume)
 	AudioDevice      string  `json:"audioDevice"`      // Audio output device name (empty = system default)
+	SoundPlayer      string  `json:"soundPlayer"`      // "auto" (built-in, else system player), "builtin" or "system" (empty = auto)
+	CriticalSound    string  `json:"criticalSound"`    // Sound file or name for permission prompts (empty = question sound)
 	AppIcon          string  `json:"appIcon"`          // Path to app icon
 	ClickToFocus     bool    `json:"clickToFocus"`     // macOS: activate terminal on notification click (default: true)
+	AutoDismiss      bool    `json:"autoDismiss"`      // Linux daemon: close a session's notifications once the user is back at it
+	WhenFocused      string  `json:"whenFocused"`      // "silent" (no sound) or "skip" when the session's terminal is focused (empty = notify)
+	GroupBySession   bool    `json:"groupBySession"`   // One notification per session, replaced in place, with the project in the title
 	TerminalBundleID string  `json:"terminalBundleId"` // macOS: override auto-detected terminal bundle ID (empty = auto)
+	Urgency          string  `json:"urgency"`          // "low", "normal" or "critical" for every status except errors (empty = by status)
+	// TerminalNotification sends notifications as terminal escape sequences instead of
+	// OS notifications: "auto", "osc9", "osc777", "osc99" (kitty), or "" (disabled)
+	TerminalNotification string `json:"terminalNotification"`
+	// TmuxMessage shows notifications in the tmux status line with
+	// display-message when running inside tmux: "fallback" when no desktop
+	// notification could be shown, "always", or "" (disabled)
+	TmuxMessage string `json:"tmuxMessage"`
+	// WSL sends notifications to the Windows host when running under WSL:
+	// "wsl-notify-send", "powershell" (toast without extra tools), "off"
+	// (Linux notifications only), or "" (wsl-notify-send if installed, else
+	// PowerShell)
+	WSL string `json:"wsl"`
+	// Route restricts desktop notifications to matching events (empty = all)
+	Route RouteConfig `json:"route"`
+	// Content overrides notifications.content for desktop notifications
+	Content ContentConfig `json:"content"`
+	// Privacy limits what desktop notifications show: "full" (default),
+	// "title-only" or "presence-only"
+	Privacy string `json:"privacy"`
+	// Throttle coalesces bursts of notifications (Linux click-to-focus daemon)
+	Throttle ThrottleConfig `json:"throttle"`
+	// Focus controls how the daemon focuses the terminal when a notification is clicked
+	Focus FocusConfig `json:"focus"`
+	// ExecTimeout stops helper commands that hang, e.g. "10s" (empty = 10s):
+	// terminal-notifier, osascript, PowerShell, tmux and zellij
+	ExecTimeout string `json:"execTimeout"`
+	// Terminals declares terminals the built-in tables do not know, or
+	// overrides them, keyed by terminal name (TERM_PROGRAM, or any name
+	// when Detect is set)
+	Terminals map[string]TerminalConfig `json:"terminals"`
+}
+
+// TerminalConfig tells click-to-focus how to find a terminal's windows.
+// Empty fields keep the built-in value for the terminal.
+type TerminalConfig struct {
+	// Detect identifies the terminal by an environment variable the hook
+	// sees: "NAME" when it is set, or "NAME=value" (empty = by name only)
+	Detect       string `json:"detect"`
+	DesktopEntry string `json:"desktopEntry"` // .desktop file ID, e.g. "org.codeberg.dnkl.foot"
+	AppID        string `json:"appId"`        // Wayland app_id
+	WindowClass  string `json:"windowClass"`  // X11 WM_CLASS
+	Title        string `json:"title"`        // Text in the window title, for title-based search
+	BundleID     string `json:"bundleId"`     // macOS bundle identifier
+}
+
+// DefaultExecTimeout is how long a helper command may run unless configured
+const DefaultExecTimeout = 10 * time.Second
+
+// ExecTimeoutDuration returns how long a helper command may run
+func (d DesktopConfig) ExecTimeoutDuration() time.Duration {
+	if v, err := time.ParseDuration(d.ExecTimeout); err == nil && v > 0 {
+		return v
+	}
+	return DefaultExecTimeout
+}
+
+// FocusConfig controls how the Linux click-to-focus daemon tries focus methods.
+// In "race" mode several methods run at once and the first one to work wins.
+type FocusConfig struct {
+	Mode          string `json:"mode"`          // "sequential" or "race" (empty = sequential)
+	Parallel      int    `json:"parallel"`      // Methods running at once in race mode (0 = 3)
+	Timeout       string `json:"timeout"`       // Give up focusing after this long (empty = 300ms racing, 5s sequential)
+	MethodTimeout string `json:"methodTimeout"` // Stop one method after this long, e.g. "2s" (empty = 2s)
+	// PinWindow remembers the window a session starts in and focuses exactly
+	// that window on click (default: true)
+	PinWindow *bool `json:"pinWindow"`
+}
+
+// ThrottleConfig controls how the Linux notification daemon handles bursts.
+// Coalesced notifications replace the one already on screen instead of stacking.
+type ThrottleConfig struct {
+	CoalesceSeconds int `json:"coalesceSeconds"` // Replace a session's notification updated less than N seconds ago (0 = disabled)
+	MaxPerMinute    int `json:"maxPerMinute"`    // New notifications per minute across sessions; more replace the latest one (0 = unlimited)
 }
 
 // WebhookConfig represents webhook settings
 type WebhookConfig struct {
+	Name           string               `json:"name,omitempty"` // Backend name used by rules (additional webhooks; default "webhooks[N]")
 	Enabled        bool                 `json:"enabled"`
 	Preset         string               `json:"preset"`
 	URL            string               `json:"url"`
 	ChatID         string               `json:"chat_id"`
 	Format         string               `json:"format"`
+	Template       string               `json:"template"` // Go text/template for the request body (custom preset only)
+	Timeout        string               `json:"timeout"`  // HTTP request timeout, e.g. "10s" (default: 10s)
 	Headers        map[string]string    `json:"headers"`
 	Retry          RetryConfig          `json:"retry"`
 	CircuitBreaker CircuitBreakerConfig `json:"circuitBreaker"`
 	RateLimit      RateLimitConfig      `json:"rateLimit"`
+	Ntfy           NtfyConfig           `json:"ntfy"`
+	Pushover       PushoverConfig       `json:"pushover"`
+	Gotify         GotifyConfig         `json:"gotify"`
+	Matrix         MatrixConfig         `json:"matrix"`
+	Telegram       TelegramConfig       `json:"telegram"`
+	Slack          SlackConfig          `json:"slack"`
+	Route          RouteConfig          `json:"route"`   // Restricts this webhook to matching events (empty = all)
+	Content        ContentConfig        `json:"content"` // Overrides notifications.content for this webhook
+	Privacy        string               `json:"privacy"` // "full" (default), "title-only" or "presence-only"
+	Encrypt        EncryptConfig        `json:"encrypt"` // Encrypts the content so the service cannot read it
+}
+
+// SlackConfig represents Slack app settings (webhook preset "slack")
+type SlackConfig struct {
+	BotToken string            `json:"botToken"` // Bot token (xoxb-...); posts via chat.postMessage instead of an incoming webhook
+	Channel  string            `json:"channel"`  // Default channel (required with botToken)
+	Channels map[string]string `json:"channels"` // Project folder name -> channel override
+	Mention  string            `json:"mention"`  // Mentioned in critical notifications: "here", "channel", or a user or group ID (empty = nobody)
+}
+
+// TelegramConfig represents Telegram bot settings (webhook preset "telegram")
+type TelegramConfig struct {
+	BotToken      string `json:"botToken"`      // Bot API token; builds the sendMessage URL when url is empty
+	PreviewLength int    `json:"previewLength"` // Max characters of the message preview (0 = 300)
+}
+
+// PushoverConfig represents Pushover settings (webhook preset "pushover")
+type PushoverConfig struct {
+	UserKey    string         `json:"userKey"`    // User or group key
+	AppToken   string         `json:"appToken"`   // Application API token
+	Priorities map[string]int `json:"priorities"` // Per-status priority, -2 (lowest) to 2 (emergency)
+	Retry      int            `json:"retry"`      // Emergency re-alert interval in seconds (min 30, default 60)
+	Expire     int            `json:"expire"`     // Emergency re-alerts stop after this many seconds (max 10800, default 3600)
+	Device     string         `json:"device"`     // Device name (empty = all devices)
+}
+
+// NtfyConfig represents ntfy settings (webhook preset "ntfy")
+type NtfyConfig struct {
+	Topic    string   `json:"topic"`    // Topic to publish to (empty = last path segment of url)
+	Token    string   `json:"token"`    // Access token for protected topics, sent as Bearer auth
+	Priority int      `json:"priority"` // 1 (min) to 5 (max); 0 = by status
+	Tags     []string `json:"tags"`     // Tags or emoji shortcodes shown with the message
+}
+
+// GotifyConfig represents Gotify server settings (webhook preset "gotify")
+type GotifyConfig struct {
+	Token      string         `json:"token"`      // Application token, sent as X-Gotify-Key
+	Priorities map[string]int `json:"priorities"` // Per-status priority, 0 (silent) to 10
+	ClickURL   string         `json:"clickUrl"`   // Go template over the notification fields, opened when the notification is clicked
+	Markdown   bool           `json:"markdown"`   // Render the message as Markdown in the Gotify clients
+}
+
+// MatrixConfig represents Matrix room settings (webhook preset "matrix")
+type MatrixConfig struct {
+	AccessToken string `json:"accessToken"` // Access token of the posting account, sent as Bearer auth
+	RoomID      string `json:"roomId"`      // Room ID like !abc:example.org (not an alias)
+	MsgType     string `json:"msgtype"`     // "m.notice" (default, for bots) or "m.text"
+	Mention     string `json:"mention"`     // Mentioned in critical notifications: "room" or a user ID like @me:example.org (empty = nobody)
+}
+
+// DefaultRemoteAddress is the listener address on both ends of the SSH tunnel
+const DefaultRemoteAddress = "127.0.0.1:9876"
+
+// RemoteConfig represents forwarding of desktop notifications from SSH sessions
+// to a "claude-notifications listen" process on the local machine
+type RemoteConfig struct {
+	Enabled bool   `json:"enabled"`
+	Address string `json:"address"` // host:port of the (SSH-forwarded) listener or unix:<socket path>, default "127.0.0.1:9876" ("host.docker.internal:9876" in a container)
+	Token   string `json:"token"`   // Shared secret checked by the listener (empty = no auth)
+	Name    string `json:"name"`    // Shown on forwarded notifications (empty = the container's name in a container)
+}
+
+// EmailConfig represents SMTP email notification settings
+type EmailConfig struct {
+	Enabled  bool          `json:"enabled"`
+	Host     string        `json:"host"`     // SMTP server host
+	Port     int           `json:"port"`     // Default: 587 (starttls), 465 (tls), 25 (none)
+	Security string        `json:"security"` // "starttls" (default), "tls" (implicit TLS), or "none"
+	Username string        `json:"username"` // Empty = no authentication
+	Password string        `json:"password"` // Supports ${ENV_VAR}, $(command) and keyring:service/account
+	From     string        `json:"from"`
+	To       []string      `json:"to"`
+	Subject  string        `json:"subject"` // Go template over the notification fields (empty = built-in)
+	Body     string        `json:"body"`    // Go template over the notification fields (empty = built-in)
+	Timeout  string        `json:"timeout"` // Connection and delivery timeout, e.g. "30s" (default: 30s)
+	Route    RouteConfig   `json:"route"`   // Restricts email to matching events (empty = all)
+	Content  ContentConfig `json:"content"` // Overrides notifications.content; feeds .Title and .Message of subject and body
+	Privacy  string        `json:"privacy"` // "full" (default), "title-only" or "presence-only"
+}
+
+// SpeechConfig speaks notifications aloud with the platform's text-to-speech:
+// espeak-ng or spd-say on Linux, say on macOS and SAPI on Windows
+type SpeechConfig struct {
+	Enabled bool        `json:"enabled"`
+	Phrase  string      `json:"phrase"`  // Go template over ContentData (empty = "{{.Title}} in {{.Project}}")
+	Voice   string      `json:"voice"`   // Voice of the speech engine, e.g. "Samantha" or "en-us" (empty = system default)
+	Rate    int         `json:"rate"`    // Words per minute (0 = engine default)
+	Route   RouteConfig `json:"route"`   // Restricts speech to matching events (empty = all)
+	Privacy string      `json:"privacy"` // "full" (default), "title-only" or "presence-only"
+}
+
+// MQTTConfig publishes notifications to an MQTT broker, e.g. for Home
+// Assistant automations
+type MQTTConfig struct {
+	Enabled  bool          `json:"enabled"`
+	Broker   string        `json:"broker"`   // mqtt://host:1883 or mqtts://host:8883 (tcp://, ssl:// and tls:// work too)
+	Topic    string        `json:"topic"`    // Go template over the notification fields (empty = "claude-notifications/{{.Status}}")
+	Payload  string        `json:"payload"`  // Go template for the message (empty = JSON with every field)
+	QoS      int           `json:"qos"`      // 0 (at most once, default), 1 (at least once) or 2 (exactly once)
+	Retain   bool          `json:"retain"`   // Broker keeps the last message for new subscribers
+	ClientID string        `json:"clientId"` // Empty = "claude-notifications-" and a random suffix
+	Username string        `json:"username"` // Empty = no authentication; supports ${ENV_VAR}
+	Password string        `json:"password"` // Supports ${ENV_VAR}, $(command) and keyring:service/account
+	TLS      MQTTTLSConfig `json:"tls"`
+	Timeout  string        `json:"timeout"` // Connection and delivery timeout, e.g. "10s" (default: 10s)
+	Route    RouteConfig   `json:"route"`   // Restricts MQTT to matching events (empty = all)
+	Content  ContentConfig `json:"content"` // Overrides notifications.content; feeds .Title and .Message of topic and payload
+	Privacy  string        `json:"privacy"` // "full" (default), "title-only" or "presence-only"
+	Encrypt  EncryptConfig `json:"encrypt"` // Encrypts the content so the broker cannot read it
+}
+
+// PluginBackendPrefix starts the backend name of a plugin, e.g. "plugin:led"
+const PluginBackendPrefix = "plugin:"
+
+// DefaultPluginTimeout stops a plugin that runs longer
+const DefaultPluginTimeout = 10 * time.Second
+
+// PluginsConfig runs the executables of a directory as extra backends,
+// each receiving the notification as JSON on stdin
+type PluginsConfig struct {
+	Enabled  bool                      `json:"enabled"`
+	Dir      string                    `json:"dir"`      // Directory of plugin executables (empty = plugins/ in the config directory)
+	Timeout  string                    `json:"timeout"`  // Stops a plugin that runs longer, e.g. "30s" (default: 10s)
+	Settings map[string]PluginSettings `json:"settings"` // By plugin name, the file name without extension
+}
+
+// PluginSettings configures one plugin
+type PluginSettings struct {
+	Enabled *bool       `json:"enabled"` // false = ignore the executable (default: true)
+	Route   RouteConfig `json:"route"`   // Restricts the plugin to matching events (empty = all)
+	Privacy string      `json:"privacy"` // "full" (default), "title-only" or "presence-only"
+}
+
+// IsEnabled returns true unless the plugin is turned off
+func (s PluginSettings) IsEnabled() bool {
+	return s.Enabled == nil || *s.Enabled
+}
+
+// PluginSettings returns the settings of the plugin with the given
+// backend name ("plugin:<name>")
+func (c *Config) PluginSettings(backend string) PluginSettings {
+	return c.Notifications.Plugins.Settings[strings.TrimPrefix(backend, PluginBackendPrefix)]
+}
+
+// PluginTimeout returns how long a plugin may run
+func (c *Config) PluginTimeout() time.Duration {
+	if d, err := time.ParseDuration(c.Notifications.Plugins.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return DefaultPluginTimeout
+}
+
+// MQTTTLSConfig configures TLS for mqtts:// brokers
+type MQTTTLSConfig struct {
+	CAFile             string `json:"caFile"`             // PEM file of the CA that signed the broker certificate (empty = system roots)
+	CertFile           string `json:"certFile"`           // Client certificate for mutual TLS
+	KeyFile            string `json:"keyFile"`            // Key of the client certificate
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"` // Accept any broker certificate; only for testing
+}
+
+// HistoryConfig represents the delivered-notification history shown by
+// "claude-notifications history"
+type HistoryConfig struct {
+	Enabled    *bool `json:"enabled"`    // Record every delivery attempt (default: true)
+	MaxEntries int   `json:"maxEntries"` // Entries kept on disk, oldest dropped first (0 = unlimited)
+}
+
+// BreakerConfig represents the circuit breakers that skip a failing
+// webhook, email or MQTT backend for a while instead of waiting for it on
+// every hook
+type BreakerConfig struct {
+	Enabled    *bool  `json:"enabled"`    // Skip backends after repeated failures (default: true)
+	Failures   int    `json:"failures"`   // Consecutive failures that open the breaker (default: 3)
+	Backoff    string `json:"backoff"`    // First pause, doubled after each failed probe (default: "1m")
+	MaxBackoff string `json:"maxBackoff"` // Longest pause (default: "1h")
+}
+
+// Breaker defaults
+const (
+	defaultBreakerFailures   = 3
+	defaultBreakerBackoff    = time.Minute
+	defaultBreakerMaxBackoff = time.Hour
+)
+
+// Settings returns the breaker settings, with defaults for unset fields
+func (b BreakerConfig) Settings() breaker.Settings {
+	s := breaker.Settings{Failures: b.Failures, Backoff: defaultBreakerBackoff, MaxBackoff: defaultBreakerMaxBackoff}
+	if s.Failures <= 0 {
+		s.Failures = defaultBreakerFailures
+	}
+	if d, err := time.ParseDuration(b.Backoff); err == nil && d > 0 {
+		s.Backoff = d
+	}
+	if d, err := time.ParseDuration(b.MaxBackoff); err == nil && d > 0 {
+		s.MaxBackoff = d
+	}
+	if s.MaxBackoff < s.Backoff {
+		s.MaxBackoff = s.Backoff
+	}
+	return s
+}
+
+// RedactConfig represents the scrubbing of secrets from notifications that
+// leave the machine: webhooks, email, MQTT and desktop notifications
+// forwarded to a remote listener
+type RedactConfig struct {
+	Enabled     *bool    `json:"enabled"`            // Scrub secrets before sending (default: true)
+	Patterns    []string `json:"patterns,omitempty"` // Regular expressions added to the built-in ones
+	Disable     []string `json:"disable,omitempty"`  // Built-in patterns not to use, e.g. "jwt"
+	Replacement string   `json:"replacement"`        // Replaces each secret (default: "[REDACTED]")
+	HomePaths   bool     `json:"homePaths"`          // Also shorten the home directory to ~ in paths
+}
+
+// Options returns the redactor options of the config
+func (r RedactConfig) Options() redact.Options {
+	opts := redact.Options{Patterns: r.Patterns, Disable: r.Disable, Replacement: r.Replacement}
+	if r.HomePaths {
+		opts.Home, _ = os.UserHomeDir()
+	}
+	return opts
+}
+
+// ToolsConfig announces the use of selected tools with the tool_use status.
+// The PreToolUse and PostToolUse hooks must be registered for these tools
+// ("claude-notifications install-hooks" does so).
+type ToolsConfig struct {
+	Notify    []string `json:"notify,omitempty"`    // Tool names or globs, e.g. "Bash", "WebFetch", "mcp__github__*"
+	When      string   `json:"when,omitempty"`      // "before" (PreToolUse, default), "after" (PostToolUse) or "both"
+	ArgLength int      `json:"argLength,omitempty"` // Longest argument shown, in characters (default: 80)
+}
+
+// ContentConfig formats the notification title and body with Go
+// text/template over ContentData ("" = built-in). A backend's templates
+// replace the global ones field by field.
+type ContentConfig struct {
+	Title string `json:"title,omitempty"` // e.g. "{{.Project}} · {{.Title}}"
+	Body  string `json:"body,omitempty"`  // e.g. "{{.Message}}{{if .Elapsed}} ({{.Elapsed}}){{end}}"
+}
+
+// ContentData is the data available to content templates
+type ContentData struct {
+	Title     string // Status title, after rules
+	Message   string // Message without the session prefix
+	Status    string // e.g. "task_complete"
+	Event     string // Hook event, e.g. "Stop"
+	Project   string // Project folder name
+	Repo      string // Git repository name ("" outside git repos)
+	Branch    string // Git branch ("" outside git repos)
+	Session   string // Session name, e.g. "bold-cat"
+	SessionID string
+	ToolName  string // Tool of PreToolUse/PostToolUse events ("" otherwise)
+	Elapsed   string // Time since the last prompt, e.g. "4m12s" ("" = unknown)
+}
+
+// ContentFuncs are the helpers available in content templates, as in
+// webhook body templates
+var ContentFuncs = template.FuncMap{
+	"truncate": func(s string, limit int) string {
+		if r := []rune(s); len(r) > limit && limit > 0 {
+			return strings.TrimRight(string(r[:limit-1]), " \n") + "…"
+		}
+		return s
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// ParseContentTemplate parses a title or body template ("" = nil)
+func ParseContentTemplate(name, text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	return template.New(name).Funcs(ContentFuncs).Option("missingkey=error").Parse(text)
+}
+
+// Override returns c with the templates of override that are set
+func (c ContentConfig) Override(override ContentConfig) ContentConfig {
+	if override.Title != "" {
+		c.Title = override.Title
+	}
+	if override.Body != "" {
+		c.Body = override.Body
+	}
+	return c
+}
+
+// IsSet reports whether either template is set
+func (c ContentConfig) IsSet() bool {
+	return c.Title != "" || c.Body != ""
+}
+
+// UsesContentTemplates reports whether any backend formats notifications
+// with content templates
+func (c *Config) UsesContentTemplates() bool {
+	n := c.Notifications
+	if n.Content.IsSet() || n.Desktop.Content.IsSet() || n.Webhook.Content.IsSet() || n.Email.Content.IsSet() || n.MQTT.Content.IsSet() {
+		return true
+	}
+	for _, w := range n.Webhooks {
+		if w.Content.IsSet() {
+			return true
+		}
+	}
+	return false
+}
+
+// validate parses both templates
+func (c ContentConfig) validate(scope string) error {
+	if _, err := ParseContentTemplate("title", c.Title); err != nil {
+		return fmt.Errorf("%s content: invalid title template: %w", scope, err)
+	}
+	if _, err := ParseContentTemplate("body", c.Body); err != nil {
+		return fmt.Errorf("%s content: invalid body template: %w", scope, err)
+	}
+	return nil
+}
+
+// TranscriptSummaryConfig adds the gist of Claude's last message to
+// Notification hook messages, which alone are generic ("Claude needs your
+// permission to use Bash")
+type TranscriptSummaryConfig struct {
+	Enabled bool `json:"enabled"` // Read the last assistant message from the transcript (default: false)
+	Length  int  `json:"length"`  // Longest summary, in characters (0 = 120)
+}
+
+// SessionSummaryConfig shows the stats of the whole session when a task
+// or review completes, instead of those of the last response
+type SessionSummaryConfig struct {
+	Enabled bool `json:"enabled"` // Working time, tool calls, edited files, tokens and cost (default: false)
+}
+
+// BudgetConfig sends a budget_exceeded notification once a session uses
+// more tokens or dollars than allowed, counted from its transcript. The
+// PostToolUse hook must be registered for all tools to notice it before
+// the task completes ("claude-notifications install-hooks" does so).
+type BudgetConfig struct {
+	Tokens int     `json:"tokens,omitempty"` // Input, output and cache tokens per session (0 = no limit)
+	Cost   float64 `json:"cost,omitempty"`   // Dollars per session, recorded or estimated from list prices (0 = no limit)
+}
+
+// Enabled reports whether a token or cost budget is set
+func (b BudgetConfig) Enabled() bool {
+	return b.Tokens > 0 || b.Cost > 0
+}
+
+// DefaultTranscriptSummaryLength is the default TranscriptSummaryConfig.Length
+const DefaultTranscriptSummaryLength = 120
+
+// GetLength returns the longest summary shown (default: 120)
+func (t *TranscriptSummaryConfig) GetLength() int {
+	if t.Length <= 0 {
+		return DefaultTranscriptSummaryLength
+	}
+	return t.Length
+}
+
+// DefaultToolArgLength is the default ToolsConfig.ArgLength
+const DefaultToolArgLength = 80
+
+// ShouldNotify returns true if tool's use should be announced for hookEvent
+// ("PreToolUse" or "PostToolUse")
+func (t *ToolsConfig) ShouldNotify(tool, hookEvent string) bool {
+	switch t.When {
+	case "", "before":
+		if hookEvent != "PreToolUse" {
+			return false
+		}
+	case "after":
+		if hookEvent != "PostToolUse" {
+			return false
+		}
+	}
+	for _, pattern := range t.Notify {
+		if ok, _ := path.Match(pattern, tool); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Matcher returns the Claude Code hook matcher (a regular expression)
+// selecting the tools in Notify ("" = none)
+func (t *ToolsConfig) Matcher() string {
+	parts := make([]string, 0, len(t.Notify))
+	for _, pattern := range t.Notify {
+		re := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+		parts = append(parts, strings.ReplaceAll(re, `\?`, "."))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "^(" + strings.Join(parts, "|") + ")$"
+}
+
+// GetArgLength returns the longest tool argument shown (default: 80)
+func (t *ToolsConfig) GetArgLength() int {
+	if t.ArgLength <= 0 {
+		return DefaultToolArgLength
+	}
+	return t.ArgLength
+}
+
+// LoggingConfig controls the log file shown by "claude-notifications logs"
+type LoggingConfig struct {
+	Level     string `json:"level"`     // "debug" (default), "info", "warn" or "error"
+	Format    string `json:"format"`    // "text" (default) or "json", one object per line
+	MaxSizeMB int    `json:"maxSizeMB"` // Rotate the log file at this size (default: 5)
+	MaxFiles  int    `json:"maxFiles"`  // Rotated log files kept (default: 3)
+}
+
+// DefaultMetricsAddress is where the daemon serves metrics unless configured
+const DefaultMetricsAddress = "127.0.0.1:9877"
+
+// MetricsConfig controls the daemon's Prometheus metrics endpoint (Linux)
+type MetricsConfig struct {
+	Enabled bool   `json:"enabled"` // Serve /metrics and report deliveries to the daemon (default: false)
+	Address string `json:"address"` // host:port to listen on (default: 127.0.0.1:9877)
+}
+
+// DefaultDashboardAddress is where the daemon serves the dashboard unless configured
+const DefaultDashboardAddress = "127.0.0.1:9878"
+
+// DashboardConfig controls the daemon's web dashboard (Linux)
+type DashboardConfig struct {
+	Enabled bool   `json:"enabled"` // Serve the dashboard (default: false)
+	Address string `json:"address"` // Loopback host:port to listen on (default: 127.0.0.1:9878)
+}
+
+// MinAPITokenLength is the shortest API token accepted, so it cannot be guessed
+const MinAPITokenLength = 16
+
+// DefaultAPIAddress is where the daemon serves the HTTP API unless configured
+const DefaultAPIAddress = "127.0.0.1:9879"
+
+// APIConfig controls the daemon's HTTP API for other tools (Linux)
+type APIConfig struct {
+	Enabled bool   `json:"enabled"` // Serve the API (default: false)
+	Address string `json:"address"` // host:port to listen on (default: 127.0.0.1:9879)
+	Token   string `json:"token"`   // Bearer token every request must send (required)
+}
+
+// Options returns the logger settings for this config
+func (l LoggingConfig) Options() logging.Options {
+	opts := logging.DefaultOptions()
+	if level, err := logging.ParseLevel(l.Level); err == nil {
+		opts.Level = level
+	}
+	if l.Format != "" {
+		opts.Format = l.Format
+	}
+	if l.MaxSizeMB > 0 {
+		opts.MaxSize = int64(l.MaxSizeMB) * 1024 * 1024
+	}
+	if l.MaxFiles > 0 {
+		opts.MaxFiles = l.MaxFiles
+	}
+	return opts
+}
+
+// DNDConfig represents do-not-disturb settings. DND is active during a
+// scheduled window or after "claude-notifications dnd on".
+type DNDConfig struct {
+	Schedule []DNDWindow `json:"schedule,omitempty"` // Recurring quiet periods
+	Mode     string      `json:"mode"`               // "queue" (default): hold notifications; "downgrade": deliver silently with low urgency
+	Digest   *bool       `json:"digest"`             // Deliver a summary of queued notifications when DND ends (default: true)
+}
+
+// PresenceConfig adapts delivery to whether the user is at the terminal:
+// recent keyboard or mouse input with the session's terminal focused
+type PresenceConfig struct {
+	Enabled      bool   `json:"enabled"`
+	ActiveWithin string `json:"activeWithin"` // Input this recent counts as being at the terminal, e.g. "30s" (empty = 30s)
+	WhenActive   string `json:"whenActive"`   // "downgrade" (default): deliver silently with low priority; "suppress": drop
+}
+
+// DefaultActiveWithin is how recent input must be to count as being at the
+// terminal unless configured
+const DefaultActiveWithin = 30 * time.Second
+
+// ActiveWithinDuration returns activeWithin, or the default when empty or invalid
+func (p *PresenceConfig) ActiveWithinDuration() time.Duration {
+	if d, err := time.ParseDuration(p.ActiveWithin); err == nil && d > 0 {
+		return d
+	}
+	return DefaultActiveWithin
+}
+
+// EscalationConfig holds some backends back until a notification goes
+// unacknowledged: the session sees no activity and its desktop
+// notification is neither clicked nor dismissed
+type EscalationConfig struct {
+	Enabled  bool     `json:"enabled"`
+	After    string   `json:"after"`    // How long to wait before escalating, e.g. "5m" (empty = 5m)
+	Backends []string `json:"backends"` // Backends used only to escalate, e.g. ["webhook"]
+}
+
+// DefaultEscalationAfter is how long a notification waits for an
+// acknowledgement before it escalates unless configured
+const DefaultEscalationAfter = 5 * time.Minute
+
+// AfterDuration returns after, or the default when empty or invalid
+func (e *EscalationConfig) AfterDuration() time.Duration {
+	if d, err := time.ParseDuration(e.After); err == nil && d > 0 {
+		return d
+	}
+	return DefaultEscalationAfter
+}
+
+// Escalates reports whether backend is held back for escalation
+func (e *EscalationConfig) Escalates(backend string) bool {
+	if !e.Enabled {
+		return false
+	}
+	for _, b := range e.Backends {
+		if b == backend {
+			return true
+		}
+	}
+	return false
+}
+
+// DigestConfig batches noisy, low-priority events into one summary
+// notification, delivered every interval or with the next event that is
+// not batched
+type DigestConfig struct {
+	Enabled  bool     `json:"enabled"`
+	Interval string   `json:"interval"` // Deliver the summary at most this often, e.g. "10m" (empty = 10m)
+	Events   []string `json:"events"`   // Hook events or statuses to batch besides low priority (empty = SubagentStop and tool_use)
+}
+
+// HeartbeatConfig reports a session still working on a prompt every
+// interval, updating its previous heartbeat in place rather than stacking
+type HeartbeatConfig struct {
+	Enabled  bool     `json:"enabled"`
+	Interval string   `json:"interval"`           // Time between heartbeats, e.g. "15m" (empty = 15m)
+	Backends []string `json:"backends,omitempty"` // Backends heartbeats go to (empty = desktop)
+}
+
+// DefaultHeartbeatInterval is the time between heartbeats unless configured
+const DefaultHeartbeatInterval = 15 * time.Minute
+
+// MinHeartbeatInterval is the shortest heartbeat interval accepted
+const MinHeartbeatInterval = time.Minute
+
+// IntervalDuration returns the time between heartbeats
+func (h *HeartbeatConfig) IntervalDuration() time.Duration {
+	if d, err := time.ParseDuration(h.Interval); err == nil && d > 0 {
+		return d
+	}
+	return DefaultHeartbeatInterval
+}
+
+// GetBackends returns the backends heartbeats go to (default: desktop)
+func (h *HeartbeatConfig) GetBackends() []string {
+	if len(h.Backends) == 0 {
+		return []string{"desktop"}
+	}
+	return h.Backends
+}
+
+// ProgressConfig keeps one notification per session up to date with its
+// progress on the prompt — the step of its todo list, or its tool calls —
+// as PostToolUse hooks arrive. The PostToolUse hook must be registered for
+// all tools ("claude-notifications install-hooks" does so).
+type ProgressConfig struct {
+	Enabled  bool     `json:"enabled"`
+	Interval string   `json:"interval"`           // Least time between updates that do not advance a step, e.g. "30s" (empty = 30s)
+	Backends []string `json:"backends,omitempty"` // Backends progress goes to (empty = desktop)
+}
+
+// DefaultProgressInterval is the least time between progress updates that
+// do not advance a step unless configured
+const DefaultProgressInterval = 30 * time.Second
+
+// IntervalDuration returns the least time between progress updates that
+// do not advance a step
+func (p *ProgressConfig) IntervalDuration() time.Duration {
+	if d, err := time.ParseDuration(p.Interval); err == nil && d >= 0 {
+		return d
+	}
+	return DefaultProgressInterval
+}
+
+// GetBackends returns the backends progress goes to (default: desktop)
+func (p *ProgressConfig) GetBackends() []string {
+	if len(p.Backends) == 0 {
+		return []string{"desktop"}
+	}
+	return p.Backends
+}
+
+// StallConfig sends a session_stalled notification when a session working
+// on a prompt shows no activity for a while although Claude Code still
+// runs, e.g. because it waits for input and the Notification hook did
+// not fire
+type StallConfig struct {
+	Enabled bool   `json:"enabled"`
+	After   string `json:"after"` // Time without hook events or transcript writes, e.g. "10m" (empty = 10m)
+}
+
+// DefaultStallAfter is how long a session may show no activity before it
+// counts as stalled unless configured
+const DefaultStallAfter = 10 * time.Minute
+
+// MinStallAfter is the shortest stall.after accepted
+const MinStallAfter = time.Minute
+
+// AfterDuration returns how long a session may show no activity
+func (s *StallConfig) AfterDuration() time.Duration {
+	if d, err := time.ParseDuration(s.After); err == nil && d > 0 {
+		return d
+	}
+	return DefaultStallAfter
+}
+
+// DefaultDigestInterval is how long batched events wait for their summary
+// unless configured
+const DefaultDigestInterval = 10 * time.Minute
+
+// defaultDigestEvents are batched when digest.events is empty
+var defaultDigestEvents = []string{"SubagentStop", "tool_use"}
+
+// IntervalDuration returns interval, or the default when empty or invalid
+func (d *DigestConfig) IntervalDuration() time.Duration {
+	if v, err := time.ParseDuration(d.Interval); err == nil && v > 0 {
+		return v
+	}
+	return DefaultDigestInterval
+}
+
+// Batches reports whether an event goes into the digest: it has low
+// priority, or its hook event or status is listed in events. Critical
+// events are never batched.
+func (d *DigestConfig) Batches(hookEvent, status, priority string) bool {
+	if !d.Enabled || priority == "critical" {
+		return false
+	}
+	if priority == "low" {
+		return true
+	}
+	events := d.Events
+	if len(events) == 0 {
+		events = defaultDigestEvents
+	}
+	for _, e := range events {
+		if e == hookEvent || e == status {
+			return true
+		}
+	}
+	return false
+}
+
+// DNDWindow is a recurring quiet period. A window that wraps past midnight
+// belongs to the day it starts on.
+type DNDWindow struct {
+	Days []string `json:"days,omitempty"` // "mon".."sun", "weekdays", "weekends" (empty = every day)
+	Time string   `json:"time,omitempty"` // Clock range, e.g. "22:00-08:00" (empty = all day)
+}
+
+// dndDays maps day names to the weekdays they cover
+var dndDays = map[string][]time.Weekday{
+	"sun":      {time.Sunday},
+	"mon":      {time.Monday},
+	"tue":      {time.Tuesday},
+	"wed":      {time.Wednesday},
+	"thu":      {time.Thursday},
+	"fri":      {time.Friday},
+	"sat":      {time.Saturday},
+	"weekdays": {time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	"weekends": {time.Saturday, time.Sunday},
+}
+
+// onDay returns true if the window applies to the given weekday
+func (w *DNDWindow) onDay(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, name := range w.Days {
+		for _, d := range dndDays[strings.ToLower(name)] {
+			if d == day {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Contains reports whether t falls inside the window
+func (w *DNDWindow) Contains(t time.Time) bool {
+	if w.Time == "" {
+		return w.onDay(t.Weekday())
+	}
+	tw, err := ParseTimeWindow(w.Time)
+	if err != nil {
+		return false
+	}
+	if !tw.Contains(t) {
+		return false
+	}
+	// The early-morning part of an overnight window belongs to the previous day
+	m := t.Hour()*60 + t.Minute()
+	if tw.Start > tw.End && m < tw.End {
+		return w.onDay(t.AddDate(0, 0, -1).Weekday())
+	}
+	return w.onDay(t.Weekday())
+}
+
+// IsScheduled returns true if any schedule window contains t
+func (d *DNDConfig) IsScheduled(t time.Time) bool {
+	for i := range d.Schedule {
+		if d.Schedule[i].Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// ScheduleEnd returns the first minute after t at which no schedule window is
+// active, or the zero time if the schedule never ends within a week
+func (d *DNDConfig) ScheduleEnd(t time.Time) time.Time {
+	next := t.Truncate(time.Minute)
+	for i := 0; i < 8*24*60; i++ {
+		next = next.Add(time.Minute)
+		if !d.IsScheduled(next) {
+			return next
+		}
+	}
+	return time.Time{}
 }
 
 // RetryConfig represents retry settings
@@ -60,6 +921,17 @@ type RetryConfig struct {
 	MaxAttempts    int    `json:"maxAttempts"`
 	InitialBackoff string `json:"initialBackoff"` // e.g. "1s"
 	MaxBackoff     string `json:"maxBackoff"`     // e.g. "10s"
+	Queue          *bool  `json:"queue"`          // Keep deliveries that still fail on disk and retry them later (default: true)
+	QueueMaxAge    string `json:"queueMaxAge"`    // Give up on a queued delivery after this long, e.g. "24h" (default: 24h)
+}
+
+// IsQueueEnabled returns true if deliveries that fail with a temporary
+// error are kept in the retry queue (default: true)
+func (r RetryConfig) IsQueueEnabled() bool {
+	if r.Queue == nil {
+		return true
+	}
+	return *r.Queue
 }
 
 // CircuitBreakerConfig represents circuit breaker settings
@@ -112,22 +984,307 @@ func (f *SuppressFilter) HasConditions() bool {
 	return f.Status != nil || f.GitBranch != nil || f.Folder != nil
 }
 
-// intPtr returns a pointer to the given int value
-func intPtr(v int) *int {
-	return &v
+// EncryptConfig encrypts the content of a webhook or MQTT backend's
+// notifications with a key shared with the receiving side, which reads
+// them with "claude-notifications decrypt"
+type EncryptConfig struct {
+	Key string `json:"key"` // Base64 key from "claude-notifications decrypt --new-key"; supports ${ENV_VAR} ("" = off)
 }
 
-// stringPtr returns a pointer to the given string value
-func stringPtr(v string) *string {
-	return &v
+// validate checks the key of an encrypted backend
+func (e EncryptConfig) validate() error {
+	if e.Key == "" || platform.IsSecretRef(e.Key) {
+		return nil
+	}
+	if _, err := seal.ParseKey(e.Key); err != nil {
+		return fmt.Errorf("encrypt key: %w", err)
+	}
+	return nil
 }
 
-// DefaultConfig returns a config with sensible defaults
-func DefaultConfig() *Config {
-	// Get plugin root from environment, fallback to current directory
-	pluginRoot := platform.ExpandEnv("${CLAUDE_PLUGIN_ROOT}")
-	if pluginRoot == "" || pluginRoot == "${CLAUDE_PLUGIN_ROOT}" {
-		pluginRoot = "."
+// Privacy modes of a backend: how much of each notification it receives
+const (
+	PrivacyFull         = "full"          // Title and message
+	PrivacyTitleOnly    = "title-only"    // Title and project; the message only says the project needs attention
+	PrivacyPresenceOnly = "presence-only" // Only that Claude needs attention
+)
+
+// validatePrivacy checks a backend privacy mode ("" = full)
+func validatePrivacy(privacy string) error {
+	switch privacy {
+	case "", PrivacyFull, PrivacyTitleOnly, PrivacyPresenceOnly:
+		return nil
+	}
+	return fmt.Errorf("privacy must be full, title-only or presence-only (got %q)", privacy)
+}
+
+// RouteConfig restricts a notification backend to matching events.
+// All specified fields must match; an empty route matches every event.
+type RouteConfig struct {
+	Statuses   []string `json:"statuses,omitempty"`   // Status names, e.g. ["question"] (empty = any)
+	Projects   []string `json:"projects,omitempty"`   // Glob patterns over the project folder name or full path (empty = any)
+	MinElapsed string   `json:"minElapsed,omitempty"` // Minimum time the session has been running, e.g. "10m" (empty = any)
+	MinIdle    string   `json:"minIdle,omitempty"`    // Minimum time without keyboard or mouse input, e.g. "5m" (empty = any)
+}
+
+// IsEmpty returns true if the route has no conditions.
+func (r *RouteConfig) IsEmpty() bool {
+	return len(r.Statuses) == 0 && len(r.Projects) == 0 && r.MinElapsed == "" && r.MinIdle == ""
+}
+
+// MatchesIdle returns true if the user has been idle for minIdle. An
+// unknown idle time (0) matches, so escalations are not lost on systems
+// where it cannot be read.
+func (r *RouteConfig) MatchesIdle(idle time.Duration) bool {
+	if r.MinIdle == "" || idle == 0 {
+		return true
+	}
+	minIdle, err := time.ParseDuration(r.MinIdle)
+	return err == nil && idle >= minIdle
+}
+
+// Matches returns true if the event passes every condition of the route.
+// projectPath is the project directory; running is how long the session
+// has been running (0 = unknown, which never satisfies minElapsed).
+func (r *RouteConfig) Matches(status, projectPath string, running time.Duration) bool {
+	if len(r.Statuses) > 0 && !containsString(r.Statuses, status) {
+		return false
+	}
+	if len(r.Projects) > 0 && !matchesAnyProject(r.Projects, projectPath) {
+		return false
+	}
+	if r.MinElapsed != "" {
+		minElapsed, err := time.ParseDuration(r.MinElapsed)
+		if err != nil || running < minElapsed {
+			return false
+		}
+	}
+	return true
+}
+
+// validate checks route statuses, project patterns, minElapsed and minIdle.
+func (r *RouteConfig) validate() error {
+	for _, status := range r.Statuses {
+		if !validStatuses[status] {
+			return fmt.Errorf("route: invalid status %q", status)
+		}
+	}
+	for _, pattern := range r.Projects {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("route: invalid project pattern %q: %w", pattern, err)
+		}
+	}
+	if r.MinElapsed != "" {
+		if d, err := time.ParseDuration(r.MinElapsed); err != nil || d < 0 {
+			return fmt.Errorf("route: invalid minElapsed %q (use a duration like \"10m\")", r.MinElapsed)
+		}
+	}
+	if r.MinIdle != "" {
+		if d, err := time.ParseDuration(r.MinIdle); err != nil || d < 0 {
+			return fmt.Errorf("route: invalid minIdle %q (use a duration like \"5m\")", r.MinIdle)
+		}
+	}
+	return nil
+}
+
+// matchesAnyProject reports whether any glob pattern matches the project's
+// full path or its folder name.
+func matchesAnyProject(patterns []string, projectPath string) bool {
+	if projectPath == "" {
+		return false
+	}
+	p := filepath.ToSlash(projectPath)
+	base := path.Base(p)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString returns true if list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ScriptConfig runs a Starlark script on each notification after the
+// rules: its transform(event) can rewrite or suppress the notification
+type ScriptConfig struct {
+	File     string `json:"file"`     // Path of the script ("" = none); supports ${ENV_VAR}
+	MaxSteps int    `json:"maxSteps"` // Stops a run after this many steps (default: 1000000)
+}
+
+// Rule matches notification events and changes how they are delivered.
+// Rules run in order and every matching rule applies its actions, later
+// rules overriding earlier ones; a suppressing rule drops the notification.
+type Rule struct {
+	Name    string      `json:"name,omitempty"`
+	Match   RuleMatch   `json:"match"`
+	Actions RuleActions `json:"actions"`
+}
+
+// RuleMatch holds the conditions of a rule. All specified fields must match;
+// omitted fields match any value.
+type RuleMatch struct {
+	Events     []string `json:"events,omitempty"`     // Hook events: Stop, SubagentStop, Notification, PreToolUse, PostToolUse (empty = any)
+	Statuses   []string `json:"statuses,omitempty"`   // Status names (empty = any)
+	Projects   []string `json:"projects,omitempty"`   // Glob patterns over the project folder name or full path
+	Message    string   `json:"message,omitempty"`    // Regular expression over the notification message
+	Time       string   `json:"time,omitempty"`       // Local time-of-day window, e.g. "22:00-08:00"
+	MinElapsed string   `json:"minElapsed,omitempty"` // Minimum time since the last prompt, e.g. "10m"
+	MaxElapsed string   `json:"maxElapsed,omitempty"` // Maximum time since the last prompt, e.g. "30s"
+}
+
+// RuleActions holds what a matching rule does to the notification
+type RuleActions struct {
+	Suppress bool     `json:"suppress,omitempty"` // Drop the notification on every backend
+	Urgency  string   `json:"urgency,omitempty"`  // Desktop urgency: "low", "normal" or "critical"
+	Sound    string   `json:"sound,omitempty"`    // Desktop sound file, or "none" for silence
+	Backends []string `json:"backends,omitempty"` // Deliver only to these backends: desktop, webhook, email, speech, mqtt, a webhooks entry name or plugin:<name>
+	Title    string   `json:"title,omitempty"`    // New title; Go template with .Title, .Status, .Event and .Project
+}
+
+// HasActions returns true if the rule changes anything
+func (a *RuleActions) HasActions() bool {
+	return a.Suppress || a.Urgency != "" || a.Sound != "" || len(a.Backends) > 0 || a.Title != ""
+}
+
+// knownBackends are the backend names rules and watchers may route to.
+// "plugin:*" accepts every plugin name.
+type knownBackends map[string]bool
+
+// has reports whether name is a known backend
+func (b knownBackends) has(name string) bool {
+	return b[name] || (strings.HasPrefix(name, PluginBackendPrefix) && len(name) > len(PluginBackendPrefix) && b[PluginBackendPrefix+"*"])
+}
+
+// validate checks conditions and actions; backends lists the known backend names
+func (r *Rule) validate(backends knownBackends) error {
+	m := r.Match
+	for _, event := range m.Events {
+		if !validHookEvents[event] {
+			return fmt.Errorf("invalid event %q (must be one of: Stop, SubagentStop, Notification, PreToolUse, PostToolUse)", event)
+		}
+	}
+	for _, status := range m.Statuses {
+		if !validStatuses[status] {
+			return fmt.Errorf("invalid status %q", status)
+		}
+	}
+	for _, pattern := range m.Projects {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid project pattern %q: %w", pattern, err)
+		}
+	}
+	if m.Message != "" {
+		if _, err := regexp.Compile(m.Message); err != nil {
+			return fmt.Errorf("invalid message regex: %w", err)
+		}
+	}
+	if m.Time != "" {
+		if _, err := ParseTimeWindow(m.Time); err != nil {
+			return err
+		}
+	}
+	for _, d := range []string{m.MinElapsed, m.MaxElapsed} {
+		if d == "" {
+			continue
+		}
+		if v, err := time.ParseDuration(d); err != nil || v < 0 {
+			return fmt.Errorf("invalid elapsed duration %q (use a duration like \"10m\")", d)
+		}
+	}
+
+	a := r.Actions
+	if !a.HasActions() {
+		return fmt.Errorf("must have at least one action (suppress, urgency, sound, backends, or title)")
+	}
+	validUrgency := map[string]bool{"": true, "low": true, "normal": true, "critical": true}
+	if !validUrgency[a.Urgency] {
+		return fmt.Errorf("invalid urgency: %s (must be one of: low, normal, critical)", a.Urgency)
+	}
+	for _, name := range a.Backends {
+		if !backends.has(name) {
+			return fmt.Errorf("unknown backend %q", name)
+		}
+	}
+	if a.Title != "" {
+		if _, err := template.New("title").Parse(a.Title); err != nil {
+			return fmt.Errorf("invalid title template: %w", err)
+		}
+	}
+	return nil
+}
+
+// TimeWindow is a daily clock range such as 22:00-08:00, in minutes since
+// midnight. A window that ends before it starts wraps past midnight.
+type TimeWindow struct {
+	Start int
+	End   int
+}
+
+// ParseTimeWindow parses "HH:MM-HH:MM"
+func ParseTimeWindow(s string) (TimeWindow, error) {
+	start, end, ok := strings.Cut(strings.ReplaceAll(s, " ", ""), "-")
+	if !ok {
+		return TimeWindow{}, fmt.Errorf("invalid time window %q (use HH:MM-HH:MM, e.g. \"22:00-08:00\")", s)
+	}
+	startMin, err1 := parseClock(start)
+	endMin, err2 := parseClock(end)
+	if err1 != nil || err2 != nil {
+		return TimeWindow{}, fmt.Errorf("invalid time window %q (use HH:MM-HH:MM, e.g. \"22:00-08:00\")", s)
+	}
+	return TimeWindow{Start: startMin, End: endMin}, nil
+}
+
+// Contains reports whether the local clock time of t falls inside the window.
+// Start is inclusive and end exclusive; equal start and end cover the whole day.
+func (w TimeWindow) Contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	switch {
+	case w.Start == w.End:
+		return true
+	case w.Start < w.End:
+		return m >= w.Start && m < w.End
+	default:
+		return m >= w.Start || m < w.End
+	}
+}
+
+// parseClock parses "HH:MM" (24-hour) into minutes since midnight
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// intPtr returns a pointer to the given int value
+func intPtr(v int) *int {
+	return &v
+}
+
+// stringPtr returns a pointer to the given string value
+func stringPtr(v string) *string {
+	return &v
+}
+
+// DefaultConfig returns a config with sensible defaults
+func DefaultConfig() *Config {
+	// Get plugin root from environment, fallback to current directory
+	pluginRoot := platform.ExpandEnv("${CLAUDE_PLUGIN_ROOT}")
+	if pluginRoot == "" || pluginRoot == "${CLAUDE_PLUGIN_ROOT}" {
+		pluginRoot = "."
 	}
 
 	return &Config{
@@ -139,6 +1296,10 @@ func DefaultConfig() *Config {
 				AppIcon:      filepath.Join(pluginRoot, "claude_icon.png"),
 				ClickToFocus: true, // macOS: activate terminal on click (default: enabled)
 				// TerminalBundleID: "" - empty means auto-detect
+				Throttle: ThrottleConfig{
+					CoalesceSeconds: 10,
+					MaxPerMinute:    10,
+				},
 			},
 			Webhook: WebhookConfig{
 				Enabled: false,
@@ -164,6 +1325,13 @@ func DefaultConfig() *Config {
 					RequestsPerMinute: 10,
 				},
 			},
+			Remote: RemoteConfig{
+				Enabled: false,
+				Address: defaultRemoteAddress(),
+			},
+			History: HistoryConfig{
+				MaxEntries: 1000,
+			},
 			SuppressQuestionAfterTaskCompleteSeconds:    intPtr(12),
 			SuppressQuestionAfterAnyNotificationSeconds: intPtr(0),
 		},
@@ -196,6 +1364,24 @@ func DefaultConfig() *Config {
 				Title: "🔴 API Error",
 				Sound: filepath.Join(pluginRoot, "sounds", "error.mp3"),
 			},
+			"tool_use": {
+				Title: "🔧 Tool Use",
+				Sound: filepath.Join(pluginRoot, "sounds", "question.mp3"),
+			},
+			"budget_exceeded": {
+				Title: "💸 Budget Exceeded",
+				Sound: filepath.Join(pluginRoot, "sounds", "error.mp3"),
+			},
+			"still_working": {
+				Title: "⏳ Still Working",
+			},
+			"session_stalled": {
+				Title: "⚠️ Session Stalled",
+				Sound: filepath.Join(pluginRoot, "sounds", "question.mp3"),
+			},
+			"in_progress": {
+				Title: "🔄 In Progress",
+			},
 		},
 	}
 }
@@ -208,6 +1394,16 @@ func Load(path string) (*Config, error) {
 		return DefaultConfig(), nil
 	}
 
+	config, err := decodeJSONFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config.finalize()
+	return config, nil
+}
+
+// decodeJSONFile reads a config.json onto the default config
+func decodeJSONFile(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
@@ -217,214 +1413,976 @@ func Load(path string) (*Config, error) {
 	if err := json.Unmarshal(data, config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
+	return config, nil
+}
 
+// finalize expands environment variables and applies defaults once every
+// config source has been read. Command and keyring references are kept
+// for ResolveSecrets.
+func (c *Config) finalize() {
 	// Expand environment variables in paths
-	config.Notifications.Desktop.AppIcon = platform.ExpandEnv(config.Notifications.Desktop.AppIcon)
-	config.Notifications.Webhook.URL = platform.ExpandEnv(config.Notifications.Webhook.URL)
+	c.Notifications.Desktop.AppIcon = platform.ExpandEnv(c.Notifications.Desktop.AppIcon)
+	expandWebhookEnv(&c.Notifications.Webhook)
+	for i := range c.Notifications.Webhooks {
+		expandWebhookEnv(&c.Notifications.Webhooks[i])
+	}
+	c.expandServiceURLs()
+	c.Notifications.Email.Username = expandSecretEnv(c.Notifications.Email.Username)
+	c.Notifications.Email.Password = expandSecretEnv(c.Notifications.Email.Password)
+	c.Notifications.MQTT.Username = expandSecretEnv(c.Notifications.MQTT.Username)
+	c.Notifications.MQTT.Password = expandSecretEnv(c.Notifications.MQTT.Password)
+	c.Notifications.MQTT.Encrypt.Key = expandSecretEnv(c.Notifications.MQTT.Encrypt.Key)
+	c.Notifications.MQTT.TLS.CAFile = platform.ExpandEnv(c.Notifications.MQTT.TLS.CAFile)
+	c.Notifications.MQTT.TLS.CertFile = platform.ExpandEnv(c.Notifications.MQTT.TLS.CertFile)
+	c.Notifications.MQTT.TLS.KeyFile = platform.ExpandEnv(c.Notifications.MQTT.TLS.KeyFile)
+
+	// Expand environment variables in sound paths
+	for status, info := range c.Statuses {
+		info.Sound = platform.ExpandEnv(info.Sound)
+		c.Statuses[status] = info
+	}
+	for i := range c.Notifications.Rules {
+		c.Notifications.Rules[i].Actions.Sound = platform.ExpandEnv(c.Notifications.Rules[i].Actions.Sound)
+	}
+	c.Notifications.Desktop.CriticalSound = platform.ExpandEnv(c.Notifications.Desktop.CriticalSound)
+	c.Notifications.Script.File = platform.ExpandEnv(c.Notifications.Script.File)
+
+	// Apply defaults for missing fields
+	c.ApplyDefaults()
+}
+
+// expandWebhookEnv expands environment variables in webhook URLs and secrets
+func expandWebhookEnv(w *WebhookConfig) {
+	w.URL = expandSecretEnv(w.URL)
+	w.Ntfy.Token = expandSecretEnv(w.Ntfy.Token)
+	w.Pushover.UserKey = expandSecretEnv(w.Pushover.UserKey)
+	w.Pushover.AppToken = expandSecretEnv(w.Pushover.AppToken)
+	w.Gotify.Token = expandSecretEnv(w.Gotify.Token)
+	w.Matrix.AccessToken = expandSecretEnv(w.Matrix.AccessToken)
+	w.Telegram.BotToken = expandSecretEnv(w.Telegram.BotToken)
+	w.Slack.BotToken = expandSecretEnv(w.Slack.BotToken)
+	w.Encrypt.Key = expandSecretEnv(w.Encrypt.Key)
+}
+
+// expandSecretEnv expands environment variables in a credential, leaving a
+// command or keyring reference for ResolveSecrets
+func expandSecretEnv(s string) string {
+	if platform.IsSecretRef(s) {
+		return s
+	}
+	return platform.ExpandEnv(s)
+}
+
+// SecretResolver reads the secret a "$(command)" or
+// "keyring:service/account" reference names, e.g. platform.ResolveSecret
+type SecretResolver func(ref string) (string, error)
+
+// ResolveSecretRef returns value, or the secret it names when it is a
+// command or keyring reference
+func ResolveSecretRef(value string, resolve SecretResolver) (string, error) {
+	if !platform.IsSecretRef(value) {
+		return value, nil
+	}
+	return resolve(value)
+}
+
+// ResolveSecrets replaces the command and keyring references among the
+// credentials of the enabled webhooks, email, MQTT and remote forwarding
+// with the secrets they name, so nothing runs for a disabled backend. A
+// reference that cannot be read leaves its field empty and is returned.
+func (c *Config) ResolveSecrets(resolve SecretResolver) []error {
+	var errs []error
+	c.secretFields(func(value *string, key string) {
+		secret, err := ResolveSecretRef(*value, resolve)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+		*value = secret
+	})
+	// Build the URLs presets derive from a resolved token
+	c.Notifications.Webhook.applyDefaults()
+	for i := range c.Notifications.Webhooks {
+		c.Notifications.Webhooks[i].applyDefaults()
+	}
+	return errs
+}
+
+// SecretRefs returns the command and keyring references ResolveSecrets
+// reads, leaving the config unchanged
+func (c *Config) SecretRefs() []string {
+	var refs []string
+	c.secretFields(func(value *string, key string) {
+		if platform.IsSecretRef(*value) {
+			refs = append(refs, *value)
+		}
+	})
+	return refs
+}
+
+// secretFields calls fn with each credential of the enabled backends and
+// its config key
+func (c *Config) secretFields(fn func(value *string, key string)) {
+	if c.Notifications.Webhook.Enabled {
+		webhookSecretFields(&c.Notifications.Webhook, "webhook", fn)
+	}
+	for i := range c.Notifications.Webhooks {
+		if c.Notifications.Webhooks[i].Enabled {
+			webhookSecretFields(&c.Notifications.Webhooks[i], fmt.Sprintf("webhooks[%d]", i), fn)
+		}
+	}
+	if c.Notifications.Email.Enabled {
+		fn(&c.Notifications.Email.Username, "email.username")
+		fn(&c.Notifications.Email.Password, "email.password")
+	}
+	if c.Notifications.MQTT.Enabled {
+		fn(&c.Notifications.MQTT.Username, "mqtt.username")
+		fn(&c.Notifications.MQTT.Password, "mqtt.password")
+		fn(&c.Notifications.MQTT.Encrypt.Key, "mqtt.encrypt.key")
+	}
+	if c.Notifications.Remote.Enabled {
+		fn(&c.Notifications.Remote.Token, "remote.token")
+	}
+}
+
+// webhookSecretFields calls fn with the URL and credentials of a webhook.
+// Only headers holding a whole reference count, and a header is stored
+// only when fn changed it.
+func webhookSecretFields(w *WebhookConfig, key string, fn func(value *string, key string)) {
+	fn(&w.URL, key+".url")
+	fn(&w.Ntfy.Token, key+".ntfy.token")
+	fn(&w.Pushover.UserKey, key+".pushover.userKey")
+	fn(&w.Pushover.AppToken, key+".pushover.appToken")
+	fn(&w.Gotify.Token, key+".gotify.token")
+	fn(&w.Matrix.AccessToken, key+".matrix.accessToken")
+	fn(&w.Telegram.BotToken, key+".telegram.botToken")
+	fn(&w.Slack.BotToken, key+".slack.botToken")
+	fn(&w.Encrypt.Key, key+".encrypt.key")
+	for name, value := range w.Headers {
+		if !platform.IsSecretRef(value) {
+			continue
+		}
+		resolved := value
+		fn(&resolved, key+".headers."+name)
+		if resolved != value {
+			w.Headers[name] = resolved
+		}
+	}
+}
+
+// GetStableConfigDir returns the stable config directory outside the plugin cache.
+// This directory survives plugin updates (bootstrap.sh rm -rf of cache).
+func GetStableConfigDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	return filepath.Join(home, ".claude", "claude-notifications-go"), nil
+}
+
+// GetStableConfigPath returns the stable config file path outside the plugin cache.
+func GetStableConfigPath() (string, error) {
+	dir, err := GetStableConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+// LoadFromPluginRoot loads configuration with a resilient fallback chain:
+// 1. Stable path (~/.claude/claude-notifications-go/config.json) — preferred
+// 2. Old path (pluginRoot/config/config.json) — fallback, auto-migrates to stable
+// 3. Default config — if neither path has valid config
+//
+// The user config file (~/.config/claude-notifications/config.toml or
+// config.yaml) is then layered on top, followed by CLAUDE_NOTIFICATIONS_*
+// environment overrides. LoadForProject adds a project's config.
+//
+// Corrupted config files are non-fatal: a warning is printed to stderr and
+// logged, then the next source in the chain is tried.
+func LoadFromPluginRoot(pluginRoot string) (*Config, error) {
+	cfg, warnings := LoadWithWarnings(pluginRoot)
+	for _, w := range warnings {
+		msg := fmt.Sprintf("warning: %v", w)
+		fmt.Fprintln(os.Stderr, msg)
+		logging.Warn("%s", msg)
+	}
+	return cfg, nil
+}
+
+// LoadWithWarnings loads configuration like LoadFromPluginRoot, returning
+// the problems it skipped over instead of printing them
+func LoadWithWarnings(pluginRoot string) (*Config, []error) {
+	return LoadForProject(pluginRoot, "")
+}
+
+// LoadForProject loads configuration like LoadWithWarnings, with the
+// .claude-notifications.toml of the project in projectDir (or a parent)
+// layered over the user's config and under environment overrides
+func LoadForProject(pluginRoot, projectDir string) (*Config, []error) {
+	cfg, loaded, warnings := loadBaseConfig(pluginRoot)
+
+	applied, overrideWarnings := applyOverrides(cfg, projectDir, os.Environ())
+	warnings = append(warnings, overrideWarnings...)
+	if loaded || applied {
+		cfg.finalize()
+	}
+	return cfg, warnings
+}
+
+// loadBaseConfig reads config.json through the fallback chain, without
+// expanding environment variables or applying defaults. loaded is false
+// when the defaults are used.
+func loadBaseConfig(pluginRoot string) (cfg *Config, loaded bool, warnings []error) {
+	// 1. Try stable path
+	stablePath, stableErr := GetStableConfigPath()
+	if stableErr != nil {
+		warnings = append(warnings, fmt.Errorf("cannot resolve stable config path: %v, using legacy path only", stableErr))
+	}
+	if stableErr == nil {
+		if platform.FileExists(stablePath) {
+			cfg, err := decodeJSONFile(stablePath)
+			if err != nil {
+				// Corrupted stable config — warn and fall through to old path
+				warnings = append(warnings, fmt.Errorf("failed to load config from %s: %v, trying legacy path", stablePath, err))
+			} else {
+				return cfg, true, warnings
+			}
+		}
+	}
+
+	// 2. Try old path (pluginRoot/config/config.json)
+	oldPath := filepath.Join(pluginRoot, "config", "config.json")
+	if platform.FileExists(oldPath) {
+		cfg, err := decodeJSONFile(oldPath)
+		if err != nil {
+			// Corrupted old config — warn, return defaults (non-fatal)
+			warnings = append(warnings, fmt.Errorf("corrupted config at %s, using defaults", oldPath))
+			return DefaultConfig(), false, warnings
+		}
+
+		// Migrate to stable path (best-effort)
+		if stableErr == nil && stablePath != "" {
+			if migErr := migrateConfig(oldPath, stablePath); migErr != nil {
+				warnings = append(warnings, fmt.Errorf("config migration failed: %v", migErr))
+			}
+		}
+
+		return cfg, true, warnings
+	}
+
+	// 3. Neither path has config — return defaults
+	return DefaultConfig(), false, warnings
+}
+
+// migrateConfig copies config from oldPath to stablePath atomically.
+// Uses temp file + rename in the same directory for safe atomic write.
+func migrateConfig(oldPath, stablePath string) error {
+	data, err := os.ReadFile(oldPath)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(stablePath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	// Create temp file in same dir — guarantees same filesystem for safe os.Rename
+	tmpFile, err := os.CreateTemp(dir, "config-*.json.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath) // cleanup on any error path
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, stablePath)
+}
+
+// ApplyDefaults fills in missing fields with default values
+func (c *Config) ApplyDefaults() {
+	// Desktop defaults
+	if c.Notifications.Desktop.Volume == 0 {
+		c.Notifications.Desktop.Volume = 1.0 // Default to full volume
+	}
+	// AppIcon: Keep empty if not set (no default)
+
+	// Webhook defaults
+	c.Notifications.Webhook.applyDefaults()
+	for i := range c.Notifications.Webhooks {
+		c.Notifications.Webhooks[i].applyDefaults()
+	}
+
+	// Remote forwarding defaults
+	if c.Notifications.Remote.Address == "" {
+		c.Notifications.Remote.Address = defaultRemoteAddress()
+	}
+
+	// Email defaults
+	if c.Notifications.Email.Security == "" {
+		c.Notifications.Email.Security = "starttls"
+	}
+	if c.Notifications.Email.Port == 0 {
+		switch c.Notifications.Email.Security {
+		case "tls":
+			c.Notifications.Email.Port = 465
+		case "none":
+			c.Notifications.Email.Port = 25
+		default:
+			c.Notifications.Email.Port = 587
+		}
+	}
+
+	// DND defaults
+	if c.Notifications.DND.Mode == "" {
+		c.Notifications.DND.Mode = "queue"
+	}
+
+	// Cooldown defaults (nil = not set in config, apply defaults)
+	if c.Notifications.SuppressQuestionAfterTaskCompleteSeconds == nil {
+		c.Notifications.SuppressQuestionAfterTaskCompleteSeconds = intPtr(12)
+	}
+	if c.Notifications.SuppressQuestionAfterAnyNotificationSeconds == nil {
+		c.Notifications.SuppressQuestionAfterAnyNotificationSeconds = intPtr(0) // Disabled by default
+	}
+
+	// Logging defaults
+	if c.Logging.Level == "" {
+		c.Logging.Level = "debug"
+	}
+	if c.Logging.Format == "" {
+		c.Logging.Format = logging.FormatText
+	}
+	if c.Logging.MaxSizeMB == 0 {
+		c.Logging.MaxSizeMB = logging.DefaultMaxSize / (1024 * 1024)
+	}
+	if c.Logging.MaxFiles == 0 {
+		c.Logging.MaxFiles = logging.DefaultMaxFiles
+	}
+
+	// Metrics defaults
+	if c.Metrics.Address == "" {
+		c.Metrics.Address = DefaultMetricsAddress
+	}
+	if c.Dashboard.Address == "" {
+		c.Dashboard.Address = DefaultDashboardAddress
+	}
+	if c.API.Address == "" {
+		c.API.Address = DefaultAPIAddress
+	}
+
+	// Status defaults
+	defaults := DefaultConfig()
+	if c.Statuses == nil {
+		c.Statuses = defaults.Statuses
+	} else {
+		// Fill in missing statuses
+		for key, val := range defaults.Statuses {
+			if _, exists := c.Statuses[key]; !exists {
+				c.Statuses[key] = val
+			}
+		}
+	}
+}
+
+// applyDefaults fills in missing webhook fields, including preset-specific URLs
+func (w *WebhookConfig) applyDefaults() {
+	if w.Preset == "" {
+		w.Preset = "custom"
+	}
+	if w.Format == "" {
+		w.Format = "json"
+	}
+	if w.Headers == nil {
+		w.Headers = make(map[string]string)
+	}
+	if w.Preset == "ntfy" && w.URL == "" {
+		w.URL = "https://ntfy.sh"
+	}
+	// A token read from a command or keyring builds the URL once resolved
+	if w.Preset == "telegram" && w.URL == "" && w.Telegram.BotToken != "" && !platform.IsSecretRef(w.Telegram.BotToken) {
+		w.URL = "https://api.telegram.org/bot" + w.Telegram.BotToken + "/sendMessage"
+	}
+	if w.Preset == "slack" && w.URL == "" && w.Slack.BotToken != "" {
+		w.URL = "https://slack.com/api/chat.postMessage"
+	}
+	if w.Preset == "pushover" && w.URL == "" {
+		w.URL = "https://api.pushover.net/1/messages.json"
+	}
+}
+
+// validHookEvents are the hook events that send notifications
+var validHookEvents = map[string]bool{
+	"Stop":         true,
+	"SubagentStop": true,
+	"Notification": true,
+	"PreToolUse":   true,
+	"PostToolUse":  true,
+}
+
+// validMQTTSchemes are the broker URL schemes, plain TCP or TLS
+var validMQTTSchemes = map[string]bool{"mqtt": true, "tcp": true, "mqtts": true, "ssl": true, "tls": true}
+
+// validStatuses lists the status names accepted in filters, routes and priorities
+var validStatuses = map[string]bool{
+	"task_complete":         true,
+	"review_complete":       true,
+	"question":              true,
+	"plan_ready":            true,
+	"session_limit_reached": true,
+	"api_error":             true,
+	"api_error_overloaded":  true,
+	"tool_use":              true,
+	"budget_exceeded":       true,
+	"still_working":         true,
+	"session_stalled":       true,
+	"in_progress":           true,
+}
+
+// validate checks a single webhook's preset, format, URL and preset settings
+func (w *WebhookConfig) validate() error {
+	// Validate webhook preset (only if webhooks are enabled)
+	validPresets := map[string]bool{
+		"slack":    true,
+		"discord":  true,
+		"telegram": true,
+		"lark":     true,
+		"ntfy":     true,
+		"pushover": true,
+		"gotify":   true,
+		"matrix":   true,
+		"custom":   true,
+	}
+	if w.Enabled && !validPresets[w.Preset] {
+		return fmt.Errorf("invalid webhook preset: %s (must be one of: slack, discord, telegram, lark, ntfy, pushover, gotify, matrix, custom)", w.Preset)
+	}
+
+	// Validate webhook format (only if webhooks are enabled)
+	validFormats := map[string]bool{
+		"json": true,
+		"text": true,
+	}
+	if w.Enabled && !validFormats[w.Format] {
+		return fmt.Errorf("invalid webhook format: %s (must be one of: json, text)", w.Format)
+	}
+
+	// Validate webhook URL if enabled
+	if w.Enabled && w.URL == "" && !(w.Preset == "telegram" && platform.IsSecretRef(w.Telegram.BotToken)) {
+		return fmt.Errorf("webhook URL is required when webhooks are enabled")
+	}
+
+	if w.Retry.QueueMaxAge != "" {
+		if d, err := time.ParseDuration(w.Retry.QueueMaxAge); err != nil || d <= 0 {
+			return fmt.Errorf("retry queueMaxAge must be a positive duration like 24h (got %q)", w.Retry.QueueMaxAge)
+		}
+	}
+
+	// Validate Telegram chat_id if Telegram preset is used
+	if w.Enabled && w.Preset == "telegram" && w.ChatID == "" {
+		return fmt.Errorf("chat_id is required for Telegram webhook")
+	}
+	if previewLength := w.Telegram.PreviewLength; previewLength < 0 || previewLength > 3500 {
+		return fmt.Errorf("telegram previewLength must be between 0 and 3500 (got %d)", previewLength)
+	}
+
+	// Validate Slack Web API settings: chat.postMessage needs a channel
+	if w.Enabled && w.Preset == "slack" &&
+		w.Slack.BotToken != "" && w.Slack.Channel == "" {
+		return fmt.Errorf("slack channel is required when slack botToken is set")
+	}
+
+	// Validate ntfy settings if ntfy preset is used
+	if w.Enabled && w.Preset == "ntfy" {
+		ntfy := w.Ntfy
+		if ntfy.Priority < 0 || ntfy.Priority > 5 {
+			return fmt.Errorf("ntfy priority must be between 1 and 5, or 0 for automatic (got %d)", ntfy.Priority)
+		}
+		if ntfy.Topic == "" && !platform.IsSecretRef(w.URL) {
+			if u, err := url.Parse(w.URL); err != nil || strings.Trim(u.Path, "/") == "" {
+				return fmt.Errorf("ntfy topic is required (set ntfy.topic or use a topic URL like https://ntfy.sh/my-topic)")
+			}
+		}
+	}
+
+	// Validate Pushover settings if Pushover preset is used
+	if w.Enabled && w.Preset == "pushover" {
+		pushover := w.Pushover
+		if pushover.UserKey == "" || pushover.AppToken == "" {
+			return fmt.Errorf("pushover userKey and appToken are required for Pushover webhook")
+		}
+		for status, priority := range pushover.Priorities {
+			if !validStatuses[status] {
+				return fmt.Errorf("pushover priorities: invalid status %q", status)
+			}
+			if priority < -2 || priority > 2 {
+				return fmt.Errorf("pushover priorities[%s] must be between -2 and 2 (got %d)", status, priority)
+			}
+		}
+		if pushover.Retry != 0 && pushover.Retry < 30 {
+			return fmt.Errorf("pushover retry must be at least 30 seconds (got %d)", pushover.Retry)
+		}
+		if pushover.Expire < 0 || pushover.Expire > 10800 {
+			return fmt.Errorf("pushover expire must be between 0 and 10800 seconds (got %d)", pushover.Expire)
+		}
+	}
+
+	// Validate Gotify settings if Gotify preset is used
+	if w.Enabled && w.Preset == "gotify" {
+		if w.Gotify.Token == "" {
+			return fmt.Errorf("gotify token is required for Gotify webhook (an application token from the Gotify web UI)")
+		}
+		for status, priority := range w.Gotify.Priorities {
+			if !validStatuses[status] {
+				return fmt.Errorf("gotify priorities: invalid status %q", status)
+			}
+			if priority < 0 || priority > 10 {
+				return fmt.Errorf("gotify priorities[%s] must be between 0 and 10 (got %d)", status, priority)
+			}
+		}
+	}
+
+	// Validate Matrix settings if Matrix preset is used
+	if w.Enabled && w.Preset == "matrix" {
+		matrix := w.Matrix
+		if matrix.AccessToken == "" {
+			return fmt.Errorf("matrix accessToken is required for Matrix webhook")
+		}
+		if !strings.HasPrefix(matrix.RoomID, "!") {
+			return fmt.Errorf("matrix roomId must be a room ID like !abc:example.org, not an alias (got %q)", matrix.RoomID)
+		}
+		if matrix.MsgType != "" && matrix.MsgType != "m.notice" && matrix.MsgType != "m.text" {
+			return fmt.Errorf("matrix msgtype must be m.notice or m.text (got %q)", matrix.MsgType)
+		}
+		if matrix.Mention != "" && matrix.Mention != "room" && !strings.HasPrefix(matrix.Mention, "@") {
+			return fmt.Errorf("matrix mention must be \"room\" or a user ID like @me:example.org (got %q)", matrix.Mention)
+		}
+	}
+
+	return nil
+}
+
+// Validate validates the configuration
+func (c *Config) Validate() error {
+	// Validate volume
+	if c.Notifications.Desktop.Volume < 0.0 || c.Notifications.Desktop.Volume > 1.0 {
+		return fmt.Errorf("desktop volume must be between 0.0 and 1.0 (got %.2f)", c.Notifications.Desktop.Volume)
+	}
+
+	// Validate terminal notification protocol
+	validTerminalNotifications := map[string]bool{
+		"":       true,
+		"auto":   true,
+		"osc9":   true,
+		"osc777": true,
+		"osc99":  true,
+	}
+	if !validTerminalNotifications[c.Notifications.Desktop.TerminalNotification] {
+		return fmt.Errorf("invalid terminalNotification: %s (must be one of: auto, osc9, osc777, osc99)", c.Notifications.Desktop.TerminalNotification)
+	}
+
+	switch c.Notifications.Desktop.TmuxMessage {
+	case "", "fallback", "always":
+	default:
+		return fmt.Errorf("invalid tmuxMessage: %s (must be one of: fallback, always)", c.Notifications.Desktop.TmuxMessage)
+	}
+
+	switch c.Notifications.Desktop.WSL {
+	case "", "wsl-notify-send", "powershell", "off":
+	default:
+		return fmt.Errorf("invalid wsl: %s (must be one of: wsl-notify-send, powershell, off)", c.Notifications.Desktop.WSL)
+	}
+
+	// Validate sound player
+	switch c.Notifications.Desktop.SoundPlayer {
+	case "", "auto", "builtin", "system":
+	default:
+		return fmt.Errorf("invalid soundPlayer: %s (must be one of: auto, builtin, system)", c.Notifications.Desktop.SoundPlayer)
+	}
+
+	// Validate desktop urgency
+	validUrgency := map[string]bool{"": true, "low": true, "normal": true, "critical": true}
+	if !validUrgency[c.Notifications.Desktop.Urgency] {
+		return fmt.Errorf("invalid desktop urgency: %s (must be one of: low, normal, critical)", c.Notifications.Desktop.Urgency)
+	}
+	validWhenFocused := map[string]bool{"": true, "silent": true, "skip": true}
+	if !validWhenFocused[c.Notifications.Desktop.WhenFocused] {
+		return fmt.Errorf("invalid desktop whenFocused: %s (must be one of: silent, skip)", c.Notifications.Desktop.WhenFocused)
+	}
+
+	// Validate desktop throttling
+	if c.Notifications.Desktop.Throttle.CoalesceSeconds < 0 {
+		return fmt.Errorf("desktop throttle coalesceSeconds must be >= 0 (got %d)", c.Notifications.Desktop.Throttle.CoalesceSeconds)
+	}
+	if c.Notifications.Desktop.Throttle.MaxPerMinute < 0 {
+		return fmt.Errorf("desktop throttle maxPerMinute must be >= 0 (got %d)", c.Notifications.Desktop.Throttle.MaxPerMinute)
+	}
+
+	// Validate focus mode
+	focus := c.Notifications.Desktop.Focus
+	if focus.Mode != "" && focus.Mode != "sequential" && focus.Mode != "race" {
+		return fmt.Errorf("invalid desktop focus mode: %s (must be one of: sequential, race)", focus.Mode)
+	}
+	if focus.Parallel < 0 {
+		return fmt.Errorf("desktop focus parallel must be >= 0 (got %d)", focus.Parallel)
+	}
+	if focus.Timeout != "" {
+		if d, err := time.ParseDuration(focus.Timeout); err != nil || d <= 0 {
+			return fmt.Errorf("desktop focus timeout must be a positive duration like 300ms (got %q)", focus.Timeout)
+		}
+	}
+	if focus.MethodTimeout != "" {
+		if d, err := time.ParseDuration(focus.MethodTimeout); err != nil || d <= 0 {
+			return fmt.Errorf("desktop focus methodTimeout must be a positive duration like 2s (got %q)", focus.MethodTimeout)
+		}
+	}
+	if execTimeout := c.Notifications.Desktop.ExecTimeout; execTimeout != "" {
+		if d, err := time.ParseDuration(execTimeout); err != nil || d <= 0 {
+			return fmt.Errorf("desktop execTimeout must be a positive duration like 10s (got %q)", execTimeout)
+		}
+	}
+
+	for name, t := range c.Notifications.Desktop.Terminals {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("desktop terminals: a terminal name is empty")
+		}
+		if t.Detect != "" && strings.HasPrefix(t.Detect, "=") {
+			return fmt.Errorf("desktop terminal %q: detect must be NAME or NAME=value (got %q)", name, t.Detect)
+		}
+	}
+
+	if c.Notifications.History.MaxEntries < 0 {
+		return fmt.Errorf("history maxEntries must be >= 0 (got %d)", c.Notifications.History.MaxEntries)
+	}
+
+	br := c.Notifications.Breaker
+	if br.Failures < 0 {
+		return fmt.Errorf("breaker failures must be >= 0 (got %d)", br.Failures)
+	}
+	for _, f := range []struct{ name, value string }{{"backoff", br.Backoff}, {"maxBackoff", br.MaxBackoff}} {
+		if f.value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(f.value); err != nil || d <= 0 {
+			return fmt.Errorf("breaker %s must be a positive duration like 5m (got %q)", f.name, f.value)
+		}
+	}
+
+	if c.IsRedactEnabled() {
+		if _, err := redact.New(c.Notifications.Redact.Options()); err != nil {
+			return fmt.Errorf("invalid redact config: %w", err)
+		}
+	}
 
-	// Expand environment variables in sound paths
-	for status, info := range config.Statuses {
-		info.Sound = platform.ExpandEnv(info.Sound)
-		config.Statuses[status] = info
+	tools := c.Notifications.Tools
+	switch tools.When {
+	case "", "before", "after", "both":
+	default:
+		return fmt.Errorf("tools when must be before, after or both (got %q)", tools.When)
+	}
+	for _, pattern := range tools.Notify {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return fmt.Errorf("tools notify: invalid tool pattern %q", pattern)
+		}
+	}
+	if tools.ArgLength < 0 {
+		return fmt.Errorf("tools argLength must be >= 0 (got %d)", tools.ArgLength)
 	}
 
-	// Apply defaults for missing fields
-	config.ApplyDefaults()
+	scopes := []string{"notifications", "desktop", "webhook", "email", "mqtt"}
+	contents := []ContentConfig{c.Notifications.Content, c.Notifications.Desktop.Content, c.Notifications.Webhook.Content, c.Notifications.Email.Content, c.Notifications.MQTT.Content}
+	for i, w := range c.Notifications.Webhooks {
+		scopes = append(scopes, c.ExtraWebhookName(i))
+		contents = append(contents, w.Content)
+	}
+	for i, content := range contents {
+		if err := content.validate(scopes[i]); err != nil {
+			return err
+		}
+	}
 
-	return config, nil
-}
+	if _, err := ParseContentTemplate("phrase", c.Notifications.Speech.Phrase); err != nil {
+		return fmt.Errorf("speech: invalid phrase template: %w", err)
+	}
+	if c.Notifications.Speech.Rate < 0 {
+		return fmt.Errorf("speech rate must be >= 0 (got %d)", c.Notifications.Speech.Rate)
+	}
 
-// GetStableConfigDir returns the stable config directory outside the plugin cache.
-// This directory survives plugin updates (bootstrap.sh rm -rf of cache).
-func GetStableConfigDir() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("cannot determine home directory: %w", err)
+	if c.Notifications.TranscriptSummary.Length < 0 {
+		return fmt.Errorf("transcriptSummary length must be >= 0 (got %d)", c.Notifications.TranscriptSummary.Length)
 	}
-	return filepath.Join(home, ".claude", "claude-notifications-go"), nil
-}
 
-// GetStableConfigPath returns the stable config file path outside the plugin cache.
-func GetStableConfigPath() (string, error) {
-	dir, err := GetStableConfigDir()
-	if err != nil {
-		return "", err
+	// Validate budget
+	if c.Notifications.Budget.Tokens < 0 {
+		return fmt.Errorf("budget tokens must be >= 0 (got %d)", c.Notifications.Budget.Tokens)
+	}
+	if c.Notifications.Budget.Cost < 0 {
+		return fmt.Errorf("budget cost must be >= 0 (got %.2f)", c.Notifications.Budget.Cost)
 	}
-	return filepath.Join(dir, "config.json"), nil
-}
 
-// LoadFromPluginRoot loads configuration with a resilient fallback chain:
-// 1. Stable path (~/.claude/claude-notifications-go/config.json) — preferred
-// 2. Old path (pluginRoot/config/config.json) — fallback, auto-migrates to stable
-// 3. Default config — if neither path has valid config
-//
-// Corrupted config files are non-fatal: a warning is printed to stderr and
-// logged, then the next source in the chain is tried.
-func LoadFromPluginRoot(pluginRoot string) (*Config, error) {
-	// 1. Try stable path
-	stablePath, stableErr := GetStableConfigPath()
-	if stableErr != nil {
-		msg := fmt.Sprintf("warning: cannot resolve stable config path: %v, using legacy path only", stableErr)
-		fmt.Fprintln(os.Stderr, msg)
-		logging.Warn("%s", msg)
+	// Validate logging
+	if c.Logging.Level != "" {
+		if _, err := logging.ParseLevel(c.Logging.Level); err != nil {
+			return fmt.Errorf("logging: %w", err)
+		}
 	}
-	if stableErr == nil {
-		if platform.FileExists(stablePath) {
-			cfg, err := Load(stablePath)
-			if err != nil {
-				// Corrupted stable config — warn and fall through to old path
-				msg := fmt.Sprintf("warning: failed to load config from %s: %v, trying legacy path", stablePath, err)
-				fmt.Fprintln(os.Stderr, msg)
-				logging.Warn("%s", msg)
-			} else {
-				return cfg, nil
-			}
+	if c.Logging.Format != "" && c.Logging.Format != logging.FormatText && c.Logging.Format != logging.FormatJSON {
+		return fmt.Errorf("invalid logging format: %s (must be one of: text, json)", c.Logging.Format)
+	}
+	if c.Logging.MaxSizeMB < 0 {
+		return fmt.Errorf("logging maxSizeMB must be >= 0 (got %d)", c.Logging.MaxSizeMB)
+	}
+	if c.Logging.MaxFiles < 0 {
+		return fmt.Errorf("logging maxFiles must be >= 0 (got %d)", c.Logging.MaxFiles)
+	}
+
+	// Validate metrics
+	if c.Metrics.Enabled && c.Metrics.Address != "" {
+		if _, _, err := net.SplitHostPort(c.Metrics.Address); err != nil {
+			return fmt.Errorf("invalid metrics address %q: %w", c.Metrics.Address, err)
 		}
 	}
 
-	// 2. Try old path (pluginRoot/config/config.json)
-	oldPath := filepath.Join(pluginRoot, "config", "config.json")
-	if platform.FileExists(oldPath) {
-		cfg, err := Load(oldPath)
+	// Validate dashboard: it can focus terminals and mute projects, so it
+	// only listens on the loopback interface
+	if c.Dashboard.Enabled && c.Dashboard.Address != "" {
+		host, _, err := net.SplitHostPort(c.Dashboard.Address)
 		if err != nil {
-			// Corrupted old config — warn, return defaults (non-fatal)
-			msg := fmt.Sprintf("warning: corrupted config at %s, using defaults", oldPath)
-			fmt.Fprintln(os.Stderr, msg)
-			logging.Warn("%s", msg)
-			return DefaultConfig(), nil
+			return fmt.Errorf("invalid dashboard address %q: %w", c.Dashboard.Address, err)
 		}
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return fmt.Errorf("invalid dashboard address %q: must be a loopback address such as 127.0.0.1", c.Dashboard.Address)
+		}
+	}
 
-		// Migrate to stable path (best-effort)
-		if stableErr == nil && stablePath != "" {
-			if migErr := migrateConfig(oldPath, stablePath); migErr != nil {
-				msg := fmt.Sprintf("warning: config migration failed: %v", migErr)
-				fmt.Fprintln(os.Stderr, msg)
-				logging.Warn("%s", msg)
+	// Validate API
+	if c.API.Enabled {
+		if c.API.Address != "" {
+			if _, _, err := net.SplitHostPort(c.API.Address); err != nil {
+				return fmt.Errorf("invalid api address %q: %w", c.API.Address, err)
 			}
 		}
-
-		return cfg, nil
+		if len(c.API.Token) < MinAPITokenLength && !platform.IsSecretRef(c.API.Token) {
+			return fmt.Errorf("api token must be at least %d characters (got %d)", MinAPITokenLength, len(c.API.Token))
+		}
 	}
 
-	// 3. Neither path has config — return defaults
-	return DefaultConfig(), nil
-}
-
-// migrateConfig copies config from oldPath to stablePath atomically.
-// Uses temp file + rename in the same directory for safe atomic write.
-func migrateConfig(oldPath, stablePath string) error {
-	data, err := os.ReadFile(oldPath)
-	if err != nil {
+	// Validate webhooks (primary and additional)
+	if err := c.Notifications.Webhook.validate(); err != nil {
 		return err
 	}
-
-	dir := filepath.Dir(stablePath)
-	if err := os.MkdirAll(dir, 0700); err != nil {
-		return err
+	for i := range c.Notifications.Webhooks {
+		if err := c.Notifications.Webhooks[i].validate(); err != nil {
+			return fmt.Errorf("%s: %w", c.ExtraWebhookName(i), err)
+		}
 	}
-
-	// Create temp file in same dir — guarantees same filesystem for safe os.Rename
-	tmpFile, err := os.CreateTemp(dir, "config-*.json.tmp")
-	if err != nil {
-		return err
+	for i, raw := range c.Notifications.URLs {
+		if _, err := ParseServiceURL(platform.ExpandEnv(raw)); err != nil {
+			return fmt.Errorf("%s: %w", ServiceURLName(i), err)
+		}
 	}
-	tmpPath := tmpFile.Name()
-	defer os.Remove(tmpPath) // cleanup on any error path
 
-	if _, err := tmpFile.Write(data); err != nil {
-		tmpFile.Close()
-		return err
+	// Validate backend routes
+	if err := c.Notifications.Desktop.Route.validate(); err != nil {
+		return fmt.Errorf("desktop %w", err)
 	}
-	if err := tmpFile.Sync(); err != nil {
-		tmpFile.Close()
-		return err
+	if err := c.Notifications.Webhook.Route.validate(); err != nil {
+		return fmt.Errorf("webhook %w", err)
 	}
-	if err := tmpFile.Close(); err != nil {
-		return err
+	for i := range c.Notifications.Webhooks {
+		if err := c.Notifications.Webhooks[i].Route.validate(); err != nil {
+			return fmt.Errorf("webhooks[%d] %w", i, err)
+		}
 	}
-	if err := os.Chmod(tmpPath, 0600); err != nil {
-		return err
+	if err := c.Notifications.Email.Route.validate(); err != nil {
+		return fmt.Errorf("email %w", err)
+	}
+	if err := c.Notifications.Speech.Route.validate(); err != nil {
+		return fmt.Errorf("speech %w", err)
+	}
+	if err := c.Notifications.MQTT.Route.validate(); err != nil {
+		return fmt.Errorf("mqtt %w", err)
+	}
+	for name, settings := range c.Notifications.Plugins.Settings {
+		if err := settings.Route.validate(); err != nil {
+			return fmt.Errorf("plugins.settings.%s %w", name, err)
+		}
 	}
-	return os.Rename(tmpPath, stablePath)
-}
 
-// ApplyDefaults fills in missing fields with default values
-func (c *Config) ApplyDefaults() {
-	// Desktop defaults
-	if c.Notifications.Desktop.Volume == 0 {
-		c.Notifications.Desktop.Volume = 1.0 // Default to full volume
+	// Validate backend privacy modes
+	privacy := []struct{ name, value string }{
+		{"desktop", c.Notifications.Desktop.Privacy},
+		{"webhook", c.Notifications.Webhook.Privacy},
+		{"email", c.Notifications.Email.Privacy},
+		{"speech", c.Notifications.Speech.Privacy},
+		{"mqtt", c.Notifications.MQTT.Privacy},
+	}
+	for i, w := range c.Notifications.Webhooks {
+		privacy = append(privacy, struct{ name, value string }{fmt.Sprintf("webhooks[%d]", i), w.Privacy})
+	}
+	for name, settings := range c.Notifications.Plugins.Settings {
+		privacy = append(privacy, struct{ name, value string }{"plugins.settings." + name, settings.Privacy})
+	}
+	for _, p := range privacy {
+		if err := validatePrivacy(p.value); err != nil {
+			return fmt.Errorf("%s %w", p.name, err)
+		}
 	}
-	// AppIcon: Keep empty if not set (no default)
 
-	// Webhook defaults
-	if c.Notifications.Webhook.Preset == "" {
-		c.Notifications.Webhook.Preset = "custom"
+	// Validate encryption keys
+	if err := c.Notifications.Webhook.Encrypt.validate(); err != nil {
+		return fmt.Errorf("webhook %w", err)
 	}
-	if c.Notifications.Webhook.Format == "" {
-		c.Notifications.Webhook.Format = "json"
+	for i := range c.Notifications.Webhooks {
+		if err := c.Notifications.Webhooks[i].Encrypt.validate(); err != nil {
+			return fmt.Errorf("webhooks[%d] %w", i, err)
+		}
 	}
-	if c.Notifications.Webhook.Headers == nil {
-		c.Notifications.Webhook.Headers = make(map[string]string)
+	if err := c.Notifications.MQTT.Encrypt.validate(); err != nil {
+		return fmt.Errorf("mqtt %w", err)
 	}
 
-	// Cooldown defaults (nil = not set in config, apply defaults)
-	if c.Notifications.SuppressQuestionAfterTaskCompleteSeconds == nil {
-		c.Notifications.SuppressQuestionAfterTaskCompleteSeconds = intPtr(12)
+	// Validate remote listener address if forwarding is enabled
+	if c.Notifications.Remote.Enabled {
+		if path, ok := strings.CutPrefix(c.Notifications.Remote.Address, "unix:"); ok {
+			if path == "" {
+				return fmt.Errorf("invalid remote address %q (missing socket path)", c.Notifications.Remote.Address)
+			}
+		} else if _, _, err := net.SplitHostPort(c.Notifications.Remote.Address); err != nil {
+			return fmt.Errorf("invalid remote address %q (must be host:port or unix:<path>): %w", c.Notifications.Remote.Address, err)
+		}
 	}
-	if c.Notifications.SuppressQuestionAfterAnyNotificationSeconds == nil {
-		c.Notifications.SuppressQuestionAfterAnyNotificationSeconds = intPtr(0) // Disabled by default
+
+	// Validate email settings if email is enabled
+	if c.Notifications.Email.Enabled {
+		email := c.Notifications.Email
+		validSecurity := map[string]bool{"starttls": true, "tls": true, "none": true}
+		if !validSecurity[email.Security] {
+			return fmt.Errorf("invalid email security: %s (must be one of: starttls, tls, none)", email.Security)
+		}
+		if email.Host == "" {
+			return fmt.Errorf("email host is required when email is enabled")
+		}
+		if email.Port < 1 || email.Port > 65535 {
+			return fmt.Errorf("email port must be between 1 and 65535 (got %d)", email.Port)
+		}
+		if _, err := mail.ParseAddress(email.From); err != nil {
+			return fmt.Errorf("invalid email from address %q: %w", email.From, err)
+		}
+		if len(email.To) == 0 {
+			return fmt.Errorf("email requires at least one recipient in to")
+		}
+		for _, to := range email.To {
+			if _, err := mail.ParseAddress(to); err != nil {
+				return fmt.Errorf("invalid email to address %q: %w", to, err)
+			}
+		}
 	}
 
-	// Status defaults
-	defaults := DefaultConfig()
-	if c.Statuses == nil {
-		c.Statuses = defaults.Statuses
-	} else {
-		// Fill in missing statuses
-		for key, val := range defaults.Statuses {
-			if _, exists := c.Statuses[key]; !exists {
-				c.Statuses[key] = val
+	// Validate MQTT settings if MQTT is enabled
+	if c.Notifications.MQTT.Enabled {
+		mqtt := c.Notifications.MQTT
+		if u, err := url.Parse(mqtt.Broker); err != nil || u.Host == "" || !validMQTTSchemes[u.Scheme] {
+			return fmt.Errorf("invalid mqtt broker %q (use mqtt://host:1883 or mqtts://host:8883)", mqtt.Broker)
+		}
+		if mqtt.QoS < 0 || mqtt.QoS > 2 {
+			return fmt.Errorf("mqtt qos must be 0, 1 or 2 (got %d)", mqtt.QoS)
+		}
+		if t := mqtt.Timeout; t != "" {
+			if d, err := time.ParseDuration(t); err != nil || d <= 0 {
+				return fmt.Errorf("invalid mqtt timeout %q (use a duration like \"10s\")", t)
 			}
 		}
+		if (mqtt.TLS.CertFile == "") != (mqtt.TLS.KeyFile == "") {
+			return fmt.Errorf("mqtt tls needs both certFile and keyFile for a client certificate")
+		}
 	}
-}
 
-// Validate validates the configuration
-func (c *Config) Validate() error {
-	// Validate volume
-	if c.Notifications.Desktop.Volume < 0.0 || c.Notifications.Desktop.Volume > 1.0 {
-		return fmt.Errorf("desktop volume must be between 0.0 and 1.0 (got %.2f)", c.Notifications.Desktop.Volume)
+	// Validate presence detection
+	validWhenActive := map[string]bool{"": true, "downgrade": true, "suppress": true}
+	if !validWhenActive[c.Notifications.Presence.WhenActive] {
+		return fmt.Errorf("invalid presence whenActive: %s (must be one of: downgrade, suppress)", c.Notifications.Presence.WhenActive)
+	}
+	if a := c.Notifications.Presence.ActiveWithin; a != "" {
+		if d, err := time.ParseDuration(a); err != nil || d <= 0 {
+			return fmt.Errorf("invalid presence activeWithin %q (use a duration like \"30s\")", a)
+		}
 	}
 
-	// Validate webhook preset (only if webhooks are enabled)
-	validPresets := map[string]bool{
-		"slack":    true,
-		"discord":  true,
-		"telegram": true,
-		"lark":     true,
-		"custom":   true,
+	// Validate escalation
+	if a := c.Notifications.Escalation.After; a != "" {
+		if d, err := time.ParseDuration(a); err != nil || d <= 0 {
+			return fmt.Errorf("invalid escalation after %q (use a duration like \"5m\")", a)
+		}
 	}
-	if c.Notifications.Webhook.Enabled && !validPresets[c.Notifications.Webhook.Preset] {
-		return fmt.Errorf("invalid webhook preset: %s (must be one of: slack, discord, telegram, lark, custom)", c.Notifications.Webhook.Preset)
+	if c.Notifications.Escalation.Enabled && len(c.Notifications.Escalation.Backends) == 0 {
+		return fmt.Errorf("escalation requires at least one backend in backends")
 	}
 
-	// Validate webhook format (only if webhooks are enabled)
-	validFormats := map[string]bool{
-		"json": true,
-		"text": true,
+	// Validate digest
+	if i := c.Notifications.Digest.Interval; i != "" {
+		if d, err := time.ParseDuration(i); err != nil || d <= 0 {
+			return fmt.Errorf("invalid digest interval %q (use a duration like \"10m\")", i)
+		}
 	}
-	if c.Notifications.Webhook.Enabled && !validFormats[c.Notifications.Webhook.Format] {
-		return fmt.Errorf("invalid webhook format: %s (must be one of: json, text)", c.Notifications.Webhook.Format)
+	for _, e := range c.Notifications.Digest.Events {
+		if !validHookEvents[e] && !validStatuses[e] {
+			return fmt.Errorf("invalid digest event %q (must be a hook event such as SubagentStop, or a status)", e)
+		}
 	}
 
-	// Validate webhook URL if enabled
-	if c.Notifications.Webhook.Enabled && c.Notifications.Webhook.URL == "" {
-		return fmt.Errorf("webhook URL is required when webhooks are enabled")
+	// Validate heartbeat
+	if i := c.Notifications.Heartbeat.Interval; i != "" {
+		if d, err := time.ParseDuration(i); err != nil || d < MinHeartbeatInterval {
+			return fmt.Errorf("invalid heartbeat interval %q (use a duration of at least 1m, like \"15m\")", i)
+		}
 	}
 
-	// Validate Telegram chat_id if Telegram preset is used
-	if c.Notifications.Webhook.Enabled && c.Notifications.Webhook.Preset == "telegram" && c.Notifications.Webhook.ChatID == "" {
-		return fmt.Errorf("chat_id is required for Telegram webhook")
+	// Validate progress
+	if i := c.Notifications.Progress.Interval; i != "" {
+		if d, err := time.ParseDuration(i); err != nil || d < 0 {
+			return fmt.Errorf("invalid progress interval %q (use a duration like \"30s\")", i)
+		}
+	}
+
+	// Validate stall detection
+	if a := c.Notifications.Stall.After; a != "" {
+		if d, err := time.ParseDuration(a); err != nil || d < MinStallAfter {
+			return fmt.Errorf("invalid stall after %q (use a duration of at least 1m, like \"10m\")", a)
+		}
+	}
+
+	// Validate do-not-disturb settings
+	validDNDModes := map[string]bool{"": true, "queue": true, "downgrade": true}
+	if !validDNDModes[c.Notifications.DND.Mode] {
+		return fmt.Errorf("invalid dnd mode: %s (must be one of: queue, downgrade)", c.Notifications.DND.Mode)
+	}
+	for i, w := range c.Notifications.DND.Schedule {
+		if len(w.Days) == 0 && w.Time == "" {
+			return fmt.Errorf("dnd.schedule[%d]: must set days, time, or both", i)
+		}
+		for _, day := range w.Days {
+			if _, ok := dndDays[strings.ToLower(day)]; !ok {
+				return fmt.Errorf("dnd.schedule[%d]: invalid day %q (use mon..sun, weekdays, or weekends)", i, day)
+			}
+		}
+		if w.Time != "" {
+			if _, err := ParseTimeWindow(w.Time); err != nil {
+				return fmt.Errorf("dnd.schedule[%d]: %w", i, err)
+			}
+		}
 	}
 
 	// Validate cooldowns (both fields, if explicitly set)
@@ -436,15 +2394,6 @@ func (c *Config) Validate() error {
 	}
 
 	// Validate suppress-filters
-	validStatuses := map[string]bool{
-		"task_complete":         true,
-		"review_complete":       true,
-		"question":              true,
-		"plan_ready":            true,
-		"session_limit_reached": true,
-		"api_error":             true,
-		"api_error_overloaded":  true,
-	}
 	for i, f := range c.Notifications.SuppressFilters {
 		if !f.HasConditions() {
 			return fmt.Errorf("suppressFilters[%d]: must have at least one condition (status, gitBranch, or folder)", i)
@@ -454,6 +2403,61 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Validate plugins
+	if t := c.Notifications.Plugins.Timeout; t != "" {
+		if d, err := time.ParseDuration(t); err != nil || d <= 0 {
+			return fmt.Errorf("plugins.timeout: invalid duration %q (use a duration like \"30s\")", t)
+		}
+	}
+
+	// Validate rules, including the backends they route to. Plugins are
+	// found when a hook runs, so any plugin name is accepted.
+	backends := knownBackends{"desktop": true, "webhook": true, "email": true, "speech": true, "mqtt": true}
+	if c.Notifications.Plugins.Enabled {
+		backends[PluginBackendPrefix+"*"] = true
+	}
+	for i := range c.Notifications.Webhooks {
+		name := c.ExtraWebhookName(i)
+		if backends[name] {
+			return fmt.Errorf("webhooks[%d]: duplicate backend name %q", i, name)
+		}
+		if strings.HasPrefix(name, PluginBackendPrefix) {
+			return fmt.Errorf("webhooks[%d]: name %q is reserved for plugins", i, name)
+		}
+		backends[name] = true
+	}
+	for i := range c.Notifications.Rules {
+		if err := c.Notifications.Rules[i].validate(backends); err != nil {
+			return fmt.Errorf("rules[%d]: %w", i, err)
+		}
+	}
+	if sc := c.Notifications.Script; sc.File != "" {
+		if sc.MaxSteps < 0 {
+			return fmt.Errorf("script.maxSteps must be >= 0")
+		}
+		if _, err := script.Load(sc.File, sc.MaxSteps); err != nil {
+			return fmt.Errorf("script: %w", err)
+		}
+	}
+	for _, name := range c.Notifications.Escalation.Backends {
+		if !backends.has(name) {
+			return fmt.Errorf("escalation: unknown backend %q", name)
+		}
+		if name == "desktop" {
+			return fmt.Errorf("escalation: desktop cannot be an escalation backend, it is the first notification")
+		}
+	}
+	for _, name := range c.Notifications.Heartbeat.Backends {
+		if !backends.has(name) {
+			return fmt.Errorf("heartbeat: unknown backend %q", name)
+		}
+	}
+	for _, name := range c.Notifications.Progress.Backends {
+		if !backends.has(name) {
+			return fmt.Errorf("progress: unknown backend %q", name)
+		}
+	}
+
 	return nil
 }
 
@@ -473,9 +2477,99 @@ func (c *Config) IsWebhookEnabled() bool {
 	return c.Notifications.Webhook.Enabled
 }
 
+// defaultRemoteAddress is where the listener is reached: through the SSH
+// tunnel on the loopback address, or from a container on the host, outside
+// its network
+func defaultRemoteAddress() string {
+	if platform.IsContainer() {
+		return "host.docker.internal:9876"
+	}
+	return DefaultRemoteAddress
+}
+
+// IsRemoteForwardingActive returns true if desktop notifications should be forwarded
+// to the local listener: forwarding is enabled and the hook runs inside an
+// SSH session or a container
+func (c *Config) IsRemoteForwardingActive() bool {
+	return c.Notifications.Remote.Enabled && (platform.IsSSHSession() || platform.IsContainer())
+}
+
+// RemoteSource returns the name shown on forwarded notifications:
+// remote.name, else the container's name inside a container ("" = none)
+func (c *Config) RemoteSource() string {
+	if name := c.Notifications.Remote.Name; name != "" {
+		return name
+	}
+	if platform.IsContainer() {
+		return platform.ContainerName()
+	}
+	return ""
+}
+
+// ExtraWebhookName returns the backend name of notifications.webhooks[i]:
+// its configured name, or "webhooks[i]"
+func (c *Config) ExtraWebhookName(i int) string {
+	if name := c.Notifications.Webhooks[i].Name; name != "" {
+		return name
+	}
+	return fmt.Sprintf("webhooks[%d]", i)
+}
+
+// HasExtraWebhooks returns true if any additional webhook in notifications.webhooks is enabled
+func (c *Config) HasExtraWebhooks() bool {
+	for i := range c.Notifications.Webhooks {
+		if c.Notifications.Webhooks[i].Enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEmailEnabled returns true if email notifications are enabled
+func (c *Config) IsEmailEnabled() bool {
+	return c.Notifications.Email.Enabled
+}
+
+// IsSpeechEnabled returns true if spoken notifications are enabled
+func (c *Config) IsSpeechEnabled() bool {
+	return c.Notifications.Speech.Enabled
+}
+
+// IsMQTTEnabled returns true if notifications are published over MQTT
+func (c *Config) IsMQTTEnabled() bool {
+	return c.Notifications.MQTT.Enabled
+}
+
+// UsesIdle returns true if presence detection or a backend route needs the
+// time since the last keyboard or mouse input
+func (c *Config) UsesIdle() bool {
+	if c.Notifications.Presence.Enabled {
+		return true
+	}
+	routes := []RouteConfig{c.Notifications.Desktop.Route, c.Notifications.Webhook.Route, c.Notifications.Email.Route, c.Notifications.Speech.Route, c.Notifications.MQTT.Route}
+	for _, w := range c.Notifications.Webhooks {
+		routes = append(routes, w.Route)
+	}
+	for _, p := range c.Notifications.Plugins.Settings {
+		routes = append(routes, p.Route)
+	}
+	for _, r := range routes {
+		if r.MinIdle != "" {
+			return true
+		}
+	}
+	return false
+}
+
 // IsAnyNotificationEnabled returns true if at least one notification method is enabled
 func (c *Config) IsAnyNotificationEnabled() bool {
-	return c.IsDesktopEnabled() || c.IsWebhookEnabled()
+	return c.IsDesktopEnabled() || c.IsWebhookEnabled() || c.IsEmailEnabled() || c.IsSpeechEnabled() || c.IsMQTTEnabled() || c.HasExtraWebhooks() || c.ArePluginsEnabled()
+}
+
+// ArePluginsEnabled returns true if the executables of the plugins
+// directory receive notifications
+func (c *Config) ArePluginsEnabled() bool {
+	return c.Notifications.Plugins.Enabled
 }
 
 // GetSuppressQuestionAfterTaskCompleteSeconds returns the cooldown in seconds
@@ -542,6 +2636,15 @@ func (c *Config) IsTerminalBellEnabled() bool {
 	return *c.Notifications.Desktop.TerminalBell
 }
 
+// IsWindowPinningEnabled returns true if sessions remember the window they
+// started in for click-to-focus (default: true)
+func (c *Config) IsWindowPinningEnabled() bool {
+	if c.Notifications.Desktop.Focus.PinWindow == nil {
+		return true // Default: enabled
+	}
+	return *c.Notifications.Desktop.Focus.PinWindow
+}
+
 // IsStatusDesktopEnabled returns true if desktop notifications for this status are enabled
 // Considers both global desktop.enabled and per-status enabled
 func (c *Config) IsStatusDesktopEnabled(status string) bool {
@@ -554,6 +2657,51 @@ func (c *Config) IsStatusWebhookEnabled(status string) bool {
 	return c.IsWebhookEnabled() && c.IsStatusEnabled(status)
 }
 
+// IsDNDDigestEnabled returns true if queued notifications should be summarized
+// when do-not-disturb ends (default: true)
+func (c *Config) IsDNDDigestEnabled() bool {
+	if c.Notifications.DND.Digest == nil {
+		return true
+	}
+	return *c.Notifications.DND.Digest
+}
+
+// IsHistoryEnabled returns true if deliveries should be recorded in the
+// notification history (default: true)
+func (c *Config) IsHistoryEnabled() bool {
+	if c.Notifications.History.Enabled == nil {
+		return true
+	}
+	return *c.Notifications.History.Enabled
+}
+
+// IsAsyncEnabled returns true if hooks are handed to the daemon instead of
+// delivering before Claude Code continues (default: true)
+func (c *Config) IsAsyncEnabled() bool {
+	if c.Notifications.Async == nil {
+		return true
+	}
+	return *c.Notifications.Async
+}
+
+// IsBreakerEnabled returns true if failing backends are skipped for a while
+// after repeated failures (default: true)
+func (c *Config) IsBreakerEnabled() bool {
+	if c.Notifications.Breaker.Enabled == nil {
+		return true
+	}
+	return *c.Notifications.Breaker.Enabled
+}
+
+// IsRedactEnabled returns true if secrets are scrubbed from notifications
+// sent off the machine (default: true)
+func (c *Config) IsRedactEnabled() bool {
+	if c.Notifications.Redact.Enabled == nil {
+		return true
+	}
+	return *c.Notifications.Redact.Enabled
+}
+
 // ShouldFilter returns true if any suppress-filter rule matches the given context.
 // When true, the notification should be suppressed entirely (both desktop and webhook).
 func (c *Config) ShouldFilter(status, gitBranch, folder string) bool {
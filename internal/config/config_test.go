@@ -1,20 +1,28 @@
 package config
 
 import (
+	"errors"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/777genius/claude-notifications/internal/breaker"
+	"github.com/777genius/claude-notifications/internal/platform"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 // setTestHome sets HOME (and USERPROFILE on Windows) so that
 // os.UserHomeDir() returns the given directory on all platforms.
+// XDG_CONFIG_HOME is cleared so the user config file is looked up there too.
 func setTestHome(t *testing.T, dir string) {
 	t.Helper()
 	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", "")
 	if runtime.GOOS == "windows" {
 		t.Setenv("USERPROFILE", dir)
 	}
@@ -327,6 +335,94 @@ func TestLoadFromPluginRoot_WithEnvironmentVariables(t *testing.T) {
 	assert.Equal(t, "https://example.com/hook", cfg.Notifications.Webhook.URL)
 }
 
+func TestLoadWithWarnings_KeepsSecretRefs(t *testing.T) {
+	setTestHome(t, t.TempDir())
+	t.Setenv("TEST_NTFY_TOKEN", "tk_from_env")
+
+	tmpDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "config"), 0755))
+	configJSON := `{
+		"notifications": {
+			"webhook": {
+				"enabled": true,
+				"preset": "telegram",
+				"chat_id": "42",
+				"telegram": {"botToken": "keyring:claude/telegram"},
+				"ntfy": {"token": "${TEST_NTFY_TOKEN}"}
+			},
+			"email": {"password": "$(pass show email)"}
+		},
+		"api": {"enabled": true, "token": "$(pass show api)"}
+	}`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "config", "config.json"), []byte(configJSON), 0644))
+
+	cfg, warnings := LoadWithWarnings(tmpDir)
+
+	// Nothing runs at load; references wait for ResolveSecrets
+	assert.Empty(t, warnings)
+	assert.Equal(t, "keyring:claude/telegram", cfg.Notifications.Webhook.Telegram.BotToken)
+	assert.Empty(t, cfg.Notifications.Webhook.URL, "the URL is built once the token is read")
+	assert.Equal(t, "tk_from_env", cfg.Notifications.Webhook.Ntfy.Token)
+	assert.Equal(t, "$(pass show email)", cfg.Notifications.Email.Password)
+	assert.Equal(t, "$(pass show api)", cfg.API.Token)
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestResolveSecrets(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.Webhook = WebhookConfig{
+		Enabled:  true,
+		Preset:   "telegram",
+		ChatID:   "42",
+		Telegram: TelegramConfig{BotToken: "keyring:claude/telegram"},
+		Headers:  map[string]string{"X-Token": "$(pass show header)", "X-Plain": "$(kept)x"},
+	}
+	cfg.Notifications.Webhooks = []WebhookConfig{{Enabled: false, Gotify: GotifyConfig{Token: "$(pass show gotify)"}}}
+	cfg.Notifications.Email.Enabled = true
+	cfg.Notifications.Email.Password = "$(pass show email)"
+	cfg.Notifications.MQTT.Password = "$(pass show mqtt)"
+	cfg.Notifications.Remote.Enabled = true
+	cfg.Notifications.Remote.Token = "pa$$word"
+
+	var asked []string
+	errs := cfg.ResolveSecrets(func(ref string) (string, error) {
+		asked = append(asked, ref)
+		if ref == "$(pass show email)" {
+			return "", errors.New("denied")
+		}
+		return "secret-" + strings.Trim(ref, "$()"), nil
+	})
+
+	assert.Equal(t, "https://api.telegram.org/botsecret-keyring:claude/telegram/sendMessage", cfg.Notifications.Webhook.URL)
+	assert.Equal(t, "secret-pass show header", cfg.Notifications.Webhook.Headers["X-Token"])
+	assert.Equal(t, "$(kept)x", cfg.Notifications.Webhook.Headers["X-Plain"])
+	assert.Equal(t, "pa$$word", cfg.Notifications.Remote.Token, "a literal token is kept")
+	assert.Empty(t, cfg.Notifications.Email.Password)
+	// Disabled backends never run their commands
+	assert.Equal(t, "$(pass show gotify)", cfg.Notifications.Webhooks[0].Gotify.Token)
+	assert.Equal(t, "$(pass show mqtt)", cfg.Notifications.MQTT.Password)
+	assert.NotContains(t, asked, "$(pass show gotify)")
+	assert.NotContains(t, asked, "$(pass show mqtt)")
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "email.password")
+	assert.Contains(t, errs[0].Error(), "denied")
+}
+
+func TestSecretRefs(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.Webhook = WebhookConfig{
+		Enabled: true,
+		Ntfy:    NtfyConfig{Token: "$(pass show ntfy)"},
+		Headers: map[string]string{"X-Token": "keyring:claude/header", "X-Plain": "plain"},
+	}
+	cfg.Notifications.MQTT.Password = "$(pass show mqtt)"
+
+	assert.ElementsMatch(t, []string{"$(pass show ntfy)", "keyring:claude/header"}, cfg.SecretRefs(),
+		"only the references of enabled backends count")
+	assert.Equal(t, "$(pass show ntfy)", cfg.Notifications.Webhook.Ntfy.Token)
+	assert.Equal(t, "keyring:claude/header", cfg.Notifications.Webhook.Headers["X-Token"])
+}
+
 // === Tests for ApplyDefaults ===
 
 func TestApplyDefaults(t *testing.T) {
@@ -1441,3 +1537,1323 @@ func TestLoadConfig_WithSuppressFilters(t *testing.T) {
 	assert.True(t, cfg.ShouldFilter("question", "main", "scratch"))
 	assert.False(t, cfg.ShouldFilter("task_complete", "main", "my-project"))
 }
+
+func TestApplyDefaults_RemoteAddress(t *testing.T) {
+	t.Setenv(platform.ContainerEnv, "0")
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	assert.Equal(t, "127.0.0.1:9876", cfg.Notifications.Remote.Address)
+
+	cfg = &Config{Notifications: NotificationsConfig{Remote: RemoteConfig{Address: "localhost:7000"}}}
+	cfg.ApplyDefaults()
+	assert.Equal(t, "localhost:7000", cfg.Notifications.Remote.Address)
+
+	if runtime.GOOS == "linux" {
+		t.Setenv(platform.ContainerEnv, "api")
+		cfg = &Config{}
+		cfg.ApplyDefaults()
+		assert.Equal(t, "host.docker.internal:9876", cfg.Notifications.Remote.Address, "the host, from a container")
+	}
+}
+
+func TestValidate_RemoteAddress(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.Remote.Enabled = true
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Notifications.Remote.Address = "no-port"
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid remote address")
+
+	cfg.Notifications.Remote.Address = "unix:/run/claude-notifications.sock"
+	assert.NoError(t, cfg.Validate())
+	cfg.Notifications.Remote.Address = "unix:"
+	assert.Error(t, cfg.Validate())
+
+	// Address is not checked while forwarding is disabled
+	cfg.Notifications.Remote.Enabled = false
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestIsRemoteForwardingActive(t *testing.T) {
+	for _, key := range []string{"SSH_CONNECTION", "SSH_CLIENT", "SSH_TTY"} {
+		t.Setenv(key, "")
+	}
+	t.Setenv(platform.ContainerEnv, "0")
+
+	cfg := DefaultConfig()
+	cfg.Notifications.Remote.Enabled = true
+	assert.False(t, cfg.IsRemoteForwardingActive(), "not active outside SSH")
+
+	t.Setenv("SSH_CONNECTION", "10.0.0.2 51234 10.0.0.1 22")
+	assert.True(t, cfg.IsRemoteForwardingActive())
+
+	cfg.Notifications.Remote.Enabled = false
+	assert.False(t, cfg.IsRemoteForwardingActive(), "not active when disabled")
+}
+
+func TestIsRemoteForwardingActive_Container(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("containers run Linux")
+	}
+	for _, key := range []string{"SSH_CONNECTION", "SSH_CLIENT", "SSH_TTY"} {
+		t.Setenv(key, "")
+	}
+	t.Setenv(platform.ContainerEnv, "api-devcontainer")
+
+	cfg := DefaultConfig()
+	cfg.Notifications.Remote.Enabled = true
+	assert.True(t, cfg.IsRemoteForwardingActive())
+	assert.Equal(t, "api-devcontainer", cfg.RemoteSource())
+
+	cfg.Notifications.Remote.Name = "laptop-vm"
+	assert.Equal(t, "laptop-vm", cfg.RemoteSource(), "remote.name wins")
+
+	t.Setenv(platform.ContainerEnv, "0")
+	cfg.Notifications.Remote.Name = ""
+	assert.Equal(t, "", cfg.RemoteSource())
+}
+
+func TestValidate_TerminalNotification(t *testing.T) {
+	for _, mode := range []string{"", "auto", "osc9", "osc777", "osc99"} {
+		cfg := DefaultConfig()
+		cfg.Notifications.Desktop.TerminalNotification = mode
+		assert.NoError(t, cfg.Validate(), "mode %q should be valid", mode)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Notifications.Desktop.TerminalNotification = "osc1337"
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid terminalNotification")
+}
+
+func TestValidate_TmuxMessage(t *testing.T) {
+	for _, mode := range []string{"", "fallback", "always"} {
+		cfg := DefaultConfig()
+		cfg.Notifications.Desktop.TmuxMessage = mode
+		assert.NoError(t, cfg.Validate(), "mode %q should be valid", mode)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Notifications.Desktop.TmuxMessage = "never"
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid tmuxMessage")
+}
+
+func TestValidate_WSL(t *testing.T) {
+	for _, mode := range []string{"", "wsl-notify-send", "powershell", "off"} {
+		cfg := DefaultConfig()
+		cfg.Notifications.Desktop.WSL = mode
+		assert.NoError(t, cfg.Validate(), "mode %q should be valid", mode)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Notifications.Desktop.WSL = "burnttoast"
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid wsl")
+}
+
+func TestValidate_Privacy(t *testing.T) {
+	for _, mode := range []string{"", PrivacyFull, PrivacyTitleOnly, PrivacyPresenceOnly} {
+		cfg := DefaultConfig()
+		cfg.Notifications.Desktop.Privacy = mode
+		cfg.Notifications.Webhook.Privacy = mode
+		assert.NoError(t, cfg.Validate(), "mode %q should be valid", mode)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Notifications.Email.Privacy = "minimal"
+	assert.ErrorContains(t, cfg.Validate(), "email privacy must be full, title-only or presence-only")
+
+	cfg = DefaultConfig()
+	cfg.Notifications.Webhooks = []WebhookConfig{{Privacy: "full"}, {Privacy: "none"}}
+	assert.ErrorContains(t, cfg.Validate(), "webhooks[1] privacy")
+}
+
+func TestValidate_Encrypt(t *testing.T) {
+	const key = "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="
+	cfg := DefaultConfig()
+	cfg.Notifications.Webhook.Encrypt.Key = key
+	cfg.Notifications.MQTT.Encrypt.Key = key
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Notifications.MQTT.Encrypt.Key = "c2hvcnQ="
+	assert.ErrorContains(t, cfg.Validate(), "mqtt encrypt key: key must be 32 bytes")
+
+	cfg = DefaultConfig()
+	cfg.Notifications.Webhooks = []WebhookConfig{{Encrypt: EncryptConfig{Key: "not base64!"}}}
+	assert.ErrorContains(t, cfg.Validate(), "webhooks[0] encrypt key")
+}
+
+func TestValidate_Plugins(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.Plugins = PluginsConfig{
+		Enabled:  true,
+		Timeout:  "30s",
+		Settings: map[string]PluginSettings{"led": {Privacy: PrivacyPresenceOnly, Route: RouteConfig{Statuses: []string{"question"}}}},
+	}
+	cfg.Notifications.Rules = []Rule{{Match: RuleMatch{Projects: []string{"api"}}, Actions: RuleActions{Backends: []string{"plugin:led"}}}}
+	cfg.Notifications.Heartbeat.Backends = []string{"plugin:chat"}
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, 30*time.Second, cfg.PluginTimeout())
+	assert.Equal(t, PrivacyPresenceOnly, cfg.PluginSettings("plugin:led").Privacy)
+	assert.True(t, cfg.PluginSettings("plugin:chat").IsEnabled())
+
+	cfg.Notifications.Plugins.Enabled = false
+	assert.ErrorContains(t, cfg.Validate(), `unknown backend "plugin:led"`)
+
+	cfg = DefaultConfig()
+	cfg.Notifications.Plugins.Timeout = "soon"
+	assert.ErrorContains(t, cfg.Validate(), `plugins.timeout: invalid duration "soon"`)
+	assert.Equal(t, DefaultPluginTimeout, cfg.PluginTimeout())
+
+	cfg = DefaultConfig()
+	cfg.Notifications.Plugins.Settings = map[string]PluginSettings{"led": {Privacy: "secret"}}
+	assert.ErrorContains(t, cfg.Validate(), "plugins.settings.led")
+
+	cfg = DefaultConfig()
+	cfg.Notifications.Webhooks = []WebhookConfig{{Name: "plugin:led"}}
+	assert.ErrorContains(t, cfg.Validate(), `name "plugin:led" is reserved for plugins`)
+}
+
+func TestValidate_Script(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.star")
+	require.NoError(t, os.WriteFile(good, []byte("def transform(event):\n    pass\n"), 0o644))
+	bad := filepath.Join(dir, "bad.star")
+	require.NoError(t, os.WriteFile(bad, []byte("def transform(event)\n"), 0o644))
+
+	cfg := DefaultConfig()
+	cfg.Notifications.Script.File = good
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Notifications.Script.File = bad
+	assert.ErrorContains(t, cfg.Validate(), "script: "+bad)
+
+	cfg.Notifications.Script = ScriptConfig{File: good, MaxSteps: -1}
+	assert.ErrorContains(t, cfg.Validate(), "script.maxSteps must be >= 0")
+}
+
+func TestValidate_Throttle(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.Equal(t, 10, cfg.Notifications.Desktop.Throttle.CoalesceSeconds)
+	assert.Equal(t, 10, cfg.Notifications.Desktop.Throttle.MaxPerMinute)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Notifications.Desktop.Throttle = ThrottleConfig{}
+	assert.NoError(t, cfg.Validate(), "zero disables throttling")
+
+	cfg.Notifications.Desktop.Throttle.CoalesceSeconds = -1
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "coalesceSeconds")
+
+	cfg = DefaultConfig()
+	cfg.Notifications.Desktop.Throttle.MaxPerMinute = -5
+	err = cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "maxPerMinute")
+}
+
+func TestLoad_ThrottleOverride(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"notifications": {"desktop": {"throttle": {"coalesceSeconds": 0}}}}`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 0, cfg.Notifications.Desktop.Throttle.CoalesceSeconds, "explicit 0 disables coalescing")
+	assert.Equal(t, 10, cfg.Notifications.Desktop.Throttle.MaxPerMinute, "unset fields keep defaults")
+}
+
+func TestHistoryConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.True(t, cfg.IsHistoryEnabled(), "history should be enabled by default")
+	assert.Equal(t, 1000, cfg.Notifications.History.MaxEntries)
+
+	disabled := false
+	cfg.Notifications.History.Enabled = &disabled
+	assert.False(t, cfg.IsHistoryEnabled())
+
+	cfg.Notifications.History.MaxEntries = -1
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "history maxEntries")
+}
+
+func TestIsAsyncEnabled(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.True(t, cfg.IsAsyncEnabled(), "hooks should be handed to the daemon by default")
+
+	disabled := false
+	cfg.Notifications.Async = &disabled
+	assert.False(t, cfg.IsAsyncEnabled())
+}
+
+func TestBreakerConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.True(t, cfg.IsBreakerEnabled(), "circuit breakers should be enabled by default")
+	assert.Equal(t, breaker.Settings{Failures: 3, Backoff: time.Minute, MaxBackoff: time.Hour}, cfg.Notifications.Breaker.Settings())
+
+	cfg.Notifications.Breaker = BreakerConfig{Failures: 5, Backoff: "2h"}
+	assert.Equal(t, breaker.Settings{Failures: 5, Backoff: 2 * time.Hour, MaxBackoff: 2 * time.Hour}, cfg.Notifications.Breaker.Settings(),
+		"maxBackoff is at least backoff")
+
+	cfg.Notifications.Breaker.MaxBackoff = "soon"
+	assert.ErrorContains(t, cfg.Validate(), "breaker maxBackoff")
+	cfg.Notifications.Breaker = BreakerConfig{Failures: -1}
+	assert.ErrorContains(t, cfg.Validate(), "breaker failures")
+}
+
+func TestRedactConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.True(t, cfg.IsRedactEnabled(), "redaction should be enabled by default")
+	assert.NoError(t, cfg.Validate())
+	assert.Empty(t, cfg.Notifications.Redact.Options().Home)
+
+	cfg.Notifications.Redact = RedactConfig{Patterns: []string{`ticket-\d+`}, Disable: []string{"jwt"}, HomePaths: true}
+	assert.NoError(t, cfg.Validate())
+	home, _ := os.UserHomeDir()
+	assert.Equal(t, home, cfg.Notifications.Redact.Options().Home)
+
+	cfg.Notifications.Redact.Patterns = []string{"("}
+	assert.ErrorContains(t, cfg.Validate(), "invalid redact config")
+	cfg.Notifications.Redact = RedactConfig{Disable: []string{"azure"}}
+	assert.ErrorContains(t, cfg.Validate(), "unknown built-in pattern")
+
+	disabled := false
+	cfg.Notifications.Redact.Enabled = &disabled
+	assert.False(t, cfg.IsRedactEnabled())
+	assert.NoError(t, cfg.Validate(), "disabled redaction is not validated")
+}
+
+func TestValidate_Ntfy(t *testing.T) {
+	newNtfyConfig := func(url string, ntfy NtfyConfig) *Config {
+		cfg := DefaultConfig()
+		cfg.Notifications.Webhook.Enabled = true
+		cfg.Notifications.Webhook.Preset = "ntfy"
+		cfg.Notifications.Webhook.URL = url
+		cfg.Notifications.Webhook.Ntfy = ntfy
+		return cfg
+	}
+
+	assert.NoError(t, newNtfyConfig("https://ntfy.sh/my-topic", NtfyConfig{}).Validate())
+	assert.NoError(t, newNtfyConfig("https://ntfy.example.com", NtfyConfig{Topic: "claude", Priority: 5}).Validate())
+
+	err := newNtfyConfig("https://ntfy.sh", NtfyConfig{}).Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ntfy topic is required")
+
+	err = newNtfyConfig("https://ntfy.sh/t", NtfyConfig{Priority: 6}).Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ntfy priority")
+}
+
+func TestApplyDefaults_NtfyURL(t *testing.T) {
+	cfg := &Config{Notifications: NotificationsConfig{Webhook: WebhookConfig{Preset: "ntfy"}}}
+	cfg.ApplyDefaults()
+	assert.Equal(t, "https://ntfy.sh", cfg.Notifications.Webhook.URL)
+
+	cfg = &Config{Notifications: NotificationsConfig{Webhook: WebhookConfig{Preset: "slack"}}}
+	cfg.ApplyDefaults()
+	assert.Empty(t, cfg.Notifications.Webhook.URL)
+}
+
+func TestValidate_Pushover(t *testing.T) {
+	newPushoverConfig := func(pushover PushoverConfig) *Config {
+		cfg := DefaultConfig()
+		cfg.Notifications.Webhook.Enabled = true
+		cfg.Notifications.Webhook.Preset = "pushover"
+		cfg.Notifications.Webhook.URL = "https://api.pushover.net/1/messages.json"
+		cfg.Notifications.Webhook.Pushover = pushover
+		return cfg
+	}
+
+	assert.NoError(t, newPushoverConfig(PushoverConfig{UserKey: "u", AppToken: "a"}).Validate())
+	assert.NoError(t, newPushoverConfig(PushoverConfig{
+		UserKey: "u", AppToken: "a",
+		Priorities: map[string]int{"task_complete": 2, "question": -2},
+		Retry:      30, Expire: 10800,
+	}).Validate())
+
+	tests := []struct {
+		name     string
+		pushover PushoverConfig
+		wantErr  string
+	}{
+		{"missing keys", PushoverConfig{UserKey: "u"}, "userKey and appToken are required"},
+		{"unknown status", PushoverConfig{UserKey: "u", AppToken: "a", Priorities: map[string]int{"done": 1}}, "invalid status"},
+		{"priority too high", PushoverConfig{UserKey: "u", AppToken: "a", Priorities: map[string]int{"question": 3}}, "between -2 and 2"},
+		{"retry too short", PushoverConfig{UserKey: "u", AppToken: "a", Retry: 10}, "retry must be at least 30"},
+		{"expire too long", PushoverConfig{UserKey: "u", AppToken: "a", Expire: 20000}, "expire must be between"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newPushoverConfig(tt.pushover).Validate()
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestValidate_Gotify(t *testing.T) {
+	newGotifyConfig := func(gotify GotifyConfig) *Config {
+		cfg := DefaultConfig()
+		cfg.Notifications.Webhook.Enabled = true
+		cfg.Notifications.Webhook.Preset = "gotify"
+		cfg.Notifications.Webhook.URL = "https://gotify.example.com"
+		cfg.Notifications.Webhook.Gotify = gotify
+		return cfg
+	}
+
+	assert.NoError(t, newGotifyConfig(GotifyConfig{Token: "A1b2", Priorities: map[string]int{"task_complete": 0, "question": 10}}).Validate())
+
+	tests := []struct {
+		name    string
+		gotify  GotifyConfig
+		wantErr string
+	}{
+		{"missing token", GotifyConfig{}, "gotify token is required"},
+		{"unknown status", GotifyConfig{Token: "t", Priorities: map[string]int{"done": 1}}, "invalid status"},
+		{"priority too high", GotifyConfig{Token: "t", Priorities: map[string]int{"question": 11}}, "between 0 and 10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newGotifyConfig(tt.gotify).Validate()
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	// The server URL has no default
+	cfg := newGotifyConfig(GotifyConfig{Token: "t"})
+	cfg.Notifications.Webhook.URL = ""
+	assert.ErrorContains(t, cfg.Validate(), "webhook URL is required")
+}
+
+func TestValidate_Matrix(t *testing.T) {
+	newMatrixConfig := func(matrix MatrixConfig) *Config {
+		cfg := DefaultConfig()
+		cfg.Notifications.Webhook.Enabled = true
+		cfg.Notifications.Webhook.Preset = "matrix"
+		cfg.Notifications.Webhook.URL = "https://matrix.example.org"
+		cfg.Notifications.Webhook.Matrix = matrix
+		return cfg
+	}
+
+	assert.NoError(t, newMatrixConfig(MatrixConfig{AccessToken: "syt_x", RoomID: "!abc:example.org", MsgType: "m.text", Mention: "@me:example.org"}).Validate())
+
+	tests := []struct {
+		name    string
+		matrix  MatrixConfig
+		wantErr string
+	}{
+		{"missing token", MatrixConfig{RoomID: "!abc:example.org"}, "accessToken is required"},
+		{"alias instead of ID", MatrixConfig{AccessToken: "t", RoomID: "#claude:example.org"}, "not an alias"},
+		{"unknown msgtype", MatrixConfig{AccessToken: "t", RoomID: "!a:b", MsgType: "m.emote"}, "m.notice or m.text"},
+		{"bad mention", MatrixConfig{AccessToken: "t", RoomID: "!a:b", Mention: "here"}, "matrix mention"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newMatrixConfig(tt.matrix).Validate()
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestApplyDefaults_PushoverURL(t *testing.T) {
+	cfg := &Config{Notifications: NotificationsConfig{Webhook: WebhookConfig{Preset: "pushover"}}}
+	cfg.ApplyDefaults()
+	assert.Equal(t, "https://api.pushover.net/1/messages.json", cfg.Notifications.Webhook.URL)
+}
+
+func TestApplyDefaults_TelegramBotToken(t *testing.T) {
+	cfg := &Config{Notifications: NotificationsConfig{Webhook: WebhookConfig{
+		Preset:   "telegram",
+		Telegram: TelegramConfig{BotToken: "123:ABC"},
+	}}}
+	cfg.ApplyDefaults()
+	assert.Equal(t, "https://api.telegram.org/bot123:ABC/sendMessage", cfg.Notifications.Webhook.URL)
+
+	// An explicit URL is kept
+	cfg = &Config{Notifications: NotificationsConfig{Webhook: WebhookConfig{
+		Preset:   "telegram",
+		URL:      "https://proxy.example.com/sendMessage",
+		Telegram: TelegramConfig{BotToken: "123:ABC"},
+	}}}
+	cfg.ApplyDefaults()
+	assert.Equal(t, "https://proxy.example.com/sendMessage", cfg.Notifications.Webhook.URL)
+}
+
+func TestValidate_TelegramPreviewLength(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.Webhook.Enabled = true
+	cfg.Notifications.Webhook.Preset = "telegram"
+	cfg.Notifications.Webhook.URL = "https://api.telegram.org/bot123:ABC/sendMessage"
+	cfg.Notifications.Webhook.ChatID = "42"
+
+	cfg.Notifications.Webhook.Telegram.PreviewLength = 500
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Notifications.Webhook.Telegram.PreviewLength = 5000
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "previewLength")
+}
+
+func TestSlackBotTokenDefaultsAndValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.Webhook.Enabled = true
+	cfg.Notifications.Webhook.Preset = "slack"
+	cfg.Notifications.Webhook.URL = ""
+	cfg.Notifications.Webhook.Slack = SlackConfig{BotToken: "xoxb-1"}
+	cfg.ApplyDefaults()
+	assert.Equal(t, "https://slack.com/api/chat.postMessage", cfg.Notifications.Webhook.URL)
+
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "slack channel is required")
+
+	cfg.Notifications.Webhook.Slack.Channel = "#claude"
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestEmailDefaultsAndValidation(t *testing.T) {
+	newEmailConfig := func(email EmailConfig) *Config {
+		cfg := DefaultConfig()
+		cfg.Notifications.Email = email
+		cfg.ApplyDefaults()
+		return cfg
+	}
+
+	cfg := newEmailConfig(EmailConfig{Enabled: true, Host: "smtp.example.com", From: "a@example.com", To: []string{"b@example.com"}})
+	assert.Equal(t, "starttls", cfg.Notifications.Email.Security)
+	assert.Equal(t, 587, cfg.Notifications.Email.Port)
+	assert.NoError(t, cfg.Validate())
+	assert.True(t, cfg.IsEmailEnabled())
+	assert.True(t, cfg.IsAnyNotificationEnabled())
+
+	assert.Equal(t, 465, newEmailConfig(EmailConfig{Security: "tls"}).Notifications.Email.Port)
+	assert.Equal(t, 25, newEmailConfig(EmailConfig{Security: "none"}).Notifications.Email.Port)
+
+	tests := []struct {
+		name    string
+		email   EmailConfig
+		wantErr string
+	}{
+		{"missing host", EmailConfig{Enabled: true, From: "a@example.com", To: []string{"b@example.com"}}, "email host is required"},
+		{"bad security", EmailConfig{Enabled: true, Security: "ssl", Host: "h", From: "a@example.com", To: []string{"b@example.com"}}, "invalid email security"},
+		{"bad from", EmailConfig{Enabled: true, Host: "h", From: "not an address", To: []string{"b@example.com"}}, "invalid email from address"},
+		{"no recipients", EmailConfig{Enabled: true, Host: "h", From: "a@example.com"}, "at least one recipient"},
+		{"bad recipient", EmailConfig{Enabled: true, Host: "h", From: "a@example.com", To: []string{"b@"}}, "invalid email to address"},
+		{"bad port", EmailConfig{Enabled: true, Host: "h", Port: 70000, From: "a@example.com", To: []string{"b@example.com"}}, "email port"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newEmailConfig(tt.email).Validate()
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	// Disabled email is not validated
+	assert.NoError(t, newEmailConfig(EmailConfig{Host: ""}).Validate())
+}
+
+func TestMQTTValidation(t *testing.T) {
+	newMQTTConfig := func(mqtt MQTTConfig) *Config {
+		cfg := DefaultConfig()
+		cfg.Notifications.MQTT = mqtt
+		cfg.ApplyDefaults()
+		return cfg
+	}
+
+	cfg := newMQTTConfig(MQTTConfig{Enabled: true, Broker: "mqtts://broker.local:8883", QoS: 1, Timeout: "5s"})
+	assert.NoError(t, cfg.Validate())
+	assert.True(t, cfg.IsMQTTEnabled())
+	assert.True(t, cfg.IsAnyNotificationEnabled())
+
+	tests := []struct {
+		name    string
+		mqtt    MQTTConfig
+		wantErr string
+	}{
+		{"missing broker", MQTTConfig{Enabled: true}, "invalid mqtt broker"},
+		{"websocket broker", MQTTConfig{Enabled: true, Broker: "ws://broker.local"}, "invalid mqtt broker"},
+		{"bare host", MQTTConfig{Enabled: true, Broker: "broker.local:1883"}, "invalid mqtt broker"},
+		{"bad qos", MQTTConfig{Enabled: true, Broker: "mqtt://h", QoS: 3}, "mqtt qos"},
+		{"bad timeout", MQTTConfig{Enabled: true, Broker: "mqtt://h", Timeout: "soon"}, "invalid mqtt timeout"},
+		{"cert without key", MQTTConfig{Enabled: true, Broker: "mqtts://h", TLS: MQTTTLSConfig{CertFile: "c.pem"}}, "certFile and keyFile"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newMQTTConfig(tt.mqtt).Validate()
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	// Disabled MQTT is not validated
+	assert.NoError(t, newMQTTConfig(MQTTConfig{Broker: "nope"}).Validate())
+}
+
+func TestRouteConfig_Matches(t *testing.T) {
+	tests := []struct {
+		name    string
+		route   RouteConfig
+		status  string
+		project string
+		running time.Duration
+		want    bool
+	}{
+		{"empty route matches all", RouteConfig{}, "task_complete", "", 0, true},
+		{"status match", RouteConfig{Statuses: []string{"question", "plan_ready"}}, "question", "/w/api", 0, true},
+		{"status mismatch", RouteConfig{Statuses: []string{"question"}}, "task_complete", "/w/api", 0, false},
+		{"project folder glob", RouteConfig{Projects: []string{"billing-*"}}, "task_complete", "/w/billing-api", 0, true},
+		{"project path glob", RouteConfig{Projects: []string{"/work/*/api"}}, "task_complete", "/work/acme/api", 0, true},
+		{"project mismatch", RouteConfig{Projects: []string{"billing-*"}}, "task_complete", "/w/web", 0, false},
+		{"project unknown", RouteConfig{Projects: []string{"*"}}, "task_complete", "", 0, false},
+		{"long session", RouteConfig{MinElapsed: "10m"}, "task_complete", "", 12 * time.Minute, true},
+		{"short session", RouteConfig{MinElapsed: "10m"}, "task_complete", "", 3 * time.Minute, false},
+		{"unknown running time", RouteConfig{MinElapsed: "10m"}, "task_complete", "", 0, false},
+		{"all conditions", RouteConfig{Statuses: []string{"task_complete"}, Projects: []string{"api"}, MinElapsed: "1m"}, "task_complete", "/w/api", 2 * time.Minute, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.route.Matches(tt.status, tt.project, tt.running))
+		})
+	}
+}
+
+func TestRouteConfig_MatchesIdle(t *testing.T) {
+	route := RouteConfig{MinIdle: "5m"}
+	assert.True(t, route.MatchesIdle(10*time.Minute))
+	assert.False(t, route.MatchesIdle(30*time.Second))
+	assert.True(t, route.MatchesIdle(0), "unknown idle time should not hold back an escalation")
+	assert.True(t, (&RouteConfig{}).MatchesIdle(time.Second))
+	assert.False(t, route.IsEmpty())
+}
+
+func TestValidate_Escalation(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		errMsg string
+	}{
+		{"bad escalation after", func(c *Config) { c.Notifications.Escalation.After = "later" }, "invalid escalation after"},
+		{"escalation without backends", func(c *Config) { c.Notifications.Escalation.Enabled = true }, "escalation requires at least one backend"},
+		{"escalation to unknown backend", func(c *Config) { c.Notifications.Escalation.Backends = []string{"pager"} }, `escalation: unknown backend "pager"`},
+		{"escalation to desktop", func(c *Config) { c.Notifications.Escalation.Backends = []string{"desktop"} }, "desktop cannot be an escalation backend"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConfig()
+			tt.modify(c)
+			err := c.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+
+	c := DefaultConfig()
+	c.Notifications.Escalation = EscalationConfig{Enabled: true, After: "10m", Backends: []string{"webhook"}}
+	assert.NoError(t, c.Validate())
+
+	e := EscalationConfig{Backends: []string{"webhook"}}
+	assert.False(t, e.Escalates("webhook"), "disabled escalation holds nothing back")
+	e.Enabled = true
+	assert.True(t, e.Escalates("webhook"))
+	assert.False(t, e.Escalates("email"))
+	assert.Equal(t, DefaultEscalationAfter, e.AfterDuration())
+	e.After = "90s"
+	assert.Equal(t, 90*time.Second, e.AfterDuration())
+}
+
+func TestValidate_Digest(t *testing.T) {
+	c := DefaultConfig()
+	c.Notifications.Digest.Interval = "soon"
+	err := c.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid digest interval")
+
+	c = DefaultConfig()
+	c.Notifications.Digest.Events = []string{"SubagentStop", "tool_use", "Compact"}
+	err = c.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid digest event "Compact"`)
+
+	c = DefaultConfig()
+	c.Notifications.Digest = DigestConfig{Enabled: true, Interval: "5m", Events: []string{"SubagentStop", "review_complete"}}
+	assert.NoError(t, c.Validate())
+	assert.Equal(t, 5*time.Minute, c.Notifications.Digest.IntervalDuration())
+}
+
+func TestDigestConfig_Batches(t *testing.T) {
+	d := DigestConfig{}
+	assert.False(t, d.Batches("SubagentStop", "task_complete", ""), "disabled digest batches nothing")
+
+	d.Enabled = true
+	assert.Equal(t, DefaultDigestInterval, d.IntervalDuration())
+	assert.True(t, d.Batches("SubagentStop", "task_complete", ""))
+	assert.True(t, d.Batches("PostToolUse", "tool_use", ""))
+	assert.True(t, d.Batches("Stop", "task_complete", "low"), "low priority is always batched")
+	assert.False(t, d.Batches("Stop", "task_complete", ""))
+	assert.False(t, d.Batches("Notification", "question", "critical"))
+
+	d.Events = []string{"review_complete", "api_error"}
+	assert.True(t, d.Batches("Stop", "review_complete", ""))
+	assert.False(t, d.Batches("Stop", "api_error", "critical"), "critical events are never batched")
+	assert.False(t, d.Batches("SubagentStop", "task_complete", ""), "events replace the defaults")
+}
+
+func TestValidate_Presence(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		errMsg string
+	}{
+		{"bad whenActive", func(c *Config) { c.Notifications.Presence.WhenActive = "hide" }, "invalid presence whenActive: hide"},
+		{"bad activeWithin", func(c *Config) { c.Notifications.Presence.ActiveWithin = "0s" }, "invalid presence activeWithin"},
+		{"bad minIdle", func(c *Config) { c.Notifications.Webhook.Route.MinIdle = "a while" }, "webhook route: invalid minIdle"},
+		{"bad whenFocused", func(c *Config) { c.Notifications.Desktop.WhenFocused = "mute" }, "invalid desktop whenFocused: mute"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConfig()
+			tt.modify(c)
+			err := c.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+
+	c := DefaultConfig()
+	assert.False(t, c.UsesIdle())
+	c.Notifications.Presence = PresenceConfig{Enabled: true, ActiveWithin: "1m", WhenActive: "suppress"}
+	assert.NoError(t, c.Validate())
+	assert.True(t, c.UsesIdle())
+	assert.Equal(t, time.Minute, c.Notifications.Presence.ActiveWithinDuration())
+
+	c = DefaultConfig()
+	c.Notifications.Webhooks = []WebhookConfig{{Route: RouteConfig{MinIdle: "5m"}}}
+	assert.True(t, c.UsesIdle())
+	assert.Equal(t, DefaultActiveWithin, c.Notifications.Presence.ActiveWithinDuration())
+}
+
+func TestValidate_Routes(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.Webhook.Route = RouteConfig{Statuses: []string{"question"}, Projects: []string{"api-*"}, MinElapsed: "10m"}
+	assert.NoError(t, cfg.Validate())
+
+	tests := []struct {
+		name    string
+		setup   func(c *Config)
+		wantErr string
+	}{
+		{"bad status", func(c *Config) { c.Notifications.Desktop.Route.Statuses = []string{"permission"} }, `desktop route: invalid status "permission"`},
+		{"bad pattern", func(c *Config) { c.Notifications.Webhook.Route.Projects = []string{"[api"} }, "webhook route: invalid project pattern"},
+		{"bad duration", func(c *Config) { c.Notifications.Email.Route.MinElapsed = "ten minutes" }, "email route: invalid minElapsed"},
+		{"bad extra webhook route", func(c *Config) {
+			c.Notifications.Webhooks = []WebhookConfig{{Route: RouteConfig{MinElapsed: "-1m"}}}
+		}, "webhooks[0] route: invalid minElapsed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConfig()
+			tt.setup(c)
+			err := c.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestExtraWebhooks(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.Desktop.Enabled = false
+	cfg.Notifications.Webhooks = []WebhookConfig{
+		{Enabled: true, Preset: "ntfy", Ntfy: NtfyConfig{Topic: "claude"}},
+		{Enabled: false, Preset: "slack"},
+	}
+	cfg.ApplyDefaults()
+
+	assert.Equal(t, "https://ntfy.sh", cfg.Notifications.Webhooks[0].URL)
+	assert.Equal(t, "json", cfg.Notifications.Webhooks[1].Format)
+	assert.NoError(t, cfg.Validate())
+	assert.True(t, cfg.HasExtraWebhooks())
+	assert.True(t, cfg.IsAnyNotificationEnabled())
+
+	cfg.Notifications.Webhooks = append(cfg.Notifications.Webhooks, WebhookConfig{Enabled: true, Preset: "custom"})
+	cfg.ApplyDefaults()
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "webhooks[2]: webhook URL is required")
+}
+
+func TestLoadConfig_ExtraWebhooksExpandEnv(t *testing.T) {
+	t.Setenv("TEST_SLACK_URL", "https://hooks.slack.com/services/T/B/X")
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	configJSON := `{
+		"notifications": {
+			"webhooks": [
+				{"enabled": true, "preset": "slack", "url": "${TEST_SLACK_URL}", "route": {"minElapsed": "10m"}}
+			]
+		}
+	}`
+	require.NoError(t, os.WriteFile(configPath, []byte(configJSON), 0644))
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+	require.Len(t, cfg.Notifications.Webhooks, 1)
+	assert.Equal(t, "https://hooks.slack.com/services/T/B/X", cfg.Notifications.Webhooks[0].URL)
+	assert.Equal(t, "10m", cfg.Notifications.Webhooks[0].Route.MinElapsed)
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestParseTimeWindow(t *testing.T) {
+	w, err := ParseTimeWindow("22:00-08:00")
+	require.NoError(t, err)
+	assert.Equal(t, TimeWindow{Start: 22 * 60, End: 8 * 60}, w)
+
+	day := func(h, m int) time.Time { return time.Date(2026, 1, 5, h, m, 0, 0, time.Local) }
+	assert.True(t, w.Contains(day(22, 0)))
+	assert.True(t, w.Contains(day(3, 15)))
+	assert.False(t, w.Contains(day(8, 0)))
+	assert.False(t, w.Contains(day(12, 0)))
+
+	w, err = ParseTimeWindow("09:30 - 17:00")
+	require.NoError(t, err)
+	assert.True(t, w.Contains(day(9, 30)))
+	assert.False(t, w.Contains(day(17, 0)))
+
+	w, err = ParseTimeWindow("00:00-00:00")
+	require.NoError(t, err)
+	assert.True(t, w.Contains(day(13, 0)), "equal start and end cover the whole day")
+
+	for _, bad := range []string{"22:00", "25:00-08:00", "10pm-8am", ""} {
+		_, err := ParseTimeWindow(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestValidate_Rules(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.Webhooks = []WebhookConfig{{Name: "phone", Preset: "ntfy"}}
+	cfg.Notifications.Rules = []Rule{
+		{
+			Name:    "night",
+			Match:   RuleMatch{Time: "22:00-08:00", Statuses: []string{"task_complete"}, Events: []string{"Stop", "SubagentStop"}},
+			Actions: RuleActions{Urgency: "low", Sound: "none"},
+		},
+		{
+			Match:   RuleMatch{Message: `(?i)failed`, Projects: []string{"client-*"}, MinElapsed: "5m"},
+			Actions: RuleActions{Backends: []string{"desktop", "phone"}, Title: "{{.Title}} ({{.Project}})"},
+		},
+	}
+	assert.NoError(t, cfg.Validate())
+
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr string
+	}{
+		{"no actions", Rule{Match: RuleMatch{Statuses: []string{"question"}}}, "rules[0]: must have at least one action"},
+		{"bad status", Rule{Match: RuleMatch{Statuses: []string{"done"}}, Actions: RuleActions{Suppress: true}}, `invalid status "done"`},
+		{"bad event", Rule{Match: RuleMatch{Events: []string{"stop"}}, Actions: RuleActions{Suppress: true}}, `invalid event "stop"`},
+		{"bad regex", Rule{Match: RuleMatch{Message: "("}, Actions: RuleActions{Suppress: true}}, "invalid message regex"},
+		{"bad time", Rule{Match: RuleMatch{Time: "late"}, Actions: RuleActions{Suppress: true}}, "invalid time window"},
+		{"bad elapsed", Rule{Match: RuleMatch{MaxElapsed: "soon"}, Actions: RuleActions{Suppress: true}}, "invalid elapsed duration"},
+		{"bad urgency", Rule{Actions: RuleActions{Urgency: "urgent"}}, "invalid urgency"},
+		{"unknown backend", Rule{Actions: RuleActions{Backends: []string{"pager"}}}, `unknown backend "pager"`},
+		{"bad title", Rule{Actions: RuleActions{Title: "{{.Title"}}, "invalid title template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConfig()
+			c.Notifications.Rules = []Rule{tt.rule}
+			err := c.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	// Backend names must be unique
+	c := DefaultConfig()
+	c.Notifications.Webhooks = []WebhookConfig{{Name: "email"}}
+	err := c.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `duplicate backend name "email"`)
+}
+
+func TestDNDConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ApplyDefaults()
+	assert.Equal(t, "queue", cfg.Notifications.DND.Mode)
+	assert.True(t, cfg.IsDNDDigestEnabled())
+
+	cfg.Notifications.DND.Digest = boolPtr(false)
+	assert.False(t, cfg.IsDNDDigestEnabled())
+
+	cfg.Notifications.DND.Schedule = []DNDWindow{{Time: "22:00-08:00"}, {Days: []string{"Weekends"}}}
+	assert.NoError(t, cfg.Validate())
+
+	// 2026-01-09 is a Friday
+	fri := func(h, m int) time.Time { return time.Date(2026, 1, 9, h, m, 0, 0, time.Local) }
+	dndCfg := cfg.Notifications.DND
+	assert.True(t, dndCfg.IsScheduled(fri(23, 0)))
+	assert.False(t, dndCfg.IsScheduled(fri(12, 0)))
+	assert.True(t, dndCfg.IsScheduled(fri(12, 0).AddDate(0, 0, 1)), "saturday")
+	// Friday night runs into the weekend and Sunday's overnight window, ending Monday 08:00
+	assert.Equal(t, fri(8, 0).AddDate(0, 0, 3), dndCfg.ScheduleEnd(fri(23, 0)))
+
+	tests := []struct {
+		name    string
+		dnd     DNDConfig
+		wantErr string
+	}{
+		{"bad mode", DNDConfig{Mode: "mute"}, "invalid dnd mode"},
+		{"empty window", DNDConfig{Schedule: []DNDWindow{{}}}, "dnd.schedule[0]: must set days, time, or both"},
+		{"bad day", DNDConfig{Schedule: []DNDWindow{{Days: []string{"funday"}}}}, `invalid day "funday"`},
+		{"bad time", DNDConfig{Schedule: []DNDWindow{{Time: "10pm-8am"}}}, "dnd.schedule[0]: invalid time window"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConfig()
+			c.Notifications.DND = tt.dnd
+			err := c.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestRetryQueueConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.True(t, cfg.Notifications.Webhook.Retry.IsQueueEnabled())
+
+	off := false
+	cfg.Notifications.Webhook.Retry.Queue = &off
+	assert.False(t, cfg.Notifications.Webhook.Retry.IsQueueEnabled())
+
+	cfg.Notifications.Webhook.Enabled = true
+	cfg.Notifications.Webhook.URL = "https://example.com/hook"
+	cfg.Notifications.Webhook.Retry.QueueMaxAge = "12h"
+	assert.NoError(t, cfg.Validate())
+
+	for _, bad := range []string{"forever", "-1h", "0s"} {
+		cfg.Notifications.Webhook.Retry.QueueMaxAge = bad
+		err := cfg.Validate()
+		require.Error(t, err, bad)
+		assert.Contains(t, err.Error(), "queueMaxAge")
+	}
+}
+
+func TestLoggingConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ApplyDefaults()
+	assert.Equal(t, "debug", cfg.Logging.Level)
+	assert.Equal(t, "text", cfg.Logging.Format)
+	assert.Equal(t, 5, cfg.Logging.MaxSizeMB)
+	assert.Equal(t, 3, cfg.Logging.MaxFiles)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Logging = LoggingConfig{Level: "warn", Format: "json", MaxSizeMB: 1, MaxFiles: 5}
+	opts := cfg.Logging.Options()
+	assert.Equal(t, slog.LevelWarn, opts.Level)
+	assert.Equal(t, "json", opts.Format)
+	assert.Equal(t, int64(1024*1024), opts.MaxSize)
+	assert.Equal(t, 5, opts.MaxFiles)
+
+	for _, bad := range []LoggingConfig{{Level: "verbose"}, {Format: "xml"}, {MaxSizeMB: -1}, {MaxFiles: -1}} {
+		cfg.Logging = bad
+		err := cfg.Validate()
+		require.Error(t, err, "%+v", bad)
+		assert.Contains(t, err.Error(), "logging")
+	}
+}
+
+func TestLoggingConfigFromEnv(t *testing.T) {
+	cfg := DefaultConfig()
+	applied, errs := ApplyEnv(cfg, []string{"CLAUDE_NOTIFICATIONS_LOGGING_LEVEL=error"})
+	require.Empty(t, errs)
+	assert.Equal(t, []string{"CLAUDE_NOTIFICATIONS_LOGGING_LEVEL"}, applied)
+	assert.Equal(t, "error", cfg.Logging.Level)
+}
+
+func TestMetricsConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ApplyDefaults()
+	assert.False(t, cfg.Metrics.Enabled)
+	assert.Equal(t, DefaultMetricsAddress, cfg.Metrics.Address)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Metrics = MetricsConfig{Enabled: true, Address: ":9100"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Metrics.Address = "localhost"
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "metrics address")
+
+	// An invalid address is ignored while metrics are off
+	cfg.Metrics.Enabled = false
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestMetricsConfigFromEnv(t *testing.T) {
+	cfg := DefaultConfig()
+	applied, errs := ApplyEnv(cfg, []string{"CLAUDE_NOTIFICATIONS_METRICS_ENABLED=true"})
+	require.Empty(t, errs)
+	assert.Equal(t, []string{"CLAUDE_NOTIFICATIONS_METRICS_ENABLED"}, applied)
+	assert.True(t, cfg.Metrics.Enabled)
+}
+
+func TestDashboardConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ApplyDefaults()
+	assert.False(t, cfg.Dashboard.Enabled)
+	assert.Equal(t, DefaultDashboardAddress, cfg.Dashboard.Address)
+
+	for _, addr := range []string{"127.0.0.1:8080", "localhost:8080", "[::1]:8080"} {
+		cfg.Dashboard = DashboardConfig{Enabled: true, Address: addr}
+		assert.NoError(t, cfg.Validate(), addr)
+	}
+	for _, addr := range []string{":8080", "0.0.0.0:8080", "192.168.1.5:8080", "localhost"} {
+		cfg.Dashboard = DashboardConfig{Enabled: true, Address: addr}
+		err := cfg.Validate()
+		require.Error(t, err, addr)
+		assert.Contains(t, err.Error(), "dashboard address")
+	}
+}
+
+func TestAPIConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ApplyDefaults()
+	assert.False(t, cfg.API.Enabled)
+	assert.Equal(t, DefaultAPIAddress, cfg.API.Address)
+
+	cfg.API = APIConfig{Enabled: true, Address: "0.0.0.0:9879", Token: "0123456789abcdef"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.API.Token = "short"
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "api token")
+
+	cfg.API = APIConfig{Enabled: true, Address: "9879", Token: "0123456789abcdef"}
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "api address")
+
+	// Nothing is checked while the API is off
+	cfg.API = APIConfig{Address: "9879"}
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestFocusConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.Equal(t, FocusConfig{}, cfg.Notifications.Desktop.Focus)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Notifications.Desktop.Focus = FocusConfig{Mode: "race", Parallel: 2, Timeout: "500ms"}
+	assert.NoError(t, cfg.Validate())
+
+	for _, tc := range []struct {
+		focus FocusConfig
+		want  string
+	}{
+		{FocusConfig{Mode: "parallel"}, "focus mode"},
+		{FocusConfig{Parallel: -1}, "focus parallel"},
+		{FocusConfig{Timeout: "soon"}, "focus timeout"},
+		{FocusConfig{Timeout: "0s"}, "focus timeout"},
+		{FocusConfig{MethodTimeout: "-1s"}, "focus methodTimeout"},
+	} {
+		cfg.Notifications.Desktop.Focus = tc.focus
+		err := cfg.Validate()
+		require.Error(t, err, tc.focus)
+		assert.Contains(t, err.Error(), tc.want)
+	}
+}
+
+func TestIsWindowPinningEnabled(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.True(t, cfg.IsWindowPinningEnabled())
+
+	off := false
+	cfg.Notifications.Desktop.Focus.PinWindow = &off
+	assert.False(t, cfg.IsWindowPinningEnabled())
+}
+
+func TestFocusConfigFromEnv(t *testing.T) {
+	cfg := DefaultConfig()
+	applied, errs := ApplyEnv(cfg, []string{"CLAUDE_NOTIFICATIONS_DESKTOP_FOCUS_MODE=race"})
+	require.Empty(t, errs)
+	assert.Equal(t, []string{"CLAUDE_NOTIFICATIONS_DESKTOP_FOCUS_MODE"}, applied)
+	assert.Equal(t, "race", cfg.Notifications.Desktop.Focus.Mode)
+}
+
+func TestExecTimeout(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.Equal(t, DefaultExecTimeout, cfg.Notifications.Desktop.ExecTimeoutDuration())
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Notifications.Desktop.ExecTimeout = "3s"
+	assert.Equal(t, 3*time.Second, cfg.Notifications.Desktop.ExecTimeoutDuration())
+	assert.NoError(t, cfg.Validate())
+
+	for _, bad := range []string{"later", "0s", "-5s"} {
+		cfg.Notifications.Desktop.ExecTimeout = bad
+		err := cfg.Validate()
+		require.Error(t, err, bad)
+		assert.Contains(t, err.Error(), "execTimeout")
+	}
+}
+
+func TestTerminalsValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.Desktop.Terminals = map[string]TerminalConfig{
+		"foot":    {Detect: "TERM=foot", DesktopEntry: "org.codeberg.dnkl.foot", AppID: "foot"},
+		"contour": {Detect: "TERMINAL_NAME"},
+	}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Notifications.Desktop.Terminals = map[string]TerminalConfig{"rio": {Detect: "=rio"}}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "detect")
+
+	cfg.Notifications.Desktop.Terminals = map[string]TerminalConfig{" ": {AppID: "rio"}}
+	assert.Error(t, cfg.Validate())
+}
+
+func TestToolsConfig_ShouldNotify(t *testing.T) {
+	tools := ToolsConfig{Notify: []string{"Bash", "mcp__github__*"}}
+	assert.True(t, tools.ShouldNotify("Bash", "PreToolUse"))
+	assert.True(t, tools.ShouldNotify("mcp__github__create_issue", "PreToolUse"))
+	assert.False(t, tools.ShouldNotify("Edit", "PreToolUse"))
+	assert.False(t, tools.ShouldNotify("Bash", "PostToolUse"), "before is the default")
+
+	tools.When = "after"
+	assert.False(t, tools.ShouldNotify("Bash", "PreToolUse"))
+	assert.True(t, tools.ShouldNotify("Bash", "PostToolUse"))
+
+	tools.When = "both"
+	assert.True(t, tools.ShouldNotify("Bash", "PreToolUse"))
+	assert.True(t, tools.ShouldNotify("Bash", "PostToolUse"))
+}
+
+func TestToolsConfig_Matcher(t *testing.T) {
+	assert.Equal(t, "", (&ToolsConfig{}).Matcher())
+	tools := ToolsConfig{Notify: []string{"Bash", "mcp__github__*", "Web?etch"}}
+	assert.Equal(t, "^(Bash|mcp__github__.*|Web.etch)$", tools.Matcher())
+}
+
+func TestToolsConfig_GetArgLength(t *testing.T) {
+	assert.Equal(t, DefaultToolArgLength, (&ToolsConfig{}).GetArgLength())
+	assert.Equal(t, 20, (&ToolsConfig{ArgLength: 20}).GetArgLength())
+}
+
+func TestValidate_Tools(t *testing.T) {
+	c := DefaultConfig()
+	c.Notifications.Tools = ToolsConfig{Notify: []string{"Bash", "mcp__*"}, When: "both", ArgLength: 40}
+	require.NoError(t, c.Validate())
+
+	tests := []struct {
+		name    string
+		tools   ToolsConfig
+		wantErr string
+	}{
+		{"bad when", ToolsConfig{When: "during"}, "tools when must be before, after or both"},
+		{"bad pattern", ToolsConfig{Notify: []string{"Bash["}}, `invalid tool pattern "Bash["`},
+		{"negative argLength", ToolsConfig{ArgLength: -1}, "tools argLength must be >= 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConfig()
+			c.Notifications.Tools = tt.tools
+			err := c.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestTranscriptSummaryConfig(t *testing.T) {
+	assert.False(t, DefaultConfig().Notifications.TranscriptSummary.Enabled)
+	assert.Equal(t, DefaultTranscriptSummaryLength, (&TranscriptSummaryConfig{}).GetLength())
+	assert.Equal(t, 60, (&TranscriptSummaryConfig{Length: 60}).GetLength())
+
+	c := DefaultConfig()
+	c.Notifications.TranscriptSummary.Length = -1
+	err := c.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "transcriptSummary length must be >= 0")
+}
+
+func TestSessionSummaryConfig(t *testing.T) {
+	assert.False(t, DefaultConfig().Notifications.SessionSummary.Enabled)
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"notifications": {"sessionSummary": {"enabled": true}}}`), 0644))
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+	assert.True(t, cfg.Notifications.SessionSummary.Enabled)
+}
+
+func TestBudgetConfig(t *testing.T) {
+	assert.False(t, DefaultConfig().Notifications.Budget.Enabled())
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"notifications": {"budget": {"tokens": 2000000, "cost": 10}}}`), 0644))
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, BudgetConfig{Tokens: 2000000, Cost: 10}, cfg.Notifications.Budget)
+	assert.True(t, cfg.Notifications.Budget.Enabled())
+	assert.True(t, BudgetConfig{Cost: 0.5}.Enabled())
+
+	cfg.Notifications.Budget.Cost = -1
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "budget cost must be >= 0")
+}
+
+func TestHeartbeatConfig(t *testing.T) {
+	h := DefaultConfig().Notifications.Heartbeat
+	assert.False(t, h.Enabled)
+	assert.Equal(t, DefaultHeartbeatInterval, h.IntervalDuration())
+	assert.Equal(t, []string{"desktop"}, h.GetBackends())
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"notifications": {"heartbeat": {"enabled": true, "interval": "25m", "backends": ["desktop", "webhook"]}}}`), 0644))
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, 25*time.Minute, cfg.Notifications.Heartbeat.IntervalDuration())
+	assert.Equal(t, []string{"desktop", "webhook"}, cfg.Notifications.Heartbeat.GetBackends())
+
+	cfg.Notifications.Heartbeat.Interval = "30s"
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid heartbeat interval")
+
+	cfg.Notifications.Heartbeat.Interval = ""
+	cfg.Notifications.Heartbeat.Backends = []string{"pager"}
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `heartbeat: unknown backend "pager"`)
+}
+
+func TestProgressConfig(t *testing.T) {
+	p := DefaultConfig().Notifications.Progress
+	assert.False(t, p.Enabled)
+	assert.Equal(t, DefaultProgressInterval, p.IntervalDuration())
+	assert.Equal(t, []string{"desktop"}, p.GetBackends())
+	assert.Equal(t, time.Duration(0), (&ProgressConfig{Interval: "0s"}).IntervalDuration())
+
+	c := DefaultConfig()
+	c.Notifications.Progress = ProgressConfig{Enabled: true, Interval: "soon"}
+	err := c.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid progress interval")
+
+	c.Notifications.Progress = ProgressConfig{Enabled: true, Backends: []string{"pager"}}
+	err = c.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `progress: unknown backend "pager"`)
+}
+
+func TestStallConfig(t *testing.T) {
+	s := DefaultConfig().Notifications.Stall
+	assert.False(t, s.Enabled)
+	assert.Equal(t, DefaultStallAfter, s.AfterDuration())
+	assert.Equal(t, 20*time.Minute, (&StallConfig{After: "20m"}).AfterDuration())
+
+	c := DefaultConfig()
+	c.Notifications.Stall = StallConfig{Enabled: true, After: "20s"}
+	err := c.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid stall after")
+}
+
+func TestContentConfig(t *testing.T) {
+	global := ContentConfig{Title: "{{.Project}}: {{.Title}}", Body: "{{.Message}}"}
+	assert.Equal(t, ContentConfig{Title: "{{.Project}}: {{.Title}}", Body: "{{upper .Message}}"},
+		global.Override(ContentConfig{Body: "{{upper .Message}}"}))
+	assert.Equal(t, global, global.Override(ContentConfig{}))
+
+	c := DefaultConfig()
+	assert.False(t, c.UsesContentTemplates())
+	c.Notifications.Webhooks = []WebhookConfig{{Content: ContentConfig{Title: "{{.Title}}"}}}
+	assert.True(t, c.UsesContentTemplates())
+	require.NoError(t, c.Validate())
+
+	tmpl, err := ParseContentTemplate("title", "")
+	assert.Nil(t, tmpl)
+	assert.NoError(t, err)
+}
+
+func TestValidate_Content(t *testing.T) {
+	c := DefaultConfig()
+	c.Notifications.Content.Title = "{{.Title"
+	err := c.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "notifications content: invalid title template")
+
+	c = DefaultConfig()
+	c.Notifications.Desktop.Content.Body = "{{bogus .Message}}"
+	err = c.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "desktop content: invalid body template")
+
+	c = DefaultConfig()
+	c.Notifications.Webhooks = []WebhookConfig{{Name: "ntfy", Content: ContentConfig{Body: "{{end}}"}}}
+	err = c.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ntfy content: invalid body template")
+}
+
+func TestValidate_SoundPlayer(t *testing.T) {
+	for _, player := range []string{"", "auto", "builtin", "system"} {
+		c := DefaultConfig()
+		c.Notifications.Desktop.SoundPlayer = player
+		assert.NoError(t, c.Validate(), player)
+	}
+
+	c := DefaultConfig()
+	c.Notifications.Desktop.SoundPlayer = "vlc"
+	err := c.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid soundPlayer: vlc")
+}
+
+func TestValidate_Speech(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		errMsg string
+	}{
+		{"bad phrase", func(c *Config) { c.Notifications.Speech.Phrase = "{{.Title" }, "speech: invalid phrase template"},
+		{"negative rate", func(c *Config) { c.Notifications.Speech.Rate = -1 }, "speech rate must be >= 0"},
+		{"bad route", func(c *Config) { c.Notifications.Speech.Route.MinElapsed = "soon" }, "speech route: invalid minElapsed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConfig()
+			tt.modify(c)
+			err := c.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+
+	c := DefaultConfig()
+	c.Notifications.Speech = SpeechConfig{Enabled: true, Phrase: "{{.Title}} in {{.Project}}", Voice: "en-us", Rate: 200}
+	c.Notifications.Rules = []Rule{{Actions: RuleActions{Backends: []string{"speech"}}}}
+	assert.NoError(t, c.Validate())
+	assert.True(t, c.IsAnyNotificationEnabled())
+}
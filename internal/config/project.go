@@ -0,0 +1,151 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ProjectConfigName is the per-project config file, found by walking up
+// from the project directory
+const ProjectConfigName = ".claude-notifications.toml"
+
+// projectKeys are the keys a project config may set. A project file comes
+// with the repository, so it can change how notifications look and which
+// backends fire, but not where they are sent: URLs, tokens, hosts and
+// recipients stay in the user's own config.
+var projectKeys = []string{
+	"notifications.desktop",
+	"notifications.webhook.enabled",
+	"notifications.email.enabled",
+	"notifications.speech",
+	"notifications.mqtt.enabled",
+	"notifications.remote.enabled",
+	"notifications.dnd",
+	"notifications.history.enabled",
+	"notifications.rules",
+	"notifications.suppressFilters",
+	"notifications.suppressQuestionAfterTaskCompleteSeconds",
+	"notifications.suppressQuestionAfterAnyNotificationSeconds",
+	"notifications.notifyOnSubagentStop",
+	"notifications.suppressForSubagents",
+	"notifications.notifyOnTextResponse",
+	"statuses",
+}
+
+// FindProjectConfig returns the nearest .claude-notifications.toml in dir
+// or one of its parents, or "" if there is none
+func FindProjectConfig(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, ProjectConfigName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// CheckProjectFile checks a project config like CheckFile, and also
+// reports keys a project file may not set
+func CheckProjectFile(path string) []error {
+	errs := CheckFile(path)
+	values, err := decodeFile(path)
+	if err != nil {
+		return errs
+	}
+	for _, err := range restrictProjectKeys(values) {
+		errs = append(errs, fmt.Errorf("%s: %w", path, err))
+	}
+	return errs
+}
+
+// restrictProjectKeys removes keys outside projectKeys from values and
+// returns an error for each
+func restrictProjectKeys(values map[string]interface{}) []error {
+	var errs []error
+	var walk func(m map[string]interface{}, path string)
+	walk = func(m map[string]interface{}, path string) {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			key := joinKey(path, k)
+			switch projectKeyAccess(key) {
+			case keyAllowed:
+			case keyPartial:
+				if child, ok := m[k].(map[string]interface{}); ok {
+					walk(child, key)
+					if len(child) == 0 {
+						delete(m, k)
+					}
+					continue
+				}
+				fallthrough
+			default:
+				errs = append(errs, fmt.Errorf("%s cannot be set in a project config; set it in your own config", key))
+				delete(m, k)
+			}
+		}
+	}
+	walk(values, "")
+	return errs
+}
+
+type keyAccess int
+
+const (
+	keyDenied  keyAccess = iota
+	keyAllowed           // The key and everything below it
+	keyPartial           // Some keys below it
+)
+
+// projectKeyAccess reports whether a project config may set key
+func projectKeyAccess(key string) keyAccess {
+	for _, allowed := range projectKeys {
+		if key == allowed || strings.HasPrefix(key, allowed+".") {
+			return keyAllowed
+		}
+		if strings.HasPrefix(allowed, key+".") {
+			return keyPartial
+		}
+	}
+	return keyDenied
+}
+
+// applyProjectConfig overlays the project config for dir onto cfg
+func applyProjectConfig(cfg *Config, dir string) (applied bool, warnings []error) {
+	path := FindProjectConfig(dir)
+	if path == "" {
+		return false, nil
+	}
+	values, err := decodeFile(path)
+	if err != nil {
+		return false, []error{err}
+	}
+	for _, err := range restrictProjectKeys(values) {
+		warnings = append(warnings, fmt.Errorf("%s: %w", path, err))
+	}
+	for _, err := range unknownKeys(reflect.TypeOf(Config{}), values, "") {
+		warnings = append(warnings, fmt.Errorf("%s: %w", path, err))
+	}
+	if err := overlay(cfg, values); err != nil {
+		warnings = append(warnings, fmt.Errorf("%s: %w", path, err))
+	}
+	return true, warnings
+}
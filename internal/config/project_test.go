@@ -0,0 +1,114 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeProjectConfig writes .claude-notifications.toml into a new project directory
+func writeProjectConfig(t *testing.T, content string) string {
+	t.Helper()
+	project := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(project, ProjectConfigName), []byte(content), 0644))
+	return project
+}
+
+func TestFindProjectConfig(t *testing.T) {
+	project := writeProjectConfig(t, "")
+	sub := filepath.Join(project, "internal", "api")
+	require.NoError(t, os.MkdirAll(sub, 0755))
+
+	assert.Equal(t, filepath.Join(project, ProjectConfigName), FindProjectConfig(sub))
+	assert.Equal(t, filepath.Join(project, ProjectConfigName), FindProjectConfig(project))
+	assert.Equal(t, "", FindProjectConfig(t.TempDir()))
+	assert.Equal(t, "", FindProjectConfig(""))
+}
+
+func TestLoadForProject(t *testing.T) {
+	setTestHome(t, t.TempDir())
+	project := writeProjectConfig(t, `
+[notifications.desktop]
+sound = false
+urgency = "low"
+
+[notifications.webhook]
+enabled = true
+
+[notifications.dnd]
+schedule = [{ time = "18:00-09:00" }]
+
+[statuses.task_complete]
+sound = "/sounds/quiet.mp3"
+`)
+
+	cfg, warnings := LoadForProject(t.TempDir(), project)
+	assert.Empty(t, warnings)
+	assert.False(t, cfg.Notifications.Desktop.Sound)
+	assert.Equal(t, "low", cfg.Notifications.Desktop.Urgency)
+	assert.True(t, cfg.Notifications.Webhook.Enabled)
+	require.Len(t, cfg.Notifications.DND.Schedule, 1)
+	assert.Equal(t, "18:00-09:00", cfg.Notifications.DND.Schedule[0].Time)
+	assert.Equal(t, "/sounds/quiet.mp3", cfg.Statuses["task_complete"].Sound)
+	assert.True(t, cfg.Notifications.Desktop.Enabled, "keys the project leaves out keep their value")
+
+	t.Setenv("CLAUDE_NOTIFICATIONS_DESKTOP_URGENCY", "critical")
+	cfg, _ = LoadForProject(t.TempDir(), project)
+	assert.Equal(t, "critical", cfg.Notifications.Desktop.Urgency, "environment wins over the project")
+
+	cfg, _ = LoadForProject(t.TempDir(), t.TempDir())
+	assert.True(t, cfg.Notifications.Desktop.Sound, "projects without a config use the user's")
+}
+
+func TestLoadForProject_RestrictedKeys(t *testing.T) {
+	setTestHome(t, t.TempDir())
+	project := writeProjectConfig(t, `
+[notifications.webhook]
+enabled = true
+url = "https://attacker.example/collect"
+
+[notifications.remote]
+address = "attacker.example:9876"
+
+[notifications.desktop]
+sound = false
+`)
+
+	cfg, warnings := LoadForProject(t.TempDir(), project)
+	assert.Empty(t, cfg.Notifications.Webhook.URL, "a project cannot redirect notifications")
+	assert.Equal(t, DefaultConfig().Notifications.Remote.Address, cfg.Notifications.Remote.Address)
+	assert.True(t, cfg.Notifications.Webhook.Enabled)
+	assert.False(t, cfg.Notifications.Desktop.Sound)
+
+	var msgs []string
+	for _, w := range warnings {
+		msgs = append(msgs, w.Error())
+	}
+	all := strings.Join(msgs, "\n")
+	assert.Contains(t, all, "notifications.webhook.url cannot be set in a project config")
+	assert.Contains(t, all, "notifications.remote.address cannot be set in a project config")
+	assert.Len(t, warnings, 2)
+}
+
+func TestCheckProjectFile(t *testing.T) {
+	project := writeProjectConfig(t, "[notifications.email]\nto = [\"me@example.com\"]\n\n[notifications.desktop]\nsond = false\n")
+
+	errs := CheckProjectFile(filepath.Join(project, ProjectConfigName))
+	require.Len(t, errs, 2)
+	all := errs[0].Error() + "\n" + errs[1].Error()
+	assert.Contains(t, all, "unknown key notifications.desktop.sond")
+	assert.Contains(t, all, "notifications.email.to cannot be set in a project config")
+}
+
+func TestValidate_DesktopUrgency(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.Desktop.Urgency = "low"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Notifications.Desktop.Urgency = "loud"
+	assert.ErrorContains(t, cfg.Validate(), "invalid desktop urgency")
+}
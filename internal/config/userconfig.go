@@ -0,0 +1,453 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts every environment variable that overrides a config key
+const EnvPrefix = "CLAUDE_NOTIFICATIONS_"
+
+// userConfigNames are the user config files, in order of preference
+var userConfigNames = []string{"config.toml", "config.yaml", "config.yml"}
+
+// nonConfigEnv are variables with EnvPrefix that are not config overrides
+var nonConfigEnv = map[string]bool{
+	EnvPrefix + "BIN":   true,
+	EnvPrefix + "DEBUG": true,
+	// Set by hooks for the worker the daemon handles them in
+	EnvPrefix + "TERMINAL": true,
+	EnvPrefix + "TTY":      true,
+}
+
+// retiredKeys are keys that older config files still carry; they are
+// ignored without a warning
+var retiredKeys = map[reflect.Type]map[string]bool{
+	reflect.TypeOf(StatusInfo{}): {"keywords": true},
+}
+
+// GetUserConfigDir returns the directory of the hand-written config file:
+// $XDG_CONFIG_HOME/claude-notifications, or ~/.config/claude-notifications
+func GetUserConfigDir() (string, error) {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "claude-notifications"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "claude-notifications"), nil
+}
+
+// FindUserConfigFile returns the user config file (config.toml,
+// config.yaml or config.yml), or "" if there is none
+func FindUserConfigFile() (string, error) {
+	dir, err := GetUserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	for _, name := range userConfigNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
+// CheckFile checks a JSON, TOML or YAML config file against the config
+// schema without loading it: syntax errors, unknown keys and values of
+// the wrong type, each naming the offending key
+func CheckFile(path string) []error {
+	values, err := decodeFile(path)
+	if err != nil {
+		return []error{err}
+	}
+	errs := unknownKeys(reflect.TypeOf(Config{}), values, "")
+	if err := overlay(DefaultConfig(), values); err != nil {
+		errs = append(errs, err)
+	}
+	for i, err := range errs {
+		errs[i] = fmt.Errorf("%s: %w", path, err)
+	}
+	return errs
+}
+
+// applyOverrides overlays the user config file, the project config for
+// projectDir and then environment overrides onto cfg. Keys a source
+// leaves out keep their current value. Problems are returned as warnings:
+// everything valid is still applied.
+func applyOverrides(cfg *Config, projectDir string, environ []string) (applied bool, warnings []error) {
+	path, err := FindUserConfigFile()
+	if err != nil {
+		warnings = append(warnings, err)
+	}
+	if path != "" {
+		values, err := decodeFile(path)
+		if err != nil {
+			warnings = append(warnings, err)
+		} else {
+			for _, err := range unknownKeys(reflect.TypeOf(Config{}), values, "") {
+				warnings = append(warnings, fmt.Errorf("%s: %w", path, err))
+			}
+			if err := overlay(cfg, values); err != nil {
+				warnings = append(warnings, fmt.Errorf("%s: %w", path, err))
+			}
+			applied = true
+		}
+	}
+
+	projectApplied, projectWarnings := applyProjectConfig(cfg, projectDir)
+	warnings = append(warnings, projectWarnings...)
+
+	names, errs := ApplyEnv(cfg, environ)
+	warnings = append(warnings, errs...)
+	return applied || projectApplied || len(names) > 0, warnings
+}
+
+// decodeFile parses a config file by extension into generic values
+func decodeFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	values := map[string]interface{}{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".toml":
+		if _, err := toml.Decode(string(data), &values); err != nil {
+			// Parse errors read "toml: line N (last key ...): ..."
+			return nil, fmt.Errorf("%s: %s", path, strings.TrimPrefix(err.Error(), "toml: "))
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil, fmt.Errorf("%s: %s", path, strings.TrimPrefix(err.Error(), "yaml: "))
+		}
+	case ".json":
+		if err := json.Unmarshal(data, &values); err != nil {
+			var serr *json.SyntaxError
+			if errors.As(err, &serr) {
+				line := bytes.Count(data[:serr.Offset], []byte("\n")) + 1
+				return nil, fmt.Errorf("%s: line %d: %w", path, line, err)
+			}
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("%s: unsupported config format %q (use .toml, .yaml or .json)", path, ext)
+	}
+
+	// Normalize TOML and YAML values (tables, typed lists) to their JSON form
+	data, err = json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("%s: unsupported value: %w", path, err)
+	}
+	values = map[string]interface{}{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return values, nil
+}
+
+// overlay decodes generic values onto cfg through their JSON form, so
+// TOML and YAML use exactly the keys of config.json
+func overlay(cfg *Config, values map[string]interface{}) error {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		var terr *json.UnmarshalTypeError
+		if errors.As(err, &terr) && terr.Field != "" {
+			return fmt.Errorf("%s: expected %s, got %s", terr.Field, typeName(terr.Type), terr.Value)
+		}
+		return err
+	}
+	return nil
+}
+
+// unknownKeys returns an error for every key in v that t has no field
+// for, with a suggestion when the key looks like a typo
+func unknownKeys(t reflect.Type, v interface{}, path string) []error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var errs []error
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil // Wrong type, reported by overlay
+		}
+		fields := jsonFields(t)
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			f, ok := fields[k]
+			if !ok && retiredKeys[t][k] {
+				continue
+			}
+			if !ok {
+				errs = append(errs, unknownKeyError(joinKey(path, k), k, fields))
+				continue
+			}
+			errs = append(errs, unknownKeys(f.Type, m[k], joinKey(path, k))...)
+		}
+	case reflect.Map:
+		if m, ok := v.(map[string]interface{}); ok {
+			for k, item := range m {
+				errs = append(errs, unknownKeys(t.Elem(), item, joinKey(path, k))...)
+			}
+		}
+	case reflect.Slice:
+		if items, ok := v.([]interface{}); ok {
+			for i, item := range items {
+				errs = append(errs, unknownKeys(t.Elem(), item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return errs
+}
+
+// unknownKeyError names the unknown key and the closest known one
+func unknownKeyError(fullKey, key string, fields map[string]reflect.StructField) error {
+	best, bestDist := "", 3 // Suggest only close matches
+	for name := range fields {
+		d := editDistance(strings.ToLower(key), strings.ToLower(name))
+		if d < bestDist || (d == bestDist && name < best) {
+			best, bestDist = name, d
+		}
+	}
+	if best != "" {
+		return fmt.Errorf("unknown key %s (did you mean %s?)", fullKey, best)
+	}
+	return fmt.Errorf("unknown key %s", fullKey)
+}
+
+// jsonFields maps JSON key names to the struct fields they decode into
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+// ApplyEnv sets config keys from CLAUDE_NOTIFICATIONS_* variables in
+// environ ("KEY=value" pairs, as from os.Environ). The variable name is
+// the key path without the leading "notifications", in upper snake case:
+// notifications.desktop.clickToFocus is CLAUDE_NOTIFICATIONS_DESKTOP_CLICK_TO_FOCUS.
+// List values take comma-separated items. It returns the variables applied.
+func ApplyEnv(cfg *Config, environ []string) ([]string, []error) {
+	keys := EnvKeys()
+	var applied []string
+	var errs []error
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, EnvPrefix) || nonConfigEnv[name] {
+			continue
+		}
+		path, ok := keys[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: no config key has this name", name))
+			continue
+		}
+		if err := setPath(reflect.ValueOf(cfg).Elem(), path, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s (%s): %w", name, strings.Join(path, "."), err))
+			continue
+		}
+		applied = append(applied, name)
+	}
+	sort.Strings(applied)
+	return applied, errs
+}
+
+// EnvKeys maps each override variable to the config key path it sets.
+// Only scalar keys and string lists outside maps and object lists can
+// be overridden.
+func EnvKeys() map[string][]string {
+	keys := map[string][]string{}
+	var walk func(t reflect.Type, path []string)
+	walk = func(t reflect.Type, path []string) {
+		for name, f := range jsonFields(t) {
+			p := append(append([]string{}, path...), name)
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			switch {
+			case ft.Kind() == reflect.Struct:
+				walk(ft, p)
+			case isScalar(ft.Kind()) || (ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.String):
+				envPath := p
+				if envPath[0] == "notifications" {
+					envPath = envPath[1:]
+				}
+				var parts []string
+				for _, s := range envPath {
+					parts = append(parts, snakeUpper(s))
+				}
+				keys[EnvPrefix+strings.Join(parts, "_")] = p
+			}
+		}
+	}
+	walk(reflect.TypeOf(Config{}), nil)
+	return keys
+}
+
+// setPath parses value into the field at path below v
+func setPath(v reflect.Value, path []string, value string) error {
+	for _, name := range path {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		f, ok := jsonFields(v.Type())[name]
+		if !ok {
+			return fmt.Errorf("unknown key %s", name)
+		}
+		v = v.FieldByIndex(f.Index)
+	}
+	if v.Kind() == reflect.Ptr {
+		elem := reflect.New(v.Type().Elem())
+		if err := setScalar(elem.Elem(), value); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+	return setScalar(v, value)
+}
+
+// setScalar parses value into a bool, number, string or string list
+func setScalar(v reflect.Value, value string) error {
+	value = strings.TrimSpace(value)
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("expected true or false, got %q", value)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("expected a whole number, got %q", value)
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("expected a number, got %q", value)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("cannot be set from the environment")
+	}
+	return nil
+}
+
+// isScalar reports whether a kind can be parsed from a single string
+func isScalar(k reflect.Kind) bool {
+	switch k {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// typeName describes a Go type in config terms for error messages
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int64:
+		return "a whole number"
+	case reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice:
+		return "a list"
+	case reflect.Map, reflect.Struct:
+		return "a table"
+	}
+	return t.String()
+}
+
+// snakeUpper converts a camelCase key to UPPER_SNAKE_CASE
+func snakeUpper(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) && i > 0 && s[i-1] != '_' {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// joinKey appends a key to a dotted path
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeUserConfig writes a file into the user config directory of a fresh home
+func writeUserConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	home := t.TempDir()
+	setTestHome(t, home)
+	dir := filepath.Join(home, ".config", "claude-notifications")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestGetUserConfigDir(t *testing.T) {
+	home := t.TempDir()
+	setTestHome(t, home)
+	dir, err := GetUserConfigDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".config", "claude-notifications"), dir)
+
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	dir, err = GetUserConfigDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/xdg", "claude-notifications"), dir)
+}
+
+func TestLoadFromPluginRoot_UserConfigTOML(t *testing.T) {
+	writeUserConfig(t, "config.toml", `
+[notifications.desktop]
+sound = false
+volume = 0.4
+
+[notifications.desktop.throttle]
+maxPerMinute = 3
+
+[statuses.task_complete]
+title = "Done"
+`)
+
+	cfg, err := LoadFromPluginRoot(t.TempDir())
+	require.NoError(t, err)
+	assert.False(t, cfg.Notifications.Desktop.Sound)
+	assert.Equal(t, 0.4, cfg.Notifications.Desktop.Volume)
+	assert.Equal(t, 3, cfg.Notifications.Desktop.Throttle.MaxPerMinute)
+	assert.Equal(t, 10, cfg.Notifications.Desktop.Throttle.CoalesceSeconds, "keys left out keep their defaults")
+	assert.True(t, cfg.Notifications.Desktop.Enabled)
+	assert.Equal(t, "Done", cfg.Statuses["task_complete"].Title)
+	assert.Contains(t, cfg.Statuses, "question", "statuses left out are filled in")
+}
+
+func TestLoadFromPluginRoot_UserConfigYAMLOverridesJSON(t *testing.T) {
+	writeUserConfig(t, "config.yaml", `
+notifications:
+  webhook:
+    enabled: true
+    preset: ntfy
+    url: https://ntfy.sh/mytopic
+`)
+	stable, err := GetStableConfigPath()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(stable), 0755))
+	require.NoError(t, os.WriteFile(stable, []byte(`{"notifications":{"desktop":{"sound":false},"webhook":{"url":"https://old"}}}`), 0644))
+
+	cfg, err := LoadFromPluginRoot(t.TempDir())
+	require.NoError(t, err)
+	assert.False(t, cfg.Notifications.Desktop.Sound, "config.json keys the YAML leaves out are kept")
+	assert.Equal(t, "https://ntfy.sh/mytopic", cfg.Notifications.Webhook.URL)
+	assert.Equal(t, "ntfy", cfg.Notifications.Webhook.Preset)
+}
+
+func TestLoadFromPluginRoot_EnvOverrides(t *testing.T) {
+	writeUserConfig(t, "config.toml", "[notifications.desktop]\nsound = true\n")
+	t.Setenv("CLAUDE_NOTIFICATIONS_DESKTOP_SOUND", "false")
+	t.Setenv("CLAUDE_NOTIFICATIONS_WEBHOOK_URL", "https://hooks.example/${TEST_HOOK_ID}")
+	t.Setenv("TEST_HOOK_ID", "abc")
+
+	cfg, err := LoadFromPluginRoot(t.TempDir())
+	require.NoError(t, err)
+	assert.False(t, cfg.Notifications.Desktop.Sound, "environment wins over the file")
+	assert.Equal(t, "https://hooks.example/abc", cfg.Notifications.Webhook.URL, "${VAR} is expanded in overrides too")
+}
+
+func TestApplyEnv(t *testing.T) {
+	cfg := DefaultConfig()
+	applied, errs := ApplyEnv(cfg, []string{
+		"CLAUDE_NOTIFICATIONS_DESKTOP_CLICK_TO_FOCUS=false",
+		"CLAUDE_NOTIFICATIONS_DESKTOP_VOLUME=0.5",
+		"CLAUDE_NOTIFICATIONS_HISTORY_ENABLED=false",
+		"CLAUDE_NOTIFICATIONS_EMAIL_TO=a@example.com, b@example.com",
+		"CLAUDE_NOTIFICATIONS_SUPPRESS_QUESTION_AFTER_TASK_COMPLETE_SECONDS=30",
+		"CLAUDE_NOTIFICATIONS_WEBHOOK_CHAT_ID=42",
+		"CLAUDE_NOTIFICATIONS_DEBUG=1",
+		"PATH=/usr/bin",
+	})
+	assert.Empty(t, errs)
+	assert.Len(t, applied, 6)
+	assert.False(t, cfg.Notifications.Desktop.ClickToFocus)
+	assert.Equal(t, 0.5, cfg.Notifications.Desktop.Volume)
+	assert.False(t, cfg.IsHistoryEnabled())
+	assert.Equal(t, []string{"a@example.com", "b@example.com"}, cfg.Notifications.Email.To)
+	assert.Equal(t, 30, cfg.GetSuppressQuestionAfterTaskCompleteSeconds())
+	assert.Equal(t, "42", cfg.Notifications.Webhook.ChatID)
+}
+
+func TestApplyEnv_Errors(t *testing.T) {
+	cfg := DefaultConfig()
+	_, errs := ApplyEnv(cfg, []string{
+		"CLAUDE_NOTIFICATIONS_DESKTOP_VOLUME=loud",
+		"CLAUDE_NOTIFICATIONS_DESKTOP_SOND=false",
+	})
+	require.Len(t, errs, 2)
+	msgs := errs[0].Error() + "\n" + errs[1].Error()
+	assert.Contains(t, msgs, "CLAUDE_NOTIFICATIONS_DESKTOP_VOLUME (notifications.desktop.volume): expected a number")
+	assert.Contains(t, msgs, "CLAUDE_NOTIFICATIONS_DESKTOP_SOND: no config key")
+	assert.Equal(t, 1.0, cfg.Notifications.Desktop.Volume, "invalid values are not applied")
+}
+
+func TestEnvKeysAreUnique(t *testing.T) {
+	keys := EnvKeys()
+	assert.Equal(t, []string{"notifications", "desktop", "throttle", "maxPerMinute"}, keys["CLAUDE_NOTIFICATIONS_DESKTOP_THROTTLE_MAX_PER_MINUTE"])
+	for name := range nonConfigEnv {
+		assert.NotContains(t, keys, name)
+	}
+	seen := map[string]string{}
+	for name, path := range keys {
+		p := strings.Join(path, ".")
+		if other, ok := seen[p]; ok {
+			t.Errorf("%s and %s both set %s", name, other, p)
+		}
+		seen[p] = name
+	}
+}
+
+func TestCheckFile(t *testing.T) {
+	path := writeUserConfig(t, "config.toml", `
+[notifications.desktop]
+sond = true
+volume = "loud"
+
+[[notifications.webhooks]]
+name = "team"
+presett = "slack"
+
+[statuses.task_complete]
+titel = "Done"
+`)
+
+	errs := CheckFile(path)
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	all := strings.Join(msgs, "\n")
+	assert.Contains(t, all, "unknown key notifications.desktop.sond (did you mean sound?)")
+	assert.Contains(t, all, "unknown key notifications.webhooks[0].presett (did you mean preset?)")
+	assert.Contains(t, all, "unknown key statuses.task_complete.titel (did you mean title?)")
+	assert.Contains(t, all, "notifications.desktop.volume: expected a number, got string")
+	assert.True(t, strings.HasPrefix(msgs[0], path+": "), "errors name the file")
+}
+
+func TestCheckFile_Syntax(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"config.toml", "[notifications.desktop]\nsound = \n", "expected value"},
+		{"config.yaml", "notifications:\n  desktop: [\n", "line 2"},
+		{"config.json", "{\n\"notifications\": {,\n}", "line 2"},
+		{"config.ini", "sound=1", "unsupported config format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := CheckFile(writeUserConfig(t, tt.name, tt.content))
+			require.Len(t, errs, 1)
+			assert.Contains(t, errs[0].Error(), tt.want)
+		})
+	}
+
+	assert.Empty(t, CheckFile(writeUserConfig(t, "config.yml", "notifications:\n  desktop:\n    sound: false\n")))
+	assert.Empty(t, CheckFile(writeUserConfig(t, "config.json", `{"statuses":{"question":{"title":"?","keywords":["ask"]}}}`)),
+		"retired keys from older config files are not reported")
+}
+
+func TestLoadFromPluginRoot_InvalidUserConfigIsNonFatal(t *testing.T) {
+	writeUserConfig(t, "config.toml", "[notifications.desktop\n")
+
+	cfg, err := LoadFromPluginRoot(t.TempDir())
+	require.NoError(t, err)
+	assert.True(t, cfg.Notifications.Desktop.Enabled, "defaults are used")
+}
+
+func TestSnakeUpper(t *testing.T) {
+	assert.Equal(t, "CLICK_TO_FOCUS", snakeUpper("clickToFocus"))
+	assert.Equal(t, "CHAT_ID", snakeUpper("chat_id"))
+	assert.Equal(t, "URL", snakeUpper("url"))
+}
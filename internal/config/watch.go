@@ -0,0 +1,305 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultWatchInterval is how often a Watcher checks the config files
+const DefaultWatchInterval = 2 * time.Second
+
+// secretKeys are key names whose values are never shown in a diff:
+// URLs carry webhook secrets, and the rest are credentials
+var secretKeys = map[string]bool{
+	"url":      true,
+	"headers":  true,
+	"token":    true,
+	"bottoken": true,
+	"apptoken": true,
+	"userkey":  true,
+	"password": true,
+	"key":      true,
+}
+
+// Change is one key that differs between two configs. Old and New are
+// JSON values; "" means the key is not set.
+type Change struct {
+	Key string `json:"key"`
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+// String formats a change as "key: old → new"
+func (c Change) String() string {
+	from, to := c.Old, c.New
+	if from == "" {
+		from = "(unset)"
+	}
+	if to == "" {
+		to = "(unset)"
+	}
+	return fmt.Sprintf("%s: %s → %s", c.Key, from, to)
+}
+
+// Diff returns the keys that differ between prev and next, sorted by key.
+// Values of secrets (URLs, tokens, passwords, headers) are redacted.
+func Diff(prev, next *Config) []Change {
+	before := flatten(prev)
+	after := flatten(next)
+
+	keys := make(map[string]bool, len(before)+len(after))
+	for k := range before {
+		keys[k] = true
+	}
+	for k := range after {
+		keys[k] = true
+	}
+
+	var changes []Change
+	for k := range keys {
+		if before[k] == after[k] {
+			continue
+		}
+		c := Change{Key: k, Old: before[k], New: after[k]}
+		if isSecretKey(k) {
+			c.Old, c.New = maskSecret(c.Old), maskSecret(c.New)
+		}
+		changes = append(changes, c)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// flatten maps every leaf of cfg to its JSON value, keyed by its dotted
+// path. Lists of objects are indexed ("rules[0].match.status"); lists of
+// scalars are a single value.
+func flatten(cfg *Config) map[string]string {
+	out := map[string]string{}
+	if cfg == nil {
+		return out
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return out
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return out
+	}
+
+	var walk func(v interface{}, path string)
+	walk = func(v interface{}, path string) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, child := range v {
+				walk(child, joinKey(path, k))
+			}
+			return
+		case []interface{}:
+			if hasObjects(v) {
+				for i, child := range v {
+					walk(child, fmt.Sprintf("%s[%d]", path, i))
+				}
+				return
+			}
+		}
+		b, _ := json.Marshal(v)
+		out[path] = string(b)
+	}
+	walk(v, "")
+	return out
+}
+
+// hasObjects reports whether a list holds objects or lists
+func hasObjects(list []interface{}) bool {
+	for _, v := range list {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			return true
+		}
+	}
+	return false
+}
+
+// isSecretKey reports whether any segment of a dotted key names a secret
+func isSecretKey(key string) bool {
+	for _, part := range strings.Split(key, ".") {
+		if i := strings.IndexByte(part, '['); i >= 0 {
+			part = part[:i]
+		}
+		if secretKeys[strings.ToLower(part)] {
+			return true
+		}
+	}
+	return false
+}
+
+// maskSecret hides a secret value, keeping whether it was set
+func maskSecret(value string) string {
+	if value == "" || value == `""` || value == "null" || value == "{}" {
+		return value
+	}
+	return "(redacted)"
+}
+
+// Reload is the outcome of loading the config again
+type Reload struct {
+	Config   *Config  // The config in effect after the reload
+	Changes  []Change // What changed (empty = nothing)
+	Warnings []error  // Problems skipped over while loading
+	Err      error    // The new config is invalid and was not applied
+}
+
+// Watcher keeps a long-running process on the current config: it loads
+// the config again when one of its files changes or Reload is called,
+// and reports what changed. An invalid config is reported and ignored,
+// so the last good config stays in effect.
+type Watcher struct {
+	pluginRoot string
+	interval   time.Duration
+	onReload   func(Reload)
+
+	mu      sync.Mutex
+	current *Config
+	stamps  map[string]fileStamp
+}
+
+// fileStamp identifies a version of a file (zero = missing)
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewWatcher loads the config for pluginRoot. onReload is called after
+// every reload, from Reload or from Run, with the outcome.
+func NewWatcher(pluginRoot string, onReload func(Reload)) (*Watcher, []error) {
+	w := &Watcher{
+		pluginRoot: pluginRoot,
+		interval:   DefaultWatchInterval,
+		onReload:   onReload,
+	}
+	w.stamps = w.stat()
+	cfg, warnings := w.load()
+	w.current = cfg
+	return w, warnings
+}
+
+// load loads the config with defaults applied, also when no file exists,
+// so reloads compare like with like
+func (w *Watcher) load() (*Config, []error) {
+	cfg, warnings := LoadWithWarnings(w.pluginRoot)
+	cfg.ApplyDefaults()
+	return cfg, warnings
+}
+
+// Config returns the config in effect
+func (w *Watcher) Config() *Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// Paths returns the files the watcher checks for changes: config.json
+// (stable and legacy locations) and the user config files
+func (w *Watcher) Paths() []string {
+	var paths []string
+	if stable, err := GetStableConfigPath(); err == nil {
+		paths = append(paths, stable)
+	}
+	paths = append(paths, filepath.Join(w.pluginRoot, "config", "config.json"))
+	if dir, err := GetUserConfigDir(); err == nil {
+		for _, name := range userConfigNames {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+	}
+	return paths
+}
+
+// Run checks the config files every interval and reloads when one of
+// them was created, changed or removed, until done is closed
+func (w *Watcher) Run(done <-chan struct{}) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if w.filesChanged() {
+				w.Reload()
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// Reload loads the config again and applies it if it is valid. A config
+// file that does not parse, as while an editor is still writing it, keeps
+// the current config instead of falling back to the next source.
+func (w *Watcher) Reload() Reload {
+	w.mu.Lock()
+	w.stamps = w.stat()
+	cfg, warnings := w.load()
+	r := Reload{Warnings: warnings}
+	if err := w.parseFiles(); err != nil {
+		r.Err = err
+		r.Config = w.current
+	} else if err := cfg.Validate(); err != nil {
+		r.Err = err
+		r.Config = w.current
+	} else {
+		r.Changes = Diff(w.current, cfg)
+		w.current = cfg
+		r.Config = cfg
+	}
+	w.mu.Unlock()
+
+	if w.onReload != nil {
+		w.onReload(r)
+	}
+	return r
+}
+
+// parseFiles returns the first error parsing a watched file that exists
+func (w *Watcher) parseFiles() error {
+	for path, s := range w.stamps {
+		if s.modTime.IsZero() {
+			continue
+		}
+		if _, err := decodeFile(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filesChanged reports whether a config file differs from the last reload
+func (w *Watcher) filesChanged() bool {
+	stamps := w.stat()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for path, s := range stamps {
+		if w.stamps[path] != s {
+			return true
+		}
+	}
+	return false
+}
+
+// stat records the current version of every watched file
+func (w *Watcher) stat() map[string]fileStamp {
+	stamps := map[string]fileStamp{}
+	for _, path := range w.Paths() {
+		if info, err := os.Stat(path); err == nil {
+			stamps[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		} else {
+			stamps[path] = fileStamp{}
+		}
+	}
+	return stamps
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	prev := DefaultConfig()
+	next := DefaultConfig()
+	next.Notifications.Desktop.Sound = false
+	next.Notifications.DND.Mode = "downgrade"
+	next.Notifications.Rules = []Rule{{Name: "quiet", Actions: RuleActions{Suppress: true}}}
+
+	changes := Diff(prev, next)
+	keys := make([]string, len(changes))
+	for i, c := range changes {
+		keys[i] = c.Key
+	}
+	assert.Contains(t, keys, "notifications.desktop.sound")
+	assert.Contains(t, keys, "notifications.dnd.mode")
+	assert.Contains(t, keys, "notifications.rules[0].name")
+	assert.IsIncreasing(t, keys)
+
+	for _, c := range changes {
+		if c.Key == "notifications.desktop.sound" {
+			assert.Equal(t, "true", c.Old)
+			assert.Equal(t, "false", c.New)
+			assert.Equal(t, "notifications.desktop.sound: true → false", c.String())
+		}
+		if c.Key == "notifications.rules[0].name" {
+			assert.Equal(t, "", c.Old)
+			assert.Equal(t, "notifications.rules[0].name: (unset) → \"quiet\"", c.String())
+		}
+	}
+
+	assert.Empty(t, Diff(DefaultConfig(), DefaultConfig()))
+}
+
+func TestDiff_RedactsSecrets(t *testing.T) {
+	prev := DefaultConfig()
+	next := DefaultConfig()
+	next.Notifications.Webhook.URL = "https://hooks.slack.com/services/T000/B000/secret"
+	next.Notifications.Webhook.Headers = map[string]string{"Authorization": "Bearer abc"}
+	next.Notifications.Email.Password = "hunter2"
+
+	changes := Diff(prev, next)
+	require.NotEmpty(t, changes)
+	for _, c := range changes {
+		assert.NotContains(t, c.String(), "secret", c.Key)
+		assert.NotContains(t, c.String(), "Bearer", c.Key)
+		assert.NotContains(t, c.String(), "hunter2", c.Key)
+	}
+
+	for _, c := range changes {
+		if c.Key == "notifications.webhook.url" {
+			assert.Equal(t, `""`, c.Old)
+			assert.Equal(t, "(redacted)", c.New)
+		}
+	}
+}
+
+func TestDiff_ScalarListIsOneValue(t *testing.T) {
+	prev := DefaultConfig()
+	next := DefaultConfig()
+	next.Notifications.DND.Schedule = []DNDWindow{{Days: []string{"weekends"}, Time: "00:00-23:59"}}
+
+	changes := Diff(prev, next)
+	var keys []string
+	for _, c := range changes {
+		keys = append(keys, c.Key)
+	}
+	assert.Contains(t, keys, "notifications.dnd.schedule[0].days")
+	assert.NotContains(t, keys, "notifications.dnd.schedule[0].days[0]")
+}
+
+// writeStableConfig writes config.json in the stable config directory of home
+func writeStableConfig(t *testing.T, home, content string) string {
+	t.Helper()
+	dir := filepath.Join(home, ".claude", "claude-notifications-go")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	path := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestWatcher_Reload(t *testing.T) {
+	home := t.TempDir()
+	setTestHome(t, home)
+	path := writeStableConfig(t, home, `{"notifications": {"desktop": {"enabled": true, "sound": true}}}`)
+
+	var reported []Reload
+	w, warnings := NewWatcher(t.TempDir(), func(r Reload) { reported = append(reported, r) })
+	assert.Empty(t, warnings)
+	assert.True(t, w.Config().Notifications.Desktop.Sound)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"notifications": {"desktop": {"enabled": true, "sound": false}}}`), 0644))
+	r := w.Reload()
+
+	require.NoError(t, r.Err)
+	assert.False(t, w.Config().Notifications.Desktop.Sound)
+	assert.Same(t, w.Config(), r.Config)
+	require.Len(t, r.Changes, 1)
+	assert.Equal(t, "notifications.desktop.sound", r.Changes[0].Key)
+	require.Len(t, reported, 1)
+	assert.Equal(t, r.Changes, reported[0].Changes)
+
+	// Nothing changed since
+	assert.Empty(t, w.Reload().Changes)
+}
+
+func TestWatcher_KeepsConfigWhenInvalid(t *testing.T) {
+	home := t.TempDir()
+	setTestHome(t, home)
+	path := writeStableConfig(t, home, `{"notifications": {"desktop": {"enabled": true, "sound": false}}}`)
+
+	w, _ := NewWatcher(t.TempDir(), nil)
+	before := w.Config()
+
+	// Fails validation
+	require.NoError(t, os.WriteFile(path, []byte(`{"notifications": {"desktop": {"enabled": true, "urgency": "loud"}}}`), 0644))
+	r := w.Reload()
+	require.Error(t, r.Err)
+	assert.Same(t, before, w.Config())
+
+	// Half-written file: would otherwise fall back to the defaults
+	require.NoError(t, os.WriteFile(path, []byte(`{"notifications": {"desktop": {"ena`), 0644))
+	r = w.Reload()
+	require.Error(t, r.Err)
+	assert.Same(t, before, w.Config())
+	assert.False(t, w.Config().Notifications.Desktop.Sound)
+}
+
+func TestWatcher_RunReloadsOnChange(t *testing.T) {
+	home := t.TempDir()
+	setTestHome(t, home)
+
+	reloads := make(chan Reload, 4)
+	w, _ := NewWatcher(t.TempDir(), func(r Reload) { reloads <- r })
+	w.interval = 10 * time.Millisecond
+	done := make(chan struct{})
+	defer close(done)
+	go w.Run(done)
+
+	// A user config file that did not exist at startup
+	dir := filepath.Join(home, ".config", "claude-notifications")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.toml"), []byte("[notifications.dnd]\nmode = \"downgrade\"\n"), 0644))
+
+	select {
+	case r := <-reloads:
+		require.NoError(t, r.Err)
+		assert.Equal(t, "downgrade", r.Config.Notifications.DND.Mode)
+		require.NotEmpty(t, r.Changes)
+	case <-time.After(5 * time.Second):
+		t.Fatal("watcher did not reload after the user config file was created")
+	}
+}
@@ -0,0 +1,54 @@
+//go:build linux
+
+// ABOUTME: Tracks when the user last acknowledged a session's notifications.
+// ABOUTME: Clicking, dismissing or returning to the window counts; escalations ask before sending.
+package daemon
+
+import (
+	"log"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/sessions"
+)
+
+// ackMaxAge drops acknowledgements older than any escalation waits
+const ackMaxAge = 24 * time.Hour
+
+// acknowledge records that the user saw the notifications of a coalesce key.
+// For a session's key it is also marked on disk, where focus-pending, the
+// status bar and the prompt segment read it.
+func (s *Server) acknowledge(coalesceKey string, at time.Time) {
+	if coalesceKey == "" {
+		return
+	}
+	s.acksMu.Lock()
+	for key, t := range s.acks {
+		if at.Sub(t) > ackMaxAge {
+			delete(s.acks, key)
+		}
+	}
+	s.acks[coalesceKey] = at
+	s.acksMu.Unlock()
+
+	if s.sessions == nil || s.sessions.get(coalesceKey) == nil {
+		return
+	}
+	if dir, err := config.GetStableConfigDir(); err == nil {
+		if err := sessions.NewAcks(dir).Mark(coalesceKey, at); err != nil {
+			log.Printf("[WARN] Failed to mark session %s acknowledged: %v", coalesceKey, err)
+		}
+	}
+}
+
+// handleAcknowledged reports when the notifications of a coalesce key were
+// last acknowledged
+func (s *Server) handleAcknowledged(req *AcknowledgedRequest) *AcknowledgedResponse {
+	s.acksMu.Lock()
+	defer s.acksMu.Unlock()
+	at, ok := s.acks[req.CoalesceKey]
+	if !ok {
+		return &AcknowledgedResponse{}
+	}
+	return &AcknowledgedResponse{AcknowledgedAt: &at}
+}
@@ -0,0 +1,82 @@
+//go:build linux
+
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/sessions"
+	"github.com/esiqveland/notify"
+)
+
+func TestHandleConnection_Acknowledged(t *testing.T) {
+	s := newTestServer(t)
+	s.focusCtx[1] = focusInfo{target: "kitty", coalesceKey: "session-a"}
+	s.focusCtx[2] = focusInfo{target: "kitty", coalesceKey: "session-b"}
+
+	ask := func(key string) *AcknowledgedResponse {
+		t.Helper()
+		resp := roundTrip(t, s, Request{Type: MessageTypeAcked, Version: ProtocolVersion, Acked: &AcknowledgedRequest{CoalesceKey: key}})
+		if resp.Error != "" || resp.Acked == nil {
+			t.Fatalf("acknowledged response = %+v", resp)
+		}
+		return resp.Acked
+	}
+
+	if got := ask("session-a"); got.AcknowledgedAt != nil {
+		t.Errorf("acknowledged at %v before any click", got.AcknowledgedAt)
+	}
+
+	before := time.Now()
+	s.onNotificationClosed(&notify.NotificationClosedSignal{ID: 1, Reason: notify.ReasonDismissedByUser})
+	s.onNotificationClosed(&notify.NotificationClosedSignal{ID: 2, Reason: notify.ReasonExpired})
+
+	if got := ask("session-a"); got.AcknowledgedAt == nil || got.AcknowledgedAt.Before(before) {
+		t.Errorf("dismissed by the user: acknowledged at %v, want after %v", got.AcknowledgedAt, before)
+	}
+	if got := ask("session-b"); got.AcknowledgedAt != nil {
+		t.Errorf("an expired notification should not count, acknowledged at %v", got.AcknowledgedAt)
+	}
+
+	if resp := roundTrip(t, s, Request{Type: MessageTypeAcked, Version: ProtocolVersion}); resp.Error == "" {
+		t.Error("acknowledged without a payload should fail")
+	}
+}
+
+func TestAcknowledge_DropsOldEntries(t *testing.T) {
+	s := newTestServer(t)
+	now := time.Now()
+	s.acknowledge("old", now.Add(-2*ackMaxAge))
+	s.acknowledge("", now)
+	s.acknowledge("new", now)
+	if _, ok := s.acks["old"]; ok {
+		t.Error("an acknowledgement older than ackMaxAge should be dropped")
+	}
+	if len(s.acks) != 1 {
+		t.Errorf("acks = %v, want only the new one", s.acks)
+	}
+}
+
+func TestAcknowledge_MarksSession(t *testing.T) {
+	s := newTestServer(t)
+	now := time.Now()
+	if err := s.sessions.update(&SessionUpdate{SessionID: "session-a", CWD: "/work/api"}, now); err != nil {
+		t.Fatal(err)
+	}
+	s.acknowledge("session-a", now)
+	s.acknowledge("working-session-a", now)
+
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	acks, err := sessions.NewAcks(dir).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(acks) != 1 || !acks["session-a"].Equal(now) {
+		t.Errorf("marked acks = %v, want only session-a", acks)
+	}
+}
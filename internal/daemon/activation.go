@@ -0,0 +1,41 @@
+//go:build linux
+
+// ABOUTME: systemd socket activation: takes over the listening socket systemd passes in.
+// ABOUTME: Used when the daemon runs from the claude-notifications.socket user unit.
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// listenFdsStart is the first file descriptor systemd passes (SD_LISTEN_FDS_START)
+const listenFdsStart = 3
+
+// activationListener returns the socket passed by systemd socket
+// activation, or nil when the daemon was not socket-activated
+func activationListener() (net.Listener, error) {
+	if os.Getenv("LISTEN_PID") != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 1 {
+		return nil, nil
+	}
+	// Not inherited by focus tools the daemon runs
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	syscall.CloseOnExec(listenFdsStart)
+	f := os.NewFile(listenFdsStart, "systemd-socket")
+	defer f.Close()
+	listener, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use socket from systemd: %w", err)
+	}
+	return listener, nil
+}
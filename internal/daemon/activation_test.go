@@ -0,0 +1,40 @@
+//go:build linux
+
+package daemon
+
+import (
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestActivationListener_NotActivated(t *testing.T) {
+	t.Setenv("LISTEN_PID", "")
+	t.Setenv("LISTEN_FDS", "")
+
+	l, err := activationListener()
+	if l != nil || err != nil {
+		t.Errorf("activationListener() = %v, %v; want nil, nil without LISTEN_PID", l, err)
+	}
+}
+
+func TestActivationListener_OtherProcess(t *testing.T) {
+	// The variables were meant for a parent or child process
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "1")
+
+	l, err := activationListener()
+	if l != nil || err != nil {
+		t.Errorf("activationListener() = %v, %v; want nil, nil for another PID", l, err)
+	}
+}
+
+func TestActivationListener_NoFds(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "0")
+
+	l, err := activationListener()
+	if l != nil || err != nil {
+		t.Errorf("activationListener() = %v, %v; want nil, nil for LISTEN_FDS=0", l, err)
+	}
+}
@@ -0,0 +1,203 @@
+//go:build linux
+
+// ABOUTME: HTTP API of the daemon for other tools: notify, sessions, history and do-not-disturb.
+// ABOUTME: Every request must carry the configured bearer token.
+package daemon
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/history"
+)
+
+// apiMaxBody limits the size of a request body
+const apiMaxBody = 64 * 1024
+
+// HistoryResponse lists notification history entries, oldest first
+type HistoryResponse struct {
+	Entries []history.Entry `json:"entries"`
+}
+
+// startAPI starts serving the HTTP API on the API address
+func (s *Server) startAPI() error {
+	listener, err := net.Listen("tcp", s.apiAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.apiAddress, err)
+	}
+	s.apiSrv = &http.Server{Handler: s.apiHandler(), ReadHeaderTimeout: 10 * time.Second}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.apiSrv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("[ERROR] API stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// apiHandler routes the API requests, after checking the token
+func (s *Server) apiHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/notify", s.apiNotify)
+	mux.HandleFunc("/sessions", s.apiSessions)
+	mux.HandleFunc("/history", s.apiHistory)
+	mux.HandleFunc("/dnd", s.apiDND)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.apiAuthorized(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="claude-notifications"`)
+			apiError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, apiMaxBody)
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// apiAuthorized reports whether the request carries the configured token
+func (s *Server) apiAuthorized(r *http.Request) bool {
+	sent, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && s.apiToken != "" && subtle.ConstantTimeCompare([]byte(sent), []byte(s.apiToken)) == 1
+}
+
+// apiNotify shows a desktop notification: POST /notify with a notify payload
+func (s *Server) apiNotify(w http.ResponseWriter, r *http.Request) {
+	if !apiMethod(w, r, http.MethodPost) {
+		return
+	}
+	var req NotifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiError(w, http.StatusBadRequest, "invalid notify payload: "+err.Error())
+		return
+	}
+	if req.Title == "" && req.Body == "" {
+		apiError(w, http.StatusBadRequest, "notify needs a title or body")
+		return
+	}
+	key := s.trackInflight(req, time.Now())
+	resp, err := s.handleNotification(&req, key)
+	s.untrackInflight(key)
+	if err != nil {
+		apiError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	apiJSON(w, resp)
+}
+
+// apiSessions lists the running sessions: GET /sessions
+func (s *Server) apiSessions(w http.ResponseWriter, r *http.Request) {
+	if apiMethod(w, r, http.MethodGet) {
+		apiJSON(w, s.handleSessions(time.Now()))
+	}
+}
+
+// apiHistory lists delivered notifications: GET /history with the
+// filters of the history command as query parameters (project, event,
+// since, limit; default limit 50)
+func (s *Server) apiHistory(w http.ResponseWriter, r *http.Request) {
+	if !apiMethod(w, r, http.MethodGet) {
+		return
+	}
+	cfg := config.DefaultConfig()
+	if s.config != nil {
+		cfg = s.config.Config()
+	}
+	if !cfg.IsHistoryEnabled() {
+		apiError(w, http.StatusNotFound, "notification history is disabled")
+		return
+	}
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	q := r.URL.Query()
+	filter := history.Filter{Project: q.Get("project"), Event: q.Get("event"), Limit: 50}
+	if v := q.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit < 0 {
+			apiError(w, http.StatusBadRequest, fmt.Sprintf("invalid limit %q", v))
+			return
+		}
+	}
+	if v := q.Get("since"); v != "" {
+		if filter.Since, err = history.ParseSince(v, time.Now()); err != nil {
+			apiError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	entries, err := history.NewStore(dir, cfg.Notifications.History.MaxEntries).Query(filter)
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if entries == nil {
+		entries = []history.Entry{}
+	}
+	apiJSON(w, HistoryResponse{Entries: entries})
+}
+
+// apiDND shows the do-not-disturb state (GET /dnd) or changes it (POST
+// /dnd with a mute payload)
+func (s *Server) apiDND(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	switch r.Method {
+	case http.MethodGet:
+		mgr, err := s.dndManager()
+		if err != nil {
+			apiError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		st := mgr.Status(now)
+		apiJSON(w, MuteResponse{Muted: st.Active, Reason: st.Reason, Until: timePtr(st.Until)})
+	case http.MethodPost:
+		var req MuteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apiError(w, http.StatusBadRequest, "invalid mute payload: "+err.Error())
+			return
+		}
+		resp, err := s.handleMute(&req, now)
+		if err != nil {
+			apiError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		apiJSON(w, resp)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		apiError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// apiMethod reports whether the request uses method, answering 405 if not
+func apiMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	apiError(w, http.StatusMethodNotAllowed, "method not allowed")
+	return false
+}
+
+// apiJSON writes a successful response
+func apiJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// apiError writes an error response: {"error": "..."}
+func apiError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
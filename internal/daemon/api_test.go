@@ -0,0 +1,134 @@
+//go:build linux
+
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/history"
+)
+
+const testAPIToken = "0123456789abcdef"
+
+// apiCall sends a request to the API of s and decodes its JSON reply into out
+func apiCall(t *testing.T, s *Server, method, target, body string, out interface{}) int {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testAPIToken)
+	rec := httptest.NewRecorder()
+	s.apiHandler().ServeHTTP(rec, req)
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: invalid reply %q: %v", method, target, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+func TestAPI_RequiresToken(t *testing.T) {
+	s := newTestServer(t)
+	s.apiToken = testAPIToken
+
+	for _, auth := range []string{"", "Bearer wrong-token-000000", testAPIToken} {
+		req := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		s.apiHandler().ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("Authorization %q: status %d, want 401 with a challenge", auth, rec.Code)
+		}
+	}
+
+	// Without a configured token nothing is accepted
+	s.apiToken = ""
+	req := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	rec := httptest.NewRecorder()
+	s.apiHandler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("empty token: status %d, want 401", rec.Code)
+	}
+}
+
+func TestAPI_SessionsAndDND(t *testing.T) {
+	s := newTestServer(t)
+	s.apiToken = testAPIToken
+
+	var sessions SessionsResponse
+	if code := apiCall(t, s, http.MethodGet, "/sessions", "", &sessions); code != http.StatusOK || sessions.Sessions == nil {
+		t.Errorf("GET /sessions = %d, %+v", code, sessions)
+	}
+	if code := apiCall(t, s, http.MethodPost, "/sessions", "", nil); code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /sessions = %d, want 405", code)
+	}
+
+	var mute MuteResponse
+	if code := apiCall(t, s, http.MethodPost, "/dnd", `{"seconds":600}`, &mute); code != http.StatusOK || !mute.Muted || mute.Until == nil {
+		t.Fatalf("POST /dnd = %d, %+v, want muted for 10 minutes", code, mute)
+	}
+	mute = MuteResponse{}
+	if code := apiCall(t, s, http.MethodGet, "/dnd", "", &mute); code != http.StatusOK || !mute.Muted {
+		t.Errorf("GET /dnd = %d, %+v, want muted", code, mute)
+	}
+	mute = MuteResponse{}
+	if code := apiCall(t, s, http.MethodPost, "/dnd", `{"unmute":true}`, &mute); code != http.StatusOK || mute.Muted {
+		t.Errorf("POST /dnd unmute = %d, %+v", code, mute)
+	}
+
+	var apiErr map[string]string
+	if code := apiCall(t, s, http.MethodPost, "/dnd", `not json`, &apiErr); code != http.StatusBadRequest || apiErr["error"] == "" {
+		t.Errorf("POST /dnd with bad payload = %d, %v", code, apiErr)
+	}
+}
+
+func TestAPI_History(t *testing.T) {
+	s := newTestServer(t)
+	s.apiToken = testAPIToken
+
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := history.NewStore(dir, 0)
+	now := time.Now()
+	for _, e := range []history.Entry{
+		{Time: now.Add(-2 * time.Hour), Event: "task_complete", Title: "Old", Project: "api", Backend: "desktop", Result: history.ResultDelivered},
+		{Time: now.Add(-time.Minute), Event: "question", Title: "Asked", Project: "api", Backend: "desktop", Result: history.ResultDelivered},
+		{Time: now, Event: "task_complete", Title: "Other", Project: "web", Backend: "desktop", Result: history.ResultDelivered},
+	} {
+		if err := store.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var resp HistoryResponse
+	if code := apiCall(t, s, http.MethodGet, "/history?project=api&since=1h", "", &resp); code != http.StatusOK {
+		t.Fatalf("GET /history = %d", code)
+	}
+	if len(resp.Entries) != 1 || resp.Entries[0].Title != "Asked" {
+		t.Errorf("GET /history = %+v, want the recent api entry", resp.Entries)
+	}
+	if code := apiCall(t, s, http.MethodGet, "/history?limit=x", "", nil); code != http.StatusBadRequest {
+		t.Errorf("GET /history with bad limit = %d, want 400", code)
+	}
+}
+
+func TestAPI_NotifyNeedsContent(t *testing.T) {
+	s := newTestServer(t)
+	s.apiToken = testAPIToken
+
+	if code := apiCall(t, s, http.MethodPost, "/notify", `{}`, nil); code != http.StatusBadRequest {
+		t.Errorf("POST /notify without title = %d, want 400", code)
+	}
+	if code := apiCall(t, s, http.MethodGet, "/notify", "", nil); code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /notify = %d, want 405", code)
+	}
+}
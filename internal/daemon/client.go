@@ -6,6 +6,7 @@ package daemon
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -15,6 +16,9 @@ import (
 	"strings"
 	"syscall"
 	"time"
+
+	"github.com/777genius/claude-notifications/internal/platform"
+	"github.com/777genius/claude-notifications/internal/service"
 )
 
 // Client communicates with the daemon via Unix socket
@@ -28,7 +32,11 @@ func NewClient() (*Client, error) {
 
 	// Check if socket exists
 	if _, err := os.Stat(socketPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("daemon not running: socket does not exist")
+		return nil, fmt.Errorf("%w: socket does not exist", ErrDaemonNotRunning)
+	}
+	// A socket in a directory of another user may be a fake daemon
+	if err := secureRuntimeDir(GetRuntimeDir(), false); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDaemonNotAvailable, err)
 	}
 
 	return &Client{socketPath: socketPath}, nil
@@ -37,16 +45,22 @@ func NewClient() (*Client, error) {
 // SendNotification sends a notification request to the daemon.
 // focusFolder is the project folder name for window-specific focus (may be empty).
 func (c *Client) SendNotification(title, body, focusTarget, focusFolder string, timeout int) (*NotifyResponse, error) {
+	return c.Send(&NotifyRequest{
+		Title:       title,
+		Body:        body,
+		FocusTarget: focusTarget,
+		FocusFolder: focusFolder,
+		Timeout:     timeout,
+	})
+}
+
+// Send sends a fully specified notification request to the daemon.
+// The returned NotificationID can be passed back as ReplacesID or to CloseNotification.
+func (c *Client) Send(notifyReq *NotifyRequest) (*NotifyResponse, error) {
 	req := Request{
 		Type:    MessageTypeNotify,
 		Version: ProtocolVersion,
-		Notify: &NotifyRequest{
-			Title:       title,
-			Body:        body,
-			FocusTarget: focusTarget,
-			FocusFolder: focusFolder,
-			Timeout:     timeout,
-		},
+		Notify:  notifyReq,
 	}
 
 	resp, err := c.send(req)
@@ -61,6 +75,52 @@ func (c *Client) SendNotification(title, body, focusTarget, focusFolder string,
 	return resp.Notify, nil
 }
 
+// CloseNotification asks the daemon to close a notification it previously sent
+func (c *Client) CloseNotification(id uint32) error {
+	req := Request{
+		Type:    MessageTypeClose,
+		Version: ProtocolVersion,
+		Close:   &CloseRequest{NotificationID: id},
+	}
+
+	resp, err := c.send(req)
+	if err != nil {
+		return err
+	}
+
+	if resp.Error != "" {
+		return fmt.Errorf("daemon error: %s", resp.Error)
+	}
+
+	return nil
+}
+
+// Dismiss closes the notifications the daemon still shows for a coalesce
+// key and returns how many it closed
+func (c *Client) Dismiss(coalesceKey string) (int, error) {
+	resp, err := c.call(Request{Type: MessageTypeDismiss, Dismiss: &DismissRequest{CoalesceKey: coalesceKey}})
+	if err != nil {
+		return 0, err
+	}
+	if resp.Dismiss == nil {
+		return 0, nil
+	}
+	return resp.Dismiss.Closed, nil
+}
+
+// Acknowledged returns when the user last clicked, dismissed or returned
+// to a notification of a coalesce key (zero = never)
+func (c *Client) Acknowledged(coalesceKey string) (time.Time, error) {
+	resp, err := c.call(Request{Type: MessageTypeAcked, Acked: &AcknowledgedRequest{CoalesceKey: coalesceKey}})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if resp.Acked == nil || resp.Acked.AcknowledgedAt == nil {
+		return time.Time{}, nil
+	}
+	return *resp.Acked.AcknowledgedAt, nil
+}
+
 // Ping checks if the daemon is responding and returns status info
 func (c *Client) Ping() (*PingResponse, error) {
 	req := Request{
@@ -80,6 +140,122 @@ func (c *Client) Ping() (*PingResponse, error) {
 	return resp.Ping, nil
 }
 
+// Focus asks the daemon to focus the terminal of a notification, a
+// session, or an explicit target
+func (c *Client) Focus(focusReq *FocusRequest) (*FocusResponse, error) {
+	resp, err := c.call(Request{Type: MessageTypeFocus, Focus: focusReq})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Focus, nil
+}
+
+// Status returns the daemon's status
+func (c *Client) Status() (*StatusResponse, error) {
+	resp, err := c.call(Request{Type: MessageTypeStatus})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Status, nil
+}
+
+// ListSessions returns the running Claude Code sessions
+func (c *Client) ListSessions() ([]SessionInfo, error) {
+	resp, err := c.call(Request{Type: MessageTypeSessions})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Sessions == nil {
+		return nil, nil
+	}
+	return resp.Sessions.Sessions, nil
+}
+
+// Mute turns do-not-disturb on for d (0 = until unmuted)
+func (c *Client) Mute(d time.Duration) (*MuteResponse, error) {
+	resp, err := c.call(Request{Type: MessageTypeMute, Mute: &MuteRequest{Seconds: int(d.Seconds())}})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Mute, nil
+}
+
+// Unmute turns do-not-disturb off
+func (c *Client) Unmute() (*MuteResponse, error) {
+	resp, err := c.call(Request{Type: MessageTypeMute, Mute: &MuteRequest{Unmute: true}})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Mute, nil
+}
+
+// UpdateSession tells the daemon where a session runs, or that it ended
+func (c *Client) UpdateSession(update *SessionUpdate) error {
+	_, err := c.call(Request{Type: MessageTypeSession, Session: update})
+	return err
+}
+
+// HandleHook hands a hook event to the daemon, which handles it after
+// answering, and returns how many hooks of the session are ahead of it
+func (c *Client) HandleHook(hook *HookRequest) (int, error) {
+	resp, err := c.call(Request{Type: MessageTypeHook, Hook: hook})
+	if err != nil {
+		return 0, err
+	}
+	if resp.Hook == nil {
+		return 0, fmt.Errorf("daemon does not handle hooks")
+	}
+	return resp.Hook.Queued, nil
+}
+
+// HookEnv returns this process's environment for a hook handed to the
+// daemon, with the terminal, controlling terminal and Claude Code process
+// the worker cannot find from its own process
+func HookEnv() []string {
+	env := append(os.Environ(), TerminalEnv+"="+PinnedTerminal(), ClaudeEnv+"="+strconv.Itoa(ClaudePID()))
+	if tty := ControllingTTY(); tty != "" {
+		env = append(env, platform.TTYEnv+"="+tty)
+	}
+	return env
+}
+
+// ReportDelivery records the outcome of a delivery in the daemon's metrics
+func (c *Client) ReportDelivery(backend string, success bool, d time.Duration) error {
+	_, err := c.call(Request{Type: MessageTypeReport, Report: &ReportRequest{
+		Backend:    backend,
+		Success:    success,
+		DurationMs: d.Milliseconds(),
+	}})
+	return err
+}
+
+// ResolveSecret returns the secret a "$(command)" or
+// "keyring:service/account" reference names, read once by the daemon
+func (c *Client) ResolveSecret(ref string) (string, error) {
+	resp, err := c.call(Request{Type: MessageTypeSecret, Secret: &SecretRequest{Ref: ref}})
+	if err != nil {
+		return "", err
+	}
+	if resp.Secret == nil {
+		return "", fmt.Errorf("daemon does not resolve secrets")
+	}
+	return resp.Secret.Value, nil
+}
+
+// call sends a request with the current protocol version and turns an
+// error response into an error
+func (c *Client) call(req Request) (*Response, error) {
+	req.Version = ProtocolVersion
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("daemon error: %s", resp.Error)
+	}
+	return resp, nil
+}
+
 // Stop requests the daemon to shut down
 func (c *Client) Stop() error {
 	req := Request{
@@ -95,7 +271,7 @@ func (c *Client) Stop() error {
 func (c *Client) send(req Request) (*Response, error) {
 	conn, err := net.DialTimeout("unix", c.socketPath, 5*time.Second)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to daemon: %w", err)
+		return nil, fmt.Errorf("failed to connect to daemon: %w: %w", ErrDaemonNotRunning, err)
 	}
 	defer conn.Close()
 
@@ -131,14 +307,44 @@ func IsDaemonRunning() bool {
 	return err == nil
 }
 
+// ensureDaemon starts the daemon for WithDaemon; replaced in tests
+var ensureDaemon = StartDaemonOnDemand
+
+// WithDaemon starts the daemon when needed and calls fn with a client. When
+// fn cannot connect, e.g. because the daemon exited when idle in between, the
+// daemon is started again and fn retried once. A request that reached the
+// daemon is never repeated.
+func WithDaemon(fn func(*Client) error) error {
+	for attempt := 0; ; attempt++ {
+		if !ensureDaemon() {
+			return ErrDaemonNotAvailable
+		}
+		client, err := NewClient()
+		if err == nil {
+			err = fn(client)
+		}
+		if err == nil || attempt > 0 || !errors.Is(err, ErrDaemonNotRunning) {
+			return err
+		}
+	}
+}
+
 // StartDaemonOnDemand starts the daemon if it's not already running.
 // Returns true if daemon is running (either started now or was already running).
+// An installed systemd service is started through systemd, so no second
+// daemon runs outside it; the daemon is only forked when that fails.
 func StartDaemonOnDemand() bool {
 	// Check if already running
 	if IsDaemonRunning() {
 		return true
 	}
 
+	if installed, _ := service.Installed(); installed {
+		if err := service.Start(); err == nil && waitForDaemon(nil) {
+			return true
+		}
+	}
+
 	// Find the daemon binary
 	daemonPath, err := findDaemonBinary()
 	if err != nil {
@@ -162,15 +368,30 @@ func StartDaemonOnDemand() bool {
 	if err := cmd.Start(); err != nil {
 		return false
 	}
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+
+	// Wait for the daemon to be ready, or for it to fail, e.g. without a
+	// D-Bus session or because another daemon just started
+	return waitForDaemon(exited)
+}
 
-	// Wait for daemon to be ready (up to 5 seconds)
+// waitForDaemon waits up to 5 seconds for the daemon to answer, or until
+// exited is closed
+func waitForDaemon(exited <-chan struct{}) bool {
 	for i := 0; i < 50; i++ {
-		time.Sleep(100 * time.Millisecond)
+		select {
+		case <-exited:
+			return IsDaemonRunning()
+		case <-time.After(100 * time.Millisecond):
+		}
 		if IsDaemonRunning() {
 			return true
 		}
 	}
-
 	return false
 }
 
@@ -0,0 +1,136 @@
+//go:build linux
+
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"testing"
+)
+
+// fakeEnsure replaces ensureDaemon with start, counting the calls
+func fakeEnsure(t *testing.T, start func(call int) bool) *int {
+	t.Helper()
+	calls := 0
+	orig := ensureDaemon
+	ensureDaemon = func() bool {
+		calls++
+		return start(calls)
+	}
+	t.Cleanup(func() { ensureDaemon = orig })
+	return &calls
+}
+
+// listenBusy answers one request on the daemon socket with an error
+func listenBusy(t *testing.T) {
+	t.Helper()
+	l, err := net.Listen("unix", GetSocketPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var req Request
+		_ = json.NewDecoder(conn).Decode(&req)
+		_ = json.NewEncoder(conn).Encode(Response{Error: "busy"})
+	}()
+}
+
+func TestWithDaemon_RetriesWhenDaemonGone(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	if err := secureRuntimeDir(GetRuntimeDir(), true); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first start finds a daemon that is gone before the request,
+	// the second one starts a new daemon
+	calls := fakeEnsure(t, func(call int) bool {
+		if call == 1 {
+			l, err := net.Listen("unix", GetSocketPath())
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.(*net.UnixListener).SetUnlinkOnClose(false)
+			l.Close()
+			return true
+		}
+		os.Remove(GetSocketPath())
+		listenBusy(t)
+		return true
+	})
+	tries := 0
+	err := WithDaemon(func(c *Client) error {
+		tries++
+		_, err := c.Ping()
+		return err
+	})
+	if err == nil || errors.Is(err, ErrDaemonNotRunning) {
+		t.Fatalf("WithDaemon() = %v, want the daemon's answer", err)
+	}
+	if *calls != 2 || tries != 2 {
+		t.Errorf("started %d times and called fn %d times, want 2 and 2", *calls, tries)
+	}
+}
+
+func TestWithDaemon_RetriesOnce(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	if err := secureRuntimeDir(GetRuntimeDir(), true); err != nil {
+		t.Fatal(err)
+	}
+	calls := fakeEnsure(t, func(int) bool { return true })
+
+	err := WithDaemon(func(c *Client) error { return nil })
+	if !errors.Is(err, ErrDaemonNotRunning) {
+		t.Errorf("WithDaemon() = %v, want ErrDaemonNotRunning", err)
+	}
+	if *calls != 2 {
+		t.Errorf("started %d times, want 2", *calls)
+	}
+}
+
+func TestWithDaemon_DoesNotRepeatDeliveredRequests(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	if err := secureRuntimeDir(GetRuntimeDir(), true); err != nil {
+		t.Fatal(err)
+	}
+	calls := fakeEnsure(t, func(int) bool {
+		listenBusy(t)
+		return true
+	})
+
+	tries := 0
+	err := WithDaemon(func(c *Client) error {
+		tries++
+		_, err := c.Ping()
+		return err
+	})
+	if err == nil || errors.Is(err, ErrDaemonNotRunning) {
+		t.Fatalf("WithDaemon() = %v, want the daemon's answer", err)
+	}
+	if *calls != 1 || tries != 1 {
+		t.Errorf("started %d times and called fn %d times, want 1 and 1", *calls, tries)
+	}
+}
+
+func TestWithDaemon_NotAvailable(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	if err := secureRuntimeDir(GetRuntimeDir(), true); err != nil {
+		t.Fatal(err)
+	}
+	fakeEnsure(t, func(int) bool { return false })
+
+	err := WithDaemon(func(c *Client) error {
+		t.Error("fn called without a daemon")
+		return nil
+	})
+	if !errors.Is(err, ErrDaemonNotAvailable) {
+		t.Errorf("WithDaemon() = %v, want ErrDaemonNotAvailable", err)
+	}
+}
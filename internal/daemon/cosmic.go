@@ -0,0 +1,134 @@
+//go:build linux
+
+// ABOUTME: COSMIC focus through its toplevel protocols, without an external tool.
+// ABOUTME: Lists windows with ext-foreign-toplevel-list and activates one with zcosmic_toplevel_manager_v1.
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Interfaces the COSMIC focus method binds
+const (
+	extForeignToplevelList = "ext_foreign_toplevel_list_v1"
+	cosmicToplevelInfo     = "zcosmic_toplevel_info_v1"
+	cosmicToplevelManager  = "zcosmic_toplevel_manager_v1"
+	wlSeat                 = "wl_seat"
+)
+
+// Opcodes of the toplevel protocols
+const (
+	extToplevelListToplevel      uint16 = 0 // event: new handle
+	extToplevelHandleTitle       uint16 = 2 // event
+	extToplevelHandleAppID       uint16 = 3 // event
+	cosmicInfoGetCosmicToplevel  uint16 = 1 // request, since version 2
+	cosmicManagerActivate        uint16 = 2 // request
+	cosmicToplevelInfoMinVersion        = 2
+)
+
+// TryCosmicToplevel focuses a window on System76 COSMIC over the
+// compositor's own toplevel protocols, so no tool has to be installed.
+// Only attempted when XDG_CURRENT_DESKTOP is COSMIC.
+func TryCosmicToplevel(ctx context.Context, terminalName, folderName string) error {
+	if !strings.Contains(strings.ToUpper(os.Getenv("XDG_CURRENT_DESKTOP")), "COSMIC") {
+		return fmt.Errorf("not running under COSMIC")
+	}
+	socketPath, err := waylandSocketPath()
+	if err != nil {
+		return err
+	}
+	return focusCosmicToplevel(ctx, socketPath, func(windows []toplevel) *toplevel {
+		return pickToplevel(windows, terminalName, folderName)
+	})
+}
+
+// focusCosmicToplevel lists the windows on the COSMIC compositor at
+// socketPath and activates the one pick returns, which also switches to
+// its workspace.
+func focusCosmicToplevel(ctx context.Context, socketPath string, pick func([]toplevel) *toplevel) error {
+	c, err := dialWayland(ctx, socketPath)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	registry, globals, err := c.globals()
+	if err != nil {
+		return err
+	}
+	for _, iface := range []string{extForeignToplevelList, cosmicToplevelInfo, cosmicToplevelManager, wlSeat} {
+		if _, ok := globals[iface]; !ok {
+			return fmt.Errorf("compositor does not offer %s", iface)
+		}
+	}
+	if globals[cosmicToplevelInfo].version < cosmicToplevelInfoMinVersion {
+		return fmt.Errorf("%s version %d is too old", cosmicToplevelInfo, globals[cosmicToplevelInfo].version)
+	}
+
+	list, err := c.bind(registry, globals[extForeignToplevelList], extForeignToplevelList, 1)
+	if err != nil {
+		return err
+	}
+	info, err := c.bind(registry, globals[cosmicToplevelInfo], cosmicToplevelInfo, cosmicToplevelInfoMinVersion)
+	if err != nil {
+		return err
+	}
+	manager, err := c.bind(registry, globals[cosmicToplevelManager], cosmicToplevelManager, 1)
+	if err != nil {
+		return err
+	}
+	seat, err := c.bind(registry, globals[wlSeat], wlSeat, 1)
+	if err != nil {
+		return err
+	}
+
+	// The list announces every window after the bind; the second round
+	// trip catches compositors that send them on their next refresh
+	handles := map[uint32]*toplevel{}
+	var order []uint32
+	collect := func(ev waylandEvent) {
+		if ev.sender == list && ev.opcode == extToplevelListToplevel {
+			id := ev.args.uint()
+			handles[id] = &toplevel{id: strconv.FormatUint(uint64(id), 10)}
+			order = append(order, id)
+			return
+		}
+		if w := handles[ev.sender]; w != nil {
+			switch ev.opcode {
+			case extToplevelHandleTitle:
+				w.title = ev.args.string()
+			case extToplevelHandleAppID:
+				w.appID = ev.args.string()
+			}
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if err := c.roundtrip(collect); err != nil {
+			return err
+		}
+	}
+
+	windows := make([]toplevel, 0, len(order))
+	for _, id := range order {
+		windows = append(windows, *handles[id])
+	}
+	w := pick(windows)
+	if w == nil {
+		return fmt.Errorf("no matching window among %d COSMIC toplevels", len(windows))
+	}
+
+	handle, _ := strconv.ParseUint(w.id, 10, 32)
+	cosmicHandle := c.newID()
+	if err := c.send(info, cosmicInfoGetCosmicToplevel, cosmicHandle, uint32(handle)); err != nil {
+		return err
+	}
+	if err := c.send(manager, cosmicManagerActivate, cosmicHandle, seat); err != nil {
+		return err
+	}
+	// Wait for the compositor to process the activation, or report an error
+	return c.roundtrip(func(waylandEvent) {})
+}
@@ -0,0 +1,89 @@
+//go:build linux
+
+package daemon
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// fakeCosmicWindow is a window the fake compositor lists
+type fakeCosmicWindow struct {
+	appID, title string
+}
+
+// startFakeCosmic serves one client like a COSMIC compositor offering
+// globals, and reports the foreign toplevel handle of every activated window.
+func startFakeCosmic(t *testing.T, globals []string, windows []fakeCosmicWindow) (string, <-chan uint32) {
+	t.Helper()
+
+	activated := make(chan uint32, 1)
+	cosmicHandles := map[uint32]uint32{}
+	socketPath := startFakeCompositor(t, globals, func(send fakeWaylandSend, iface string, id uint32) {
+		if iface == extForeignToplevelList {
+			for i, w := range windows {
+				handle := 0xff000000 + uint32(i)
+				send(id, extToplevelListToplevel, handle)
+				send(handle, extToplevelHandleTitle, w.title)
+				send(handle, extToplevelHandleAppID, w.appID)
+			}
+		}
+	}, func(iface string, object uint32, opcode uint16, args *waylandArgs) {
+		switch {
+		case iface == cosmicToplevelInfo && opcode == cosmicInfoGetCosmicToplevel:
+			cosmic, foreign := args.uint(), args.uint()
+			cosmicHandles[cosmic] = foreign
+		case iface == cosmicToplevelManager && opcode == cosmicManagerActivate:
+			activated <- cosmicHandles[args.uint()]
+		}
+	})
+	return socketPath, activated
+}
+
+var fakeCosmicGlobals = []string{"wl_compositor", extForeignToplevelList, cosmicToplevelInfo, cosmicToplevelManager, wlSeat}
+
+func TestFocusCosmicToplevel(t *testing.T) {
+	socketPath, activated := startFakeCosmic(t, fakeCosmicGlobals, []fakeCosmicWindow{
+		{appID: "firefox", title: "api - Mozilla Firefox"},
+		{appID: "code", title: "web - Visual Studio Code"},
+		{appID: "code", title: "api - Visual Studio Code"},
+	})
+
+	err := focusCosmicToplevel(context.Background(), socketPath, func(windows []toplevel) *toplevel {
+		return pickToplevel(windows, "Code", "api")
+	})
+	if err != nil {
+		t.Fatalf("focusCosmicToplevel() error = %v", err)
+	}
+	if got := <-activated; got != 0xff000002 {
+		t.Errorf("activated handle %#x, want the VS Code window showing api", got)
+	}
+}
+
+func TestFocusCosmicToplevel_NoMatch(t *testing.T) {
+	socketPath, _ := startFakeCosmic(t, fakeCosmicGlobals, []fakeCosmicWindow{{appID: "firefox", title: "Inbox"}})
+
+	err := focusCosmicToplevel(context.Background(), socketPath, func(windows []toplevel) *toplevel {
+		return pickToplevel(windows, "kitty", "")
+	})
+	if err == nil || !strings.Contains(err.Error(), "no matching window") {
+		t.Errorf("error = %v, want no matching window", err)
+	}
+}
+
+func TestFocusCosmicToplevel_NotCosmic(t *testing.T) {
+	socketPath, _ := startFakeCosmic(t, []string{"wl_compositor", wlSeat}, nil)
+
+	err := focusCosmicToplevel(context.Background(), socketPath, func([]toplevel) *toplevel { return nil })
+	if err == nil || !strings.Contains(err.Error(), "does not offer") {
+		t.Errorf("error = %v, want missing protocols", err)
+	}
+}
+
+func TestTryCosmicToplevel_NotCosmicSession(t *testing.T) {
+	t.Setenv("XDG_CURRENT_DESKTOP", "GNOME")
+	if err := TryCosmicToplevel(context.Background(), "kitty", ""); err == nil || !strings.Contains(err.Error(), "not running under COSMIC") {
+		t.Errorf("TryCosmicToplevel() error = %v", err)
+	}
+}
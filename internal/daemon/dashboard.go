@@ -0,0 +1,82 @@
+//go:build linux
+
+// ABOUTME: Serves the optional web dashboard on localhost from the daemon.
+// ABOUTME: Reads sessions, history, breakers and rule hits; focuses sessions through the daemon.
+// ABOUTME: Its URL with a per-daemon access token is kept in the private runtime directory.
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/breaker"
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/dashboard"
+	"github.com/777genius/claude-notifications/internal/history"
+	"github.com/777genius/claude-notifications/internal/rules"
+	"github.com/777genius/claude-notifications/internal/sessions"
+)
+
+// startDashboard starts serving the dashboard on the dashboard address and
+// writes its URL, with a new token, to the dashboard URL file
+func (s *Server) startDashboard() error {
+	token, err := dashboard.NewToken()
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", s.dashboardAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.dashboardAddress, err)
+	}
+	dashboardURL := (&url.URL{Scheme: "http", Host: s.dashboardAddress, Path: "/", RawQuery: url.Values{"token": {token}}.Encode()}).String()
+	if err := os.WriteFile(GetDashboardURLPath(), []byte(dashboardURL+"\n"), 0600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to write dashboard URL: %w", err)
+	}
+	handler := dashboard.New(s.dashboardSource, dashboard.Actions{
+		Focus: func(sessionID string) error {
+			_, err := s.handleFocus(&FocusRequest{SessionID: sessionID})
+			return err
+		},
+	}, token)
+	s.dashboardSrv = &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.dashboardSrv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("[ERROR] Dashboard stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// dashboardSource returns where the dashboard reads from under the
+// current config
+func (s *Server) dashboardSource() dashboard.Source {
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		return dashboard.Source{}
+	}
+	cfg := s.config.Config()
+	src := dashboard.Source{
+		Sessions: sessions.NewRegistry(dir),
+		RuleHits: rules.NewHitStore(dir),
+	}
+	if mgr, err := s.dndManager(); err == nil {
+		src.DND = mgr
+	}
+	if cfg.IsHistoryEnabled() {
+		src.History = history.NewStore(dir, cfg.Notifications.History.MaxEntries)
+	}
+	if cfg.IsBreakerEnabled() {
+		src.Breakers = breaker.NewStore(dir, cfg.Notifications.Breaker.Settings())
+	}
+	return src
+}
@@ -0,0 +1,137 @@
+//go:build linux
+
+// ABOUTME: Closes stale notifications once the user is back at the session they came from.
+// ABOUTME: On request when the session resumes, or when its pinned window regains focus.
+package daemon
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/sessions"
+)
+
+// focusPollInterval is how often the active window is checked while a
+// notification waits for the user to return to its window
+const focusPollInterval = 2 * time.Second
+
+// queryActiveWindow returns the focused window; replaced in tests
+var queryActiveWindow = activeWindow
+
+// handleDismiss closes every notification still shown for a coalesce key
+func (s *Server) handleDismiss(req *DismissRequest) *DismissResponse {
+	if req.CoalesceKey == "" {
+		return &DismissResponse{}
+	}
+	closed := s.closeMatching(func(info focusInfo) bool {
+		return info.coalesceKey == req.CoalesceKey
+	})
+	if closed > 0 {
+		log.Printf("[INFO] Dismissed %d notification(s) of %s", closed, req.CoalesceKey)
+	}
+	return &DismissResponse{Closed: closed}
+}
+
+// closeMatching closes the notifications whose focus context matches and
+// returns how many were closed
+func (s *Server) closeMatching(match func(focusInfo) bool) int {
+	var ids []uint32
+	s.focusCtxMu.Lock()
+	for id, info := range s.focusCtx {
+		if match(info) {
+			ids = append(ids, id)
+			delete(s.focusCtx, id)
+		}
+	}
+	s.focusCtxMu.Unlock()
+
+	closed := 0
+	for _, id := range ids {
+		if _, err := s.notifier.CloseNotification(id); err != nil {
+			log.Printf("[WARN] Failed to close notification %d: %v", id, err)
+			continue
+		}
+		closed++
+	}
+	// A closed notification can no longer absorb new events
+	if s.throttle != nil && len(ids) > 0 {
+		s.throttleMu.Lock()
+		for _, id := range ids {
+			s.throttle.forget(id)
+		}
+		s.throttleMu.Unlock()
+	}
+	return closed
+}
+
+// focusWatcher closes notifications when the user returns to their window
+func (s *Server) focusWatcher() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(focusPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.checkFocus()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// checkFocus looks at the active window once. A notification is closed
+// when its window is active after it was seen inactive, so one sent while
+// the user is already looking at the terminal stays until they leave and
+// come back.
+func (s *Server) checkFocus() {
+	if !s.watchingFocus() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultFocusMethodTimeout)
+	defer cancel()
+	active, err := queryActiveWindow(ctx)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	closed := s.closeMatching(func(info focusInfo) bool {
+		if info.dismissOnFocus && info.away && sameWindow(info.window, active) {
+			s.acknowledge(info.coalesceKey, now)
+			return true
+		}
+		return false
+	})
+	if closed > 0 {
+		log.Printf("[INFO] Dismissed %d notification(s): returned to %q", closed, active.Title)
+	}
+
+	s.focusCtxMu.Lock()
+	for id, info := range s.focusCtx {
+		if info.dismissOnFocus && info.window != nil && !sameWindow(info.window, active) {
+			info.away = true
+			s.focusCtx[id] = info
+		}
+	}
+	s.focusCtxMu.Unlock()
+}
+
+// watchingFocus reports whether a notification waits for its window
+func (s *Server) watchingFocus() bool {
+	s.focusCtxMu.RLock()
+	defer s.focusCtxMu.RUnlock()
+	for _, info := range s.focusCtx {
+		if info.dismissOnFocus && info.window != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// sameWindow reports whether a pinned window is the active one
+func sameWindow(pinned, active *sessions.Window) bool {
+	return pinned != nil && active != nil && pinned.Backend == active.Backend && pinned.ID == active.ID
+}
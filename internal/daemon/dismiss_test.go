@@ -0,0 +1,110 @@
+//go:build linux
+
+package daemon
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/777genius/claude-notifications/internal/sessions"
+	"github.com/esiqveland/notify"
+)
+
+// closingNotifier records the notifications the server closes
+type closingNotifier struct {
+	notify.Notifier
+	closed []uint32
+}
+
+func (n *closingNotifier) CloseNotification(id uint32) (bool, error) {
+	n.closed = append(n.closed, id)
+	return true, nil
+}
+
+func (n *closingNotifier) sortedClosed() []uint32 {
+	sort.Slice(n.closed, func(i, j int) bool { return n.closed[i] < n.closed[j] })
+	return n.closed
+}
+
+func TestHandleConnection_Dismiss(t *testing.T) {
+	s := newTestServer(t)
+	n := &closingNotifier{}
+	s.notifier = n
+	s.throttle = newThrottle()
+	s.throttle.sessions["session-a"] = shownNotification{id: 2}
+	s.focusCtx[1] = focusInfo{target: "kitty", coalesceKey: "session-a"}
+	s.focusCtx[2] = focusInfo{target: "kitty", coalesceKey: "session-a"}
+	s.focusCtx[3] = focusInfo{target: "kitty", coalesceKey: "session-b"}
+
+	resp := roundTrip(t, s, Request{Type: MessageTypeDismiss, Version: ProtocolVersion, Dismiss: &DismissRequest{CoalesceKey: "session-a"}})
+	if resp.Error != "" || resp.Dismiss == nil || resp.Dismiss.Closed != 2 {
+		t.Fatalf("dismiss response = %+v", resp)
+	}
+	if got := n.sortedClosed(); !reflect.DeepEqual(got, []uint32{1, 2}) {
+		t.Errorf("closed = %v, want [1 2]", got)
+	}
+	if _, ok := s.focusCtx[3]; !ok || len(s.focusCtx) != 1 {
+		t.Errorf("focus contexts = %v, want only the other session's", s.focusCtx)
+	}
+	if _, ok := s.throttle.sessions["session-a"]; ok {
+		t.Error("a dismissed notification should not absorb new events")
+	}
+
+	if resp := roundTrip(t, s, Request{Type: MessageTypeDismiss, Version: ProtocolVersion}); resp.Error == "" {
+		t.Error("dismiss without a payload should fail")
+	}
+}
+
+func TestCheckFocus_DismissesOnReturn(t *testing.T) {
+	terminal := &sessions.Window{Backend: windowBackendX11, ID: "1", Title: "api"}
+	browser := &sessions.Window{Backend: windowBackendX11, ID: "2", Title: "docs"}
+	active := terminal
+	orig := queryActiveWindow
+	queryActiveWindow = func(context.Context) (*sessions.Window, error) { return active, nil }
+	t.Cleanup(func() { queryActiveWindow = orig })
+
+	s := newTestServer(t)
+	n := &closingNotifier{}
+	s.notifier = n
+	s.focusCtx[1] = focusInfo{target: "kitty", window: terminal, dismissOnFocus: true, coalesceKey: "session-a"}
+	s.focusCtx[2] = focusInfo{target: "kitty", window: terminal}
+
+	// Shown while the user looks at the terminal: kept
+	s.checkFocus()
+	if len(n.closed) != 0 {
+		t.Fatalf("closed = %v, want none while the terminal stays focused", n.closed)
+	}
+
+	active = browser
+	s.checkFocus()
+	if len(n.closed) != 0 {
+		t.Fatalf("closed = %v, want none while away", n.closed)
+	}
+
+	active = terminal
+	s.checkFocus()
+	if !reflect.DeepEqual(n.closed, []uint32{1}) {
+		t.Errorf("closed = %v, want [1] after returning to the terminal", n.closed)
+	}
+	if _, ok := s.acks["session-a"]; !ok {
+		t.Error("returning to the window should acknowledge the session's notifications")
+	}
+	if _, ok := s.focusCtx[2]; !ok {
+		t.Error("a notification without dismissOnFocus should stay")
+	}
+}
+
+func TestSameWindow(t *testing.T) {
+	w := &sessions.Window{Backend: windowBackendSway, ID: "7"}
+	if !sameWindow(w, &sessions.Window{Backend: windowBackendSway, ID: "7", Title: "renamed"}) {
+		t.Error("same backend and ID should match")
+	}
+	if sameWindow(w, &sessions.Window{Backend: windowBackendX11, ID: "7"}) {
+		t.Error("another backend should not match")
+	}
+	if sameWindow(nil, w) || sameWindow(w, nil) {
+		t.Error("a missing window should not match")
+	}
+}
@@ -1,59 +1,48 @@
 //go:build linux
 
 // ABOUTME: Window focus methods for Linux desktop environments.
-// ABOUTME: Implements a fallback chain to focus windows on GNOME, KDE, Sway, and other compositors.
+// ABOUTME: Implements a fallback chain to focus windows on GNOME, KDE, Hyprland, Sway, X11, and other compositors.
 package daemon
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"os/exec"
+	"regexp"
 	"strings"
-)
 
-// FocusMethod represents a method for focusing a window
-type FocusMethod struct {
-	Name string
-	Fn   func(terminalName, folderName string) error
-}
+	"github.com/777genius/claude-notifications/internal/platform"
+)
 
 // GetFocusMethods returns the ordered list of focus methods to try
 func GetFocusMethods() []FocusMethod {
 	return []FocusMethod{
+		{"WSL host", TryWSLHost},
 		{"activate-window-by-title extension", TryActivateWindowByTitle},
 		{"GNOME Shell Eval (by window title)", TryGnomeShellEvalByTitle},
 		{"GNOME Shell Eval (by app)", TryGnomeShellEval},
 		{"GNOME Shell FocusApp", TryGnomeFocusApp},
+		{"hyprctl", TryHyprctl},
+		{"niri", TryNiri},
+		{"sway IPC", TrySwayIPC},
+		{"COSMIC toplevel", TryCosmicToplevel},
+		{"wlr foreign toplevel", TryWlrForeignToplevel},
 		{"wlrctl", TryWlrctl},
+		{"KWin script", TryKWinScript},
 		{"kdotool", TryKdotool},
 		{"xdotool", TryXdotool},
+		{"wmctrl", TryWmctrl},
 	}
 }
 
-// TryFocus attempts to focus a window using available tools.
-// folderName is the project folder name used for title-based window search (may be empty).
-// It tries each method in order until one succeeds.
-func TryFocus(terminalName, folderName string) error {
-	methods := GetFocusMethods()
-
-	var lastErr error
-	for _, method := range methods {
-		if err := method.Fn(terminalName, folderName); err != nil {
-			lastErr = err
-			continue
-		}
-		return nil
-	}
-
-	return fmt.Errorf("all focus methods failed, last error: %v", lastErr)
-}
-
 // TryActivateWindowByTitle uses the activate-window-by-title GNOME extension.
 // https://extensions.gnome.org/extension/5021/activate-window-by-title/
 // This method does NOT require unsafe_mode and works on GNOME 42+.
-func TryActivateWindowByTitle(terminalName, folderName string) error {
+func TryActivateWindowByTitle(ctx context.Context, terminalName, folderName string) error {
 	searchTerm := GetSearchTermWithFolder(terminalName, folderName)
 
-	cmd := exec.Command("busctl", "--user", "call",
+	cmd := exec.CommandContext(ctx, "busctl", "--user", "call",
 		"org.gnome.Shell",
 		"/de/lucaswerkmeister/ActivateWindowByTitle",
 		"de.lucaswerkmeister.ActivateWindowByTitle",
@@ -68,27 +57,25 @@ func TryActivateWindowByTitle(terminalName, folderName string) error {
 
 // TryGnomeShellEvalByTitle uses GNOME Shell's Eval to find and focus window by title.
 // Requires unsafe_mode or development-tools enabled.
-func TryGnomeShellEvalByTitle(terminalName, folderName string) error {
+func TryGnomeShellEvalByTitle(ctx context.Context, terminalName, folderName string) error {
 	searchTerm := escapeJS(GetSearchTermWithFolder(terminalName, folderName))
 
-	// JavaScript to find window by title and activate it
+	// JavaScript to find window by title and activate it, preferring one on
+	// the active workspace and switching workspaces otherwise
 	js := fmt.Sprintf(`
 		(function() {
-			let start = Date.now();
-			let found = false;
-			global.get_window_actors().forEach(function(actor) {
-				let win = actor.get_meta_window();
-				let title = win.get_title() || '';
-				if (title.indexOf('%s') !== -1) {
-					win.activate(start);
-					found = true;
-				}
-			});
-			return found ? 'activated' : 'no matching window';
+			%s
+			let wins = global.get_window_actors()
+				.map(actor => actor.get_meta_window())
+				.filter(win => (win.get_title() || '').indexOf('%s') !== -1);
+			if (wins.length === 0) return 'no matching window';
+			let active = global.workspace_manager.get_active_workspace();
+			activateWindow(wins.find(win => win.located_on_workspace(active)) || wins[0]);
+			return 'activated';
 		})()
-	`, searchTerm)
+	`, gnomeActivateWindowJS, searchTerm)
 
-	cmd := exec.Command("gdbus", "call",
+	cmd := exec.CommandContext(ctx, "gdbus", "call",
 		"--session",
 		"--dest", "org.gnome.Shell",
 		"--object-path", "/org/gnome/Shell",
@@ -113,7 +100,7 @@ func TryGnomeShellEvalByTitle(terminalName, folderName string) error {
 
 // TryGnomeShellEval uses GNOME Shell's Eval method to activate an app.
 // Requires unsafe_mode or development-tools enabled.
-func TryGnomeShellEval(terminalName, folderName string) error {
+func TryGnomeShellEval(ctx context.Context, terminalName, folderName string) error {
 	appID := escapeJS(GetAppID(terminalName))
 
 	// JavaScript to find and activate the app's windows
@@ -128,7 +115,7 @@ func TryGnomeShellEval(terminalName, folderName string) error {
 		})()
 	`, appID)
 
-	cmd := exec.Command("gdbus", "call",
+	cmd := exec.CommandContext(ctx, "gdbus", "call",
 		"--session",
 		"--dest", "org.gnome.Shell",
 		"--object-path", "/org/gnome/Shell",
@@ -152,10 +139,10 @@ func TryGnomeShellEval(terminalName, folderName string) error {
 }
 
 // TryGnomeFocusApp uses GNOME Shell's FocusApp method (available since GNOME 45).
-func TryGnomeFocusApp(terminalName, folderName string) error {
+func TryGnomeFocusApp(ctx context.Context, terminalName, folderName string) error {
 	appID := GetAppID(terminalName)
 
-	cmd := exec.Command("gdbus", "call",
+	cmd := exec.CommandContext(ctx, "gdbus", "call",
 		"--session",
 		"--dest", "org.gnome.Shell",
 		"--object-path", "/org/gnome/Shell",
@@ -169,38 +156,93 @@ func TryGnomeFocusApp(terminalName, folderName string) error {
 	return nil
 }
 
+// TryHyprctl uses hyprctl to focus a window on Hyprland.
+// Only attempted when HYPRLAND_INSTANCE_SIGNATURE is set (i.e. inside a Hyprland session).
+func TryHyprctl(ctx context.Context, terminalName, folderName string) error {
+	if os.Getenv("HYPRLAND_INSTANCE_SIGNATURE") == "" {
+		return fmt.Errorf("not running under Hyprland")
+	}
+	if _, err := exec.LookPath("hyprctl"); err != nil {
+		return fmt.Errorf("hyprctl not installed")
+	}
+
+	var lastErr error
+	for _, selector := range HyprlandFocusSelectors(terminalName, folderName) {
+		// hyprctl exits 0 even when no window matches; success is reported as "ok"
+		output, err := exec.CommandContext(ctx, "hyprctl", "dispatch", "focuswindow", selector).CombinedOutput()
+		outputStr := strings.TrimSpace(string(output))
+		if err == nil && outputStr == "ok" {
+			return nil
+		}
+		lastErr = fmt.Errorf("hyprctl focuswindow %s: %v, output: %s", selector, err, outputStr)
+	}
+	return lastErr
+}
+
+// TrySwayIPC focuses a window on Sway by sending criteria commands over the
+// IPC socket in SWAYSOCK. Talks to the socket directly, so swaymsg is not required.
+func TrySwayIPC(ctx context.Context, terminalName, folderName string) error {
+	socketPath := os.Getenv("SWAYSOCK")
+	if socketPath == "" {
+		return fmt.Errorf("not running under Sway (SWAYSOCK not set)")
+	}
+
+	var lastErr error
+	for _, command := range SwayFocusCommands(terminalName, folderName) {
+		err := swayRunCommand(ctx, socketPath, command)
+		if err == nil {
+			return nil
+		}
+		lastErr = fmt.Errorf("sway %s: %w", command, err)
+	}
+	return lastErr
+}
+
 // TryWlrctl uses wlrctl for wlroots-based compositors (Sway, etc.).
-func TryWlrctl(terminalName, folderName string) error {
+func TryWlrctl(ctx context.Context, terminalName, folderName string) error {
 	if _, err := exec.LookPath("wlrctl"); err != nil {
 		return fmt.Errorf("wlrctl not installed")
 	}
 
 	// Try app_id first (more reliable)
 	appID := GetWlrctlAppID(terminalName)
-	cmd := exec.Command("wlrctl", "toplevel", "focus", "app_id:"+appID)
+	cmd := exec.CommandContext(ctx, "wlrctl", "toplevel", "focus", "app_id:"+appID)
 	if err := cmd.Run(); err == nil {
-		return nil
+		return foreignToplevelShowWindow(ctx, func(n *swayNode) bool { return n.class() == appID })
 	}
 
 	// Fallback to title
 	searchTerm := GetSearchTermWithFolder(terminalName, folderName)
-	cmd = exec.Command("wlrctl", "toplevel", "focus", "title:"+searchTerm)
+	cmd = exec.CommandContext(ctx, "wlrctl", "toplevel", "focus", "title:"+searchTerm)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("wlrctl failed: %w, output: %s", err, string(output))
 	}
+	return foreignToplevelShowWindow(ctx, func(n *swayNode) bool { return strings.Contains(n.Name, searchTerm) })
+}
+
+// foreignToplevelShowWindow switches to the workspace of a window activated
+// over wlr-foreign-toplevel (wlrctl or the built-in client) when running under Sway
+func foreignToplevelShowWindow(ctx context.Context, match func(*swayNode) bool) error {
+	socketPath := os.Getenv("SWAYSOCK")
+	if socketPath == "" {
+		return nil
+	}
+	if err := swayShowWindow(ctx, socketPath, match); err != nil {
+		return fmt.Errorf("activated the window but switching to its workspace failed: %w", err)
+	}
 	return nil
 }
 
 // TryKdotool uses kdotool for KDE Plasma.
-func TryKdotool(terminalName, folderName string) error {
+func TryKdotool(ctx context.Context, terminalName, folderName string) error {
 	if _, err := exec.LookPath("kdotool"); err != nil {
 		return fmt.Errorf("kdotool not installed")
 	}
 
 	// Search by class
 	className := GetKdotoolClass(terminalName)
-	searchCmd := exec.Command("kdotool", "search", "--class", className)
+	searchCmd := exec.CommandContext(ctx, "kdotool", "search", "--class", className)
 	output, err := searchCmd.CombinedOutput()
 	outputStr := strings.TrimSpace(string(output))
 
@@ -210,7 +252,7 @@ func TryKdotool(terminalName, folderName string) error {
 
 	windowIDs := strings.Split(outputStr, "\n")
 
-	cmd := exec.Command("kdotool", "windowactivate", windowIDs[0])
+	cmd := exec.CommandContext(ctx, "kdotool", "windowactivate", windowIDs[0])
 	if _, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("kdotool windowactivate failed: %w", err)
 	}
@@ -219,55 +261,124 @@ func TryKdotool(terminalName, folderName string) error {
 
 // TryXdotool uses xdotool for X11-based desktop environments
 // (XFCE, MATE, Cinnamon, i3, bspwm, and X11 sessions of GNOME/KDE).
-func TryXdotool(terminalName, folderName string) error {
+// Windows are matched by exact WM_CLASS; when folderName is set, a visible window of
+// that class whose title contains the folder is preferred.
+func TryXdotool(ctx context.Context, terminalName, folderName string) error {
 	if _, err := exec.LookPath("xdotool"); err != nil {
 		return fmt.Errorf("xdotool not installed")
 	}
+	if os.Getenv("DISPLAY") == "" {
+		return fmt.Errorf("xdotool requires an X11 display (DISPLAY not set)")
+	}
 
-	// Search by class name first (more reliable)
-	className := GetXdotoolClass(terminalName)
-	searchCmd := exec.Command("xdotool", "search", "--class", className)
-	output, err := searchCmd.CombinedOutput()
-	outputStr := strings.TrimSpace(string(output))
+	classPattern := WMClassPattern(GetXdotoolClass(terminalName))
 
-	if err != nil || outputStr == "" {
-		// Fallback: search by window name
+	var windowIDs []string
+
+	// Most specific: window of the right class showing the project folder in its title
+	if folderName != "" {
+		windowIDs = xdotoolSearch(ctx, "--all", "--onlyvisible", "--class", classPattern, "--name", regexp.QuoteMeta(folderName))
+	}
+
+	// Any visible window of the right class
+	if len(windowIDs) == 0 {
+		windowIDs = xdotoolSearch(ctx, "--onlyvisible", "--class", classPattern)
+	}
+
+	// Fallback: search by window name
+	if len(windowIDs) == 0 {
 		searchTerm := GetSearchTermWithFolder(terminalName, folderName)
-		searchCmd = exec.Command("xdotool", "search", "--name", searchTerm)
-		output, err = searchCmd.CombinedOutput()
-		outputStr = strings.TrimSpace(string(output))
+		windowIDs = xdotoolSearch(ctx, "--onlyvisible", "--name", regexp.QuoteMeta(searchTerm))
 	}
 
-	if err != nil || outputStr == "" {
-		return fmt.Errorf("no windows found via xdotool")
+	// Windows on other desktops are not visible
+	if len(windowIDs) == 0 {
+		return xdotoolActivateOnDesktop(ctx, classPattern, folderName)
 	}
 
 	// Take the first matching window
-	windowIDs := strings.Split(outputStr, "\n")
-	cmd := exec.Command("xdotool", "windowactivate", windowIDs[0])
+	cmd := exec.CommandContext(ctx, "xdotool", "windowactivate", windowIDs[0])
 	if _, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("xdotool windowactivate failed: %w", err)
 	}
 	return nil
 }
 
+// xdotoolSearch runs "xdotool search" with the given criteria and returns matching window IDs.
+// xdotool exits non-zero when nothing matches, which is reported as no IDs.
+func xdotoolSearch(ctx context.Context, args ...string) []string {
+	output, err := exec.CommandContext(ctx, "xdotool", append([]string{"search"}, args...)...).Output()
+	if err != nil {
+		return nil
+	}
+	return ParseWindowIDs(string(output))
+}
+
+// TryWmctrl uses wmctrl for EWMH-compliant X11 window managers.
+// wmctrl -a activates the first window whose title contains the argument;
+// with -x the argument is matched against WM_CLASS ("instance.Class") instead.
+func TryWmctrl(ctx context.Context, terminalName, folderName string) error {
+	if _, err := exec.LookPath("wmctrl"); err != nil {
+		return fmt.Errorf("wmctrl not installed")
+	}
+	if os.Getenv("DISPLAY") == "" {
+		return fmt.Errorf("wmctrl requires an X11 display (DISPLAY not set)")
+	}
+
+	// Project folder in the title is more specific than the class
+	if folderName != "" {
+		if err := exec.CommandContext(ctx, "wmctrl", "-a", folderName).Run(); err == nil {
+			return nil
+		}
+	}
+
+	// Match by WM_CLASS
+	if err := exec.CommandContext(ctx, "wmctrl", "-x", "-a", GetXdotoolClass(terminalName)).Run(); err == nil {
+		return nil
+	}
+
+	// Fallback: match by title
+	searchTerm := GetSearchTerm(terminalName)
+	output, err := exec.CommandContext(ctx, "wmctrl", "-a", searchTerm).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("wmctrl failed: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
 // DetectFocusTools returns a map of available focus tools.
 func DetectFocusTools() map[string]bool {
 	tools := map[string]bool{}
 
 	// Check command-line tools
-	for _, tool := range []string{"wlrctl", "kdotool", "xdotool", "gdbus", "busctl"} {
+	for _, tool := range []string{"hyprctl", "niri", "wlrctl", "kdotool", "xdotool", "wmctrl", "gdbus", "busctl"} {
 		_, err := exec.LookPath(tool)
 		tools[tool] = err == nil
 	}
 
 	// Check GNOME activate-window-by-title extension
-	cmd := exec.Command("busctl", "--user", "introspect",
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultFocusMethodTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "busctl", "--user", "introspect",
 		"org.gnome.Shell",
 		"/de/lucaswerkmeister/ActivateWindowByTitle",
 	)
 	output, err := cmd.CombinedOutput()
 	tools["activate-window-by-title"] = err == nil && strings.Contains(string(output), "activateBySubstring")
 
+	// Check the wlroots foreign toplevel protocol, spoken by the daemon itself
+	tools["wlr-foreign-toplevel"] = wlrForeignToplevelAvailable(ctx)
+
+	// Check KWin scripting (KDE Plasma), which needs no extra tool
+	cmd = exec.CommandContext(ctx, "busctl", "--user", "introspect", "org.kde.KWin", "/Scripting")
+	output, err = cmd.CombinedOutput()
+	tools["kwin-scripting"] = err == nil && strings.Contains(string(output), "loadScript")
+
+	// Check the Windows host's PowerShell, which focuses windows from WSL
+	if platform.IsWSL() {
+		_, err := exec.LookPath(platform.WSLPowerShell("")[0])
+		tools["wsl-host"] = err == nil
+	}
+
 	return tools
 }
@@ -0,0 +1,244 @@
+// ABOUTME: Platform-independent focus fallback chain.
+// ABOUTME: Each platform supplies its ordered methods via GetFocusMethods.
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/sessions"
+)
+
+const (
+	// DefaultFocusParallel is how many methods run at once in race mode
+	DefaultFocusParallel = 3
+	// DefaultFocusTimeout is how long race mode waits for a method to work
+	DefaultFocusTimeout = 300 * time.Millisecond
+	// DefaultSequentialFocusTimeout is how long sequential mode tries methods
+	DefaultSequentialFocusTimeout = 5 * time.Second
+	// DefaultFocusMethodTimeout is how long one method may run, so a hung
+	// D-Bus call or tool cannot hold up the chain
+	DefaultFocusMethodTimeout = 2 * time.Second
+)
+
+// pinnedWindowMethod names focusing the window pinned to a session in
+// logs and metrics
+const pinnedWindowMethod = "pinned window"
+
+// FocusMethod represents a method for focusing a window
+type FocusMethod struct {
+	Name string
+	Fn   func(ctx context.Context, terminalName, folderName string) error
+}
+
+// FocusOptions choose how the focus chain runs
+type FocusOptions struct {
+	Race          bool          // Run several methods at once, the first that works wins
+	Parallel      int           // Methods running at once when racing
+	Timeout       time.Duration // Overall deadline (0 = none)
+	MethodTimeout time.Duration // Deadline of each method (0 = none)
+}
+
+// NewFocusOptions returns the options set in the desktop focus config,
+// with defaults for what is not set
+func NewFocusOptions(cfg config.FocusConfig) FocusOptions {
+	opts := FocusOptions{
+		Race:          cfg.Mode == "race",
+		Parallel:      cfg.Parallel,
+		Timeout:       DefaultSequentialFocusTimeout,
+		MethodTimeout: DefaultFocusMethodTimeout,
+	}
+	if opts.Race {
+		opts.Timeout = DefaultFocusTimeout
+	}
+	if opts.Parallel <= 0 {
+		opts.Parallel = DefaultFocusParallel
+	}
+	if d, err := time.ParseDuration(cfg.Timeout); err == nil && d > 0 {
+		opts.Timeout = d
+	}
+	if d, err := time.ParseDuration(cfg.MethodTimeout); err == nil && d > 0 {
+		opts.MethodTimeout = d
+	}
+	return opts
+}
+
+// TryFocus attempts to focus a window using available tools.
+// folderName is the project folder name used for title-based window search (may be empty).
+// It tries each method in order until one succeeds, starting with the one
+// that worked last time, within the default timeouts.
+func TryFocus(terminalName, folderName string) error {
+	return tryFocus(terminalName, folderName, nil, NewFocusOptions(config.FocusConfig{}), nil)
+}
+
+// TryFocusWithOptions is TryFocus, racing the methods when opts.Race is set
+func TryFocusWithOptions(terminalName, folderName string, opts FocusOptions) error {
+	return tryFocus(terminalName, folderName, nil, opts, nil)
+}
+
+// tryFocus is TryFocusWithOptions, calling observe (if set) with the
+// outcome of each method tried. A window pinned to the session (nil =
+// none) is focused first; when it is gone, the method that worked last
+// time in this desktop environment is tried first.
+func tryFocus(terminalName, folderName string, window *sessions.Window, opts FocusOptions, observe func(method string, err error)) error {
+	chain := focusChain{
+		methods:       GetFocusMethods(),
+		path:          focusOrderPath(),
+		env:           FocusEnvironment(),
+		methodTimeout: opts.MethodTimeout,
+		observe:       observe,
+	}
+	ctx := context.Background()
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	if window != nil {
+		pinned := FocusMethod{Name: pinnedWindowMethod, Fn: func(ctx context.Context, _, _ string) error {
+			return FocusPinnedWindow(ctx, window)
+		}}
+		err := chain.call(ctx, pinned, terminalName, folderName)
+		if observe != nil {
+			observe(pinnedWindowMethod, err)
+		}
+		if err == nil {
+			return nil
+		}
+	}
+	if opts.Race {
+		return chain.race(ctx, opts.Parallel, terminalName, folderName)
+	}
+	return chain.run(ctx, terminalName, folderName)
+}
+
+// focusChain tries focus methods until one works, starting with the one
+// learned for env in the file at path (empty = nothing learned or saved).
+// A method that works but was not the learned one is remembered.
+type focusChain struct {
+	methods       []FocusMethod
+	path          string
+	env           string
+	methodTimeout time.Duration                  // Deadline of each method (0 = none)
+	observe       func(method string, err error) // Called with each outcome (nil = not observed)
+}
+
+// call runs one method within the method timeout
+func (c focusChain) call(ctx context.Context, method FocusMethod, terminalName, folderName string) error {
+	if c.methodTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.methodTimeout)
+		defer cancel()
+	}
+	return method.Fn(ctx, terminalName, folderName)
+}
+
+// ordered returns the methods with the learned one first, and the learned one
+func (c focusChain) ordered() ([]FocusMethod, string) {
+	learned := ""
+	if c.path != "" {
+		learned = loadFocusOrder(c.path)[c.env].Method
+	}
+	return orderFocusMethods(c.methods, learned), learned
+}
+
+// succeeded remembers a method that worked if it was not the learned one
+func (c focusChain) succeeded(method, learned string, d time.Duration) {
+	if c.path != "" && method != learned {
+		_ = rememberFocusMethod(c.path, c.env, method, d, time.Now())
+	}
+}
+
+// run tries the methods one after another until ctx ends
+func (c focusChain) run(ctx context.Context, terminalName, folderName string) error {
+	if len(c.methods) == 0 {
+		return fmt.Errorf("no focus methods available on this platform")
+	}
+
+	methods, learned := c.ordered()
+	var lastErr error
+	for _, method := range methods {
+		if ctx.Err() != nil {
+			return fmt.Errorf("focus timed out: %v, last error: %v", ctx.Err(), lastErr)
+		}
+		start := time.Now()
+		err := c.call(ctx, method, terminalName, folderName)
+		if c.observe != nil {
+			c.observe(method.Name, err)
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		c.succeeded(method.Name, learned, time.Since(start))
+		return nil
+	}
+
+	return fmt.Errorf("all focus methods failed, last error: %v", lastErr)
+}
+
+// focusOutcome is what one raced method returned
+type focusOutcome struct {
+	method   string
+	duration time.Duration
+	err      error
+}
+
+// race runs up to parallel methods at once, starting the next one in
+// order whenever one fails. The first method that works wins and the
+// others are cancelled; when ctx ends first, the focus has failed.
+func (c focusChain) race(ctx context.Context, parallel int, terminalName, folderName string) error {
+	if len(c.methods) == 0 {
+		return fmt.Errorf("no focus methods available on this platform")
+	}
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	methods, learned := c.ordered()
+	// Buffered for every method so losers never block after the race ends
+	outcomes := make(chan focusOutcome, len(methods))
+	start := func(method FocusMethod) {
+		go func() {
+			began := time.Now()
+			err := c.call(ctx, method, terminalName, folderName)
+			outcomes <- focusOutcome{method: method.Name, duration: time.Since(began), err: err}
+		}()
+	}
+
+	next, running := 0, 0
+	for ; next < len(methods) && next < parallel; next++ {
+		start(methods[next])
+		running++
+	}
+
+	var lastErr error
+	for running > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("focus timed out: %v", ctx.Err())
+		case o := <-outcomes:
+			running--
+			if c.observe != nil {
+				c.observe(o.method, o.err)
+			}
+			if o.err == nil {
+				c.succeeded(o.method, learned, o.duration)
+				return nil
+			}
+			lastErr = o.err
+			if next < len(methods) {
+				start(methods[next])
+				next++
+				running++
+			}
+		}
+	}
+
+	return fmt.Errorf("all focus methods failed, last error: %v", lastErr)
+}
@@ -0,0 +1,204 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+// raceMethod is a focus method for race tests that returns err after
+// delay, or ctx's error when cancelled first
+type raceMethod struct {
+	name  string
+	delay time.Duration
+	err   error
+}
+
+// raceMethods turns specs into focus methods, recording which ones
+// started and which ones were cancelled
+func raceMethods(specs []raceMethod, mu *sync.Mutex, started, cancelled *[]string) []FocusMethod {
+	var methods []FocusMethod
+	for _, spec := range specs {
+		spec := spec
+		methods = append(methods, FocusMethod{Name: spec.name, Fn: func(ctx context.Context, terminalName, folderName string) error {
+			mu.Lock()
+			*started = append(*started, spec.name)
+			mu.Unlock()
+			select {
+			case <-time.After(spec.delay):
+				return spec.err
+			case <-ctx.Done():
+				mu.Lock()
+				*cancelled = append(*cancelled, spec.name)
+				mu.Unlock()
+				return ctx.Err()
+			}
+		}})
+	}
+	return methods
+}
+
+func TestFocusChainRace_FirstWinnerCancelsOthers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), focusOrderFileName)
+	var mu sync.Mutex
+	var started, cancelled []string
+	methods := raceMethods([]raceMethod{
+		{name: "slow", delay: 5 * time.Second},
+		{name: "fast", delay: 10 * time.Millisecond},
+		{name: "unused", delay: 0},
+	}, &mu, &started, &cancelled)
+
+	chain := focusChain{methods: methods, path: path, env: "env"}
+	if err := chain.race(context.Background(), 2, "kitty", ""); err != nil {
+		t.Fatalf("focusChain.race() error = %v", err)
+	}
+	if learned := loadFocusOrder(path)["env"]; learned.Method != "fast" {
+		t.Errorf("learned = %+v, want fast", learned)
+	}
+
+	// The loser sees the cancellation shortly after the race ends
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		done := len(cancelled) == 1
+		mu.Unlock()
+		if done || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(cancelled, ",") != "slow" {
+		t.Errorf("cancelled = %v, want slow", cancelled)
+	}
+	if len(started) != 2 || strings.Contains(strings.Join(started, ","), "unused") {
+		t.Errorf("started = %v, want only the first two methods", started)
+	}
+}
+
+func TestFocusChainRace_StartsNextAfterFailure(t *testing.T) {
+	var mu sync.Mutex
+	var started, cancelled []string
+	methods := raceMethods([]raceMethod{
+		{name: "first", err: errors.New("first failed")},
+		{name: "second", err: errors.New("second failed")},
+		{name: "third"},
+	}, &mu, &started, &cancelled)
+
+	var observed []string
+	chain := focusChain{methods: methods, observe: func(method string, err error) {
+		observed = append(observed, method)
+	}}
+	if err := chain.race(context.Background(), 1, "kitty", ""); err != nil {
+		t.Fatalf("focusChain.race() error = %v", err)
+	}
+	if got := strings.Join(started, ","); got != "first,second,third" {
+		t.Errorf("started %s, want one method at a time in order", got)
+	}
+	if got := strings.Join(observed, ","); got != "first,second,third" {
+		t.Errorf("observed %s", got)
+	}
+}
+
+func TestFocusChainRace_Deadline(t *testing.T) {
+	var mu sync.Mutex
+	var started, cancelled []string
+	methods := raceMethods([]raceMethod{
+		{name: "first", delay: 5 * time.Second},
+		{name: "second", delay: 5 * time.Second},
+	}, &mu, &started, &cancelled)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	begin := time.Now()
+	err := focusChain{methods: methods}.race(ctx, 3, "kitty", "")
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("race took %v, want it to end at the deadline", elapsed)
+	}
+}
+
+func TestFocusChainRace_AllFail(t *testing.T) {
+	var mu sync.Mutex
+	var started, cancelled []string
+	methods := raceMethods([]raceMethod{
+		{name: "first", err: errors.New("first failed")},
+		{name: "second", err: errors.New("second failed")},
+	}, &mu, &started, &cancelled)
+
+	err := focusChain{methods: methods}.race(context.Background(), 3, "kitty", "")
+	if err == nil || !strings.Contains(err.Error(), "all focus methods failed") {
+		t.Errorf("error = %v, want every method to fail", err)
+	}
+	if err := (focusChain{}).race(context.Background(), 3, "kitty", ""); err == nil {
+		t.Error("focusChain.race() with no methods should fail")
+	}
+}
+
+func TestNewFocusOptions(t *testing.T) {
+	opts := NewFocusOptions(config.FocusConfig{})
+	want := FocusOptions{Parallel: DefaultFocusParallel, Timeout: DefaultSequentialFocusTimeout, MethodTimeout: DefaultFocusMethodTimeout}
+	if opts != want {
+		t.Errorf("NewFocusOptions(defaults) = %+v, want %+v", opts, want)
+	}
+
+	opts = NewFocusOptions(config.FocusConfig{Mode: "race"})
+	if opts.Timeout != DefaultFocusTimeout {
+		t.Errorf("race timeout = %v, want %v", opts.Timeout, DefaultFocusTimeout)
+	}
+
+	opts = NewFocusOptions(config.FocusConfig{Mode: "race", Parallel: 5, Timeout: "1s", MethodTimeout: "500ms"})
+	want = FocusOptions{Race: true, Parallel: 5, Timeout: time.Second, MethodTimeout: 500 * time.Millisecond}
+	if opts != want {
+		t.Errorf("NewFocusOptions() = %+v, want %+v", opts, want)
+	}
+}
+
+func TestFocusChainRun_MethodTimeout(t *testing.T) {
+	var mu sync.Mutex
+	var started, cancelled []string
+	methods := raceMethods([]raceMethod{
+		{name: "hung", delay: 5 * time.Second},
+		{name: "works"},
+	}, &mu, &started, &cancelled)
+
+	begin := time.Now()
+	chain := focusChain{methods: methods, methodTimeout: 20 * time.Millisecond}
+	if err := chain.run(context.Background(), "kitty", ""); err != nil {
+		t.Fatalf("focusChain.run() error = %v", err)
+	}
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("run took %v, want the hung method stopped", elapsed)
+	}
+	if got := strings.Join(cancelled, ","); got != "hung" {
+		t.Errorf("cancelled = %s, want hung", got)
+	}
+}
+
+func TestFocusChainRun_Deadline(t *testing.T) {
+	var mu sync.Mutex
+	var started, cancelled []string
+	methods := raceMethods([]raceMethod{
+		{name: "first", delay: 5 * time.Second},
+		{name: "second"},
+	}, &mu, &started, &cancelled)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := focusChain{methods: methods}.run(ctx, "kitty", "")
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("error = %v, want a timeout", err)
+	}
+	if got := strings.Join(started, ","); got != "first" {
+		t.Errorf("started = %s, want nothing after the deadline", got)
+	}
+}
@@ -0,0 +1,136 @@
+//go:build darwin
+
+// ABOUTME: Window focus methods for macOS.
+// ABOUTME: Tries AppleScript window raising, then app activation, then LaunchServices via open -b.
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/777genius/claude-notifications/internal/sessions"
+)
+
+// GetFocusMethods returns the ordered list of focus methods to try.
+// On macOS terminalName may be a bundle ID or a TERM_PROGRAM value.
+func GetFocusMethods() []FocusMethod {
+	return []FocusMethod{
+		{"AppleScript (by window title)", TryAppleScriptWindow},
+		{"AppleScript activate", TryAppleScriptActivate},
+		{"open -b", TryOpenBundle},
+	}
+}
+
+// TryAppleScriptWindow activates the app and raises the first window whose
+// title contains the project folder. Apps without an AppleScript window
+// dictionary (e.g. VS Code) fail here and fall through to plain activation.
+func TryAppleScriptWindow(ctx context.Context, terminalName, folderName string) error {
+	if folderName == "" {
+		return fmt.Errorf("no folder name for window search")
+	}
+	bundleID := GetMacBundleID(terminalName)
+	if bundleID == "" {
+		return fmt.Errorf("unknown bundle ID for %s", terminalName)
+	}
+	return runOsascript(ctx, buildRaiseWindowScript(bundleID, folderName)...)
+}
+
+// TryAppleScriptActivate brings the app to the front with "tell application id ... to activate".
+func TryAppleScriptActivate(ctx context.Context, terminalName, folderName string) error {
+	bundleID := GetMacBundleID(terminalName)
+	if bundleID == "" {
+		return fmt.Errorf("unknown bundle ID for %s", terminalName)
+	}
+	return runOsascript(ctx, fmt.Sprintf(`tell application id "%s" to activate`, escapeAppleScript(bundleID)))
+}
+
+// TryOpenBundle activates the app through LaunchServices. Works without
+// Automation permission, but cannot select a specific window.
+func TryOpenBundle(ctx context.Context, terminalName, folderName string) error {
+	bundleID := GetMacBundleID(terminalName)
+	if bundleID == "" {
+		return fmt.Errorf("unknown bundle ID for %s", terminalName)
+	}
+	output, err := exec.CommandContext(ctx, "open", "-b", bundleID).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("open -b failed: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
+// buildRaiseWindowScript returns osascript -e lines that activate the app and
+// raise the window whose title is, or contains as a " — " / " - " separated
+// component, folderName.
+func buildRaiseWindowScript(bundleID, folderName string) []string {
+	return []string{
+		fmt.Sprintf(`tell application id "%s"`, escapeAppleScript(bundleID)),
+		`activate`,
+		fmt.Sprintf(`set _n to "%s"`, escapeAppleScript(folderName)),
+		`set _d1 to " — " & _n`,
+		`set _d2 to _n & " — "`,
+		`set _d3 to " - " & _n`,
+		`set _d4 to _n & " - "`,
+		`repeat with w in windows`,
+		`set _t to name of w`,
+		`if _t = _n or _t contains _d1 or _t contains _d2 or _t contains _d3 or _t contains _d4 then`,
+		`set index of w to 1`,
+		`return`,
+		`end if`,
+		`end repeat`,
+		`error "no window matching " & _n`,
+		`end tell`,
+	}
+}
+
+// runOsascript runs osascript with one -e argument per script line.
+func runOsascript(ctx context.Context, lines ...string) error {
+	args := make([]string, 0, len(lines)*2)
+	for _, line := range lines {
+		args = append(args, "-e", line)
+	}
+	output, err := exec.CommandContext(ctx, "osascript", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("osascript failed: %w, output: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// TerminalFocused reports whether terminalName is the frontmost app.
+// Windows are not pinned on macOS, so pinned is ignored.
+func TerminalFocused(ctx context.Context, terminalName string, pinned *sessions.Window) (bool, error) {
+	bundleID := GetMacBundleID(terminalName)
+	if bundleID == "" {
+		return false, fmt.Errorf("unknown bundle ID for %s", terminalName)
+	}
+	front, err := exec.CommandContext(ctx, "lsappinfo", "front").Output()
+	if err != nil {
+		return false, fmt.Errorf("lsappinfo front failed: %w", err)
+	}
+	output, err := exec.CommandContext(ctx, "lsappinfo", "info", "-only", "bundleid", strings.TrimSpace(string(front))).Output()
+	if err != nil {
+		return false, fmt.Errorf("lsappinfo info failed: %w", err)
+	}
+	return strings.EqualFold(parseLsappinfoBundleID(string(output)), bundleID), nil
+}
+
+// parseLsappinfoBundleID reads the bundle ID from "lsappinfo info -only
+// bundleid" output, e.g. "CFBundleIdentifier"="com.googlecode.iterm2"
+func parseLsappinfoBundleID(output string) string {
+	_, value, ok := strings.Cut(strings.TrimSpace(output), "=")
+	if !ok {
+		return ""
+	}
+	return strings.Trim(value, `"`)
+}
+
+// DetectFocusTools returns a map of available focus tools.
+func DetectFocusTools() map[string]bool {
+	tools := map[string]bool{}
+	for _, tool := range []string{"osascript", "open"} {
+		_, err := exec.LookPath(tool)
+		tools[tool] = err == nil
+	}
+	return tools
+}
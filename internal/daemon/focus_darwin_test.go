@@ -0,0 +1,62 @@
+//go:build darwin
+
+package daemon
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGetFocusMethods_Order(t *testing.T) {
+	expected := []string{
+		"AppleScript (by window title)",
+		"AppleScript activate",
+		"open -b",
+	}
+
+	methods := GetFocusMethods()
+	if len(methods) != len(expected) {
+		t.Fatalf("GetFocusMethods() returned %d methods, want %d", len(methods), len(expected))
+	}
+	for i, name := range expected {
+		if methods[i].Name != name {
+			t.Errorf("method[%d] = %q, want %q", i, methods[i].Name, name)
+		}
+	}
+}
+
+func TestBuildRaiseWindowScript(t *testing.T) {
+	script := strings.Join(buildRaiseWindowScript("com.googlecode.iterm2", `my "app"`), "\n")
+
+	if !strings.HasPrefix(script, `tell application id "com.googlecode.iterm2"`) {
+		t.Errorf("script should target bundle ID, got: %s", script)
+	}
+	if !strings.Contains(script, `set _n to "my \"app\""`) {
+		t.Errorf("folder name should be escaped, got: %s", script)
+	}
+	if !strings.Contains(script, "set index of w to 1") {
+		t.Errorf("script should raise the matching window, got: %s", script)
+	}
+}
+
+func TestTryAppleScriptWindow_NoFolder(t *testing.T) {
+	if err := TryAppleScriptWindow(context.Background(), "com.apple.Terminal", ""); err == nil {
+		t.Error("TryAppleScriptWindow should fail without a folder name")
+	}
+}
+
+func TestTryOpenBundle_UnknownTerminal(t *testing.T) {
+	if err := TryOpenBundle(context.Background(), "unknown-terminal", ""); err == nil {
+		t.Error("TryOpenBundle should fail for a terminal without a bundle ID")
+	}
+}
+
+func TestParseLsappinfoBundleID(t *testing.T) {
+	if got := parseLsappinfoBundleID(`"CFBundleIdentifier"="com.googlecode.iterm2"` + "\n"); got != "com.googlecode.iterm2" {
+		t.Errorf("got %q, want com.googlecode.iterm2", got)
+	}
+	if got := parseLsappinfoBundleID(""); got != "" {
+		t.Errorf("got %q for empty output, want empty", got)
+	}
+}
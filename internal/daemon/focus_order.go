@@ -0,0 +1,176 @@
+// ABOUTME: Remembers which focus method works in each desktop environment.
+// ABOUTME: The focus chain tries that method first; a probe re-learns it from scratch.
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+// focusOrderFileName is the file in the stable config dir holding learned focus methods
+const focusOrderFileName = "focus-methods.json"
+
+// LearnedFocus is the method that last focused a window in one environment
+type LearnedFocus struct {
+	Method     string    `json:"method"`
+	DurationMs int64     `json:"duration_ms"` // How long the method took
+	Updated    time.Time `json:"updated"`
+}
+
+// ProbeResult is the outcome of one focus method during a probe
+type ProbeResult struct {
+	Method   string
+	Duration time.Duration
+	Err      error
+}
+
+// FocusEnvironment identifies the desktop a focus method was learned in,
+// e.g. "linux/GNOME/wayland": the OS, XDG_CURRENT_DESKTOP and XDG_SESSION_TYPE
+func FocusEnvironment() string {
+	parts := []string{runtime.GOOS}
+	if runtime.GOOS == "linux" {
+		for _, env := range []string{"XDG_CURRENT_DESKTOP", "XDG_SESSION_TYPE"} {
+			value := os.Getenv(env)
+			if value == "" {
+				value = "unknown"
+			}
+			parts = append(parts, value)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// LearnedFocusMethod returns the method learned for the current
+// environment (nil = none yet)
+func LearnedFocusMethod() *LearnedFocus {
+	path := focusOrderPath()
+	if path == "" {
+		return nil
+	}
+	learned, ok := loadFocusOrder(path)[FocusEnvironment()]
+	if !ok {
+		return nil
+	}
+	return &learned
+}
+
+// ProbeFocusMethods tries every focus method on a terminal, not just up
+// to the first that works, and remembers the fastest one that worked for
+// the current environment. Without a working method, the learned one is
+// forgotten so the next focus starts from the default order again.
+func ProbeFocusMethods(terminalName, folderName string) []ProbeResult {
+	var results []ProbeResult
+	best := -1
+	for _, method := range GetFocusMethods() {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultFocusMethodTimeout)
+		start := time.Now()
+		err := method.Fn(ctx, terminalName, folderName)
+		cancel()
+		results = append(results, ProbeResult{Method: method.Name, Duration: time.Since(start), Err: err})
+		if err == nil && (best < 0 || results[len(results)-1].Duration < results[best].Duration) {
+			best = len(results) - 1
+		}
+	}
+
+	if path := focusOrderPath(); path != "" {
+		env := FocusEnvironment()
+		if best >= 0 {
+			_ = rememberFocusMethod(path, env, results[best].Method, results[best].Duration, time.Now())
+		} else {
+			_ = forgetFocusMethod(path, env)
+		}
+	}
+	return results
+}
+
+// orderFocusMethods moves the learned method to the front, keeping the
+// order of the others
+func orderFocusMethods(methods []FocusMethod, learned string) []FocusMethod {
+	if learned == "" {
+		return methods
+	}
+	ordered := make([]FocusMethod, 0, len(methods))
+	for _, m := range methods {
+		if m.Name == learned {
+			ordered = append(ordered, m)
+		}
+	}
+	if len(ordered) == 0 {
+		return methods // Learned by another version that no longer has it
+	}
+	for _, m := range methods {
+		if m.Name != learned {
+			ordered = append(ordered, m)
+		}
+	}
+	return ordered
+}
+
+// focusOrderPath returns the learned focus methods file ("" = no config dir)
+func focusOrderPath() string {
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, focusOrderFileName)
+}
+
+// loadFocusOrder reads the learned methods by environment; a missing or
+// corrupted file has none
+func loadFocusOrder(path string) map[string]LearnedFocus {
+	learned := map[string]LearnedFocus{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return learned
+	}
+	if err := json.Unmarshal(data, &learned); err != nil {
+		return map[string]LearnedFocus{}
+	}
+	return learned
+}
+
+// rememberFocusMethod records the method that worked in env
+func rememberFocusMethod(path, env, method string, d time.Duration, now time.Time) error {
+	learned := loadFocusOrder(path)
+	learned[env] = LearnedFocus{Method: method, DurationMs: d.Milliseconds(), Updated: now}
+	return saveFocusOrder(path, learned)
+}
+
+// forgetFocusMethod removes what was learned for env
+func forgetFocusMethod(path, env string) error {
+	learned := loadFocusOrder(path)
+	if _, ok := learned[env]; !ok {
+		return nil
+	}
+	delete(learned, env)
+	return saveFocusOrder(path, learned)
+}
+
+// saveFocusOrder writes the learned methods, replacing the file at once
+// so a concurrent reader never sees half of it
+func saveFocusOrder(path string, learned map[string]LearnedFocus) error {
+	data, err := json.MarshalIndent(learned, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize focus methods: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for focus methods: %w", err)
+	}
+	tmp := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write focus methods: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write focus methods: %w", err)
+	}
+	return nil
+}
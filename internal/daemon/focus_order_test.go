@@ -0,0 +1,150 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeMethods returns focus methods that fail unless named in working,
+// appending each method tried to calls
+func fakeMethods(calls *[]string, working ...string) []FocusMethod {
+	var methods []FocusMethod
+	for _, name := range []string{"first", "second", "third"} {
+		name := name
+		methods = append(methods, FocusMethod{Name: name, Fn: func(ctx context.Context, terminalName, folderName string) error {
+			*calls = append(*calls, name)
+			for _, w := range working {
+				if w == name {
+					return nil
+				}
+			}
+			return errors.New(name + " failed")
+		}})
+	}
+	return methods
+}
+
+func TestFocusChainRun_LearnsWorkingMethod(t *testing.T) {
+	path := filepath.Join(t.TempDir(), focusOrderFileName)
+	var calls []string
+
+	// First run: the default order, until "third" works
+	if err := (focusChain{methods: fakeMethods(&calls, "third"), path: path, env: "linux/GNOME/wayland"}).run(context.Background(), "kitty", ""); err != nil {
+		t.Fatalf("focusChain.run() error = %v", err)
+	}
+	if got := strings.Join(calls, ","); got != "first,second,third" {
+		t.Errorf("first run tried %s", got)
+	}
+	if learned := loadFocusOrder(path)["linux/GNOME/wayland"]; learned.Method != "third" {
+		t.Errorf("learned = %+v, want third", learned)
+	}
+
+	// Second run: the learned method goes first
+	calls = nil
+	if err := (focusChain{methods: fakeMethods(&calls, "third"), path: path, env: "linux/GNOME/wayland"}).run(context.Background(), "kitty", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(calls, ","); got != "third" {
+		t.Errorf("second run tried %s, want third only", got)
+	}
+
+	// Another environment keeps the default order
+	calls = nil
+	_ = (focusChain{methods: fakeMethods(&calls, "second"), path: path, env: "linux/KDE/x11"}).run(context.Background(), "kitty", "")
+	if got := strings.Join(calls, ","); got != "first,second" {
+		t.Errorf("other environment tried %s", got)
+	}
+	if learned := loadFocusOrder(path)["linux/GNOME/wayland"]; learned.Method != "third" {
+		t.Errorf("learning in another environment changed GNOME to %+v", learned)
+	}
+}
+
+func TestFocusChainRun_RelearnsWhenLearnedMethodFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), focusOrderFileName)
+	if err := rememberFocusMethod(path, "env", "third", time.Second, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls []string
+	if err := (focusChain{methods: fakeMethods(&calls, "second"), path: path, env: "env"}).run(context.Background(), "kitty", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(calls, ","); got != "third,first,second" {
+		t.Errorf("tried %s, want third,first,second", got)
+	}
+	if learned := loadFocusOrder(path)["env"]; learned.Method != "second" {
+		t.Errorf("learned = %+v, want second", learned)
+	}
+}
+
+func TestFocusChainRun_AllFail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), focusOrderFileName)
+	var calls []string
+	var observed []string
+	chain := focusChain{methods: fakeMethods(&calls), path: path, env: "env", observe: func(method string, err error) {
+		observed = append(observed, method)
+	}}
+	err := chain.run(context.Background(), "kitty", "")
+	if err == nil || !strings.Contains(err.Error(), "third failed") {
+		t.Errorf("error = %v, want the last method's error", err)
+	}
+	if len(observed) != 3 {
+		t.Errorf("observed %v, want all three methods", observed)
+	}
+	if _, statErr := os.Stat(path); !os.IsNotExist(statErr) {
+		t.Errorf("nothing should be learned when every method fails")
+	}
+
+	if err := (focusChain{path: path, env: "env"}).run(context.Background(), "kitty", ""); err == nil {
+		t.Error("focusChain.run() with no methods should fail")
+	}
+}
+
+func TestOrderFocusMethods_UnknownLearned(t *testing.T) {
+	var calls []string
+	methods := fakeMethods(&calls)
+	ordered := orderFocusMethods(methods, "removed-method")
+	if len(ordered) != 3 || ordered[0].Name != "first" {
+		t.Errorf("unknown learned method should keep the default order, got %v", ordered)
+	}
+}
+
+func TestLoadFocusOrder_Corrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), focusOrderFileName)
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if learned := loadFocusOrder(path); len(learned) != 0 {
+		t.Errorf("corrupted file should have no learned methods, got %v", learned)
+	}
+	if err := rememberFocusMethod(path, "env", "first", time.Millisecond, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if learned := loadFocusOrder(path)["env"]; learned.Method != "first" {
+		t.Errorf("learned = %+v after overwriting a corrupted file", learned)
+	}
+	if err := forgetFocusMethod(path, "env"); err != nil {
+		t.Fatal(err)
+	}
+	if learned := loadFocusOrder(path); len(learned) != 0 {
+		t.Errorf("forgetFocusMethod() left %v", learned)
+	}
+}
+
+func TestFocusEnvironment(t *testing.T) {
+	t.Setenv("XDG_CURRENT_DESKTOP", "Hyprland")
+	t.Setenv("XDG_SESSION_TYPE", "")
+	want := runtime.GOOS
+	if runtime.GOOS == "linux" {
+		want = "linux/Hyprland/unknown"
+	}
+	if got := FocusEnvironment(); got != want {
+		t.Errorf("FocusEnvironment() = %q, want %q", got, want)
+	}
+}
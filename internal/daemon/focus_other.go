@@ -0,0 +1,26 @@
+//go:build !linux && !darwin && !windows
+
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	"github.com/777genius/claude-notifications/internal/sessions"
+)
+
+// GetFocusMethods returns no methods on platforms without a focus backend.
+func GetFocusMethods() []FocusMethod {
+	return nil
+}
+
+// TerminalFocused is not supported on platforms without a focus backend.
+func TerminalFocused(ctx context.Context, terminalName string, pinned *sessions.Window) (bool, error) {
+	return false, fmt.Errorf("focus detection is not supported on %s", runtime.GOOS)
+}
+
+// DetectFocusTools returns no tools on platforms without a focus backend.
+func DetectFocusTools() map[string]bool {
+	return map[string]bool{}
+}
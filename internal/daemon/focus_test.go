@@ -3,6 +3,8 @@
 package daemon
 
 import (
+	"context"
+	"strings"
 	"testing"
 )
 
@@ -12,13 +14,21 @@ func TestGetFocusMethods_Order(t *testing.T) {
 	methods := GetFocusMethods()
 
 	expectedNames := []string{
+		"WSL host",
 		"activate-window-by-title extension",
 		"GNOME Shell Eval (by window title)",
 		"GNOME Shell Eval (by app)",
 		"GNOME Shell FocusApp",
+		"hyprctl",
+		"niri",
+		"sway IPC",
+		"COSMIC toplevel",
+		"wlr foreign toplevel",
 		"wlrctl",
+		"KWin script",
 		"kdotool",
 		"xdotool",
+		"wmctrl",
 	}
 
 	if len(methods) != len(expectedNames) {
@@ -52,3 +62,39 @@ func TestGetFocusMethods_AllHaveFunctions(t *testing.T) {
 		}
 	}
 }
+
+// --- TryHyprctl tests ---
+
+func TestTryHyprctl_NotHyprlandSession(t *testing.T) {
+	t.Setenv("HYPRLAND_INSTANCE_SIGNATURE", "")
+
+	err := TryHyprctl(context.Background(), "kitty", "project")
+	if err == nil {
+		t.Fatal("TryHyprctl should fail outside a Hyprland session")
+	}
+	if !strings.Contains(err.Error(), "Hyprland") {
+		t.Errorf("error = %q, want mention of Hyprland", err)
+	}
+}
+
+// --- TrySwayIPC tests ---
+
+func TestTrySwayIPC_NotSwaySession(t *testing.T) {
+	t.Setenv("SWAYSOCK", "")
+
+	if err := TrySwayIPC(context.Background(), "kitty", "project"); err == nil {
+		t.Fatal("TrySwayIPC should fail when SWAYSOCK is not set")
+	}
+}
+
+func TestTrySwayIPC_FocusesFirstMatch(t *testing.T) {
+	socketPath, received := startFakeSway(t, `[{"success":true}]`)
+	t.Setenv("SWAYSOCK", socketPath)
+
+	if err := TrySwayIPC(context.Background(), "kitty", ""); err != nil {
+		t.Fatalf("TrySwayIPC() error = %v", err)
+	}
+	if got := <-received; got != `[app_id="^kitty$"] focus` {
+		t.Errorf("first command = %q, want app_id criteria", got)
+	}
+}
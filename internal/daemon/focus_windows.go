@@ -0,0 +1,175 @@
+//go:build windows
+
+// ABOUTME: Window focus for Windows via the Win32 API.
+// ABOUTME: Enumerates top-level windows and works around the foreground lock with AttachThreadInput.
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/777genius/claude-notifications/internal/sessions"
+)
+
+const (
+	swRestore                      = 9
+	processQueryLimitedInformation = 0x1000
+)
+
+var (
+	user32   = syscall.NewLazyDLL("user32.dll")
+	kernel32 = syscall.NewLazyDLL("kernel32.dll")
+
+	procEnumWindows                = user32.NewProc("EnumWindows")
+	procGetWindowTextW             = user32.NewProc("GetWindowTextW")
+	procIsWindowVisible            = user32.NewProc("IsWindowVisible")
+	procGetWindowThreadProcessID   = user32.NewProc("GetWindowThreadProcessId")
+	procGetForegroundWindow        = user32.NewProc("GetForegroundWindow")
+	procSetForegroundWindow        = user32.NewProc("SetForegroundWindow")
+	procBringWindowToTop           = user32.NewProc("BringWindowToTop")
+	procShowWindow                 = user32.NewProc("ShowWindow")
+	procIsIconic                   = user32.NewProc("IsIconic")
+	procAttachThreadInput          = user32.NewProc("AttachThreadInput")
+	procGetCurrentThreadID         = kernel32.NewProc("GetCurrentThreadId")
+	procQueryFullProcessImageNameW = kernel32.NewProc("QueryFullProcessImageNameW")
+)
+
+// enumWindowsCallback is created once: syscall.NewCallback slots are never freed.
+// enumMu serializes EnumWindows calls, which append to enumResult.
+var (
+	enumMu              sync.Mutex
+	enumResult          []windowCandidate
+	enumWindowsCallback = syscall.NewCallback(func(hwnd uintptr, _ uintptr) uintptr {
+		if visible, _, _ := procIsWindowVisible.Call(hwnd); visible != 0 {
+			if title := windowTitle(hwnd); title != "" {
+				enumResult = append(enumResult, windowCandidate{
+					Handle:  hwnd,
+					Title:   title,
+					Process: windowProcessImage(hwnd),
+				})
+			}
+		}
+		return 1 // continue enumeration
+	})
+)
+
+// GetFocusMethods returns the ordered list of focus methods to try.
+func GetFocusMethods() []FocusMethod {
+	return []FocusMethod{
+		{"Win32 SetForegroundWindow", TrySetForegroundWindow},
+	}
+}
+
+// TrySetForegroundWindow finds the terminal's top-level window (preferring one whose
+// title contains folderName) and brings it to the foreground.
+func TrySetForegroundWindow(ctx context.Context, terminalName, folderName string) error {
+	windows, err := listWindows()
+	if err != nil {
+		return err
+	}
+
+	w, ok := pickWindow(windows, terminalName, folderName)
+	if !ok {
+		return fmt.Errorf("no window found for %s", terminalName)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return focusWindowHandle(w.Handle)
+}
+
+// TerminalFocused reports whether the foreground window belongs to
+// terminalName. Windows are not pinned, so pinned is ignored.
+func TerminalFocused(ctx context.Context, terminalName string, pinned *sessions.Window) (bool, error) {
+	hwnd, _, _ := procGetForegroundWindow.Call()
+	if hwnd == 0 {
+		return false, fmt.Errorf("no foreground window")
+	}
+	w := windowCandidate{Handle: hwnd, Title: windowTitle(hwnd), Process: windowProcessImage(hwnd)}
+	_, ok := pickWindow([]windowCandidate{w}, terminalName, "")
+	return ok, nil
+}
+
+// listWindows enumerates visible, titled top-level windows.
+func listWindows() ([]windowCandidate, error) {
+	enumMu.Lock()
+	defer enumMu.Unlock()
+
+	enumResult = nil
+	if ret, _, err := procEnumWindows.Call(enumWindowsCallback, 0); ret == 0 {
+		return nil, fmt.Errorf("EnumWindows failed: %w", err)
+	}
+	return enumResult, nil
+}
+
+// windowTitle returns the title bar text of hwnd.
+func windowTitle(hwnd uintptr) string {
+	buf := make([]uint16, 512)
+	n, _, _ := procGetWindowTextW.Call(hwnd, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	return syscall.UTF16ToString(buf[:n])
+}
+
+// windowProcessImage returns the full executable path of the process owning hwnd,
+// or "" if it cannot be queried (e.g. elevated processes).
+func windowProcessImage(hwnd uintptr) string {
+	var pid uint32
+	procGetWindowThreadProcessID.Call(hwnd, uintptr(unsafe.Pointer(&pid)))
+	if pid == 0 {
+		return ""
+	}
+
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, pid)
+	if err != nil {
+		return ""
+	}
+	defer syscall.CloseHandle(h)
+
+	buf := make([]uint16, syscall.MAX_PATH)
+	size := uint32(len(buf))
+	ret, _, _ := procQueryFullProcessImageNameW.Call(uintptr(h), 0, uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&size)))
+	if ret == 0 {
+		return ""
+	}
+	return syscall.UTF16ToString(buf[:size])
+}
+
+// focusWindowHandle restores and raises hwnd. Windows only lets the foreground
+// process change the foreground window, so the calling thread temporarily attaches
+// its input queue to the current foreground thread to inherit that right. The
+// goroutine stays on its OS thread until the deferred detach has run, so the
+// attach, SetForegroundWindow and the detach all use the same thread.
+func focusWindowHandle(hwnd uintptr) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if iconic, _, _ := procIsIconic.Call(hwnd); iconic != 0 {
+		procShowWindow.Call(hwnd, swRestore)
+	}
+
+	currentThread, _, _ := procGetCurrentThreadID.Call()
+	if fg, _, _ := procGetForegroundWindow.Call(); fg != 0 && fg != hwnd {
+		fgThread, _, _ := procGetWindowThreadProcessID.Call(fg, 0)
+		if fgThread != 0 && fgThread != currentThread {
+			if attached, _, _ := procAttachThreadInput.Call(currentThread, fgThread, 1); attached != 0 {
+				defer procAttachThreadInput.Call(currentThread, fgThread, 0)
+			}
+		}
+	}
+
+	procBringWindowToTop.Call(hwnd)
+	if ok, _, err := procSetForegroundWindow.Call(hwnd); ok == 0 {
+		return fmt.Errorf("SetForegroundWindow failed: %w", err)
+	}
+	return nil
+}
+
+// DetectFocusTools returns a map of available focus tools.
+func DetectFocusTools() map[string]bool {
+	return map[string]bool{
+		"user32": user32.Load() == nil,
+	}
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package daemon
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGetFocusMethods_Windows(t *testing.T) {
+	methods := GetFocusMethods()
+	if len(methods) != 1 || methods[0].Name != "Win32 SetForegroundWindow" {
+		t.Errorf("GetFocusMethods() = %v, want Win32 SetForegroundWindow", methods)
+	}
+}
+
+func TestListWindows(t *testing.T) {
+	if _, err := listWindows(); err != nil {
+		t.Fatalf("listWindows() error = %v", err)
+	}
+}
+
+func TestTrySetForegroundWindow_NoMatch(t *testing.T) {
+	if err := TrySetForegroundWindow(context.Background(), "no-such-terminal-xyz", ""); err == nil {
+		t.Error("TrySetForegroundWindow should fail when no window matches")
+	}
+}
@@ -0,0 +1,60 @@
+//go:build linux
+
+// ABOUTME: Window focus on the Windows host for sessions running in WSL.
+// ABOUTME: Activates the Windows Terminal or VS Code window through PowerShell on the host.
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/777genius/claude-notifications/internal/platform"
+)
+
+// wslEditorProcesses are the Windows editors that connect to WSL; their
+// integrated terminals set TERM_PROGRAM=vscode
+var wslEditorProcesses = []string{"Code", "Code - Insiders", "Cursor", "Windsurf"}
+
+// wslTerminalProcesses are the Windows terminals that run WSL shells.
+// Windows Terminal, the usual one, does not set TERM_PROGRAM.
+var wslTerminalProcesses = []string{"WindowsTerminal", "wezterm-gui", "alacritty"}
+
+// TryWSLHost focuses the Windows window of a session running in WSL: the
+// Windows Terminal or VS Code window with the project folder in its title,
+// else the first window of the terminal.
+func TryWSLHost(ctx context.Context, terminalName, folderName string) error {
+	if !platform.IsWSL() {
+		return fmt.Errorf("not running in WSL")
+	}
+	args := platform.WSLPowerShell(buildWSLFocusScript(terminalName, folderName))
+	output, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("WSL host focus failed: %w, output: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// buildWSLFocusScript builds the PowerShell script activating the window
+// of terminalName with folderName in its title. It exits 1 when no window
+// matches and 2 when Windows refuses to activate it.
+func buildWSLFocusScript(terminalName, folderName string) string {
+	names := append(append([]string(nil), wslTerminalProcesses...), wslEditorProcesses...)
+	if strings.EqualFold(terminalName, "vscode") {
+		names = wslEditorProcesses
+	}
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = platform.PSQuote(name)
+	}
+
+	return strings.Join([]string{
+		"$windows = @(Get-Process -Name " + strings.Join(quoted, ", ") + " -ErrorAction SilentlyContinue | Where-Object { $_.MainWindowHandle -ne 0 })",
+		"$folder = " + platform.PSQuote(folderName),
+		"$match = $windows | Where-Object { $folder -ne '' -and $_.MainWindowTitle -like ('*' + $folder + '*') } | Select-Object -First 1",
+		"if (-not $match) { $match = $windows | Select-Object -First 1 }",
+		"if (-not $match) { exit 1 }",
+		"if (-not (New-Object -ComObject WScript.Shell).AppActivate($match.Id)) { exit 2 }",
+	}, "; ")
+}
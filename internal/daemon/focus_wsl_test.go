@@ -0,0 +1,40 @@
+//go:build linux
+
+package daemon
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/777genius/claude-notifications/internal/platform"
+)
+
+func TestBuildWSLFocusScript(t *testing.T) {
+	script := buildWSLFocusScript("", "it's-api")
+	for _, want := range []string{
+		"Get-Process -Name 'WindowsTerminal', 'wezterm-gui', 'alacritty', 'Code',",
+		"$folder = 'it''s-api'",
+		"AppActivate($match.Id)",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+
+	script = buildWSLFocusScript("vscode", "api")
+	if strings.Contains(script, "WindowsTerminal") || !strings.Contains(script, "'Code', 'Code - Insiders'") {
+		t.Errorf("VS Code script should only look for editors:\n%s", script)
+	}
+}
+
+func TestTryWSLHost_NotWSL(t *testing.T) {
+	t.Setenv("WSL_DISTRO_NAME", "")
+	t.Setenv("WSL_INTEROP", "")
+	if platform.IsWSL() {
+		t.Skip("running in WSL")
+	}
+	if err := TryWSLHost(context.Background(), "", "api"); err == nil {
+		t.Error("TryWSLHost outside WSL should fail")
+	}
+}
@@ -0,0 +1,168 @@
+//go:build linux
+
+// ABOUTME: Runs the hooks that "handle-hook" hands to the daemon, in worker processes.
+// ABOUTME: Hooks of one session run one at a time in arrival order; sessions run in parallel.
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hookWorkerTimeout bounds one worker, which may wait out webhook retries
+const hookWorkerTimeout = 5 * time.Minute
+
+// hookRunner runs the hooks handed to the daemon. Claude Code used to wait
+// for each hook, so the hooks of a session still run one at a time, in the
+// order they arrived.
+type hookRunner struct {
+	mu     sync.Mutex
+	queues map[string][]*HookRequest // Hooks per session; the first one is running
+	wg     *sync.WaitGroup           // Shutdown waits for the queues to drain
+
+	// run handles a hook and waits for it; start only starts it. Tests
+	// replace both.
+	run   func(*HookRequest) error
+	start func(*HookRequest) error
+}
+
+// newHookRunner creates a runner whose queues wg waits for
+func newHookRunner(wg *sync.WaitGroup) *hookRunner {
+	return &hookRunner{
+		queues: make(map[string][]*HookRequest),
+		wg:     wg,
+		run:    runHookWorker,
+		start:  startHookWorker,
+	}
+}
+
+// enqueue queues req behind the hooks of its session and returns how many
+// are ahead of it
+func (r *hookRunner) enqueue(req *HookRequest) int {
+	key := hookSession(req.Payload)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ahead := len(r.queues[key])
+	r.queues[key] = append(r.queues[key], req)
+	if ahead == 0 {
+		r.wg.Add(1)
+		go r.drain(key)
+	}
+	return ahead
+}
+
+// drain runs the hooks of a session until its queue is empty
+func (r *hookRunner) drain(key string) {
+	defer r.wg.Done()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for len(r.queues[key]) > 0 {
+		req := r.queues[key][0]
+		r.mu.Unlock()
+		started := time.Now()
+		if err := r.run(req); err != nil {
+			log.Printf("[ERROR] %s hook failed after %v: %v", req.Event, time.Since(started).Round(time.Millisecond), err)
+		}
+		r.mu.Lock()
+		r.queues[key] = r.queues[key][1:]
+	}
+	delete(r.queues, key)
+}
+
+// pending returns the number of hooks running or waiting
+func (r *hookRunner) pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, queue := range r.queues {
+		n += len(queue)
+	}
+	return n
+}
+
+// flush starts the hooks still waiting behind a running one without
+// waiting for them, so a daemon that has to exit loses none. It returns
+// how many it started.
+func (r *hookRunner) flush() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for key, queue := range r.queues {
+		for _, req := range queue[1:] {
+			if err := r.start(req); err != nil {
+				log.Printf("[ERROR] Failed to start %s hook: %v", req.Event, err)
+				continue
+			}
+			n++
+		}
+		r.queues[key] = queue[:1]
+	}
+	return n
+}
+
+// hookSession returns the session ID in a hook payload ("" = none)
+func hookSession(payload json.RawMessage) string {
+	var hook struct {
+		SessionID string `json:"session_id"`
+	}
+	_ = json.Unmarshal(payload, &hook)
+	return hook.SessionID
+}
+
+// hookCommand builds the worker process that handles req like the hook
+// would have: same directory, environment and input
+func hookCommand(ctx context.Context, req *HookRequest) (*exec.Cmd, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find executable: %w", err)
+	}
+	cmd := exec.CommandContext(ctx, exe, "handle-hook", "--sync", req.Event)
+	if info, err := os.Stat(req.Dir); err == nil && info.IsDir() {
+		cmd.Dir = req.Dir
+	}
+	cmd.Env = req.Env
+	cmd.Stdin = bytes.NewReader(req.Payload)
+	return cmd, nil
+}
+
+// runHookWorker handles req in a worker process and waits for it
+func runHookWorker(req *HookRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hookWorkerTimeout)
+	defer cancel()
+
+	cmd, err := hookCommand(ctx, req)
+	if err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// startHookWorker starts a worker process for req that outlives the daemon
+func startHookWorker(req *HookRequest) error {
+	cmd, err := hookCommand(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
@@ -0,0 +1,143 @@
+//go:build linux
+
+package daemon
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+// hookFor returns a hook request of event in session
+func hookFor(event, session string) *HookRequest {
+	payload, _ := json.Marshal(map[string]string{"session_id": session})
+	return &HookRequest{Event: event, Payload: payload}
+}
+
+func TestHookRunner_RunsSessionInOrder(t *testing.T) {
+	var wg sync.WaitGroup
+	r := newHookRunner(&wg)
+
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var ran []string
+	r.run = func(req *HookRequest) error {
+		if req.Event == "PreToolUse" {
+			<-release
+		}
+		mu.Lock()
+		ran = append(ran, req.Event)
+		mu.Unlock()
+		return nil
+	}
+
+	if ahead := r.enqueue(hookFor("PreToolUse", "a")); ahead != 0 {
+		t.Errorf("first hook ahead = %d, want 0", ahead)
+	}
+	if ahead := r.enqueue(hookFor("Stop", "a")); ahead != 1 {
+		t.Errorf("second hook ahead = %d, want 1", ahead)
+	}
+	if got := r.pending(); got != 2 {
+		t.Errorf("pending = %d, want 2", got)
+	}
+	close(release)
+	wg.Wait()
+
+	if len(ran) != 2 || ran[0] != "PreToolUse" || ran[1] != "Stop" {
+		t.Errorf("ran %v, want [PreToolUse Stop]", ran)
+	}
+	if got := r.pending(); got != 0 {
+		t.Errorf("pending after drain = %d, want 0", got)
+	}
+}
+
+func TestHookRunner_SessionsRunInParallel(t *testing.T) {
+	var wg sync.WaitGroup
+	r := newHookRunner(&wg)
+
+	blocked := make(chan struct{})
+	done := make(chan string, 1)
+	r.run = func(req *HookRequest) error {
+		if hookSession(req.Payload) == "a" {
+			<-blocked
+			return nil
+		}
+		done <- req.Event
+		return nil
+	}
+
+	r.enqueue(hookFor("Stop", "a"))
+	if ahead := r.enqueue(hookFor("Notification", "b")); ahead != 0 {
+		t.Errorf("other session ahead = %d, want 0", ahead)
+	}
+	select {
+	case event := <-done:
+		if event != "Notification" {
+			t.Errorf("ran %s, want Notification", event)
+		}
+	case <-time.After(time.Second):
+		t.Error("a hook of another session waited for a running one")
+	}
+	close(blocked)
+	wg.Wait()
+}
+
+func TestHookRunner_FlushStartsWaitingHooks(t *testing.T) {
+	var wg sync.WaitGroup
+	r := newHookRunner(&wg)
+
+	release := make(chan struct{})
+	r.run = func(req *HookRequest) error {
+		<-release
+		return nil
+	}
+	var started []string
+	r.start = func(req *HookRequest) error {
+		started = append(started, req.Event)
+		return nil
+	}
+
+	r.enqueue(hookFor("PreToolUse", "a"))
+	r.enqueue(hookFor("Notification", "a"))
+	r.enqueue(hookFor("Stop", "a"))
+	if n := r.flush(); n != 2 {
+		t.Errorf("flush() = %d, want 2", n)
+	}
+	if len(started) != 2 || started[0] != "Notification" || started[1] != "Stop" {
+		t.Errorf("started %v, want [Notification Stop]", started)
+	}
+	close(release)
+	wg.Wait()
+	if got := r.pending(); got != 0 {
+		t.Errorf("pending = %d, want 0", got)
+	}
+}
+
+func TestHandleConnection_Hook(t *testing.T) {
+	s := newTestServer(t)
+	ran := make(chan *HookRequest, 1)
+	s.hooks.run = func(req *HookRequest) error {
+		ran <- req
+		return nil
+	}
+
+	req := hookFor("Stop", "abc")
+	req.Dir = "/home/me/api"
+	resp := roundTrip(t, s, Request{Type: MessageTypeHook, Version: ProtocolVersion, Hook: req})
+	if resp.Error != "" || resp.Hook == nil || resp.Hook.Queued != 0 {
+		t.Fatalf("response = %+v, want the hook accepted", resp)
+	}
+	select {
+	case got := <-ran:
+		if got.Event != "Stop" || got.Dir != "/home/me/api" || hookSession(got.Payload) != "abc" {
+			t.Errorf("ran %+v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("hook did not run")
+	}
+
+	if resp := roundTrip(t, s, Request{Type: MessageTypeHook, Version: ProtocolVersion}); resp.Error == "" {
+		t.Error("a hook request without payload should fail")
+	}
+}
@@ -0,0 +1,81 @@
+//go:build linux
+
+// ABOUTME: Single-instance enforcement: an flock on the lock file is held while the daemon runs.
+// ABOUTME: The kernel drops the lock when the daemon dies, so a crashed daemon's socket is detected as stale.
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrAlreadyRunning is returned by Run when another daemon holds the lock
+// or serves the socket
+var ErrAlreadyRunning = errors.New("notification daemon is already running")
+
+// acquireLock takes the exclusive lock on lockPath for the lifetime of
+// the returned file. It fails with ErrAlreadyRunning, naming the PID from
+// pidPath, when another daemon holds it.
+func acquireLock(lockPath, pidPath string) (*os.File, error) {
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			if pid := readPidFile(pidPath); pid > 0 {
+				return nil, fmt.Errorf("%w (pid %d)", ErrAlreadyRunning, pid)
+			}
+			return nil, ErrAlreadyRunning
+		}
+		return nil, fmt.Errorf("failed to lock %s: %w", lockPath, err)
+	}
+	return f, nil
+}
+
+// removeStaleSocket removes a socket file left behind by a daemon that
+// died without cleaning up. A socket that still accepts connections
+// belongs to a running daemon and is left alone. It reports whether a
+// stale socket was removed.
+func removeStaleSocket(socketPath string) (bool, error) {
+	info, err := os.Lstat(socketPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check socket: %w", err)
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return false, fmt.Errorf("%s exists and is not a socket", socketPath)
+	}
+
+	conn, err := net.DialTimeout("unix", socketPath, time.Second)
+	if err == nil {
+		conn.Close()
+		return false, fmt.Errorf("%w (listening on %s)", ErrAlreadyRunning, socketPath)
+	}
+	if err := os.Remove(socketPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+	return true, nil
+}
+
+// readPidFile returns the PID in path, or 0
+func readPidFile(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0
+	}
+	return pid
+}
@@ -0,0 +1,123 @@
+//go:build linux
+
+package daemon
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAcquireLock(t *testing.T) {
+	dir := t.TempDir()
+	lockPath := filepath.Join(dir, "daemon.lock")
+	pidPath := filepath.Join(dir, "daemon.pid")
+	if err := os.WriteFile(pidPath, []byte("4242\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := acquireLock(lockPath, pidPath)
+	if err != nil {
+		t.Fatalf("first acquireLock() error = %v", err)
+	}
+
+	_, err = acquireLock(lockPath, pidPath)
+	if !errors.Is(err, ErrAlreadyRunning) {
+		t.Fatalf("second acquireLock() error = %v, want ErrAlreadyRunning", err)
+	}
+	if !strings.Contains(err.Error(), "pid 4242") {
+		t.Errorf("error %q should name the running daemon's PID", err)
+	}
+
+	// Closing the holder (or its process dying) releases the lock
+	first.Close()
+	again, err := acquireLock(lockPath, pidPath)
+	if err != nil {
+		t.Fatalf("acquireLock() after release error = %v", err)
+	}
+	again.Close()
+}
+
+func TestRemoveStaleSocket(t *testing.T) {
+	dir := t.TempDir()
+	socketPath := filepath.Join(dir, "daemon.sock")
+
+	// No socket: nothing to do
+	removed, err := removeStaleSocket(socketPath)
+	if err != nil || removed {
+		t.Fatalf("removeStaleSocket(missing) = %v, %v", removed, err)
+	}
+
+	// A socket nobody listens on, as left by a crashed daemon
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+
+	removed, err = removeStaleSocket(socketPath)
+	if err != nil || !removed {
+		t.Fatalf("removeStaleSocket(stale) = %v, %v, want removed", removed, err)
+	}
+	if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+		t.Errorf("stale socket still exists, stat error = %v", err)
+	}
+}
+
+func TestRemoveStaleSocketLive(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "daemon.sock")
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	removed, err := removeStaleSocket(socketPath)
+	if !errors.Is(err, ErrAlreadyRunning) || removed {
+		t.Fatalf("removeStaleSocket(live) = %v, %v, want ErrAlreadyRunning", removed, err)
+	}
+	if _, err := os.Stat(socketPath); err != nil {
+		t.Errorf("live socket removed: %v", err)
+	}
+}
+
+func TestRemoveStaleSocketNotSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.sock")
+	if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := removeStaleSocket(path); err == nil {
+		t.Error("removeStaleSocket(regular file) should fail")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("regular file removed: %v", err)
+	}
+}
+
+func TestReadPidFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		content string
+		want    int
+	}{
+		{"123\n", 123},
+		{"garbage", 0},
+		{"-5", 0},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, "pid")
+		if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if got := readPidFile(path); got != tt.want {
+			t.Errorf("readPidFile(%q) = %d, want %d", tt.content, got, tt.want)
+		}
+	}
+	if got := readPidFile(filepath.Join(dir, "missing")); got != 0 {
+		t.Errorf("readPidFile(missing) = %d, want 0", got)
+	}
+}
@@ -0,0 +1,154 @@
+//go:build linux
+
+// ABOUTME: KDE Plasma focus through KWin's scripting D-Bus API, without kdotool.
+// ABOUTME: Loads a short KWin script that activates the window and reports back over D-Bus.
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	// kwinScriptTimeout bounds loading, running and hearing back from a script
+	kwinScriptTimeout = 2 * time.Second
+	// kwinReplyPath and kwinReplyInterface receive the script's result
+	kwinReplyPath      = dbus.ObjectPath("/com/github/claude_notifications/KWinFocus")
+	kwinReplyInterface = "com.github.claude_notifications.KWinFocus"
+)
+
+// kwinFocusScript finds a window by resourceClass, preferring one whose
+// caption contains the title, then any window whose caption contains the
+// fallback, and activates it. It reports the caption of the window
+// activated ("" = none) by calling Done on the reply object. Plasma 6
+// renamed clientList/activeClient to windowList/activeWindow.
+const kwinFocusScript = `(function() {
+	const classes = %s;
+	const title = %s;
+	const fallback = %s;
+	const windows = workspace.windowList ? workspace.windowList() : workspace.clientList();
+	const normal = windows.filter(w => w.normalWindow);
+	const ofClass = normal.filter(w => classes.indexOf(String(w.resourceClass).toLowerCase()) !== -1);
+	let win = (title && ofClass.find(w => String(w.caption).indexOf(title) !== -1)) || ofClass[0] ||
+		(fallback && normal.find(w => String(w.caption).indexOf(fallback) !== -1));
+	if (win) {
+		if (win.minimized) win.minimized = false;
+		if (workspace.windowList) workspace.activeWindow = win; else workspace.activeClient = win;
+	}
+	callDBus(%s, %s, %s, "Done", win ? String(win.caption) : "");
+})();
+`
+
+// kwinReply receives the result of a focus script
+type kwinReply chan string
+
+// Done is called by the script with the caption of the window it activated
+func (r kwinReply) Done(caption string) *dbus.Error {
+	select {
+	case r <- caption:
+	default:
+	}
+	return nil
+}
+
+// TryKWinScript focuses a window on KDE Plasma by loading a KWin script
+// over org.kde.KWin /Scripting. Works on Plasma 5 and 6 without kdotool.
+func TryKWinScript(ctx context.Context, terminalName, folderName string) error {
+	if !strings.Contains(strings.ToUpper(os.Getenv("XDG_CURRENT_DESKTOP")), "KDE") {
+		return fmt.Errorf("not running under KDE Plasma")
+	}
+	ctx, cancel := context.WithTimeout(ctx, kwinScriptTimeout)
+	defer cancel()
+
+	// A private connection, so the reply object goes away with it
+	conn, err := dbus.ConnectSessionBus(dbus.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to connect to D-Bus session bus: %w", err)
+	}
+	defer conn.Close()
+
+	reply := make(kwinReply, 1)
+	if err := conn.Export(reply, kwinReplyPath, kwinReplyInterface); err != nil {
+		return fmt.Errorf("failed to export KWin reply object: %w", err)
+	}
+
+	classes := []string{strings.ToLower(GetKdotoolClass(terminalName)), strings.ToLower(GetDesktopEntryID(terminalName))}
+	script := kwinScriptSource(classes, folderName, GetSearchTermWithFolder(terminalName, folderName), conn.Names()[0])
+	caption, err := runKWinScript(ctx, conn, script, reply)
+	if err != nil {
+		return err
+	}
+	if caption == "" {
+		return fmt.Errorf("no %s window found by KWin script", terminalName)
+	}
+	return nil
+}
+
+// kwinScriptSource returns the focus script, reporting to the bus name replyTo
+func kwinScriptSource(classes []string, title, fallback, replyTo string) string {
+	quote := func(v interface{}) string {
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+	return fmt.Sprintf(kwinFocusScript, quote(classes), quote(title), quote(fallback),
+		quote(replyTo), quote(string(kwinReplyPath)), quote(kwinReplyInterface))
+}
+
+// runKWinScript loads script into KWin, runs it and returns what it
+// reported on reply, then unloads it. KWin reads scripts from files, and
+// only once running, so the temporary file is kept until the reply.
+func runKWinScript(ctx context.Context, conn *dbus.Conn, script string, reply kwinReply) (string, error) {
+	file, err := os.CreateTemp("", "claude-notifications-kwin-*.js")
+	if err != nil {
+		return "", fmt.Errorf("failed to create KWin script: %w", err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(script); err != nil {
+		file.Close()
+		return "", fmt.Errorf("failed to write KWin script: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to write KWin script: %w", err)
+	}
+
+	plugin := strings.TrimSuffix(filepath.Base(file.Name()), ".js")
+	scripting := conn.Object("org.kde.KWin", "/Scripting")
+	var id int32
+	if err := scripting.CallWithContext(ctx, "org.kde.kwin.Scripting.loadScript", 0, file.Name(), plugin).Store(&id); err != nil {
+		return "", fmt.Errorf("KWin loadScript failed: %w", err)
+	}
+	defer func() {
+		// Unload even when ctx ended, so scripts do not pile up in KWin
+		unloadCtx, cancel := context.WithTimeout(context.Background(), kwinScriptTimeout)
+		defer cancel()
+		scripting.CallWithContext(unloadCtx, "org.kde.kwin.Scripting.unloadScript", 0, plugin)
+	}()
+	if id < 0 {
+		return "", fmt.Errorf("KWin refused to load the focus script")
+	}
+
+	// Plasma 6 registers scripts under /Scripting, Plasma 5 at the root
+	var runErr error
+	for _, path := range []string{fmt.Sprintf("/Scripting/Script%d", id), fmt.Sprintf("/%d", id)} {
+		if runErr = conn.Object("org.kde.KWin", dbus.ObjectPath(path)).CallWithContext(ctx, "org.kde.kwin.Script.run", 0).Err; runErr == nil {
+			break
+		}
+	}
+	if runErr != nil {
+		return "", fmt.Errorf("KWin script run failed: %w", runErr)
+	}
+
+	select {
+	case caption := <-reply:
+		return caption, nil
+	case <-ctx.Done():
+		return "", fmt.Errorf("KWin script did not report back: %w", ctx.Err())
+	}
+}
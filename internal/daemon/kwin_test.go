@@ -0,0 +1,47 @@
+//go:build linux
+
+package daemon
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestTryKWinScript_NotKDE(t *testing.T) {
+	t.Setenv("XDG_CURRENT_DESKTOP", "GNOME")
+
+	err := TryKWinScript(context.Background(), "konsole", "api")
+	if err == nil || !strings.Contains(err.Error(), "not running under KDE") {
+		t.Errorf("TryKWinScript() error = %v, want not running under KDE", err)
+	}
+}
+
+func TestKWinScriptSource(t *testing.T) {
+	script := kwinScriptSource([]string{"konsole"}, `my "api"`, "Konsole", ":1.42")
+
+	for _, want := range []string{
+		`const classes = ["konsole"];`,
+		`const title = "my \"api\"";`,
+		`const fallback = "Konsole";`,
+		`callDBus(":1.42", "/com/github/claude_notifications/KWinFocus", "com.github.claude_notifications.KWinFocus", "Done"`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+}
+
+func TestKWinReply_Done(t *testing.T) {
+	reply := make(kwinReply, 1)
+	if err := reply.Done("api - Konsole"); err != nil {
+		t.Fatalf("Done() error = %v", err)
+	}
+	// A second report must not block the D-Bus dispatcher
+	if err := reply.Done("other"); err != nil {
+		t.Fatalf("Done() error = %v", err)
+	}
+	if got := <-reply; got != "api - Konsole" {
+		t.Errorf("reply = %q", got)
+	}
+}
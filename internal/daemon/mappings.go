@@ -2,6 +2,7 @@ package daemon
 
 import (
 	"os"
+	"regexp"
 	"strings"
 )
 
@@ -21,8 +22,17 @@ func escapeJS(s string) string {
 	return r.Replace(s)
 }
 
+// escapeAppleScript escapes a string for interpolation into an AppleScript
+// double-quoted string literal.
+func escapeAppleScript(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
 // GetAppID returns the .desktop app ID for a terminal name.
 func GetAppID(terminalName string) string {
+	if t, ok := lookupTerminal(terminalName); ok && t.DesktopEntry != "" {
+		return strings.TrimSuffix(t.DesktopEntry, ".desktop") + ".desktop"
+	}
 	switch strings.ToLower(terminalName) {
 	case "code", "vscode", "visual studio code":
 		return "code.desktop"
@@ -40,7 +50,20 @@ func GetAppID(terminalName string) string {
 		return "com.gexperts.Tilix.desktop"
 	case "terminator":
 		return "terminator.desktop"
+	case "ghostty":
+		return "com.mitchellh.ghostty.desktop"
+	case "foot":
+		return "org.codeberg.dnkl.foot.desktop"
+	case "warpterminal", "warp":
+		return "dev.warp.Warp.desktop"
+	case "vscodium":
+		return "codium.desktop"
+	case "zed":
+		return "dev.zed.Zed.desktop"
 	default:
+		if class := jetbrainsClass(terminalName); class != "" {
+			return class + ".desktop"
+		}
 		return strings.ToLower(terminalName) + ".desktop"
 	}
 }
@@ -53,6 +76,9 @@ func GetDesktopEntryID(terminalName string) string {
 
 // GetWlrctlAppID returns the wlroots app_id for a terminal name.
 func GetWlrctlAppID(terminalName string) string {
+	if t, ok := lookupTerminal(terminalName); ok && t.AppID != "" {
+		return t.AppID
+	}
 	switch strings.ToLower(terminalName) {
 	case "code", "vscode", "visual studio code":
 		return "code"
@@ -66,13 +92,73 @@ func GetWlrctlAppID(terminalName string) string {
 		return "org.gnome.Terminal"
 	case "konsole":
 		return "org.kde.konsole"
+	case "ghostty":
+		return "com.mitchellh.ghostty"
+	case "warpterminal", "warp":
+		return "dev.warp.Warp"
+	case "vscodium":
+		return "codium"
+	case "zed":
+		return "dev.zed.Zed"
 	default:
+		if class := jetbrainsClass(terminalName); class != "" {
+			return class
+		}
 		return strings.ToLower(terminalName)
 	}
 }
 
+// GetMacBundleID returns the macOS bundle identifier for a terminal.
+// Accepts TERM_PROGRAM values or a bundle ID, which is returned unchanged.
+// Returns "" for unknown terminals.
+func GetMacBundleID(terminalName string) string {
+	if t, ok := lookupTerminal(terminalName); ok && t.BundleID != "" {
+		return t.BundleID
+	}
+	switch strings.ToLower(terminalName) {
+	case "code", "vscode", "visual studio code":
+		return "com.microsoft.VSCode"
+	case "apple_terminal", "terminal":
+		return "com.apple.Terminal"
+	case "iterm.app", "iterm", "iterm2":
+		return "com.googlecode.iterm2"
+	case "warpterminal", "warp":
+		return "dev.warp.Warp-Stable"
+	case "kitty":
+		return "net.kovidgoyal.kitty"
+	case "ghostty":
+		return "com.mitchellh.ghostty"
+	case "wezterm":
+		return "com.github.wez.wezterm"
+	case "alacritty":
+		return "org.alacritty"
+	case "hyper":
+		return "co.zeit.hyper"
+	case "vscodium":
+		return "com.vscodium"
+	case "cursor":
+		return "com.todesktop.230313mzl4w4u92"
+	case "zed":
+		return "dev.zed.Zed"
+	}
+	if bundleID, ok := jetbrainsIDEs[strings.ToLower(terminalName)]; ok {
+		return bundleID
+	}
+	if strings.Count(terminalName, ".") >= 2 {
+		return terminalName
+	}
+	return ""
+}
+
 // GetKdotoolClass returns the window class for kdotool search.
+// KWin's resourceClass is the app_id of Wayland windows.
 func GetKdotoolClass(terminalName string) string {
+	if t, ok := lookupTerminal(terminalName); ok && (t.AppID != "" || t.WindowClass != "") {
+		if t.AppID != "" {
+			return t.AppID
+		}
+		return t.WindowClass
+	}
 	switch strings.ToLower(terminalName) {
 	case "code", "vscode", "visual studio code":
 		return "code"
@@ -86,13 +172,27 @@ func GetKdotoolClass(terminalName string) string {
 		return "gnome-terminal-server"
 	case "konsole":
 		return "konsole"
+	case "ghostty":
+		return "com.mitchellh.ghostty"
+	case "warpterminal", "warp":
+		return "dev.warp.Warp"
+	case "vscodium":
+		return "codium"
+	case "zed":
+		return "dev.zed.Zed"
 	default:
+		if class := jetbrainsClass(terminalName); class != "" {
+			return class
+		}
 		return strings.ToLower(terminalName)
 	}
 }
 
 // GetXdotoolClass returns the X11 WM_CLASS for xdotool search.
 func GetXdotoolClass(terminalName string) string {
+	if t, ok := lookupTerminal(terminalName); ok && t.WindowClass != "" {
+		return t.WindowClass
+	}
 	switch strings.ToLower(terminalName) {
 	case "code", "vscode", "visual studio code":
 		return "Code"
@@ -114,13 +214,153 @@ func GetXdotoolClass(terminalName string) string {
 		return "Tilix"
 	case "terminator":
 		return "Terminator"
+	case "xterm":
+		return "XTerm"
+	case "urxvt", "rxvt-unicode":
+		return "URxvt"
+	case "st", "st-256color":
+		return "st-256color"
+	case "lxterminal":
+		return "Lxterminal"
+	case "qterminal":
+		return "qterminal"
+	case "ghostty":
+		return "com.mitchellh.ghostty"
+	case "warpterminal", "warp":
+		return "dev.warp.Warp"
+	case "vscodium":
+		return "VSCodium"
+	case "zed":
+		return "dev.zed.Zed"
 	default:
+		if class := jetbrainsClass(terminalName); class != "" {
+			return class
+		}
 		return terminalName
 	}
 }
 
+// WMClassPattern returns an anchored regular expression matching exactly the given
+// WM_CLASS. xdotool treats --class as an (case-insensitive) regex, so an unanchored
+// "Code" would also match "VSCodium" or "Xcode-helper".
+func WMClassPattern(class string) string {
+	return "^" + regexp.QuoteMeta(class) + "$"
+}
+
+// HyprlandFocusSelectors returns hyprctl focuswindow selectors to try in order.
+// Hyprland's window class is the Wayland app_id. For VS Code with a known project
+// folder, the folder title is tried first because all VS Code windows share a class.
+func HyprlandFocusSelectors(terminalName, folderName string) []string {
+	var selectors []string
+	searchTerm := GetSearchTerm(terminalName)
+	if folderTerm := GetSearchTermWithFolder(terminalName, folderName); folderTerm != searchTerm {
+		selectors = append(selectors, "title:"+regexp.QuoteMeta(folderTerm))
+	}
+	selectors = append(selectors,
+		"class:^("+regexp.QuoteMeta(GetWlrctlAppID(terminalName))+")$",
+		"title:"+regexp.QuoteMeta(searchTerm),
+	)
+	return selectors
+}
+
+// swayCriterion formats a Sway criteria value as a quoted regular expression.
+func swayCriterion(key, pattern string) string {
+	return key + `="` + strings.ReplaceAll(pattern, `"`, `\"`) + `"`
+}
+
+// SwayFocusCommands returns Sway IPC commands to try in order.
+// Native Wayland windows are matched by app_id, XWayland windows by X11 class.
+// For VS Code with a known project folder, a window of that app_id whose title
+// contains the folder is tried first.
+func SwayFocusCommands(terminalName, folderName string) []string {
+	appID := swayCriterion("app_id", "^"+regexp.QuoteMeta(GetWlrctlAppID(terminalName))+"$")
+	searchTerm := GetSearchTerm(terminalName)
+
+	var commands []string
+	if folderTerm := GetSearchTermWithFolder(terminalName, folderName); folderTerm != searchTerm {
+		commands = append(commands, "["+appID+" "+swayCriterion("title", regexp.QuoteMeta(folderTerm))+"] focus")
+	}
+	commands = append(commands,
+		"["+appID+"] focus",
+		"["+swayCriterion("class", WMClassPattern(GetXdotoolClass(terminalName)))+"] focus",
+		"["+swayCriterion("title", regexp.QuoteMeta(searchTerm))+"] focus",
+	)
+	return commands
+}
+
+// windowMatchesTerminal reports whether a window's WM_CLASS or Wayland
+// app_id is one the terminal is known by. Unknown terminals match nothing.
+func windowMatchesTerminal(class, terminalName string) bool {
+	if class == "" || terminalName == "" || terminalName == "Terminal" {
+		return false
+	}
+	for _, known := range []string{
+		GetWlrctlAppID(terminalName),
+		GetXdotoolClass(terminalName),
+		GetKdotoolClass(terminalName),
+		GetDesktopEntryID(terminalName),
+	} {
+		if strings.EqualFold(class, known) {
+			return true
+		}
+	}
+	return false
+}
+
+// toplevel is a window as listed by a compositor
+type toplevel struct {
+	id    string
+	appID string
+	title string
+}
+
+// pickToplevel returns the terminal's window among windows, preferring one
+// whose title contains folderName, else a window whose title contains the
+// search term (nil = none).
+func pickToplevel(windows []toplevel, terminalName, folderName string) *toplevel {
+	var first *toplevel
+	for i := range windows {
+		w := &windows[i]
+		if !windowMatchesTerminal(w.appID, terminalName) {
+			continue
+		}
+		if folderName != "" && strings.Contains(w.title, folderName) {
+			return w
+		}
+		if first == nil {
+			first = w
+		}
+	}
+	if first != nil {
+		return first
+	}
+
+	searchTerm := GetSearchTermWithFolder(terminalName, folderName)
+	for i := range windows {
+		if strings.Contains(windows[i].title, searchTerm) {
+			return &windows[i]
+		}
+	}
+	return nil
+}
+
+// ParseWindowIDs splits newline-separated window IDs (xdotool/kdotool search output),
+// dropping blank lines.
+func ParseWindowIDs(output string) []string {
+	var ids []string
+	for _, line := range strings.Split(output, "\n") {
+		if id := strings.TrimSpace(line); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
 // GetSearchTerm returns a window title search term for a terminal name.
 func GetSearchTerm(terminalName string) string {
+	if t, ok := lookupTerminal(terminalName); ok && t.Title != "" {
+		return t.Title
+	}
 	switch strings.ToLower(terminalName) {
 	case "code", "vscode", "visual studio code":
 		return "Visual Studio Code"
@@ -132,21 +372,66 @@ func GetSearchTerm(terminalName string) string {
 }
 
 // GetSearchTermWithFolder returns the window title search term, using the project
-// folder name for VS Code when available (more specific than "Visual Studio Code").
+// folder name for editors when available. Their titles name the project but not
+// always the app: "file — folder — Visual Studio Code" (also Cursor, VSCodium),
+// "folder — file" (Zed) and "project – file" (JetBrains IDEs).
 func GetSearchTermWithFolder(terminalName, folderName string) string {
-	switch strings.ToLower(terminalName) {
-	case "code", "vscode", "visual studio code":
-		if folderName != "" {
-			return folderName
-		}
+	if folderName != "" && isEditor(terminalName) {
+		return folderName
 	}
 	return GetSearchTerm(terminalName)
 }
 
-// GetTerminalName detects the current terminal from environment variables.
+// isEditor reports whether terminalName is an IDE or editor with a built-in
+// terminal, whose window title names the project
+func isEditor(terminalName string) bool {
+	switch strings.ToLower(terminalName) {
+	case "code", "vscode", "visual studio code", "vscodium", "cursor", "zed":
+		return true
+	}
+	return jetbrainsClass(terminalName) != ""
+}
+
+// terminalEnvMarkers are environment variables only one terminal sets
+var terminalEnvMarkers = []struct {
+	env, terminal string
+}{
+	{"WEZTERM_PANE", "WezTerm"},
+	{"WEZTERM_EXECUTABLE", "WezTerm"},
+	{"KITTY_WINDOW_ID", "kitty"},
+	{"GHOSTTY_RESOURCES_DIR", "ghostty"},
+	{"GHOSTTY_BIN_DIR", "ghostty"},
+	{"ALACRITTY_WINDOW_ID", "Alacritty"},
+	{"ALACRITTY_SOCKET", "Alacritty"},
+	{"ALACRITTY_LOG", "Alacritty"},
+}
+
+// jetbrainsClass returns the Linux window class of a JetBrains IDE
+// ("jetbrains-goland"), or "" for other terminals
+func jetbrainsClass(terminalName string) string {
+	name := strings.ToLower(terminalName)
+	if _, ok := jetbrainsIDEs[name]; ok {
+		return "jetbrains-" + name
+	}
+	return ""
+}
+
+// GetTerminalName detects the current terminal from environment variables,
+// then from the parent process tree.
 func GetTerminalName() string {
+	// Terminals declared in the config with a detect variable win
+	if name := detectCustomTerminal(); name != "" {
+		return name
+	}
+
 	// Try TERM_PROGRAM first (set by many terminals)
 	if termProg := os.Getenv("TERM_PROGRAM"); termProg != "" {
+		// Cursor and other VS Code forks keep TERM_PROGRAM=vscode
+		if strings.EqualFold(termProg, "vscode") {
+			if terminal := terminalFromProcessTree(); terminal != "" {
+				return terminal
+			}
+		}
 		return termProg
 	}
 
@@ -155,11 +440,22 @@ func GetTerminalName() string {
 		return "Code"
 	}
 
+	for _, marker := range terminalEnvMarkers {
+		if os.Getenv(marker.env) != "" {
+			return marker.terminal
+		}
+	}
+
 	// Check GNOME Terminal indicators
 	if os.Getenv("GNOME_TERMINAL_SCREEN") != "" || os.Getenv("GNOME_TERMINAL_SERVICE") != "" {
 		return "gnome-terminal"
 	}
 
+	// Walk up to the terminal emulator or IDE that runs us (JetBrains, Zed, ...)
+	if terminal := terminalFromProcessTree(); terminal != "" {
+		return terminal
+	}
+
 	// Fallback to generic terminal
 	return "Terminal"
 }
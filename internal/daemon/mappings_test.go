@@ -2,6 +2,7 @@ package daemon
 
 import (
 	"os"
+	"regexp"
 	"strings"
 	"testing"
 )
@@ -10,6 +11,9 @@ import (
 func saveTerminalEnv(t *testing.T) func() {
 	t.Helper()
 	vars := []string{"TERM_PROGRAM", "VSCODE_INJECTION", "VSCODE_GIT_IPC_HANDLE", "GNOME_TERMINAL_SCREEN", "GNOME_TERMINAL_SERVICE"}
+	for _, marker := range terminalEnvMarkers {
+		vars = append(vars, marker.env)
+	}
 	type envState struct {
 		value string
 		isSet bool
@@ -23,6 +27,10 @@ func saveTerminalEnv(t *testing.T) func() {
 	for _, v := range vars {
 		os.Unsetenv(v)
 	}
+	// Keep the terminal running the tests out of detection
+	savedProcessInfo := processInfo
+	processInfo = fakeProcessTable(nil)
+	t.Cleanup(func() { processInfo = savedProcessInfo })
 	return func() {
 		for _, v := range vars {
 			if saved[v].isSet {
@@ -321,6 +329,9 @@ func TestGetXdotoolClass_X11Terminals(t *testing.T) {
 		"mate-terminal":  "Mate-terminal",
 		"tilix":          "Tilix",
 		"terminator":     "Terminator",
+		"xterm":          "XTerm",
+		"urxvt":          "URxvt",
+		"st":             "st-256color",
 	}
 
 	for input, expected := range tests {
@@ -338,6 +349,167 @@ func TestGetXdotoolClass_UnknownFallback(t *testing.T) {
 	}
 }
 
+// --- WMClassPattern tests ---
+
+func TestWMClassPattern(t *testing.T) {
+	tests := map[string]string{
+		"Code":                   "^Code$",
+		"org.wezfurlong.wezterm": `^org\.wezfurlong\.wezterm$`,
+		"st-256color":            "^st-256color$",
+		"weird(term)":            `^weird\(term\)$`,
+	}
+
+	for input, expected := range tests {
+		if got := WMClassPattern(input); got != expected {
+			t.Errorf("WMClassPattern(%q) = %q, want %q", input, got, expected)
+		}
+	}
+}
+
+func TestWMClassPattern_ExactMatchOnly(t *testing.T) {
+	re := regexp.MustCompile("(?i)" + WMClassPattern("Code"))
+
+	if !re.MatchString("code") {
+		t.Error("pattern should match the class case-insensitively (as xdotool does)")
+	}
+	for _, other := range []string{"VSCodium", "Xcode-helper", "Code - OSS"} {
+		if re.MatchString(other) {
+			t.Errorf("pattern for Code should not match %q", other)
+		}
+	}
+}
+
+// --- HyprlandFocusSelectors tests ---
+
+func TestHyprlandFocusSelectors_Terminal(t *testing.T) {
+	got := HyprlandFocusSelectors("kitty", "project")
+	want := []string{"class:^(kitty)$", "title:kitty"}
+
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("HyprlandFocusSelectors(kitty) = %v, want %v", got, want)
+	}
+}
+
+func TestHyprlandFocusSelectors_VSCodeWithFolder(t *testing.T) {
+	got := HyprlandFocusSelectors("vscode", "my.app")
+	want := []string{`title:my\.app`, "class:^(code)$", "title:Visual Studio Code"}
+
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("HyprlandFocusSelectors(vscode, my.app) = %v, want %v", got, want)
+	}
+}
+
+func TestHyprlandFocusSelectors_EscapesClass(t *testing.T) {
+	got := HyprlandFocusSelectors("wezterm", "")
+	if got[0] != `class:^(org\.wezfurlong\.wezterm)$` {
+		t.Errorf("class selector = %q, want escaped app_id", got[0])
+	}
+}
+
+// --- SwayFocusCommands tests ---
+
+func TestSwayFocusCommands_Terminal(t *testing.T) {
+	got := SwayFocusCommands("alacritty", "project")
+	want := []string{
+		`[app_id="^Alacritty$"] focus`,
+		`[class="^Alacritty$"] focus`,
+		`[title="alacritty"] focus`,
+	}
+
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("SwayFocusCommands(alacritty) = %v, want %v", got, want)
+	}
+}
+
+func TestSwayFocusCommands_VSCodeWithFolder(t *testing.T) {
+	got := SwayFocusCommands("vscode", "my.app")
+	want := `[app_id="^code$" title="my\.app"] focus`
+
+	if len(got) != 4 || got[0] != want {
+		t.Errorf("SwayFocusCommands(vscode, my.app) = %v, want first %q", got, want)
+	}
+}
+
+func TestSwayFocusCommands_EscapesQuotes(t *testing.T) {
+	got := SwayFocusCommands("vscode", `say "hi"`)
+	if !strings.Contains(got[0], `title="say \"hi\""`) {
+		t.Errorf("folder quotes not escaped: %q", got[0])
+	}
+}
+
+// --- escapeAppleScript tests ---
+
+func TestEscapeAppleScript(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"my-project", "my-project"},
+		{`say "hi"`, `say \"hi\"`},
+		{`C:\dir`, `C:\\dir`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeAppleScript(tt.input); got != tt.want {
+			t.Errorf("escapeAppleScript(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+// --- GetMacBundleID tests ---
+
+func TestGetMacBundleID(t *testing.T) {
+	tests := []struct {
+		terminal string
+		want     string
+	}{
+		{"vscode", "com.microsoft.VSCode"},
+		{"Apple_Terminal", "com.apple.Terminal"},
+		{"iTerm.app", "com.googlecode.iterm2"},
+		{"WarpTerminal", "dev.warp.Warp-Stable"},
+		{"ghostty", "com.mitchellh.ghostty"},
+		{"WezTerm", "com.github.wez.wezterm"},
+		{"com.example.CustomTerm", "com.example.CustomTerm"},
+		{"unknown-terminal", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := GetMacBundleID(tt.terminal); got != tt.want {
+			t.Errorf("GetMacBundleID(%q) = %q, want %q", tt.terminal, got, tt.want)
+		}
+	}
+}
+
+// --- ParseWindowIDs tests ---
+
+func TestParseWindowIDs(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{"single", "62914561\n", []string{"62914561"}},
+		{"multiple", "62914561\n71303170\n", []string{"62914561", "71303170"}},
+		{"blank lines", "\n  62914561  \n\n", []string{"62914561"}},
+		{"empty", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseWindowIDs(tt.output)
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseWindowIDs(%q) = %v, want %v", tt.output, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ParseWindowIDs(%q)[%d] = %q, want %q", tt.output, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
 // --- GetSearchTerm tests ---
 
 func TestGetSearchTerm_VSCode(t *testing.T) {
@@ -509,3 +681,139 @@ func TestMappingConsistency_CommonTerminals(t *testing.T) {
 		}
 	}
 }
+
+func TestWindowMatchesTerminal(t *testing.T) {
+	tests := []struct {
+		class, terminal string
+		want            bool
+	}{
+		{"Code", "vscode", true},
+		{"code", "vscode", true},
+		{"kitty", "kitty", true},
+		{"org.gnome.Terminal", "gnome-terminal", true},
+		{"Gnome-terminal", "gnome-terminal", true},
+		{"gnome-terminal-server", "gnome-terminal", true},
+		{"firefox", "vscode", false},
+		{"VSCodium", "vscode", false},
+		{"", "kitty", false},
+		{"Terminal", "Terminal", false}, // Unknown terminal: nothing to check against
+	}
+	for _, tt := range tests {
+		if got := windowMatchesTerminal(tt.class, tt.terminal); got != tt.want {
+			t.Errorf("windowMatchesTerminal(%q, %q) = %v, want %v", tt.class, tt.terminal, got, tt.want)
+		}
+	}
+}
+
+func TestPickToplevel(t *testing.T) {
+	windows := []toplevel{
+		{id: "1", appID: "firefox", title: "api - Mozilla Firefox"},
+		{id: "2", appID: "code", title: "web - Visual Studio Code"},
+		{id: "3", appID: "code", title: "api - Visual Studio Code"},
+		{id: "4", appID: "org.wezfurlong.wezterm", title: "zsh"},
+		{id: "5", appID: "", title: "MyTerm shell"},
+	}
+
+	tests := []struct {
+		terminal, folder, want string
+	}{
+		{"Code", "api", "3"},     // Folder in the title wins
+		{"Code", "", "2"},        // First window of the terminal
+		{"Code", "missing", "2"}, // Folder not shown anywhere
+		{"WezTerm", "api", "4"},  // Matched by app_id only
+		{"MyTerm", "", "5"},      // Unknown terminal: by title
+		{"kitty", "", ""},        // No window
+	}
+	for _, tt := range tests {
+		got := ""
+		if w := pickToplevel(windows, tt.terminal, tt.folder); w != nil {
+			got = w.id
+		}
+		if got != tt.want {
+			t.Errorf("pickToplevel(%s, %q) = %q, want %q", tt.terminal, tt.folder, got, tt.want)
+		}
+	}
+}
+
+func TestGetTerminalName_EnvMarkers(t *testing.T) {
+	tests := []struct {
+		env, want string
+	}{
+		{"WEZTERM_PANE", "WezTerm"},
+		{"KITTY_WINDOW_ID", "kitty"},
+		{"GHOSTTY_RESOURCES_DIR", "ghostty"},
+		{"ALACRITTY_SOCKET", "Alacritty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			restore := saveTerminalEnv(t)
+			defer restore()
+			t.Setenv(tt.env, "1")
+
+			if got := GetTerminalName(); got != tt.want {
+				t.Errorf("GetTerminalName() with %s = %q, want %q", tt.env, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetTerminalName_ProcessTree(t *testing.T) {
+	restore := saveTerminalEnv(t)
+	defer restore()
+	setProcessAncestors(t, "claude", "zsh", "zed-editor")
+
+	if got := GetTerminalName(); got != "Zed" {
+		t.Errorf("GetTerminalName() under Zed = %q, want %q", got, "Zed")
+	}
+}
+
+func TestGetTerminalName_VSCodeFork(t *testing.T) {
+	restore := saveTerminalEnv(t)
+	defer restore()
+	t.Setenv("TERM_PROGRAM", "vscode")
+	setProcessAncestors(t, "claude", "bash", "cursor")
+
+	if got := GetTerminalName(); got != "Cursor" {
+		t.Errorf("GetTerminalName() under Cursor = %q, want %q", got, "Cursor")
+	}
+}
+
+func TestGetSearchTermWithFolder_Editors(t *testing.T) {
+	tests := []struct {
+		terminal, folder, want string
+	}{
+		{"Code", "my-project", "my-project"},
+		{"Cursor", "my-project", "my-project"},
+		{"Zed", "my-project", "my-project"},
+		{"goland", "my-project", "my-project"},
+		{"PyCharm", "my-project", "my-project"},
+		{"Cursor", "", "Cursor"},
+		{"kitty", "my-project", "kitty"},
+	}
+	for _, tt := range tests {
+		if got := GetSearchTermWithFolder(tt.terminal, tt.folder); got != tt.want {
+			t.Errorf("GetSearchTermWithFolder(%q, %q) = %q, want %q", tt.terminal, tt.folder, got, tt.want)
+		}
+	}
+}
+
+func TestEditorMappings(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"GetAppID(zed)", GetAppID("Zed"), "dev.zed.Zed.desktop"},
+		{"GetWlrctlAppID(zed)", GetWlrctlAppID("Zed"), "dev.zed.Zed"},
+		{"GetAppID(cursor)", GetAppID("Cursor"), "cursor.desktop"},
+		{"GetXdotoolClass(cursor)", GetXdotoolClass("Cursor"), "Cursor"},
+		{"GetAppID(idea)", GetAppID("idea"), "jetbrains-idea.desktop"},
+		{"GetKdotoolClass(goland)", GetKdotoolClass("goland"), "jetbrains-goland"},
+		{"GetMacBundleID(zed)", GetMacBundleID("zed"), "dev.zed.Zed"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
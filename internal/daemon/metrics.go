@@ -0,0 +1,209 @@
+//go:build linux
+
+// ABOUTME: Counters and histograms exposed by the daemon's optional metrics endpoint.
+// ABOUTME: Written in the Prometheus text exposition format without a client library.
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the delivery latency histogram
+var latencyBuckets = []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+const (
+	resultSuccess = "success"
+	resultFailure = "failure"
+)
+
+// histogram counts observations per bucket (not cumulative)
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// observe adds one value in seconds
+func (h *histogram) observe(v float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(latencyBuckets))
+	}
+	for i, bound := range latencyBuckets {
+		if v <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// labelPair is a metric with two labels, such as backend and result
+type labelPair struct {
+	first, second string
+}
+
+// metrics collects what the daemon has done since it started
+type metrics struct {
+	mu            sync.Mutex
+	deliveries    map[labelPair]uint64 // backend, result
+	latency       map[string]*histogram
+	notifications map[string]uint64    // result
+	focus         map[labelPair]uint64 // method, result
+}
+
+// gauges are the current values read when the metrics are scraped
+type gauges struct {
+	inflight     int
+	webhookQueue int
+	active       int
+	muted        bool
+	startTime    time.Time
+}
+
+// newMetrics creates empty metrics
+func newMetrics() *metrics {
+	return &metrics{
+		deliveries:    map[labelPair]uint64{},
+		latency:       map[string]*histogram{},
+		notifications: map[string]uint64{},
+		focus:         map[labelPair]uint64{},
+	}
+}
+
+// resultLabel returns the result label of an outcome
+func resultLabel(ok bool) string {
+	if ok {
+		return resultSuccess
+	}
+	return resultFailure
+}
+
+// observeDelivery records a delivery to a backend and how long it took
+func (m *metrics) observeDelivery(backend string, success bool, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deliveries[labelPair{backend, resultLabel(success)}]++
+	h, ok := m.latency[backend]
+	if !ok {
+		h = &histogram{}
+		m.latency[backend] = h
+	}
+	h.observe(d.Seconds())
+}
+
+// observeNotification records a desktop notification sent by the daemon
+func (m *metrics) observeNotification(err error) {
+	m.mu.Lock()
+	m.notifications[resultLabel(err == nil)]++
+	m.mu.Unlock()
+}
+
+// observeFocus records one focus method tried
+func (m *metrics) observeFocus(method string, err error) {
+	m.mu.Lock()
+	m.focus[labelPair{method, resultLabel(err == nil)}]++
+	m.mu.Unlock()
+}
+
+// write writes all metrics in the Prometheus text format
+func (m *metrics) write(w io.Writer, g gauges) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	header(&b, "claude_notifications_deliveries_total", "counter", "Notifications delivered per backend and result.")
+	for _, key := range sortedPairs(m.deliveries) {
+		fmt.Fprintf(&b, "claude_notifications_deliveries_total{backend=%s,result=%s} %d\n",
+			quote(key.first), quote(key.second), m.deliveries[key])
+	}
+
+	header(&b, "claude_notifications_delivery_duration_seconds", "histogram", "Time a delivery took, including retries.")
+	backends := make([]string, 0, len(m.latency))
+	for backend := range m.latency {
+		backends = append(backends, backend)
+	}
+	sort.Strings(backends)
+	for _, backend := range backends {
+		h := m.latency[backend]
+		var cumulative uint64
+		for i, bound := range latencyBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(&b, "claude_notifications_delivery_duration_seconds_bucket{backend=%s,le=%q} %d\n",
+				quote(backend), formatFloat(bound), cumulative)
+		}
+		fmt.Fprintf(&b, "claude_notifications_delivery_duration_seconds_bucket{backend=%s,le=\"+Inf\"} %d\n", quote(backend), h.count)
+		fmt.Fprintf(&b, "claude_notifications_delivery_duration_seconds_sum{backend=%s} %s\n", quote(backend), formatFloat(h.sum))
+		fmt.Fprintf(&b, "claude_notifications_delivery_duration_seconds_count{backend=%s} %d\n", quote(backend), h.count)
+	}
+
+	header(&b, "claude_notifications_daemon_notifications_total", "counter", "Desktop notifications sent by the daemon per result.")
+	for _, r := range []string{resultSuccess, resultFailure} {
+		fmt.Fprintf(&b, "claude_notifications_daemon_notifications_total{result=%q} %d\n", r, m.notifications[r])
+	}
+
+	header(&b, "claude_notifications_focus_attempts_total", "counter", "Focus methods tried per method and result.")
+	for _, key := range sortedPairs(m.focus) {
+		fmt.Fprintf(&b, "claude_notifications_focus_attempts_total{method=%s,result=%s} %d\n",
+			quote(key.first), quote(key.second), m.focus[key])
+	}
+
+	header(&b, "claude_notifications_queue_depth", "gauge", "Entries waiting in the daemon's queues.")
+	fmt.Fprintf(&b, "claude_notifications_queue_depth{queue=\"inflight\"} %d\n", g.inflight)
+	fmt.Fprintf(&b, "claude_notifications_queue_depth{queue=\"webhook_retry\"} %d\n", g.webhookQueue)
+
+	header(&b, "claude_notifications_active_notifications", "gauge", "Notifications on screen that can still be clicked.")
+	fmt.Fprintf(&b, "claude_notifications_active_notifications %d\n", g.active)
+
+	header(&b, "claude_notifications_muted", "gauge", "Whether do-not-disturb is on (1) or off (0).")
+	muted := 0
+	if g.muted {
+		muted = 1
+	}
+	fmt.Fprintf(&b, "claude_notifications_muted %d\n", muted)
+
+	header(&b, "claude_notifications_daemon_start_time_seconds", "gauge", "Unix time the daemon started.")
+	fmt.Fprintf(&b, "claude_notifications_daemon_start_time_seconds %d\n", g.startTime.Unix())
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// header writes the HELP and TYPE lines of a metric
+func header(b *strings.Builder, name, typ, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sortedPairs returns the keys of a counter map in a stable order
+func sortedPairs(counts map[labelPair]uint64) []labelPair {
+	keys := make([]labelPair, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].first != keys[j].first {
+			return keys[i].first < keys[j].first
+		}
+		return keys[i].second < keys[j].second
+	})
+	return keys
+}
+
+// quote escapes a label value
+func quote(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	v = strings.ReplaceAll(v, `"`, `\"`)
+	return `"` + v + `"`
+}
+
+// formatFloat formats a sample value or bucket bound
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
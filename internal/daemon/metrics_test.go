@@ -0,0 +1,112 @@
+//go:build linux
+
+package daemon
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsWrite(t *testing.T) {
+	m := newMetrics()
+	m.observeDelivery("webhook", true, 30*time.Millisecond)
+	m.observeDelivery("webhook", false, 3*time.Second)
+	m.observeDelivery("email", true, 200*time.Millisecond)
+	m.observeNotification(nil)
+	m.observeNotification(errors.New("no notification server"))
+	m.observeFocus("wmctrl", errors.New("not found"))
+	m.observeFocus("swaymsg", nil)
+
+	var b strings.Builder
+	start := time.Unix(1700000000, 0)
+	if err := m.write(&b, gauges{inflight: 1, webhookQueue: 4, active: 2, muted: true, startTime: start}); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		"# TYPE claude_notifications_deliveries_total counter\n",
+		`claude_notifications_deliveries_total{backend="email",result="success"} 1`,
+		`claude_notifications_deliveries_total{backend="webhook",result="failure"} 1`,
+		`claude_notifications_deliveries_total{backend="webhook",result="success"} 1`,
+		"# TYPE claude_notifications_delivery_duration_seconds histogram\n",
+		`claude_notifications_delivery_duration_seconds_bucket{backend="webhook",le="0.01"} 0`,
+		`claude_notifications_delivery_duration_seconds_bucket{backend="webhook",le="0.05"} 1`,
+		`claude_notifications_delivery_duration_seconds_bucket{backend="webhook",le="2.5"} 1`,
+		`claude_notifications_delivery_duration_seconds_bucket{backend="webhook",le="5"} 2`,
+		`claude_notifications_delivery_duration_seconds_bucket{backend="webhook",le="+Inf"} 2`,
+		`claude_notifications_delivery_duration_seconds_sum{backend="webhook"} 3.03`,
+		`claude_notifications_delivery_duration_seconds_count{backend="webhook"} 2`,
+		`claude_notifications_daemon_notifications_total{result="success"} 1`,
+		`claude_notifications_daemon_notifications_total{result="failure"} 1`,
+		`claude_notifications_focus_attempts_total{method="swaymsg",result="success"} 1`,
+		`claude_notifications_focus_attempts_total{method="wmctrl",result="failure"} 1`,
+		`claude_notifications_queue_depth{queue="inflight"} 1`,
+		`claude_notifications_queue_depth{queue="webhook_retry"} 4`,
+		"claude_notifications_active_notifications 2\n",
+		"claude_notifications_muted 1\n",
+		"claude_notifications_daemon_start_time_seconds 1700000000\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics output is missing %q:\n%s", want, out)
+		}
+	}
+
+	// Labels are sorted, so scrapes are stable
+	if strings.Index(out, `backend="email"`) > strings.Index(out, `backend="webhook"`) {
+		t.Error("deliveries should be sorted by backend")
+	}
+}
+
+func TestMetricsWrite_Empty(t *testing.T) {
+	var b strings.Builder
+	if err := newMetrics().write(&b, gauges{startTime: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	if strings.Contains(out, "claude_notifications_deliveries_total{") {
+		t.Errorf("no delivery samples expected:\n%s", out)
+	}
+	if !strings.Contains(out, "claude_notifications_muted 0\n") {
+		t.Errorf("muted gauge missing:\n%s", out)
+	}
+}
+
+func TestHistogramObserve(t *testing.T) {
+	var h histogram
+	h.observe(0.01) // Upper bounds are inclusive
+	h.observe(120)  // Beyond the last bucket: only in +Inf
+	if h.counts[0] != 1 || h.count != 2 {
+		t.Errorf("counts = %v, count = %d", h.counts, h.count)
+	}
+	var total uint64
+	for _, c := range h.counts {
+		total += c
+	}
+	if total != 1 {
+		t.Errorf("bucketed observations = %d, want 1", total)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	if got := quote("a\"b\\c\nd"); got != `"a\"b\\c\nd"` {
+		t.Errorf("quote() = %s", got)
+	}
+}
+
+func TestTryFocus_Observes(t *testing.T) {
+	methods := GetFocusMethods()
+	if len(methods) == 0 {
+		t.Skip("no focus methods on this platform")
+	}
+	t.Setenv("HOME", t.TempDir()) // No learned method reorders the chain
+	var tried []string
+	_ = tryFocus("no-such-terminal-xyz", "", nil, FocusOptions{}, func(method string, err error) {
+		tried = append(tried, method)
+	})
+	if len(tried) == 0 || tried[0] != methods[0].Name {
+		t.Errorf("observed methods = %v, want to start with %s", tried, methods[0].Name)
+	}
+}
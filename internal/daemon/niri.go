@@ -0,0 +1,64 @@
+//go:build linux
+
+// ABOUTME: Niri focus through its "niri msg" IPC client.
+// ABOUTME: Lists windows as JSON and focuses one by ID, switching workspace and monitor.
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// niriWindow is an entry of "niri msg --json windows"
+type niriWindow struct {
+	ID    uint64 `json:"id"`
+	Title string `json:"title"`
+	AppID string `json:"app_id"`
+}
+
+// TryNiri focuses a window on Niri with "niri msg action focus-window".
+// Only attempted when NIRI_SOCKET is set (i.e. inside a Niri session).
+func TryNiri(ctx context.Context, terminalName, folderName string) error {
+	if os.Getenv("NIRI_SOCKET") == "" {
+		return fmt.Errorf("not running under Niri (NIRI_SOCKET not set)")
+	}
+	if _, err := exec.LookPath("niri"); err != nil {
+		return fmt.Errorf("niri not installed")
+	}
+
+	output, err := exec.CommandContext(ctx, "niri", "msg", "--json", "windows").Output()
+	if err != nil {
+		return fmt.Errorf("niri msg windows failed: %w", err)
+	}
+	windows, err := parseNiriWindows(output)
+	if err != nil {
+		return err
+	}
+	w := pickToplevel(windows, terminalName, folderName)
+	if w == nil {
+		return fmt.Errorf("no %s window found via niri", terminalName)
+	}
+
+	output, err = exec.CommandContext(ctx, "niri", "msg", "action", "focus-window", "--id", w.id).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("niri focus-window --id %s failed: %w, output: %s", w.id, err, string(output))
+	}
+	return nil
+}
+
+// parseNiriWindows reads "niri msg --json windows" output
+func parseNiriWindows(output []byte) ([]toplevel, error) {
+	var windows []niriWindow
+	if err := json.Unmarshal(output, &windows); err != nil {
+		return nil, fmt.Errorf("invalid niri windows output: %w", err)
+	}
+	toplevels := make([]toplevel, 0, len(windows))
+	for _, w := range windows {
+		toplevels = append(toplevels, toplevel{id: strconv.FormatUint(w.ID, 10), appID: w.AppID, title: w.Title})
+	}
+	return toplevels, nil
+}
@@ -0,0 +1,34 @@
+//go:build linux
+
+package daemon
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestParseNiriWindows(t *testing.T) {
+	output := `[{"id":3,"title":"api","app_id":"kitty","pid":100,"workspace_id":1,"is_focused":false},
+		{"id":12,"title":"Inbox","app_id":"firefox","workspace_id":2,"is_focused":true}]`
+
+	windows, err := parseNiriWindows([]byte(output))
+	if err != nil {
+		t.Fatalf("parseNiriWindows() error = %v", err)
+	}
+	want := []toplevel{{id: "3", appID: "kitty", title: "api"}, {id: "12", appID: "firefox", title: "Inbox"}}
+	if len(windows) != len(want) || windows[0] != want[0] || windows[1] != want[1] {
+		t.Errorf("parseNiriWindows() = %+v, want %+v", windows, want)
+	}
+
+	if _, err := parseNiriWindows([]byte("Error: not running")); err == nil {
+		t.Error("parseNiriWindows() should fail on non-JSON output")
+	}
+}
+
+func TestTryNiri_NotNiriSession(t *testing.T) {
+	t.Setenv("NIRI_SOCKET", "")
+	if err := TryNiri(context.Background(), "kitty", ""); err == nil || !strings.Contains(err.Error(), "not running under Niri") {
+		t.Errorf("TryNiri() error = %v", err)
+	}
+}
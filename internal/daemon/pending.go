@@ -0,0 +1,129 @@
+//go:build linux
+
+// ABOUTME: Notifications not delivered: accepted when the daemon stopped, or spooled by
+// ABOUTME: hooks that could not show them. The next daemon sends them when it starts.
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// pendingFileName is the file in the stable config dir holding undelivered notifications
+	pendingFileName = "daemon-pending.jsonl"
+
+	// pendingMaxAge drops saved notifications too old to be worth showing
+	pendingMaxAge = time.Hour
+)
+
+// pendingNotification is a notification request that was not delivered
+type pendingNotification struct {
+	Request  NotifyRequest `json:"request"`
+	Received time.Time     `json:"received"`
+}
+
+// savePending appends notifications to the pending file at path
+func savePending(path string, list []pendingNotification) error {
+	if len(list) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for pending notifications: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open pending notifications: %w", err)
+	}
+	defer f.Close()
+	for _, p := range list {
+		data, err := json.Marshal(p)
+		if err != nil {
+			return fmt.Errorf("failed to serialize pending notification: %w", err)
+		}
+		if _, err := f.Write(append(data, '\n')); err != nil {
+			return fmt.Errorf("failed to write pending notifications: %w", err)
+		}
+	}
+	return nil
+}
+
+// Spool saves a notification that no daemon or notification service could
+// show, for the next daemon to deliver when it starts
+func Spool(req *NotifyRequest) error {
+	path, err := pendingPath()
+	if err != nil {
+		return err
+	}
+	return savePending(path, []pendingNotification{{Request: *req, Received: time.Now()}})
+}
+
+// Spooled returns the number of saved notifications the next daemon will deliver
+func Spooled() int {
+	path, err := pendingPath()
+	if err != nil {
+		return 0
+	}
+	return countPending(path, time.Now())
+}
+
+// countPending returns the number of notifications in the pending file at
+// path that are younger than pendingMaxAge, without claiming them
+func countPending(path string, now time.Time) int {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	n := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var p pendingNotification
+		if err := json.Unmarshal(scanner.Bytes(), &p); err == nil && now.Sub(p.Received) <= pendingMaxAge {
+			n++
+		}
+	}
+	return n
+}
+
+// loadPending removes the pending file at path and returns the
+// notifications in it that are younger than pendingMaxAge. The file is
+// renamed before reading, so a notification is never delivered twice.
+func loadPending(path string, now time.Time) ([]pendingNotification, error) {
+	claimed := fmt.Sprintf("%s.%d", path, os.Getpid())
+	if err := os.Rename(path, claimed); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim pending notifications: %w", err)
+	}
+	defer os.Remove(claimed)
+
+	f, err := os.Open(claimed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending notifications: %w", err)
+	}
+	defer f.Close()
+
+	var list []pendingNotification
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var p pendingNotification
+		if err := json.Unmarshal(scanner.Bytes(), &p); err != nil {
+			continue // skip corrupted lines
+		}
+		if now.Sub(p.Received) > pendingMaxAge {
+			continue
+		}
+		list = append(list, p)
+	}
+	return list, scanner.Err()
+}
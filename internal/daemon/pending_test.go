@@ -0,0 +1,155 @@
+//go:build linux
+
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveLoadPending(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", pendingFileName)
+	now := time.Now()
+
+	if err := savePending(path, []pendingNotification{
+		{Request: NotifyRequest{Title: "old"}, Received: now.Add(-2 * time.Hour)},
+		{Request: NotifyRequest{Title: "a", CoalesceKey: "s1"}, Received: now.Add(-time.Minute)},
+	}); err != nil {
+		t.Fatalf("savePending() error = %v", err)
+	}
+	// Saving again appends
+	if err := savePending(path, []pendingNotification{{Request: NotifyRequest{Title: "b"}, Received: now}}); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := loadPending(path, now)
+	if err != nil {
+		t.Fatalf("loadPending() error = %v", err)
+	}
+	if len(list) != 2 || list[0].Request.Title != "a" || list[1].Request.Title != "b" {
+		t.Fatalf("loadPending() = %+v, want a and b (old dropped)", list)
+	}
+	if list[0].Request.CoalesceKey != "s1" {
+		t.Errorf("CoalesceKey = %q, want s1", list[0].Request.CoalesceKey)
+	}
+
+	// Loading removes the file, so nothing is delivered twice
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("pending file still exists, stat error = %v", err)
+	}
+	list, err = loadPending(path, now)
+	if err != nil || len(list) != 0 {
+		t.Errorf("second loadPending() = %+v, %v, want nothing", list, err)
+	}
+}
+
+func TestLoadPending_SkipsCorruptedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), pendingFileName)
+	now := time.Now()
+	if err := savePending(path, []pendingNotification{{Request: NotifyRequest{Title: "ok"}, Received: now}}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("{not json\n")
+	f.Close()
+
+	list, err := loadPending(path, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Request.Title != "ok" {
+		t.Errorf("loadPending() = %+v, want only ok", list)
+	}
+}
+
+func TestSavePending_Empty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), pendingFileName)
+	if err := savePending(path, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("empty save created a file, stat error = %v", err)
+	}
+}
+
+func TestSpool(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if n := Spooled(); n != 0 {
+		t.Errorf("Spooled() = %d before spooling, want 0", n)
+	}
+	for _, title := range []string{"a", "b"} {
+		if err := Spool(&NotifyRequest{Title: title, CoalesceKey: "s1"}); err != nil {
+			t.Fatalf("Spool() error = %v", err)
+		}
+	}
+	if n := Spooled(); n != 2 {
+		t.Errorf("Spooled() = %d, want 2", n)
+	}
+
+	// The next daemon delivers spooled notifications like saved ones
+	path, err := pendingPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := loadPending(path, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Request.Title != "a" || list[1].Request.Title != "b" {
+		t.Errorf("loadPending() = %+v, want a and b", list)
+	}
+	if n := Spooled(); n != 0 {
+		t.Errorf("Spooled() = %d after loading, want 0", n)
+	}
+}
+
+func TestCountPending_SkipsOld(t *testing.T) {
+	path := filepath.Join(t.TempDir(), pendingFileName)
+	now := time.Now()
+	if err := savePending(path, []pendingNotification{
+		{Request: NotifyRequest{Title: "old"}, Received: now.Add(-2 * time.Hour)},
+		{Request: NotifyRequest{Title: "new"}, Received: now},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if n := countPending(path, now); n != 1 {
+		t.Errorf("countPending() = %d, want 1", n)
+	}
+}
+
+func TestServerSavePending_SkipsSending(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	s := &Server{}
+	now := time.Now()
+	sending := s.trackInflight(NotifyRequest{Title: "sending"}, now)
+	waiting := s.trackInflight(NotifyRequest{Title: "waiting"}, now)
+	if !s.startInflight(sending) {
+		t.Fatal("startInflight() = false for a tracked notification")
+	}
+
+	s.savePending()
+	path, err := pendingPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := loadPending(path, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Request.Title != "waiting" {
+		t.Errorf("saved %+v, want only the notification not being sent", list)
+	}
+	// The next daemon sends the saved one, so this one must not
+	if s.startInflight(waiting) {
+		t.Error("startInflight() = true for a saved notification")
+	}
+	if n := s.inflightCount(); n != 1 {
+		t.Errorf("inflightCount() = %d, want the one being sent", n)
+	}
+}
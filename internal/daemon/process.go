@@ -0,0 +1,169 @@
+// ABOUTME: Terminal detection from the parent process tree.
+// ABOUTME: Walks up from the hook's process to the terminal emulator or IDE that runs it.
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// maxProcessDepth bounds the walk up the process tree
+const maxProcessDepth = 32
+
+// TerminalEnv carries the terminal found by a hook ("name:pid") to the
+// worker process the daemon handles the hook in, whose ancestors are the
+// daemon's
+const TerminalEnv = "CLAUDE_NOTIFICATIONS_TERMINAL"
+
+// ClaudeEnv carries the Claude Code process found by a hook to the worker
+// process the daemon handles the hook in
+const ClaudeEnv = "CLAUDE_NOTIFICATIONS_CLAUDE_PID"
+
+// shells run hook commands; the Claude Code process is the first ancestor
+// that is not one of them
+var shells = map[string]bool{
+	"sh": true, "bash": true, "dash": true, "zsh": true, "fish": true, "ash": true,
+}
+
+// processInfo returns the executable name and parent PID of pid; tests
+// replace it with a fake process table
+var processInfo = readProcessInfo
+
+// terminalProcesses maps lowercase executable names of terminal emulators
+// and IDEs to the terminal names the mapping functions know
+var terminalProcesses = map[string]string{
+	"code":                  "Code",
+	"code-insiders":         "Code",
+	"code-oss":              "Code",
+	"codium":                "VSCodium",
+	"cursor":                "Cursor",
+	"zed":                   "Zed",
+	"zed-editor":            "Zed",
+	"gnome-terminal-server": "gnome-terminal",
+	"konsole":               "konsole",
+	"alacritty":             "Alacritty",
+	"kitty":                 "kitty",
+	"wezterm-gui":           "WezTerm",
+	"ghostty":               "ghostty",
+	"foot":                  "foot",
+	"tilix":                 "tilix",
+	"terminator":            "terminator",
+	"xfce4-terminal":        "xfce4-terminal",
+	"mate-terminal":         "mate-terminal",
+	"lxterminal":            "lxterminal",
+	"qterminal":             "qterminal",
+	"xterm":                 "xterm",
+	"urxvt":                 "urxvt",
+	"st":                    "st",
+	"terminal":              "Apple_Terminal",
+	"iterm2":                "iTerm.app",
+	"hyper":                 "Hyper",
+}
+
+// jetbrainsIDEs maps JetBrains launcher names to their macOS bundle IDs.
+// On Linux their window class is "jetbrains-<launcher>".
+var jetbrainsIDEs = map[string]string{
+	"idea":      "com.jetbrains.intellij",
+	"pycharm":   "com.jetbrains.pycharm",
+	"goland":    "com.jetbrains.goland",
+	"webstorm":  "com.jetbrains.WebStorm",
+	"phpstorm":  "com.jetbrains.PhpStorm",
+	"clion":     "com.jetbrains.CLion",
+	"rider":     "com.jetbrains.rider",
+	"rubymine":  "com.jetbrains.rubymine",
+	"rustrover": "com.jetbrains.rustrover",
+	"datagrip":  "com.jetbrains.datagrip",
+}
+
+// terminalFromProcessTree returns the terminal that owns this process, found
+// by walking up its ancestors ("" = none found, e.g. under a tmux server)
+func terminalFromProcessTree() string {
+	terminal, _ := terminalAncestor()
+	return terminal
+}
+
+// TerminalPID returns the process of the terminal emulator or IDE that
+// runs this process (0 = none found, e.g. under a tmux server)
+func TerminalPID() int {
+	_, pid := terminalAncestor()
+	return pid
+}
+
+// PinnedTerminal returns the TerminalEnv value for this process's terminal
+func PinnedTerminal() string {
+	terminal, pid := terminalAncestor()
+	return fmt.Sprintf("%s:%d", terminal, pid)
+}
+
+// terminalAncestor walks up this process's ancestors to the first terminal
+// emulator or IDE and returns its name and PID, unless TerminalEnv pins them
+func terminalAncestor() (terminal string, pid int) {
+	if pinned, ok := os.LookupEnv(TerminalEnv); ok {
+		i := strings.LastIndexByte(pinned, ':')
+		if i < 0 {
+			return pinned, 0
+		}
+		pid, _ = strconv.Atoi(pinned[i+1:])
+		return pinned[:i], pid
+	}
+
+	pid = os.Getppid()
+	for i := 0; i < maxProcessDepth && pid > 1; i++ {
+		name, ppid, err := processInfo(pid)
+		if err != nil {
+			return "", 0
+		}
+		if terminal := terminalForProcess(name); terminal != "" {
+			return terminal, pid
+		}
+		pid = ppid
+	}
+	return "", 0
+}
+
+// ClaudePID returns the Claude Code process that runs this hook, the first
+// ancestor that is not a shell, unless ClaudeEnv pins it (0 = unknown)
+func ClaudePID() int {
+	if pinned, ok := os.LookupEnv(ClaudeEnv); ok {
+		pid, _ := strconv.Atoi(pinned)
+		return pid
+	}
+	pid := os.Getppid()
+	for i := 0; i < maxProcessDepth && pid > 1; i++ {
+		name, ppid, err := processInfo(pid)
+		if err != nil {
+			return 0
+		}
+		if !shells[strings.ToLower(name)] {
+			return pid
+		}
+		pid = ppid
+	}
+	return 0
+}
+
+// ProcessAlive reports whether process pid still runs. Where the process
+// table cannot be read, every process counts as running.
+func ProcessAlive(pid int) bool {
+	_, _, err := processInfo(pid)
+	return err == nil || !processTableSupported
+}
+
+// terminalForProcess returns the terminal name for an executable name.
+// Electron helpers ("Code Helper (Plugin)") count as their app.
+func terminalForProcess(name string) string {
+	name = strings.ToLower(name)
+	if i := strings.Index(name, " helper"); i > 0 {
+		name = name[:i]
+	}
+	name = strings.TrimSuffix(name, "64") // idea64, pycharm64
+	if terminal, ok := terminalProcesses[name]; ok {
+		return terminal
+	}
+	if _, ok := jetbrainsIDEs[name]; ok {
+		return name
+	}
+	return ""
+}
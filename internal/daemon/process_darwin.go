@@ -0,0 +1,27 @@
+//go:build darwin
+
+package daemon
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// processTableSupported reports whether readProcessInfo works here
+const processTableSupported = true
+
+// readProcessInfo reads pid's command name and parent with sysctl
+// kern.proc.pid. The name is cut to 16 bytes ("Code Helper (Plu").
+func readProcessInfo(pid int) (name string, ppid int, err error) {
+	info, err := unix.SysctlKinfoProc("kern.proc.pid", pid)
+	if err != nil {
+		return "", 0, fmt.Errorf("sysctl kern.proc.pid %d failed: %w", pid, err)
+	}
+	comm := info.Proc.P_comm[:]
+	if i := strings.IndexByte(string(comm), 0); i >= 0 {
+		comm = comm[:i]
+	}
+	return string(comm), int(info.Eproc.Ppid), nil
+}
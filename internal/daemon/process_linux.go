@@ -0,0 +1,88 @@
+//go:build linux
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// processTableSupported reports whether readProcessInfo works here
+const processTableSupported = true
+
+// readProcessInfo reads pid's executable and parent from /proc. The
+// executable path is preferred over comm, which is cut to 15 bytes
+// ("gnome-terminal-").
+func readProcessInfo(pid int) (name string, ppid int, err error) {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return "", 0, err
+	}
+	// "pid (comm) state ppid ...", where comm may hold spaces and parentheses
+	open, end := strings.IndexByte(string(stat), '('), strings.LastIndexByte(string(stat), ')')
+	if open < 0 || end < open {
+		return "", 0, fmt.Errorf("invalid /proc/%d/stat", pid)
+	}
+	fields := strings.Fields(string(stat[end+1:]))
+	if len(fields) < 2 {
+		return "", 0, fmt.Errorf("invalid /proc/%d/stat", pid)
+	}
+	ppid, err = strconv.Atoi(fields[1])
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid /proc/%d/stat: %w", pid, err)
+	}
+
+	name = string(stat[open+1 : end])
+	if exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid)); err == nil {
+		name = filepath.Base(strings.TrimSuffix(exe, " (deleted)"))
+	}
+	return name, ppid, nil
+}
+
+// ControllingTTY returns the device of this process's controlling
+// terminal, e.g. /dev/pts/3 ("" = none)
+func ControllingTTY() string {
+	stat, err := os.ReadFile("/proc/self/stat")
+	if err != nil {
+		return ""
+	}
+	end := strings.LastIndexByte(string(stat), ')')
+	if end < 0 {
+		return ""
+	}
+	// state ppid pgrp session tty_nr ...
+	fields := strings.Fields(string(stat[end+1:]))
+	if len(fields) < 5 {
+		return ""
+	}
+	nr, err := strconv.ParseUint(fields[4], 10, 64)
+	if err != nil {
+		return ""
+	}
+	path := ttyDevicePath(nr)
+	var st syscall.Stat_t
+	if path == "" || syscall.Stat(path, &st) != nil || uint64(st.Rdev) != nr {
+		return ""
+	}
+	return path
+}
+
+// ttyDevicePath maps a device number from /proc/<pid>/stat to a pseudo
+// terminal or virtual console ("" = none or another kind of device)
+func ttyDevicePath(nr uint64) string {
+	major := (nr >> 8) & 0xfff
+	minor := (nr & 0xff) | ((nr >> 12) & 0xfff00)
+	switch {
+	case nr == 0:
+		return ""
+	case major >= 136 && major <= 143: // Unix98 pseudo terminals, 256 per major
+		return fmt.Sprintf("/dev/pts/%d", (major-136)*256+minor)
+	case major == 4 && minor < 64:
+		return fmt.Sprintf("/dev/tty%d", minor)
+	}
+	return ""
+}
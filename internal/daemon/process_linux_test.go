@@ -0,0 +1,49 @@
+//go:build linux
+
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadProcessInfo_Self(t *testing.T) {
+	name, ppid, err := readProcessInfo(os.Getpid())
+	if err != nil {
+		t.Fatalf("readProcessInfo() error = %v", err)
+	}
+	exe, _ := os.Executable()
+	if name != filepath.Base(exe) {
+		t.Errorf("name = %q, want %q", name, filepath.Base(exe))
+	}
+	if ppid != os.Getppid() {
+		t.Errorf("ppid = %d, want %d", ppid, os.Getppid())
+	}
+}
+
+func TestReadProcessInfo_Missing(t *testing.T) {
+	if _, _, err := readProcessInfo(1 << 30); err == nil {
+		t.Error("expected an error for a missing process")
+	}
+}
+
+func TestTTYDevicePath(t *testing.T) {
+	tests := []struct {
+		nr   uint64
+		want string
+	}{
+		{0, ""},
+		{136<<8 | 3, "/dev/pts/3"},
+		{137<<8 | 1, "/dev/pts/257"},
+		{136<<8 | 1<<20, "/dev/pts/256"}, // Minor above 255
+		{4<<8 | 2, "/dev/tty2"},
+		{4<<8 | 64, ""}, // Serial port
+		{8<<8 | 1, ""},  // Block device
+	}
+	for _, tt := range tests {
+		if got := ttyDevicePath(tt.nr); got != tt.want {
+			t.Errorf("ttyDevicePath(%#x) = %q, want %q", tt.nr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package daemon
+
+import "fmt"
+
+// processTableSupported reports whether readProcessInfo works here
+const processTableSupported = false
+
+// readProcessInfo is not implemented on this platform
+func readProcessInfo(pid int) (string, int, error) {
+	return "", 0, fmt.Errorf("process tree not supported on this platform")
+}
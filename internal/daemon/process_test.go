@@ -0,0 +1,148 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// fakeProcess is an entry of a fake process table
+type fakeProcess struct {
+	name string
+	ppid int
+}
+
+// fakeProcessTable returns a processInfo reading from procs
+func fakeProcessTable(procs map[int]fakeProcess) func(int) (string, int, error) {
+	return func(pid int) (string, int, error) {
+		p, ok := procs[pid]
+		if !ok {
+			return "", 0, fmt.Errorf("no process %d", pid)
+		}
+		return p.name, p.ppid, nil
+	}
+}
+
+// setProcessAncestors fakes the ancestors of the test process, parent first
+func setProcessAncestors(t *testing.T, names ...string) {
+	t.Helper()
+	procs := map[int]fakeProcess{}
+	pid := os.Getppid()
+	for i, name := range names {
+		ppid := 1000 + i
+		if i == len(names)-1 {
+			ppid = 1
+		}
+		procs[pid] = fakeProcess{name: name, ppid: ppid}
+		pid = ppid
+	}
+	saved := processInfo
+	processInfo = fakeProcessTable(procs)
+	t.Cleanup(func() { processInfo = saved })
+}
+
+func TestTerminalFromProcessTree(t *testing.T) {
+	tests := []struct {
+		name      string
+		ancestors []string
+		want      string
+	}{
+		{"kitty", []string{"claude", "zsh", "kitty", "systemd"}, "kitty"},
+		{"GNOME Terminal", []string{"node", "bash", "gnome-terminal-server", "systemd"}, "gnome-terminal"},
+		{"VS Code on macOS", []string{"node", "zsh", "Code Helper (Plu", "Code"}, "Code"},
+		{"Cursor", []string{"claude", "bash", "cursor", "systemd"}, "Cursor"},
+		{"Zed", []string{"claude", "fish", "zed-editor"}, "Zed"},
+		{"JetBrains", []string{"claude", "zsh", "goland"}, "goland"},
+		{"JetBrains 64-bit launcher", []string{"claude", "zsh", "idea64"}, "idea"},
+		{"nearest wins", []string{"claude", "bash", "wezterm-gui", "code"}, "WezTerm"},
+		{"tmux server", []string{"claude", "bash", "tmux: server", "systemd"}, ""},
+		{"nothing known", []string{"claude", "sshd"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setProcessAncestors(t, tt.ancestors...)
+			if got := terminalFromProcessTree(); got != tt.want {
+				t.Errorf("terminalFromProcessTree() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTerminalPID(t *testing.T) {
+	// The parent is os.Getppid(), its ancestors 1000, 1001, ...
+	setProcessAncestors(t, "claude", "zsh", "kitty", "systemd")
+	if got := TerminalPID(); got != 1001 {
+		t.Errorf("TerminalPID() = %d, want 1001", got)
+	}
+
+	setProcessAncestors(t, "claude", "bash", "tmux: server", "systemd")
+	if got := TerminalPID(); got != 0 {
+		t.Errorf("TerminalPID() under tmux = %d, want 0", got)
+	}
+}
+
+func TestPinnedTerminal(t *testing.T) {
+	setProcessAncestors(t, "claude", "zsh", "kitty", "systemd")
+	pinned := PinnedTerminal()
+	if pinned != "kitty:1001" {
+		t.Fatalf("PinnedTerminal() = %q, want kitty:1001", pinned)
+	}
+
+	// A worker of the daemon runs under other ancestors
+	setProcessAncestors(t, "claude-notifications", "systemd")
+	t.Setenv(TerminalEnv, pinned)
+	if got := terminalFromProcessTree(); got != "kitty" {
+		t.Errorf("terminalFromProcessTree() = %q, want kitty", got)
+	}
+	if got := TerminalPID(); got != 1001 {
+		t.Errorf("TerminalPID() = %d, want 1001", got)
+	}
+
+	t.Setenv(TerminalEnv, ":0")
+	if got := terminalFromProcessTree(); got != "" {
+		t.Errorf("terminalFromProcessTree() with none pinned = %q, want \"\"", got)
+	}
+}
+
+func TestTerminalFromProcessTree_Cycle(t *testing.T) {
+	saved := processInfo
+	processInfo = fakeProcessTable(map[int]fakeProcess{
+		os.Getppid(): {name: "bash", ppid: 4242},
+		4242:         {name: "zsh", ppid: os.Getppid()},
+	})
+	t.Cleanup(func() { processInfo = saved })
+
+	if got := terminalFromProcessTree(); got != "" {
+		t.Errorf("terminalFromProcessTree() = %q, want \"\"", got)
+	}
+}
+
+func TestJetBrainsMappings(t *testing.T) {
+	if got := GetXdotoolClass("goland"); got != "jetbrains-goland" {
+		t.Errorf("GetXdotoolClass(goland) = %q", got)
+	}
+	if got := GetWlrctlAppID("PyCharm"); got != "jetbrains-pycharm" {
+		t.Errorf("GetWlrctlAppID(PyCharm) = %q", got)
+	}
+	if got := GetMacBundleID("idea"); got != "com.jetbrains.intellij" {
+		t.Errorf("GetMacBundleID(idea) = %q", got)
+	}
+	if got := GetMacBundleID("cursor"); got == "" {
+		t.Error("GetMacBundleID(cursor) is empty")
+	}
+}
+
+func TestClaudePID(t *testing.T) {
+	setProcessAncestors(t, "bash", "claude", "zsh", "kitty")
+	if got, want := ClaudePID(), 1000; got != want {
+		t.Errorf("ClaudePID() = %d, want the first ancestor that is not a shell (%d)", got, want)
+	}
+	if !ProcessAlive(1000) || (processTableSupported && ProcessAlive(4242)) {
+		t.Error("ProcessAlive should report the processes of the table")
+	}
+
+	t.Setenv(ClaudeEnv, "777")
+	if got := ClaudePID(); got != 777 {
+		t.Errorf("ClaudePID() = %d, want the pinned 777", got)
+	}
+}
@@ -2,13 +2,20 @@
 
 // ABOUTME: IPC protocol types for communication between daemon client and server.
 // ABOUTME: Uses JSON-over-Unix-socket for simple, reliable inter-process communication.
+// ABOUTME: The protocol is documented for third-party clients in docs/DAEMON_PROTOCOL.md.
 package daemon
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/sessions"
+	"github.com/esiqveland/notify"
 )
 
 // Common errors
@@ -17,40 +24,104 @@ var (
 	ErrDaemonNotRunning   = errors.New("daemon not running")
 )
 
-// Protocol version for compatibility checking
-const ProtocolVersion = "1.0"
+// ProtocolVersion is "major.minor". The minor version grows when messages
+// or fields are added; the daemon answers any request of its major version.
+const ProtocolVersion = "1.9"
 
 // MessageType identifies the type of IPC message
 type MessageType string
 
 const (
-	MessageTypeNotify MessageType = "notify"
-	MessageTypePing   MessageType = "ping"
-	MessageTypeStop   MessageType = "stop"
+	MessageTypeNotify   MessageType = "notify"
+	MessageTypePing     MessageType = "ping"
+	MessageTypeStop     MessageType = "stop" // Same as shutdown; kept for 1.0 clients
+	MessageTypeClose    MessageType = "close"
+	MessageTypeFocus    MessageType = "focus"
+	MessageTypeStatus   MessageType = "status"
+	MessageTypeSessions MessageType = "list_sessions"
+	MessageTypeMute     MessageType = "mute"
+	MessageTypeShutdown MessageType = "shutdown"
+	MessageTypeReport   MessageType = "report_delivery"
+	MessageTypeDismiss  MessageType = "dismiss"
+	MessageTypeAcked    MessageType = "acknowledged"
+	MessageTypeSession  MessageType = "update_session"
+	MessageTypeHook     MessageType = "handle_hook"
+	MessageTypeSecret   MessageType = "resolve_secret"
+)
+
+// Urgency levels for NotifyRequest.Urgency (freedesktop notification spec)
+const (
+	UrgencyLow      = "low"
+	UrgencyNormal   = "normal"
+	UrgencyCritical = "critical"
+)
+
+// Notification action keys. "default" is invoked by clicking the notification body;
+// "focus" is an explicit button for servers that don't treat body clicks as actions.
+const (
+	ActionKeyDefault = "default"
+	ActionKeyFocus   = "focus"
 )
 
 // Request is the wrapper for all IPC requests
 type Request struct {
-	Type    MessageType    `json:"type"`
-	Notify  *NotifyRequest `json:"notify,omitempty"`
-	Version string         `json:"version"`
+	Type    MessageType          `json:"type"`
+	Notify  *NotifyRequest       `json:"notify,omitempty"`
+	Close   *CloseRequest        `json:"close,omitempty"`
+	Focus   *FocusRequest        `json:"focus,omitempty"`
+	Mute    *MuteRequest         `json:"mute,omitempty"`
+	Report  *ReportRequest       `json:"report,omitempty"`
+	Dismiss *DismissRequest      `json:"dismiss,omitempty"`
+	Acked   *AcknowledgedRequest `json:"acknowledged,omitempty"`
+	Session *SessionUpdate       `json:"session,omitempty"`
+	Hook    *HookRequest         `json:"hook,omitempty"`
+	Secret  *SecretRequest       `json:"secret,omitempty"`
+	Version string               `json:"version"` // Client's ProtocolVersion (empty = 1.0)
 }
 
 // Response is the wrapper for all IPC responses
 type Response struct {
-	Type   MessageType     `json:"type"`
-	Notify *NotifyResponse `json:"notify,omitempty"`
-	Ping   *PingResponse   `json:"ping,omitempty"`
-	Error  string          `json:"error,omitempty"`
+	Type     MessageType           `json:"type"`
+	Notify   *NotifyResponse       `json:"notify,omitempty"`
+	Ping     *PingResponse         `json:"ping,omitempty"`
+	Focus    *FocusResponse        `json:"focus,omitempty"`
+	Status   *StatusResponse       `json:"status,omitempty"`
+	Sessions *SessionsResponse     `json:"sessions,omitempty"`
+	Mute     *MuteResponse         `json:"mute,omitempty"`
+	Dismiss  *DismissResponse      `json:"dismiss,omitempty"`
+	Acked    *AcknowledgedResponse `json:"acknowledged,omitempty"`
+	Hook     *HookResponse         `json:"hook,omitempty"`
+	Secret   *SecretResponse       `json:"secret,omitempty"`
+	Error    string                `json:"error,omitempty"`
 }
 
 // NotifyRequest contains notification details sent to the daemon
 type NotifyRequest struct {
-	Title       string `json:"title"`
-	Body        string `json:"body"`
-	FocusTarget string `json:"focus_target"`           // Terminal identifier (empty = auto-detect)
-	FocusFolder string `json:"focus_folder,omitempty"` // Project folder name for window-specific focus
-	Timeout     int    `json:"timeout"`                // Notification timeout in seconds
+	Title         string `json:"title"`
+	Body          string `json:"body"`
+	FocusTarget   string `json:"focus_target"`             // Terminal identifier (empty = auto-detect)
+	FocusFolder   string `json:"focus_folder,omitempty"`   // Project folder name for window-specific focus
+	Timeout       int    `json:"timeout"`                  // Notification timeout in seconds
+	Urgency       string `json:"urgency,omitempty"`        // low, normal (default) or critical
+	ReplacesID    uint32 `json:"replaces_id,omitempty"`    // Atomically replace this notification (0 = new)
+	TmuxPane      string `json:"tmux_pane,omitempty"`      // tmux pane ID (TMUX_PANE, e.g. "%42") to select on click
+	TmuxSocket    string `json:"tmux_socket,omitempty"`    // tmux server socket path (from TMUX)
+	ZellijSession string `json:"zellij_session,omitempty"` // Zellij session name (ZELLIJ_SESSION_NAME)
+	ZellijTab     string `json:"zellij_tab,omitempty"`     // Zellij tab name to switch to on click
+	// Window the session started in, focused on click before searching by terminal (nil = not pinned)
+	Window *sessions.Window `json:"window,omitempty"`
+	// Close the notification once the user returns to Window after switching away
+	DismissOnFocus bool `json:"dismiss_on_focus,omitempty"`
+
+	// Burst control: the daemon replaces an earlier notification instead of stacking a new one
+	CoalesceKey     string `json:"coalesce_key,omitempty"`     // Groups notifications that may replace each other (session ID)
+	CoalesceSeconds int    `json:"coalesce_seconds,omitempty"` // Replace the key's notification if updated less than N seconds ago (0 = never)
+	MaxPerMinute    int    `json:"max_per_minute,omitempty"`   // New notifications per minute across keys; beyond it the latest is replaced (0 = unlimited)
+	// Replace the key's notification for as long as it is open, however old (one notification per session)
+	CoalesceOpen bool `json:"coalesce_open,omitempty"`
+	// Replace the key's notification while it is open as a newer version of
+	// it, e.g. a progress heartbeat, rather than count it as another event
+	Update bool `json:"update,omitempty"`
 }
 
 // NotifyResponse contains the result of a notification request
@@ -60,28 +131,215 @@ type NotifyResponse struct {
 	Error          string `json:"error,omitempty"`
 }
 
+// CloseRequest asks the daemon to close a previously sent notification
+type CloseRequest struct {
+	NotificationID uint32 `json:"notification_id"`
+}
+
+// DismissRequest asks the daemon to close every notification it still
+// shows for a coalesce key, e.g. when the session resumes
+type DismissRequest struct {
+	CoalesceKey string `json:"coalesce_key"`
+}
+
+// DismissResponse reports how many notifications were closed
+type DismissResponse struct {
+	Closed int `json:"closed"`
+}
+
+// AcknowledgedRequest asks when the user last acknowledged the
+// notifications of a coalesce key, e.g. before escalating one
+type AcknowledgedRequest struct {
+	CoalesceKey string `json:"coalesce_key"`
+}
+
+// AcknowledgedResponse holds the time of the last click, dismissal or
+// return to the window (nil = none since the daemon started)
+type AcknowledgedResponse struct {
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+}
+
 // PingResponse contains daemon status information
 type PingResponse struct {
 	Version string `json:"version"`
 	Uptime  int64  `json:"uptime"` // Seconds since daemon started
 }
 
-// GetSocketPath returns the Unix socket path for the daemon.
+// FocusRequest asks the daemon to focus a terminal. The first field set
+// picks the target: a notification's terminal and multiplexer pane, a
+// session's terminal and project, or an explicit terminal.
+type FocusRequest struct {
+	NotificationID uint32 `json:"notification_id,omitempty"` // A notification sent by this daemon
+	SessionID      string `json:"session_id,omitempty"`      // A session from list_sessions
+	Target         string `json:"target,omitempty"`          // Terminal identifier, e.g. "kitty" or "code"
+	Folder         string `json:"folder,omitempty"`          // Project folder name for window-specific focus (with target)
+}
+
+// FocusResponse reports what was focused
+type FocusResponse struct {
+	Target string `json:"target"`
+	Folder string `json:"folder,omitempty"`
+}
+
+// StatusResponse describes the running daemon
+type StatusResponse struct {
+	Version             string     `json:"version"`
+	PID                 int        `json:"pid"`
+	Uptime              int64      `json:"uptime"` // Seconds since daemon started
+	SupportsActions     bool       `json:"supports_actions"`
+	NotificationsSent   int        `json:"notifications_sent"`
+	LastNotification    *time.Time `json:"last_notification,omitempty"`
+	ActiveNotifications int        `json:"active_notifications"` // Notifications that can still be clicked
+	Muted               bool       `json:"muted"`
+	MutedReason         string     `json:"muted_reason,omitempty"` // "manual" or "schedule"
+	MutedUntil          *time.Time `json:"muted_until,omitempty"`  // Absent = until unmuted
+}
+
+// SessionsResponse lists the running Claude Code sessions
+type SessionsResponse struct {
+	Sessions []SessionInfo `json:"sessions"`
+}
+
+// SessionInfo is a running Claude Code session
+type SessionInfo struct {
+	ID           string           `json:"id"`
+	CWD          string           `json:"cwd"`
+	Project      string           `json:"project"`
+	Terminal     string           `json:"terminal,omitempty"`
+	TerminalPID  int              `json:"terminal_pid,omitempty"`
+	Window       *sessions.Window `json:"window,omitempty"`
+	TmuxPane     string           `json:"tmux_pane,omitempty"`
+	StartedAt    time.Time        `json:"started_at"`
+	LastActivity time.Time        `json:"last_activity"`
+}
+
+// SessionUpdate tells the daemon where a session runs. Hooks send one on
+// every event; empty fields keep what the daemon already knows.
+type SessionUpdate struct {
+	SessionID   string           `json:"session_id"`
+	CWD         string           `json:"cwd,omitempty"`          // Project directory
+	Terminal    string           `json:"terminal,omitempty"`     // Terminal identifier, e.g. "kitty"
+	TerminalPID int              `json:"terminal_pid,omitempty"` // Process of the terminal emulator or IDE
+	Window      *sessions.Window `json:"window,omitempty"`       // Window the session runs in
+	TmuxPane    string           `json:"tmux_pane,omitempty"`    // tmux pane ID (TMUX_PANE, e.g. "%42")
+	TmuxSocket  string           `json:"tmux_socket,omitempty"`  // tmux server socket path (from TMUX)
+	Ended       bool             `json:"ended,omitempty"`        // The session ended: forget it
+}
+
+// MuteRequest turns do-not-disturb on or off. Muting goes through the
+// same state as "claude-notifications dnd", so hooks and every backend
+// honor it.
+type MuteRequest struct {
+	Seconds int  `json:"seconds,omitempty"` // Mute for this long (0 = until unmuted)
+	Unmute  bool `json:"unmute,omitempty"`
+}
+
+// MuteResponse reports the do-not-disturb state after a mute request
+type MuteResponse struct {
+	Muted  bool       `json:"muted"`
+	Reason string     `json:"reason,omitempty"` // "manual" or "schedule"
+	Until  *time.Time `json:"until,omitempty"`  // Absent = until unmuted
+}
+
+// ReportRequest tells the daemon the outcome of a delivery made outside
+// it (a webhook, email, or a desktop notification sent by a hook), for
+// the metrics endpoint
+type ReportRequest struct {
+	Backend    string `json:"backend"` // "desktop", "webhook", "email", "speech", "mqtt", a webhooks entry name or "plugin:<name>"
+	Success    bool   `json:"success"`
+	DurationMs int64  `json:"duration_ms"` // Time the delivery took, including retries
+}
+
+// HookRequest hands a hook event to the daemon, so the hook returns to
+// Claude Code at once. The daemon runs "handle-hook --sync" on it in a
+// worker process with the hook's directory and environment.
+type HookRequest struct {
+	Event   string          `json:"event"`         // Hook name, e.g. "Stop"
+	Payload json.RawMessage `json:"payload"`       // JSON the hook read from stdin
+	Dir     string          `json:"dir,omitempty"` // Working directory of the hook
+	Env     []string        `json:"env,omitempty"` // Environment of the hook, "KEY=value"
+}
+
+// HookResponse reports that a hook was accepted
+type HookResponse struct {
+	Queued int `json:"queued"` // Hooks of the same session still ahead of it
+}
+
+// SecretRequest asks the daemon for the secret a config credential names:
+// "$(command)" or "keyring:service/account"
+type SecretRequest struct {
+	Ref string `json:"ref"`
+}
+
+// SecretResponse holds the secret, read once and kept until the config is
+// reloaded
+type SecretResponse struct {
+	Value string `json:"value"`
+}
+
+// IsCompatibleVersion reports whether the daemon can answer a request sent
+// with version: the major versions match, or the client sent none
+func IsCompatibleVersion(version string) bool {
+	if version == "" {
+		return true
+	}
+	major, _, _ := strings.Cut(version, ".")
+	want, _, _ := strings.Cut(ProtocolVersion, ".")
+	return major == want
+}
+
+// GetRuntimeDir returns the private directory (mode 0700) holding the
+// daemon's socket, PID and lock files.
 // Uses XDG_RUNTIME_DIR if available, falls back to /tmp with UID suffix.
-func GetSocketPath() string {
+func GetRuntimeDir() string {
 	// Prefer XDG_RUNTIME_DIR (usually /run/user/1000)
 	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
-		return filepath.Join(runtimeDir, "claude-notifications.sock")
+		return filepath.Join(runtimeDir, "claude-notifications")
 	}
 
 	// Fallback to /tmp with UID for isolation
-	return fmt.Sprintf("/tmp/claude-notifications-%d.sock", os.Getuid())
+	return fmt.Sprintf("/tmp/claude-notifications-%d", os.Getuid())
+}
+
+// GetSocketPath returns the Unix socket path for the daemon.
+func GetSocketPath() string {
+	return filepath.Join(GetRuntimeDir(), "daemon.sock")
 }
 
 // GetPidFilePath returns the path to the daemon's PID file.
 func GetPidFilePath() string {
+	return filepath.Join(GetRuntimeDir(), "daemon.pid")
+}
+
+// GetLockFilePath returns the path of the lock file held by the running daemon.
+func GetLockFilePath() string {
+	return filepath.Join(GetRuntimeDir(), "daemon.lock")
+}
+
+// GetDashboardURLPath returns the path of the file holding the dashboard's
+// URL with its access token, while the daemon serves the dashboard.
+func GetDashboardURLPath() string {
+	return filepath.Join(GetRuntimeDir(), "dashboard.url")
+}
+
+// legacyRuntimePath returns the path of a daemon file of versions before
+// the runtime directory, e.g. "pid" for $XDG_RUNTIME_DIR/claude-notifications.pid
+func legacyRuntimePath(ext string) string {
 	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
-		return filepath.Join(runtimeDir, "claude-notifications.pid")
+		return filepath.Join(runtimeDir, "claude-notifications."+ext)
+	}
+	return fmt.Sprintf("/tmp/claude-notifications-%d.%s", os.Getuid(), ext)
+}
+
+// ParseUrgency converts an urgency name to the freedesktop urgency level.
+// Unknown or empty values map to normal urgency.
+func ParseUrgency(urgency string) notify.Urgency {
+	switch strings.ToLower(urgency) {
+	case UrgencyLow:
+		return notify.UrgencyLow
+	case UrgencyCritical:
+		return notify.UrgencyCritical
+	default:
+		return notify.UrgencyNormal
 	}
-	return fmt.Sprintf("/tmp/claude-notifications-%d.pid", os.Getuid())
 }
@@ -9,6 +9,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/esiqveland/notify"
 )
 
 // --- GetSocketPath tests ---
@@ -17,7 +19,7 @@ func TestGetSocketPath_WithXDGRuntimeDir(t *testing.T) {
 	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
 
 	result := GetSocketPath()
-	expected := filepath.Join("/run/user/1000", "claude-notifications.sock")
+	expected := filepath.Join("/run/user/1000", "claude-notifications", "daemon.sock")
 	if result != expected {
 		t.Errorf("GetSocketPath() = %q, want %q", result, expected)
 	}
@@ -28,7 +30,7 @@ func TestGetSocketPath_WithoutXDGRuntimeDir(t *testing.T) {
 	os.Unsetenv("XDG_RUNTIME_DIR")
 
 	result := GetSocketPath()
-	expected := fmt.Sprintf("/tmp/claude-notifications-%d.sock", os.Getuid())
+	expected := fmt.Sprintf("/tmp/claude-notifications-%d/daemon.sock", os.Getuid())
 	if result != expected {
 		t.Errorf("GetSocketPath() = %q, want %q", result, expected)
 	}
@@ -64,7 +66,7 @@ func TestGetPidFilePath_WithXDGRuntimeDir(t *testing.T) {
 	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
 
 	result := GetPidFilePath()
-	expected := filepath.Join("/run/user/1000", "claude-notifications.pid")
+	expected := filepath.Join("/run/user/1000", "claude-notifications", "daemon.pid")
 	if result != expected {
 		t.Errorf("GetPidFilePath() = %q, want %q", result, expected)
 	}
@@ -75,7 +77,7 @@ func TestGetPidFilePath_WithoutXDGRuntimeDir(t *testing.T) {
 	os.Unsetenv("XDG_RUNTIME_DIR")
 
 	result := GetPidFilePath()
-	expected := fmt.Sprintf("/tmp/claude-notifications-%d.pid", os.Getuid())
+	expected := fmt.Sprintf("/tmp/claude-notifications-%d/daemon.pid", os.Getuid())
 	if result != expected {
 		t.Errorf("GetPidFilePath() = %q, want %q", result, expected)
 	}
@@ -150,6 +152,112 @@ func TestRequest_JSONRoundtrip_Notify(t *testing.T) {
 	}
 }
 
+func TestRequest_JSONRoundtrip_NotifyUrgencyAndReplace(t *testing.T) {
+	req := Request{
+		Type:    MessageTypeNotify,
+		Version: ProtocolVersion,
+		Notify: &NotifyRequest{
+			Title:      "Updated",
+			Urgency:    UrgencyCritical,
+			ReplacesID: 42,
+		},
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"replaces_id":42`) {
+		t.Errorf("JSON should contain replaces_id, got: %s", data)
+	}
+
+	var decoded Request
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded.Notify.Urgency != UrgencyCritical {
+		t.Errorf("Urgency = %q, want %q", decoded.Notify.Urgency, UrgencyCritical)
+	}
+	if decoded.Notify.ReplacesID != 42 {
+		t.Errorf("ReplacesID = %d, want 42", decoded.Notify.ReplacesID)
+	}
+}
+
+func TestRequest_JSONOmitsEmptyUrgencyAndReplace(t *testing.T) {
+	data, err := json.Marshal(NotifyRequest{Title: "t"})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	for _, key := range []string{"urgency", "replaces_id"} {
+		if strings.Contains(string(data), key) {
+			t.Errorf("JSON should omit empty %s, got: %s", key, data)
+		}
+	}
+}
+
+func TestRequest_JSONRoundtrip_NotifyTmux(t *testing.T) {
+	data, err := json.Marshal(NotifyRequest{
+		Title:      "t",
+		TmuxPane:   "%42",
+		TmuxSocket: "/tmp/tmux-1000/default",
+	})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var decoded NotifyRequest
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded.TmuxPane != "%42" || decoded.TmuxSocket != "/tmp/tmux-1000/default" {
+		t.Errorf("tmux fields = %q, %q; want %%42, /tmp/tmux-1000/default", decoded.TmuxPane, decoded.TmuxSocket)
+	}
+
+	empty, _ := json.Marshal(NotifyRequest{Title: "t"})
+	if strings.Contains(string(empty), "tmux") {
+		t.Errorf("JSON should omit empty tmux fields, got: %s", empty)
+	}
+}
+
+func TestRequest_JSONRoundtrip_NotifyZellij(t *testing.T) {
+	data, err := json.Marshal(NotifyRequest{Title: "t", ZellijSession: "dev", ZellijTab: "Tab #2"})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var decoded NotifyRequest
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded.ZellijSession != "dev" || decoded.ZellijTab != "Tab #2" {
+		t.Errorf("zellij fields = %q, %q; want dev, Tab #2", decoded.ZellijSession, decoded.ZellijTab)
+	}
+}
+
+func TestRequest_JSONRoundtrip_Close(t *testing.T) {
+	req := Request{
+		Type:    MessageTypeClose,
+		Version: ProtocolVersion,
+		Close:   &CloseRequest{NotificationID: 7},
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var decoded Request
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded.Type != MessageTypeClose {
+		t.Errorf("Type = %q, want %q", decoded.Type, MessageTypeClose)
+	}
+	if decoded.Close == nil || decoded.Close.NotificationID != 7 {
+		t.Errorf("Close = %+v, want NotificationID 7", decoded.Close)
+	}
+}
+
 func TestRequest_JSONRoundtrip_Ping(t *testing.T) {
 	req := Request{
 		Type:    MessageTypePing,
@@ -198,6 +306,56 @@ func TestRequest_JSONRoundtrip_Stop(t *testing.T) {
 	}
 }
 
+func TestRequest_JSONRoundtrip_FocusAndMute(t *testing.T) {
+	req := Request{
+		Type:    MessageTypeFocus,
+		Version: ProtocolVersion,
+		Focus:   &FocusRequest{SessionID: "abc-123"},
+		Mute:    &MuteRequest{Seconds: 1800},
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := `{"type":"focus","focus":{"session_id":"abc-123"},"mute":{"seconds":1800},"version":"1.9"}`
+	if string(data) != want {
+		t.Errorf("JSON = %s, want %s", data, want)
+	}
+
+	var decoded Request
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded.Focus == nil || decoded.Focus.SessionID != "abc-123" {
+		t.Errorf("Focus = %+v", decoded.Focus)
+	}
+	if decoded.Mute == nil || decoded.Mute.Seconds != 1800 || decoded.Mute.Unmute {
+		t.Errorf("Mute = %+v", decoded.Mute)
+	}
+}
+
+func TestResponse_JSONStatusOmitsUnsetTimes(t *testing.T) {
+	resp := Response{
+		Type:   MessageTypeStatus,
+		Status: &StatusResponse{Version: ProtocolVersion, PID: 42, Uptime: 10},
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	jsonStr := string(data)
+	for _, key := range []string{"last_notification", "muted_until", "muted_reason"} {
+		if strings.Contains(jsonStr, key) {
+			t.Errorf("JSON should not contain %q when unset: %s", key, jsonStr)
+		}
+	}
+	if !strings.Contains(jsonStr, `"muted":false`) {
+		t.Errorf("JSON should always contain muted: %s", jsonStr)
+	}
+}
+
 func TestResponse_JSONRoundtrip_WithError(t *testing.T) {
 	resp := Response{
 		Type:  MessageTypeNotify,
@@ -313,7 +471,11 @@ func TestProtocolVersion(t *testing.T) {
 
 func TestMessageTypes(t *testing.T) {
 	// Ensure message types are distinct
-	types := []MessageType{MessageTypeNotify, MessageTypePing, MessageTypeStop}
+	types := []MessageType{
+		MessageTypeNotify, MessageTypePing, MessageTypeStop, MessageTypeClose,
+		MessageTypeFocus, MessageTypeStatus, MessageTypeSessions, MessageTypeMute, MessageTypeShutdown,
+		MessageTypeReport, MessageTypeDismiss, MessageTypeAcked, MessageTypeSession, MessageTypeHook,
+	}
 	seen := make(map[MessageType]bool)
 
 	for _, mt := range types {
@@ -337,6 +499,66 @@ func TestMessageTypes_Values(t *testing.T) {
 	if MessageTypeStop != "stop" {
 		t.Errorf("MessageTypeStop = %q, want %q", MessageTypeStop, "stop")
 	}
+	if MessageTypeClose != "close" {
+		t.Errorf("MessageTypeClose = %q, want %q", MessageTypeClose, "close")
+	}
+	// Documented in docs/DAEMON_PROTOCOL.md; renaming breaks third-party clients
+	documented := map[MessageType]string{
+		MessageTypeFocus:    "focus",
+		MessageTypeStatus:   "status",
+		MessageTypeSessions: "list_sessions",
+		MessageTypeMute:     "mute",
+		MessageTypeShutdown: "shutdown",
+		MessageTypeSession:  "update_session",
+		MessageTypeHook:     "handle_hook",
+	}
+	for mt, want := range documented {
+		if string(mt) != want {
+			t.Errorf("MessageType = %q, want %q", mt, want)
+		}
+	}
+}
+
+func TestIsCompatibleVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"", true},
+		{"1.0", true},
+		{"1.1", true},
+		{"1.9", true},
+		{"1", true},
+		{"2.0", false},
+		{"0.9", false},
+	}
+	for _, tt := range tests {
+		if got := IsCompatibleVersion(tt.version); got != tt.want {
+			t.Errorf("IsCompatibleVersion(%q) = %v, want %v", tt.version, got, tt.want)
+		}
+	}
+}
+
+// --- Urgency tests ---
+
+func TestParseUrgency(t *testing.T) {
+	tests := []struct {
+		input string
+		want  notify.Urgency
+	}{
+		{"low", notify.UrgencyLow},
+		{"normal", notify.UrgencyNormal},
+		{"critical", notify.UrgencyCritical},
+		{"CRITICAL", notify.UrgencyCritical},
+		{"", notify.UrgencyNormal},
+		{"bogus", notify.UrgencyNormal},
+	}
+
+	for _, tt := range tests {
+		if got := ParseUrgency(tt.input); got != tt.want {
+			t.Errorf("ParseUrgency(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
 }
 
 // --- Error types tests ---
@@ -364,3 +586,37 @@ func TestErrorTypes_Distinct(t *testing.T) {
 		t.Error("ErrDaemonNotAvailable and ErrDaemonNotRunning should have distinct messages")
 	}
 }
+
+func TestRequest_JSONRoundtrip_Report(t *testing.T) {
+	req := Request{
+		Type:    MessageTypeReport,
+		Version: ProtocolVersion,
+		Report:  &ReportRequest{Backend: "slack", Success: true, DurationMs: 250},
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := `{"type":"report_delivery","report":{"backend":"slack","success":true,"duration_ms":250},"version":"1.9"}`
+	if string(data) != want {
+		t.Errorf("JSON = %s, want %s", data, want)
+	}
+}
+
+func TestRequest_JSONRoundtrip_Dismiss(t *testing.T) {
+	req := Request{
+		Type:    MessageTypeDismiss,
+		Version: ProtocolVersion,
+		Dismiss: &DismissRequest{CoalesceKey: "abc-123"},
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := `{"type":"dismiss","dismiss":{"coalesce_key":"abc-123"},"version":"1.9"}`
+	if string(data) != want {
+		t.Errorf("JSON = %s, want %s", data, want)
+	}
+}
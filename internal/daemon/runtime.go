@@ -0,0 +1,116 @@
+//go:build linux
+
+// ABOUTME: Private runtime directory of the daemon and peer credential checks on its socket.
+// ABOUTME: Only the user running the daemon may connect, create its files or talk to it.
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"syscall"
+)
+
+// secureRuntimeDir checks that dir is a directory of the current user
+// that no one else can enter, tightening its mode if needed. With create,
+// a missing directory is created with mode 0700. A directory owned by
+// another user is refused: in /tmp, anyone could create it first to
+// receive the hooks' notifications or serve a fake daemon.
+func secureRuntimeDir(dir string, create bool) error {
+	if create {
+		if err := os.Mkdir(dir, 0700); err != nil && !errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("failed to create runtime directory: %w", err)
+		}
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to check runtime directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("runtime directory %s is not a directory", dir)
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && int(st.Uid) != os.Getuid() {
+		return fmt.Errorf("runtime directory %s belongs to uid %d, not %d", dir, st.Uid, os.Getuid())
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		if err := os.Chmod(dir, 0700); err != nil {
+			return fmt.Errorf("failed to restrict runtime directory: %w", err)
+		}
+	}
+	return nil
+}
+
+// peerUID returns the user ID of the process at the other end of a Unix
+// socket connection (SO_PEERCRED)
+func peerUID(conn net.Conn) (int, error) {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return -1, fmt.Errorf("not a Unix socket connection")
+	}
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return -1, err
+	}
+	var cred *syscall.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return -1, err
+	}
+	if credErr != nil {
+		return -1, fmt.Errorf("failed to read peer credentials: %w", credErr)
+	}
+	return int(cred.Uid), nil
+}
+
+// allowPeer reports whether a client may use the daemon: only processes
+// of the user running it. The socket's mode already keeps others out;
+// this also holds when the socket came from systemd or its mode changed.
+func allowPeer(conn net.Conn) bool {
+	uid, err := peerUID(conn)
+	if err != nil {
+		log.Printf("[WARN] Rejected connection: %v", err)
+		return false
+	}
+	if uid != os.Getuid() {
+		log.Printf("[WARN] Rejected connection from uid %d", uid)
+		return false
+	}
+	return true
+}
+
+// stopLegacyDaemon stops a daemon of an earlier version, which kept its
+// files directly in $XDG_RUNTIME_DIR or /tmp, so that it does not keep
+// running next to this one. It is only signaled while it holds its lock
+// and its PID file belongs to the current user.
+func stopLegacyDaemon() {
+	lock, err := os.OpenFile(legacyRuntimePath("lock"), os.O_RDWR, 0)
+	if err != nil {
+		return
+	}
+	defer lock.Close()
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err == nil {
+		return // No daemon holds it; the kernel unlocks when lock is closed
+	}
+
+	pidPath := legacyRuntimePath("pid")
+	info, err := os.Lstat(pidPath)
+	if err != nil || !info.Mode().IsRegular() {
+		return
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); !ok || int(st.Uid) != os.Getuid() {
+		return
+	}
+	pid := readPidFile(pidPath)
+	if pid <= 0 || pid == os.Getpid() {
+		return
+	}
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+		log.Printf("[WARN] Failed to stop the daemon of an earlier version (pid %d): %v", pid, err)
+		return
+	}
+	log.Printf("[INFO] Stopped the daemon of an earlier version (pid %d)", pid)
+}
@@ -0,0 +1,135 @@
+//go:build linux
+
+package daemon
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSecureRuntimeDir(t *testing.T) {
+	base := t.TempDir()
+	dir := filepath.Join(base, "claude-notifications")
+
+	if err := secureRuntimeDir(dir, false); err == nil {
+		t.Error("a missing directory should fail without create")
+	}
+	if err := secureRuntimeDir(dir, true); err != nil {
+		t.Fatalf("secureRuntimeDir(create) error = %v", err)
+	}
+	if info, _ := os.Stat(dir); info.Mode().Perm() != 0700 {
+		t.Errorf("mode = %v, want 0700", info.Mode().Perm())
+	}
+
+	if err := os.Chmod(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := secureRuntimeDir(dir, true); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(dir); info.Mode().Perm() != 0700 {
+		t.Errorf("mode after tightening = %v, want 0700", info.Mode().Perm())
+	}
+
+	link := filepath.Join(base, "link")
+	if err := os.Symlink(dir, link); err != nil {
+		t.Fatal(err)
+	}
+	if err := secureRuntimeDir(link, true); err == nil {
+		t.Error("a symlink should be refused")
+	}
+
+	if os.Getuid() == 0 {
+		other := filepath.Join(base, "other")
+		if err := os.Mkdir(other, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chown(other, 4242, -1); err != nil {
+			t.Fatal(err)
+		}
+		if err := secureRuntimeDir(other, true); err == nil {
+			t.Error("a directory of another user should be refused")
+		}
+	}
+}
+
+func TestPeerUID(t *testing.T) {
+	l, err := net.Listen("unix", filepath.Join(t.TempDir(), "test.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	client, err := net.Dial("unix", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	uid, err := peerUID(conn)
+	if err != nil || uid != os.Getuid() {
+		t.Errorf("peerUID() = %d, %v; want %d", uid, err, os.Getuid())
+	}
+	if !allowPeer(conn) {
+		t.Error("a client of the same user should be allowed")
+	}
+
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	if allowPeer(a) {
+		t.Error("a connection without peer credentials should be rejected")
+	}
+}
+
+func TestStopLegacyDaemon(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	legacy := exec.Command("sleep", "30")
+	if err := legacy.Start(); err != nil {
+		t.Skipf("cannot start a process: %v", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- legacy.Wait() }()
+	t.Cleanup(func() { _ = legacy.Process.Kill() })
+	if err := os.WriteFile(legacyRuntimePath("pid"), []byte(strconv.Itoa(legacy.Process.Pid)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Not stopped while nobody holds the lock
+	if err := os.WriteFile(legacyRuntimePath("lock"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	stopLegacyDaemon()
+	select {
+	case <-exited:
+		t.Fatal("a process not holding the legacy lock was stopped")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	lock, err := os.OpenFile(legacyRuntimePath("lock"), os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Close()
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		t.Fatal(err)
+	}
+	stopLegacyDaemon()
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the legacy daemon holding its lock was not stopped")
+	}
+}
@@ -0,0 +1,63 @@
+//go:build linux
+
+// ABOUTME: Reads the command and keyring secrets of the config for hooks, once per daemon.
+// ABOUTME: Hooks run in new processes, so without it every event would run "pass show" again.
+package daemon
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/777genius/claude-notifications/internal/platform"
+)
+
+// secretCache keeps the secrets read from commands and the keyring until
+// the config is reloaded. Failures are not kept, so the next hook tries
+// again, e.g. once the keyring is unlocked.
+type secretCache struct {
+	resolve func(string) (string, error) // platform.ResolveSecret; replaced in tests
+	values  map[string]string
+	mu      sync.Mutex
+}
+
+// newSecretCache creates an empty cache reading secrets with platform.ResolveSecret
+func newSecretCache() *secretCache {
+	return &secretCache{resolve: platform.ResolveSecret, values: make(map[string]string)}
+}
+
+// get returns the secret ref names, reading it on first use. Lookups run
+// one at a time, so concurrent hooks wait for one passphrase prompt.
+func (c *secretCache) get(ref string) (string, error) {
+	if !platform.IsSecretRef(ref) {
+		return "", fmt.Errorf("not a command or keyring reference")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if value, ok := c.values[ref]; ok {
+		return value, nil
+	}
+	value, err := c.resolve(ref)
+	if err != nil {
+		return "", err
+	}
+	c.values[ref] = value
+	return value, nil
+}
+
+// resolveSecret returns a secret for a resolve_secret request. Only the
+// references in the daemon's own config are read, so a client cannot make
+// the daemon run any other command.
+func (s *Server) resolveSecret(ref string) (string, error) {
+	if s.config == nil || !slices.Contains(s.config.Config().SecretRefs(), ref) {
+		return "", fmt.Errorf("not a secret of the daemon's config")
+	}
+	return s.secrets.get(ref)
+}
+
+// clear forgets every secret, so the next hook reads them again
+func (c *secretCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values = make(map[string]string)
+}
@@ -5,24 +5,41 @@
 package daemon
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"sort"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/dnd"
+	"github.com/777genius/claude-notifications/internal/sessions"
+	"github.com/777genius/claude-notifications/internal/webhook"
 	"github.com/esiqveland/notify"
 	"github.com/godbus/dbus/v5"
 )
 
 // focusInfo holds the focus target and folder for a notification.
 type focusInfo struct {
-	target string
-	folder string
+	target         string
+	folder         string
+	tmuxPane       string // tmux pane to select after focusing (empty = not in tmux)
+	tmuxSocket     string
+	zellijSession  string // Zellij session and tab to switch to (empty = not in Zellij)
+	zellijTab      string
+	window         *sessions.Window // Window pinned to the session (nil = search by target)
+	coalesceKey    string           // Session the notification belongs to, for dismiss requests
+	dismissOnFocus bool             // Close once the user returns to window
+	away           bool             // window was seen inactive since the notification was shown
 }
 
 // Server is the notification daemon server
@@ -30,33 +47,88 @@ type Server struct {
 	conn      *dbus.Conn
 	notifier  notify.Notifier
 	listener  net.Listener
+	activated bool     // The listener came from systemd socket activation, which owns the socket file
+	lock      *os.File // Held while running, so a second daemon refuses to start
 	startTime time.Time
 
+	// Whether the notification server renders action buttons
+	supportsActions bool
+
+	// Config, reloaded on SIGHUP and when a config file changes
+	config *config.Watcher
+
 	// Focus context mapping: notification ID -> focus info
 	focusCtx   map[uint32]focusInfo
 	focusCtxMu sync.RWMutex
 
+	// Last acknowledgement per coalesce key, asked for before escalating
+	acks   map[string]time.Time
+	acksMu sync.Mutex
+
+	// Where each running session runs, updated by hooks
+	sessions *sessionMap
+
+	// Hooks handed over by handle-hook, run in worker processes
+	hooks *hookRunner
+
+	// Secrets read from commands and the keyring for hooks
+	secrets *secretCache
+
+	// Burst control: serializes planning, sending and recording notifications
+	throttle   *throttle
+	throttleMu sync.Mutex
+
+	// Notifications being delivered; those not yet sent are saved for the
+	// next daemon when the drain timeout runs out during shutdown
+	inflight    map[uint64]inflightNotification
+	inflightSeq uint64
+	inflightMu  sync.Mutex
+
+	// Delivery statistics for status requests
+	sent     int
+	lastSent time.Time
+	statsMu  sync.Mutex
+
+	// Prometheus metrics, served over HTTP when metricsAddress is set
+	metrics        *metrics
+	metricsAddress string
+	metricsSrv     *http.Server
+
+	// Web dashboard, served over HTTP when dashboardAddress is set
+	dashboardAddress string
+	dashboardSrv     *http.Server
+
+	// HTTP API for other tools, served when apiAddress is set
+	apiAddress string
+	apiToken   string
+	apiSrv     *http.Server
+
 	// Idle timeout for auto-shutdown
 	idleTimeout  time.Duration
 	lastActivity time.Time
 	activityMu   sync.Mutex
 
 	// Shutdown handling
-	done     chan struct{}
-	wg       sync.WaitGroup
-	shutdown bool
-	mu       sync.Mutex
+	done         chan struct{}
+	wg           sync.WaitGroup
+	shutdown     bool // No longer accepting requests (done is closed)
+	closed       bool // Shutdown ran
+	drainTimeout time.Duration
+	mu           sync.Mutex
 }
 
 // ServerConfig contains server configuration options
 type ServerConfig struct {
-	IdleTimeout time.Duration // Auto-shutdown after this duration of inactivity (0 = disabled)
+	IdleTimeout  time.Duration // Auto-shutdown after this duration of inactivity (0 = disabled)
+	DrainTimeout time.Duration // How long shutdown waits for notifications being delivered
+	PluginRoot   string        // Plugin directory holding the legacy config/config.json
 }
 
 // DefaultServerConfig returns the default server configuration
 func DefaultServerConfig() ServerConfig {
 	return ServerConfig{
-		IdleTimeout: 5 * time.Minute,
+		IdleTimeout:  5 * time.Minute,
+		DrainTimeout: 5 * time.Second,
 	}
 }
 
@@ -72,10 +144,17 @@ func NewServer(cfg ServerConfig) (*Server, error) {
 		conn:         conn,
 		startTime:    time.Now(),
 		focusCtx:     make(map[uint32]focusInfo),
+		acks:         make(map[string]time.Time),
+		sessions:     loadSessionMap(time.Now()),
+		throttle:     newThrottle(),
+		metrics:      newMetrics(),
+		secrets:      newSecretCache(),
 		idleTimeout:  cfg.IdleTimeout,
+		drainTimeout: cfg.DrainTimeout,
 		lastActivity: time.Now(),
 		done:         make(chan struct{}),
 	}
+	s.hooks = newHookRunner(&s.wg)
 
 	// Create notifier with action callback
 	notifier, err := notify.New(conn,
@@ -88,57 +167,172 @@ func NewServer(cfg ServerConfig) (*Server, error) {
 	}
 	s.notifier = notifier
 
+	watcher, warnings := config.NewWatcher(cfg.PluginRoot, s.onConfigReload)
+	for _, w := range warnings {
+		log.Printf("[WARN] Config: %v", w)
+	}
+	s.config = watcher
+	SetTerminals(watcher.Config().Notifications.Desktop.Terminals)
+	if m := watcher.Config().Metrics; m.Enabled {
+		s.metricsAddress = m.Address
+	}
+	if d := watcher.Config().Dashboard; d.Enabled {
+		s.dashboardAddress = d.Address
+	}
+	if a := watcher.Config().API; a.Enabled {
+		token, err := config.ResolveSecretRef(a.Token, s.secrets.get)
+		if err != nil {
+			// An empty token rejects every request
+			log.Printf("[WARN] Config: api.token: %v", err)
+		}
+		s.apiAddress, s.apiToken = a.Address, token
+	}
+
+	// Detect action button support (body clicks still invoke "default" without it)
+	if caps, err := notifier.GetCapabilities(); err != nil {
+		log.Printf("[WARN] Failed to get notification server capabilities: %v", err)
+	} else {
+		s.supportsActions = hasCapability(caps, "actions")
+	}
+
 	return s, nil
 }
 
 // Run starts the daemon server
 func (s *Server) Run() error {
 	socketPath := GetSocketPath()
+	pidPath := GetPidFilePath()
+	if err := secureRuntimeDir(GetRuntimeDir(), true); err != nil {
+		return err
+	}
 
-	// Remove existing socket
-	os.Remove(socketPath)
+	// Only one daemon per user; the lock dies with its holder
+	lock, err := acquireLock(GetLockFilePath(), pidPath)
+	if err != nil {
+		return err
+	}
+	s.lock = lock
+	stopLegacyDaemon()
 
-	// Create listener
-	listener, err := net.Listen("unix", socketPath)
+	// Use the socket from systemd socket activation, or create one
+	listener, err := activationListener()
 	if err != nil {
-		return fmt.Errorf("failed to create socket: %w", err)
+		lock.Close()
+		return err
 	}
-	s.listener = listener
+	if listener != nil {
+		s.activated = true
+		socketPath = listener.Addr().String()
+	} else {
+		// A daemon that crashed left its socket behind
+		removed, err := removeStaleSocket(socketPath)
+		if err != nil {
+			lock.Close()
+			return err
+		}
+		if removed {
+			log.Printf("[INFO] Removed stale socket %s left by a previous daemon", socketPath)
+		}
+
+		listener, err = net.Listen("unix", socketPath)
+		if err != nil {
+			lock.Close()
+			return fmt.Errorf("failed to create socket: %w", err)
+		}
 
-	// Set socket permissions
-	if err := os.Chmod(socketPath, 0600); err != nil {
-		listener.Close()
-		return fmt.Errorf("failed to set socket permissions: %w", err)
+		// Set socket permissions
+		if err := os.Chmod(socketPath, 0600); err != nil {
+			listener.Close()
+			lock.Close()
+			return fmt.Errorf("failed to set socket permissions: %w", err)
+		}
 	}
+	s.listener = listener
 
 	// Write PID file
-	pidPath := GetPidFilePath()
 	if err := os.WriteFile(pidPath, []byte(fmt.Sprintf("%d", os.Getpid())), 0600); err != nil {
 		log.Printf("[WARN] Failed to write PID file: %v", err)
 	}
 
-	log.Printf("[INFO] Daemon started, listening on %s", socketPath)
+	if s.activated {
+		log.Printf("[INFO] Daemon started by socket activation, listening on %s", socketPath)
+	} else {
+		log.Printf("[INFO] Daemon started, listening on %s", socketPath)
+	}
 
 	// Handle signals
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	defer signal.Stop(hupChan)
+
+	// Reload config when its files change
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.config.Run(s.done)
+	}()
+
+	// Serve metrics; a scraped daemon must keep running, so it never idles out
+	if s.metricsAddress != "" {
+		if err := s.startMetrics(); err != nil {
+			log.Printf("[ERROR] Metrics endpoint disabled: %v", err)
+		} else {
+			log.Printf("[INFO] Serving metrics on http://%s/metrics", s.metricsAddress)
+		}
+	}
+
+	// Serve the dashboard; like metrics, it keeps the daemon from idling out
+	if s.dashboardAddress != "" {
+		if err := s.startDashboard(); err != nil {
+			log.Printf("[ERROR] Dashboard disabled: %v", err)
+		} else {
+			log.Printf("[INFO] Serving dashboard on http://%s/ (open the URL printed by \"claude-notifications daemon dashboard\")", s.dashboardAddress)
+		}
+	}
+
+	// Serve the API; clients expect the daemon to be there, so it never idles out either
+	if s.apiAddress != "" {
+		if err := s.startAPI(); err != nil {
+			log.Printf("[ERROR] API disabled: %v", err)
+		} else {
+			log.Printf("[INFO] Serving API on http://%s/", s.apiAddress)
+		}
+	}
 
 	// Start idle timeout checker if enabled
-	if s.idleTimeout > 0 {
+	if s.idleTimeout > 0 && s.metricsSrv == nil && s.dashboardSrv == nil && s.apiSrv == nil {
 		s.wg.Add(1)
 		go s.idleChecker()
 	}
 
+	// Close notifications once the user is back at their window
+	s.wg.Add(1)
+	go s.focusWatcher()
+
 	// Accept connections
 	s.wg.Add(1)
 	go s.acceptLoop()
 
-	// Wait for shutdown signal
-	select {
-	case sig := <-sigChan:
-		log.Printf("[INFO] Received signal %v, shutting down", sig)
-	case <-s.done:
-		log.Printf("[INFO] Shutdown requested")
+	// Deliver what the previous daemon could not before it stopped, or
+	// hooks spooled while none ran
+	s.wg.Add(1)
+	go s.deliverPending()
+
+	// Wait for shutdown signal, reloading config on SIGHUP
+	for running := true; running; {
+		select {
+		case <-hupChan:
+			log.Printf("[INFO] Received SIGHUP, reloading config")
+			s.config.Reload()
+		case sig := <-sigChan:
+			log.Printf("[INFO] Received signal %v, shutting down", sig)
+			running = false
+		case <-s.done:
+			log.Printf("[INFO] Shutdown requested")
+			running = false
+		}
 	}
 
 	return s.Shutdown()
@@ -161,6 +355,10 @@ func (s *Server) acceptLoop() {
 			log.Printf("[ERROR] Accept error: %v", err)
 			continue
 		}
+		if !allowPeer(conn) {
+			conn.Close()
+			continue
+		}
 
 		s.wg.Add(1)
 		go s.handleConnection(conn)
@@ -189,6 +387,11 @@ func (s *Server) handleConnection(conn net.Conn) {
 		return
 	}
 
+	if !IsCompatibleVersion(req.Version) {
+		s.sendError(conn, fmt.Sprintf("unsupported protocol version %s (daemon speaks %s)", req.Version, ProtocolVersion))
+		return
+	}
+
 	// Handle request
 	var resp Response
 	resp.Type = req.Type
@@ -199,34 +402,119 @@ func (s *Server) handleConnection(conn net.Conn) {
 			s.sendError(conn, "missing notify payload")
 			return
 		}
-		notifyResp, err := s.handleNotification(req.Notify)
+		key := s.trackInflight(*req.Notify, time.Now())
+		notifyResp, err := s.handleNotification(req.Notify, key)
+		s.untrackInflight(key)
 		if err != nil {
 			resp.Error = err.Error()
 		} else {
 			resp.Notify = notifyResp
 		}
 
+	case MessageTypeClose:
+		if req.Close == nil {
+			s.sendError(conn, "missing close payload")
+			return
+		}
+		if err := s.handleClose(req.Close); err != nil {
+			resp.Error = err.Error()
+		}
+
+	case MessageTypeDismiss:
+		if req.Dismiss == nil {
+			s.sendError(conn, "missing dismiss payload")
+			return
+		}
+		resp.Dismiss = s.handleDismiss(req.Dismiss)
+
+	case MessageTypeAcked:
+		if req.Acked == nil {
+			s.sendError(conn, "missing acknowledged payload")
+			return
+		}
+		resp.Acked = s.handleAcknowledged(req.Acked)
+
+	case MessageTypeSession:
+		if req.Session == nil || req.Session.SessionID == "" {
+			s.sendError(conn, "missing session payload")
+			return
+		}
+		if err := s.sessions.update(req.Session, time.Now()); err != nil {
+			resp.Error = err.Error()
+		}
+
+	case MessageTypeHook:
+		if req.Hook == nil || req.Hook.Event == "" {
+			s.sendError(conn, "missing hook payload")
+			return
+		}
+		resp.Hook = &HookResponse{Queued: s.hooks.enqueue(req.Hook)}
+
+	case MessageTypeSecret:
+		if req.Secret == nil || req.Secret.Ref == "" {
+			s.sendError(conn, "missing secret payload")
+			return
+		}
+		if value, err := s.resolveSecret(req.Secret.Ref); err != nil {
+			resp.Error = err.Error()
+		} else {
+			resp.Secret = &SecretResponse{Value: value}
+		}
+
 	case MessageTypePing:
 		resp.Ping = &PingResponse{
 			Version: ProtocolVersion,
 			Uptime:  int64(time.Since(s.startTime).Seconds()),
 		}
 
-	case MessageTypeStop:
+	case MessageTypeFocus:
+		if req.Focus == nil {
+			s.sendError(conn, "missing focus payload")
+			return
+		}
+		focusResp, err := s.handleFocus(req.Focus)
+		if err != nil {
+			resp.Error = err.Error()
+		} else {
+			resp.Focus = focusResp
+		}
+
+	case MessageTypeStatus:
+		resp.Status = s.handleStatus(time.Now())
+
+	case MessageTypeSessions:
+		resp.Sessions = s.handleSessions(time.Now())
+
+	case MessageTypeMute:
+		if req.Mute == nil {
+			s.sendError(conn, "missing mute payload")
+			return
+		}
+		muteResp, err := s.handleMute(req.Mute, time.Now())
+		if err != nil {
+			resp.Error = err.Error()
+		} else {
+			resp.Mute = muteResp
+		}
+
+	case MessageTypeReport:
+		if req.Report == nil {
+			s.sendError(conn, "missing report payload")
+			return
+		}
+		if s.metrics != nil {
+			s.metrics.observeDelivery(req.Report.Backend, req.Report.Success,
+				time.Duration(req.Report.DurationMs)*time.Millisecond)
+		}
+
+	case MessageTypeStop, MessageTypeShutdown:
 		log.Printf("[INFO] Stop command received")
 		resp.Ping = &PingResponse{
 			Version: ProtocolVersion,
 			Uptime:  int64(time.Since(s.startTime).Seconds()),
 		}
 		// Signal shutdown after sending response
-		defer func() {
-			s.mu.Lock()
-			if !s.shutdown {
-				s.shutdown = true
-				close(s.done)
-			}
-			s.mu.Unlock()
-		}()
+		defer s.stop()
 
 	default:
 		s.sendError(conn, "unknown message type")
@@ -240,8 +528,17 @@ func (s *Server) handleConnection(conn net.Conn) {
 	}
 }
 
-// handleNotification processes a notification request
-func (s *Server) handleNotification(req *NotifyRequest) (*NotifyResponse, error) {
+// handleNotification processes a notification request tracked as key. A
+// notification that shutdown saved for the next daemon before it was sent
+// succeeds with notification ID 0, so the client does not show it too.
+func (s *Server) handleNotification(req *NotifyRequest, key uint64) (*NotifyResponse, error) {
+	if s.config != nil {
+		cfg := s.config.Config()
+		if err := applyConfig(req, cfg, dndActive(cfg, time.Now())); err != nil {
+			return nil, err
+		}
+	}
+
 	// Determine focus target
 	focusTarget := req.FocusTarget
 	if focusTarget == "" {
@@ -254,33 +551,63 @@ func (s *Server) handleNotification(req *NotifyRequest) (*NotifyResponse, error)
 		timeout = 30 * time.Second
 	}
 
+	// Coalesce bursts: replace a notification already on screen instead of stacking
+	s.throttleMu.Lock()
+	defer s.throttleMu.Unlock()
+	if !s.startInflight(key) {
+		log.Printf("[INFO] Notification %q saved for the next daemon", req.Title)
+		return &NotifyResponse{Success: true}, nil
+	}
+	now := time.Now()
+	replacesID, count := s.throttle.plan(req, now)
+
 	// Create notification with click action
 	n := notify.Notification{
 		AppName:       "claude-notifications",
-		Summary:       req.Title,
+		Summary:       coalescedTitle(req.Title, count),
 		Body:          req.Body,
 		ExpireTimeout: timeout,
-		Actions: []notify.Action{
-			{Key: "default", Label: "Focus Terminal"},
-		},
+		Actions:       notificationActions(s.supportsActions),
 		Hints: map[string]dbus.Variant{
 			"desktop-entry":  dbus.MakeVariant(GetDesktopEntryID(focusTarget)),
 			"suppress-sound": dbus.MakeVariant(true),
 		},
+		ReplacesID: replacesID,
 	}
+	n.SetUrgency(ParseUrgency(req.Urgency))
 
 	// Send notification
 	id, err := s.notifier.SendNotification(n)
+	if s.metrics != nil {
+		s.metrics.observeNotification(err)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to send notification: %w", err)
 	}
+	s.throttle.record(req, id, replacesID != 0, count, now)
+
+	s.statsMu.Lock()
+	s.sent++
+	s.lastSent = now
+	s.statsMu.Unlock()
 
 	// Store focus context
 	s.focusCtxMu.Lock()
-	s.focusCtx[id] = focusInfo{target: focusTarget, folder: req.FocusFolder}
+	s.focusCtx[id] = focusInfo{
+		target:         focusTarget,
+		folder:         req.FocusFolder,
+		tmuxPane:       req.TmuxPane,
+		tmuxSocket:     req.TmuxSocket,
+		zellijSession:  req.ZellijSession,
+		zellijTab:      req.ZellijTab,
+		window:         req.Window,
+		coalesceKey:    req.CoalesceKey,
+		dismissOnFocus: req.DismissOnFocus,
+	}
 	s.focusCtxMu.Unlock()
 
-	log.Printf("[INFO] Notification sent: ID=%d, focus_target=%s, focus_folder=%s", id, focusTarget, req.FocusFolder)
+	log.Printf("[INFO] Notification sent: ID=%d, replaces=%d, events=%d, urgency=%s, focus_target=%s, focus_folder=%s, tmux_pane=%s, zellij_tab=%s",
+		id, replacesID, count, req.Urgency, focusTarget, req.FocusFolder, req.TmuxPane, req.ZellijTab)
 
 	return &NotifyResponse{
 		Success:        true,
@@ -288,11 +615,371 @@ func (s *Server) handleNotification(req *NotifyRequest) (*NotifyResponse, error)
 	}, nil
 }
 
+// inflightNotification is a notification being delivered
+type inflightNotification struct {
+	pendingNotification
+	sending bool // Its D-Bus send started, so it is no longer saved on shutdown
+}
+
+// trackInflight records a notification being delivered, until untrackInflight
+func (s *Server) trackInflight(req NotifyRequest, received time.Time) uint64 {
+	s.inflightMu.Lock()
+	defer s.inflightMu.Unlock()
+	if s.inflight == nil {
+		s.inflight = make(map[uint64]inflightNotification)
+	}
+	s.inflightSeq++
+	s.inflight[s.inflightSeq] = inflightNotification{pendingNotification: pendingNotification{Request: req, Received: received}}
+	return s.inflightSeq
+}
+
+// startInflight marks the notification as being sent. It returns false
+// when shutdown already saved it for the next daemon, which sends it.
+func (s *Server) startInflight(key uint64) bool {
+	s.inflightMu.Lock()
+	defer s.inflightMu.Unlock()
+	n, ok := s.inflight[key]
+	if !ok {
+		return false
+	}
+	n.sending = true
+	s.inflight[key] = n
+	return true
+}
+
+// untrackInflight forgets a notification once it was delivered or refused
+func (s *Server) untrackInflight(key uint64) {
+	s.inflightMu.Lock()
+	delete(s.inflight, key)
+	s.inflightMu.Unlock()
+}
+
+// inflightCount returns the number of notifications being delivered
+func (s *Server) inflightCount() int {
+	s.inflightMu.Lock()
+	defer s.inflightMu.Unlock()
+	return len(s.inflight)
+}
+
+// savePending saves the notifications whose sending has not started, in
+// the order they arrived, for the next daemon to send. They are untracked,
+// so they are not sent here too should the daemon get to them before it
+// exits; those already being sent are left to finish.
+func (s *Server) savePending() {
+	s.inflightMu.Lock()
+	keys := make([]uint64, 0, len(s.inflight))
+	for key, n := range s.inflight {
+		if !n.sending {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	list := make([]pendingNotification, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, s.inflight[key].pendingNotification)
+		delete(s.inflight, key)
+	}
+	s.inflightMu.Unlock()
+	if len(list) == 0 {
+		return
+	}
+
+	path, err := pendingPath()
+	if err == nil {
+		err = savePending(path, list)
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to save %d undelivered notification(s): %v", len(list), err)
+		// Still try to send them before exiting
+		s.inflightMu.Lock()
+		for i, key := range keys {
+			s.inflight[key] = inflightNotification{pendingNotification: list[i]}
+		}
+		s.inflightMu.Unlock()
+		return
+	}
+	log.Printf("[INFO] Saved %d undelivered notification(s) for the next daemon", len(list))
+}
+
+// deliverPending sends the notifications a previous daemon saved when
+// it stopped before delivering them, and those hooks spooled while no
+// daemon could be reached
+func (s *Server) deliverPending() {
+	defer s.wg.Done()
+
+	path, err := pendingPath()
+	if err != nil {
+		return
+	}
+	list, err := loadPending(path, time.Now())
+	if err != nil {
+		log.Printf("[WARN] Failed to load saved notifications: %v", err)
+		return
+	}
+	if len(list) == 0 {
+		return
+	}
+	log.Printf("[INFO] Delivering %d notification(s) saved while no daemon could show them", len(list))
+	for _, p := range list {
+		req := p.Request
+		key := s.trackInflight(req, p.Received)
+		if _, err := s.handleNotification(&req, key); err != nil {
+			log.Printf("[WARN] Failed to deliver saved notification %q: %v", req.Title, err)
+		}
+		s.untrackInflight(key)
+	}
+}
+
+// pendingPath returns the file holding undelivered notifications
+func pendingPath() (string, error) {
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, pendingFileName), nil
+}
+
+// applyConfig applies the daemon's config to a request that was not sent
+// by a hook. Hook requests carry a coalesce key (the session ID) and were
+// already shaped by the hook's config, including the project's, so they
+// are left alone.
+func applyConfig(req *NotifyRequest, cfg *config.Config, dndActive bool) error {
+	if req.CoalesceKey != "" {
+		return nil
+	}
+	desktop := cfg.Notifications.Desktop
+	if !desktop.Enabled {
+		return fmt.Errorf("desktop notifications are disabled in config")
+	}
+	if req.MaxPerMinute == 0 {
+		req.MaxPerMinute = desktop.Throttle.MaxPerMinute
+	}
+	if req.Urgency == "" {
+		req.Urgency = desktop.Urgency
+	}
+	if dndActive && req.Urgency != UrgencyCritical {
+		req.Urgency = UrgencyLow
+	}
+	return nil
+}
+
+// dndActive reports whether do-not-disturb is on, manually or by schedule
+func dndActive(cfg *config.Config, now time.Time) bool {
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		return cfg.Notifications.DND.IsScheduled(now)
+	}
+	return dnd.NewManager(dir, cfg.Notifications.DND).Status(now).Active
+}
+
+// onConfigReload logs the outcome of a config reload, one line per changed
+// key. Secrets are read again after any reload, so SIGHUP picks up a
+// rotated one.
+func (s *Server) onConfigReload(r config.Reload) {
+	s.secrets.clear()
+	for _, w := range r.Warnings {
+		log.Printf("[WARN] Config: %v", w)
+	}
+	if r.Err != nil {
+		log.Printf("[ERROR] Config reload failed, keeping the current config: %v", r.Err)
+		return
+	}
+	if len(r.Changes) == 0 {
+		log.Printf("[INFO] Config reloaded: no changes")
+		return
+	}
+	SetTerminals(r.Config.Notifications.Desktop.Terminals)
+	log.Printf("[INFO] Config reloaded: %d changes", len(r.Changes))
+	for _, c := range r.Changes {
+		log.Printf("[INFO] Config changed: key=%s old=%s new=%s", c.Key, orUnset(c.Old), orUnset(c.New))
+	}
+}
+
+// orUnset shows a missing config value
+func orUnset(v string) string {
+	if v == "" {
+		return "(unset)"
+	}
+	return v
+}
+
+// handleFocus focuses the terminal of a notification, a session, or an
+// explicit target
+func (s *Server) handleFocus(req *FocusRequest) (*FocusResponse, error) {
+	var info focusInfo
+	switch {
+	case req.NotificationID != 0:
+		s.focusCtxMu.RLock()
+		ctx, ok := s.focusCtx[req.NotificationID]
+		s.focusCtxMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("unknown notification %d", req.NotificationID)
+		}
+		info = ctx
+	case req.SessionID != "":
+		session := s.sessions.get(req.SessionID)
+		if session == nil {
+			return nil, fmt.Errorf("unknown session %s", req.SessionID)
+		}
+		if session.Terminal == "" {
+			return nil, fmt.Errorf("session %s has no known terminal", req.SessionID)
+		}
+		info = focusInfo{
+			target:     session.Terminal,
+			folder:     session.Project(),
+			window:     session.Window,
+			tmuxPane:   session.TmuxPane,
+			tmuxSocket: session.TmuxSocket,
+		}
+	case req.Target != "":
+		info = focusInfo{target: req.Target, folder: req.Folder}
+	default:
+		return nil, fmt.Errorf("focus needs notification_id, session_id or target")
+	}
+
+	log.Printf("[INFO] Focus requested: %s (folder: %s)", info.target, info.folder)
+	if err := s.tryFocus(info); err != nil {
+		return nil, fmt.Errorf("focus failed: %w", err)
+	}
+	s.focusMultiplexer(info)
+	return &FocusResponse{Target: info.target, Folder: info.folder}, nil
+}
+
+// handleStatus describes the daemon
+func (s *Server) handleStatus(now time.Time) *StatusResponse {
+	resp := &StatusResponse{
+		Version:         ProtocolVersion,
+		PID:             os.Getpid(),
+		Uptime:          int64(now.Sub(s.startTime).Seconds()),
+		SupportsActions: s.supportsActions,
+	}
+
+	s.statsMu.Lock()
+	resp.NotificationsSent = s.sent
+	if !s.lastSent.IsZero() {
+		last := s.lastSent
+		resp.LastNotification = &last
+	}
+	s.statsMu.Unlock()
+
+	s.focusCtxMu.RLock()
+	resp.ActiveNotifications = len(s.focusCtx)
+	s.focusCtxMu.RUnlock()
+
+	if mgr, err := s.dndManager(); err == nil {
+		st := mgr.Status(now)
+		resp.Muted, resp.MutedReason, resp.MutedUntil = st.Active, st.Reason, timePtr(st.Until)
+	}
+	return resp
+}
+
+// handleSessions lists the running Claude Code sessions
+func (s *Server) handleSessions(now time.Time) *SessionsResponse {
+	resp := &SessionsResponse{Sessions: []SessionInfo{}}
+	for _, session := range s.sessions.list(now) {
+		resp.Sessions = append(resp.Sessions, SessionInfo{
+			ID:           session.ID,
+			CWD:          session.CWD,
+			Project:      session.Project(),
+			Terminal:     session.Terminal,
+			TerminalPID:  session.TerminalPID,
+			Window:       session.Window,
+			TmuxPane:     session.TmuxPane,
+			StartedAt:    session.StartedAt,
+			LastActivity: session.LastActivity,
+		})
+	}
+	return resp
+}
+
+// handleMute turns do-not-disturb on or off
+func (s *Server) handleMute(req *MuteRequest, now time.Time) (*MuteResponse, error) {
+	mgr, err := s.dndManager()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case req.Unmute:
+		err = mgr.Off(now)
+	case req.Seconds < 0:
+		return nil, fmt.Errorf("mute seconds must be >= 0 (got %d)", req.Seconds)
+	case req.Seconds > 0:
+		err = mgr.On(now.Add(time.Duration(req.Seconds) * time.Second))
+	default:
+		err = mgr.On(time.Time{})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	st := mgr.Status(now)
+	log.Printf("[INFO] Mute requested: muted=%v, reason=%s, until=%v", st.Active, st.Reason, timePtr(st.Until))
+	return &MuteResponse{Muted: st.Active, Reason: st.Reason, Until: timePtr(st.Until)}, nil
+}
+
+// dndManager returns the do-not-disturb manager for the current config
+func (s *Server) dndManager() (*dnd.Manager, error) {
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	cfg := config.DefaultConfig()
+	if s.config != nil {
+		cfg = s.config.Config()
+	}
+	return dnd.NewManager(dir, cfg.Notifications.DND), nil
+}
+
+// timePtr returns nil for the zero time
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// handleClose closes a notification previously sent by the daemon
+func (s *Server) handleClose(req *CloseRequest) error {
+	if _, err := s.notifier.CloseNotification(req.NotificationID); err != nil {
+		return fmt.Errorf("failed to close notification %d: %w", req.NotificationID, err)
+	}
+
+	s.focusCtxMu.Lock()
+	delete(s.focusCtx, req.NotificationID)
+	s.focusCtxMu.Unlock()
+
+	log.Printf("[INFO] Notification closed: ID=%d", req.NotificationID)
+	return nil
+}
+
+// notificationActions returns the actions attached to every notification.
+// The "default" action is always present so clicking the body focuses the terminal;
+// servers that render action buttons additionally get an explicit "Focus" button.
+func notificationActions(supportsActions bool) []notify.Action {
+	actions := []notify.Action{
+		{Key: ActionKeyDefault, Label: "Focus Terminal"},
+	}
+	if supportsActions {
+		actions = append(actions, notify.Action{Key: ActionKeyFocus, Label: "Focus"})
+	}
+	return actions
+}
+
+// hasCapability reports whether the notification server advertises a capability
+func hasCapability(caps []string, capability string) bool {
+	for _, c := range caps {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
 // onActionInvoked is called when a notification action is invoked
 func (s *Server) onActionInvoked(sig *notify.ActionInvokedSignal) {
 	log.Printf("[INFO] ActionInvoked: ID=%d, Action=%s", sig.ID, sig.ActionKey)
 
-	if sig.ActionKey != "default" {
+	if sig.ActionKey != ActionKeyDefault && sig.ActionKey != ActionKeyFocus {
 		return
 	}
 
@@ -300,34 +987,97 @@ func (s *Server) onActionInvoked(sig *notify.ActionInvokedSignal) {
 	s.focusCtxMu.RLock()
 	info, exists := s.focusCtx[sig.ID]
 	s.focusCtxMu.RUnlock()
-	focusTarget := info.target
-	focusFolder := info.folder
 
 	if !exists {
 		log.Printf("[WARN] No focus context for notification %d", sig.ID)
 		return
 	}
+	s.acknowledge(info.coalesceKey, time.Now())
 
 	// Attempt to focus
-	log.Printf("[INFO] Attempting to focus: %s (folder: %s)", focusTarget, focusFolder)
-	if err := TryFocus(focusTarget, focusFolder); err != nil {
+	log.Printf("[INFO] Attempting to focus: %s (folder: %s)", info.target, info.folder)
+	if err := s.tryFocus(info); err != nil {
 		log.Printf("[ERROR] Focus failed: %v", err)
 	} else {
 		log.Printf("[INFO] Focus succeeded")
 	}
 
+	// Switch the multiplexer even if the window could not be raised:
+	// the user may already be looking at the terminal.
+	s.focusMultiplexer(info)
+
 	// Clean up focus context
 	s.focusCtxMu.Lock()
 	delete(s.focusCtx, sig.ID)
 	s.focusCtxMu.Unlock()
 }
 
+// tryFocus focuses a terminal window the way the desktop focus config
+// says, starting with the pinned window, counting each method tried
+func (s *Server) tryFocus(info focusInfo) error {
+	var opts FocusOptions
+	if s.config != nil {
+		opts = NewFocusOptions(s.config.Config().Notifications.Desktop.Focus)
+	}
+	var observe func(method string, err error)
+	if s.metrics != nil {
+		observe = s.metrics.observeFocus
+	}
+	return tryFocus(info.target, info.folder, info.window, opts, observe)
+}
+
+// focusMultiplexer switches tmux or Zellij to the pane/tab the
+// notification came from, stopping tmux or zellij after the exec timeout
+func (s *Server) focusMultiplexer(info focusInfo) {
+	if info.tmuxPane == "" && info.zellijTab == "" {
+		return
+	}
+	timeout := config.DefaultExecTimeout
+	if s.config != nil {
+		timeout = s.config.Config().Notifications.Desktop.ExecTimeoutDuration()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if info.tmuxPane != "" {
+		if err := FocusTmuxPane(ctx, info.tmuxSocket, info.tmuxPane); err != nil {
+			log.Printf("[ERROR] tmux pane focus failed: %v", err)
+		} else {
+			log.Printf("[INFO] tmux pane %s selected", info.tmuxPane)
+		}
+	}
+	if info.zellijTab != "" {
+		if err := FocusZellijTab(ctx, info.zellijSession, info.zellijTab); err != nil {
+			log.Printf("[ERROR] Zellij tab focus failed: %v", err)
+		} else {
+			log.Printf("[INFO] Zellij tab %q selected", info.zellijTab)
+		}
+	}
+}
+
 // onNotificationClosed is called when a notification is closed
 func (s *Server) onNotificationClosed(sig *notify.NotificationClosedSignal) {
 	// Clean up focus context
 	s.focusCtxMu.Lock()
+	info, exists := s.focusCtx[sig.ID]
 	delete(s.focusCtx, sig.ID)
 	s.focusCtxMu.Unlock()
+
+	// Closing the notification by hand means the user saw it
+	if exists && sig.Reason == notify.ReasonDismissedByUser {
+		s.acknowledge(info.coalesceKey, time.Now())
+	}
+
+	// A dismissed or expired notification can no longer absorb new events.
+	// Runs asynchronously: handleNotification holds throttleMu during D-Bus calls,
+	// and blocking the signal callback on it could stall those calls.
+	if s.throttle != nil {
+		go func() {
+			s.throttleMu.Lock()
+			s.throttle.forget(sig.ID)
+			s.throttleMu.Unlock()
+		}()
+	}
 }
 
 // updateActivity updates the last activity timestamp
@@ -353,12 +1103,7 @@ func (s *Server) idleChecker() {
 
 			if idle >= s.idleTimeout {
 				log.Printf("[INFO] Idle timeout reached (%v), shutting down", s.idleTimeout)
-				s.mu.Lock()
-				if !s.shutdown {
-					s.shutdown = true
-					close(s.done)
-				}
-				s.mu.Unlock()
+				s.stop()
 				return
 			}
 
@@ -368,23 +1113,93 @@ func (s *Server) idleChecker() {
 	}
 }
 
-// Shutdown gracefully shuts down the server
+// startMetrics starts serving /metrics on the metrics address
+func (s *Server) startMetrics() error {
+	listener, err := net.Listen("tcp", s.metricsAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.metricsAddress, err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", s.serveMetrics)
+	s.metricsSrv = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.metricsSrv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("[ERROR] Metrics endpoint stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// serveMetrics writes the metrics in the Prometheus text format
+func (s *Server) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	g := gauges{inflight: s.inflightCount(), startTime: s.startTime}
+	if dir, err := config.GetStableConfigDir(); err == nil {
+		if n, err := webhook.NewQueue(dir).Len(); err == nil {
+			g.webhookQueue = n
+		}
+	}
+	s.focusCtxMu.RLock()
+	g.active = len(s.focusCtx)
+	s.focusCtxMu.RUnlock()
+	if mgr, err := s.dndManager(); err == nil {
+		g.muted = mgr.Status(now).Active
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := s.metrics.write(w, g); err != nil {
+		log.Printf("[WARN] Failed to write metrics: %v", err)
+	}
+}
+
+// stop makes the server stop accepting requests; Run then shuts it down
+func (s *Server) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.shutdown {
+		s.shutdown = true
+		close(s.done)
+	}
+}
+
+// Shutdown gracefully shuts down the server: it stops accepting
+// connections, waits up to the drain timeout for notifications being
+// delivered, and saves those still undelivered for the next daemon
 func (s *Server) Shutdown() error {
 	s.mu.Lock()
-	if s.shutdown {
+	if s.closed {
 		s.mu.Unlock()
 		return nil
 	}
-	s.shutdown = true
-	close(s.done)
+	s.closed = true
 	s.mu.Unlock()
+	s.stop()
 
 	// Close listener
 	if s.listener != nil {
 		s.listener.Close()
 	}
+	if s.metricsSrv != nil {
+		s.metricsSrv.Close()
+	}
+	if s.dashboardSrv != nil {
+		s.dashboardSrv.Close()
+		os.Remove(GetDashboardURLPath())
+	}
+	if s.apiSrv != nil {
+		s.apiSrv.Close()
+	}
 
-	// Wait for goroutines with timeout
+	// Wait for requests and hooks being handled, up to the drain timeout
+	if n := s.inflightCount(); n > 0 {
+		log.Printf("[INFO] Draining %d notification(s) being delivered (up to %v)", n, s.drainTimeout)
+	}
+	if n := s.hooks.pending(); n > 0 {
+		log.Printf("[INFO] Waiting for %d hook(s) (up to %v)", n, s.drainTimeout)
+	}
 	done := make(chan struct{})
 	go func() {
 		s.wg.Wait()
@@ -393,8 +1208,12 @@ func (s *Server) Shutdown() error {
 
 	select {
 	case <-done:
-	case <-time.After(5 * time.Second):
-		log.Printf("[WARN] Shutdown timeout, forcing exit")
+	case <-time.After(s.drainTimeout):
+		log.Printf("[WARN] Drain timeout (%v) reached, forcing exit", s.drainTimeout)
+		s.savePending()
+		if n := s.hooks.flush(); n > 0 {
+			log.Printf("[INFO] Started %d waiting hook(s) without the daemon", n)
+		}
 	}
 
 	// Close notifier
@@ -407,9 +1226,14 @@ func (s *Server) Shutdown() error {
 		s.conn.Close()
 	}
 
-	// Clean up socket and PID files
-	os.Remove(GetSocketPath())
+	// Clean up socket and PID files; systemd keeps an activated socket for the next start
+	if !s.activated {
+		os.Remove(GetSocketPath())
+	}
 	os.Remove(GetPidFilePath())
+	if s.lock != nil {
+		s.lock.Close()
+	}
 
 	log.Printf("[INFO] Daemon stopped")
 	return nil
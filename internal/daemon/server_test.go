@@ -0,0 +1,466 @@
+//go:build linux
+
+package daemon
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/sessions"
+	"github.com/esiqveland/notify"
+)
+
+// --- notificationActions tests ---
+
+func TestNotificationActions_WithoutActionSupport(t *testing.T) {
+	actions := notificationActions(false)
+
+	if len(actions) != 1 {
+		t.Fatalf("notificationActions(false) returned %d actions, want 1", len(actions))
+	}
+	if actions[0].Key != ActionKeyDefault {
+		t.Errorf("actions[0].Key = %q, want %q", actions[0].Key, ActionKeyDefault)
+	}
+}
+
+func TestNotificationActions_WithActionSupport(t *testing.T) {
+	actions := notificationActions(true)
+
+	want := []notify.Action{
+		{Key: ActionKeyDefault, Label: "Focus Terminal"},
+		{Key: ActionKeyFocus, Label: "Focus"},
+	}
+	if len(actions) != len(want) {
+		t.Fatalf("notificationActions(true) returned %d actions, want %d", len(actions), len(want))
+	}
+	for i := range want {
+		if actions[i] != want[i] {
+			t.Errorf("actions[%d] = %+v, want %+v", i, actions[i], want[i])
+		}
+	}
+}
+
+// --- hasCapability tests ---
+
+func TestHasCapability(t *testing.T) {
+	caps := []string{"body", "actions", "persistence"}
+
+	if !hasCapability(caps, "actions") {
+		t.Error("hasCapability should find \"actions\"")
+	}
+	if hasCapability(caps, "body-markup") {
+		t.Error("hasCapability should not find \"body-markup\"")
+	}
+	if hasCapability(nil, "actions") {
+		t.Error("hasCapability(nil) should be false")
+	}
+}
+
+// --- onActionInvoked tests ---
+
+func TestOnActionInvoked_IgnoresUnknownAction(t *testing.T) {
+	s := &Server{focusCtx: map[uint32]focusInfo{
+		5: {target: "kitty", folder: "project"},
+	}}
+
+	s.onActionInvoked(&notify.ActionInvokedSignal{ID: 5, ActionKey: "dismiss"})
+
+	if _, ok := s.focusCtx[5]; !ok {
+		t.Error("focus context should be kept when an unrelated action is invoked")
+	}
+}
+
+func TestOnNotificationClosed_RemovesFocusContext(t *testing.T) {
+	s := &Server{focusCtx: map[uint32]focusInfo{
+		5: {target: "kitty", folder: "project"},
+	}}
+
+	s.onNotificationClosed(&notify.NotificationClosedSignal{ID: 5})
+
+	if _, ok := s.focusCtx[5]; ok {
+		t.Error("focus context should be removed when the notification is closed")
+	}
+}
+
+// --- applyConfig tests ---
+
+func TestApplyConfig_LeavesHookRequestsAlone(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Notifications.Desktop.Enabled = false
+	cfg.Notifications.Desktop.Urgency = UrgencyLow
+	req := &NotifyRequest{Title: "t", CoalesceKey: "session-1"}
+
+	if err := applyConfig(req, cfg, true); err != nil {
+		t.Fatalf("applyConfig() error = %v", err)
+	}
+	if req.Urgency != "" || req.MaxPerMinute != 0 {
+		t.Errorf("hook request was changed: %+v", req)
+	}
+}
+
+func TestApplyConfig_FillsDefaults(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Notifications.Desktop.Urgency = UrgencyLow
+	cfg.Notifications.Desktop.Throttle.MaxPerMinute = 7
+
+	req := &NotifyRequest{Title: "t"}
+	if err := applyConfig(req, cfg, false); err != nil {
+		t.Fatalf("applyConfig() error = %v", err)
+	}
+	if req.Urgency != UrgencyLow {
+		t.Errorf("Urgency = %q, want %q", req.Urgency, UrgencyLow)
+	}
+	if req.MaxPerMinute != 7 {
+		t.Errorf("MaxPerMinute = %d, want 7", req.MaxPerMinute)
+	}
+
+	req = &NotifyRequest{Title: "t", Urgency: UrgencyCritical, MaxPerMinute: 2}
+	if err := applyConfig(req, cfg, false); err != nil {
+		t.Fatalf("applyConfig() error = %v", err)
+	}
+	if req.Urgency != UrgencyCritical || req.MaxPerMinute != 2 {
+		t.Errorf("explicit request values were overridden: %+v", req)
+	}
+}
+
+func TestApplyConfig_DND(t *testing.T) {
+	cfg := config.DefaultConfig()
+
+	req := &NotifyRequest{Title: "t", Urgency: UrgencyNormal}
+	if err := applyConfig(req, cfg, true); err != nil {
+		t.Fatalf("applyConfig() error = %v", err)
+	}
+	if req.Urgency != UrgencyLow {
+		t.Errorf("Urgency during DND = %q, want %q", req.Urgency, UrgencyLow)
+	}
+
+	req = &NotifyRequest{Title: "t", Urgency: UrgencyCritical}
+	if err := applyConfig(req, cfg, true); err != nil {
+		t.Fatalf("applyConfig() error = %v", err)
+	}
+	if req.Urgency != UrgencyCritical {
+		t.Errorf("critical Urgency during DND = %q, want %q", req.Urgency, UrgencyCritical)
+	}
+}
+
+func TestApplyConfig_DesktopDisabled(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Notifications.Desktop.Enabled = false
+
+	if err := applyConfig(&NotifyRequest{Title: "t"}, cfg, false); err == nil {
+		t.Error("applyConfig() should refuse when desktop notifications are disabled")
+	}
+}
+
+// --- control protocol tests ---
+
+// newTestServer returns a server without a D-Bus connection, keeping its
+// state in a temporary home directory
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	s := &Server{
+		startTime: time.Now().Add(-time.Minute),
+		focusCtx:  map[uint32]focusInfo{},
+		acks:      map[string]time.Time{},
+		sessions:  loadSessionMap(time.Now()),
+		metrics:   newMetrics(),
+		secrets:   newSecretCache(),
+		done:      make(chan struct{}),
+	}
+	s.hooks = newHookRunner(&s.wg)
+	return s
+}
+
+// roundTrip sends req over a connection handled by s and returns the response
+func roundTrip(t *testing.T, s *Server, req Request) Response {
+	t.Helper()
+	client, server := net.Pipe()
+	defer client.Close()
+
+	s.wg.Add(1)
+	go s.handleConnection(server)
+
+	if err := json.NewEncoder(client).Encode(req); err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	var resp Response
+	if err := json.NewDecoder(client).Decode(&resp); err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	return resp
+}
+
+func TestHandleConnection_RejectsOtherMajorVersion(t *testing.T) {
+	s := newTestServer(t)
+
+	resp := roundTrip(t, s, Request{Type: MessageTypeStatus, Version: "2.0"})
+	if !strings.Contains(resp.Error, "unsupported protocol version 2.0") {
+		t.Errorf("Error = %q, want unsupported protocol version", resp.Error)
+	}
+
+	for _, version := range []string{"", "1.0", ProtocolVersion} {
+		if resp := roundTrip(t, s, Request{Type: MessageTypeStatus, Version: version}); resp.Error != "" {
+			t.Errorf("version %q: Error = %q, want none", version, resp.Error)
+		}
+	}
+}
+
+func TestHandleConnection_Status(t *testing.T) {
+	s := newTestServer(t)
+	s.supportsActions = true
+	s.sent = 3
+	s.lastSent = time.Now()
+	s.focusCtx[7] = focusInfo{target: "kitty"}
+
+	resp := roundTrip(t, s, Request{Type: MessageTypeStatus, Version: ProtocolVersion})
+	if resp.Error != "" || resp.Status == nil {
+		t.Fatalf("status response = %+v", resp)
+	}
+	st := resp.Status
+	if st.Version != ProtocolVersion || st.PID == 0 || st.Uptime < 60 {
+		t.Errorf("status = %+v", st)
+	}
+	if st.NotificationsSent != 3 || st.LastNotification == nil || st.ActiveNotifications != 1 || !st.SupportsActions {
+		t.Errorf("status = %+v", st)
+	}
+	if st.Muted {
+		t.Error("status should not be muted")
+	}
+}
+
+func TestHandleConnection_Mute(t *testing.T) {
+	s := newTestServer(t)
+
+	resp := roundTrip(t, s, Request{Type: MessageTypeMute, Mute: &MuteRequest{Seconds: 600}})
+	if resp.Error != "" || resp.Mute == nil {
+		t.Fatalf("mute response = %+v", resp)
+	}
+	if !resp.Mute.Muted || resp.Mute.Reason != "manual" || resp.Mute.Until == nil {
+		t.Errorf("mute = %+v, want muted manually with an end time", resp.Mute)
+	}
+	if st := roundTrip(t, s, Request{Type: MessageTypeStatus}).Status; !st.Muted {
+		t.Error("status should report muted after mute")
+	}
+
+	resp = roundTrip(t, s, Request{Type: MessageTypeMute, Mute: &MuteRequest{}})
+	if !resp.Mute.Muted || resp.Mute.Until != nil {
+		t.Errorf("mute = %+v, want muted until unmuted", resp.Mute)
+	}
+
+	resp = roundTrip(t, s, Request{Type: MessageTypeMute, Mute: &MuteRequest{Unmute: true}})
+	if resp.Error != "" || resp.Mute.Muted {
+		t.Errorf("unmute response = %+v", resp)
+	}
+
+	if resp := roundTrip(t, s, Request{Type: MessageTypeMute}); resp.Error != "missing mute payload" {
+		t.Errorf("Error = %q, want missing mute payload", resp.Error)
+	}
+}
+
+func TestHandleConnection_ListSessions(t *testing.T) {
+	s := newTestServer(t)
+
+	resp := roundTrip(t, s, Request{Type: MessageTypeSessions})
+	if resp.Error != "" || resp.Sessions == nil || len(resp.Sessions.Sessions) != 0 {
+		t.Fatalf("empty list_sessions response = %+v", resp)
+	}
+
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sessions.NewRegistry(dir).Start("abc-123", "/home/me/api", "kitty", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = roundTrip(t, s, Request{Type: MessageTypeSessions})
+	if resp.Error != "" || len(resp.Sessions.Sessions) != 1 {
+		t.Fatalf("list_sessions response = %+v", resp)
+	}
+	got := resp.Sessions.Sessions[0]
+	if got.ID != "abc-123" || got.Project != "api" || got.Terminal != "kitty" || got.StartedAt.IsZero() {
+		t.Errorf("session = %+v", got)
+	}
+}
+
+func TestHandleConnection_UpdateSession(t *testing.T) {
+	s := newTestServer(t)
+
+	update := &SessionUpdate{SessionID: "abc-123", CWD: "/home/me/api", Terminal: "kitty", TerminalPID: 4242, TmuxPane: "%7"}
+	if resp := roundTrip(t, s, Request{Type: MessageTypeSession, Session: update}); resp.Error != "" {
+		t.Fatalf("update_session response = %+v", resp)
+	}
+	resp := roundTrip(t, s, Request{Type: MessageTypeSessions})
+	if resp.Error != "" || len(resp.Sessions.Sessions) != 1 {
+		t.Fatalf("list_sessions response = %+v", resp)
+	}
+	got := resp.Sessions.Sessions[0]
+	if got.ID != "abc-123" || got.Project != "api" || got.TerminalPID != 4242 || got.TmuxPane != "%7" {
+		t.Errorf("session = %+v", got)
+	}
+
+	if resp := roundTrip(t, s, Request{Type: MessageTypeSession, Session: &SessionUpdate{}}); resp.Error != "missing session payload" {
+		t.Errorf("Error = %q, want missing session payload", resp.Error)
+	}
+}
+
+func TestHandleConnection_FocusErrors(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		focus *FocusRequest
+		want  string
+	}{
+		{nil, "missing focus payload"},
+		{&FocusRequest{}, "focus needs notification_id, session_id or target"},
+		{&FocusRequest{NotificationID: 42}, "unknown notification 42"},
+		{&FocusRequest{SessionID: "nope"}, "unknown session nope"},
+	}
+	for _, tt := range tests {
+		resp := roundTrip(t, s, Request{Type: MessageTypeFocus, Focus: tt.focus})
+		if !strings.Contains(resp.Error, tt.want) {
+			t.Errorf("focus %+v: Error = %q, want %q", tt.focus, resp.Error, tt.want)
+		}
+	}
+}
+
+func TestHandleConnection_Shutdown(t *testing.T) {
+	for _, msgType := range []MessageType{MessageTypeShutdown, MessageTypeStop} {
+		s := newTestServer(t)
+		resp := roundTrip(t, s, Request{Type: msgType})
+		if resp.Error != "" || resp.Ping == nil {
+			t.Errorf("%s response = %+v", msgType, resp)
+		}
+		select {
+		case <-s.done:
+		case <-time.After(time.Second):
+			t.Errorf("%s did not signal shutdown", msgType)
+		}
+	}
+}
+
+func TestShutdown_SavesUndeliveredAfterDrainTimeout(t *testing.T) {
+	s := newTestServer(t)
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	s.drainTimeout = 50 * time.Millisecond
+
+	// A handler stuck delivering a notification
+	received := time.Now().Add(-time.Second)
+	s.trackInflight(NotifyRequest{Title: "first"}, received)
+	s.trackInflight(NotifyRequest{Title: "second"}, received)
+	delivered := s.trackInflight(NotifyRequest{Title: "delivered"}, received)
+	s.untrackInflight(delivered)
+	s.wg.Add(1)
+	defer s.wg.Done()
+
+	if err := s.Shutdown(); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	path, err := pendingPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := loadPending(path, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Request.Title != "first" || list[1].Request.Title != "second" {
+		t.Fatalf("saved notifications = %+v, want first and second", list)
+	}
+	if !list[0].Received.Equal(received) {
+		t.Errorf("Received = %v, want %v", list[0].Received, received)
+	}
+}
+
+func TestShutdown_NothingSavedWhenDrained(t *testing.T) {
+	s := newTestServer(t)
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	s.drainTimeout = time.Second
+
+	if err := s.Shutdown(); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	path, _ := pendingPath()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("pending file should not exist, stat error = %v", err)
+	}
+}
+
+func TestShutdown_CleansUpAfterStopRequest(t *testing.T) {
+	s := newTestServer(t)
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	if err := secureRuntimeDir(GetRuntimeDir(), true); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(GetPidFilePath(), []byte("1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// A stop request or the idle timeout closes done before Run calls Shutdown
+	s.stop()
+	if err := s.Shutdown(); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if _, err := os.Stat(GetPidFilePath()); !os.IsNotExist(err) {
+		t.Errorf("PID file not removed after stop, stat error = %v", err)
+	}
+	// Shutting down twice is a no-op
+	if err := s.Shutdown(); err != nil {
+		t.Errorf("second Shutdown() error = %v", err)
+	}
+}
+
+func TestHandleConnection_ReportDelivery(t *testing.T) {
+	s := newTestServer(t)
+
+	resp := roundTrip(t, s, Request{Type: MessageTypeReport, Version: ProtocolVersion,
+		Report: &ReportRequest{Backend: "slack", Success: false, DurationMs: 1500}})
+	if resp.Error != "" {
+		t.Fatalf("report response = %+v", resp)
+	}
+	if got := s.metrics.deliveries[labelPair{"slack", resultFailure}]; got != 1 {
+		t.Errorf("slack failures = %d, want 1", got)
+	}
+	if h := s.metrics.latency["slack"]; h == nil || h.sum != 1.5 {
+		t.Errorf("slack latency = %+v, want 1.5s", h)
+	}
+
+	resp = roundTrip(t, s, Request{Type: MessageTypeReport, Version: ProtocolVersion})
+	if resp.Error != "missing report payload" {
+		t.Errorf("Error = %q, want missing report payload", resp.Error)
+	}
+}
+
+func TestServeMetrics(t *testing.T) {
+	s := newTestServer(t)
+	s.focusCtx[3] = focusInfo{target: "kitty"}
+	s.metrics.observeDelivery("desktop", true, 40*time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	s.serveMetrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`claude_notifications_deliveries_total{backend="desktop",result="success"} 1`,
+		"claude_notifications_active_notifications 1\n",
+		`claude_notifications_queue_depth{queue="webhook_retry"} 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+}
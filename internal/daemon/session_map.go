@@ -0,0 +1,148 @@
+//go:build linux
+
+// ABOUTME: Live map of running sessions to where they run: project, terminal process, window, tmux pane.
+// ABOUTME: Hooks update it on every event; it is persisted in the session registry across daemon restarts.
+package daemon
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/sessions"
+)
+
+// sessionMap tracks running sessions, backed by the session registry the
+// hooks also write, so sessions survive daemon restarts
+type sessionMap struct {
+	mu      sync.Mutex
+	reg     *sessions.Registry // nil = in memory only
+	entries map[string]*sessions.Session
+}
+
+// loadSessionMap restores the sessions of the registry in the stable config directory
+func loadSessionMap(now time.Time) *sessionMap {
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		log.Printf("[WARN] Sessions are not persisted: %v", err)
+		return newSessionMap(nil, now)
+	}
+	return newSessionMap(sessions.NewRegistry(dir), now)
+}
+
+// newSessionMap creates a map holding the active sessions of reg
+func newSessionMap(reg *sessions.Registry, now time.Time) *sessionMap {
+	m := &sessionMap{reg: reg, entries: make(map[string]*sessions.Session)}
+	m.sync(now)
+	return m
+}
+
+// update merges what a hook knows about a session and persists it
+func (m *sessionMap) update(u *SessionUpdate, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if u.Ended {
+		delete(m.entries, u.SessionID)
+		if m.reg != nil {
+			return m.reg.End(u.SessionID)
+		}
+		return nil
+	}
+
+	s := m.lookup(u.SessionID)
+	if s == nil {
+		s = &sessions.Session{ID: u.SessionID, StartedAt: now}
+		m.entries[u.SessionID] = s
+	}
+	if u.CWD != "" {
+		s.CWD = u.CWD
+	}
+	if u.Terminal != "" {
+		s.Terminal = u.Terminal
+	}
+	if u.TerminalPID != 0 {
+		s.TerminalPID = u.TerminalPID
+	}
+	if u.Window != nil {
+		s.Window = u.Window
+	}
+	// A session can move between panes when resumed, or leave tmux
+	s.TmuxPane, s.TmuxSocket = u.TmuxPane, u.TmuxSocket
+	s.LastActivity = now
+
+	if m.reg != nil {
+		return m.reg.Put(s)
+	}
+	return nil
+}
+
+// get returns a copy of a session (nil = unknown)
+func (m *sessionMap) get(id string) *sessions.Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.lookup(id)
+	if s == nil {
+		return nil
+	}
+	copied := *s
+	return &copied
+}
+
+// list returns the active sessions, oldest first
+func (m *sessionMap) list(now time.Time) []sessions.Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sync(now)
+	list := make([]sessions.Session, 0, len(m.entries))
+	for _, s := range m.entries {
+		list = append(list, *s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].StartedAt.Before(list[j].StartedAt)
+	})
+	return list
+}
+
+// lookup returns a session, reading it from the registry when a hook
+// registered it while the daemon was not running. The caller holds mu.
+func (m *sessionMap) lookup(id string) *sessions.Session {
+	if s, ok := m.entries[id]; ok {
+		return s
+	}
+	if m.reg == nil {
+		return nil
+	}
+	s, err := m.reg.Get(id)
+	if err != nil || s == nil {
+		return nil
+	}
+	m.entries[id] = s
+	return s
+}
+
+// sync catches up with hooks that ran while the daemon was not running:
+// it adds sessions they registered and drops ones that ended or went stale.
+// The caller holds mu, except during construction.
+func (m *sessionMap) sync(now time.Time) {
+	if m.reg == nil {
+		for id, s := range m.entries {
+			if now.Sub(s.LastActivity) > sessions.StaleAfter {
+				delete(m.entries, id)
+			}
+		}
+		return
+	}
+	active, err := m.reg.Active(now)
+	if err != nil {
+		log.Printf("[WARN] Failed to read sessions: %v", err)
+		return
+	}
+	entries := make(map[string]*sessions.Session, len(active))
+	for i := range active {
+		entries[active[i].ID] = &active[i]
+	}
+	m.entries = entries
+}
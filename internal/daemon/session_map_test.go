@@ -0,0 +1,77 @@
+//go:build linux
+
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/sessions"
+)
+
+func TestSessionMap_UpdateMergesAndPersists(t *testing.T) {
+	reg := sessions.NewRegistry(t.TempDir())
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.Local)
+	m := newSessionMap(reg, now)
+
+	window := &sessions.Window{Backend: "sway", ID: "42"}
+	if err := m.update(&SessionUpdate{SessionID: "a", CWD: "/work/api", Terminal: "kitty", TerminalPID: 4242, Window: window, TmuxPane: "%3"}, now); err != nil {
+		t.Fatal(err)
+	}
+	// Later events keep what they leave out, except the pane the hook runs in
+	if err := m.update(&SessionUpdate{SessionID: "a", TerminalPID: 4343}, now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	got := m.get("a")
+	if got == nil || got.CWD != "/work/api" || got.Terminal != "kitty" || got.TerminalPID != 4343 || got.Window == nil || got.Window.ID != "42" {
+		t.Fatalf("session = %+v", got)
+	}
+	if got.TmuxPane != "" || !got.StartedAt.Equal(now) || !got.LastActivity.Equal(now.Add(time.Minute)) {
+		t.Errorf("session = %+v", got)
+	}
+
+	// A restarted daemon finds the session in the registry
+	restored := newSessionMap(reg, now.Add(2*time.Minute)).get("a")
+	if restored == nil || restored.TerminalPID != 4343 || restored.Window == nil {
+		t.Errorf("restored session = %+v", restored)
+	}
+
+	if err := m.update(&SessionUpdate{SessionID: "a", Ended: true}, now); err != nil {
+		t.Fatal(err)
+	}
+	if m.get("a") != nil {
+		t.Error("ended session should be forgotten")
+	}
+	if s, _ := reg.Get("a"); s != nil {
+		t.Error("ended session should be removed from the registry")
+	}
+}
+
+func TestSessionMap_ListCatchesUpWithRegistry(t *testing.T) {
+	reg := sessions.NewRegistry(t.TempDir())
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.Local)
+	m := newSessionMap(reg, now)
+
+	// Registered by hooks while the daemon did not get updates
+	reg.Start("b", "/work/web", "foot", now.Add(time.Minute))
+	reg.Start("a", "/work/api", "kitty", now)
+	m.update(&SessionUpdate{SessionID: "gone"}, now)
+	reg.End("gone")
+
+	list := m.list(now.Add(2 * time.Minute))
+	if len(list) != 2 || list[0].ID != "a" || list[1].ID != "b" {
+		t.Errorf("list = %+v, want a and b, oldest first", list)
+	}
+}
+
+func TestSessionMap_InMemory(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.Local)
+	m := newSessionMap(nil, now)
+	if err := m.update(&SessionUpdate{SessionID: "a", CWD: "/work/api"}, now); err != nil {
+		t.Fatal(err)
+	}
+	if list := m.list(now.Add(sessions.StaleAfter + time.Minute)); len(list) != 0 {
+		t.Errorf("stale sessions should be dropped, got %+v", list)
+	}
+}
@@ -0,0 +1,193 @@
+//go:build linux
+
+// ABOUTME: Minimal client for the Sway (i3-compatible) IPC protocol.
+// ABOUTME: Runs commands over $SWAYSOCK directly so swaymsg does not need to be installed.
+package daemon
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	// swayIPCMagic prefixes every i3/sway IPC message.
+	swayIPCMagic = "i3-ipc"
+	// swayIPCRunCommand is the RUN_COMMAND message type.
+	swayIPCRunCommand uint32 = 0
+	// swayIPCGetTree is the GET_TREE message type.
+	swayIPCGetTree uint32 = 4
+	// swayIPCTimeout bounds the whole request/response exchange.
+	swayIPCTimeout = 2 * time.Second
+)
+
+// swayCommandResult is one entry of a RUN_COMMAND reply.
+type swayCommandResult struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// swayNode is a container in a GET_TREE reply; windows are its leaves.
+type swayNode struct {
+	ID               int64      `json:"id"`
+	Type             string     `json:"type"`              // root, output, workspace, con or floating_con
+	Name             string     `json:"name"`              // Window title, or workspace/output name
+	CurrentWorkspace string     `json:"current_workspace"` // Outputs only
+	AppID            string     `json:"app_id"`
+	Focused          bool       `json:"focused"`
+	Nodes            []swayNode `json:"nodes"`
+	FloatingNodes    []swayNode `json:"floating_nodes"`
+	WindowProperties *struct {
+		Class string `json:"class"`
+	} `json:"window_properties"` // XWayland windows only
+}
+
+// encodeSwayIPCMessage builds an IPC frame: magic, payload length, message type, payload.
+// Sway uses the host byte order, which is little-endian on every platform it supports.
+func encodeSwayIPCMessage(msgType uint32, payload string) []byte {
+	buf := make([]byte, len(swayIPCMagic)+8+len(payload))
+	n := copy(buf, swayIPCMagic)
+	binary.LittleEndian.PutUint32(buf[n:], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[n+4:], msgType)
+	copy(buf[n+8:], payload)
+	return buf
+}
+
+// readSwayIPCMessage reads one IPC frame and returns its type and payload.
+func readSwayIPCMessage(r io.Reader) (uint32, []byte, error) {
+	header := make([]byte, len(swayIPCMagic)+8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, fmt.Errorf("failed to read IPC header: %w", err)
+	}
+	if string(header[:len(swayIPCMagic)]) != swayIPCMagic {
+		return 0, nil, fmt.Errorf("invalid IPC magic: %q", header[:len(swayIPCMagic)])
+	}
+
+	length := binary.LittleEndian.Uint32(header[len(swayIPCMagic):])
+	msgType := binary.LittleEndian.Uint32(header[len(swayIPCMagic)+4:])
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, fmt.Errorf("failed to read IPC payload: %w", err)
+	}
+	return msgType, payload, nil
+}
+
+// parseSwayCommandReply returns an error unless every command in the reply succeeded.
+func parseSwayCommandReply(payload []byte) error {
+	var results []swayCommandResult
+	if err := json.Unmarshal(payload, &results); err != nil {
+		return fmt.Errorf("invalid RUN_COMMAND reply: %w", err)
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("empty RUN_COMMAND reply")
+	}
+
+	var errs []string
+	for _, r := range results {
+		if !r.Success {
+			errs = append(errs, r.Error)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// swayRunCommand sends a RUN_COMMAND message to the Sway IPC socket at socketPath.
+func swayRunCommand(ctx context.Context, socketPath, command string) error {
+	payload, err := swayIPCRequest(ctx, socketPath, swayIPCRunCommand, command)
+	if err != nil {
+		return err
+	}
+	return parseSwayCommandReply(payload)
+}
+
+// swayFocusedWindow returns the focused window in the Sway tree (nil = none).
+func swayFocusedWindow(ctx context.Context, socketPath string) (*swayNode, error) {
+	payload, err := swayIPCRequest(ctx, socketPath, swayIPCGetTree, "")
+	if err != nil {
+		return nil, err
+	}
+	var root swayNode
+	if err := json.Unmarshal(payload, &root); err != nil {
+		return nil, fmt.Errorf("invalid GET_TREE reply: %w", err)
+	}
+	return root.focused(), nil
+}
+
+// focused returns the focused node below n, including n itself (nil = none).
+func (n *swayNode) focused() *swayNode {
+	if n.Focused {
+		return n
+	}
+	for _, children := range [][]swayNode{n.Nodes, n.FloatingNodes} {
+		for i := range children {
+			if f := children[i].focused(); f != nil {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// class returns the node's Wayland app_id, or X11 class for XWayland windows.
+func (n *swayNode) class() string {
+	if n.AppID != "" {
+		return n.AppID
+	}
+	if n.WindowProperties != nil {
+		return n.WindowProperties.Class
+	}
+	return ""
+}
+
+// swayIPCRequest sends one message to the Sway IPC socket at socketPath and
+// returns the payload of the reply. The exchange ends at ctx's deadline when
+// that comes before swayIPCTimeout.
+func swayIPCRequest(ctx context.Context, socketPath string, msgType uint32, payload string) ([]byte, error) {
+	deadline := time.Now().Add(swayIPCTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to sway IPC: %w", err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("failed to set IPC deadline: %w", err)
+	}
+
+	// Cancelling ctx (another focus method won) unblocks the exchange
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Now())
+		case <-stop:
+		}
+	}()
+
+	if _, err := conn.Write(encodeSwayIPCMessage(msgType, payload)); err != nil {
+		return nil, fmt.Errorf("failed to send IPC message: %w", err)
+	}
+
+	replyType, reply, err := readSwayIPCMessage(conn)
+	if err != nil {
+		return nil, err
+	}
+	if replyType != msgType {
+		return nil, fmt.Errorf("unexpected IPC reply type %d", replyType)
+	}
+	return reply, nil
+}
@@ -0,0 +1,167 @@
+//go:build linux
+
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncodeSwayIPCMessage(t *testing.T) {
+	got := encodeSwayIPCMessage(swayIPCRunCommand, "focus")
+	want := append([]byte("i3-ipc"), 5, 0, 0, 0, 0, 0, 0, 0)
+	want = append(want, []byte("focus")...)
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("encodeSwayIPCMessage() = %v, want %v", got, want)
+	}
+}
+
+func TestReadSwayIPCMessage_RoundTrip(t *testing.T) {
+	frame := encodeSwayIPCMessage(7, `[{"success":true}]`)
+
+	msgType, payload, err := readSwayIPCMessage(bytes.NewReader(frame))
+	if err != nil {
+		t.Fatalf("readSwayIPCMessage() error = %v", err)
+	}
+	if msgType != 7 {
+		t.Errorf("msgType = %d, want 7", msgType)
+	}
+	if string(payload) != `[{"success":true}]` {
+		t.Errorf("payload = %q", payload)
+	}
+}
+
+func TestReadSwayIPCMessage_BadMagic(t *testing.T) {
+	frame := append([]byte("xx-ipc"), 0, 0, 0, 0, 0, 0, 0, 0)
+
+	if _, _, err := readSwayIPCMessage(bytes.NewReader(frame)); err == nil {
+		t.Error("readSwayIPCMessage() should reject invalid magic")
+	}
+}
+
+func TestReadSwayIPCMessage_Truncated(t *testing.T) {
+	frame := encodeSwayIPCMessage(0, "payload")
+
+	if _, _, err := readSwayIPCMessage(bytes.NewReader(frame[:len(frame)-2])); err == nil {
+		t.Error("readSwayIPCMessage() should fail on truncated payload")
+	}
+}
+
+func TestParseSwayCommandReply(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		wantErr string
+	}{
+		{"success", `[{"success":true}]`, ""},
+		{"no match", `[{"success":false,"error":"No matching node."}]`, "No matching node."},
+		{"partial failure", `[{"success":true},{"success":false,"error":"bad"}]`, "bad"},
+		{"empty", `[]`, "empty"},
+		{"invalid json", `{`, "invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parseSwayCommandReply([]byte(tt.payload))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("parseSwayCommandReply() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseSwayCommandReply() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// startFakeSway serves one request per reply on a temporary socket,
+// records the received payloads, and answers them with replies in order.
+func startFakeSway(t *testing.T, replies ...string) (string, <-chan string) {
+	t.Helper()
+
+	socketPath := filepath.Join(t.TempDir(), "sway-ipc.sock")
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan string, len(replies))
+	go func() {
+		for _, reply := range replies {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			msgType, payload, err := readSwayIPCMessage(conn)
+			if err != nil {
+				conn.Close()
+				return
+			}
+			received <- string(payload)
+			conn.Write(encodeSwayIPCMessage(msgType, reply))
+			conn.Close()
+		}
+	}()
+
+	return socketPath, received
+}
+
+func TestSwayRunCommand_Success(t *testing.T) {
+	socketPath, received := startFakeSway(t, `[{"success":true}]`)
+
+	if err := swayRunCommand(context.Background(), socketPath, `[app_id="^kitty$"] focus`); err != nil {
+		t.Fatalf("swayRunCommand() error = %v", err)
+	}
+	if got := <-received; got != `[app_id="^kitty$"] focus` {
+		t.Errorf("server received %q", got)
+	}
+}
+
+func TestSwayRunCommand_NoMatch(t *testing.T) {
+	socketPath, _ := startFakeSway(t, `[{"success":false,"error":"No matching node."}]`)
+
+	err := swayRunCommand(context.Background(), socketPath, `[app_id="^nope$"] focus`)
+	if err == nil || !strings.Contains(err.Error(), "No matching node") {
+		t.Errorf("swayRunCommand() error = %v, want No matching node", err)
+	}
+}
+
+func TestSwayRunCommand_NoSocket(t *testing.T) {
+	err := swayRunCommand(context.Background(), filepath.Join(t.TempDir(), "missing.sock"), "focus")
+	if err == nil {
+		t.Error("swayRunCommand() should fail when socket does not exist")
+	}
+}
+
+func TestSwayFocusedWindow(t *testing.T) {
+	tree := `{"id":1,"nodes":[{"id":2,"nodes":[
+		{"id":10,"name":"notes","app_id":"org.gnome.TextEditor","focused":false},
+		{"id":11,"name":"api — Visual Studio Code","app_id":null,"focused":true,"window_properties":{"class":"Code"}}
+	]}]}`
+	socketPath, _ := startFakeSway(t, tree)
+
+	node, err := swayFocusedWindow(context.Background(), socketPath)
+	if err != nil {
+		t.Fatalf("swayFocusedWindow() error = %v", err)
+	}
+	if node == nil || node.ID != 11 || node.class() != "Code" {
+		t.Errorf("focused = %+v, want window 11 of class Code", node)
+	}
+}
+
+func TestSwayFocusedWindow_NoneFocused(t *testing.T) {
+	socketPath, _ := startFakeSway(t, `{"id":1,"nodes":[{"id":2,"floating_nodes":[{"id":3,"app_id":"kitty"}]}]}`)
+
+	node, err := swayFocusedWindow(context.Background(), socketPath)
+	if err != nil || node != nil {
+		t.Errorf("swayFocusedWindow() = %+v, %v, want nil", node, err)
+	}
+}
@@ -0,0 +1,68 @@
+// ABOUTME: Terminals declared in the desktop config, consulted before the built-in tables.
+// ABOUTME: Lets users add terminals such as foot, rio or contour without code changes.
+package daemon
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+// customTerminal is a terminal declared in the config under name
+type customTerminal struct {
+	name string
+	config.TerminalConfig
+}
+
+var (
+	customTerminalsMu sync.RWMutex
+	customTerminals   map[string]customTerminal // By lowercase name
+)
+
+// SetTerminals registers the terminals declared in the desktop config,
+// replacing those registered before. Names match case-insensitively.
+func SetTerminals(terminals map[string]config.TerminalConfig) {
+	m := make(map[string]customTerminal, len(terminals))
+	for name, t := range terminals {
+		m[strings.ToLower(name)] = customTerminal{name: name, TerminalConfig: t}
+	}
+	customTerminalsMu.Lock()
+	customTerminals = m
+	customTerminalsMu.Unlock()
+}
+
+// lookupTerminal returns the terminal declared as terminalName
+func lookupTerminal(terminalName string) (customTerminal, bool) {
+	customTerminalsMu.RLock()
+	defer customTerminalsMu.RUnlock()
+	t, ok := customTerminals[strings.ToLower(terminalName)]
+	return t, ok
+}
+
+// detectCustomTerminal returns the name of the first declared terminal,
+// by name, whose Detect variable matches the environment ("" = none)
+func detectCustomTerminal() string {
+	customTerminalsMu.RLock()
+	defer customTerminalsMu.RUnlock()
+
+	keys := make([]string, 0, len(customTerminals))
+	for key := range customTerminals {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		t := customTerminals[key]
+		if t.Detect == "" {
+			continue
+		}
+		name, want, hasValue := strings.Cut(t.Detect, "=")
+		value, set := os.LookupEnv(name)
+		if set && (!hasValue || value == want) {
+			return t.name
+		}
+	}
+	return ""
+}
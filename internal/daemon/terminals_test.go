@@ -0,0 +1,107 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+func setTestTerminals(t *testing.T, terminals map[string]config.TerminalConfig) {
+	t.Helper()
+	SetTerminals(terminals)
+	t.Cleanup(func() { SetTerminals(nil) })
+}
+
+func TestSetTerminals_OverridesMappings(t *testing.T) {
+	setTestTerminals(t, map[string]config.TerminalConfig{
+		"Rio": {
+			DesktopEntry: "com.raphamorim.rio",
+			AppID:        "rio-wayland",
+			WindowClass:  "Rio",
+			Title:        "rio:",
+			BundleID:     "com.raphael.rio",
+		},
+	})
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"GetAppID", GetAppID("rio"), "com.raphamorim.rio.desktop"},
+		{"GetDesktopEntryID", GetDesktopEntryID("RIO"), "com.raphamorim.rio"},
+		{"GetWlrctlAppID", GetWlrctlAppID("rio"), "rio-wayland"},
+		{"GetKdotoolClass", GetKdotoolClass("rio"), "rio-wayland"},
+		{"GetXdotoolClass", GetXdotoolClass("rio"), "Rio"},
+		{"GetSearchTerm", GetSearchTerm("rio"), "rio:"},
+		{"GetMacBundleID", GetMacBundleID("rio"), "com.raphael.rio"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestSetTerminals_EmptyFieldsKeepBuiltins(t *testing.T) {
+	setTestTerminals(t, map[string]config.TerminalConfig{
+		"kitty": {Title: "my-kitty"},
+	})
+
+	if got := GetWlrctlAppID("kitty"); got != "kitty" {
+		t.Errorf("GetWlrctlAppID = %q, want built-in %q", got, "kitty")
+	}
+	if got := GetAppID("kitty"); got != "kitty.desktop" {
+		t.Errorf("GetAppID = %q, want built-in %q", got, "kitty.desktop")
+	}
+	if got := GetSearchTerm("kitty"); got != "my-kitty" {
+		t.Errorf("GetSearchTerm = %q, want %q", got, "my-kitty")
+	}
+}
+
+func TestSetTerminals_Replaces(t *testing.T) {
+	setTestTerminals(t, map[string]config.TerminalConfig{"rio": {AppID: "rio-wayland"}})
+	SetTerminals(map[string]config.TerminalConfig{})
+
+	if got := GetWlrctlAppID("rio"); got != "rio" {
+		t.Errorf("GetWlrctlAppID after reset = %q, want %q", got, "rio")
+	}
+}
+
+func TestGetTerminalName_CustomDetect(t *testing.T) {
+	restore := saveTerminalEnv(t)
+	defer restore()
+	t.Setenv("TERM_PROGRAM", "iTerm.app")
+
+	setTestTerminals(t, map[string]config.TerminalConfig{
+		"foot":    {Detect: "TERM=foot"},
+		"contour": {Detect: "CONTOUR_TEST_SESSION"},
+	})
+
+	t.Setenv("TERM", "xterm-256color")
+	if got := GetTerminalName(); got != "iTerm.app" {
+		t.Errorf("GetTerminalName with non-matching TERM = %q, want %q", got, "iTerm.app")
+	}
+
+	t.Setenv("TERM", "foot")
+	if got := GetTerminalName(); got != "foot" {
+		t.Errorf("GetTerminalName with TERM=foot = %q, want %q", got, "foot")
+	}
+
+	t.Setenv("CONTOUR_TEST_SESSION", "")
+	if got := GetTerminalName(); got != "contour" {
+		t.Errorf("GetTerminalName with a set variable = %q, want %q (sorted first)", got, "contour")
+	}
+}
+
+func TestBuiltinTerminals(t *testing.T) {
+	if got := GetAppID("ghostty"); got != "com.mitchellh.ghostty.desktop" {
+		t.Errorf("GetAppID(ghostty) = %q", got)
+	}
+	if got := GetAppID("foot"); got != "org.codeberg.dnkl.foot.desktop" {
+		t.Errorf("GetAppID(foot) = %q", got)
+	}
+	if got := GetWlrctlAppID("WarpTerminal"); got != "dev.warp.Warp" {
+		t.Errorf("GetWlrctlAppID(WarpTerminal) = %q", got)
+	}
+}
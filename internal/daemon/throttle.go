@@ -0,0 +1,114 @@
+//go:build linux
+
+// ABOUTME: Burst control for daemon notifications: per-session coalescing and a global rate limit.
+// ABOUTME: Decides when a new notification should replace one already on screen instead of stacking.
+package daemon
+
+import (
+	"fmt"
+	"time"
+)
+
+// rateWindow is the period MaxPerMinute is counted over
+const rateWindow = time.Minute
+
+// throttleMaxAge is how long per-session entries are kept after their last update
+const throttleMaxAge = 10 * time.Minute
+
+// shownNotification is a notification currently (or recently) on screen
+type shownNotification struct {
+	id    uint32
+	at    time.Time // Last time it was shown or replaced
+	count int       // Number of events merged into it
+	open  bool      // Replaced for as long as it is open (CoalesceOpen, Update), so never pruned by age
+}
+
+// throttle tracks shown notifications to coalesce bursts.
+// It is not safe for concurrent use; the server serializes access.
+type throttle struct {
+	sessions map[string]shownNotification // Coalesce key -> its latest notification
+	last     shownNotification            // Latest notification across all keys
+	recent   []time.Time                  // When new (non-replacing) notifications were shown
+}
+
+func newThrottle() *throttle {
+	return &throttle{sessions: make(map[string]shownNotification)}
+}
+
+// plan returns the notification to replace (0 = show a new one) and how many
+// events the result will represent. An explicit ReplacesID always wins.
+func (t *throttle) plan(req *NotifyRequest, now time.Time) (replacesID uint32, count int) {
+	if req.ReplacesID != 0 {
+		return req.ReplacesID, 1
+	}
+	if req.CoalesceKey != "" && req.Update {
+		if prev, ok := t.sessions[req.CoalesceKey]; ok {
+			return prev.id, 1
+		}
+	}
+
+	if req.CoalesceKey != "" && req.CoalesceSeconds > 0 {
+		if prev, ok := t.sessions[req.CoalesceKey]; ok && now.Sub(prev.at) < time.Duration(req.CoalesceSeconds)*time.Second {
+			return prev.id, prev.count + 1
+		}
+	}
+	if req.CoalesceKey != "" && req.CoalesceOpen {
+		if prev, ok := t.sessions[req.CoalesceKey]; ok {
+			return prev.id, prev.count + 1
+		}
+	}
+
+	t.pruneRecent(now)
+	if req.MaxPerMinute > 0 && len(t.recent) >= req.MaxPerMinute && t.last.id != 0 {
+		return t.last.id, t.last.count + 1
+	}
+
+	return 0, 1
+}
+
+// record stores the outcome of a notification planned with plan
+func (t *throttle) record(req *NotifyRequest, id uint32, replaced bool, count int, now time.Time) {
+	n := shownNotification{id: id, at: now, count: count, open: req.CoalesceOpen || req.Update}
+	if req.CoalesceKey != "" {
+		t.sessions[req.CoalesceKey] = n
+	}
+	t.last = n
+	if !replaced {
+		t.recent = append(t.recent, now)
+	}
+
+	for key, s := range t.sessions {
+		if !s.open && now.Sub(s.at) > throttleMaxAge {
+			delete(t.sessions, key)
+		}
+	}
+}
+
+// forget drops a closed notification so the next event shows a new one
+func (t *throttle) forget(id uint32) {
+	for key, s := range t.sessions {
+		if s.id == id {
+			delete(t.sessions, key)
+		}
+	}
+	if t.last.id == id {
+		t.last = shownNotification{}
+	}
+}
+
+// pruneRecent drops timestamps older than the rate window
+func (t *throttle) pruneRecent(now time.Time) {
+	i := 0
+	for i < len(t.recent) && now.Sub(t.recent[i]) >= rateWindow {
+		i++
+	}
+	t.recent = t.recent[i:]
+}
+
+// coalescedTitle marks a title that stands for several events, e.g. "✅ Completed (×3)"
+func coalescedTitle(title string, count int) string {
+	if count <= 1 {
+		return title
+	}
+	return fmt.Sprintf("%s (×%d)", title, count)
+}
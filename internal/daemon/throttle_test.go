@@ -0,0 +1,156 @@
+//go:build linux
+
+package daemon
+
+import (
+	"testing"
+	"time"
+)
+
+// show plans and records a notification, assigning nextID to new ones
+func show(t *throttle, req *NotifyRequest, now time.Time, nextID uint32) (id uint32, count int) {
+	replacesID, count := t.plan(req, now)
+	id = replacesID
+	if id == 0 {
+		id = nextID
+	}
+	t.record(req, id, replacesID != 0, count, now)
+	return id, count
+}
+
+func TestThrottle_CoalescesSameSession(t *testing.T) {
+	th := newThrottle()
+	base := time.Date(2026, 3, 13, 12, 0, 0, 0, time.Local)
+	req := &NotifyRequest{CoalesceKey: "session-a", CoalesceSeconds: 10}
+
+	if id, count := show(th, req, base, 1); id != 1 || count != 1 {
+		t.Fatalf("first = (%d, %d), want (1, 1)", id, count)
+	}
+	if id, count := show(th, req, base.Add(5*time.Second), 2); id != 1 || count != 2 {
+		t.Fatalf("second within window = (%d, %d), want (1, 2)", id, count)
+	}
+	// The window slides with each update, so a steady stream keeps coalescing
+	if id, count := show(th, req, base.Add(14*time.Second), 3); id != 1 || count != 3 {
+		t.Fatalf("third within sliding window = (%d, %d), want (1, 3)", id, count)
+	}
+	if id, count := show(th, req, base.Add(30*time.Second), 4); id != 4 || count != 1 {
+		t.Fatalf("after window = (%d, %d), want (4, 1)", id, count)
+	}
+}
+
+func TestThrottle_SessionsDoNotCoalesceWithEachOther(t *testing.T) {
+	th := newThrottle()
+	now := time.Date(2026, 3, 13, 12, 0, 0, 0, time.Local)
+
+	show(th, &NotifyRequest{CoalesceKey: "a", CoalesceSeconds: 10}, now, 1)
+	if id, _ := show(th, &NotifyRequest{CoalesceKey: "b", CoalesceSeconds: 10}, now, 2); id != 2 {
+		t.Errorf("other session got id %d, want a new notification", id)
+	}
+}
+
+func TestThrottle_DisabledCoalescing(t *testing.T) {
+	th := newThrottle()
+	now := time.Date(2026, 3, 13, 12, 0, 0, 0, time.Local)
+	req := &NotifyRequest{CoalesceKey: "a"}
+
+	show(th, req, now, 1)
+	if id, _ := show(th, req, now, 2); id != 2 {
+		t.Errorf("coalesceSeconds 0 should stack notifications, got id %d", id)
+	}
+}
+
+func TestThrottle_RateLimitReplacesLatest(t *testing.T) {
+	th := newThrottle()
+	base := time.Date(2026, 3, 13, 12, 0, 0, 0, time.Local)
+	limit := func(key string) *NotifyRequest {
+		return &NotifyRequest{CoalesceKey: key, MaxPerMinute: 2}
+	}
+
+	show(th, limit("a"), base, 1)
+	show(th, limit("b"), base.Add(time.Second), 2)
+	if id, count := show(th, limit("c"), base.Add(2*time.Second), 3); id != 2 || count != 2 {
+		t.Fatalf("over limit = (%d, %d), want the latest notification (2, 2)", id, count)
+	}
+	// Once the oldest notification leaves the window, new ones are allowed again
+	if id, count := show(th, limit("d"), base.Add(61*time.Second), 4); id != 4 || count != 1 {
+		t.Fatalf("after window = (%d, %d), want (4, 1)", id, count)
+	}
+}
+
+func TestThrottle_ExplicitReplacesIDWins(t *testing.T) {
+	th := newThrottle()
+	now := time.Date(2026, 3, 13, 12, 0, 0, 0, time.Local)
+
+	show(th, &NotifyRequest{CoalesceKey: "a", CoalesceSeconds: 10}, now, 1)
+	replacesID, count := th.plan(&NotifyRequest{CoalesceKey: "a", CoalesceSeconds: 10, ReplacesID: 7}, now)
+	if replacesID != 7 || count != 1 {
+		t.Errorf("plan = (%d, %d), want (7, 1)", replacesID, count)
+	}
+}
+
+func TestThrottle_ForgetClosedNotification(t *testing.T) {
+	th := newThrottle()
+	now := time.Date(2026, 3, 13, 12, 0, 0, 0, time.Local)
+	req := &NotifyRequest{CoalesceKey: "a", CoalesceSeconds: 10, MaxPerMinute: 1}
+
+	show(th, req, now, 1)
+	th.forget(1)
+	if replacesID, _ := th.plan(req, now); replacesID != 0 {
+		t.Errorf("closed notification should not be replaced, got %d", replacesID)
+	}
+}
+
+func TestThrottle_PrunesOldSessions(t *testing.T) {
+	th := newThrottle()
+	now := time.Date(2026, 3, 13, 12, 0, 0, 0, time.Local)
+
+	show(th, &NotifyRequest{CoalesceKey: "old"}, now, 1)
+	show(th, &NotifyRequest{CoalesceKey: "new"}, now.Add(throttleMaxAge+time.Second), 2)
+	if _, ok := th.sessions["old"]; ok {
+		t.Error("stale session entry should be pruned")
+	}
+}
+
+func TestCoalescedTitle(t *testing.T) {
+	if got := coalescedTitle("✅ Completed", 1); got != "✅ Completed" {
+		t.Errorf("single event title = %q", got)
+	}
+	if got := coalescedTitle("✅ Completed", 3); got != "✅ Completed (×3)" {
+		t.Errorf("coalesced title = %q", got)
+	}
+}
+
+func TestThrottle_CoalesceOpenReplacesUntilClosed(t *testing.T) {
+	th := newThrottle()
+	base := time.Date(2026, 3, 13, 12, 0, 0, 0, time.Local)
+	req := &NotifyRequest{CoalesceKey: "a", CoalesceSeconds: 10, CoalesceOpen: true}
+
+	show(th, req, base, 1)
+	// Long past the coalesce window and the pruning age, the open notification is still replaced
+	later := base.Add(throttleMaxAge + time.Hour)
+	show(th, &NotifyRequest{CoalesceKey: "b"}, later, 2)
+	if id, count := show(th, req, later, 3); id != 1 || count != 2 {
+		t.Fatalf("open notification = (%d, %d), want (1, 2)", id, count)
+	}
+
+	th.forget(1)
+	if id, _ := show(th, req, later, 4); id != 4 {
+		t.Errorf("closed notification should not be replaced, got id %d", id)
+	}
+}
+
+func TestThrottle_UpdateReplacesWithoutCounting(t *testing.T) {
+	th := newThrottle()
+	base := time.Date(2026, 3, 13, 12, 0, 0, 0, time.Local)
+	req := &NotifyRequest{CoalesceKey: "heartbeat-a", Update: true}
+
+	show(th, req, base, 1)
+	later := base.Add(throttleMaxAge + time.Hour)
+	if id, count := show(th, req, later, 2); id != 1 || count != 1 {
+		t.Fatalf("update = (%d, %d), want (1, 1)", id, count)
+	}
+	th.forget(1)
+	if id, _ := show(th, req, later, 3); id != 3 {
+		t.Errorf("closed notification should not be updated, got id %d", id)
+	}
+}
@@ -0,0 +1,44 @@
+//go:build linux
+
+// ABOUTME: tmux pane selection for click-to-focus.
+// ABOUTME: Switches tmux to the window/pane recorded when the notification was sent.
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+)
+
+// tmuxPaneIDPattern matches tmux pane IDs such as "%42" (the value of TMUX_PANE).
+var tmuxPaneIDPattern = regexp.MustCompile(`^%[0-9]+$`)
+
+// buildTmuxSelectArgs returns tmux arguments that select the window containing
+// paneID and then the pane itself. socketPath may be empty to use the default server.
+func buildTmuxSelectArgs(socketPath, paneID string) []string {
+	var args []string
+	if socketPath != "" {
+		args = append(args, "-S", socketPath)
+	}
+	return append(args,
+		"select-window", "-t", paneID, ";",
+		"select-pane", "-t", paneID,
+	)
+}
+
+// FocusTmuxPane selects the tmux window and pane identified by paneID.
+func FocusTmuxPane(ctx context.Context, socketPath, paneID string) error {
+	if !tmuxPaneIDPattern.MatchString(paneID) {
+		return fmt.Errorf("invalid tmux pane ID: %q", paneID)
+	}
+	if _, err := exec.LookPath("tmux"); err != nil {
+		return fmt.Errorf("tmux not installed")
+	}
+
+	output, err := exec.CommandContext(ctx, "tmux", buildTmuxSelectArgs(socketPath, paneID)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("tmux select-pane failed: %w, output: %s", err, string(output))
+	}
+	return nil
+}
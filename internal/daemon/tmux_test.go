@@ -0,0 +1,38 @@
+//go:build linux
+
+package daemon
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestBuildTmuxSelectArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		socket string
+		pane   string
+		want   string
+	}{
+		{"default server", "", "%3", "select-window -t %3 ; select-pane -t %3"},
+		{"explicit socket", "/tmp/tmux-1000/default", "%42", "-S /tmp/tmux-1000/default select-window -t %42 ; select-pane -t %42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.Join(buildTmuxSelectArgs(tt.socket, tt.pane), " ")
+			if got != tt.want {
+				t.Errorf("buildTmuxSelectArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFocusTmuxPane_InvalidPaneID(t *testing.T) {
+	for _, pane := range []string{"", "42", "%", "%1; kill-server", "-t"} {
+		if err := FocusTmuxPane(context.Background(), "", pane); err == nil || !strings.Contains(err.Error(), "invalid tmux pane ID") {
+			t.Errorf("FocusTmuxPane(%q) error = %v, want invalid pane ID", pane, err)
+		}
+	}
+}
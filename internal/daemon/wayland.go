@@ -0,0 +1,266 @@
+//go:build linux
+
+// ABOUTME: Minimal Wayland wire-protocol client over the compositor socket.
+// ABOUTME: Binds globals and exchanges messages without libwayland, for compositor-specific protocols.
+package daemon
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// waylandDisplayID is the wl_display object every connection starts with
+	waylandDisplayID uint32 = 1
+	// waylandTimeout bounds a whole exchange with the compositor
+	waylandTimeout = 2 * time.Second
+)
+
+// wl_display and wl_registry opcodes
+const (
+	wlDisplaySync        uint16 = 0 // request
+	wlDisplayGetRegistry uint16 = 1 // request
+	wlDisplayError       uint16 = 0 // event
+	wlRegistryBind       uint16 = 0 // request
+	wlRegistryGlobal     uint16 = 0 // event
+	wlCallbackDone       uint16 = 0 // event
+)
+
+// waylandEvent is a message from the compositor
+type waylandEvent struct {
+	sender uint32
+	opcode uint16
+	args   waylandArgs
+}
+
+// waylandGlobal is an interface advertised by the registry
+type waylandGlobal struct {
+	name    uint32
+	version uint32
+}
+
+// waylandConn is a connection to the compositor. Requests are sent one
+// message at a time; file descriptors are not supported.
+type waylandConn struct {
+	conn   net.Conn
+	lastID uint32
+	stop   chan struct{}
+}
+
+// waylandSocketPath returns the compositor socket from WAYLAND_DISPLAY,
+// relative to XDG_RUNTIME_DIR unless it is absolute.
+func waylandSocketPath() (string, error) {
+	display := os.Getenv("WAYLAND_DISPLAY")
+	if display == "" {
+		return "", fmt.Errorf("not running under Wayland (WAYLAND_DISPLAY not set)")
+	}
+	if filepath.IsAbs(display) {
+		return display, nil
+	}
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" {
+		return "", fmt.Errorf("XDG_RUNTIME_DIR not set")
+	}
+	return filepath.Join(runtimeDir, display), nil
+}
+
+// dialWayland connects to the compositor socket at socketPath. The
+// exchange ends at ctx's deadline when that comes before waylandTimeout.
+func dialWayland(ctx context.Context, socketPath string) (*waylandConn, error) {
+	deadline := time.Now().Add(waylandTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Wayland compositor: %w", err)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to set Wayland deadline: %w", err)
+	}
+
+	c := &waylandConn{conn: conn, lastID: waylandDisplayID, stop: make(chan struct{})}
+	// Cancelling ctx (another focus method won) unblocks the exchange
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Now())
+		case <-c.stop:
+		}
+	}()
+	return c, nil
+}
+
+// Close closes the connection; the compositor destroys its objects
+func (c *waylandConn) Close() error {
+	close(c.stop)
+	return c.conn.Close()
+}
+
+// newID allocates a client object ID
+func (c *waylandConn) newID() uint32 {
+	c.lastID++
+	return c.lastID
+}
+
+// send sends a request; args are uint32 (uint, object or new_id), string or []byte (array)
+func (c *waylandConn) send(object uint32, opcode uint16, args ...interface{}) error {
+	msg, err := encodeWaylandMessage(object, opcode, args...)
+	if err != nil {
+		return err
+	}
+	if _, err := c.conn.Write(msg); err != nil {
+		return fmt.Errorf("failed to send Wayland request: %w", err)
+	}
+	return nil
+}
+
+// read reads the next event
+func (c *waylandConn) read() (waylandEvent, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(c.conn, header); err != nil {
+		return waylandEvent{}, fmt.Errorf("failed to read Wayland event: %w", err)
+	}
+	sizeOpcode := binary.LittleEndian.Uint32(header[4:])
+	size := sizeOpcode >> 16
+	if size < 8 {
+		return waylandEvent{}, fmt.Errorf("invalid Wayland event size %d", size)
+	}
+	args := make([]byte, size-8)
+	if _, err := io.ReadFull(c.conn, args); err != nil {
+		return waylandEvent{}, fmt.Errorf("failed to read Wayland event: %w", err)
+	}
+	return waylandEvent{
+		sender: binary.LittleEndian.Uint32(header),
+		opcode: uint16(sizeOpcode),
+		args:   waylandArgs{data: args},
+	}, nil
+}
+
+// roundtrip sends wl_display.sync and passes every event to handle until
+// the compositor answers it, so all earlier requests have been processed.
+// A protocol error ends the connection and is returned.
+func (c *waylandConn) roundtrip(handle func(waylandEvent)) error {
+	callback := c.newID()
+	if err := c.send(waylandDisplayID, wlDisplaySync, callback); err != nil {
+		return err
+	}
+	for {
+		ev, err := c.read()
+		if err != nil {
+			return err
+		}
+		switch {
+		case ev.sender == callback && ev.opcode == wlCallbackDone:
+			return nil
+		case ev.sender == waylandDisplayID && ev.opcode == wlDisplayError:
+			object, code, message := ev.args.uint(), ev.args.uint(), ev.args.string()
+			return fmt.Errorf("Wayland protocol error on object %d (code %d): %s", object, code, message)
+		default:
+			handle(ev)
+		}
+	}
+}
+
+// globals returns the interfaces the registry advertises
+func (c *waylandConn) globals() (registry uint32, globals map[string]waylandGlobal, err error) {
+	registry = c.newID()
+	if err := c.send(waylandDisplayID, wlDisplayGetRegistry, registry); err != nil {
+		return 0, nil, err
+	}
+	globals = map[string]waylandGlobal{}
+	err = c.roundtrip(func(ev waylandEvent) {
+		if ev.sender == registry && ev.opcode == wlRegistryGlobal {
+			name, iface, version := ev.args.uint(), ev.args.string(), ev.args.uint()
+			globals[iface] = waylandGlobal{name: name, version: version}
+		}
+	})
+	return registry, globals, err
+}
+
+// bind binds a global and returns the new object's ID
+func (c *waylandConn) bind(registry uint32, global waylandGlobal, iface string, version uint32) (uint32, error) {
+	id := c.newID()
+	return id, c.send(registry, wlRegistryBind, global.name, iface, version, id)
+}
+
+// encodeWaylandMessage builds a message: object ID, size and opcode, and
+// arguments. Strings and arrays are length-prefixed and padded to 32 bits;
+// strings are NUL-terminated.
+func encodeWaylandMessage(object uint32, opcode uint16, args ...interface{}) ([]byte, error) {
+	msg := make([]byte, 8, 64)
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case uint32:
+			msg = binary.LittleEndian.AppendUint32(msg, v)
+		case string:
+			msg = binary.LittleEndian.AppendUint32(msg, uint32(len(v)+1))
+			msg = append(msg, v...)
+			msg = append(msg, make([]byte, 4-len(v)%4)...) // NUL and padding
+		case []byte:
+			msg = binary.LittleEndian.AppendUint32(msg, uint32(len(v)))
+			msg = append(msg, v...)
+			msg = append(msg, make([]byte, (4-len(v)%4)%4)...)
+		default:
+			return nil, fmt.Errorf("unsupported Wayland argument %T", arg)
+		}
+	}
+	if len(msg) > 0xffff {
+		return nil, fmt.Errorf("Wayland message too long")
+	}
+	binary.LittleEndian.PutUint32(msg, object)
+	binary.LittleEndian.PutUint32(msg[4:], uint32(len(msg))<<16|uint32(opcode))
+	return msg, nil
+}
+
+// waylandArgs decodes event arguments in order. Reading past the end
+// yields zero values.
+type waylandArgs struct {
+	data []byte
+}
+
+// uint reads a uint, int, object or new_id argument
+func (a *waylandArgs) uint() uint32 {
+	if len(a.data) < 4 {
+		a.data = nil
+		return 0
+	}
+	v := binary.LittleEndian.Uint32(a.data)
+	a.data = a.data[4:]
+	return v
+}
+
+// array reads an array argument
+func (a *waylandArgs) array() []byte {
+	n := int(a.uint())
+	padded := (n + 3) &^ 3
+	if padded > len(a.data) {
+		a.data = nil
+		return nil
+	}
+	v := a.data[:n]
+	a.data = a.data[padded:]
+	return v
+}
+
+// string reads a string argument
+func (a *waylandArgs) string() string {
+	n := int(a.uint())
+	padded := (n + 3) &^ 3
+	if n == 0 || padded > len(a.data) {
+		a.data = nil
+		return ""
+	}
+	s := string(a.data[:n-1]) // Without the NUL
+	a.data = a.data[padded:]
+	return s
+}
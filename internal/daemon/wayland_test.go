@@ -0,0 +1,138 @@
+//go:build linux
+
+package daemon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncodeWaylandMessage(t *testing.T) {
+	msg, err := encodeWaylandMessage(2, 0, uint32(7), "wl_seat", uint32(1), uint32(5))
+	if err != nil {
+		t.Fatalf("encodeWaylandMessage() error = %v", err)
+	}
+	want := []byte{
+		2, 0, 0, 0, // object
+		0, 0, 32, 0, // opcode 0, size 32
+		7, 0, 0, 0, // name
+		8, 0, 0, 0, 'w', 'l', '_', 's', 'e', 'a', 't', 0, // "wl_seat" with NUL
+		1, 0, 0, 0, // version
+		5, 0, 0, 0, // new_id
+	}
+	if !bytes.Equal(msg, want) {
+		t.Errorf("encodeWaylandMessage() = %v, want %v", msg, want)
+	}
+
+	if _, err := encodeWaylandMessage(1, 0, 3.5); err == nil {
+		t.Error("a float argument should be refused")
+	}
+}
+
+func TestWaylandArgs(t *testing.T) {
+	msg, _ := encodeWaylandMessage(1, 0, uint32(42), "kitty", "", uint32(9))
+	args := waylandArgs{data: msg[8:]}
+	if got := args.uint(); got != 42 {
+		t.Errorf("uint() = %d", got)
+	}
+	if got := args.string(); got != "kitty" {
+		t.Errorf("string() = %q", got)
+	}
+	if got := args.string(); got != "" {
+		t.Errorf("empty string() = %q", got)
+	}
+	if got := args.uint(); got != 9 {
+		t.Errorf("uint() after strings = %d", got)
+	}
+	if got := args.uint(); got != 0 {
+		t.Errorf("uint() past the end = %d, want 0", got)
+	}
+}
+
+func TestWaylandSocketPath(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	t.Setenv("WAYLAND_DISPLAY", "wayland-1")
+	if got, err := waylandSocketPath(); err != nil || got != "/run/user/1000/wayland-1" {
+		t.Errorf("waylandSocketPath() = %q, %v", got, err)
+	}
+
+	t.Setenv("WAYLAND_DISPLAY", "/tmp/compositor.sock")
+	if got, err := waylandSocketPath(); err != nil || got != "/tmp/compositor.sock" {
+		t.Errorf("waylandSocketPath(absolute) = %q, %v", got, err)
+	}
+
+	t.Setenv("WAYLAND_DISPLAY", "")
+	if _, err := waylandSocketPath(); err == nil {
+		t.Error("waylandSocketPath() without WAYLAND_DISPLAY should fail")
+	}
+}
+
+// fakeWaylandSend sends an event from a fake compositor
+type fakeWaylandSend func(sender uint32, opcode uint16, args ...interface{})
+
+// startFakeCompositor serves one client on a temporary socket like a
+// Wayland compositor offering globals. It answers sync and get_registry
+// itself, calls bound when the client binds a global, and passes every
+// other request to request with the interface of its object.
+func startFakeCompositor(t *testing.T, globals []string,
+	bound func(send fakeWaylandSend, iface string, id uint32),
+	request func(iface string, object uint32, opcode uint16, args *waylandArgs)) string {
+	t.Helper()
+
+	socketPath := filepath.Join(t.TempDir(), "wayland-0")
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		send := func(sender uint32, opcode uint16, args ...interface{}) {
+			msg, _ := encodeWaylandMessage(sender, opcode, args...)
+			conn.Write(msg)
+		}
+		objects := map[uint32]string{waylandDisplayID: "wl_display"}
+		for {
+			header := make([]byte, 8)
+			if _, err := io.ReadFull(conn, header); err != nil {
+				return
+			}
+			object := binary.LittleEndian.Uint32(header)
+			opcode := uint16(binary.LittleEndian.Uint32(header[4:]))
+			body := make([]byte, binary.LittleEndian.Uint32(header[4:])>>16-8)
+			if _, err := io.ReadFull(conn, body); err != nil {
+				return
+			}
+			args := waylandArgs{data: body}
+
+			switch iface := objects[object]; {
+			case iface == "wl_display" && opcode == wlDisplaySync:
+				send(args.uint(), wlCallbackDone, uint32(1))
+			case iface == "wl_display" && opcode == wlDisplayGetRegistry:
+				registry := args.uint()
+				objects[registry] = "wl_registry"
+				for i, name := range globals {
+					send(registry, wlRegistryGlobal, uint32(i+1), name, uint32(3))
+				}
+			case iface == "wl_registry" && opcode == wlRegistryBind:
+				_, name, _, id := args.uint(), args.string(), args.uint(), args.uint()
+				objects[id] = name
+				bound(send, name, id)
+			default:
+				request(iface, object, opcode, &args)
+			}
+		}
+	}()
+
+	return socketPath
+}
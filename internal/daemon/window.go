@@ -0,0 +1,311 @@
+//go:build linux
+
+// ABOUTME: Pins the window a session started in and focuses exactly that window on click.
+// ABOUTME: Queries the active window from Hyprland, Sway, KDE, GNOME Shell or X11.
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/777genius/claude-notifications/internal/sessions"
+	"github.com/godbus/dbus/v5"
+)
+
+// Window backends: where a pinned window ID is valid
+const (
+	windowBackendHyprland = "hyprland"
+	windowBackendSway     = "sway"
+	windowBackendKDE      = "kde"
+	windowBackendGNOME    = "gnome"
+	windowBackendX11      = "x11"
+)
+
+// CaptureWindow returns the focused window when it belongs to terminalName,
+// to pin it to the session started in it. A window of another app (the
+// user switched away, or the terminal is unknown) is not returned.
+func CaptureWindow(ctx context.Context, terminalName string) (*sessions.Window, error) {
+	w, err := activeWindow(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !windowMatchesTerminal(w.Class, terminalName) {
+		return nil, fmt.Errorf("active window %q (%s) is not %s", w.Title, w.Class, terminalName)
+	}
+	return w, nil
+}
+
+// TerminalFocused reports whether the focused window is the session's:
+// its pinned window, or else any window of terminalName
+func TerminalFocused(ctx context.Context, terminalName string, pinned *sessions.Window) (bool, error) {
+	w, err := activeWindow(ctx)
+	if err != nil {
+		return false, err
+	}
+	if pinned != nil && pinned.Backend == w.Backend {
+		return pinned.ID == w.ID, nil
+	}
+	return windowMatchesTerminal(w.Class, terminalName), nil
+}
+
+// activeWindow returns the focused window of any app
+func activeWindow(ctx context.Context) (*sessions.Window, error) {
+	var (
+		w   *sessions.Window
+		err error
+	)
+	desktop := strings.ToUpper(os.Getenv("XDG_CURRENT_DESKTOP"))
+	wayland := os.Getenv("XDG_SESSION_TYPE") == "wayland"
+	switch {
+	case os.Getenv("HYPRLAND_INSTANCE_SIGNATURE") != "":
+		w, err = captureHyprland(ctx)
+	case os.Getenv("SWAYSOCK") != "":
+		w, err = captureSway(ctx, os.Getenv("SWAYSOCK"))
+	case strings.Contains(desktop, "KDE"):
+		w, err = captureKDE(ctx)
+	case strings.Contains(desktop, "GNOME") && wayland:
+		w, err = captureGNOME(ctx)
+	case os.Getenv("DISPLAY") != "" && !wayland:
+		w, err = captureX11(ctx)
+	default:
+		return nil, fmt.Errorf("cannot query the active window in this session")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if w == nil || w.ID == "" {
+		return nil, fmt.Errorf("no active window")
+	}
+	return w, nil
+}
+
+// FocusPinnedWindow focuses the window pinned to a session. It fails when
+// the window was closed or the desktop session changed.
+func FocusPinnedWindow(ctx context.Context, w *sessions.Window) error {
+	switch w.Backend {
+	case windowBackendHyprland:
+		output, err := exec.CommandContext(ctx, "hyprctl", "dispatch", "focuswindow", "address:"+w.ID).CombinedOutput()
+		if out := strings.TrimSpace(string(output)); err != nil || out != "ok" {
+			return fmt.Errorf("hyprctl focuswindow address:%s: %v, output: %s", w.ID, err, out)
+		}
+		return nil
+	case windowBackendSway:
+		socketPath := os.Getenv("SWAYSOCK")
+		if socketPath == "" {
+			return fmt.Errorf("not running under Sway (SWAYSOCK not set)")
+		}
+		if _, err := strconv.ParseInt(w.ID, 10, 64); err != nil {
+			return fmt.Errorf("invalid sway container ID %q", w.ID)
+		}
+		return swayRunCommand(ctx, socketPath, "[con_id="+w.ID+"] focus")
+	case windowBackendKDE:
+		if output, err := exec.CommandContext(ctx, "kdotool", "windowactivate", w.ID).CombinedOutput(); err != nil {
+			return fmt.Errorf("kdotool windowactivate %s failed: %w, output: %s", w.ID, err, string(output))
+		}
+		return nil
+	case windowBackendGNOME:
+		id, err := strconv.ParseUint(w.ID, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid GNOME window ID %q", w.ID)
+		}
+		result, err := gnomeShellEval(ctx, fmt.Sprintf(`
+			(function() {
+				%s
+				let found = global.get_window_actors().find(a => a.get_meta_window().get_id() == %d);
+				if (!found) return 'no matching window';
+				activateWindow(found.get_meta_window());
+				return 'activated';
+			})()
+		`, gnomeActivateWindowJS, id))
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(result, "activated") {
+			return fmt.Errorf("GNOME window %s not found", w.ID)
+		}
+		return nil
+	case windowBackendX11:
+		if output, err := exec.CommandContext(ctx, "xdotool", "windowactivate", w.ID).CombinedOutput(); err != nil {
+			return fmt.Errorf("xdotool windowactivate %s failed: %w, output: %s", w.ID, err, string(output))
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown window backend %q", w.Backend)
+}
+
+// captureHyprland queries the active window with hyprctl
+func captureHyprland(ctx context.Context) (*sessions.Window, error) {
+	output, err := exec.CommandContext(ctx, "hyprctl", "activewindow", "-j").Output()
+	if err != nil {
+		return nil, fmt.Errorf("hyprctl activewindow failed: %w", err)
+	}
+	return parseHyprlandActiveWindow(output)
+}
+
+// parseHyprlandActiveWindow reads "hyprctl activewindow -j" output, which
+// is {} when no window is focused
+func parseHyprlandActiveWindow(output []byte) (*sessions.Window, error) {
+	var active struct {
+		Address string `json:"address"`
+		Class   string `json:"class"`
+		Title   string `json:"title"`
+	}
+	if err := json.Unmarshal(output, &active); err != nil {
+		return nil, fmt.Errorf("invalid hyprctl activewindow output: %w", err)
+	}
+	if active.Address == "" {
+		return nil, nil
+	}
+	return &sessions.Window{Backend: windowBackendHyprland, ID: active.Address, Title: active.Title, Class: active.Class}, nil
+}
+
+// captureSway finds the focused window in the Sway tree
+func captureSway(ctx context.Context, socketPath string) (*sessions.Window, error) {
+	node, err := swayFocusedWindow(ctx, socketPath)
+	if err != nil || node == nil {
+		return nil, err
+	}
+	return &sessions.Window{Backend: windowBackendSway, ID: strconv.FormatInt(node.ID, 10), Title: node.Name, Class: node.class()}, nil
+}
+
+// captureKDE queries the active window with kdotool
+func captureKDE(ctx context.Context) (*sessions.Window, error) {
+	id, err := commandLine(ctx, "kdotool", "getactivewindow")
+	if err != nil {
+		return nil, err
+	}
+	class, _ := commandLine(ctx, "kdotool", "getwindowclassname", id)
+	title, _ := commandLine(ctx, "kdotool", "getwindowname", id)
+	return &sessions.Window{Backend: windowBackendKDE, ID: id, Title: title, Class: class}, nil
+}
+
+// captureX11 queries the active window with xdotool
+func captureX11(ctx context.Context) (*sessions.Window, error) {
+	id, err := commandLine(ctx, "xdotool", "getactivewindow")
+	if err != nil {
+		return nil, err
+	}
+	class, _ := commandLine(ctx, "xdotool", "getwindowclassname", id)
+	title, _ := commandLine(ctx, "xdotool", "getwindowname", id)
+	return &sessions.Window{Backend: windowBackendX11, ID: id, Title: title, Class: class}, nil
+}
+
+// captureGNOME asks GNOME Shell for the focused window through Shell Eval,
+// which like the Eval focus methods needs unsafe_mode or development-tools,
+// or else through the Introspect interface, which needs introspect enabled
+func captureGNOME(ctx context.Context) (*sessions.Window, error) {
+	w, err := captureGNOMEEval(ctx)
+	if err == nil {
+		return w, nil
+	}
+	w, introspectErr := captureGNOMEIntrospect(ctx)
+	if introspectErr != nil {
+		return nil, fmt.Errorf("%v; %v", err, introspectErr)
+	}
+	return w, nil
+}
+
+// captureGNOMEEval asks for the focus window with Shell Eval
+func captureGNOMEEval(ctx context.Context) (*sessions.Window, error) {
+	result, err := gnomeShellEval(ctx, `
+		(function() {
+			let w = global.display.focus_window;
+			if (!w) return {};
+			return {id: String(w.get_id()), title: w.get_title() || '', class: w.get_wm_class() || ''};
+		})()
+	`)
+	if err != nil {
+		return nil, err
+	}
+	var active struct {
+		ID    string `json:"id"`
+		Title string `json:"title"`
+		Class string `json:"class"`
+	}
+	if err := json.Unmarshal([]byte(result), &active); err != nil {
+		return nil, fmt.Errorf("invalid Shell.Eval result: %w", err)
+	}
+	if active.ID == "" {
+		return nil, nil
+	}
+	return &sessions.Window{Backend: windowBackendGNOME, ID: active.ID, Title: active.Title, Class: active.Class}, nil
+}
+
+// captureGNOMEIntrospect lists windows with org.gnome.Shell.Introspect,
+// available with "gsettings set org.gnome.shell introspect true"
+func captureGNOMEIntrospect(ctx context.Context) (*sessions.Window, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to D-Bus session bus: %w", err)
+	}
+	defer conn.Close()
+
+	var windows map[uint64]map[string]dbus.Variant
+	if err := conn.Object("org.gnome.Shell", "/org/gnome/Shell/Introspect").
+		CallWithContext(ctx, "org.gnome.Shell.Introspect.GetWindows", 0).Store(&windows); err != nil {
+		return nil, fmt.Errorf("Introspect.GetWindows failed (enable org.gnome.shell introspect): %w", err)
+	}
+	return focusedIntrospectWindow(windows), nil
+}
+
+// focusedIntrospectWindow returns the window that has focus among those
+// listed by Introspect.GetWindows, keyed by the same ID Shell Eval uses
+func focusedIntrospectWindow(windows map[uint64]map[string]dbus.Variant) *sessions.Window {
+	for id, props := range windows {
+		if focused, _ := props["has-focus"].Value().(bool); !focused {
+			continue
+		}
+		title, _ := props["title"].Value().(string)
+		class, _ := props["wm-class"].Value().(string)
+		return &sessions.Window{Backend: windowBackendGNOME, ID: strconv.FormatUint(id, 10), Title: title, Class: class}
+	}
+	return nil
+}
+
+// gnomeShellEval runs js in GNOME Shell and returns the JSON of its result
+func gnomeShellEval(ctx context.Context, js string) (string, error) {
+	output, err := exec.CommandContext(ctx, "gdbus", "call",
+		"--session",
+		"--dest", "org.gnome.Shell",
+		"--object-path", "/org/gnome/Shell",
+		"--method", "org.gnome.Shell.Eval",
+		js,
+	).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("gdbus Eval failed: %w, output: %s", err, string(output))
+	}
+	return parseEvalResult(string(output))
+}
+
+// parseEvalResult returns the result string from gdbus output such as
+// "(true, '{\"id\":\"1\"}')". GNOME 41+ answers (false, ”) unless
+// unsafe_mode is on.
+func parseEvalResult(output string) (string, error) {
+	out := strings.TrimSpace(output)
+	if !strings.HasPrefix(out, "(true, ") || !strings.HasSuffix(out, ")") {
+		return "", fmt.Errorf("Shell.Eval blocked (GNOME 41+ security) - install unsafe-mode-menu extension")
+	}
+	quoted := strings.TrimSuffix(strings.TrimPrefix(out, "(true, "), ")")
+	if len(quoted) < 2 || (quoted[0] != '\'' && quoted[0] != '"') || quoted[len(quoted)-1] != quoted[0] {
+		return "", fmt.Errorf("unexpected Shell.Eval output: %s", out)
+	}
+	return strings.NewReplacer(`\\`, `\`, `\'`, `'`, `\"`, `"`).Replace(quoted[1 : len(quoted)-1]), nil
+}
+
+// commandLine runs a command and returns its trimmed output
+func commandLine(ctx context.Context, name string, args ...string) (string, error) {
+	output, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("%s %s failed: %w", name, args[0], err)
+	}
+	line := strings.TrimSpace(string(output))
+	if line == "" {
+		return "", fmt.Errorf("%s %s returned nothing", name, args[0])
+	}
+	return line, nil
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package daemon
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/777genius/claude-notifications/internal/sessions"
+)
+
+// CaptureWindow is not supported outside Linux: macOS finds the window by
+// its document, and Windows by title.
+func CaptureWindow(ctx context.Context, terminalName string) (*sessions.Window, error) {
+	return nil, fmt.Errorf("window pinning is only supported on Linux")
+}
+
+// FocusPinnedWindow is not supported outside Linux.
+func FocusPinnedWindow(ctx context.Context, w *sessions.Window) error {
+	return fmt.Errorf("window pinning is only supported on Linux")
+}
@@ -0,0 +1,140 @@
+//go:build linux
+
+package daemon
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/777genius/claude-notifications/internal/sessions"
+	"github.com/godbus/dbus/v5"
+)
+
+func TestParseHyprlandActiveWindow(t *testing.T) {
+	w, err := parseHyprlandActiveWindow([]byte(`{"address":"0x55d1c0a0","class":"kitty","title":"~/api","pid":42}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := sessions.Window{Backend: "hyprland", ID: "0x55d1c0a0", Title: "~/api", Class: "kitty"}
+	if w == nil || *w != want {
+		t.Errorf("window = %+v, want %+v", w, want)
+	}
+
+	if w, err := parseHyprlandActiveWindow([]byte(`{}`)); err != nil || w != nil {
+		t.Errorf("no active window = %+v, %v, want nil", w, err)
+	}
+	if _, err := parseHyprlandActiveWindow([]byte(`Invalid`)); err == nil {
+		t.Error("invalid output should fail")
+	}
+}
+
+func TestFocusedIntrospectWindow(t *testing.T) {
+	windows := map[uint64]map[string]dbus.Variant{
+		7:  {"title": dbus.MakeVariant("docs"), "wm-class": dbus.MakeVariant("firefox"), "has-focus": dbus.MakeVariant(false)},
+		12: {"title": dbus.MakeVariant("~/api"), "wm-class": dbus.MakeVariant("kitty"), "has-focus": dbus.MakeVariant(true)},
+	}
+	w := focusedIntrospectWindow(windows)
+	want := sessions.Window{Backend: windowBackendGNOME, ID: "12", Title: "~/api", Class: "kitty"}
+	if w == nil || *w != want {
+		t.Errorf("focusedIntrospectWindow = %+v, want %+v", w, want)
+	}
+	delete(windows, 12)
+	if w := focusedIntrospectWindow(windows); w != nil {
+		t.Errorf("focusedIntrospectWindow = %+v, want nil without a focused window", w)
+	}
+}
+
+func TestParseEvalResult(t *testing.T) {
+	tests := []struct {
+		name, output, want, wantErr string
+	}{
+		{"object", `(true, '{"id":"12","title":"api","class":"Code"}')` + "\n", `{"id":"12","title":"api","class":"Code"}`, ""},
+		{"escaped quote", `(true, '{"title":"it\'s"}')`, `{"title":"it's"}`, ""},
+		{"double quoted", `(true, "'activated'")`, `'activated'`, ""},
+		{"blocked", `(false, '')`, "", "blocked"},
+		{"garbage", `(true, x)`, "", "unexpected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEvalResult(tt.output)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("parseEvalResult() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("parseEvalResult() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCaptureWindow_Sway(t *testing.T) {
+	socketPath, _ := startFakeSway(t, `{"id":1,"nodes":[{"id":7,"name":"api","app_id":"kitty","focused":true}]}`)
+	t.Setenv("HYPRLAND_INSTANCE_SIGNATURE", "")
+	t.Setenv("SWAYSOCK", socketPath)
+
+	w, err := CaptureWindow(context.Background(), "kitty")
+	if err != nil {
+		t.Fatalf("CaptureWindow() error = %v", err)
+	}
+	want := sessions.Window{Backend: "sway", ID: "7", Title: "api", Class: "kitty"}
+	if *w != want {
+		t.Errorf("window = %+v, want %+v", w, want)
+	}
+}
+
+func TestCaptureWindow_OtherApp(t *testing.T) {
+	socketPath, _ := startFakeSway(t, `{"id":1,"nodes":[{"id":7,"name":"Inbox","app_id":"firefox","focused":true}]}`)
+	t.Setenv("HYPRLAND_INSTANCE_SIGNATURE", "")
+	t.Setenv("SWAYSOCK", socketPath)
+
+	if w, err := CaptureWindow(context.Background(), "kitty"); err == nil {
+		t.Errorf("CaptureWindow() = %+v, want an error for another app's window", w)
+	}
+}
+
+func TestTerminalFocused_Sway(t *testing.T) {
+	t.Setenv("HYPRLAND_INSTANCE_SIGNATURE", "")
+	tree := `{"id":1,"nodes":[{"id":7,"name":"api","app_id":"kitty","focused":true}]}`
+	tests := []struct {
+		name   string
+		pinned *sessions.Window
+		want   bool
+	}{
+		{"terminal window", nil, true},
+		{"pinned window", &sessions.Window{Backend: "sway", ID: "7"}, true},
+		{"another window of the terminal", &sessions.Window{Backend: "sway", ID: "8"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			socketPath, _ := startFakeSway(t, tree)
+			t.Setenv("SWAYSOCK", socketPath)
+			got, err := TerminalFocused(context.Background(), "kitty", tt.pinned)
+			if err != nil || got != tt.want {
+				t.Errorf("TerminalFocused() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFocusPinnedWindow_Sway(t *testing.T) {
+	socketPath, received := startFakeSway(t, `[{"success":true}]`)
+	t.Setenv("SWAYSOCK", socketPath)
+
+	if err := FocusPinnedWindow(context.Background(), &sessions.Window{Backend: "sway", ID: "7"}); err != nil {
+		t.Fatalf("FocusPinnedWindow() error = %v", err)
+	}
+	if got := <-received; got != "[con_id=7] focus" {
+		t.Errorf("command = %q", got)
+	}
+
+	if err := FocusPinnedWindow(context.Background(), &sessions.Window{Backend: "sway", ID: "7] kill; ["}); err == nil {
+		t.Error("a malformed container ID should be refused")
+	}
+	if err := FocusPinnedWindow(context.Background(), &sessions.Window{Backend: "beos", ID: "1"}); err == nil {
+		t.Error("an unknown backend should fail")
+	}
+}
@@ -0,0 +1,75 @@
+package daemon
+
+import "strings"
+
+// windowCandidate describes a top-level window considered for focusing on Windows.
+type windowCandidate struct {
+	Handle  uintptr
+	Title   string
+	Process string // executable base name, e.g. "Code.exe"
+}
+
+// GetWindowsProcessName returns the executable name that owns a terminal's windows.
+func GetWindowsProcessName(terminalName string) string {
+	switch strings.ToLower(terminalName) {
+	case "code", "vscode", "visual studio code":
+		return "Code.exe"
+	case "windows-terminal", "windowsterminal", "windows terminal", "wt":
+		return "WindowsTerminal.exe"
+	case "wezterm":
+		return "wezterm-gui.exe"
+	case "alacritty":
+		return "alacritty.exe"
+	case "hyper":
+		return "Hyper.exe"
+	case "tabby":
+		return "Tabby.exe"
+	case "conhost", "cmd", "powershell":
+		return "conhost.exe"
+	case "vscodium":
+		return "VSCodium.exe"
+	case "cursor":
+		return "Cursor.exe"
+	case "zed":
+		return "Zed.exe"
+	default:
+		if jetbrainsClass(terminalName) != "" {
+			return strings.ToLower(terminalName) + "64.exe" // idea64.exe, goland64.exe
+		}
+		return strings.ToLower(terminalName) + ".exe"
+	}
+}
+
+// pickWindow selects the window to focus, in order of preference:
+// a window of the terminal's process whose title contains folderName,
+// any window of that process, then any window whose title contains the search term.
+func pickWindow(windows []windowCandidate, terminalName, folderName string) (windowCandidate, bool) {
+	process := GetWindowsProcessName(terminalName)
+	ownedByTerminal := func(w windowCandidate) bool {
+		// Process may be a full image path; split on both separators so
+		// matching behaves the same regardless of the host OS.
+		name := w.Process[strings.LastIndexAny(w.Process, `\/`)+1:]
+		return strings.EqualFold(name, process)
+	}
+
+	if folderName != "" {
+		for _, w := range windows {
+			if ownedByTerminal(w) && strings.Contains(w.Title, folderName) {
+				return w, true
+			}
+		}
+	}
+	for _, w := range windows {
+		if ownedByTerminal(w) {
+			return w, true
+		}
+	}
+
+	searchTerm := strings.ToLower(GetSearchTerm(terminalName))
+	for _, w := range windows {
+		if strings.Contains(strings.ToLower(w.Title), searchTerm) {
+			return w, true
+		}
+	}
+	return windowCandidate{}, false
+}
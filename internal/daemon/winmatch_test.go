@@ -0,0 +1,72 @@
+package daemon
+
+import "testing"
+
+func TestGetWindowsProcessName(t *testing.T) {
+	tests := []struct {
+		terminal string
+		want     string
+	}{
+		{"vscode", "Code.exe"},
+		{"Windows-Terminal", "WindowsTerminal.exe"},
+		{"wezterm", "wezterm-gui.exe"},
+		{"alacritty", "alacritty.exe"},
+		{"mintty", "mintty.exe"},
+		{"Cursor", "Cursor.exe"},
+		{"zed", "Zed.exe"},
+		{"goland", "goland64.exe"},
+	}
+
+	for _, tt := range tests {
+		if got := GetWindowsProcessName(tt.terminal); got != tt.want {
+			t.Errorf("GetWindowsProcessName(%q) = %q, want %q", tt.terminal, got, tt.want)
+		}
+	}
+}
+
+func TestPickWindow(t *testing.T) {
+	windows := []windowCandidate{
+		{Handle: 1, Title: "notes - Notepad", Process: `C:\Windows\notepad.exe`},
+		{Handle: 2, Title: "main.go - other-app - Visual Studio Code", Process: `C:\Programs\VS Code\Code.exe`},
+		{Handle: 3, Title: "main.go - my-app - Visual Studio Code", Process: `C:\Programs\VS Code\Code.exe`},
+		{Handle: 4, Title: "PowerShell", Process: `C:\Program Files\WindowsApps\WindowsTerminal.exe`},
+	}
+
+	tests := []struct {
+		name       string
+		terminal   string
+		folder     string
+		wantHandle uintptr
+		wantOK     bool
+	}{
+		{"folder match preferred", "vscode", "my-app", 3, true},
+		{"process match without folder", "vscode", "", 2, true},
+		{"process match when folder not found", "vscode", "missing", 2, true},
+		{"process name is case-insensitive", "windows-terminal", "", 4, true},
+		{"title fallback", "notepad++", "", 0, false},
+		{"no match", "alacritty", "my-app", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := pickWindow(windows, tt.terminal, tt.folder)
+			if ok != tt.wantOK {
+				t.Fatalf("pickWindow() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && got.Handle != tt.wantHandle {
+				t.Errorf("pickWindow() handle = %d, want %d", got.Handle, tt.wantHandle)
+			}
+		})
+	}
+}
+
+func TestPickWindow_TitleFallback(t *testing.T) {
+	windows := []windowCandidate{
+		{Handle: 7, Title: "Visual Studio Code - Insiders", Process: "Code - Insiders.exe"},
+	}
+
+	got, ok := pickWindow(windows, "vscode", "")
+	if !ok || got.Handle != 7 {
+		t.Errorf("pickWindow() = %v, %v; want handle 7 via title match", got, ok)
+	}
+}
@@ -0,0 +1,160 @@
+//go:build linux
+
+// ABOUTME: wlroots focus through zwlr_foreign_toplevel_management, without wlrctl.
+// ABOUTME: Lists toplevels on the Wayland socket and activates the terminal's window.
+package daemon
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+)
+
+// wlrToplevelManager is the wlroots foreign toplevel management interface
+const wlrToplevelManager = "zwlr_foreign_toplevel_manager_v1"
+
+// Opcodes of zwlr_foreign_toplevel_manager_v1 and its handles
+const (
+	wlrManagerToplevel        uint16 = 0 // event: new handle
+	wlrHandleTitle            uint16 = 0 // event
+	wlrHandleAppID            uint16 = 1 // event
+	wlrHandleState            uint16 = 4 // event
+	wlrHandleUnsetMinimized   uint16 = 3 // request
+	wlrHandleActivate         uint16 = 4 // request
+	wlrHandleStateMinimized   uint32 = 1 // state array entry
+	wlrToplevelManagerVersion        = 1
+)
+
+// wlrToplevel is a toplevel listed by the manager
+type wlrToplevel struct {
+	toplevel
+	minimized bool
+}
+
+// TryWlrForeignToplevel focuses a window on wlroots compositors (Sway,
+// river, labwc, Wayfire) by speaking zwlr_foreign_toplevel_management on
+// the Wayland socket, so wlrctl does not need to be installed.
+func TryWlrForeignToplevel(ctx context.Context, terminalName, folderName string) error {
+	socketPath, err := waylandSocketPath()
+	if err != nil {
+		return err
+	}
+	w, err := focusWlrToplevel(ctx, socketPath, func(windows []toplevel) *toplevel {
+		return pickToplevel(windows, terminalName, folderName)
+	})
+	if err != nil {
+		return err
+	}
+	return foreignToplevelShowWindow(ctx, func(n *swayNode) bool {
+		return n.class() == w.appID && n.Name == w.title
+	})
+}
+
+// wlrForeignToplevelAvailable reports whether the compositor offers
+// zwlr_foreign_toplevel_manager_v1
+func wlrForeignToplevelAvailable(ctx context.Context) bool {
+	socketPath, err := waylandSocketPath()
+	if err != nil {
+		return false
+	}
+	c, err := dialWayland(ctx, socketPath)
+	if err != nil {
+		return false
+	}
+	defer c.Close()
+	_, globals, err := c.globals()
+	_, ok := globals[wlrToplevelManager]
+	return err == nil && ok
+}
+
+// focusWlrToplevel lists the toplevels on the compositor at socketPath
+// and activates the one pick returns, restoring it when minimized
+func focusWlrToplevel(ctx context.Context, socketPath string, pick func([]toplevel) *toplevel) (*toplevel, error) {
+	c, err := dialWayland(ctx, socketPath)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	registry, globals, err := c.globals()
+	if err != nil {
+		return nil, err
+	}
+	for _, iface := range []string{wlrToplevelManager, wlSeat} {
+		if _, ok := globals[iface]; !ok {
+			return nil, fmt.Errorf("compositor does not offer %s", iface)
+		}
+	}
+	manager, err := c.bind(registry, globals[wlrToplevelManager], wlrToplevelManager, wlrToplevelManagerVersion)
+	if err != nil {
+		return nil, err
+	}
+	seat, err := c.bind(registry, globals[wlSeat], wlSeat, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	// The manager announces every toplevel after the bind; the second
+	// round trip catches details sent in a later batch
+	handles := map[uint32]*wlrToplevel{}
+	var order []uint32
+	collect := func(ev waylandEvent) {
+		if ev.sender == manager && ev.opcode == wlrManagerToplevel {
+			id := ev.args.uint()
+			handles[id] = &wlrToplevel{toplevel: toplevel{id: strconv.FormatUint(uint64(id), 10)}}
+			order = append(order, id)
+			return
+		}
+		if w := handles[ev.sender]; w != nil {
+			switch ev.opcode {
+			case wlrHandleTitle:
+				w.title = ev.args.string()
+			case wlrHandleAppID:
+				w.appID = ev.args.string()
+			case wlrHandleState:
+				w.minimized = hasWlrState(ev.args.array(), wlrHandleStateMinimized)
+			}
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if err := c.roundtrip(collect); err != nil {
+			return nil, err
+		}
+	}
+
+	windows := make([]toplevel, 0, len(order))
+	for _, id := range order {
+		windows = append(windows, handles[id].toplevel)
+	}
+	w := pick(windows)
+	if w == nil {
+		return nil, fmt.Errorf("no matching window among %d toplevels", len(windows))
+	}
+
+	id, _ := strconv.ParseUint(w.id, 10, 32)
+	handle := uint32(id)
+	if handles[handle].minimized {
+		if err := c.send(handle, wlrHandleUnsetMinimized); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.send(handle, wlrHandleActivate, seat); err != nil {
+		return nil, err
+	}
+	// Wait for the compositor to process the activation, or report an error
+	if err := c.roundtrip(func(waylandEvent) {}); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// hasWlrState reports whether a state array holds state
+func hasWlrState(states []byte, state uint32) bool {
+	for i := 0; i+4 <= len(states); i += 4 {
+		if binary.LittleEndian.Uint32(states[i:]) == state {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+//go:build linux
+
+package daemon
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fakeWlrWindow is a window the fake wlroots compositor lists
+type fakeWlrWindow struct {
+	appID, title string
+	minimized    bool
+}
+
+// startFakeWlr serves one client like a wlroots compositor and reports
+// the requests sent to toplevel handles, as "<handle> <request>"
+func startFakeWlr(t *testing.T, windows []fakeWlrWindow) (string, <-chan string) {
+	t.Helper()
+
+	requests := make(chan string, 4)
+	socketPath := startFakeCompositor(t, []string{"wl_compositor", wlrToplevelManager, wlSeat}, func(send fakeWaylandSend, iface string, id uint32) {
+		if iface != wlrToplevelManager {
+			return
+		}
+		for i, w := range windows {
+			handle := 0xff000000 + uint32(i)
+			send(id, wlrManagerToplevel, handle)
+			send(handle, wlrHandleTitle, w.title)
+			send(handle, wlrHandleAppID, w.appID)
+			if w.minimized {
+				send(handle, wlrHandleState, binary.LittleEndian.AppendUint32(nil, wlrHandleStateMinimized))
+			}
+		}
+	}, func(iface string, object uint32, opcode uint16, args *waylandArgs) {
+		switch opcode {
+		case wlrHandleUnsetMinimized:
+			requests <- fmt.Sprintf("%d unset_minimized", object-0xff000000)
+		case wlrHandleActivate:
+			requests <- fmt.Sprintf("%d activate", object-0xff000000)
+		}
+	})
+	return socketPath, requests
+}
+
+func TestFocusWlrToplevel(t *testing.T) {
+	socketPath, requests := startFakeWlr(t, []fakeWlrWindow{
+		{appID: "firefox", title: "Inbox"},
+		{appID: "kitty", title: "zsh", minimized: true},
+	})
+
+	w, err := focusWlrToplevel(context.Background(), socketPath, func(windows []toplevel) *toplevel {
+		return pickToplevel(windows, "kitty", "")
+	})
+	if err != nil {
+		t.Fatalf("focusWlrToplevel() error = %v", err)
+	}
+	if w.appID != "kitty" || w.title != "zsh" {
+		t.Errorf("focused %+v, want the kitty window", w)
+	}
+	for _, want := range []string{"1 unset_minimized", "1 activate"} {
+		if got := <-requests; got != want {
+			t.Errorf("request = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestFocusWlrToplevel_NoMatch(t *testing.T) {
+	socketPath, _ := startFakeWlr(t, []fakeWlrWindow{{appID: "firefox", title: "Inbox"}})
+
+	_, err := focusWlrToplevel(context.Background(), socketPath, func(windows []toplevel) *toplevel {
+		return pickToplevel(windows, "kitty", "")
+	})
+	if err == nil || !strings.Contains(err.Error(), "no matching window") {
+		t.Errorf("error = %v, want no matching window", err)
+	}
+}
+
+func TestHasWlrState(t *testing.T) {
+	states := binary.LittleEndian.AppendUint32(binary.LittleEndian.AppendUint32(nil, 2), wlrHandleStateMinimized)
+	if !hasWlrState(states, wlrHandleStateMinimized) {
+		t.Error("hasWlrState() missed minimized")
+	}
+	if hasWlrState(states[:4], wlrHandleStateMinimized) || hasWlrState(nil, wlrHandleStateMinimized) {
+		t.Error("hasWlrState() found a state not in the array")
+	}
+}
@@ -0,0 +1,137 @@
+//go:build linux
+
+// ABOUTME: Switches to the workspace or desktop of the window being focused.
+// ABOUTME: Covers focus methods that only raise the window or mark it urgent when it is elsewhere.
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// gnomeActivateWindowJS defines activateWindow(win) for Shell.Eval scripts.
+// MetaWindow.activate only marks a window on another workspace as demanding
+// attention, so that workspace is activated with the window focused instead.
+// Windows on every workspace (e.g. on secondary monitors) are activated in place.
+const gnomeActivateWindowJS = `
+	function activateWindow(win) {
+		let time = global.get_current_time();
+		let workspace = win.get_workspace();
+		if (workspace && !win.is_on_all_workspaces() && workspace !== global.workspace_manager.get_active_workspace()) {
+			workspace.activate_with_focus(win, time);
+		} else {
+			win.activate(time);
+		}
+	}
+`
+
+// swayShowWindow switches to the workspace of the first window that
+// matches when that workspace is not shown on its output. wlr-foreign-toplevel
+// activation (wlrctl or the built-in client) only marks such a window urgent
+// with Sway's default focus_on_window_activation.
+func swayShowWindow(ctx context.Context, socketPath string, match func(*swayNode) bool) error {
+	payload, err := swayIPCRequest(ctx, socketPath, swayIPCGetTree, "")
+	if err != nil {
+		return err
+	}
+	var root swayNode
+	if err := json.Unmarshal(payload, &root); err != nil {
+		return fmt.Errorf("invalid GET_TREE reply: %w", err)
+	}
+	workspace, shown := root.windowWorkspace(match, "")
+	if workspace == "" || shown || strings.HasPrefix(workspace, "__i3") {
+		return nil // Not found, already visible, or in the scratchpad
+	}
+	return swayRunCommand(ctx, socketPath, fmt.Sprintf("workspace %q", workspace))
+}
+
+// windowWorkspace returns the workspace holding the first window below n
+// that matches, and whether it is its output's current workspace ("" = no
+// such window). current is the current workspace of the enclosing output.
+func (n *swayNode) windowWorkspace(match func(*swayNode) bool, current string) (string, bool) {
+	switch n.Type {
+	case "output":
+		current = n.CurrentWorkspace
+	case "workspace":
+		if n.hasWindow(match) {
+			return n.Name, n.Name == current
+		}
+		return "", false
+	}
+	for _, children := range [][]swayNode{n.Nodes, n.FloatingNodes} {
+		for i := range children {
+			if name, shown := children[i].windowWorkspace(match, current); name != "" {
+				return name, shown
+			}
+		}
+	}
+	return "", false
+}
+
+// hasWindow reports whether a window below n, or n itself, matches
+func (n *swayNode) hasWindow(match func(*swayNode) bool) bool {
+	isWindow := (n.Type == "con" || n.Type == "floating_con") && len(n.Nodes) == 0 && len(n.FloatingNodes) == 0
+	if isWindow && match(n) {
+		return true
+	}
+	for _, children := range [][]swayNode{n.Nodes, n.FloatingNodes} {
+		for i := range children {
+			if children[i].hasWindow(match) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// xdotoolActivateOnDesktop activates a window of classPattern on another
+// desktop, preferring one showing folderName. Most window managers unmap
+// windows on inactive desktops, so "search --onlyvisible" misses them;
+// among all windows of the class, only managed ones have a desktop.
+func xdotoolActivateOnDesktop(ctx context.Context, classPattern, folderName string) error {
+	var searches [][]string
+	if folderName != "" {
+		searches = append(searches, xdotoolSearch(ctx, "--all", "--class", classPattern, "--name", regexp.QuoteMeta(folderName)))
+	}
+	searches = append(searches, xdotoolSearch(ctx, "--class", classPattern))
+
+	for _, windowIDs := range searches {
+		for _, id := range windowIDs {
+			desktop, err := commandLine(ctx, "xdotool", "get_desktop_for_window", id)
+			if err != nil {
+				continue // Not a managed window
+			}
+			if err := switchX11Desktop(ctx, desktop); err != nil {
+				return err
+			}
+			if output, err := exec.CommandContext(ctx, "xdotool", "windowactivate", id).CombinedOutput(); err != nil {
+				return fmt.Errorf("xdotool windowactivate failed: %w, output: %s", err, string(output))
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("no windows found via xdotool")
+}
+
+// switchX11Desktop switches to an EWMH desktop with wmctrl, or xdotool
+// when wmctrl is not installed. Desktop -1 means the window is on all of them.
+func switchX11Desktop(ctx context.Context, desktop string) error {
+	if desktop == "-1" {
+		return nil
+	}
+	if current, err := commandLine(ctx, "xdotool", "get_desktop"); err == nil && current == desktop {
+		return nil
+	}
+	cmd := exec.CommandContext(ctx, "xdotool", "set_desktop", desktop)
+	if _, err := exec.LookPath("wmctrl"); err == nil {
+		cmd = exec.CommandContext(ctx, "wmctrl", "-s", desktop)
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("switching to desktop %s failed: %w, output: %s", desktop, err, string(output))
+	}
+	return nil
+}
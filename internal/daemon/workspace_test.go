@@ -0,0 +1,79 @@
+//go:build linux
+
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+// swayTestTree has a kitty window on workspace 2 of an output showing 1,
+// and a firefox window on the shown workspace 3 of another output
+const swayTestTree = `{"id":1,"type":"root","nodes":[
+	{"id":2,"type":"output","name":"DP-1","current_workspace":"1","nodes":[
+		{"id":3,"type":"workspace","name":"1","nodes":[]},
+		{"id":4,"type":"workspace","name":"2","nodes":[
+			{"id":5,"type":"con","nodes":[{"id":6,"type":"con","name":"api","app_id":"kitty"}]}
+		]}
+	]},
+	{"id":7,"type":"output","name":"HDMI-1","current_workspace":"3","nodes":[
+		{"id":8,"type":"workspace","name":"3","floating_nodes":[{"id":9,"type":"floating_con","name":"Inbox","app_id":"firefox"}]}
+	]}
+]}`
+
+func appIDIs(appID string) func(*swayNode) bool {
+	return func(n *swayNode) bool { return n.class() == appID }
+}
+
+func TestSwayNodeWindowWorkspace(t *testing.T) {
+	var root swayNode
+	if err := json.Unmarshal([]byte(swayTestTree), &root); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		appID     string
+		workspace string
+		shown     bool
+	}{
+		{"kitty", "2", false},
+		{"firefox", "3", true},
+		{"foot", "", false},
+	}
+	for _, tt := range tests {
+		workspace, shown := root.windowWorkspace(appIDIs(tt.appID), "")
+		if workspace != tt.workspace || shown != tt.shown {
+			t.Errorf("windowWorkspace(%s) = %q, %v, want %q, %v", tt.appID, workspace, shown, tt.workspace, tt.shown)
+		}
+	}
+}
+
+func TestSwayShowWindow_SwitchesWorkspace(t *testing.T) {
+	socketPath, received := startFakeSway(t, swayTestTree, `[{"success":true}]`)
+
+	if err := swayShowWindow(context.Background(), socketPath, appIDIs("kitty")); err != nil {
+		t.Fatalf("swayShowWindow() error = %v", err)
+	}
+	<-received // GET_TREE
+	if got := <-received; got != `workspace "2"` {
+		t.Errorf("command = %q, want the kitty window's workspace", got)
+	}
+}
+
+func TestSwayShowWindow_AlreadyShown(t *testing.T) {
+	socketPath, received := startFakeSway(t, swayTestTree)
+
+	if err := swayShowWindow(context.Background(), socketPath, appIDIs("firefox")); err != nil {
+		t.Fatalf("swayShowWindow() error = %v", err)
+	}
+	if n := len(received); n != 1 {
+		t.Errorf("requests = %d, want only GET_TREE", n)
+	}
+}
+
+func TestSwitchX11Desktop_Sticky(t *testing.T) {
+	if err := switchX11Desktop(context.Background(), "-1"); err != nil {
+		t.Errorf("switchX11Desktop(-1) error = %v, want nil for a window on all desktops", err)
+	}
+}
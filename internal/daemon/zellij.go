@@ -0,0 +1,37 @@
+//go:build linux
+
+// ABOUTME: Zellij tab selection for click-to-focus.
+// ABOUTME: Switches the originating Zellij session to the tab recorded when the notification was sent.
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// buildZellijSelectArgs returns zellij arguments that switch session to tabName.
+func buildZellijSelectArgs(session, tabName string) []string {
+	return []string{"-s", session, "action", "go-to-tab-name", tabName}
+}
+
+// FocusZellijTab switches the Zellij session to the tab named tabName.
+func FocusZellijTab(ctx context.Context, session, tabName string) error {
+	if session == "" || tabName == "" {
+		return fmt.Errorf("zellij session and tab name are required")
+	}
+	// Arguments are passed without a shell, but a leading dash would still be parsed as a flag
+	if strings.HasPrefix(session, "-") || strings.HasPrefix(tabName, "-") {
+		return fmt.Errorf("invalid zellij target: session=%q tab=%q", session, tabName)
+	}
+	if _, err := exec.LookPath("zellij"); err != nil {
+		return fmt.Errorf("zellij not installed")
+	}
+
+	output, err := exec.CommandContext(ctx, "zellij", buildZellijSelectArgs(session, tabName)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("zellij go-to-tab-name failed: %w, output: %s", err, string(output))
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+//go:build linux
+
+package daemon
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestBuildZellijSelectArgs(t *testing.T) {
+	got := strings.Join(buildZellijSelectArgs("dev", "Tab #2"), "|")
+	want := "-s|dev|action|go-to-tab-name|Tab #2"
+
+	if got != want {
+		t.Errorf("buildZellijSelectArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestFocusZellijTab_InvalidTarget(t *testing.T) {
+	tests := []struct {
+		session string
+		tab     string
+	}{
+		{"", "Tab #1"},
+		{"dev", ""},
+		{"-dev", "Tab #1"},
+		{"dev", "--help"},
+	}
+
+	for _, tt := range tests {
+		if err := FocusZellijTab(context.Background(), tt.session, tt.tab); err == nil {
+			t.Errorf("FocusZellijTab(%q, %q) should fail", tt.session, tt.tab)
+		}
+	}
+}
@@ -0,0 +1,391 @@
+// Package dashboard serves a web page on localhost with the active
+// sessions, recent notifications, backend health and rule hits, and
+// buttons to focus a session or mute a project. It reads the same files
+// as the sessions, history and status commands; the daemon serves it when
+// dashboard.enabled is set.
+package dashboard
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/breaker"
+	"github.com/777genius/claude-notifications/internal/dnd"
+	"github.com/777genius/claude-notifications/internal/history"
+	"github.com/777genius/claude-notifications/internal/rules"
+	"github.com/777genius/claude-notifications/internal/sessionname"
+	"github.com/777genius/claude-notifications/internal/sessions"
+)
+
+const (
+	// recentLimit is how many of the newest history entries are listed
+	recentLimit = 50
+
+	// DefaultMute is how long the mute button silences a project
+	DefaultMute = time.Hour
+
+	// tokenCookie holds the token once the dashboard URL was opened
+	tokenCookie = "claude-notifications-dashboard"
+)
+
+// Source is where the dashboard reads its state from
+type Source struct {
+	Sessions *sessions.Registry
+	History  *history.Store  // nil = history disabled
+	Breakers *breaker.Store  // nil = circuit breakers disabled
+	RuleHits *rules.HitStore // nil = rule hits unknown
+	DND      *dnd.Manager
+}
+
+// Actions are run by the dashboard buttons
+type Actions struct {
+	Focus func(sessionID string) error // Focus the terminal of a session
+}
+
+// State is what the dashboard shows
+type State struct {
+	Time          time.Time       `json:"time"`
+	Sessions      []Session       `json:"sessions"`      // Oldest first
+	Notifications []history.Entry `json:"notifications"` // Newest first
+	Backends      []Backend       `json:"backends"`
+	Rules         []RuleHit       `json:"rules"` // Most recently matched first
+	DND           DND             `json:"dnd"`
+}
+
+// Session is an active session
+type Session struct {
+	ID           string    `json:"id"`
+	Label        string    `json:"label"` // As in notification messages, e.g. "zesty 73b5e210"
+	Project      string    `json:"project"`
+	CWD          string    `json:"cwd"`
+	Terminal     string    `json:"terminal,omitempty"`
+	StartedAt    time.Time `json:"started_at"`
+	LastActivity time.Time `json:"last_activity"`
+	Working      bool      `json:"working"`        // A prompt is being worked on
+	Step         string    `json:"step,omitempty"` // Progress on the prompt, e.g. "3/7 Run the tests"
+	Muted        bool      `json:"muted"`          // Notifications of the project are muted
+}
+
+// Backend is the health of one backend, from its last delivery and its
+// circuit breaker
+type Backend struct {
+	Name        string     `json:"name"`
+	LastTime    time.Time  `json:"last_time"`
+	LastResult  string     `json:"last_result,omitempty"` // history.ResultDelivered, ResultFailed or ResultSkipped
+	LastError   string     `json:"last_error,omitempty"`
+	Failures    int        `json:"failures"`               // Consecutive failures
+	PausedUntil *time.Time `json:"paused_until,omitempty"` // The circuit breaker is open until then
+}
+
+// Healthy reports whether the last delivery worked and the backend is not failing
+func (b Backend) Healthy() bool {
+	return b.Failures == 0 && b.PausedUntil == nil && b.LastResult != history.ResultFailed
+}
+
+// RuleHit is how often a rule matched
+type RuleHit struct {
+	Name string `json:"name"`
+	rules.Hit
+}
+
+// DND is the do-not-disturb state and the muted projects
+type DND struct {
+	Active bool       `json:"active"`
+	Reason string     `json:"reason,omitempty"`
+	Until  *time.Time `json:"until,omitempty"`
+	Muted  []Mute     `json:"muted"` // Muted projects, by name
+}
+
+// Mute is a muted project
+type Mute struct {
+	Project string     `json:"project"`
+	Until   *time.Time `json:"until,omitempty"` // nil = until unmuted
+}
+
+// Load reads the state at now. What cannot be read is left out, so the
+// page keeps showing the rest.
+func (src Source) Load(now time.Time) State {
+	st := State{Time: now, Sessions: []Session{}, Notifications: []history.Entry{}, Backends: []Backend{}, Rules: []RuleHit{}, DND: DND{Muted: []Mute{}}}
+
+	muted := map[string]time.Time{}
+	if src.DND != nil {
+		status := src.DND.Status(now)
+		st.DND.Active, st.DND.Reason = status.Active, status.Reason
+		if !status.Until.IsZero() {
+			st.DND.Until = &status.Until
+		}
+		if m, err := src.DND.MutedProjects(now); err == nil {
+			muted = m
+		}
+		for project, until := range muted {
+			mute := Mute{Project: project}
+			if !until.IsZero() {
+				until := until
+				mute.Until = &until
+			}
+			st.DND.Muted = append(st.DND.Muted, mute)
+		}
+		sort.Slice(st.DND.Muted, func(i, j int) bool { return st.DND.Muted[i].Project < st.DND.Muted[j].Project })
+	}
+
+	if src.Sessions != nil {
+		active, _ := src.Sessions.Active(now)
+		for _, s := range active {
+			_, isMuted := muted[s.Project()]
+			st.Sessions = append(st.Sessions, Session{
+				ID:           s.ID,
+				Label:        sessionname.GenerateSessionLabel(s.ID),
+				Project:      s.Project(),
+				CWD:          s.CWD,
+				Terminal:     s.Terminal,
+				StartedAt:    s.StartedAt,
+				LastActivity: s.LastActivity,
+				Working:      s.Working(),
+				Step:         step(s.Progress),
+				Muted:        isMuted,
+			})
+		}
+	}
+
+	backends := map[string]*Backend{}
+	backend := func(name string) *Backend {
+		if backends[name] == nil {
+			backends[name] = &Backend{Name: name}
+		}
+		return backends[name]
+	}
+	if src.History != nil {
+		entries, _ := src.History.Query(history.Filter{Limit: recentLimit})
+		for i := len(entries) - 1; i >= 0; i-- {
+			e := entries[i]
+			st.Notifications = append(st.Notifications, e)
+			if b := backend(e.Backend); b.LastTime.IsZero() {
+				b.LastTime, b.LastResult, b.LastError = e.Time, e.Result, e.Error
+			}
+		}
+	}
+	if src.Breakers != nil {
+		states, _ := src.Breakers.States()
+		for name, bs := range states {
+			b := backend(name)
+			b.Failures = bs.Failures
+			if b.LastError == "" {
+				b.LastError = bs.LastError
+			}
+			if bs.Open(now) {
+				until := bs.OpenUntil
+				b.PausedUntil = &until
+			}
+		}
+	}
+	for _, b := range backends {
+		st.Backends = append(st.Backends, *b)
+	}
+	sort.Slice(st.Backends, func(i, j int) bool { return st.Backends[i].Name < st.Backends[j].Name })
+
+	if src.RuleHits != nil {
+		hits, _ := src.RuleHits.Hits()
+		for _, name := range rules.HitNames(hits) {
+			st.Rules = append(st.Rules, RuleHit{Name: name, Hit: hits[name]})
+		}
+	}
+	return st
+}
+
+// step describes the progress of a session on its prompt: "3/7 Run the tests"
+func step(p *sessions.Progress) string {
+	if p == nil {
+		return ""
+	}
+	var parts []string
+	if p.Total > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d", p.Done, p.Total))
+	}
+	if p.Step != "" {
+		parts = append(parts, p.Step)
+	} else if p.LastTool != "" {
+		parts = append(parts, p.LastTool)
+	}
+	return strings.Join(parts, " ")
+}
+
+// server handles the dashboard requests
+type server struct {
+	src  func() Source
+	act  Actions
+	page *template.Template
+}
+
+// NewToken returns a random token for New
+func NewToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate dashboard token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// New returns the dashboard handler. src is called on every request, so
+// it can follow config reloads. Requests must carry token, since any local
+// user can connect to a TCP port; requests whose Host is not a loopback
+// address are refused, so a web page cannot reach the dashboard through
+// DNS rebinding, and buttons must be posted from the dashboard itself.
+func New(src func() Source, act Actions, token string) http.Handler {
+	s := &server{src: src, act: act, page: template.Must(template.New("dashboard").Funcs(funcs).Parse(pageTemplate))}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.serveIndex)
+	mux.HandleFunc("/api/state", s.serveState)
+	mux.HandleFunc("/focus", s.post(s.focus))
+	mux.HandleFunc("/mute", s.post(s.mute))
+	mux.HandleFunc("/unmute", s.post(s.unmute))
+	return localOnly(withToken(token, mux))
+}
+
+// serveIndex renders the page
+func (s *server) serveIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	st := s.src().Load(time.Now())
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = s.page.Execute(w, page{State: st, Message: r.URL.Query().Get("message")})
+}
+
+// serveState returns the state as JSON for scripts
+func (s *server) serveState(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.src().Load(time.Now()))
+}
+
+// post wraps a button action: it only accepts POST from the dashboard's
+// own origin and redirects back to the page with the outcome
+func (s *server) post(action func(r *http.Request) (string, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" && origin != "http://"+r.Host {
+			http.Error(w, "cross-origin request refused", http.StatusForbidden)
+			return
+		}
+		message, err := action(r)
+		if err != nil {
+			message = "Error: " + err.Error()
+		}
+		http.Redirect(w, r, "/?message="+url.QueryEscape(message), http.StatusSeeOther)
+	}
+}
+
+// focus focuses the terminal of the session in the form
+func (s *server) focus(r *http.Request) (string, error) {
+	id := r.FormValue("session")
+	if id == "" || s.act.Focus == nil {
+		return "", fmt.Errorf("no session to focus")
+	}
+	if err := s.act.Focus(id); err != nil {
+		return "", err
+	}
+	return "Focused " + sessionname.GenerateSessionLabel(id), nil
+}
+
+// mute mutes the project in the form for DefaultMute, or for the
+// duration in "for" (0 = until unmuted)
+func (s *server) mute(r *http.Request) (string, error) {
+	project := r.FormValue("project")
+	mgr := s.src().DND
+	if project == "" || mgr == nil {
+		return "", fmt.Errorf("no project to mute")
+	}
+	d := DefaultMute
+	if v := r.FormValue("for"); v != "" {
+		var err error
+		if d, err = time.ParseDuration(v); err != nil || d < 0 {
+			return "", fmt.Errorf("invalid mute duration %q", v)
+		}
+	}
+	var until time.Time
+	if d > 0 {
+		until = time.Now().Add(d)
+	}
+	if err := mgr.MuteProject(project, until); err != nil {
+		return "", err
+	}
+	if until.IsZero() {
+		return "Muted " + project + " until unmuted", nil
+	}
+	return "Muted " + project + " until " + until.Format("15:04"), nil
+}
+
+// unmute unmutes the project in the form
+func (s *server) unmute(r *http.Request) (string, error) {
+	project := r.FormValue("project")
+	mgr := s.src().DND
+	if project == "" || mgr == nil {
+		return "", fmt.Errorf("no project to unmute")
+	}
+	if err := mgr.UnmuteProject(project); err != nil {
+		return "", err
+	}
+	return "Unmuted " + project, nil
+}
+
+// withToken refuses requests without token. Opening a page with
+// ?token=<token> stores it in a cookie and redirects to the page without
+// it, so the page and its buttons work from then on; scripts send it as a
+// bearer token.
+func withToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if sent := query.Get("token"); sent != "" && r.Method == http.MethodGet && validToken(sent, token) {
+			http.SetCookie(w, &http.Cookie{Name: tokenCookie, Value: token, Path: "/", HttpOnly: true, SameSite: http.SameSiteStrictMode})
+			query.Del("token")
+			target := *r.URL
+			target.RawQuery = query.Encode()
+			http.Redirect(w, r, target.RequestURI(), http.StatusSeeOther)
+			return
+		}
+		sent, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if cookie, err := r.Cookie(tokenCookie); err == nil && sent == "" {
+			sent = cookie.Value
+		}
+		if !validToken(sent, token) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="claude-notifications dashboard"`)
+			http.Error(w, `missing or invalid token: open the URL printed by "claude-notifications daemon dashboard"`, http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validToken reports whether sent is the dashboard token
+func validToken(sent, token string) bool {
+	return token != "" && subtle.ConstantTimeCompare([]byte(sent), []byte(token)) == 1
+}
+
+// localOnly refuses requests whose Host header is not a loopback address
+func localOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if ip := net.ParseIP(strings.Trim(host, "[]")); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			http.Error(w, "the dashboard only answers on localhost", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,180 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/breaker"
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/dnd"
+	"github.com/777genius/claude-notifications/internal/history"
+	"github.com/777genius/claude-notifications/internal/rules"
+	"github.com/777genius/claude-notifications/internal/sessions"
+)
+
+// newSource creates a source with one session in api, a delivered and a
+// failed notification, a paused backend and a rule hit
+func newSource(t *testing.T) Source {
+	t.Helper()
+	dir := t.TempDir()
+	now := time.Now()
+	src := Source{
+		Sessions: sessions.NewRegistry(dir),
+		History:  history.NewStore(dir, 0),
+		Breakers: breaker.NewStore(dir, breaker.Settings{Failures: 1, Backoff: time.Minute, MaxBackoff: time.Hour}),
+		RuleHits: rules.NewHitStore(dir),
+		DND:      dnd.NewManager(dir, config.DNDConfig{}),
+	}
+	if err := src.Sessions.Start("s1", "/work/api", "kitty", now.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []history.Entry{
+		{Time: now.Add(-2 * time.Minute), Title: "✅ Completed", Project: "api", Backend: "desktop", Result: history.ResultDelivered},
+		{Time: now.Add(-time.Minute), Title: "✅ Completed", Project: "api", Backend: "ntfy", Result: history.ResultFailed, Error: "timeout"},
+	} {
+		if err := src.History.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := src.Breakers.Record("ntfy", errors.New("timeout"), now); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.RuleHits.Record([]string{"quiet-nights"}, "task_complete", "api", now); err != nil {
+		t.Fatal(err)
+	}
+	return src
+}
+
+func TestSourceLoad(t *testing.T) {
+	src := newSource(t)
+	st := src.Load(time.Now())
+
+	if len(st.Sessions) != 1 || st.Sessions[0].Project != "api" || st.Sessions[0].Muted {
+		t.Errorf("Sessions = %+v, want api, not muted", st.Sessions)
+	}
+	if len(st.Notifications) != 2 || st.Notifications[0].Backend != "ntfy" {
+		t.Errorf("Notifications = %+v, want newest first", st.Notifications)
+	}
+	if len(st.Backends) != 2 {
+		t.Fatalf("Backends = %+v, want desktop and ntfy", st.Backends)
+	}
+	if desktop := st.Backends[0]; desktop.Name != "desktop" || !desktop.Healthy() {
+		t.Errorf("desktop = %+v, want healthy", desktop)
+	}
+	if ntfy := st.Backends[1]; ntfy.Healthy() || ntfy.Failures != 1 || ntfy.PausedUntil == nil || ntfy.LastError != "timeout" {
+		t.Errorf("ntfy = %+v, want paused after one failure", ntfy)
+	}
+	if len(st.Rules) != 1 || st.Rules[0].Name != "quiet-nights" || st.Rules[0].Count != 1 {
+		t.Errorf("Rules = %+v", st.Rules)
+	}
+
+	if err := src.DND.MuteProject("api", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	st = src.Load(time.Now())
+	if !st.Sessions[0].Muted || len(st.DND.Muted) != 1 || st.DND.Muted[0].Until != nil {
+		t.Errorf("after mute: session %+v, muted %+v", st.Sessions[0], st.DND.Muted)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	src := newSource(t)
+	var focused string
+	const token = "0123456789abcdef"
+	h := New(func() Source { return src }, Actions{Focus: func(id string) error {
+		focused = id
+		return nil
+	}}, token)
+	do := func(method, target, host string, form url.Values, header http.Header) *httptest.ResponseRecorder {
+		body := ""
+		if form != nil {
+			body = form.Encode()
+		}
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Host = host
+		if form != nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		if req.Header.Get("Authorization") == "" {
+			req.AddCookie(&http.Cookie{Name: tokenCookie, Value: token})
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("GET", "/", "127.0.0.1:9878", nil, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET / = %d", w.Code)
+	}
+	for _, want := range []string{"api", "kitty", "paused until", "quiet-nights", "Mute project 1h"} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("page does not show %q", want)
+		}
+	}
+
+	w = do("GET", "/api/state", "localhost:9878", nil, nil)
+	var st State
+	if err := json.NewDecoder(w.Body).Decode(&st); err != nil || len(st.Sessions) != 1 {
+		t.Errorf("GET /api/state = %d %+v, %v", w.Code, st, err)
+	}
+
+	// Other local users do not have the token
+	for _, auth := range []string{"Bearer wrong", "Bearer "} {
+		if w := do("GET", "/api/state", "127.0.0.1:9878", nil, http.Header{"Authorization": {auth}}); w.Code != http.StatusUnauthorized {
+			t.Errorf("GET /api/state with %q = %d, want 401", auth, w.Code)
+		}
+	}
+	if w := do("GET", "/api/state", "127.0.0.1:9878", nil, http.Header{"Authorization": {"Bearer " + token}}); w.Code != http.StatusOK {
+		t.Errorf("GET /api/state with the bearer token = %d, want 200", w.Code)
+	}
+	req := httptest.NewRequest("GET", "/?token="+token, nil)
+	req.Host = "127.0.0.1:9878"
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if cookies := w.Result().Cookies(); w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/" ||
+		len(cookies) != 1 || cookies[0].Value != token || !cookies[0].HttpOnly {
+		t.Errorf("GET /?token= = %d to %q with cookies %v, want the token stored and a redirect to /", w.Code, w.Header().Get("Location"), cookies)
+	}
+
+	// DNS rebinding and cross-site forms are refused
+	if w := do("GET", "/", "evil.example:9878", nil, nil); w.Code != http.StatusForbidden {
+		t.Errorf("foreign Host = %d, want 403", w.Code)
+	}
+	form := url.Values{"project": {"api"}}
+	if w := do("POST", "/mute", "127.0.0.1:9878", form, http.Header{"Origin": {"http://evil.example"}}); w.Code != http.StatusForbidden {
+		t.Errorf("cross-origin POST = %d, want 403", w.Code)
+	}
+	if w := do("GET", "/mute?project=api", "127.0.0.1:9878", nil, nil); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /mute = %d, want 405", w.Code)
+	}
+	if src.DND.ProjectMuted("api", time.Now()) {
+		t.Fatal("refused requests must not mute")
+	}
+
+	w = do("POST", "/mute", "127.0.0.1:9878", form, http.Header{"Origin": {"http://127.0.0.1:9878"}})
+	if w.Code != http.StatusSeeOther || !src.DND.ProjectMuted("api", time.Now()) {
+		t.Errorf("POST /mute = %d, want api muted", w.Code)
+	}
+	if src.DND.ProjectMuted("api", time.Now().Add(2*DefaultMute)) {
+		t.Error("the mute should end after DefaultMute")
+	}
+	do("POST", "/unmute", "127.0.0.1:9878", form, nil)
+	if src.DND.ProjectMuted("api", time.Now()) {
+		t.Error("POST /unmute should unmute api")
+	}
+
+	w = do("POST", "/focus", "127.0.0.1:9878", url.Values{"session": {"s1"}}, nil)
+	if focused != "s1" || !strings.Contains(w.Header().Get("Location"), "Focused") {
+		t.Errorf("POST /focus focused %q, redirect %q", focused, w.Header().Get("Location"))
+	}
+}
@@ -0,0 +1,142 @@
+package dashboard
+
+import (
+	"html/template"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/sessions"
+)
+
+// page is the data of the page template
+type page struct {
+	State
+	Message string // Outcome of the last button, shown once
+}
+
+// funcs are the helpers of the page template
+var funcs = template.FuncMap{
+	// since formats the time from t to now: "1h05m"
+	"since": func(t, now time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return sessions.FormatDuration(now.Sub(t))
+	},
+	// clock formats a time of day, with the date when it is not today
+	"clock": func(t, now time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		t = t.Local()
+		if y, m, d := t.Date(); y == now.Year() && m == now.Month() && d == now.Day() {
+			return t.Format("15:04:05")
+		}
+		return t.Format("Jan 2 15:04")
+	},
+}
+
+// pageTemplate is the dashboard page. It reloads itself every 10 seconds.
+const pageTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="10; url=/">
+<title>Claude Notifications</title>
+<style>
+body { font: 14px system-ui, sans-serif; margin: 2em; color: #222; background: #fafafa; }
+h1 { font-size: 1.4em; }
+h2 { font-size: 1.1em; margin-top: 2em; }
+table { border-collapse: collapse; width: 100%; background: #fff; }
+th, td { text-align: left; padding: 4px 8px; border-bottom: 1px solid #e4e4e4; vertical-align: top; }
+th { font-weight: 600; color: #555; }
+form { display: inline; }
+button { font: inherit; cursor: pointer; }
+.muted { color: #888; }
+.ok { color: #1a7f37; }
+.bad { color: #cf222e; }
+.message { padding: 8px 12px; background: #fff8c5; border: 1px solid #e5d46b; }
+@media (prefers-color-scheme: dark) {
+  body { color: #ddd; background: #1e1e1e; }
+  table { background: #262626; }
+  th, td { border-color: #3a3a3a; }
+  th { color: #aaa; }
+  .message { background: #3b3620; border-color: #6b5f2a; }
+}
+</style>
+</head>
+<body>
+<h1>Claude Notifications</h1>
+{{if .Message}}<p class="message">{{.Message}}</p>{{end}}
+<p>
+{{if .DND.Active}}<strong>Do-not-disturb on</strong> ({{.DND.Reason}}{{with .DND.Until}}, until {{clock . $.Time}}{{end}}){{else}}Do-not-disturb off{{end}}
+{{range .DND.Muted}} · <strong>{{.Project}}</strong> muted{{with .Until}} until {{clock . $.Time}}{{end}}
+<form method="post" action="/unmute"><input type="hidden" name="project" value="{{.Project}}"><button>Unmute</button></form>{{end}}
+</p>
+
+<h2>Active sessions</h2>
+{{if .Sessions}}
+<table>
+<tr><th>Session</th><th>Project</th><th>Terminal</th><th>Running</th><th>Last activity</th><th>Prompt</th><th></th></tr>
+{{range .Sessions}}
+<tr>
+<td>{{.Label}}</td>
+<td title="{{.CWD}}">{{.Project}}</td>
+<td>{{.Terminal}}</td>
+<td>{{since .StartedAt $.Time}}</td>
+<td>{{since .LastActivity $.Time}} ago</td>
+<td>{{if .Working}}working{{with .Step}}: {{.}}{{end}}{{else}}<span class="muted">idle</span>{{end}}</td>
+<td>
+<form method="post" action="/focus"><input type="hidden" name="session" value="{{.ID}}"><button>Focus</button></form>
+{{if .Muted}}<form method="post" action="/unmute"><input type="hidden" name="project" value="{{.Project}}"><button>Unmute project</button></form>
+{{else}}<form method="post" action="/mute"><input type="hidden" name="project" value="{{.Project}}"><button>Mute project 1h</button></form>{{end}}
+</td>
+</tr>
+{{end}}
+</table>
+{{else}}<p class="muted">No active sessions.</p>{{end}}
+
+<h2>Backends</h2>
+{{if .Backends}}
+<table>
+<tr><th>Backend</th><th>Health</th><th>Last delivery</th><th>Failures</th><th>Last error</th></tr>
+{{range .Backends}}
+<tr>
+<td>{{.Name}}</td>
+<td>{{if .PausedUntil}}<span class="bad">paused until {{clock .PausedUntil $.Time}}</span>{{else if .Healthy}}<span class="ok">ok</span>{{else}}<span class="bad">failing</span>{{end}}</td>
+<td>{{if not .LastTime.IsZero}}{{clock .LastTime $.Time}}, {{.LastResult}}{{end}}</td>
+<td>{{.Failures}}</td>
+<td>{{.LastError}}</td>
+</tr>
+{{end}}
+</table>
+{{else}}<p class="muted">No deliveries recorded yet.</p>{{end}}
+
+<h2>Recent notifications</h2>
+{{if .Notifications}}
+<table>
+<tr><th>Time</th><th>Project</th><th>Title</th><th>Message</th><th>Backend</th><th>Result</th></tr>
+{{range .Notifications}}
+<tr>
+<td>{{clock .Time $.Time}}</td>
+<td>{{.Project}}</td>
+<td>{{.Title}}</td>
+<td>{{.Message}}</td>
+<td>{{.Backend}}</td>
+<td>{{if eq .Result "delivered"}}<span class="ok">delivered</span>{{else}}<span class="bad" title="{{.Error}}">{{.Result}}</span>{{end}}</td>
+</tr>
+{{end}}
+</table>
+{{else}}<p class="muted">No notifications recorded yet.</p>{{end}}
+
+<h2>Rule hits</h2>
+{{if .Rules}}
+<table>
+<tr><th>Rule</th><th>Matches</th><th>Last match</th></tr>
+{{range .Rules}}
+<tr><td>{{.Name}}</td><td>{{.Count}}</td><td>{{clock .Last $.Time}}, {{.LastStatus}}{{with .LastProject}} in {{.}}{{end}}</td></tr>
+{{end}}
+</table>
+{{else}}<p class="muted">No rule has matched yet.</p>{{end}}
+</body>
+</html>
+`
@@ -0,0 +1,174 @@
+// Package digest batches noisy, low-priority notifications such as
+// subagent stops and tool completions, and summarizes them in a single
+// notification every few minutes or when something more important arrives.
+package digest
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	queueFileName = "digest-queue.jsonl"
+
+	// summaryMaxLines caps the number of batched notifications listed in a summary
+	summaryMaxLines = 10
+	// summaryMessageLength caps each listed message, in characters
+	summaryMessageLength = 80
+)
+
+// Item is a notification waiting for the next summary
+type Item struct {
+	Time    time.Time `json:"time"`
+	Status  string    `json:"status"`
+	Title   string    `json:"title"`
+	Message string    `json:"message"`
+	Project string    `json:"project,omitempty"`
+}
+
+// Queue keeps batched notifications in a file in a directory
+type Queue struct {
+	dir string
+}
+
+// NewQueue creates a queue storing its file in dir
+func NewQueue(dir string) *Queue {
+	return &Queue{dir: dir}
+}
+
+// Add stores a notification for the next summary
+func (q *Queue) Add(item Item) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to serialize batched notification: %w", err)
+	}
+	if err := os.MkdirAll(q.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create digest directory: %w", err)
+	}
+	f, err := os.OpenFile(q.path(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open digest queue: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write digest queue: %w", err)
+	}
+	return nil
+}
+
+// Oldest returns when the oldest batched notification arrived (zero = none)
+func (q *Queue) Oldest() (time.Time, error) {
+	f, err := os.Open(q.path())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("failed to read digest queue: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var item Item
+		if err := json.Unmarshal(scanner.Bytes(), &item); err == nil {
+			return item.Time, nil
+		}
+	}
+	return time.Time{}, scanner.Err()
+}
+
+// Drain removes and returns all batched notifications. The queue file is
+// renamed before reading, so concurrent hooks never deliver it twice.
+func (q *Queue) Drain() ([]Item, error) {
+	claimed := fmt.Sprintf("%s.%d", q.path(), os.Getpid())
+	if err := os.Rename(q.path(), claimed); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim digest queue: %w", err)
+	}
+	defer os.Remove(claimed)
+
+	f, err := os.Open(claimed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read digest queue: %w", err)
+	}
+	defer f.Close()
+
+	var items []Item
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var item Item
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			continue // skip corrupted lines
+		}
+		items = append(items, item)
+	}
+	return items, scanner.Err()
+}
+
+func (q *Queue) path() string {
+	return filepath.Join(q.dir, queueFileName)
+}
+
+// Summary builds the title and message of the notification summarizing
+// batched notifications
+func Summary(items []Item) (title, message string) {
+	if len(items) == 1 {
+		title = "📋 1 update"
+	} else {
+		title = fmt.Sprintf("📋 %d updates", len(items))
+	}
+	project := commonProject(items)
+	if project != "" {
+		title += " in " + project
+	}
+
+	var lines []string
+	for i, item := range items {
+		if i == summaryMaxLines {
+			lines = append(lines, fmt.Sprintf("… and %d more", len(items)-summaryMaxLines))
+			break
+		}
+		line := item.Time.Format("15:04") + " " + item.Title
+		if item.Project != "" && project == "" {
+			line += " · " + item.Project
+		}
+		if msg := Truncate(item.Message, summaryMessageLength); msg != "" {
+			line += ": " + msg
+		}
+		lines = append(lines, line)
+	}
+	return title, strings.Join(lines, "\n")
+}
+
+// commonProject returns the project every item belongs to ("" = several)
+func commonProject(items []Item) string {
+	project := items[0].Project
+	for _, item := range items[1:] {
+		if item.Project != project {
+			return ""
+		}
+	}
+	return project
+}
+
+// Truncate shortens s to limit characters (including the ellipsis) on one
+// line, for listing messages in a summary
+func Truncate(s string, limit int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,89 @@
+package digest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func at(minute int) time.Time {
+	return time.Date(2026, 10, 17, 14, minute, 0, 0, time.Local)
+}
+
+func TestQueueAndSummary(t *testing.T) {
+	q := NewQueue(t.TempDir())
+
+	if oldest, err := q.Oldest(); err != nil || !oldest.IsZero() {
+		t.Fatalf("empty queue: Oldest() = %v, %v", oldest, err)
+	}
+	if items, err := q.Drain(); err != nil || len(items) != 0 {
+		t.Fatalf("empty queue: items=%v err=%v", items, err)
+	}
+
+	for i := 0; i < 12; i++ {
+		err := q.Add(Item{
+			Time:    at(i),
+			Status:  "task_complete",
+			Title:   "✅ Subagent done",
+			Message: fmt.Sprintf("Agent %d\nfinished", i),
+			Project: "api",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if oldest, err := q.Oldest(); err != nil || !oldest.Equal(at(0)) {
+		t.Errorf("Oldest() = %v, %v; want %v", oldest, err, at(0))
+	}
+
+	items, err := q.Drain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 12 {
+		t.Fatalf("drained %d items, want 12", len(items))
+	}
+	if again, _ := q.Drain(); len(again) != 0 {
+		t.Errorf("queue should be empty after drain, got %d", len(again))
+	}
+
+	title, message := Summary(items)
+	if title != "📋 12 updates in api" {
+		t.Errorf("title = %q", title)
+	}
+	lines := strings.Split(message, "\n")
+	if len(lines) != summaryMaxLines+1 {
+		t.Fatalf("summary has %d lines, want %d", len(lines), summaryMaxLines+1)
+	}
+	if lines[0] != "14:00 ✅ Subagent done: Agent 0 finished" {
+		t.Errorf("first line = %q", lines[0])
+	}
+	if lines[summaryMaxLines] != "… and 2 more" {
+		t.Errorf("last line = %q", lines[summaryMaxLines])
+	}
+}
+
+func TestSummary_SeveralProjects(t *testing.T) {
+	title, message := Summary([]Item{
+		{Time: at(1), Title: "🔧 Tool used", Message: "Bash", Project: "api"},
+		{Time: at(2), Title: "✅ Subagent done", Project: "web"},
+	})
+	if title != "📋 2 updates" {
+		t.Errorf("title = %q", title)
+	}
+	want := "14:01 🔧 Tool used · api: Bash\n14:02 ✅ Subagent done · web"
+	if message != want {
+		t.Errorf("message = %q, want %q", message, want)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := Truncate("short", 10); got != "short" {
+		t.Errorf("Truncate short = %q", got)
+	}
+	if got := Truncate("Привет мир, как дела", 8); got != "Привет …" {
+		t.Errorf("Truncate = %q", got)
+	}
+}
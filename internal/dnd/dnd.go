@@ -0,0 +1,340 @@
+// Package dnd implements do-not-disturb: the notifications.dnd schedule, a
+// manual on/off override persisted on disk, a queue of notifications held
+// back while DND is active, and projects muted on their own.
+package dnd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/digest"
+)
+
+const (
+	stateFileName = "dnd.json"
+	queueFileName = "dnd-queue.jsonl"
+	mutesFileName = "dnd-projects.json"
+
+	// digestMaxLines caps the number of queued notifications listed in a digest
+	digestMaxLines = 10
+	// digestMessageLength caps each listed message, in characters
+	digestMessageLength = 80
+)
+
+// Override is a manual DND toggle set by "claude-notifications dnd"
+type Override struct {
+	Mode  string    `json:"mode"`            // "on" or "off"
+	Until time.Time `json:"until,omitempty"` // When the override expires (zero = until changed)
+}
+
+// Item is a notification held back while DND was active
+type Item struct {
+	Time    time.Time `json:"time"`
+	Status  string    `json:"status"`
+	Title   string    `json:"title"`
+	Message string    `json:"message"`
+	Project string    `json:"project,omitempty"`
+}
+
+// Status describes whether DND is active and why
+type Status struct {
+	Active bool
+	Reason string    // "manual", "schedule", or "" when inactive
+	Until  time.Time // When DND is expected to end (zero = unknown or indefinite)
+}
+
+// Manager reads and writes DND state in a directory
+type Manager struct {
+	dir string
+	cfg config.DNDConfig
+}
+
+// NewManager creates a DND manager storing its files in dir
+func NewManager(dir string, cfg config.DNDConfig) *Manager {
+	return &Manager{dir: dir, cfg: cfg}
+}
+
+// Status returns the DND status at now. An unexpired manual override wins
+// over the schedule.
+func (m *Manager) Status(now time.Time) Status {
+	if o, err := m.loadOverride(); err == nil && o != nil && (o.Until.IsZero() || now.Before(o.Until)) {
+		if o.Mode == "on" {
+			return Status{Active: true, Reason: "manual", Until: o.Until}
+		}
+		return Status{}
+	}
+	if m.cfg.IsScheduled(now) {
+		return Status{Active: true, Reason: "schedule", Until: m.cfg.ScheduleEnd(now)}
+	}
+	return Status{}
+}
+
+// On turns DND on until the given time (zero = until turned off)
+func (m *Manager) On(until time.Time) error {
+	return m.saveOverride(&Override{Mode: "on", Until: until})
+}
+
+// Off turns DND off. During a scheduled window, the schedule is overridden
+// until the window ends.
+func (m *Manager) Off(now time.Time) error {
+	if m.cfg.IsScheduled(now) {
+		return m.saveOverride(&Override{Mode: "off", Until: m.cfg.ScheduleEnd(now)})
+	}
+	err := os.Remove(m.statePath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to clear dnd state: %w", err)
+	}
+	return nil
+}
+
+// MuteProject silences the notifications of a project (folder name) until
+// the given time (zero = until unmuted)
+func (m *Manager) MuteProject(project string, until time.Time) error {
+	mutes, err := m.loadMutes()
+	if err != nil {
+		return err
+	}
+	mutes[project] = until
+	return m.saveMutes(mutes)
+}
+
+// UnmuteProject lets the notifications of a project through again
+func (m *Manager) UnmuteProject(project string) error {
+	mutes, err := m.loadMutes()
+	if err != nil {
+		return err
+	}
+	delete(mutes, project)
+	return m.saveMutes(mutes)
+}
+
+// MutedProjects returns the projects muted at now, with when each mute
+// ends (zero = until unmuted)
+func (m *Manager) MutedProjects(now time.Time) (map[string]time.Time, error) {
+	mutes, err := m.loadMutes()
+	if err != nil {
+		return nil, err
+	}
+	for project, until := range mutes {
+		if !until.IsZero() && !now.Before(until) {
+			delete(mutes, project)
+		}
+	}
+	return mutes, nil
+}
+
+// ProjectMuted reports whether the notifications of a project are muted at now
+func (m *Manager) ProjectMuted(project string, now time.Time) bool {
+	mutes, err := m.MutedProjects(now)
+	if err != nil {
+		return false
+	}
+	_, muted := mutes[project]
+	return muted
+}
+
+// Enqueue stores a notification for the digest
+func (m *Manager) Enqueue(item Item) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to serialize queued notification: %w", err)
+	}
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create dnd directory: %w", err)
+	}
+	f, err := os.OpenFile(m.queuePath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open dnd queue: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write dnd queue: %w", err)
+	}
+	return nil
+}
+
+// Drain removes and returns all queued notifications. The queue file is
+// renamed before reading, so concurrent hooks never deliver it twice.
+func (m *Manager) Drain() ([]Item, error) {
+	claimed := fmt.Sprintf("%s.%d", m.queuePath(), os.Getpid())
+	if err := os.Rename(m.queuePath(), claimed); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim dnd queue: %w", err)
+	}
+	defer os.Remove(claimed)
+
+	f, err := os.Open(claimed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dnd queue: %w", err)
+	}
+	defer f.Close()
+
+	var items []Item
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var item Item
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			continue // skip corrupted lines
+		}
+		items = append(items, item)
+	}
+	return items, scanner.Err()
+}
+
+// Held returns the number of queued notifications without removing them
+func (m *Manager) Held() (int, error) {
+	f, err := os.Open(m.queuePath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read dnd queue: %w", err)
+	}
+	defer f.Close()
+
+	n := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(strings.TrimSpace(scanner.Text())) > 0 {
+			n++
+		}
+	}
+	return n, scanner.Err()
+}
+
+// ParseUntil parses the end of a manual DND period: a duration such as
+// "30m" or "2h", or a clock time such as "07:30" (the next occurrence)
+func ParseUntil(arg string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(arg); err == nil {
+		if d <= 0 {
+			return time.Time{}, fmt.Errorf("duration must be positive: %s", arg)
+		}
+		return now.Add(d), nil
+	}
+	clock, err := time.Parse("15:04", arg)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q (use a duration like 30m or a clock time like 07:30)", arg)
+	}
+	until := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+	if !until.After(now) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until, nil
+}
+
+// Digest builds the title and message summarizing queued notifications
+func Digest(items []Item) (title, message string) {
+	if len(items) == 1 {
+		title = "🔕 1 notification during do-not-disturb"
+	} else {
+		title = fmt.Sprintf("🔕 %d notifications during do-not-disturb", len(items))
+	}
+
+	var lines []string
+	for i, item := range items {
+		if i == digestMaxLines {
+			lines = append(lines, fmt.Sprintf("… and %d more", len(items)-digestMaxLines))
+			break
+		}
+		line := item.Time.Format("15:04") + " " + item.Title
+		if item.Project != "" {
+			line += " · " + item.Project
+		}
+		if msg := digest.Truncate(item.Message, digestMessageLength); msg != "" {
+			line += ": " + msg
+		}
+		lines = append(lines, line)
+	}
+	return title, strings.Join(lines, "\n")
+}
+
+func (m *Manager) statePath() string {
+	return filepath.Join(m.dir, stateFileName)
+}
+
+func (m *Manager) queuePath() string {
+	return filepath.Join(m.dir, queueFileName)
+}
+
+func (m *Manager) mutesPath() string {
+	return filepath.Join(m.dir, mutesFileName)
+}
+
+// loadMutes returns the muted projects, including expired mutes
+func (m *Manager) loadMutes() (map[string]time.Time, error) {
+	mutes := map[string]time.Time{}
+	data, err := os.ReadFile(m.mutesPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return mutes, nil
+		}
+		return nil, fmt.Errorf("failed to read muted projects: %w", err)
+	}
+	if err := json.Unmarshal(data, &mutes); err != nil {
+		return nil, fmt.Errorf("failed to parse muted projects: %w", err)
+	}
+	return mutes, nil
+}
+
+// saveMutes writes the muted projects, removing the file when none are left
+func (m *Manager) saveMutes(mutes map[string]time.Time) error {
+	if len(mutes) == 0 {
+		if err := os.Remove(m.mutesPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to clear muted projects: %w", err)
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(mutes, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize muted projects: %w", err)
+	}
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create dnd directory: %w", err)
+	}
+	if err := os.WriteFile(m.mutesPath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write muted projects: %w", err)
+	}
+	return nil
+}
+
+// loadOverride returns the manual override, or nil if none is set
+func (m *Manager) loadOverride() (*Override, error) {
+	data, err := os.ReadFile(m.statePath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read dnd state: %w", err)
+	}
+	var o Override
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, fmt.Errorf("failed to parse dnd state: %w", err)
+	}
+	return &o, nil
+}
+
+// saveOverride writes the manual override
+func (m *Manager) saveOverride(o *Override) error {
+	data, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize dnd state: %w", err)
+	}
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create dnd directory: %w", err)
+	}
+	if err := os.WriteFile(m.statePath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write dnd state: %w", err)
+	}
+	return nil
+}
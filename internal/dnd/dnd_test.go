@@ -0,0 +1,231 @@
+package dnd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+// 2026-03-13 is a Friday
+func friday(hour, minute int) time.Time {
+	return time.Date(2026, 3, 13, hour, minute, 0, 0, time.Local)
+}
+
+func nightSchedule() config.DNDConfig {
+	return config.DNDConfig{Schedule: []config.DNDWindow{{Time: "22:00-08:00"}}}
+}
+
+func TestStatus_Schedule(t *testing.T) {
+	m := NewManager(t.TempDir(), nightSchedule())
+
+	if st := m.Status(friday(12, 0)); st.Active {
+		t.Errorf("noon should not be DND, got %+v", st)
+	}
+
+	st := m.Status(friday(23, 0))
+	if !st.Active || st.Reason != "schedule" {
+		t.Fatalf("23:00 should be scheduled DND, got %+v", st)
+	}
+	if want := friday(8, 0).AddDate(0, 0, 1); !st.Until.Equal(want) {
+		t.Errorf("Until = %v, want %v", st.Until, want)
+	}
+}
+
+func TestStatus_WeekendSchedule(t *testing.T) {
+	cfg := config.DNDConfig{Schedule: []config.DNDWindow{
+		{Days: []string{"weekends"}},
+		{Days: []string{"fri"}, Time: "20:00-09:00"},
+	}}
+	m := NewManager(t.TempDir(), cfg)
+
+	saturdayNoon := friday(12, 0).AddDate(0, 0, 1)
+	if !m.Status(saturdayNoon).Active {
+		t.Error("weekend should be DND all day")
+	}
+	if m.Status(friday(12, 0)).Active {
+		t.Error("friday noon should not be DND")
+	}
+	if !m.Status(friday(21, 0)).Active {
+		t.Error("friday evening window should be DND")
+	}
+	// Thursday 22:00-09:00 is not configured, so Friday 07:00 is free
+	if m.Status(friday(7, 0)).Active {
+		t.Error("friday morning belongs to thursday's window, which is not scheduled")
+	}
+	// All-day windows end at midnight
+	mondayMorning := friday(8, 0).AddDate(0, 0, 3)
+	if m.Status(mondayMorning).Active {
+		t.Error("monday morning should not be DND")
+	}
+}
+
+func TestManualOverride(t *testing.T) {
+	m := NewManager(t.TempDir(), nightSchedule())
+	now := friday(12, 0)
+
+	if err := m.On(now.Add(30 * time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	st := m.Status(now)
+	if !st.Active || st.Reason != "manual" {
+		t.Fatalf("expected manual DND, got %+v", st)
+	}
+	if m.Status(now.Add(31 * time.Minute)).Active {
+		t.Error("manual DND should expire")
+	}
+
+	if err := m.On(time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Status(now.Add(48 * time.Hour)).Active {
+		t.Error("indefinite manual DND should stay on")
+	}
+
+	if err := m.Off(now); err != nil {
+		t.Fatal(err)
+	}
+	if m.Status(now).Active {
+		t.Error("DND should be off")
+	}
+	// Outside the schedule, off clears the override and the schedule applies again
+	if !m.Status(friday(23, 0)).Active {
+		t.Error("schedule should apply after off")
+	}
+}
+
+func TestOffDuringScheduleOverridesUntilWindowEnds(t *testing.T) {
+	m := NewManager(t.TempDir(), nightSchedule())
+
+	if err := m.Off(friday(23, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if m.Status(friday(23, 30)).Active {
+		t.Error("off should override the current scheduled window")
+	}
+	if !m.Status(friday(23, 0).AddDate(0, 0, 1)).Active {
+		t.Error("the next scheduled window should apply again")
+	}
+}
+
+func TestMuteProject(t *testing.T) {
+	m := NewManager(t.TempDir(), config.DNDConfig{})
+	now := friday(12, 0)
+
+	if m.ProjectMuted("api", now) {
+		t.Fatal("no project should be muted yet")
+	}
+	if err := m.MuteProject("api", now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.MuteProject("web", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if !m.ProjectMuted("api", now) || m.ProjectMuted("docs", now) {
+		t.Error("only the muted projects should be muted")
+	}
+	if m.Status(now).Active {
+		t.Error("muting a project should not turn DND on")
+	}
+
+	// The timed mute expires, the other one stays
+	mutes, err := m.MutedProjects(now.Add(2 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mutes["api"]; ok || len(mutes) != 1 {
+		t.Errorf("MutedProjects() after expiry = %v, want only web", mutes)
+	}
+
+	if err := m.UnmuteProject("web"); err != nil {
+		t.Fatal(err)
+	}
+	if m.ProjectMuted("web", now) {
+		t.Error("web should be unmuted")
+	}
+}
+
+func TestQueueAndDigest(t *testing.T) {
+	m := NewManager(t.TempDir(), config.DNDConfig{})
+
+	items, err := m.Drain()
+	if err != nil || len(items) != 0 {
+		t.Fatalf("empty queue: items=%v err=%v", items, err)
+	}
+
+	for i := 0; i < 12; i++ {
+		err := m.Enqueue(Item{
+			Time:    friday(23, i),
+			Status:  "task_complete",
+			Title:   "✅ Completed",
+			Message: fmt.Sprintf("Task %d\ndone", i),
+			Project: "api",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if held, err := m.Held(); err != nil || held != 12 {
+		t.Fatalf("Held() = %d, %v; want 12", held, err)
+	}
+
+	items, err = m.Drain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 12 {
+		t.Fatalf("drained %d items, want 12", len(items))
+	}
+	if again, _ := m.Drain(); len(again) != 0 {
+		t.Errorf("queue should be empty after drain, got %d", len(again))
+	}
+	if held, err := m.Held(); err != nil || held != 0 {
+		t.Errorf("Held() after drain = %d, %v; want 0", held, err)
+	}
+
+	title, message := Digest(items)
+	if title != "🔕 12 notifications during do-not-disturb" {
+		t.Errorf("title = %q", title)
+	}
+	lines := strings.Split(message, "\n")
+	if len(lines) != digestMaxLines+1 {
+		t.Fatalf("digest has %d lines, want %d", len(lines), digestMaxLines+1)
+	}
+	if lines[0] != "23:00 ✅ Completed · api: Task 0 done" {
+		t.Errorf("first line = %q", lines[0])
+	}
+	if lines[digestMaxLines] != "… and 2 more" {
+		t.Errorf("last line = %q", lines[digestMaxLines])
+	}
+}
+
+func TestParseUntil(t *testing.T) {
+	now := friday(23, 0)
+	tests := []struct {
+		arg     string
+		want    time.Time
+		wantErr bool
+	}{
+		{"30m", friday(23, 30), false},
+		{"1h30m", friday(23, 0).Add(90 * time.Minute), false},
+		{"23:45", friday(23, 45), false},
+		{"07:30", friday(7, 30).AddDate(0, 0, 1), false},
+		{"23:00", friday(23, 0).AddDate(0, 0, 1), false},
+		{"-5m", time.Time{}, true},
+		{"tomorrow", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			got, err := ParseUntil(tt.arg, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseUntil(%q) error = %v, wantErr %v", tt.arg, err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseUntil(%q) = %v, want %v", tt.arg, got, tt.want)
+			}
+		})
+	}
+}
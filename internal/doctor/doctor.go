@@ -0,0 +1,402 @@
+// Package doctor diagnoses the notification and focus stack for
+// "claude-notifications doctor": config, hook installation, the platform
+// notification backend, focus tools, a test notification and a focus
+// round-trip. Every problem comes with a fix.
+package doctor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/daemon"
+	"github.com/777genius/claude-notifications/internal/hookinstall"
+	"github.com/777genius/claude-notifications/internal/notifier"
+)
+
+// Status is the outcome of a check
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip"
+)
+
+// pluginName is the plugin's name in Claude Code's enabledPlugins
+const pluginName = "claude-notifications-go"
+
+// Result is the outcome of one check
+type Result struct {
+	Name   string
+	Status Status
+	Detail string // What was found
+	Fix    string // How to fix a warning or failure ("" = nothing to do)
+}
+
+// Options control which checks run and where they look
+type Options struct {
+	PluginRoot string
+	Home       string // Home directory holding ~/.claude (empty = no user settings)
+	CWD        string // Project directory holding .claude/settings.json (empty = none)
+	Notify     bool   // Send a test notification
+	Focus      bool   // Try to focus the terminal
+	Probe      bool   // Try every focus method and relearn which one goes first
+}
+
+// Run executes every check in order
+func Run(opts Options) []Result {
+	cfgResult, cfg := CheckConfig(opts.PluginRoot, opts.CWD)
+	daemon.SetTerminals(cfg.Notifications.Desktop.Terminals)
+	results := []Result{cfgResult, CheckHooks(SettingsPaths(opts.Home, opts.CWD))}
+	results = append(results, platformChecks(cfg)...)
+	results = append(results, CheckFocusTools(daemon.DetectFocusTools()))
+
+	if !opts.Notify {
+		results = append(results, Result{Name: "Test notification", Status: StatusSkip, Detail: "skipped (--no-notify)"})
+	} else {
+		results = append(results, checkTestNotification(cfg, opts.CWD))
+	}
+	switch {
+	case opts.Probe:
+		results = append(results, probeFocusMethods(opts.CWD)...)
+	case !opts.Focus:
+		results = append(results, Result{Name: "Focus round-trip", Status: StatusSkip, Detail: "skipped (--no-focus)"})
+	default:
+		results = append(results, checkFocusRoundTrip(cfg, opts.CWD))
+	}
+	return results
+}
+
+// Failed returns true if any check failed
+func Failed(results []Result) bool {
+	for _, r := range results {
+		if r.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// Print writes one line per check, followed by its fix
+func Print(w io.Writer, results []Result) {
+	icons := map[Status]string{StatusOK: "✓", StatusWarn: "!", StatusFail: "✗", StatusSkip: "-"}
+	for _, r := range results {
+		fmt.Fprintf(w, "%s %-22s %s\n", icons[r.Status], r.Name, r.Detail)
+		if r.Fix != "" && (r.Status == StatusWarn || r.Status == StatusFail) {
+			fmt.Fprintf(w, "  → %s\n", r.Fix)
+		}
+	}
+}
+
+// CheckConfig loads and validates the config, including the project
+// config for cwd. Unreadable sources are skipped while loading, so the
+// returned config is always usable by later checks.
+func CheckConfig(pluginRoot, cwd string) (Result, *config.Config) {
+	r := Result{Name: "Config"}
+	path, _ := config.GetStableConfigPath()
+
+	cfg, warnings := config.LoadForProject(pluginRoot, cwd)
+	if err := cfg.Validate(); err != nil {
+		r.Status = StatusFail
+		r.Detail = "invalid: " + err.Error()
+		r.Fix = fmt.Sprintf("Edit %s (see README: Configuration); claude-notifications config validate shows every problem", path)
+		return r, cfg
+	}
+	if len(warnings) > 0 {
+		r.Status = StatusWarn
+		r.Detail = warnings[0].Error()
+		if len(warnings) > 1 {
+			r.Detail += fmt.Sprintf(" (and %d more)", len(warnings)-1)
+		}
+		r.Fix = "Run claude-notifications config validate and fix the reported keys"
+		return r, cfg
+	}
+	if !cfg.IsAnyNotificationEnabled() {
+		r.Status = StatusWarn
+		r.Detail = "valid, but every notification method is disabled"
+		r.Fix = fmt.Sprintf("Set notifications.desktop.enabled to true in %s", path)
+		return r, cfg
+	}
+
+	r.Status = StatusOK
+	var sources []string
+	if path != "" {
+		if _, err := os.Stat(path); err == nil {
+			sources = append(sources, path)
+		}
+	}
+	if userPath, _ := config.FindUserConfigFile(); userPath != "" {
+		sources = append(sources, userPath)
+	}
+	if projectPath := config.FindProjectConfig(cwd); projectPath != "" {
+		sources = append(sources, projectPath)
+	}
+	if len(sources) == 0 {
+		r.Detail = "valid (defaults, no config file)"
+		return r, cfg
+	}
+	r.Detail = "valid (" + strings.Join(sources, ", ") + ")"
+	return r, cfg
+}
+
+// claudeSettings is the part of a Claude Code settings file the doctor reads
+type claudeSettings struct {
+	EnabledPlugins map[string]bool `json:"enabledPlugins"`
+	Hooks          map[string][]struct {
+		Hooks []struct {
+			Command string `json:"command"`
+		} `json:"hooks"`
+	} `json:"hooks"`
+}
+
+// SettingsPaths returns the Claude Code settings files for a home and project directory
+func SettingsPaths(home, cwd string) []string {
+	var paths []string
+	if home != "" {
+		paths = append(paths, filepath.Join(home, ".claude", "settings.json"))
+	}
+	if cwd != "" {
+		paths = append(paths,
+			filepath.Join(cwd, ".claude", "settings.json"),
+			filepath.Join(cwd, ".claude", "settings.local.json"))
+	}
+	return paths
+}
+
+// CheckHooks verifies that Claude Code runs the notification hooks: the
+// plugin is enabled, or hooks in a settings file call this binary
+func CheckHooks(paths []string) Result {
+	r := Result{Name: "Claude Code hooks"}
+	var found []string
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				r.Status = StatusWarn
+				r.Detail = fmt.Sprintf("cannot read %s: %v", path, err)
+				r.Fix = "Check the file permissions"
+				return r
+			}
+			continue
+		}
+		var s claudeSettings
+		if err := json.Unmarshal(data, &s); err != nil {
+			r.Status = StatusFail
+			r.Detail = fmt.Sprintf("%s is not valid JSON: %v", path, err)
+			r.Fix = "Fix the JSON syntax so Claude Code can load its settings"
+			return r
+		}
+		if how := hooksInstalledIn(s); how != "" {
+			found = append(found, fmt.Sprintf("%s in %s", how, path))
+		}
+	}
+
+	if len(found) == 0 {
+		r.Status = StatusFail
+		r.Detail = "plugin not enabled and no claude-notifications hooks found"
+		r.Fix = "In Claude Code run: /plugin marketplace add 777genius/claude-notifications-go, then /plugin install " + pluginName + "@" + pluginName +
+			"\n    Or, without the plugin: claude-notifications install-hooks"
+		return r
+	}
+	r.Status = StatusOK
+	r.Detail = strings.Join(found, "; ")
+	return r
+}
+
+// hooksInstalledIn describes how settings enable the hooks ("" = they don't)
+func hooksInstalledIn(s claudeSettings) string {
+	for name, enabled := range s.EnabledPlugins {
+		if enabled && strings.HasPrefix(name, pluginName+"@") {
+			return "plugin enabled"
+		}
+	}
+	var events []string
+	for event, matchers := range s.Hooks {
+		for _, m := range matchers {
+			for _, h := range m.Hooks {
+				if hookinstall.IsOurCommand(h.Command) {
+					events = append(events, event)
+				}
+			}
+		}
+	}
+	if len(events) == 0 {
+		return ""
+	}
+	sort.Strings(events)
+	return "hooks for " + strings.Join(dedupe(events), ", ")
+}
+
+// dedupe removes adjacent duplicates from a sorted slice
+func dedupe(sorted []string) []string {
+	var out []string
+	for i, s := range sorted {
+		if i == 0 || s != sorted[i-1] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// focusToolFixes tells users how to install a missing focus tool
+var focusToolFixes = map[string]string{
+	"hyprctl":                  "Hyprland: hyprctl ships with Hyprland; check your PATH",
+	"activate-window-by-title": "GNOME: install the \"Activate Window By Title\" extension (extensions.gnome.org/extension/5021)",
+	"niri":                     "Niri: niri ships with the compositor; check your PATH",
+	"xdotool":                  "X11: install xdotool (apt install xdotool / dnf install xdotool)",
+	"wmctrl":                   "X11: install wmctrl (apt install wmctrl)",
+	"kdotool":                  "KDE Plasma: install kdotool (cargo install kdotool)",
+	"kwin-scripting":           "KDE Plasma: KWin scripting is built in; check that KWin runs on the session bus",
+	"wlrctl":                   "wlroots compositors: install wlrctl",
+	"wlr-foreign-toplevel":     "wlroots compositors (Sway, river, labwc, Wayfire): built in; the compositor must offer zwlr_foreign_toplevel_manager_v1",
+	"osascript":                "macOS: osascript ships with the system; check your PATH",
+	"open":                     "macOS: open ships with the system; check your PATH",
+	"user32":                   "Windows: user32.dll should always be present",
+	"wsl-host":                 "WSL: enable interop so powershell.exe of the Windows host can run (see /etc/wsl.conf)",
+}
+
+// helperTools are D-Bus clients the focus chain calls through; they
+// cannot focus a window on their own
+var helperTools = map[string]bool{"gdbus": true, "busctl": true}
+
+// CheckFocusTools reports which focus tools are available. Only a
+// complete absence is a problem: the focus chain needs just one.
+func CheckFocusTools(tools map[string]bool) Result {
+	r := Result{Name: "Focus tools"}
+	var available, missing []string
+	for tool, ok := range tools {
+		if helperTools[tool] {
+			continue
+		}
+		if ok {
+			available = append(available, tool)
+		} else {
+			missing = append(missing, tool)
+		}
+	}
+	sort.Strings(available)
+	sort.Strings(missing)
+
+	switch {
+	case len(tools) == 0:
+		r.Status = StatusSkip
+		r.Detail = "click-to-focus is not supported on this platform"
+	case len(available) == 0:
+		r.Status = StatusWarn
+		r.Detail = "none found; clicking a notification cannot focus the terminal"
+		var fixes []string
+		for _, tool := range missing {
+			if fix, ok := focusToolFixes[tool]; ok {
+				fixes = append(fixes, fix)
+			}
+		}
+		r.Fix = strings.Join(fixes, "\n    ")
+	default:
+		r.Status = StatusOK
+		r.Detail = "available: " + strings.Join(available, ", ")
+	}
+	return r
+}
+
+// checkTestNotification sends a desktop notification through the regular path
+func checkTestNotification(cfg *config.Config, cwd string) Result {
+	r := Result{Name: "Test notification"}
+	if !cfg.IsDesktopEnabled() {
+		r.Status = StatusSkip
+		r.Detail = "desktop notifications are disabled in config"
+		return r
+	}
+
+	n := notifier.New(cfg)
+	defer n.Close()
+	err := n.SendDesktopWithOptions(analyzer.StatusTaskComplete, "Test notification from claude-notifications doctor", "", cwd,
+		notifier.Options{Title: "🩺 claude-notifications doctor", Sound: "none"})
+	if err != nil {
+		r.Status = StatusFail
+		r.Detail = err.Error()
+		r.Fix = "Fix the notification backend reported above, then run doctor again"
+		return r
+	}
+	r.Status = StatusOK
+	r.Detail = "sent — check that it appeared on screen"
+	return r
+}
+
+// checkFocusRoundTrip raises the terminal this command runs in through the
+// same focus chain a notification click uses, racing methods when the
+// desktop focus mode is "race"
+func checkFocusRoundTrip(cfg *config.Config, cwd string) Result {
+	r := Result{Name: "Focus round-trip"}
+	terminal := daemon.GetTerminalName()
+	folder := ""
+	if cwd != "" {
+		folder = filepath.Base(cwd)
+	}
+	if err := daemon.TryFocusWithOptions(terminal, folder, daemon.NewFocusOptions(cfg.Notifications.Desktop.Focus)); err != nil {
+		r.Status = StatusWarn
+		r.Detail = fmt.Sprintf("could not focus %s: %v", terminal, err)
+		r.Fix = "Install a focus tool listed above, or see docs/CLICK_TO_FOCUS.md for your desktop"
+		return r
+	}
+	r.Status = StatusOK
+	r.Detail = "focused " + terminal
+	return r
+}
+
+// probeFocusMethods tries every focus method on the terminal this command
+// runs in, one result per method, and reports which one the focus chain
+// now tries first
+func probeFocusMethods(cwd string) []Result {
+	terminal := daemon.GetTerminalName()
+	folder := ""
+	if cwd != "" {
+		folder = filepath.Base(cwd)
+	}
+
+	var results []Result
+	for _, p := range daemon.ProbeFocusMethods(terminal, folder) {
+		r := Result{Name: p.Method, Status: StatusOK, Detail: fmt.Sprintf("focused %s in %s", terminal, p.Duration.Round(time.Millisecond))}
+		if p.Err != nil {
+			r.Status = StatusSkip
+			r.Detail = firstLine(p.Err.Error())
+		}
+		results = append(results, r)
+	}
+
+	order := Result{Name: "Focus order"}
+	switch learned := daemon.LearnedFocusMethod(); {
+	case len(results) == 0:
+		order.Status = StatusSkip
+		order.Detail = "click-to-focus is not supported on this platform"
+	case learned == nil:
+		order.Status = StatusWarn
+		order.Detail = "no method could focus " + terminal
+		order.Fix = "Install a focus tool listed above, or see docs/CLICK_TO_FOCUS.md for your desktop"
+	default:
+		order.Status = StatusOK
+		order.Detail = fmt.Sprintf("%s is tried first from now on (%s)", learned.Method, daemon.FocusEnvironment())
+	}
+	return append(results, order)
+}
+
+// firstLine shortens an error message to its first line
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	const limit = 100
+	if len(s) > limit {
+		s = s[:limit] + "…"
+	}
+	return s
+}
@@ -0,0 +1,29 @@
+//go:build darwin
+
+// ABOUTME: macOS doctor checks: terminal-notifier, which click-to-focus
+// ABOUTME: notifications are sent through.
+package doctor
+
+import (
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/notifier"
+)
+
+// platformChecks checks that terminal-notifier is installed
+func platformChecks(cfg *config.Config) []Result {
+	r := Result{Name: "terminal-notifier"}
+	path, err := notifier.GetTerminalNotifierPath()
+	switch {
+	case err == nil:
+		r.Status = StatusOK
+		r.Detail = path
+	case !cfg.Notifications.Desktop.ClickToFocus:
+		r.Status = StatusSkip
+		r.Detail = "not installed, not needed with clickToFocus disabled"
+	default:
+		r.Status = StatusWarn
+		r.Detail = "not installed; notifications fall back to plain alerts without click-to-focus"
+		r.Fix = "In Claude Code run /claude-notifications-go:notifications-init, or brew install terminal-notifier"
+	}
+	return []Result{r}
+}
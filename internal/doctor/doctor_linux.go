@@ -0,0 +1,38 @@
+//go:build linux
+
+// ABOUTME: Linux doctor checks: the freedesktop notification server on D-Bus
+// ABOUTME: and the click-to-focus daemon.
+package doctor
+
+import (
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/notifier"
+)
+
+// platformChecks checks the D-Bus notification server and the daemon
+func platformChecks(cfg *config.Config) []Result {
+	server := Result{Name: "D-Bus notifications"}
+	if info, err := notifier.NotificationServerInfo(); err != nil {
+		server.Status = StatusFail
+		server.Detail = err.Error()
+		server.Fix = "Start a notification daemon (dunst, mako, swaync) or run from a desktop session with DBUS_SESSION_BUS_ADDRESS set"
+	} else {
+		server.Status = StatusOK
+		server.Detail = info
+	}
+
+	d := Result{Name: "Click-to-focus daemon"}
+	switch {
+	case !cfg.Notifications.Desktop.ClickToFocus:
+		d.Status = StatusSkip
+		d.Detail = "clickToFocus is disabled in config"
+	case notifier.IsDaemonAvailable():
+		d.Status = StatusOK
+		d.Detail = "running"
+	default:
+		// The daemon starts on demand with the first notification
+		d.Status = StatusOK
+		d.Detail = "not running (starts with the next notification)"
+	}
+	return []Result{server, d}
+}
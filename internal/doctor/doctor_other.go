@@ -0,0 +1,12 @@
+//go:build !linux && !darwin
+
+// ABOUTME: Doctor checks for platforms without extra backend requirements;
+// ABOUTME: Windows toasts need no external tools.
+package doctor
+
+import "github.com/777genius/claude-notifications/internal/config"
+
+// platformChecks has nothing to check on this platform
+func platformChecks(cfg *config.Config) []Result {
+	return nil
+}
@@ -0,0 +1,151 @@
+package doctor
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeSettings(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, ".claude", name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCheckHooks(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		want     Status
+		detail   string
+	}{
+		{"plugin enabled", `{"enabledPlugins":{"claude-notifications-go@claude-notifications-go":true}}`, StatusOK, "plugin enabled"},
+		{"plugin disabled", `{"enabledPlugins":{"claude-notifications-go@claude-notifications-go":false}}`, StatusFail, ""},
+		{"other plugin", `{"enabledPlugins":{"other@market":true}}`, StatusFail, ""},
+		{"manual hooks", `{"hooks":{
+			"Stop":[{"hooks":[{"type":"command","command":"/opt/bin/claude-notifications handle-hook Stop"}]}],
+			"Notification":[{"matcher":"","hooks":[{"type":"command","command":"claude-notifications handle-hook Notification"}]}]
+		}}`, StatusOK, "hooks for Notification, Stop"},
+		{"unrelated hooks", `{"hooks":{"Stop":[{"hooks":[{"command":"echo done"}]}]}}`, StatusFail, ""},
+		{"invalid json", `{"hooks":`, StatusFail, "not valid JSON"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeSettings(t, dir, "settings.json", tt.settings)
+
+			r := CheckHooks(SettingsPaths(dir, ""))
+			if r.Status != tt.want {
+				t.Errorf("status = %s, want %s (%s)", r.Status, tt.want, r.Detail)
+			}
+			if !strings.Contains(r.Detail, tt.detail) {
+				t.Errorf("detail = %q, want it to contain %q", r.Detail, tt.detail)
+			}
+			if r.Status == StatusFail && r.Fix == "" {
+				t.Error("failure should come with a fix")
+			}
+		})
+	}
+}
+
+func TestCheckHooks_ProjectSettings(t *testing.T) {
+	home := t.TempDir()
+	project := t.TempDir()
+	path := writeSettings(t, project, "settings.local.json", `{"enabledPlugins":{"claude-notifications-go@claude-notifications-go":true}}`)
+
+	r := CheckHooks(SettingsPaths(home, project))
+	if r.Status != StatusOK || !strings.Contains(r.Detail, path) {
+		t.Errorf("result = %+v, want ok from %s", r, path)
+	}
+}
+
+func TestCheckFocusTools(t *testing.T) {
+	if r := CheckFocusTools(map[string]bool{"xdotool": true, "wmctrl": false, "gdbus": true}); r.Status != StatusOK || r.Detail != "available: xdotool" {
+		t.Errorf("one tool available: %+v", r)
+	}
+
+	r := CheckFocusTools(map[string]bool{"xdotool": false, "kdotool": false, "gdbus": true})
+	if r.Status != StatusWarn {
+		t.Errorf("helpers alone should not count as focus tools: %+v", r)
+	}
+	if !strings.Contains(r.Fix, "xdotool") || !strings.Contains(r.Fix, "kdotool") {
+		t.Errorf("fix should explain how to install missing tools: %q", r.Fix)
+	}
+
+	if r := CheckFocusTools(map[string]bool{}); r.Status != StatusSkip {
+		t.Errorf("unsupported platform should skip: %+v", r)
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("USERPROFILE", os.Getenv("HOME"))
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	r, cfg := CheckConfig(t.TempDir(), t.TempDir())
+	if r.Status != StatusOK || cfg == nil {
+		t.Errorf("defaults should be valid: %+v", r)
+	}
+}
+
+func TestPrintAndFailed(t *testing.T) {
+	results := []Result{
+		{Name: "Config", Status: StatusOK, Detail: "valid"},
+		{Name: "Claude Code hooks", Status: StatusFail, Detail: "not installed", Fix: "install the plugin"},
+		{Name: "Test notification", Status: StatusSkip, Detail: "skipped", Fix: "not shown"},
+	}
+
+	var buf bytes.Buffer
+	Print(&buf, results)
+	out := buf.String()
+	for _, want := range []string{"✓ Config", "✗ Claude Code hooks", "→ install the plugin", "- Test notification"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "not shown") {
+		t.Error("fixes for skipped checks should not be printed")
+	}
+
+	if !Failed(results) {
+		t.Error("Failed should report the failed check")
+	}
+	if Failed(results[:1]) {
+		t.Error("Failed should be false when every check passes")
+	}
+}
+
+func TestCheckConfig_UserConfigWarning(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	dir := filepath.Join(home, ".config", "claude-notifications")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "config.toml"), []byte("[notifications.desktop]\nsond = false\n"), 0644)
+
+	r, _ := CheckConfig(t.TempDir(), t.TempDir())
+	if r.Status != StatusWarn || !strings.Contains(r.Detail, "did you mean sound") {
+		t.Errorf("unknown key should be a warning: %+v", r)
+	}
+}
+
+func TestFirstLine(t *testing.T) {
+	if got := firstLine("gdbus failed: exit status 1\noutput: Error connecting"); got != "gdbus failed: exit status 1" {
+		t.Errorf("firstLine() = %q", got)
+	}
+	long := strings.Repeat("x", 150)
+	if got := firstLine(long); len([]rune(got)) != 101 || !strings.HasSuffix(got, "…") {
+		t.Errorf("firstLine() of a long line = %q", got)
+	}
+}
@@ -0,0 +1,276 @@
+// Package email sends notifications over SMTP, for a durable record of
+// Claude runs or for headless servers without a desktop.
+package email
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/errorhandler"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/priority"
+	"github.com/777genius/claude-notifications/internal/webhook"
+)
+
+// Built-in templates, used when subject or body is not configured.
+// They use the same fields as custom webhook templates.
+const (
+	DefaultSubject = `[Claude] {{.Title}}{{if .Project}} · {{.Project}}{{end}}`
+	DefaultBody    = `{{.Message}}
+
+Status:  {{.Status}}
+{{- if .Project}}
+Project: {{.Project}}{{end}}
+{{- if .Elapsed}}
+Elapsed: {{.Elapsed}}{{end}}
+Session: {{.SessionID}}
+Time:    {{.Timestamp}}
+`
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Sender sends email notifications over SMTP
+type Sender struct {
+	cfg         config.EmailConfig
+	statuses    map[string]config.StatusInfo
+	subject     *template.Template
+	body        *template.Template
+	templateErr error
+	timeout     time.Duration
+
+	wg sync.WaitGroup
+}
+
+// New creates an email sender. Template errors are reported by Send.
+func New(cfg *config.Config) *Sender {
+	emailCfg := cfg.Notifications.Email
+
+	timeout, _ := time.ParseDuration(emailCfg.Timeout)
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	s := &Sender{cfg: emailCfg, statuses: cfg.Statuses, timeout: timeout}
+
+	subjectText, bodyText := emailCfg.Subject, emailCfg.Body
+	if subjectText == "" {
+		subjectText = DefaultSubject
+	}
+	if bodyText == "" {
+		bodyText = DefaultBody
+	}
+	if s.subject, s.templateErr = webhook.ParseBodyTemplate(subjectText); s.templateErr != nil {
+		s.templateErr = fmt.Errorf("invalid email subject template: %w", s.templateErr)
+	} else if s.body, s.templateErr = webhook.ParseBodyTemplate(bodyText); s.templateErr != nil {
+		s.templateErr = fmt.Errorf("invalid email body template: %w", s.templateErr)
+	}
+	if s.templateErr != nil {
+		logging.Error("%v", s.templateErr)
+	}
+
+	return s
+}
+
+// Send renders and delivers an email notification
+func (s *Sender) Send(status analyzer.Status, message, sessionID string, meta webhook.Meta) error {
+	if s.templateErr != nil {
+		return s.templateErr
+	}
+
+	now := time.Now()
+	title := s.statuses[string(status)].Title
+	if meta.Title != "" {
+		title = meta.Title
+	}
+	data := webhook.NewTemplateData(status, title, message, sessionID, meta, now)
+
+	subject, err := webhook.Render(s.subject, data)
+	if err != nil {
+		return fmt.Errorf("failed to render email subject: %w", err)
+	}
+	body, err := webhook.Render(s.body, data)
+	if err != nil {
+		return fmt.Errorf("failed to render email body: %w", err)
+	}
+
+	msg, err := buildMessage(s.cfg.From, s.cfg.To, strings.TrimSpace(subject), body, data.Priority, now)
+	if err != nil {
+		return err
+	}
+
+	if err := s.deliver(msg); err != nil {
+		return fmt.Errorf("failed to send email via %s: %w", net.JoinHostPort(s.cfg.Host, fmt.Sprint(s.cfg.Port)), err)
+	}
+	logging.Info("Email sent to %d recipient(s)", len(s.cfg.To))
+	return nil
+}
+
+// SendAsync sends an email in the background; Shutdown waits for it
+func (s *Sender) SendAsync(status analyzer.Status, message, sessionID string, meta webhook.Meta) {
+	s.SendAsyncWithResult(status, message, sessionID, meta, nil)
+}
+
+// SendAsyncWithResult is SendAsync that reports the outcome to done
+// (nil error = delivered). done may be nil.
+func (s *Sender) SendAsyncWithResult(status analyzer.Status, message, sessionID string, meta webhook.Meta, done func(err error)) {
+	s.wg.Add(1)
+	errorhandler.SafeGo(func() {
+		defer s.wg.Done()
+
+		err := s.Send(status, message, sessionID, meta)
+		if err != nil {
+			errorhandler.HandleError(err, "Async email send failed")
+		}
+		if done != nil {
+			done(err)
+		}
+	})
+}
+
+// Shutdown waits for in-flight emails to be delivered (with timeout)
+func (s *Sender) Shutdown(timeout time.Duration) error {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		logging.Warn("Email shutdown timeout, some emails may not be delivered")
+		return fmt.Errorf("shutdown timeout after %v", timeout)
+	}
+}
+
+// buildMessage builds an RFC 5322 message with a UTF-8 quoted-printable text body.
+// The subject is reduced to one line so template output can't inject headers.
+// Low and critical priority set the importance mail clients show.
+func buildMessage(from string, to []string, subject, body, prio string, date time.Time) ([]byte, error) {
+	subject = strings.Join(strings.Fields(subject), " ")
+
+	var buf bytes.Buffer
+	recipients := make([]string, len(to))
+	for i, addr := range to {
+		recipients[i] = headerAddress(addr)
+	}
+
+	fmt.Fprintf(&buf, "From: %s\r\n", headerAddress(from))
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(recipients, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	switch prio {
+	case priority.Critical:
+		buf.WriteString("X-Priority: 1 (Highest)\r\nImportance: High\r\n")
+	case priority.Low:
+		buf.WriteString("X-Priority: 5 (Lowest)\r\nImportance: Low\r\n")
+	}
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	buf.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	normalized := strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := qp.Write([]byte(normalized)); err != nil {
+		return nil, fmt.Errorf("failed to encode email body: %w", err)
+	}
+	if err := qp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode email body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// deliver connects to the SMTP server and sends msg to all recipients
+func (s *Sender) deliver(msg []byte) error {
+	addr := net.JoinHostPort(s.cfg.Host, fmt.Sprint(s.cfg.Port))
+	tlsConfig := &tls.Config{ServerName: s.cfg.Host}
+	dialer := &net.Dialer{Timeout: s.timeout}
+
+	var conn net.Conn
+	var err error
+	if s.cfg.Security == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Now().Add(s.timeout))
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if s.cfg.Security == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("server does not support STARTTLS (set security to \"tls\" or \"none\")")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+
+	// smtp.PlainAuth refuses to send credentials over an unencrypted
+	// connection unless the server is localhost
+	if s.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(envelopeAddress(s.cfg.From)); err != nil {
+		return err
+	}
+	for _, to := range s.cfg.To {
+		if err := client.Rcpt(envelopeAddress(to)); err != nil {
+			return fmt.Errorf("recipient %s rejected: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// headerAddress formats an address for a header, encoding non-ASCII display names
+func headerAddress(address string) string {
+	if parsed, err := mail.ParseAddress(address); err == nil {
+		return parsed.String()
+	}
+	return address
+}
+
+// envelopeAddress returns the bare address of "Name <addr>" for MAIL FROM / RCPT TO
+func envelopeAddress(address string) string {
+	if parsed, err := mail.ParseAddress(address); err == nil {
+		return parsed.Address
+	}
+	return address
+}
@@ -0,0 +1,284 @@
+package email
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime/quotedprintable"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/webhook"
+)
+
+// fakeSMTP is a minimal plaintext SMTP server that records one session
+type fakeSMTP struct {
+	addr string
+
+	mu       sync.Mutex
+	auth     string
+	from     string
+	rcpts    []string
+	data     string
+	received chan struct{}
+}
+
+func startFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	srv := &fakeSMTP{addr: ln.Addr().String(), received: make(chan struct{}, 1)}
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		srv.serve(conn)
+	}()
+	return srv
+}
+
+func (f *fakeSMTP) serve(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+
+	reply("220 localhost ESMTP fake")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		f.mu.Lock()
+		switch cmd {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			parts := strings.Fields(line)
+			if decoded, err := base64.StdEncoding.DecodeString(parts[len(parts)-1]); err == nil {
+				f.auth = string(decoded)
+			}
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			f.from = line
+			reply("250 OK")
+		case "RCPT":
+			f.rcpts = append(f.rcpts, line)
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil || l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			f.data = data.String()
+			reply("250 OK queued")
+			f.received <- struct{}{}
+		case "QUIT":
+			reply("221 Bye")
+			f.mu.Unlock()
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+		f.mu.Unlock()
+	}
+}
+
+func newTestConfig(addr string) *config.Config {
+	host, port, _ := net.SplitHostPort(addr)
+	cfg := config.DefaultConfig()
+	cfg.Notifications.Email = config.EmailConfig{
+		Enabled:  true,
+		Host:     host,
+		Security: "none",
+		Username: "bot",
+		Password: "secret",
+		From:     "Claude <claude@example.com>",
+		To:       []string{"dev@example.com", "ops@example.com"},
+		Timeout:  "5s",
+	}
+	cfg.Notifications.Email.Port, _ = strconv.Atoi(port)
+	cfg.Statuses["task_complete"] = config.StatusInfo{Title: "✅ Completed"}
+	return cfg
+}
+
+func TestSend_DeliversMessage(t *testing.T) {
+	srv := startFakeSMTP(t)
+	sender := New(newTestConfig(srv.addr))
+
+	err := sender.Send(analyzer.StatusTaskComplete, "Refactored the parser", "session-1",
+		webhook.Meta{Project: "my-app", Elapsed: 252 * time.Second})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	if srv.auth != "\x00bot\x00secret" {
+		t.Errorf("AUTH PLAIN credentials = %q", srv.auth)
+	}
+	if srv.from != "MAIL FROM:<claude@example.com>" {
+		t.Errorf("MAIL FROM = %q", srv.from)
+	}
+	if len(srv.rcpts) != 2 || !strings.Contains(srv.rcpts[1], "<ops@example.com>") {
+		t.Errorf("RCPT TO = %v", srv.rcpts)
+	}
+
+	headers, body, _ := strings.Cut(srv.data, "\r\n\r\n")
+	for _, want := range []string{
+		"From: \"Claude\" <claude@example.com>",
+		"To: <dev@example.com>, <ops@example.com>",
+		"Subject: =?utf-8?q?[Claude]_=E2=9C=85_Completed_=C2=B7_my-app?=",
+		"Content-Transfer-Encoding: quoted-printable",
+	} {
+		if !strings.Contains(headers, want) {
+			t.Errorf("headers should contain %q, got:\n%s", want, headers)
+		}
+	}
+
+	decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(body)))
+	if err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	for _, want := range []string{"Refactored the parser", "Project: my-app", "Elapsed: 4m12s", "Session: session-1"} {
+		if !strings.Contains(string(decoded), want) {
+			t.Errorf("body should contain %q, got:\n%s", want, decoded)
+		}
+	}
+}
+
+func TestSend_CustomTemplates(t *testing.T) {
+	srv := startFakeSMTP(t)
+	cfg := newTestConfig(srv.addr)
+	cfg.Notifications.Email.Username = ""
+	cfg.Notifications.Email.Subject = "{{upper .Status}} in {{.Project}}"
+	cfg.Notifications.Email.Body = "Done: {{.Message}}"
+
+	if err := New(cfg).Send(analyzer.StatusTaskComplete, "ok", "s", webhook.Meta{Project: "api"}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.auth != "" {
+		t.Errorf("no AUTH expected without username, got %q", srv.auth)
+	}
+	if !strings.Contains(srv.data, "Subject: TASK_COMPLETE in api\r\n") {
+		t.Errorf("custom subject missing:\n%s", srv.data)
+	}
+	if !strings.HasSuffix(strings.TrimRight(srv.data, "\r\n"), "Done: ok") {
+		t.Errorf("custom body missing:\n%s", srv.data)
+	}
+}
+
+func TestSend_InvalidTemplate(t *testing.T) {
+	cfg := newTestConfig("127.0.0.1:1")
+	cfg.Notifications.Email.Subject = "{{.Title"
+
+	err := New(cfg).Send(analyzer.StatusTaskComplete, "ok", "s", webhook.Meta{})
+	if err == nil || !strings.Contains(err.Error(), "invalid email subject template") {
+		t.Errorf("expected subject template error, got %v", err)
+	}
+}
+
+func TestSend_StartTLSUnsupported(t *testing.T) {
+	srv := startFakeSMTP(t)
+	cfg := newTestConfig(srv.addr)
+	cfg.Notifications.Email.Security = "starttls"
+
+	err := New(cfg).Send(analyzer.StatusTaskComplete, "ok", "s", webhook.Meta{})
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("expected STARTTLS error, got %v", err)
+	}
+}
+
+func TestSendAsyncAndShutdown(t *testing.T) {
+	srv := startFakeSMTP(t)
+	sender := New(newTestConfig(srv.addr))
+
+	sender.SendAsync(analyzer.StatusTaskComplete, "ok", "s", webhook.Meta{})
+	if err := sender.Shutdown(5 * time.Second); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	select {
+	case <-srv.received:
+	default:
+		t.Error("email should be delivered before Shutdown returns")
+	}
+}
+
+func TestSendAsyncWithResult(t *testing.T) {
+	srv := startFakeSMTP(t)
+	sender := New(newTestConfig(srv.addr))
+
+	var result error = errors.New("not called")
+	sender.SendAsyncWithResult(analyzer.StatusTaskComplete, "ok", "s", webhook.Meta{}, func(err error) {
+		result = err
+	})
+	if err := sender.Shutdown(5 * time.Second); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if result != nil {
+		t.Errorf("result = %v, want delivered", result)
+	}
+}
+
+func TestBuildMessage_SubjectIsSingleLine(t *testing.T) {
+	msg, err := buildMessage("a@example.com", []string{"b@example.com"}, "Hi\r\nBcc: evil@example.com", "body", "", time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("buildMessage: %v", err)
+	}
+	if strings.Contains(string(msg), "\r\nBcc:") {
+		t.Errorf("subject newline must not create a header:\n%s", msg)
+	}
+	if !strings.Contains(string(msg), "Subject: Hi Bcc: evil@example.com\r\n") {
+		t.Errorf("subject should be folded into one line:\n%s", msg)
+	}
+}
+
+func TestBuildMessage_Priority(t *testing.T) {
+	tests := []struct {
+		priority string
+		want     string
+	}{
+		{"critical", "X-Priority: 1 (Highest)\r\nImportance: High\r\n"},
+		{"low", "X-Priority: 5 (Lowest)\r\nImportance: Low\r\n"},
+	}
+	for _, tt := range tests {
+		msg, err := buildMessage("a@example.com", []string{"b@example.com"}, "Hi", "body", tt.priority, time.Unix(0, 0))
+		if err != nil {
+			t.Fatalf("buildMessage: %v", err)
+		}
+		if !strings.Contains(string(msg), tt.want) {
+			t.Errorf("priority %s: message lacks %q:\n%s", tt.priority, tt.want, msg)
+		}
+	}
+
+	msg, _ := buildMessage("a@example.com", []string{"b@example.com"}, "Hi", "body", "normal", time.Unix(0, 0))
+	if strings.Contains(string(msg), "Importance:") {
+		t.Errorf("normal priority should not set importance:\n%s", msg)
+	}
+}
@@ -0,0 +1,116 @@
+// Package escalation keeps notifications waiting to be escalated: sent to
+// more backends, such as a phone, when the user has not acknowledged them
+// after a while. One escalation is pending per session; a newer
+// notification replaces it, and activity in the session cancels it.
+package escalation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/notifier"
+)
+
+// Pending is a notification waiting to be escalated
+type Pending struct {
+	ID      string         `json:"id"`      // Tells a replaced escalation from the current one
+	Event   notifier.Event `json:"event"`   // Sent to the escalation backends when due
+	Created time.Time      `json:"created"` // When the first notification was sent
+	Due     time.Time      `json:"due"`
+}
+
+// Store keeps pending escalations as one file per session in a directory
+type Store struct {
+	dir string
+}
+
+// NewStore creates a store keeping its files in dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// path returns the file holding the pending escalation of a session
+func (s *Store) path(sessionID string) string {
+	return filepath.Join(s.dir, fmt.Sprintf("escalation-%s.json", sessionID))
+}
+
+// Schedule stores p for its session, replacing any pending escalation
+func (s *Store) Schedule(sessionID string, p Pending) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create escalation directory: %w", err)
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to serialize escalation: %w", err)
+	}
+	tmp := fmt.Sprintf("%s.%d.tmp", s.path(sessionID), os.Getpid())
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write escalation: %w", err)
+	}
+	if err := os.Rename(tmp, s.path(sessionID)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write escalation: %w", err)
+	}
+	return nil
+}
+
+// Get returns the pending escalation of a session (nil = none)
+func (s *Store) Get(sessionID string) (*Pending, error) {
+	return read(s.path(sessionID))
+}
+
+// Cancel drops the pending escalation of a session
+func (s *Store) Cancel(sessionID string) error {
+	if err := os.Remove(s.path(sessionID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to cancel escalation: %w", err)
+	}
+	return nil
+}
+
+// Claim removes and returns the pending escalation of a session if it is
+// still id. The file is renamed before reading, so an escalation is never
+// sent twice; nil when it was cancelled or replaced.
+func (s *Store) Claim(sessionID, id string) (*Pending, error) {
+	path := s.path(sessionID)
+	claimed := fmt.Sprintf("%s.%d", path, os.Getpid())
+	if err := os.Rename(path, claimed); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim escalation: %w", err)
+	}
+	p, err := read(claimed)
+	if err != nil || p == nil || p.ID == id {
+		os.Remove(claimed)
+		return p, err
+	}
+	// Replaced while claiming: hand it back to its own worker, unless an
+	// even newer one took its place
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		if err := os.Rename(claimed, path); err == nil {
+			return nil, nil
+		}
+	}
+	os.Remove(claimed)
+	return nil, nil
+}
+
+// read loads a pending escalation (nil = no file)
+func read(path string) (*Pending, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read escalation: %w", err)
+	}
+	var p Pending
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse escalation: %w", err)
+	}
+	return &p, nil
+}
@@ -0,0 +1,71 @@
+package escalation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/notifier"
+)
+
+func TestStore_ScheduleAndClaim(t *testing.T) {
+	s := NewStore(t.TempDir())
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	p := Pending{
+		ID:      "1",
+		Event:   notifier.Event{Status: analyzer.StatusQuestion, Message: "Which database?", SessionID: "s1", Backends: []string{"webhook"}},
+		Created: now,
+		Due:     now.Add(5 * time.Minute),
+	}
+	if err := s.Schedule("s1", p); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Get("s1")
+	if err != nil || got == nil {
+		t.Fatalf("Get = %v, %v", got, err)
+	}
+	if got.Event.Message != "Which database?" || !got.Due.Equal(p.Due) || got.Event.Backends[0] != "webhook" {
+		t.Errorf("Get = %+v, want %+v", got, p)
+	}
+
+	if got, err := s.Claim("s1", "1"); err != nil || got == nil || got.ID != "1" {
+		t.Fatalf("Claim = %v, %v", got, err)
+	}
+	if got, err := s.Claim("s1", "1"); err != nil || got != nil {
+		t.Errorf("second Claim = %v, %v; want nothing", got, err)
+	}
+}
+
+func TestStore_Replaced(t *testing.T) {
+	s := NewStore(t.TempDir())
+	if err := s.Schedule("s1", Pending{ID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Schedule("s1", Pending{ID: "2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := s.Claim("s1", "1"); err != nil || got != nil {
+		t.Fatalf("Claim of a replaced escalation = %v, %v; want nothing", got, err)
+	}
+	if got, err := s.Get("s1"); err != nil || got == nil || got.ID != "2" {
+		t.Errorf("the newer escalation should stay pending, got %v, %v", got, err)
+	}
+}
+
+func TestStore_Cancel(t *testing.T) {
+	s := NewStore(t.TempDir())
+	if err := s.Cancel("s1"); err != nil {
+		t.Errorf("cancelling nothing: %v", err)
+	}
+	if err := s.Schedule("s1", Pending{ID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Cancel("s1"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := s.Get("s1"); err != nil || got != nil {
+		t.Errorf("Get after Cancel = %v, %v; want nothing", got, err)
+	}
+}
@@ -0,0 +1,33 @@
+package escalation
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// Spawn starts "claude-notifications escalate <session> <id>" detached
+// from the hook, which Claude Code waits for, so the escalation can wait
+// for its delay after the hook has exited
+func Spawn(sessionID, id string) error {
+	return SpawnWorker("escalate", sessionID, id)
+}
+
+// SpawnWorker starts "claude-notifications <command> <args>" detached
+// from the hook, like Spawn, for other workers that outlive the hook
+func SpawnWorker(command string, args ...string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find executable: %w", err)
+	}
+	cmd := exec.Command(exe, append([]string{command}, args...)...)
+	cmd.SysProcAttr = detached()
+	if devNull, err := os.OpenFile(os.DevNull, os.O_RDWR, 0); err == nil {
+		defer devNull.Close()
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = devNull, devNull, devNull
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", command, err)
+	}
+	return cmd.Process.Release()
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package escalation
+
+import "syscall"
+
+// detached starts the process in a new session, so it outlives the hook
+// and is not stopped with the hook's process group
+func detached() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
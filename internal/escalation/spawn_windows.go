@@ -0,0 +1,17 @@
+//go:build windows
+
+package escalation
+
+import "syscall"
+
+// detachedProcess is DETACHED_PROCESS: the process gets no console
+const detachedProcess = 0x00000008
+
+// detached starts the process without a console in its own process group,
+// so it outlives the hook and Ctrl+C in the terminal does not reach it
+func detached() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{
+		CreationFlags: detachedProcess | syscall.CREATE_NEW_PROCESS_GROUP,
+		HideWindow:    true,
+	}
+}
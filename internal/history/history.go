@@ -0,0 +1,219 @@
+// Package history records delivered notifications in an append-only JSONL
+// file and queries them for "claude-notifications history".
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	fileName = "history.jsonl"
+
+	// Delivery results
+	ResultDelivered = "delivered"
+	ResultFailed    = "failed"
+	ResultSkipped   = "skipped" // Not attempted, e.g. while the backend's circuit breaker is open
+
+	// trimFactor lets the file grow to this many times maxEntries before it
+	// is rewritten, so appends rarely pay for a rewrite
+	trimFactor = 2
+)
+
+// Entry is one notification delivered (or attempted) through one backend
+type Entry struct {
+	Time      time.Time `json:"time"`
+	Event     string    `json:"event"` // Notification status, e.g. "task_complete"
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	Project   string    `json:"project,omitempty"`   // Project folder name
+	CWD       string    `json:"cwd,omitempty"`       // Project directory
+	SessionID string    `json:"sessionId,omitempty"` // Claude session ID
+	Backend   string    `json:"backend"`             // "desktop", "webhook", "email", "speech", "mqtt", a webhooks entry name or "plugin:<name>"
+	Result    string    `json:"result"`              // ResultDelivered, ResultFailed or ResultSkipped
+	Error     string    `json:"error,omitempty"`     // Delivery error, or why the delivery was skipped
+}
+
+// Filter selects entries in Query. Zero fields match everything.
+type Filter struct {
+	Project string    // Folder name or full project path
+	Event   string    // Notification status
+	Since   time.Time // Only entries at or after this time
+	Limit   int       // Keep only the newest N matches (0 = all)
+}
+
+// Store appends to and reads the history file in a directory
+type Store struct {
+	dir        string
+	maxEntries int
+	mu         sync.Mutex // Serializes appends from concurrent backend goroutines
+}
+
+// NewStore creates a history store in dir keeping about maxEntries entries
+// (0 = unlimited)
+func NewStore(dir string, maxEntries int) *Store {
+	return &Store{dir: dir, maxEntries: maxEntries}
+}
+
+// Path returns the history file path
+func (s *Store) Path() string {
+	return filepath.Join(s.dir, fileName)
+}
+
+// Append records an entry, trimming the file to the newest maxEntries
+// once it holds trimFactor times as many
+func (s *Store) Append(e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to serialize history entry: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	f, err := os.OpenFile(s.Path(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	_, err = f.Write(append(data, '\n'))
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+
+	if s.maxEntries > 0 {
+		return s.trim()
+	}
+	return nil
+}
+
+// Query returns matching entries, oldest first
+func (s *Store) Query(f Filter) ([]Entry, error) {
+	entries, err := s.readAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []Entry
+	for _, e := range entries {
+		if f.matches(e) {
+			matched = append(matched, e)
+		}
+	}
+	if f.Limit > 0 && len(matched) > f.Limit {
+		matched = matched[len(matched)-f.Limit:]
+	}
+	return matched, nil
+}
+
+// matches reports whether the entry passes every set filter field
+func (f Filter) matches(e Entry) bool {
+	if f.Event != "" && e.Event != f.Event {
+		return false
+	}
+	if f.Project != "" && !strings.EqualFold(e.Project, f.Project) && e.CWD != f.Project {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	return true
+}
+
+// readAll reads every entry, skipping corrupted lines
+func (s *Store) readAll() ([]Entry, error) {
+	file, err := os.Open(s.Path())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// trim rewrites the file with the newest maxEntries entries when it has
+// grown past trimFactor times that. The caller holds s.mu.
+func (s *Store) trim() error {
+	lines, err := countLines(s.Path())
+	if err != nil || lines <= s.maxEntries*trimFactor {
+		return err
+	}
+
+	entries, err := s.readAll()
+	if err != nil {
+		return err
+	}
+	if len(entries) > s.maxEntries {
+		entries = entries[len(entries)-s.maxEntries:]
+	}
+
+	var b strings.Builder
+	for _, e := range entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			continue
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+
+	// Write to a temp file and rename so readers never see a partial file
+	tmp := fmt.Sprintf("%s.%d.tmp", s.Path(), os.Getpid())
+	if err := os.WriteFile(tmp, []byte(b.String()), 0600); err != nil {
+		return fmt.Errorf("failed to trim history: %w", err)
+	}
+	if err := os.Rename(tmp, s.Path()); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to trim history: %w", err)
+	}
+	return nil
+}
+
+// countLines counts newline-terminated lines in a file
+func countLines(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read history: %w", err)
+	}
+	return strings.Count(string(data), "\n"), nil
+}
+
+// ParseSince parses the --since value: a duration back from now such as
+// "2h" or "30m", a date "2006-01-02", or a local date and time "2006-01-02 15:04"
+func ParseSince(arg string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(arg); err == nil {
+		if d <= 0 {
+			return time.Time{}, fmt.Errorf("duration must be positive: %s", arg)
+		}
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, arg, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q (use a duration like 2h or a date like 2026-03-14)", arg)
+}
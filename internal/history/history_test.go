@@ -0,0 +1,140 @@
+package history
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func at(day, hour int) time.Time {
+	return time.Date(2026, 3, day, hour, 0, 0, 0, time.Local)
+}
+
+func appendAll(t *testing.T, s *Store, entries ...Entry) {
+	t.Helper()
+	for _, e := range entries {
+		if err := s.Append(e); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+}
+
+func TestQuery_Filters(t *testing.T) {
+	s := NewStore(t.TempDir(), 0)
+	appendAll(t, s,
+		Entry{Time: at(13, 9), Event: "task_complete", Project: "api", CWD: "/work/api", Backend: "desktop", Result: ResultDelivered},
+		Entry{Time: at(13, 10), Event: "question", Project: "api", CWD: "/work/api", Backend: "desktop", Result: ResultDelivered},
+		Entry{Time: at(14, 9), Event: "task_complete", Project: "web", CWD: "/work/web", Backend: "webhook", Result: ResultFailed, Error: "HTTP 500"},
+	)
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   int
+	}{
+		{"all", Filter{}, 3},
+		{"event", Filter{Event: "task_complete"}, 2},
+		{"project name", Filter{Project: "API"}, 2},
+		{"project path", Filter{Project: "/work/web"}, 1},
+		{"since", Filter{Since: at(13, 10)}, 2},
+		{"combined", Filter{Event: "task_complete", Project: "api", Since: at(13, 0)}, 1},
+		{"limit keeps newest", Filter{Limit: 1}, 1},
+		{"no match", Filter{Event: "plan_ready"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Query(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("Query(%+v) returned %d entries, want %d", tt.filter, len(got), tt.want)
+			}
+		})
+	}
+
+	got, _ := s.Query(Filter{Limit: 1})
+	if got[0].Project != "web" || got[0].Error != "HTTP 500" {
+		t.Errorf("limit should keep the newest entry, got %+v", got[0])
+	}
+}
+
+func TestQuery_MissingFile(t *testing.T) {
+	got, err := NewStore(t.TempDir(), 0).Query(Filter{})
+	if err != nil || len(got) != 0 {
+		t.Errorf("Query on empty store = %v, %v", got, err)
+	}
+}
+
+func TestQuery_SkipsCorruptedLines(t *testing.T) {
+	s := NewStore(t.TempDir(), 0)
+	appendAll(t, s, Entry{Time: at(13, 9), Event: "task_complete", Backend: "desktop", Result: ResultDelivered})
+
+	f, err := os.OpenFile(s.Path(), os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("{not json\n")
+	f.Close()
+	appendAll(t, s, Entry{Time: at(13, 10), Event: "question", Backend: "desktop", Result: ResultDelivered})
+
+	got, err := s.Query(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Errorf("got %d entries, want 2", len(got))
+	}
+}
+
+func TestAppend_TrimsToMaxEntries(t *testing.T) {
+	s := NewStore(t.TempDir(), 3)
+	for i := 0; i < 7; i++ {
+		appendAll(t, s, Entry{Time: at(13, i), Event: "task_complete", Backend: "desktop", Result: ResultDelivered})
+	}
+
+	got, err := s.Query(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The 7th append pushed the file past 2×3 lines, so it was cut to the newest 3
+	if len(got) != 3 {
+		t.Fatalf("got %d entries after trim, want 3", len(got))
+	}
+	if !got[0].Time.Equal(at(13, 4)) {
+		t.Errorf("oldest kept entry is at %v, want %v", got[0].Time, at(13, 4))
+	}
+
+	data, _ := os.ReadFile(s.Path())
+	if strings.Count(string(data), "\n") != 3 {
+		t.Errorf("history file should have 3 lines after trim:\n%s", data)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := at(14, 12)
+	tests := []struct {
+		arg     string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2h", at(14, 10), false},
+		{"24h", at(13, 12), false},
+		{"2026-03-13", time.Date(2026, 3, 13, 0, 0, 0, 0, time.Local), false},
+		{"2026-03-13 18:30", time.Date(2026, 3, 13, 18, 30, 0, 0, time.Local), false},
+		{"-1h", time.Time{}, true},
+		{"yesterday", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			got, err := ParseSince(tt.arg, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSince(%q) error = %v, wantErr %v", tt.arg, err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseSince(%q) = %v, want %v", tt.arg, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+// Package hookevent parses the JSON payload Claude Code writes to a hook's
+// stdin into typed structs. Claude Code does not version the payload, so the
+// schema version is inferred from the fields present; fields added by newer
+// releases are kept in Event.Extra instead of failing the hook.
+package hookevent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Schema versions of the hook payload
+const (
+	// SchemaLegacy payloads predate hook_event_name: only session_id,
+	// transcript_path and the event-specific fields
+	SchemaLegacy = 1
+	// SchemaCurrent payloads carry hook_event_name and cwd
+	SchemaCurrent = 2
+)
+
+// Event is a hook payload. Event-specific fields are empty for other events.
+type Event struct {
+	SessionID      string `json:"session_id"`
+	TranscriptPath string `json:"transcript_path"`
+	CWD            string `json:"cwd"`
+	HookEventName  string `json:"hook_event_name,omitempty"`
+	PermissionMode string `json:"permission_mode,omitempty"` // "default", "plan", "acceptEdits" or "bypassPermissions"
+
+	// PreToolUse, PostToolUse
+	ToolName     string          `json:"tool_name,omitempty"`
+	ToolInput    json.RawMessage `json:"tool_input,omitempty"`
+	ToolResponse json.RawMessage `json:"tool_response,omitempty"` // PostToolUse only
+
+	Message            string `json:"message,omitempty"`             // Notification: "Claude needs your permission to use Bash"
+	NotificationType   string `json:"notification_type,omitempty"`   // Notification: "permission_prompt", "idle_prompt", ... (newer releases)
+	StopHookActive     bool   `json:"stop_hook_active,omitempty"`    // Stop, SubagentStop: a Stop hook already continued the turn
+	Prompt             string `json:"prompt,omitempty"`              // UserPromptSubmit
+	Trigger            string `json:"trigger,omitempty"`             // PreCompact: "manual" or "auto"
+	CustomInstructions string `json:"custom_instructions,omitempty"` // PreCompact
+	Source             string `json:"source,omitempty"`              // SessionStart: "startup", "resume", "clear" or "compact"
+	Reason             string `json:"reason,omitempty"`              // SessionEnd: "clear", "logout", "prompt_input_exit" or "other"
+
+	// SchemaVersion is the payload's schema_version when Claude Code sends
+	// one, else inferred: SchemaLegacy or SchemaCurrent
+	SchemaVersion int `json:"schema_version,omitempty"`
+	// Extra holds the fields this version does not know
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// knownFields are the JSON keys of Event
+var knownFields = map[string]bool{
+	"session_id": true, "transcript_path": true, "cwd": true, "hook_event_name": true,
+	"permission_mode": true, "tool_name": true, "tool_input": true, "tool_response": true,
+	"message": true, "notification_type": true, "stop_hook_active": true, "prompt": true, "trigger": true,
+	"custom_instructions": true, "source": true, "reason": true, "schema_version": true,
+}
+
+// Parse reads a hook payload from r. hookEvent is the event the hook was
+// registered for; it fills HookEventName for legacy payloads.
+func Parse(r io.Reader, hookEvent string) (Event, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to read hook payload: %w", err)
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return Event{}, fmt.Errorf("empty hook payload")
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return Event{}, fmt.Errorf("hook payload is not a JSON object: %w", err)
+	}
+	var e Event
+	if err := json.Unmarshal(data, &e); err != nil {
+		return Event{}, fmt.Errorf("invalid hook payload: %w", err)
+	}
+	for key, value := range fields {
+		if !knownFields[key] {
+			if e.Extra == nil {
+				e.Extra = map[string]json.RawMessage{}
+			}
+			e.Extra[key] = value
+		}
+	}
+
+	if e.SchemaVersion == 0 {
+		e.SchemaVersion = SchemaCurrent
+		if e.HookEventName == "" {
+			e.SchemaVersion = SchemaLegacy
+		}
+	}
+	if e.HookEventName == "" {
+		e.HookEventName = hookEvent
+	}
+	return e, nil
+}
+
+// IsNewer reports whether the payload comes from a schema newer than this
+// parser knows; its new fields are in Extra
+func (e *Event) IsNewer() bool {
+	return e.SchemaVersion > SchemaCurrent
+}
+
+// IsPermissionPrompt reports whether a Notification event asks the user to
+// approve a tool, by notification_type or, for releases without it, by the
+// message
+func (e *Event) IsPermissionPrompt() bool {
+	if e.HookEventName != "Notification" {
+		return false
+	}
+	if e.NotificationType != "" {
+		return e.NotificationType == "permission_prompt"
+	}
+	return strings.Contains(strings.ToLower(e.Message), "permission")
+}
+
+// ToolInput holds the tool_input fields notifications use; fields the
+// tool does not have are empty
+type ToolInput struct {
+	Command     string     `json:"command,omitempty"`     // Bash
+	Description string     `json:"description,omitempty"` // Bash, Task
+	FilePath    string     `json:"file_path,omitempty"`   // Read, Write, Edit, MultiEdit
+	Pattern     string     `json:"pattern,omitempty"`     // Glob, Grep
+	URL         string     `json:"url,omitempty"`         // WebFetch
+	Query       string     `json:"query,omitempty"`       // WebSearch
+	Prompt      string     `json:"prompt,omitempty"`      // Task, WebFetch
+	Plan        string     `json:"plan,omitempty"`        // ExitPlanMode
+	Questions   []Question `json:"questions,omitempty"`   // AskUserQuestion
+}
+
+// Argument returns the argument that best describes the call: the command,
+// file, URL, query or pattern, else the description or prompt ("" = none)
+func (in ToolInput) Argument() string {
+	for _, arg := range []string{in.Command, in.FilePath, in.URL, in.Query, in.Pattern, in.Description, in.Prompt} {
+		if arg != "" {
+			return arg
+		}
+	}
+	if len(in.Questions) > 0 {
+		return in.Questions[0].Question
+	}
+	return ""
+}
+
+// Question is an AskUserQuestion question
+type Question struct {
+	Question string `json:"question"`
+	Header   string `json:"header,omitempty"`
+}
+
+// Tool decodes tool_input. Tools with other input shapes, such as MCP
+// tools, decode to an empty ToolInput; use DecodeToolInput for those.
+func (e *Event) Tool() (ToolInput, error) {
+	var in ToolInput
+	err := e.DecodeToolInput(&in)
+	return in, err
+}
+
+// DecodeToolInput decodes tool_input into v; a missing tool_input leaves v unchanged
+func (e *Event) DecodeToolInput(v interface{}) error {
+	if len(e.ToolInput) == 0 || string(e.ToolInput) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(e.ToolInput, v); err != nil {
+		return fmt.Errorf("invalid tool_input for %s: %w", e.ToolName, err)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package hookevent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse_PreToolUse(t *testing.T) {
+	payload := `{
+		"session_id": "abc123",
+		"transcript_path": "/home/user/.claude/projects/p/abc123.jsonl",
+		"cwd": "/home/user/project",
+		"hook_event_name": "PreToolUse",
+		"permission_mode": "default",
+		"tool_name": "Bash",
+		"tool_input": {"command": "go test ./...", "description": "Run tests", "timeout": 120000}
+	}`
+	e, err := Parse(strings.NewReader(payload), "PreToolUse")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if e.SessionID != "abc123" || e.CWD != "/home/user/project" || e.ToolName != "Bash" || e.PermissionMode != "default" {
+		t.Errorf("Parse() = %+v", e)
+	}
+	if e.SchemaVersion != SchemaCurrent {
+		t.Errorf("SchemaVersion = %d, want %d", e.SchemaVersion, SchemaCurrent)
+	}
+	if len(e.Extra) != 0 {
+		t.Errorf("Extra = %v, want none", e.Extra)
+	}
+
+	in, err := e.Tool()
+	if err != nil {
+		t.Fatalf("Tool() error = %v", err)
+	}
+	if in.Command != "go test ./..." || in.Description != "Run tests" {
+		t.Errorf("Tool() = %+v", in)
+	}
+}
+
+func TestParse_AskUserQuestion(t *testing.T) {
+	payload := `{"session_id": "s", "hook_event_name": "PreToolUse", "tool_name": "AskUserQuestion",
+		"tool_input": {"questions": [{"question": "Which database?", "header": "Database", "options": []}]}}`
+	e, err := Parse(strings.NewReader(payload), "PreToolUse")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	in, err := e.Tool()
+	if err != nil {
+		t.Fatalf("Tool() error = %v", err)
+	}
+	if len(in.Questions) != 1 || in.Questions[0].Question != "Which database?" || in.Questions[0].Header != "Database" {
+		t.Errorf("Questions = %+v", in.Questions)
+	}
+}
+
+func TestParse_EventFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		check   func(Event) bool
+	}{
+		{"Notification", `{"hook_event_name": "Notification", "message": "Claude needs your permission to use Bash"}`,
+			func(e Event) bool { return e.Message == "Claude needs your permission to use Bash" }},
+		{"Stop", `{"hook_event_name": "Stop", "stop_hook_active": true}`,
+			func(e Event) bool { return e.StopHookActive }},
+		{"UserPromptSubmit", `{"hook_event_name": "UserPromptSubmit", "prompt": "fix the bug"}`,
+			func(e Event) bool { return e.Prompt == "fix the bug" }},
+		{"PreCompact", `{"hook_event_name": "PreCompact", "trigger": "manual", "custom_instructions": "keep tests"}`,
+			func(e Event) bool { return e.Trigger == "manual" && e.CustomInstructions == "keep tests" }},
+		{"SessionStart", `{"hook_event_name": "SessionStart", "source": "resume"}`,
+			func(e Event) bool { return e.Source == "resume" }},
+		{"SessionEnd", `{"hook_event_name": "SessionEnd", "reason": "logout"}`,
+			func(e Event) bool { return e.Reason == "logout" }},
+		{"PostToolUse", `{"hook_event_name": "PostToolUse", "tool_name": "Write", "tool_response": {"success": true}}`,
+			func(e Event) bool { return string(e.ToolResponse) == `{"success": true}` }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := Parse(strings.NewReader(tt.payload), tt.name)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !tt.check(e) {
+				t.Errorf("Parse() = %+v", e)
+			}
+		})
+	}
+}
+
+func TestParse_Legacy(t *testing.T) {
+	e, err := Parse(strings.NewReader(`{"session_id": "s", "transcript_path": "/t.jsonl"}`), "Stop")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if e.SchemaVersion != SchemaLegacy {
+		t.Errorf("SchemaVersion = %d, want %d", e.SchemaVersion, SchemaLegacy)
+	}
+	if e.HookEventName != "Stop" {
+		t.Errorf("HookEventName = %q, want the registered event %q", e.HookEventName, "Stop")
+	}
+}
+
+func TestParse_NewerSchema(t *testing.T) {
+	payload := `{"schema_version": 3, "session_id": "s", "hook_event_name": "Stop", "agent_id": "a1", "usage": {"tokens": 5}}`
+	e, err := Parse(strings.NewReader(payload), "Stop")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !e.IsNewer() {
+		t.Errorf("IsNewer() = false for schema %d", e.SchemaVersion)
+	}
+	if string(e.Extra["agent_id"]) != `"a1"` || string(e.Extra["usage"]) != `{"tokens": 5}` {
+		t.Errorf("Extra = %v", e.Extra)
+	}
+	if e.SessionID != "s" {
+		t.Errorf("SessionID = %q, want known fields parsed", e.SessionID)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, payload := range []string{"", "  \n", "invalid json", `["array"]`, `{"session_id": 5}`} {
+		if _, err := Parse(strings.NewReader(payload), "Stop"); err == nil {
+			t.Errorf("Parse(%q) expected an error", payload)
+		}
+	}
+}
+
+func TestTool_NoInput(t *testing.T) {
+	e := Event{ToolName: "ExitPlanMode"}
+	in, err := e.Tool()
+	if err != nil || in.Command != "" || in.Questions != nil {
+		t.Errorf("Tool() = %+v, %v; want empty", in, err)
+	}
+}
+
+func TestTool_MCPInput(t *testing.T) {
+	e := Event{ToolName: "mcp__db__query", ToolInput: []byte(`{"sql": "select 1"}`)}
+	var in struct {
+		SQL string `json:"sql"`
+	}
+	if err := e.DecodeToolInput(&in); err != nil || in.SQL != "select 1" {
+		t.Errorf("DecodeToolInput() = %+v, %v", in, err)
+	}
+}
+
+func TestIsPermissionPrompt(t *testing.T) {
+	tests := []struct {
+		e    Event
+		want bool
+	}{
+		{Event{HookEventName: "Notification", Message: "Claude needs your permission to use Bash"}, true},
+		{Event{HookEventName: "Notification", Message: "Claude is waiting for your input"}, false},
+		{Event{HookEventName: "Notification", NotificationType: "permission_prompt", Message: "Approve Bash?"}, true},
+		{Event{HookEventName: "Notification", NotificationType: "idle_prompt", Message: "permission"}, false},
+		{Event{HookEventName: "PreToolUse", Message: "permission"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.e.IsPermissionPrompt(); got != tt.want {
+			t.Errorf("IsPermissionPrompt(%+v) = %v, want %v", tt.e, got, tt.want)
+		}
+	}
+}
+
+func TestToolInput_Argument(t *testing.T) {
+	tests := []struct {
+		in   ToolInput
+		want string
+	}{
+		{ToolInput{Command: "go test ./...", Description: "Run tests"}, "go test ./..."},
+		{ToolInput{FilePath: "/src/main.go"}, "/src/main.go"},
+		{ToolInput{URL: "https://go.dev"}, "https://go.dev"},
+		{ToolInput{Description: "Explore the repo"}, "Explore the repo"},
+		{ToolInput{Questions: []Question{{Question: "Which one?"}}}, "Which one?"},
+		{ToolInput{}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.in.Argument(); got != tt.want {
+			t.Errorf("Argument(%+v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
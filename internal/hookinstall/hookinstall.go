@@ -0,0 +1,307 @@
+// Package hookinstall writes the notification hooks into Claude Code
+// settings files for "claude-notifications install-hooks", so the binary
+// works without installing the plugin. Hooks are merged next to the
+// user's own settings and hooks; running it again replaces the previous
+// entries instead of duplicating them.
+package hookinstall
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Scope selects which settings file to edit
+type Scope string
+
+const (
+	ScopeUser    Scope = "user"    // ~/.claude/settings.json
+	ScopeProject Scope = "project" // <project>/.claude/settings.json
+)
+
+// hookTimeout is the timeout in seconds for each hook, as in hooks/hooks.json
+const hookTimeout = 30
+
+// Event is a Claude Code hook event the binary handles
+type Event struct {
+	Name    string
+	Matcher string // Tool or notification type filter ("" = all)
+}
+
+// Events mirrors hooks/hooks.json, which the plugin installs
+var Events = []Event{
+	{Name: "PreToolUse", Matcher: "ExitPlanMode|AskUserQuestion"},
+	{Name: "Notification", Matcher: "permission_prompt"},
+	{Name: "Stop"},
+	{Name: "SubagentStop"},
+	{Name: "SessionStart"},
+	{Name: "SessionEnd"},
+	{Name: "UserPromptSubmit"},
+}
+
+// ToolEvents returns the hooks announcing the tools selected by
+// toolMatcher (a hook matcher, see config.ToolsConfig.Matcher), before
+// and/or after they run; nil when toolMatcher is empty
+func ToolEvents(toolMatcher string, before, after bool) []Event {
+	if toolMatcher == "" {
+		return nil
+	}
+	var events []Event
+	if before {
+		events = append(events, Event{Name: "PreToolUse", Matcher: toolMatcher})
+	}
+	if after {
+		events = append(events, Event{Name: "PostToolUse", Matcher: toolMatcher})
+	}
+	return events
+}
+
+// WithAllTools replaces any PostToolUse hook in events with one for all
+// tools, which checks notifications.budget and updates
+// notifications.progress after every tool call and still announces the
+// tools of notifications.tools
+func WithAllTools(events []Event) []Event {
+	var out []Event
+	for _, e := range events {
+		if e.Name != "PostToolUse" {
+			out = append(out, e)
+		}
+	}
+	return append(out, Event{Name: "PostToolUse"})
+}
+
+// SettingsPath returns the settings file for a scope
+func SettingsPath(scope Scope, home, project string) (string, error) {
+	switch scope {
+	case ScopeUser:
+		return filepath.Join(home, ".claude", "settings.json"), nil
+	case ScopeProject:
+		return filepath.Join(project, ".claude", "settings.json"), nil
+	default:
+		return "", fmt.Errorf("unknown scope %q (use user or project)", scope)
+	}
+}
+
+// Command returns the hook command that runs binary for an event
+func Command(binary, event string) string {
+	if strings.ContainsAny(binary, " \t'\"") {
+		binary = `"` + strings.ReplaceAll(binary, `"`, `\"`) + `"`
+	}
+	return binary + " handle-hook " + event
+}
+
+// IsOurCommand reports whether a hook command runs this binary, under
+// any name or path: it ends in "handle-hook <Event>"
+func IsOurCommand(command string) bool {
+	command = strings.TrimSpace(command)
+	for _, e := range Events {
+		if strings.HasSuffix(command, " handle-hook "+e.Name) {
+			return true
+		}
+	}
+	// Only installed for notifications.tools and notifications.budget
+	return strings.HasSuffix(command, " handle-hook PostToolUse")
+}
+
+// Install adds hooks running binary to the settings file at path,
+// replacing hooks from an earlier install. It returns false if the file
+// already had exactly these hooks.
+func Install(path, binary string) (bool, error) {
+	return InstallEvents(path, binary, Events)
+}
+
+// InstallEvents is Install for the given events, e.g. Events followed by
+// ToolEvents
+func InstallEvents(path, binary string, events []Event) (bool, error) {
+	settings, err := readSettings(path)
+	if err != nil {
+		return false, err
+	}
+	before, _ := json.Marshal(settings)
+
+	removeHooks(settings)
+	hooks, _ := settings["hooks"].(map[string]interface{})
+	if hooks == nil {
+		hooks = map[string]interface{}{}
+		settings["hooks"] = hooks
+	}
+	for _, e := range events {
+		group := map[string]interface{}{
+			"hooks": []interface{}{
+				map[string]interface{}{
+					"type":    "command",
+					"command": Command(binary, e.Name),
+					"timeout": hookTimeout,
+				},
+			},
+		}
+		if e.Matcher != "" {
+			group["matcher"] = e.Matcher
+		}
+		groups, _ := hooks[e.Name].([]interface{})
+		hooks[e.Name] = append(groups, group)
+	}
+
+	after, _ := json.Marshal(settings)
+	if bytes.Equal(before, after) {
+		return false, nil
+	}
+	return true, writeSettings(path, settings)
+}
+
+// Uninstall removes hooks running this binary from the settings file at
+// path. It returns false if there were none.
+func Uninstall(path string) (bool, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	settings, err := readSettings(path)
+	if err != nil {
+		return false, err
+	}
+	if !removeHooks(settings) {
+		return false, nil
+	}
+	return true, writeSettings(path, settings)
+}
+
+// PluginEnabled reports whether the settings file at path enables the
+// claude-notifications-go plugin, whose hooks would fire alongside
+// installed ones
+func PluginEnabled(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var s struct {
+		EnabledPlugins map[string]bool `json:"enabledPlugins"`
+	}
+	if json.Unmarshal(data, &s) != nil {
+		return false
+	}
+	for name, enabled := range s.EnabledPlugins {
+		if enabled && strings.HasPrefix(name, "claude-notifications-go@") {
+			return true
+		}
+	}
+	return false
+}
+
+// removeHooks drops hook commands running this binary, then matcher
+// groups and events left empty. It returns true if anything was removed.
+func removeHooks(settings map[string]interface{}) bool {
+	hooks, ok := settings["hooks"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	removed := false
+	for event, v := range hooks {
+		groups, ok := v.([]interface{})
+		if !ok {
+			continue
+		}
+		var keptGroups []interface{}
+		for _, g := range groups {
+			group, ok := g.(map[string]interface{})
+			if !ok {
+				keptGroups = append(keptGroups, g)
+				continue
+			}
+			commands, _ := group["hooks"].([]interface{})
+			var kept []interface{}
+			for _, c := range commands {
+				if cmd, ok := c.(map[string]interface{}); ok {
+					if s, _ := cmd["command"].(string); IsOurCommand(s) {
+						removed = true
+						continue
+					}
+				}
+				kept = append(kept, c)
+			}
+			if len(kept) == 0 && len(commands) > 0 {
+				continue
+			}
+			if len(kept) != len(commands) {
+				group["hooks"] = kept
+			}
+			keptGroups = append(keptGroups, group)
+		}
+		if len(keptGroups) == 0 {
+			delete(hooks, event)
+		} else {
+			hooks[event] = keptGroups
+		}
+	}
+	if len(hooks) == 0 {
+		delete(settings, "hooks")
+	}
+	return removed
+}
+
+// readSettings parses a settings file; a missing file is empty settings
+func readSettings(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return map[string]interface{}{}, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	settings := map[string]interface{}{}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return settings, nil
+	}
+	// UseNumber keeps numbers such as timeouts exactly as written
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&settings); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return settings, nil
+}
+
+// writeSettings replaces the settings file atomically so Claude Code
+// never reads a partial file
+func writeSettings(path string, settings map[string]interface{}) error {
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize settings: %w", err)
+	}
+	data = append(data, '\n')
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(dir, "settings-*.json.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
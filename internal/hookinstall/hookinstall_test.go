@@ -0,0 +1,247 @@
+package hookinstall
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const binary = "/opt/claude-notifications/bin/claude-notifications"
+
+func readJSON(t *testing.T, path string) map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v map[string]interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("invalid JSON written: %v\n%s", err, data)
+	}
+	return v
+}
+
+// commands returns the hook commands for an event
+func commands(settings map[string]interface{}, event string) []string {
+	hooks, _ := settings["hooks"].(map[string]interface{})
+	groups, _ := hooks[event].([]interface{})
+	var out []string
+	for _, g := range groups {
+		for _, c := range g.(map[string]interface{})["hooks"].([]interface{}) {
+			out = append(out, c.(map[string]interface{})["command"].(string))
+		}
+	}
+	return out
+}
+
+func TestInstall_NewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".claude", "settings.json")
+
+	changed, err := Install(path, binary)
+	if err != nil || !changed {
+		t.Fatalf("Install = %v, %v", changed, err)
+	}
+
+	s := readJSON(t, path)
+	for _, e := range Events {
+		got := commands(s, e.Name)
+		if len(got) != 1 || got[0] != binary+" handle-hook "+e.Name {
+			t.Errorf("%s commands = %v", e.Name, got)
+		}
+	}
+	group := s["hooks"].(map[string]interface{})["PreToolUse"].([]interface{})[0].(map[string]interface{})
+	if group["matcher"] != "ExitPlanMode|AskUserQuestion" {
+		t.Errorf("PreToolUse matcher = %v", group["matcher"])
+	}
+}
+
+func TestInstall_MergesAndIsIdempotent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	existing := `{
+  "model": "opus",
+  "permissions": {"allow": ["Bash(go test:*)"]},
+  "hooks": {
+    "Stop": [{"hooks": [{"type": "command", "command": "say done", "timeout": 5}]}]
+  }
+}`
+	if err := os.WriteFile(path, []byte(existing), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if changed, err := Install(path, binary); err != nil || !changed {
+		t.Fatalf("first Install = %v, %v", changed, err)
+	}
+	first, _ := os.ReadFile(path)
+
+	if changed, err := Install(path, binary); err != nil || changed {
+		t.Fatalf("second Install = %v, %v, want no change", changed, err)
+	}
+	second, _ := os.ReadFile(path)
+	if string(first) != string(second) {
+		t.Error("reinstalling should leave the file unchanged")
+	}
+
+	s := readJSON(t, path)
+	if s["model"] != "opus" || s["permissions"] == nil {
+		t.Errorf("unrelated settings lost: %v", s)
+	}
+	if got := commands(s, "Stop"); len(got) != 2 || got[0] != "say done" {
+		t.Errorf("Stop commands = %v, want the user's hook kept and ours added once", got)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want the original 0600 kept", info.Mode().Perm())
+	}
+}
+
+func TestInstall_ReplacesOldBinaryPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	Install(path, "/old/claude-notifications")
+
+	if changed, err := Install(path, binary); err != nil || !changed {
+		t.Fatalf("Install = %v, %v", changed, err)
+	}
+	if got := commands(readJSON(t, path), "Notification"); len(got) != 1 || !strings.HasPrefix(got[0], binary) {
+		t.Errorf("Notification commands = %v, want only the new path", got)
+	}
+}
+
+func TestInstall_InvalidJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	os.WriteFile(path, []byte(`{"hooks":`), 0644)
+
+	if _, err := Install(path, binary); err == nil {
+		t.Fatal("Install should refuse to overwrite an unparseable file")
+	}
+	if data, _ := os.ReadFile(path); string(data) != `{"hooks":` {
+		t.Error("file should be left untouched")
+	}
+}
+
+func TestInstallEvents_ToolHooks(t *testing.T) {
+	if got := ToolEvents("", true, true); got != nil {
+		t.Errorf("ToolEvents without a matcher = %v, want none", got)
+	}
+	path := filepath.Join(t.TempDir(), "settings.json")
+	events := append(append([]Event{}, Events...), ToolEvents("^(Bash)$", true, true)...)
+	if changed, err := InstallEvents(path, binary, events); err != nil || !changed {
+		t.Fatalf("InstallEvents = %v, %v", changed, err)
+	}
+
+	s := readJSON(t, path)
+	if got := commands(s, "PreToolUse"); len(got) != 2 {
+		t.Errorf("PreToolUse commands = %v, want the plugin's and the tools' hook", got)
+	}
+	post := s["hooks"].(map[string]interface{})["PostToolUse"].([]interface{})
+	if len(post) != 1 || post[0].(map[string]interface{})["matcher"] != "^(Bash)$" {
+		t.Errorf("PostToolUse groups = %v", post)
+	}
+
+	// Reinstalling without tools removes the tool hooks
+	if changed, err := Install(path, binary); err != nil || !changed {
+		t.Fatalf("Install = %v, %v", changed, err)
+	}
+	s = readJSON(t, path)
+	if _, ok := s["hooks"].(map[string]interface{})["PostToolUse"]; ok {
+		t.Error("PostToolUse hook should be removed")
+	}
+	if got := commands(s, "PreToolUse"); len(got) != 1 {
+		t.Errorf("PreToolUse commands = %v, want one", got)
+	}
+}
+
+func TestWithAllTools(t *testing.T) {
+	events := WithAllTools(ToolEvents("^(Bash)$", true, true))
+	want := []Event{{Name: "PreToolUse", Matcher: "^(Bash)$"}, {Name: "PostToolUse"}}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("WithAllTools = %v, want %v", events, want)
+	}
+	if got := WithAllTools(nil); !reflect.DeepEqual(got, []Event{{Name: "PostToolUse"}}) {
+		t.Errorf("WithAllTools(nil) = %v", got)
+	}
+}
+
+func TestUninstall(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	os.WriteFile(path, []byte(`{"hooks":{"Stop":[{"hooks":[{"type":"command","command":"say done"}]}]}}`), 0644)
+	Install(path, binary)
+
+	if changed, err := Uninstall(path); err != nil || !changed {
+		t.Fatalf("Uninstall = %v, %v", changed, err)
+	}
+	s := readJSON(t, path)
+	if got := commands(s, "Stop"); len(got) != 1 || got[0] != "say done" {
+		t.Errorf("Stop commands = %v, want only the user's hook", got)
+	}
+	if _, ok := s["hooks"].(map[string]interface{})["Notification"]; ok {
+		t.Error("events left empty should be removed")
+	}
+
+	if changed, err := Uninstall(path); err != nil || changed {
+		t.Errorf("second Uninstall = %v, %v, want no change", changed, err)
+	}
+	if changed, err := Uninstall(filepath.Join(t.TempDir(), "missing.json")); err != nil || changed {
+		t.Errorf("Uninstall of a missing file = %v, %v", changed, err)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	if got := Command("/usr/local/bin/claude-notifications", "Stop"); got != "/usr/local/bin/claude-notifications handle-hook Stop" {
+		t.Errorf("Command = %q", got)
+	}
+	if got := Command("/Users/me/My Tools/claude-notifications", "Stop"); got != `"/Users/me/My Tools/claude-notifications" handle-hook Stop` {
+		t.Errorf("paths with spaces should be quoted: %q", got)
+	}
+	if !IsOurCommand(Command("/Users/me/My Tools/claude-notifications", "Stop")) {
+		t.Error("IsOurCommand should match installed commands")
+	}
+	if !IsOurCommand("~/bin/cn handle-hook Notification") {
+		t.Error("IsOurCommand should match a renamed binary")
+	}
+	if !IsOurCommand("cn handle-hook PostToolUse") {
+		t.Error("IsOurCommand should match the tool hook")
+	}
+	if IsOurCommand("say done") || IsOurCommand("other-tool handle-hook Unknown") {
+		t.Error("IsOurCommand should not match unrelated commands")
+	}
+}
+
+func TestSettingsPath(t *testing.T) {
+	if p, _ := SettingsPath(ScopeUser, "/home/me", "/work/api"); p != filepath.Join("/home/me", ".claude", "settings.json") {
+		t.Errorf("user path = %s", p)
+	}
+	if p, _ := SettingsPath(ScopeProject, "/home/me", "/work/api"); p != filepath.Join("/work/api", ".claude", "settings.json") {
+		t.Errorf("project path = %s", p)
+	}
+	if _, err := SettingsPath("global", "", ""); err == nil {
+		t.Error("unknown scope should fail")
+	}
+}
+
+// TestEventsMatchPluginHooks keeps Events in sync with the plugin's hooks.json
+func TestEventsMatchPluginHooks(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "hooks", "hooks.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var plugin struct {
+		Hooks map[string][]struct {
+			Matcher string `json:"matcher"`
+		} `json:"hooks"`
+	}
+	if err := json.Unmarshal(data, &plugin); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plugin.Hooks) != len(Events) {
+		t.Errorf("hooks.json has %d events, Events has %d", len(plugin.Hooks), len(Events))
+	}
+	for _, e := range Events {
+		groups, ok := plugin.Hooks[e.Name]
+		if !ok || len(groups) != 1 || groups[0].Matcher != e.Matcher {
+			t.Errorf("%s: hooks.json = %+v, Events matcher = %q", e.Name, groups, e.Matcher)
+		}
+	}
+}
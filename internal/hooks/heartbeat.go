@@ -0,0 +1,20 @@
+package hooks
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/sessions"
+)
+
+// sendHeartbeat reports that the session has been working on its prompt
+// for a while, replacing its previous heartbeat or progress notification.
+// It goes to the heartbeat backends only.
+func (h *Handler) sendHeartbeat(s *sessions.Session, quiet, interval time.Duration) {
+	message := fmt.Sprintf("Claude still working on %s, %s elapsed", s.Project(), formatMinutes(time.Since(s.PromptAt)))
+	if quiet >= interval {
+		message += fmt.Sprintf(", no activity for %s", formatMinutes(quiet))
+	}
+	h.sendWatchEvent(s, "Heartbeat", analyzer.StatusStillWorking, message, h.cfg.Notifications.Heartbeat.GetBackends(), workingKey(s.ID))
+}
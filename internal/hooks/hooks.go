@@ -1,48 +1,111 @@
 package hooks
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/breaker"
+	"github.com/777genius/claude-notifications/internal/budget"
 	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/daemon"
 	"github.com/777genius/claude-notifications/internal/dedup"
+	"github.com/777genius/claude-notifications/internal/digest"
+	"github.com/777genius/claude-notifications/internal/dnd"
+	"github.com/777genius/claude-notifications/internal/email"
 	"github.com/777genius/claude-notifications/internal/errorhandler"
+	"github.com/777genius/claude-notifications/internal/escalation"
+	"github.com/777genius/claude-notifications/internal/history"
+	"github.com/777genius/claude-notifications/internal/hookevent"
+	"github.com/777genius/claude-notifications/internal/idle"
 	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/mqtt"
 	"github.com/777genius/claude-notifications/internal/notifier"
 	"github.com/777genius/claude-notifications/internal/platform"
+	"github.com/777genius/claude-notifications/internal/plugin"
+	"github.com/777genius/claude-notifications/internal/priority"
+	"github.com/777genius/claude-notifications/internal/redact"
+	"github.com/777genius/claude-notifications/internal/rules"
+	"github.com/777genius/claude-notifications/internal/script"
 	"github.com/777genius/claude-notifications/internal/sessionname"
+	"github.com/777genius/claude-notifications/internal/sessions"
+	"github.com/777genius/claude-notifications/internal/speech"
 	"github.com/777genius/claude-notifications/internal/state"
 	"github.com/777genius/claude-notifications/internal/summary"
 	"github.com/777genius/claude-notifications/internal/webhook"
 )
 
 // HookData represents the data received from Claude Code hooks
-type HookData struct {
-	TranscriptPath string `json:"transcript_path"`
-	SessionID      string `json:"session_id"`
-	CWD            string `json:"cwd"`
-	ToolName       string `json:"tool_name,omitempty"`
-	HookEventName  string `json:"hook_event_name,omitempty"`
-}
+type HookData = hookevent.Event
 
 // notifierInterface defines the interface for sending desktop notifications
 type notifierInterface interface {
-	SendDesktop(status analyzer.Status, message, sessionID, cwd string) error
+	SendDesktopWithOptions(status analyzer.Status, message, sessionID, cwd string, opts notifier.Options) error
+	DismissSession(sessionID string) error
+	Acknowledged(sessionID string) time.Time
 	Close() error
 }
 
 // webhookInterface defines the interface for sending webhook notifications
 type webhookInterface interface {
-	SendAsync(status analyzer.Status, message, sessionID string)
+	SendAsyncWithResult(status analyzer.Status, message, sessionID string, meta webhook.Meta, done func(err error))
+	Shutdown(timeout time.Duration) error
+}
+
+// emailInterface defines the interface for sending email notifications
+type emailInterface interface {
+	SendAsyncWithResult(status analyzer.Status, message, sessionID string, meta webhook.Meta, done func(err error))
+	Shutdown(timeout time.Duration) error
+}
+
+// mqttInterface defines the interface for publishing notifications over MQTT
+type mqttInterface interface {
+	PublishAsyncWithResult(status analyzer.Status, message, sessionID string, meta webhook.Meta, done func(err error))
 	Shutdown(timeout time.Duration) error
 }
 
+// speechInterface defines the interface for speaking notifications
+type speechInterface interface {
+	SpeakAsyncWithResult(data config.ContentData, done func(err error))
+	Shutdown(timeout time.Duration) error
+}
+
+// Presence detection; replaced in tests
+var (
+	idleTime        = idle.Time
+	terminalFocused = daemon.TerminalFocused
+)
+
+// Escalation and prompt workers; replaced in tests
+var (
+	spawnEscalation = escalation.Spawn
+	spawnWatcher    = func(sessionID, id string) error { return escalation.SpawnWorker("watch", sessionID, id) }
+	sleep           = time.Sleep
+)
+
+// Daemon session map; replaced in tests
+var (
+	trackSession   = notifier.TrackSession
+	untrackSession = notifier.UntrackSession
+)
+
+// escalationPollInterval is how often a waiting escalation asks whether
+// its notification was acknowledged. Each query also keeps the Linux
+// daemon, which answers it, from exiting idle.
+const escalationPollInterval = 30 * time.Second
+
+// watchMaxQuiet is how long a session may show no activity before its
+// prompt watcher stops: it most likely died
+const watchMaxQuiet = time.Hour
+
 // Handler handles hook events
 type Handler struct {
 	cfg         *config.Config
@@ -50,7 +113,127 @@ type Handler struct {
 	stateMgr    *state.Manager
 	notifierSvc notifierInterface
 	webhookSvc  webhookInterface
-	pluginRoot  string
+	emailSvc    emailInterface
+	speechSvc   speechInterface
+	mqttSvc     mqttInterface
+	extraHooks  []extraWebhook    // Enabled entries of notifications.webhooks
+	plugins     []plugin.Notifier // Executables of the plugins directory; empty = plugins disabled
+	pluginHost  *plugin.Host
+	webhookQ    *webhook.Queue   // Failed webhook deliveries retried later; nil = no webhooks
+	webhookQWG  sync.WaitGroup   // Retry pass over webhookQ in progress
+	dndMgr      *dnd.Manager     // nil = do-not-disturb disabled
+	history     *history.Store   // nil = history disabled
+	breakers    *breaker.Store   // nil = circuit breakers disabled
+	redactor    *redact.Redactor // Scrubs notifications leaving the machine; nil = redaction disabled
+	script      *script.Script   // Runs after the rules; nil = no script
+	sessionReg  *sessions.Registry
+	notified    *sessions.Marks   // Last notification per session; nil = directory unknown
+	escalations *escalation.Store // nil = directory unknown
+	digestQ     *digest.Queue     // nil = directory unknown
+	budgets     *budget.Store     // nil = directory unknown
+	ruleHits    *rules.HitStore   // nil = directory unknown
+	// onDelivery receives delivery results instead of the history and
+	// metrics while a test notification is sent (nil = record them)
+	onDelivery func(backend string, d time.Duration, err error)
+	trace      io.Writer // Decisions taken on a hook (nil = off)
+	dryRun     bool      // Decide what to send without sending or writing state
+	pluginRoot string
+}
+
+// extraWebhook is an additional webhook backend with its own route
+type extraWebhook struct {
+	name    string
+	route   config.RouteConfig
+	content config.ContentConfig
+	privacy string
+	key     string // Encryption key ("" = sent as is)
+	svc     webhookInterface
+}
+
+// newExtraWebhooks creates senders for the enabled additional webhooks
+func newExtraWebhooks(cfg *config.Config, queue *webhook.Queue) []extraWebhook {
+	var hooks []extraWebhook
+	for i, webhookCfg := range cfg.Notifications.Webhooks {
+		if !webhookCfg.Enabled {
+			continue
+		}
+		hooks = append(hooks, extraWebhook{
+			name:    cfg.ExtraWebhookName(i),
+			route:   webhookCfg.Route,
+			content: webhookCfg.Content,
+			privacy: webhookCfg.Privacy,
+			key:     webhookCfg.Encrypt.Key,
+			svc:     newWebhookSender(cfg, webhookCfg, queue),
+		})
+	}
+	return hooks
+}
+
+// newPlugins finds the plugins to notify, if plugins are enabled
+func newPlugins(cfg *config.Config) []plugin.Notifier {
+	if !cfg.ArePluginsEnabled() {
+		return nil
+	}
+	found, err := plugin.Discover(cfg)
+	if err != nil {
+		logging.Warn("Plugins: %v", err)
+	}
+	notifiers := make([]plugin.Notifier, len(found))
+	for i, p := range found {
+		notifiers[i] = p
+	}
+	return notifiers
+}
+
+// newWebhookSender creates a sender that queues deliveries failing with a
+// temporary error, unless the webhook's retry.queue is off
+func newWebhookSender(cfg *config.Config, webhookCfg config.WebhookConfig, queue *webhook.Queue) *webhook.Sender {
+	sender := webhook.NewForWebhook(cfg, webhookCfg)
+	if queue != nil && webhookCfg.Retry.IsQueueEnabled() {
+		sender.SetQueue(queue)
+	}
+	return sender
+}
+
+// newWebhookQueue creates the retry queue for failed webhook deliveries in
+// the stable config directory; nil when no webhook is enabled
+func newWebhookQueue(cfg *config.Config) *webhook.Queue {
+	enabled := cfg.IsWebhookEnabled()
+	for _, webhookCfg := range cfg.Notifications.Webhooks {
+		enabled = enabled || webhookCfg.Enabled
+	}
+	if !enabled {
+		return nil
+	}
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		logging.Warn("Webhook retry queue unavailable: %v", err)
+		return nil
+	}
+	return webhook.NewQueue(dir)
+}
+
+// retryQueuedWebhooks sends queued webhook deliveries that are due, in the
+// background; closeServices waits for it
+func (h *Handler) retryQueuedWebhooks() {
+	if h.webhookQ == nil || h.dryRun {
+		return
+	}
+	queue := h.webhookQ
+	h.webhookQWG.Add(1)
+	errorhandler.SafeGo(func() {
+		defer h.webhookQWG.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		result, err := queue.Retry(ctx, time.Now())
+		if err != nil {
+			logging.Warn("Webhook retry queue: %v", err)
+		}
+		if result.Delivered > 0 || result.Dropped > 0 {
+			logging.Info("Webhook retry queue: %d delivered, %d dropped, %d pending",
+				result.Delivered, result.Dropped, result.Pending)
+		}
+	})
 }
 
 // NewHandler creates a new hook handler
@@ -66,14 +249,168 @@ func NewHandler(pluginRoot string) (*Handler, error) {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 
-	return &Handler{
-		cfg:         cfg,
-		dedupMgr:    dedup.NewManager(),
-		stateMgr:    state.NewManager(),
-		notifierSvc: notifier.New(cfg),
-		webhookSvc:  webhook.New(cfg),
-		pluginRoot:  pluginRoot,
-	}, nil
+	h := &Handler{
+		dedupMgr:   dedup.NewManager(),
+		stateMgr:   state.NewManager(),
+		pluginRoot: pluginRoot,
+	}
+	// Session state lives next to the config file; without the directory
+	// hooks still notify but keep nothing from one hook to the next
+	if dir, err := config.GetStableConfigDir(); err != nil {
+		logging.Warn("Session tracking, escalation, digests, budget alerts and rule hits unavailable: %v", err)
+	} else {
+		h.sessionReg = sessions.NewRegistry(dir)
+		h.notified = sessions.NewNotified(dir)
+		h.escalations = escalation.NewStore(dir)
+		h.digestQ = digest.NewQueue(dir)
+		h.budgets = budget.NewStore(dir)
+		h.ruleHits = rules.NewHitStore(dir)
+	}
+	h.setConfig(cfg)
+	return h, nil
+}
+
+// setConfig switches the handler to cfg and creates the senders, DND
+// manager and history store it configures, reading the secrets of the
+// enabled backends first
+func (h *Handler) setConfig(cfg *config.Config) {
+	logging.Configure(cfg.Logging.Options())
+	for _, err := range cfg.ResolveSecrets(notifier.ResolveSecret) {
+		logging.Warn("Config: %v", err)
+	}
+	daemon.SetTerminals(cfg.Notifications.Desktop.Terminals)
+	h.cfg = cfg
+	h.notifierSvc = notifier.New(cfg)
+	h.webhookQ = newWebhookQueue(cfg)
+	h.webhookSvc = newWebhookSender(cfg, cfg.Notifications.Webhook, h.webhookQ)
+	h.emailSvc = email.New(cfg)
+	h.speechSvc = speech.New(cfg)
+	h.mqttSvc = mqtt.New(cfg)
+	h.extraHooks = newExtraWebhooks(cfg, h.webhookQ)
+	h.plugins = newPlugins(cfg)
+	h.pluginHost = &plugin.Host{}
+	h.dndMgr = newDNDManager(cfg)
+	h.history = newHistoryStore(cfg)
+	h.breakers = newBreakerStore(cfg)
+	h.redactor = newRedactor(cfg)
+	h.script = newScript(cfg)
+}
+
+// applyProjectConfig switches to the configuration of the project in cwd
+// when it has a .claude-notifications.toml. An invalid project config is
+// logged and ignored.
+func (h *Handler) applyProjectConfig(cwd string) {
+	path := config.FindProjectConfig(cwd)
+	if path == "" {
+		return
+	}
+
+	cfg, warnings := config.LoadForProject(h.pluginRoot, cwd)
+	for _, w := range warnings {
+		logging.Warn("Config: %v", w)
+	}
+	if err := cfg.Validate(); err != nil {
+		logging.Warn("Ignoring project config %s: %v", path, err)
+		return
+	}
+
+	h.tracef("Using project config %s", path)
+	h.closeServices()
+	h.setConfig(cfg)
+}
+
+// closeServices releases notifier resources and waits for webhook, email
+// and MQTT senders to finish in-flight requests, and for announcements to
+// finish
+func (h *Handler) closeServices() {
+	if h.cfg.IsEmailEnabled() {
+		if err := h.emailSvc.Shutdown(30 * time.Second); err != nil {
+			logging.Warn("Failed to shutdown email sender: %v", err)
+		}
+	}
+	if h.cfg.IsSpeechEnabled() {
+		if err := h.speechSvc.Shutdown(30 * time.Second); err != nil {
+			logging.Warn("Failed to shutdown speech: %v", err)
+		}
+	}
+	if h.cfg.IsMQTTEnabled() {
+		if err := h.mqttSvc.Shutdown(15 * time.Second); err != nil {
+			logging.Warn("Failed to shutdown MQTT publisher: %v", err)
+		}
+	}
+	if len(h.plugins) > 0 {
+		if err := h.pluginHost.Shutdown(h.cfg.PluginTimeout() + 5*time.Second); err != nil {
+			logging.Warn("Failed to shutdown plugins: %v", err)
+		}
+	}
+
+	h.webhookQWG.Wait()
+	if err := h.webhookSvc.Shutdown(5 * time.Second); err != nil {
+		logging.Warn("Failed to shutdown webhook sender: %v", err)
+	}
+	for _, extra := range h.extraHooks {
+		if err := extra.svc.Shutdown(5 * time.Second); err != nil {
+			logging.Warn("Failed to shutdown %s sender: %v", extra.name, err)
+		}
+	}
+
+	if err := h.notifierSvc.Close(); err != nil {
+		logging.Warn("Failed to close notifier: %v", err)
+	}
+}
+
+// newDNDManager creates the do-not-disturb manager, which keeps its state
+// in the stable config directory
+func newDNDManager(cfg *config.Config) *dnd.Manager {
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		logging.Warn("Do-not-disturb unavailable: %v", err)
+		return nil
+	}
+	return dnd.NewManager(dir, cfg.Notifications.DND)
+}
+
+// newHistoryStore creates the delivery history store, which lives next to the
+// config file; nil when history is disabled or the directory is unknown
+func newHistoryStore(cfg *config.Config) *history.Store {
+	if !cfg.IsHistoryEnabled() {
+		return nil
+	}
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		logging.Warn("Notification history unavailable: %v", err)
+		return nil
+	}
+	return history.NewStore(dir, cfg.Notifications.History.MaxEntries)
+}
+
+// newBreakerStore creates the circuit breakers of the network backends,
+// which live next to the config file; nil when disabled or the directory
+// is unknown
+func newBreakerStore(cfg *config.Config) *breaker.Store {
+	if !cfg.IsBreakerEnabled() {
+		return nil
+	}
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		logging.Warn("Circuit breakers unavailable: %v", err)
+		return nil
+	}
+	return breaker.NewStore(dir, cfg.Notifications.Breaker.Settings())
+}
+
+// newRedactor creates the redactor of notifications sent off the machine;
+// nil when redaction is disabled
+func newRedactor(cfg *config.Config) *redact.Redactor {
+	if !cfg.IsRedactEnabled() {
+		return nil
+	}
+	r, err := redact.New(cfg.Notifications.Redact.Options())
+	if err != nil {
+		logging.Warn("Redaction unavailable: %v", err)
+		return nil
+	}
+	return r
 }
 
 // HandleHook handles a hook event
@@ -89,31 +426,26 @@ func (h *Handler) HandleHook(hookEvent string, input io.Reader) error {
 		return nil
 	}
 
-	// Ensure notifier resources are cleaned up when function exits
-	defer func() {
-		if err := h.notifierSvc.Close(); err != nil {
-			logging.Warn("Failed to close notifier: %v", err)
-		}
-	}()
-
-	// Ensure webhook sender waits for in-flight requests before exit
-	defer func() {
-		if err := h.webhookSvc.Shutdown(5 * time.Second); err != nil {
-			logging.Warn("Failed to shutdown webhook sender: %v", err)
-		}
-	}()
+	// Ensure notifier resources are cleaned up and senders finish in-flight
+	// requests before exit
+	defer h.closeServices()
 
 	logging.SetPrefix(fmt.Sprintf("PID:%d", os.Getpid()))
 	logging.Debug("=== Hook triggered: %s ===", hookEvent)
 
 	// Parse hook data
-	var hookData HookData
-	if err := json.NewDecoder(input).Decode(&hookData); err != nil {
+	hookData, err := hookevent.Parse(input, hookEvent)
+	if err != nil {
 		return fmt.Errorf("failed to parse hook data: %w", err)
 	}
 
-	logging.Debug("Hook data: session=%s, transcript=%s, tool=%s",
-		hookData.SessionID, hookData.TranscriptPath, hookData.ToolName)
+	logging.Debug("Hook data: session=%s, transcript=%s, tool=%s, message=%q",
+		hookData.SessionID, hookData.TranscriptPath, hookData.ToolName, hookData.Message)
+	h.tracePayload(&hookData)
+	if hookData.IsNewer() {
+		logging.Debug("Hook payload schema %d is newer than supported (%d); unknown fields: %d",
+			hookData.SchemaVersion, hookevent.SchemaCurrent, len(hookData.Extra))
+	}
 
 	// Validate session ID
 	if hookData.SessionID == "" {
@@ -121,25 +453,65 @@ func (h *Handler) HandleHook(hookEvent string, input io.Reader) error {
 		logging.Warn("Session ID is empty, using 'unknown'")
 	}
 
+	h.applyProjectConfig(hookData.CWD)
+	h.retryQueuedWebhooks()
+
+	// Any later event means the user is back at the session, or it ended
+	if hookEvent != "SessionStart" {
+		h.dismissEarlier(hookData.SessionID)
+	}
+	// ...and needs no escalation, unless Claude only reminds them it waits
+	if hookEvent != "SessionStart" && hookEvent != "Notification" {
+		h.cancelEscalation(hookData.SessionID)
+	}
+
+	// Session lifecycle hooks only update the session registry
+	switch hookEvent {
+	case "SessionStart":
+		h.startSession(&hookData)
+		return nil
+	case "SessionEnd":
+		h.endSession(&hookData)
+		return nil
+	case "UserPromptSubmit":
+		// Only registered to dismiss notifications when the user replies
+		// and to start the watcher of the prompt
+		h.promptSession(&hookData)
+		return nil
+	}
+	h.touchSession(hookData.SessionID)
+	if hookEvent == "Stop" && !isSubagentTranscript(hookData.TranscriptPath) {
+		h.finishPrompt(hookData.SessionID)
+	}
+
+	// Progress counts every tool call, even one right after a notification
+	if hookEvent == "PostToolUse" {
+		h.updateProgress(&hookData)
+	}
+
 	// Phase 1: Early duplicate check (per hook event type)
 	if h.dedupMgr.CheckEarlyDuplicate(hookData.SessionID, hookEvent) {
-		logging.Debug("Early duplicate detected, skipping")
+		h.tracef("Early duplicate detected, skipping")
 		return nil
 	}
 
 	// Check if any notification method is enabled
 	if !h.cfg.IsAnyNotificationEnabled() {
-		logging.Debug("All notifications disabled, exiting")
+		h.tracef("All notifications disabled, exiting")
 		return nil
 	}
 
+	// Any hook may be the first to see the session over its budget
+	h.checkBudget(hookEvent, &hookData)
+
 	// Determine status based on hook type
 	var status analyzer.Status
-	var err error
 
 	switch hookEvent {
 	case "PreToolUse":
 		status = h.handlePreToolUse(&hookData)
+	case "PostToolUse":
+		status = h.handlePostToolUse(&hookData)
 	case "Notification":
 		// Check session state first (60s TTL) to suppress duplicates after PreToolUse
 		status, err = h.handleNotificationEvent(&hookData)
@@ -149,7 +521,7 @@ func (h *Handler) HandleHook(hookEvent string, input io.Reader) error {
 	case "Stop":
 		// Check if this is a subagent transcript and should be suppressed
 		if h.cfg.ShouldSuppressForSubagents() && isSubagentTranscript(hookData.TranscriptPath) {
-			logging.Debug("Stop: subagent transcript detected (%s), suppressing (config: suppressForSubagents)", hookData.TranscriptPath)
+			h.tracef("Stop: subagent transcript detected (%s), suppressing (config: suppressForSubagents)", hookData.TranscriptPath)
 			return nil
 		}
 		// Analyze the transcript to determine status
@@ -164,12 +536,12 @@ func (h *Handler) HandleHook(hookEvent string, input io.Reader) error {
 		// Check config: should we suppress subagent notifications?
 		// First check path-based suppression (covers subagents and teammates)
 		if h.cfg.ShouldSuppressForSubagents() && isSubagentTranscript(hookData.TranscriptPath) {
-			logging.Debug("SubagentStop: subagent transcript detected (%s), suppressing (config: suppressForSubagents)", hookData.TranscriptPath)
+			h.tracef("SubagentStop: subagent transcript detected (%s), suppressing (config: suppressForSubagents)", hookData.TranscriptPath)
 			return nil
 		}
 		// Then check the legacy notifyOnSubagentStop flag
 		if !h.cfg.Notifications.NotifyOnSubagentStop {
-			logging.Debug("SubagentStop: notifications disabled (config: notifyOnSubagentStop), skipping")
+			h.tracef("SubagentStop: notifications disabled (config: notifyOnSubagentStop), skipping")
 			return nil
 		}
 		// If enabled, handle like Stop
@@ -185,32 +557,35 @@ func (h *Handler) HandleHook(hookEvent string, input io.Reader) error {
 
 	// If status is unknown, skip
 	if status == analyzer.StatusUnknown {
-		logging.Debug("Status is unknown, skipping notification")
+		h.tracef("Status is unknown, skipping notification")
 		return nil
 	}
+	h.tracef("Status: %s", status)
 
 	// Check suppress-filters before any state mutations (dedup lock, cooldowns)
 	{
 		gitBranch := platform.GetGitBranch(hookData.CWD)
 		folderName := filepath.Base(hookData.CWD)
 		if h.cfg.ShouldFilter(string(status), gitBranch, folderName) {
-			logging.Debug("Notification suppressed by filter: status=%s branch=%q folder=%s", status, gitBranch, folderName)
+			h.tracef("Notification suppressed by filter: status=%s branch=%q folder=%s", status, gitBranch, folderName)
 			return nil
 		}
 	}
 
 	// Phase 2: Acquire lock before sending (per hook event type)
-	acquired, err := h.dedupMgr.AcquireLock(hookData.SessionID, hookEvent)
-	if err != nil {
-		return fmt.Errorf("failed to acquire lock: %w", err)
-	}
-	if !acquired {
-		logging.Debug("Failed to acquire lock (duplicate), skipping")
-		return nil
-	}
+	if !h.dryRun {
+		acquired, err := h.dedupMgr.AcquireLock(hookData.SessionID, hookEvent)
+		if err != nil {
+			return fmt.Errorf("failed to acquire lock: %w", err)
+		}
+		if !acquired {
+			logging.Debug("Failed to acquire lock (duplicate), skipping")
+			return nil
+		}
 
-	logging.Debug("Lock acquired, proceeding with notification")
-	// Note: Lock is NOT released - it ages out naturally after 2s to prevent rapid duplicates
+		logging.Debug("Lock acquired, proceeding with notification")
+		// Note: Lock is NOT released - it ages out naturally after 2s to prevent rapid duplicates
+	}
 
 	// Check cooldown for question status BEFORE updating notification time
 	if status == analyzer.StatusQuestion {
@@ -235,7 +610,7 @@ func (h *Handler) HandleHook(hookEvent string, input io.Reader) error {
 		if err != nil {
 			logging.Warn("Failed to check cooldown after any notification: %v", err)
 		} else if suppressAfterAny {
-			logging.Debug("Question suppressed due to recent notification from this session")
+			h.tracef("Question suppressed due to recent notification from this session")
 			// Lock will be released by defer
 			return nil
 		} else {
@@ -250,14 +625,14 @@ func (h *Handler) HandleHook(hookEvent string, input io.Reader) error {
 		if err != nil {
 			logging.Warn("Failed to check cooldown: %v", err)
 		} else if suppress {
-			logging.Debug("Question suppressed due to cooldown after task complete")
+			h.tracef("Question suppressed due to cooldown after task complete")
 			// Lock will be released by defer
 			return nil
 		}
 	}
 
 	// Update state (only for task_complete, PreToolUse already updated state)
-	if status == analyzer.StatusTaskComplete {
+	if status == analyzer.StatusTaskComplete && !h.dryRun {
 		if err := h.stateMgr.UpdateTaskComplete(hookData.SessionID); err != nil {
 			logging.Warn("Failed to update task complete state: %v", err)
 		}
@@ -265,17 +640,21 @@ func (h *Handler) HandleHook(hookEvent string, input io.Reader) error {
 
 	// Generate message
 	message := h.generateMessage(&hookData, status)
+	h.tracef("Message: %q", message)
 
 	// Acquire content lock to prevent race between different hooks (Stop vs Notification)
 	// This ensures only one process can check and update duplicate state at a time
-	contentLockAcquired, err := h.dedupMgr.AcquireContentLock(hookData.SessionID)
-	if err != nil {
-		logging.Warn("Failed to acquire content lock: %v", err)
-		// Error (not "lock busy") - continue without lock as fallback
-	} else if !contentLockAcquired {
-		// Lock is held by another process - it's already handling this notification
-		logging.Debug("Content lock held by another process, skipping to prevent duplicate")
-		return nil
+	contentLockAcquired := false
+	if !h.dryRun {
+		contentLockAcquired, err = h.dedupMgr.AcquireContentLock(hookData.SessionID)
+		if err != nil {
+			logging.Warn("Failed to acquire content lock: %v", err)
+			// Error (not "lock busy") - continue without lock as fallback
+		} else if !contentLockAcquired {
+			// Lock is held by another process - it's already handling this notification
+			logging.Debug("Content lock held by another process, skipping to prevent duplicate")
+			return nil
+		}
 	}
 
 	// Release lock on exit if acquired
@@ -292,31 +671,71 @@ func (h *Handler) HandleHook(hookEvent string, input io.Reader) error {
 	if err != nil {
 		logging.Warn("Failed to check duplicate message: %v", err)
 	} else if isDuplicate {
-		logging.Debug("Duplicate message content detected within 3 minutes, skipping")
+		h.tracef("Duplicate message content detected within 3 minutes, skipping")
 		return nil
 	}
 
 	// Update last notification time and message
-	if err := h.stateMgr.UpdateLastNotification(hookData.SessionID, status, message); err != nil {
-		logging.Warn("Failed to update last notification: %v", err)
+	if !h.dryRun {
+		if err := h.stateMgr.UpdateLastNotification(hookData.SessionID, status, message); err != nil {
+			logging.Warn("Failed to update last notification: %v", err)
+		}
 	}
 
 	// Send notifications
-	h.sendNotifications(status, message, hookData.SessionID, hookData.CWD)
+	hook := hookInfo{event: hookEvent, toolName: hookData.ToolName, permissionPrompt: hookData.IsPermissionPrompt()}
+	h.sendNotifications(hook, status, message, hookData.SessionID, hookData.CWD, hookData.TranscriptPath)
 
 	logging.Debug("=== Hook completed: %s ===", hookEvent)
 	return nil
 }
 
+// checkBudget adds the usage appended to the session's transcript since
+// the last hook and sends budget_exceeded for each budget it went over.
+// Subagent transcripts are skipped: their usage is not the session's.
+func (h *Handler) checkBudget(hookEvent string, hookData *HookData) {
+	limits := h.cfg.Notifications.Budget
+	if !limits.Enabled() || h.budgets == nil || hookData.TranscriptPath == "" || isSubagentTranscript(hookData.TranscriptPath) {
+		return
+	}
+	usage, err := h.budgets.Load(hookData.SessionID)
+	if err != nil {
+		logging.Warn("Budget not checked: %v", err)
+		return
+	}
+	if err := usage.Read(hookData.TranscriptPath); err != nil {
+		logging.Debug("Budget not checked: %v", err)
+		return
+	}
+	exceeded := usage.Exceeded(budget.Limits{Tokens: limits.Tokens, CostUSD: limits.Cost})
+	h.tracef("Budget: %d tokens, $%.2f used", usage.Tokens, usage.CostUSD)
+
+	// Save before sending, so a hook running meanwhile does not alert again
+	if !h.dryRun {
+		if err := h.budgets.Save(hookData.SessionID, usage); err != nil {
+			logging.Warn("Failed to save budget usage: %v", err)
+			return
+		}
+	}
+	for _, e := range exceeded {
+		h.tracef("Budget exceeded: %s", e.Message())
+		h.sendNotifications(hookInfo{event: hookEvent}, analyzer.StatusBudgetExceeded, e.Message(),
+			hookData.SessionID, hookData.CWD, hookData.TranscriptPath)
+	}
+}
+
 // handlePreToolUse handles PreToolUse hook
 func (h *Handler) handlePreToolUse(hookData *HookData) analyzer.Status {
 	logging.Debug("PreToolUse: tool_name='%s'", hookData.ToolName)
 
 	status := analyzer.GetStatusForPreToolUse(hookData.ToolName)
+	if status == analyzer.StatusUnknown && h.cfg.Notifications.Tools.ShouldNotify(hookData.ToolName, "PreToolUse") {
+		return analyzer.StatusToolUse
+	}
 
 	// Write session state BEFORE returning (prevents race with Notification hook)
 	// This matches bash version behavior: state is written BEFORE notification is sent
-	if status == analyzer.StatusPlanReady || status == analyzer.StatusQuestion {
+	if (status == analyzer.StatusPlanReady || status == analyzer.StatusQuestion) && !h.dryRun {
 		if err := h.stateMgr.UpdateInteractiveTool(hookData.SessionID, hookData.ToolName, hookData.CWD); err != nil {
 			logging.Warn("Failed to update interactive tool state: %v", err)
 		} else {
@@ -327,6 +746,16 @@ func (h *Handler) handlePreToolUse(hookData *HookData) analyzer.Status {
 	return status
 }
 
+// handlePostToolUse handles PostToolUse hook: tools listed in
+// notifications.tools announce that they finished
+func (h *Handler) handlePostToolUse(hookData *HookData) analyzer.Status {
+	logging.Debug("PostToolUse: tool_name='%s'", hookData.ToolName)
+	if h.cfg.Notifications.Tools.ShouldNotify(hookData.ToolName, "PostToolUse") {
+		return analyzer.StatusToolUse
+	}
+	return analyzer.StatusUnknown
+}
+
 // handleNotificationEvent handles Notification hook
 // Always returns StatusQuestion as per design: Notification hook is triggered
 // when Claude needs user input (e.g., permission dialogs, questions)
@@ -359,6 +788,18 @@ func (h *Handler) handleStopEvent(hookData *HookData) (analyzer.Status, error) {
 
 // generateMessage generates a notification message
 func (h *Handler) generateMessage(hookData *HookData, status analyzer.Status) string {
+	if status == analyzer.StatusToolUse {
+		in, err := hookData.Tool()
+		if err != nil {
+			logging.Debug("Tool input not summarized: %v", err)
+		}
+		after := hookData.HookEventName == "PostToolUse"
+		return summary.GenerateToolUse(hookData.ToolName, in.Argument(), after, h.cfg.Notifications.Tools.GetArgLength())
+	}
+	// The hook's own message says what Claude waits for; the transcript says why
+	if ts := h.cfg.Notifications.TranscriptSummary; ts.Enabled && hookData.HookEventName == "Notification" && hookData.Message != "" {
+		return summary.WithTranscriptSummary(hookData.Message, hookData.TranscriptPath, ts.GetLength())
+	}
 	if hookData.TranscriptPath != "" && platform.FileExists(hookData.TranscriptPath) {
 		msg := summary.GenerateFromTranscript(hookData.TranscriptPath, status, h.cfg)
 		if msg != "" {
@@ -369,43 +810,899 @@ func (h *Handler) generateMessage(hookData *HookData, status analyzer.Status) st
 	return summary.GenerateSimple(status, h.cfg)
 }
 
+// hookInfo describes the hook event a notification comes from
+type hookInfo struct {
+	event            string // e.g. "Stop"
+	toolName         string // Tool of PreToolUse/PostToolUse events
+	permissionPrompt bool   // Notification asking to approve a tool
+}
+
+// labelMessage prefixes a message with its session, git branch and folder:
+// "[sessionname|branch folder] message" or "[sessionname folder] message".
+// Below the top of a repository the folder is shown as "repo/folder".
+func labelMessage(sessionName string, git platform.GitContext, cwd, message string) string {
+	folderName := filepath.Base(cwd)
+	label := folderName
+	if git.Repo != "" && git.Root != filepath.Clean(cwd) {
+		label = git.Repo + "/" + folderName
+	}
+	if git.Branch != "" {
+		return fmt.Sprintf("[%s|%s %s] %s", sessionName, git.Branch, label, message)
+	}
+	return fmt.Sprintf("[%s %s] %s", sessionName, label, message)
+}
+
 // sendNotifications sends desktop and webhook notifications
-func (h *Handler) sendNotifications(status analyzer.Status, message, sessionID, cwd string) {
+func (h *Handler) sendNotifications(hook hookInfo, status analyzer.Status, message, sessionID, cwd, transcriptPath string) {
 	// Add panic recovery to prevent notification failures from crashing the plugin
 	defer errorhandler.HandlePanic()
 
 	// Add session name, git branch and folder name to message
 	sessionName := sessionname.GenerateSessionLabel(sessionID)
-	gitBranch := platform.GetGitBranch(cwd)
+	git := platform.GetGitContext(cwd)
+	gitBranch := git.Branch
 	folderName := filepath.Base(cwd)
 
-	// Format: "[sessionname|branch folder] message" or "[sessionname folder] message"
-	var enhancedMessage string
-	if gitBranch != "" {
-		enhancedMessage = fmt.Sprintf("[%s|%s %s] %s", sessionName, gitBranch, folderName, message)
-	} else {
-		enhancedMessage = fmt.Sprintf("[%s %s] %s", sessionName, folderName, message)
-	}
+	enhancedMessage := labelMessage(sessionName, git, cwd, message)
 
 	logging.Debug("Session name: %s, git branch: %s, folder: %s", sessionName, gitBranch, folderName)
 
 	statusStr := string(status)
+	if !h.cfg.IsStatusEnabled(statusStr) {
+		h.tracef("Notifications disabled for status: %s", statusStr)
+		return
+	}
+	if h.dndMgr != nil && h.dndMgr.ProjectMuted(folderName, time.Now()) {
+		h.tracef("Notifications muted for project: %s", folderName)
+		return
+	}
+
+	engine, err := rules.New(h.cfg.Notifications.Rules)
+	if err != nil {
+		logging.Warn("Ignoring invalid notification rules: %v", err)
+		engine, _ = rules.New(nil)
+	}
+
+	ev := notifier.Event{
+		Status:    status,
+		Message:   enhancedMessage,
+		SessionID: sessionID,
+		CWD:       cwd,
+		Project:   folderName,
+		Running:   h.sessionDuration(sessionID),
+	}
+	// Elapsed time feeds webhook/email details and rule conditions; only
+	// read the transcript when something needs it
+	if transcriptPath != "" && (h.needsElapsed() || engine.UsesElapsed()) {
+		ev.Elapsed = summary.ElapsedFromTranscript(transcriptPath)
+	}
 
-	// Send desktop notification (check per-status enabled)
-	if h.cfg.IsStatusDesktopEnabled(statusStr) {
-		if err := h.notifierSvc.SendDesktop(status, enhancedMessage, sessionID, cwd); err != nil {
-			errorhandler.HandleError(err, "Failed to send desktop notification")
+	// Time since the last input feeds presence detection and minIdle routes
+	if h.cfg.UsesIdle() {
+		if d, err := idleTime(); err == nil {
+			ev.Idle = max(d, time.Millisecond) // 0 means unknown
+		} else {
+			logging.Debug("Idle time unknown: %v", err)
 		}
-	} else {
-		logging.Debug("Desktop notification disabled for status: %s", statusStr)
 	}
 
-	// Send webhook notification (async, check per-status enabled)
-	if h.cfg.IsStatusWebhookEnabled(statusStr) {
-		h.webhookSvc.SendAsync(status, enhancedMessage, sessionID)
+	// Apply rules: suppress, or override title, sound, urgency and backends
+	statusInfo, _ := h.cfg.GetStatusInfo(statusStr)
+	result := engine.Evaluate(rules.Event{
+		HookEvent:   hook.event,
+		Status:      statusStr,
+		Title:       statusInfo.Title,
+		ProjectPath: cwd,
+		Message:     message,
+		Elapsed:     ev.Elapsed,
+		Time:        time.Now(),
+	})
+	h.tracef("Rules matched: %s", traceList(result.Matched))
+	if h.ruleHits != nil && !h.dryRun {
+		if err := h.ruleHits.Record(result.Matched, statusStr, folderName, time.Now()); err != nil {
+			logging.Warn("Failed to record rule hits: %v", err)
+		}
+	}
+	if result.Suppress {
+		h.tracef("Notification suppressed by rule: status=%s", statusStr)
+		return
+	}
+	if result.Title != "" || result.Sound != "" || result.Urgency != "" || len(result.Backends) > 0 {
+		h.tracef("Rules set title=%q sound=%q urgency=%q backends=%s",
+			result.Title, result.Sound, result.Urgency, traceList(result.Backends))
+	}
+	ev.Title = result.Title
+	ev.Sound = result.Sound
+	// Permission prompts escalate to the critical sound unless a rule chose one
+	if ev.Sound == "" && hook.permissionPrompt {
+		ev.Sound = h.cfg.Notifications.Desktop.CriticalSound
+	}
+	ev.Priority = result.Urgency
+	ev.Backends = result.Backends
+
+	// Completion notifications say how long the session has been running
+	body := message
+	if status == analyzer.StatusTaskComplete {
+		if ev.Running > 0 {
+			suffix := " · session ran for " + sessions.FormatDuration(ev.Running)
+			ev.Message += suffix
+			body += suffix
+		}
+	}
+
+	// Fields for the title and body templates of notifications.content
+	ev.Content = &config.ContentData{
+		Title:     statusInfo.Title,
+		Message:   body,
+		Status:    statusStr,
+		Event:     hook.event,
+		Project:   folderName,
+		Repo:      git.Repo,
+		Branch:    gitBranch,
+		Session:   sessionName,
+		SessionID: sessionID,
+		ToolName:  hook.toolName,
+	}
+	if ev.Title != "" {
+		ev.Content.Title = ev.Title
+	}
+	if ev.Elapsed > 0 {
+		ev.Content.Elapsed = ev.Elapsed.Round(time.Second).String()
+	}
+
+	// The user's script rewrites or suppresses what the rules left
+	if h.script != nil {
+		before := ev.Content.Message
+		if !h.applyScript(&ev) {
+			h.tracef("Notification suppressed by script: status=%s", statusStr)
+			return
+		}
+		if ev.Content.Message != before {
+			message = ev.Content.Message
+		}
+	}
+
+	// The user is typing in the session's terminal and sees it already
+	if h.cfg.Notifications.Presence.Enabled && h.atTerminal(sessionID, ev.Idle) {
+		if h.cfg.Notifications.Presence.WhenActive == "suppress" {
+			h.tracef("User is at the terminal: notification suppressed")
+			return
+		}
+		h.tracef("User is at the terminal: sending silently with low priority")
+		ev.Priority = priority.Low
+		ev.Sound = "none"
+	}
+
+	// The session now waits for the user, whether the notification is sent,
+	// held back or batched: for focus-pending, status bars and prompts
+	if h.notified != nil && sessionID != "" && !h.dryRun {
+		if err := h.notified.Mark(sessionID, time.Now()); err != nil {
+			logging.Debug("Failed to mark session notified: %v", err)
+		}
+	}
+
+	dispatcher := h.newDispatcher()
+
+	// Do-not-disturb: hold back or downgrade while active, and deliver the
+	// digest of held notifications with the first notification after it ends
+	if h.dndMgr != nil {
+		if dndStatus := h.dndMgr.Status(time.Now()); dndStatus.Active {
+			if h.cfg.Notifications.DND.Mode == "downgrade" {
+				h.tracef("Do-not-disturb active (%s): sending silently with low priority", dndStatus.Reason)
+				ev.Priority = priority.Low
+				ev.Sound = "none"
+			} else {
+				h.queueForDND(ev, statusInfo.Title, message)
+				h.tracef("Do-not-disturb active (%s): notification held back", dndStatus.Reason)
+				return
+			}
+		} else {
+			h.sendDNDDigest(dispatcher)
+		}
+	}
+
+	// Digest: batch noisy events, and send their summary once it is due or
+	// with the next event that is not batched
+	if h.cfg.Notifications.Digest.Enabled && h.digestQ != nil {
+		if h.cfg.Notifications.Digest.Batches(hook.event, statusStr, priority.Resolve(ev.Status, ev.Priority)) {
+			h.tracef("Notification %s batched for the digest", statusStr)
+			h.addToDigest(ev, statusInfo.Title, message)
+			h.sendDigest(dispatcher, false)
+			return
+		}
+		h.sendDigest(dispatcher, true)
+	}
+
+	// Escalation backends wait until the notification goes unacknowledged
+	now, held := splitEscalation(ev.Backends, dispatcher.Names(), h.cfg.Notifications.Escalation)
+	if len(held) > 0 {
+		if err := h.scheduleEscalation(ev, held); err != nil {
+			logging.Warn("Failed to schedule escalation, sending now: %v", err)
+			now = append(now, held...)
+		}
+		if len(now) == 0 {
+			return
+		}
+		ev.Backends = now
+	}
+
+	h.traceBackends(dispatcher, ev)
+	sent := dispatcher.Dispatch(ev)
+	logging.Debug("Notification %s dispatched to: %v", statusStr, sent)
+}
+
+// addToDigest stores a notification for the next digest summary
+func (h *Handler) addToDigest(ev notifier.Event, statusTitle, message string) {
+	if h.dryRun {
+		return
+	}
+	title := statusTitle
+	if ev.Title != "" {
+		title = ev.Title
+	}
+	item := digest.Item{Time: time.Now(), Status: string(ev.Status), Title: title, Message: message, Project: ev.Project}
+	if err := h.digestQ.Add(item); err != nil {
+		logging.Warn("Failed to batch notification for the digest: %v", err)
+		return
+	}
+	logging.Debug("Notification %s batched for the digest", ev.Status)
+}
+
+// sendDigest delivers one notification summarizing the batched ones: when
+// now is set, or once the oldest has waited digest.interval. Escalation
+// backends never receive it.
+func (h *Handler) sendDigest(dispatcher *notifier.Dispatcher, now bool) {
+	if h.dryRun {
+		return
+	}
+	if !now {
+		oldest, err := h.digestQ.Oldest()
+		if err != nil {
+			logging.Warn("Failed to read digest queue: %v", err)
+			return
+		}
+		if oldest.IsZero() || time.Since(oldest) < h.cfg.Notifications.Digest.IntervalDuration() {
+			return
+		}
+	}
+	items, err := h.digestQ.Drain()
+	if err != nil {
+		logging.Warn("Failed to read digest queue: %v", err)
+	}
+	if len(items) == 0 {
+		return
+	}
+
+	title, message := digest.Summary(items)
+	ev := notifier.Event{
+		Status:  analyzer.Status(items[len(items)-1].Status),
+		Title:   title,
+		Message: message,
+	}
+	if immediate, held := splitEscalation(nil, dispatcher.Names(), h.cfg.Notifications.Escalation); len(held) > 0 {
+		if len(immediate) == 0 {
+			return
+		}
+		ev.Backends = immediate
+	}
+	sent := dispatcher.Dispatch(ev)
+	logging.Debug("Digest of %d notifications dispatched to: %v", len(items), sent)
+}
+
+// splitEscalation divides the backends an event may go to, all registered
+// ones unless rules chose some, into those notified now and those held
+// back for escalation. now is nil when nothing is held back.
+func splitEscalation(backends, registered []string, cfg config.EscalationConfig) (now, held []string) {
+	for _, name := range registered {
+		if len(backends) > 0 && !slices.Contains(backends, name) {
+			continue
+		}
+		if cfg.Escalates(name) {
+			held = append(held, name)
+		} else {
+			now = append(now, name)
+		}
+	}
+	if len(held) == 0 {
+		return nil, nil
+	}
+	return now, held
+}
+
+// scheduleEscalation stores the event for the held backends and starts
+// the worker that sends it when nobody acknowledged it in time
+func (h *Handler) scheduleEscalation(ev notifier.Event, held []string) error {
+	if h.escalations == nil {
+		return fmt.Errorf("escalation store unavailable")
+	}
+	ev.Backends = held
+	created := time.Now()
+	after := h.cfg.Notifications.Escalation.AfterDuration()
+	if h.dryRun {
+		h.tracef("Would escalate to %s in %v unless acknowledged", traceList(held), after)
+		return nil
+	}
+	p := escalation.Pending{
+		ID:      strconv.FormatInt(created.UnixNano(), 36),
+		Event:   ev,
+		Created: created,
+		Due:     created.Add(after),
+	}
+	if err := h.escalations.Schedule(ev.SessionID, p); err != nil {
+		return err
+	}
+	if err := spawnEscalation(ev.SessionID, p.ID); err != nil {
+		_ = h.escalations.Cancel(ev.SessionID)
+		return err
+	}
+	logging.Debug("Escalation to %v in %v unless acknowledged", held, after)
+	return nil
+}
+
+// cancelEscalation drops the pending escalation of a session
+func (h *Handler) cancelEscalation(sessionID string) {
+	if h.escalations == nil || h.dryRun {
+		return
+	}
+	if err := h.escalations.Cancel(sessionID); err != nil {
+		logging.Debug("Failed to cancel escalation: %v", err)
+	}
+}
+
+// Escalate waits until the escalation id of a session is due and sends it
+// to the escalation backends. It gives up when the escalation is cancelled
+// by activity in the session, replaced by a newer notification, or the
+// desktop notification was acknowledged on the way.
+func (h *Handler) Escalate(sessionID, id string) error {
+	defer h.closeServices()
+	logging.SetPrefix(fmt.Sprintf("PID:%d", os.Getpid()))
+
+	if h.escalations == nil {
+		return fmt.Errorf("escalation store unavailable")
+	}
+	for {
+		p, err := h.escalations.Get(sessionID)
+		if err != nil {
+			return err
+		}
+		if p == nil || p.ID != id {
+			logging.Debug("Escalation %s cancelled or replaced", id)
+			return nil
+		}
+		if h.notifierSvc.Acknowledged(sessionID).After(p.Created) {
+			logging.Debug("Notification acknowledged, escalation %s dropped", id)
+			_, err := h.escalations.Claim(sessionID, id)
+			return err
+		}
+		wait := time.Until(p.Due)
+		if wait <= 0 {
+			break
+		}
+		sleep(min(wait, escalationPollInterval))
+	}
+
+	p, err := h.escalations.Claim(sessionID, id)
+	if err != nil || p == nil {
+		return err
+	}
+	ev := p.Event
+	h.applyProjectConfig(ev.CWD)
+	if !h.cfg.Notifications.Escalation.Enabled {
+		logging.Debug("Escalation disabled since it was scheduled, dropped")
+		return nil
+	}
+	if h.cfg.UsesIdle() {
+		if d, err := idleTime(); err == nil {
+			ev.Idle = max(d, time.Millisecond)
+		}
+	}
+	sent := h.newDispatcher().Dispatch(ev)
+	logging.Info("Notification %s unacknowledged for %v, escalated to: %v",
+		ev.Status, time.Since(p.Created).Round(time.Second), sent)
+	return nil
+}
+
+// newDispatcher registers every enabled backend; each receives an event
+// if its route matches. Every delivery attempt is recorded in the history.
+// Webhook, email and MQTT backends are skipped while their circuit breaker
+// is open.
+func (h *Handler) newDispatcher() *notifier.Dispatcher {
+	dispatcher := notifier.NewDispatcher()
+	if h.cfg.IsDesktopEnabled() {
+		dispatcher.Add(notifier.Backend{
+			Name:    "desktop",
+			Route:   h.cfg.Notifications.Desktop.Route,
+			Privacy: h.cfg.Notifications.Desktop.Privacy,
+			Send: func(ev notifier.Event) {
+				start := time.Now()
+				ev = notifier.ApplyContent(ev, h.cfg.Notifications.Content.Override(h.cfg.Notifications.Desktop.Content))
+				if h.cfg.IsRemoteForwardingActive() {
+					ev = h.redactEvent(ev)
+				}
+				opts := notifier.Options{Title: ev.Title, Sound: ev.Sound, Urgency: ev.Priority, Replace: ev.Replace}
+				if whenFocused := h.cfg.Notifications.Desktop.WhenFocused; whenFocused != "" {
+					if focused, err := h.terminalIsFocused(ev.SessionID); err != nil {
+						logging.Debug("Cannot tell whether the terminal is focused: %v", err)
+					} else if focused && whenFocused == "skip" {
+						h.tracef("Desktop notification skipped: the terminal is focused")
+						return
+					} else if focused {
+						opts.Sound = "none"
+					}
+				}
+				if h.dryRunDelivery("desktop", ev) {
+					return
+				}
+				err := h.notifierSvc.SendDesktopWithOptions(ev.Status, ev.Message, ev.SessionID, ev.CWD, opts)
+				if err != nil {
+					errorhandler.HandleError(err, "Failed to send desktop notification")
+				}
+				h.recordDelivery("desktop", ev, start, err)
+			},
+		})
+	}
+	if h.cfg.IsWebhookEnabled() {
+		dispatcher.Add(notifier.Backend{
+			Name:    "webhook",
+			Route:   h.cfg.Notifications.Webhook.Route,
+			Privacy: h.cfg.Notifications.Webhook.Privacy,
+			Send: func(ev notifier.Event) {
+				start := time.Now()
+				ev = h.redactEvent(notifier.ApplyContent(ev, h.cfg.Notifications.Content.Override(h.cfg.Notifications.Webhook.Content)))
+				if !h.breakerAllows("webhook", ev) || h.dryRunDelivery("webhook", ev) {
+					return
+				}
+				sent, err := notifier.SealEvent(ev, h.cfg.Notifications.Webhook.Encrypt.Key)
+				if err != nil {
+					h.recordDelivery("webhook", ev, start, err)
+					return
+				}
+				h.webhookSvc.SendAsyncWithResult(sent.Status, sent.Message, sent.SessionID, webhookMeta(sent), func(err error) {
+					h.recordDelivery("webhook", ev, start, err)
+					h.recordBreaker("webhook", err)
+				})
+			},
+		})
+	}
+	for _, extra := range h.extraHooks {
+		name, svc, content, key := extra.name, extra.svc, h.cfg.Notifications.Content.Override(extra.content), extra.key
+		dispatcher.Add(notifier.Backend{
+			Name:    name,
+			Route:   extra.route,
+			Privacy: extra.privacy,
+			Send: func(ev notifier.Event) {
+				start := time.Now()
+				ev = h.redactEvent(notifier.ApplyContent(ev, content))
+				if !h.breakerAllows(name, ev) || h.dryRunDelivery(name, ev) {
+					return
+				}
+				sent, err := notifier.SealEvent(ev, key)
+				if err != nil {
+					h.recordDelivery(name, ev, start, err)
+					return
+				}
+				svc.SendAsyncWithResult(sent.Status, sent.Message, sent.SessionID, webhookMeta(sent), func(err error) {
+					h.recordDelivery(name, ev, start, err)
+					h.recordBreaker(name, err)
+				})
+			},
+		})
+	}
+	if h.cfg.IsEmailEnabled() {
+		dispatcher.Add(notifier.Backend{
+			Name:    "email",
+			Route:   h.cfg.Notifications.Email.Route,
+			Privacy: h.cfg.Notifications.Email.Privacy,
+			Send: func(ev notifier.Event) {
+				start := time.Now()
+				ev = h.redactEvent(notifier.ApplyContent(ev, h.cfg.Notifications.Content.Override(h.cfg.Notifications.Email.Content)))
+				if !h.breakerAllows("email", ev) || h.dryRunDelivery("email", ev) {
+					return
+				}
+				h.emailSvc.SendAsyncWithResult(ev.Status, ev.Message, ev.SessionID, webhookMeta(ev), func(err error) {
+					h.recordDelivery("email", ev, start, err)
+					h.recordBreaker("email", err)
+				})
+			},
+		})
+	}
+	if h.cfg.IsSpeechEnabled() {
+		dispatcher.Add(notifier.Backend{
+			Name:    "speech",
+			Route:   h.cfg.Notifications.Speech.Route,
+			Privacy: h.cfg.Notifications.Speech.Privacy,
+			Send: func(ev notifier.Event) {
+				start := time.Now()
+				if h.dryRunDelivery("speech", ev) {
+					return
+				}
+				h.speechSvc.SpeakAsyncWithResult(speechData(ev), func(err error) {
+					h.recordDelivery("speech", ev, start, err)
+				})
+			},
+		})
+	}
+	if h.cfg.IsMQTTEnabled() {
+		dispatcher.Add(notifier.Backend{
+			Name:    "mqtt",
+			Route:   h.cfg.Notifications.MQTT.Route,
+			Privacy: h.cfg.Notifications.MQTT.Privacy,
+			Send: func(ev notifier.Event) {
+				start := time.Now()
+				ev = h.redactEvent(notifier.ApplyContent(ev, h.cfg.Notifications.Content.Override(h.cfg.Notifications.MQTT.Content)))
+				if !h.breakerAllows("mqtt", ev) || h.dryRunDelivery("mqtt", ev) {
+					return
+				}
+				sent, err := notifier.SealEvent(ev, h.cfg.Notifications.MQTT.Encrypt.Key)
+				if err != nil {
+					h.recordDelivery("mqtt", ev, start, err)
+					return
+				}
+				h.mqttSvc.PublishAsyncWithResult(sent.Status, sent.Message, sent.SessionID, webhookMeta(sent), func(err error) {
+					h.recordDelivery("mqtt", ev, start, err)
+					h.recordBreaker("mqtt", err)
+				})
+			},
+		})
+	}
+	for _, p := range h.plugins {
+		p, settings := p, h.cfg.PluginSettings(p.Name())
+		dispatcher.Add(notifier.Backend{
+			Name:    p.Name(),
+			Route:   settings.Route,
+			Privacy: settings.Privacy,
+			Send: func(ev notifier.Event) {
+				start := time.Now()
+				ev = h.redactEvent(notifier.ApplyContent(ev, h.cfg.Notifications.Content))
+				if !h.breakerAllows(p.Name(), ev) || h.dryRunDelivery(p.Name(), ev) {
+					return
+				}
+				h.pluginHost.NotifyAsyncWithResult(p, ev, func(err error) {
+					h.recordDelivery(p.Name(), ev, start, err)
+					h.recordBreaker(p.Name(), err)
+				})
+			},
+		})
+	}
+	return dispatcher
+}
+
+// speechData returns the fields of the speech phrase for an event. The
+// do-not-disturb digest has no content data and is spoken by its title.
+func speechData(ev notifier.Event) config.ContentData {
+	if ev.Content != nil {
+		return *ev.Content
+	}
+	return config.ContentData{Title: ev.Title, Message: ev.Message, Status: string(ev.Status), Project: ev.Project}
+}
+
+// redactEvent scrubs secrets from the title and message of an event about
+// to leave the machine
+func (h *Handler) redactEvent(ev notifier.Event) notifier.Event {
+	if h.redactor == nil {
+		return ev
+	}
+	ev.Title = h.redactor.String(ev.Title)
+	ev.Message = h.redactor.String(ev.Message)
+	if ev.Content != nil {
+		data := *ev.Content
+		data.Title = h.redactor.String(data.Title)
+		data.Message = h.redactor.String(data.Message)
+		ev.Content = &data
+	}
+	return ev
+}
+
+// webhookMeta returns the webhook and email details for an event
+func webhookMeta(ev notifier.Event) webhook.Meta {
+	meta := webhook.Meta{Project: ev.Project, Elapsed: ev.Elapsed, Title: ev.Title, Priority: ev.Priority}
+	if ev.Content != nil {
+		meta.Repo, meta.Branch = ev.Content.Repo, ev.Content.Branch
+	}
+	return meta
+}
+
+// recordDelivery appends a delivery attempt that began at start to the
+// notification history, and reports it to the daemon's metrics if enabled
+func (h *Handler) recordDelivery(backend string, ev notifier.Event, start time.Time, err error) {
+	if h.onDelivery != nil {
+		h.onDelivery(backend, time.Since(start), err)
+		return
+	}
+	if h.cfg.Metrics.Enabled {
+		notifier.ReportDelivery(backend, err == nil, time.Since(start))
+	}
+	if err != nil {
+		h.appendHistory(backend, ev, history.ResultFailed, err.Error())
+		return
+	}
+	h.appendHistory(backend, ev, history.ResultDelivered, "")
+}
+
+// recordSkipped records in the history that backend was not sent an event
+// because of reason, e.g. an open circuit breaker
+func (h *Handler) recordSkipped(backend string, ev notifier.Event, reason string) {
+	h.appendHistory(backend, ev, history.ResultSkipped, reason)
+}
+
+// appendHistory appends the outcome of sending ev to backend to the history
+func (h *Handler) appendHistory(backend string, ev notifier.Event, result, errText string) {
+	if h.history == nil {
+		return
+	}
+	title := ev.Title
+	if title == "" {
+		statusInfo, _ := h.cfg.GetStatusInfo(string(ev.Status))
+		title = statusInfo.Title
+	}
+	entry := history.Entry{
+		Time:      time.Now(),
+		Event:     string(ev.Status),
+		Title:     title,
+		Message:   ev.Message,
+		Project:   ev.Project,
+		CWD:       ev.CWD,
+		SessionID: ev.SessionID,
+		Backend:   backend,
+		Result:    result,
+		Error:     errText,
+	}
+	if err := h.history.Append(entry); err != nil {
+		logging.Warn("Failed to record notification history: %v", err)
+	}
+}
+
+// breakerAllows reports whether a delivery to backend may go ahead. While
+// its circuit breaker is open the event is recorded as skipped instead.
+// Test notifications always go ahead.
+func (h *Handler) breakerAllows(backend string, ev notifier.Event) bool {
+	if h.breakers == nil || h.onDelivery != nil {
+		return true
+	}
+	now := time.Now()
+	var st breaker.State
+	allowed := true
+	if h.dryRun {
+		// Look without claiming the probe
+		states, err := h.breakers.States()
+		if err != nil {
+			logging.Warn("Circuit breaker: %v", err)
+		}
+		st = states[backend]
+		allowed = !st.Open(now)
 	} else {
-		logging.Debug("Webhook notification disabled for status: %s", statusStr)
+		var err error
+		if allowed, st, err = h.breakers.Allow(backend, now); err != nil {
+			logging.Warn("Circuit breaker: %v", err)
+		}
 	}
+
+	if allowed {
+		if st.Tripped() {
+			h.tracef("%s: circuit breaker probing after %d failures", backend, st.Failures)
+			logging.Info("Probing %s after %d failures", backend, st.Failures)
+		}
+		return true
+	}
+	reason := fmt.Sprintf("circuit breaker open until %s after %d failures: %s",
+		st.OpenUntil.Local().Format("15:04:05"), st.Failures, st.LastError)
+	h.tracef("%s: skipped, %s", backend, reason)
+	logging.Info("Skipping %s: %s", backend, reason)
+	if !h.dryRun {
+		h.recordSkipped(backend, ev, reason)
+	}
+	return false
+}
+
+// recordBreaker updates the circuit breaker of backend with a delivery result
+func (h *Handler) recordBreaker(backend string, err error) {
+	if h.breakers == nil || h.onDelivery != nil {
+		return
+	}
+	opened, st, recordErr := h.breakers.Record(backend, err, time.Now())
+	if recordErr != nil {
+		logging.Warn("Circuit breaker: %v", recordErr)
+	}
+	if opened {
+		logging.Warn("%s failed %d times in a row, skipping it for %v", backend, st.Failures, st.Backoff)
+	}
+}
+
+// queueForDND stores a notification for the do-not-disturb digest.
+// Without a digest, held notifications are dropped.
+func (h *Handler) queueForDND(ev notifier.Event, statusTitle, message string) {
+	if !h.cfg.IsDNDDigestEnabled() || h.dryRun {
+		return
+	}
+	title := statusTitle
+	if ev.Title != "" {
+		title = ev.Title
+	}
+	item := dnd.Item{Time: time.Now(), Status: string(ev.Status), Title: title, Message: message, Project: ev.Project}
+	if err := h.dndMgr.Enqueue(item); err != nil {
+		logging.Warn("Failed to queue notification for do-not-disturb digest: %v", err)
+	}
+}
+
+// sendDNDDigest delivers one notification summarizing those held back during
+// do-not-disturb, and returns how many it summarized
+func (h *Handler) sendDNDDigest(dispatcher *notifier.Dispatcher) int {
+	if h.dndMgr == nil || !h.cfg.IsDNDDigestEnabled() || h.dryRun {
+		return 0
+	}
+	items, err := h.dndMgr.Drain()
+	if err != nil {
+		logging.Warn("Failed to read do-not-disturb queue: %v", err)
+	}
+	if len(items) == 0 {
+		return 0
+	}
+
+	title, message := dnd.Digest(items)
+	sent := dispatcher.Dispatch(notifier.Event{
+		Status:  analyzer.Status(items[len(items)-1].Status),
+		Title:   title,
+		Message: message,
+	})
+	logging.Debug("Do-not-disturb digest of %d notifications dispatched to: %v", len(items), sent)
+	return len(items)
+}
+
+// SendDNDDigest delivers the do-not-disturb digest right away and waits for
+// delivery. Used by "claude-notifications dnd off".
+func (h *Handler) SendDNDDigest() int {
+	defer h.closeServices()
+	return h.sendDNDDigest(h.newDispatcher())
+}
+
+// startSession registers a session from the SessionStart hook
+func (h *Handler) startSession(hookData *HookData) {
+	if h.dryRun {
+		h.tracef("Would register session %s", hookData.SessionID)
+		return
+	}
+	if h.sessionReg == nil {
+		return
+	}
+	terminal := daemon.GetTerminalName()
+	if err := h.sessionReg.Start(hookData.SessionID, hookData.CWD, terminal, time.Now()); err != nil {
+		logging.Warn("Failed to register session: %v", err)
+		return
+	}
+	h.pinWindow(hookData, terminal)
+	h.trackSession(hookData.SessionID)
+}
+
+// pinWindow remembers the window the session runs in, so a click on its
+// notifications focuses that window even when the terminal has several.
+// The user just started or resumed the session, so its window is the
+// active one; compaction runs unattended and keeps the pin it has.
+func (h *Handler) pinWindow(hookData *HookData, terminal string) {
+	if hookData.Source == "compact" || !h.cfg.Notifications.Desktop.ClickToFocus || !h.cfg.IsWindowPinningEnabled() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), daemon.DefaultFocusMethodTimeout)
+	defer cancel()
+	window, err := daemon.CaptureWindow(ctx, terminal)
+	if err != nil {
+		logging.Debug("Not pinning the session's window: %v", err)
+	}
+	// A resumed session may run in another window now: unpin when not captured
+	if err := h.sessionReg.Pin(hookData.SessionID, window); err != nil {
+		logging.Debug("Failed to pin the session's window: %v", err)
+	}
+}
+
+// endSession removes a session on the SessionEnd hook
+func (h *Handler) endSession(hookData *HookData) {
+	if h.dryRun {
+		h.tracef("Would unregister session %s", hookData.SessionID)
+		return
+	}
+	if h.budgets != nil {
+		if err := h.budgets.Forget(hookData.SessionID); err != nil {
+			logging.Warn("Failed to remove budget usage: %v", err)
+		}
+	}
+	if h.sessionReg == nil {
+		return
+	}
+	if err := h.sessionReg.End(hookData.SessionID); err != nil {
+		logging.Warn("Failed to unregister session: %v", err)
+	}
+	untrackSession(hookData.SessionID)
+}
+
+// dismissEarlier closes the desktop notifications still shown for a
+// session when desktop.autoDismiss is on
+func (h *Handler) dismissEarlier(sessionID string) {
+	if !h.cfg.IsDesktopEnabled() || !h.cfg.Notifications.Desktop.AutoDismiss {
+		return
+	}
+	if h.dryRun {
+		h.tracef("Would dismiss earlier notifications of session %s", sessionID)
+		return
+	}
+	if err := h.notifierSvc.DismissSession(sessionID); err != nil {
+		logging.Debug("Failed to dismiss earlier notifications: %v", err)
+	}
+}
+
+// atTerminal reports whether the user is at the session's terminal: input
+// within presence.activeWithin with the terminal focused. Where focus
+// cannot be read, recent input alone counts.
+func (h *Handler) atTerminal(sessionID string, idle time.Duration) bool {
+	if idle == 0 || idle > h.cfg.Notifications.Presence.ActiveWithinDuration() {
+		return false
+	}
+	focused, err := h.terminalIsFocused(sessionID)
+	if err != nil {
+		logging.Debug("Cannot tell whether the terminal is focused (%v), going by input alone", err)
+		return true
+	}
+	return focused
+}
+
+// terminalIsFocused reports whether the session's terminal window, pinned
+// at SessionStart when possible, is the focused window
+func (h *Handler) terminalIsFocused(sessionID string) (bool, error) {
+	terminal := daemon.GetTerminalName()
+	var pinned *sessions.Window
+	if h.sessionReg != nil {
+		if s, err := h.sessionReg.Get(sessionID); err == nil && s != nil {
+			pinned = s.Window
+			if s.Terminal != "" {
+				terminal = s.Terminal
+			}
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), daemon.DefaultFocusMethodTimeout)
+	defer cancel()
+	focused, err := terminalFocused(ctx, terminal, pinned)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", terminal, err)
+	}
+	return focused, nil
+}
+
+// touchSession records hook activity so sessions that exit without
+// SessionEnd eventually expire
+func (h *Handler) touchSession(sessionID string) {
+	if h.sessionReg == nil || h.dryRun {
+		return
+	}
+	if err := h.sessionReg.Touch(sessionID, time.Now()); err != nil {
+		logging.Debug("Failed to update session activity: %v", err)
+	}
+	h.trackSession(sessionID)
+}
+
+// trackSession passes a registered session on to the daemon's live
+// session map, with the terminal process and tmux pane of this hook
+func (h *Handler) trackSession(sessionID string) {
+	s, err := h.sessionReg.Get(sessionID)
+	if err != nil || s == nil {
+		return
+	}
+	trackSession(s)
+}
+
+// sessionDuration returns how long a registered session has been running
+// (0 = not registered)
+func (h *Handler) sessionDuration(sessionID string) time.Duration {
+	if h.sessionReg == nil {
+		return 0
+	}
+	s, err := h.sessionReg.Get(sessionID)
+	if err != nil || s == nil {
+		return 0
+	}
+	return time.Since(s.StartedAt)
+}
+
+// needsElapsed returns true if any enabled backend uses the session's elapsed time
+func (h *Handler) needsElapsed() bool {
+	return h.cfg.IsWebhookEnabled() || h.cfg.IsEmailEnabled() || h.cfg.IsSpeechEnabled() || h.cfg.IsMQTTEnabled() || len(h.extraHooks) > 0 || len(h.plugins) > 0 || h.cfg.UsesContentTemplates()
 }
 
 // isSubagentTranscript checks if the transcript path indicates a subagent session.
@@ -418,6 +1715,9 @@ func isSubagentTranscript(transcriptPath string) bool {
 
 // cleanupOldLocks cleans up old lock and state files but preserves session state for cooldown
 func (h *Handler) cleanupOldLocks() {
+	if h.dryRun {
+		return
+	}
 	// Cleanup old locks (older than 60 seconds)
 	if err := h.dedupMgr.Cleanup(60); err != nil {
 		logging.Warn("Failed to cleanup old locks: %v", err)
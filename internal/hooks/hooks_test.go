@@ -1,21 +1,37 @@
 package hooks
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/breaker"
+	"github.com/777genius/claude-notifications/internal/budget"
 	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/daemon"
 	"github.com/777genius/claude-notifications/internal/dedup"
+	"github.com/777genius/claude-notifications/internal/digest"
+	"github.com/777genius/claude-notifications/internal/dnd"
+	"github.com/777genius/claude-notifications/internal/escalation"
+	"github.com/777genius/claude-notifications/internal/history"
+	"github.com/777genius/claude-notifications/internal/notifier"
+	"github.com/777genius/claude-notifications/internal/plugin"
+	"github.com/777genius/claude-notifications/internal/rules"
+	"github.com/777genius/claude-notifications/internal/sessions"
 	"github.com/777genius/claude-notifications/internal/state"
+	"github.com/777genius/claude-notifications/internal/webhook"
 	"github.com/777genius/claude-notifications/pkg/jsonl"
 )
 
@@ -34,6 +50,8 @@ func setTestHome(t *testing.T, dir string) {
 type mockNotifier struct {
 	mu         sync.Mutex
 	calls      []notificationCall
+	dismissed  []string
+	acked      time.Time
 	shouldFail bool
 }
 
@@ -41,9 +59,10 @@ type notificationCall struct {
 	status  analyzer.Status
 	message string
 	cwd     string
+	opts    notifier.Options
 }
 
-func (m *mockNotifier) SendDesktop(status analyzer.Status, message, sessionID, cwd string) error {
+func (m *mockNotifier) SendDesktopWithOptions(status analyzer.Status, message, sessionID, cwd string, opts notifier.Options) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -51,6 +70,7 @@ func (m *mockNotifier) SendDesktop(status analyzer.Status, message, sessionID, c
 		status:  status,
 		message: message,
 		cwd:     cwd,
+		opts:    opts,
 	})
 
 	if m.shouldFail {
@@ -59,6 +79,19 @@ func (m *mockNotifier) SendDesktop(status analyzer.Status, message, sessionID, c
 	return nil
 }
 
+func (m *mockNotifier) DismissSession(sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dismissed = append(m.dismissed, sessionID)
+	return nil
+}
+
+func (m *mockNotifier) Acknowledged(sessionID string) time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.acked
+}
+
 func (m *mockNotifier) Close() error {
 	return nil
 }
@@ -91,23 +124,30 @@ type mockWebhook struct {
 	calls           []webhookCall
 	shutdownCalled  bool
 	shutdownTimeout time.Duration
+	err             error // Delivery result reported to SendAsyncWithResult callbacks
 }
 
 type webhookCall struct {
 	status    analyzer.Status
 	message   string
 	sessionID string
+	meta      webhook.Meta
 }
 
-func (m *mockWebhook) SendAsync(status analyzer.Status, message, sessionID string) {
+func (m *mockWebhook) SendAsyncWithResult(status analyzer.Status, message, sessionID string, meta webhook.Meta, done func(err error)) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	m.calls = append(m.calls, webhookCall{
 		status:    status,
 		message:   message,
 		sessionID: sessionID,
+		meta:      meta,
 	})
+	err := m.err
+	m.mu.Unlock()
+
+	if done != nil {
+		done(err)
+	}
 }
 
 func (m *mockWebhook) Shutdown(timeout time.Duration) error {
@@ -118,8 +158,40 @@ func (m *mockWebhook) Shutdown(timeout time.Duration) error {
 	return nil
 }
 
-func (m *mockWebhook) Send(status analyzer.Status, message, sessionID string) error {
-	m.SendAsync(status, message, sessionID)
+// mockSpeech records spoken notifications
+type mockSpeech struct {
+	mu             sync.Mutex
+	spoken         []config.ContentData
+	shutdownCalled bool
+}
+
+func (m *mockSpeech) SpeakAsyncWithResult(data config.ContentData, done func(err error)) {
+	m.mu.Lock()
+	m.spoken = append(m.spoken, data)
+	m.mu.Unlock()
+	if done != nil {
+		done(nil)
+	}
+}
+
+func (m *mockSpeech) Shutdown(timeout time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shutdownCalled = true
+	return nil
+}
+
+// mockMQTT records MQTT publishes like a webhook sender
+type mockMQTT struct {
+	mockWebhook
+}
+
+func (m *mockMQTT) PublishAsyncWithResult(status analyzer.Status, message, sessionID string, meta webhook.Meta, done func(err error)) {
+	m.SendAsyncWithResult(status, message, sessionID, meta, done)
+}
+
+func (m *mockWebhook) Send(status analyzer.Status, message, sessionID string, meta webhook.Meta) error {
+	m.SendAsyncWithResult(status, message, sessionID, meta, nil)
 	return nil
 }
 
@@ -289,6 +361,130 @@ func TestHandler_PreToolUse_ExitPlanMode(t *testing.T) {
 	}
 }
 
+func TestHandler_PreToolUse_ToolUse(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+			Tools:   config.ToolsConfig{Notify: []string{"Bash"}},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"tool_use": {Title: "Tool Use"},
+		},
+	}
+
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+
+	if err := handler.HandleHook("PreToolUse", buildHookDataJSON(HookData{
+		SessionID: "test-session-tools",
+		ToolName:  "Edit",
+		ToolInput: []byte(`{"file_path": "/test/main.go"}`),
+		CWD:       "/test",
+	})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mockNotif.wasCalled() {
+		t.Fatal("tools not listed in notifications.tools should not notify")
+	}
+
+	if err := handler.HandleHook("PreToolUse", buildHookDataJSON(HookData{
+		SessionID: "test-session-tools",
+		ToolName:  "Bash",
+		ToolInput: []byte(`{"command": "go test ./...", "description": "Run tests"}`),
+		CWD:       "/test",
+	})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	call := mockNotif.lastCall()
+	if call == nil {
+		t.Fatal("no notification sent")
+	}
+	// The message follows the session name prefix
+	if call.status != analyzer.StatusToolUse || !strings.HasSuffix(call.message, "] Bash: go test ./...") {
+		t.Errorf("got %v %q, want tool_use \"Bash: go test ./...\"", call.status, call.message)
+	}
+}
+
+func TestHandler_PostToolUse_ToolUse(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+			Tools:   config.ToolsConfig{Notify: []string{"Bash"}, When: "after"},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"tool_use": {Title: "Tool Use"},
+		},
+	}
+
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+
+	hookData := HookData{
+		SessionID: "test-session-tools-after",
+		ToolName:  "Bash",
+		ToolInput: []byte(`{"command": "make build"}`),
+		CWD:       "/test",
+	}
+	if err := handler.HandleHook("PreToolUse", buildHookDataJSON(hookData)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mockNotif.wasCalled() {
+		t.Fatal("when=after should not notify before the tool runs")
+	}
+
+	if err := handler.HandleHook("PostToolUse", buildHookDataJSON(hookData)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	call := mockNotif.lastCall()
+	if call == nil {
+		t.Fatal("no notification sent")
+	}
+	if call.status != analyzer.StatusToolUse || !strings.HasSuffix(call.message, "] Bash finished: make build") {
+		t.Errorf("got %v %q", call.status, call.message)
+	}
+}
+
+func TestHandler_BudgetExceeded(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+			Budget:  config.BudgetConfig{Tokens: 1500},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"budget_exceeded": {Title: "Budget Exceeded"},
+		},
+	}
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	handler.budgets = budget.NewStore(t.TempDir())
+
+	transcript := filepath.Join(t.TempDir(), "transcript.jsonl")
+	line := `{"type":"assistant","message":{"id":"msg_1","model":"claude-sonnet-4-5","role":"assistant","content":[],"usage":{"input_tokens":1000,"output_tokens":1000}}}` + "\n"
+	if err := os.WriteFile(transcript, []byte(line), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	hookData := HookData{
+		SessionID:      "test-session-budget",
+		TranscriptPath: transcript,
+		ToolName:       "Read",
+		CWD:            "/test",
+	}
+	if err := handler.HandleHook("PostToolUse", buildHookDataJSON(hookData)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	call := mockNotif.lastCall()
+	if call == nil {
+		t.Fatal("no notification sent")
+	}
+	if call.status != analyzer.StatusBudgetExceeded || !strings.HasSuffix(call.message, "Session used 2.0k tokens, over the budget of 1.5k") {
+		t.Errorf("got %v %q", call.status, call.message)
+	}
+
+	// Each budget alerts once per session
+	handler.checkBudget("PostToolUse", &hookData)
+	if n := mockNotif.callCount(); n != 1 {
+		t.Errorf("got %d notifications, want 1", n)
+	}
+}
+
 func TestHandler_PreToolUse_AskUserQuestion(t *testing.T) {
 	cfg := &config.Config{
 		Notifications: config.NotificationsConfig{
@@ -654,6 +850,94 @@ func TestHandler_EmptySessionID(t *testing.T) {
 
 // === Notification Disabled Tests ===
 
+func TestHandler_Notification_TranscriptSummary(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop:           config.DesktopConfig{Enabled: true},
+			TranscriptSummary: config.TranscriptSummaryConfig{Enabled: true},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"question": {Title: "Question"},
+		},
+	}
+
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+
+	transcriptPath := createTempTranscript(t, []jsonl.Message{
+		{
+			Type:      "user",
+			Message:   jsonl.MessageContent{Role: "user", Content: []jsonl.Content{{Type: "text", Text: "Migrate the db"}}},
+			Timestamp: "2025-01-01T12:00:00Z",
+		},
+		{
+			Type: "assistant",
+			Message: jsonl.MessageContent{Role: "assistant", Content: []jsonl.Content{
+				{Type: "text", Text: "I'll run the migration against the staging database. It takes a minute."},
+				{Type: "tool_use", Name: "Bash"},
+			}},
+			Timestamp: "2025-01-01T12:00:01Z",
+		},
+	})
+
+	err := handler.HandleHook("Notification", buildHookDataJSON(HookData{
+		SessionID:      "test-session-summary",
+		TranscriptPath: transcriptPath,
+		CWD:            "/test",
+		Message:        "Claude needs your permission to use Bash",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	call := mockNotif.lastCall()
+	if call == nil {
+		t.Fatal("no notification sent")
+	}
+	want := "] Claude needs your permission to use Bash — I'll run the migration against the staging database."
+	if !strings.HasSuffix(call.message, want) {
+		t.Errorf("message = %q, want suffix %q", call.message, want)
+	}
+}
+
+func TestHandler_Notification_CriticalSound(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true, CriticalSound: "/sounds/alarm.wav"},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"question": {Title: "Question", Sound: "/sounds/question.mp3"},
+		},
+	}
+
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	if err := handler.HandleHook("Notification", buildHookDataJSON(HookData{
+		SessionID: "test-session-permission",
+		CWD:       "/test",
+		Message:   "Claude needs your permission to use Bash",
+	})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	call := mockNotif.lastCall()
+	if call == nil {
+		t.Fatal("no notification sent")
+	}
+	if call.opts.Sound != "/sounds/alarm.wav" {
+		t.Errorf("permission prompt sound = %q, want the critical sound", call.opts.Sound)
+	}
+
+	// Other prompts keep the question sound
+	if err := handler.HandleHook("Notification", buildHookDataJSON(HookData{
+		SessionID: "test-session-idle",
+		CWD:       "/test",
+		Message:   "Claude is waiting for your input",
+	})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if call := mockNotif.lastCall(); call == nil || call.opts.Sound != "" {
+		t.Errorf("idle prompt options = %+v, want the status sound", call)
+	}
+}
+
 func TestHandler_NotificationsDisabled(t *testing.T) {
 	cfg := &config.Config{
 		Notifications: config.NotificationsConfig{
@@ -1040,96 +1324,1213 @@ func TestHandler_SendsWebhookWhenEnabled(t *testing.T) {
 	time.Sleep(50 * time.Millisecond) // Webhook is async
 
 	if !mockWH.wasCalled() {
-		t.Error("expected webhook to be called when enabled")
+		t.Fatal("expected webhook to be called when enabled")
 	}
-}
-
-// === NewHandler Constructor Tests ===
-
-func TestNewHandler_Success(t *testing.T) {
-	setTestHome(t, t.TempDir()) // isolate stable config path
-
-	// Create temp plugin root with valid config
-	tmpDir := t.TempDir()
 
-	// Create config directory and file (expected path: pluginRoot/config/config.json)
-	configDir := filepath.Join(tmpDir, "config")
-	err := os.MkdirAll(configDir, 0755)
-	if err != nil {
-		t.Fatalf("failed to create config dir: %v", err)
+	mockWH.mu.Lock()
+	project := mockWH.calls[0].meta.Project
+	mockWH.mu.Unlock()
+	if project != "test" {
+		t.Errorf("webhook meta project = %q, want %q", project, "test")
 	}
+}
 
-	configPath := filepath.Join(configDir, "config.json")
-	configJSON := `{
-		"notifications": {
-			"desktop": {"enabled": true, "sound": true},
-			"webhook": {"enabled": false}
+func TestHandler_SendsEmailWhenEnabled(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+			Email:   config.EmailConfig{Enabled: true},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete": {Title: "Task Complete"},
 		},
-		"statuses": {
-			"task_complete": {"title": "Task Complete"}
-		}
-	}`
-
-	err = os.WriteFile(configPath, []byte(configJSON), 0644)
-	if err != nil {
-		t.Fatalf("failed to write config: %v", err)
 	}
 
-	// Create handler
-	handler, err := NewHandler(tmpDir)
+	handler, _, mockWH := newTestHandler(t, cfg)
+	mockEmail := &mockWebhook{} // same SendAsyncWithResult/Shutdown shape
+	handler.emailSvc = mockEmail
 
-	if err != nil {
-		t.Fatalf("NewHandler failed: %v", err)
-	}
+	transcriptPath := createTempTranscript(t,
+		buildTranscriptWithTools([]string{"Write"}, 300))
 
-	if handler == nil {
-		t.Fatal("handler is nil")
-	}
+	hookData := buildHookDataJSON(HookData{
+		SessionID:      "test-session-email",
+		TranscriptPath: transcriptPath,
+		CWD:            "/work/api",
+	})
 
-	// Verify handler components
-	if handler.cfg == nil {
-		t.Error("handler.cfg is nil")
-	}
-	if handler.dedupMgr == nil {
-		t.Error("handler.dedupMgr is nil")
+	if err := handler.HandleHook("Stop", hookData); err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
-	if handler.stateMgr == nil {
-		t.Error("handler.stateMgr is nil")
+
+	if !mockEmail.wasCalled() {
+		t.Fatal("expected email to be sent when enabled")
 	}
-	if handler.notifierSvc == nil {
-		t.Error("handler.notifierSvc is nil")
+	if !mockEmail.wasShutdownCalled() {
+		t.Error("expected email sender to be shut down before the hook exits")
 	}
-	if handler.webhookSvc == nil {
-		t.Error("handler.webhookSvc is nil")
+	if mockEmail.calls[0].meta.Project != "api" {
+		t.Errorf("email meta project = %q, want api", mockEmail.calls[0].meta.Project)
 	}
-	if handler.pluginRoot != tmpDir {
-		t.Errorf("handler.pluginRoot = %s, want %s", handler.pluginRoot, tmpDir)
+	if mockWH.wasCalled() {
+		t.Error("webhook should not be called when disabled")
 	}
+}
 
-	// Verify the test config was actually loaded (not just defaults)
-	info, exists := handler.cfg.GetStatusInfo("task_complete")
-	if !exists {
-		t.Error("expected task_complete status to exist")
-	} else if info.Title != "Task Complete" {
-		t.Errorf("expected task_complete title 'Task Complete' from test config, got %q", info.Title)
+func TestHandler_PublishesMQTTWhenEnabled(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			MQTT: config.MQTTConfig{Enabled: true, Content: config.ContentConfig{Title: "{{.Project}} needs you"}},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete": {Title: "Task Complete"},
+		},
 	}
-}
 
-func TestNewHandler_WithDefaultConfig(t *testing.T) {
-	setTestHome(t, t.TempDir()) // isolate stable config path
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	mockPub := &mockMQTT{}
+	handler.mqttSvc = mockPub
 
-	// Create empty plugin root (no config file)
-	tmpDir := t.TempDir()
+	transcriptPath := createTempTranscript(t,
+		buildTranscriptWithTools([]string{"Write"}, 300))
 
-	// NewHandler should use default config
-	handler, err := NewHandler(tmpDir)
+	hookData := buildHookDataJSON(HookData{
+		SessionID:      "test-session-mqtt",
+		TranscriptPath: transcriptPath,
+		CWD:            "/work/api",
+	})
 
-	if err != nil {
-		t.Fatalf("NewHandler with defaults failed: %v", err)
+	if err := handler.HandleHook("Stop", hookData); err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if handler == nil {
-		t.Fatal("handler is nil")
+	if !mockPub.wasCalled() {
+		t.Fatal("expected an MQTT publish when enabled")
+	}
+	if !mockPub.wasShutdownCalled() {
+		t.Error("expected the MQTT publisher to be shut down before the hook exits")
+	}
+	if got := mockPub.calls[0].meta; got.Project != "api" || got.Title != "api needs you" {
+		t.Errorf("mqtt meta = %+v, want project api and the content title", got)
+	}
+	if mockNotif.wasCalled() {
+		t.Error("desktop should not be called when disabled")
+	}
+}
+
+func TestHandler_SpeaksWhenEnabled(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+			Speech:  config.SpeechConfig{Enabled: true},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete": {Title: "Task Complete"},
+		},
+	}
+
+	handler, _, _ := newTestHandler(t, cfg)
+	mockSpeaker := &mockSpeech{}
+	handler.speechSvc = mockSpeaker
+
+	transcriptPath := createTempTranscript(t,
+		buildTranscriptWithTools([]string{"Write"}, 300))
+
+	hookData := buildHookDataJSON(HookData{
+		SessionID:      "test-session-speech",
+		TranscriptPath: transcriptPath,
+		CWD:            "/work/api",
+	})
+
+	if err := handler.HandleHook("Stop", hookData); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mockSpeaker.spoken) != 1 {
+		t.Fatalf("spoken %d times, want 1", len(mockSpeaker.spoken))
+	}
+	if got := mockSpeaker.spoken[0]; got.Title != "Task Complete" || got.Project != "api" || got.Event != "Stop" {
+		t.Errorf("spoken data = %+v, want title Task Complete, project api, event Stop", got)
+	}
+	if !mockSpeaker.shutdownCalled {
+		t.Error("expected speech to finish before the hook exits")
+	}
+}
+
+func TestHandler_DismissesEarlierNotifications(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Notifications.Desktop.AutoDismiss = true
+
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	for _, event := range []string{"SessionStart", "UserPromptSubmit", "SessionEnd"} {
+		hookData := buildHookDataJSON(HookData{SessionID: "test-session-dismiss"})
+		if err := handler.HandleHook(event, hookData); err != nil {
+			t.Fatalf("%s: unexpected error: %v", event, err)
+		}
+	}
+
+	// SessionStart has nothing to dismiss yet
+	want := []string{"test-session-dismiss", "test-session-dismiss"}
+	if !reflect.DeepEqual(mockNotif.dismissed, want) {
+		t.Errorf("dismissed = %v, want %v", mockNotif.dismissed, want)
+	}
+	if mockNotif.wasCalled() {
+		t.Error("UserPromptSubmit should not send a notification")
+	}
+}
+
+func TestHandler_KeepsEarlierNotificationsByDefault(t *testing.T) {
+	handler, mockNotif, _ := newTestHandler(t, config.DefaultConfig())
+
+	hookData := buildHookDataJSON(HookData{SessionID: "test-session-keep"})
+	if err := handler.HandleHook("UserPromptSubmit", hookData); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mockNotif.dismissed) != 0 {
+		t.Errorf("dismissed = %v, want none without autoDismiss", mockNotif.dismissed)
+	}
+}
+
+// stubPresence makes the user idle for idle, with the terminal focused or not
+func stubPresence(t *testing.T, idle time.Duration, focused bool) {
+	t.Helper()
+	origIdle, origFocused := idleTime, terminalFocused
+	idleTime = func() (time.Duration, error) { return idle, nil }
+	terminalFocused = func(context.Context, string, *sessions.Window) (bool, error) { return focused, nil }
+	t.Cleanup(func() { idleTime, terminalFocused = origIdle, origFocused })
+}
+
+func TestHandler_PresenceAtTerminal(t *testing.T) {
+	tests := []struct {
+		name       string
+		whenActive string
+		idle       time.Duration
+		focused    bool
+		wantCalls  int
+		wantLow    bool
+	}{
+		{"typing in the terminal", "", 5 * time.Second, true, 1, true},
+		{"typing in the terminal, suppress", "suppress", 5 * time.Second, true, 0, false},
+		{"typing in another app", "", 5 * time.Second, false, 1, false},
+		{"away", "", 10 * time.Minute, true, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubPresence(t, tt.idle, tt.focused)
+			cfg := config.DefaultConfig()
+			cfg.Notifications.Presence = config.PresenceConfig{Enabled: true, WhenActive: tt.whenActive}
+			handler, mockNotif, _ := newTestHandler(t, cfg)
+
+			transcriptPath := createTempTranscript(t, buildTranscriptWithTools([]string{"Write"}, 300))
+			hookData := buildHookDataJSON(HookData{SessionID: "test-session-presence", TranscriptPath: transcriptPath})
+			if err := handler.HandleHook("Stop", hookData); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := mockNotif.callCount(); got != tt.wantCalls {
+				t.Fatalf("desktop notifications = %d, want %d", got, tt.wantCalls)
+			}
+			if tt.wantCalls == 0 {
+				return
+			}
+			opts := mockNotif.lastCall().opts
+			if low := opts.Urgency == "low" && opts.Sound == "none"; low != tt.wantLow {
+				t.Errorf("options = %+v, want downgraded: %v", opts, tt.wantLow)
+			}
+		})
+	}
+}
+
+func TestHandler_WhenFocused(t *testing.T) {
+	tests := []struct {
+		name        string
+		whenFocused string
+		focused     bool
+		wantCalls   int
+		wantSilent  bool
+	}{
+		{"default notifies", "", true, 1, false},
+		{"silent when focused", "silent", true, 1, true},
+		{"skip when focused", "skip", true, 0, false},
+		{"skip when elsewhere", "skip", false, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubPresence(t, 0, tt.focused)
+			cfg := config.DefaultConfig()
+			cfg.Notifications.Desktop.WhenFocused = tt.whenFocused
+			handler, mockNotif, _ := newTestHandler(t, cfg)
+
+			transcriptPath := createTempTranscript(t, buildTranscriptWithTools([]string{"Write"}, 300))
+			hookData := buildHookDataJSON(HookData{SessionID: "test-session-focused", TranscriptPath: transcriptPath})
+			if err := handler.HandleHook("Stop", hookData); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := mockNotif.callCount(); got != tt.wantCalls {
+				t.Fatalf("desktop notifications = %d, want %d", got, tt.wantCalls)
+			}
+			if tt.wantCalls == 0 {
+				return
+			}
+			if silent := mockNotif.lastCall().opts.Sound == "none"; silent != tt.wantSilent {
+				t.Errorf("options = %+v, want silent: %v", mockNotif.lastCall().opts, tt.wantSilent)
+			}
+		})
+	}
+}
+
+func TestHandler_EscalatesWhenIdle(t *testing.T) {
+	for _, tt := range []struct {
+		idle time.Duration
+		want bool
+	}{{20 * time.Second, false}, {10 * time.Minute, true}} {
+		stubPresence(t, tt.idle, false)
+		cfg := &config.Config{
+			Notifications: config.NotificationsConfig{
+				Desktop: config.DesktopConfig{Enabled: true},
+				Webhook: config.WebhookConfig{Enabled: true, Preset: "ntfy", Route: config.RouteConfig{MinIdle: "5m"}},
+			},
+			Statuses: map[string]config.StatusInfo{
+				"task_complete": {Title: "Task Complete"},
+			},
+		}
+		handler, _, mockWH := newTestHandler(t, cfg)
+
+		transcriptPath := createTempTranscript(t, buildTranscriptWithTools([]string{"Write"}, 300))
+		hookData := buildHookDataJSON(HookData{SessionID: "test-session-idle", TranscriptPath: transcriptPath})
+		if err := handler.HandleHook("Stop", hookData); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if mockWH.wasCalled() != tt.want {
+			t.Errorf("idle %v: webhook called = %v, want %v", tt.idle, mockWH.wasCalled(), tt.want)
+		}
+	}
+}
+
+func TestSplitEscalation(t *testing.T) {
+	cfg := config.EscalationConfig{Enabled: true, Backends: []string{"webhook", "pager"}}
+	registered := []string{"desktop", "webhook", "pager", "email"}
+	tests := []struct {
+		name     string
+		backends []string
+		cfg      config.EscalationConfig
+		now      []string
+		held     []string
+	}{
+		{"all backends", nil, cfg, []string{"desktop", "email"}, []string{"webhook", "pager"}},
+		{"rules chose some", []string{"desktop", "pager"}, cfg, []string{"desktop"}, []string{"pager"}},
+		{"only escalation backends", []string{"webhook"}, cfg, nil, []string{"webhook"}},
+		{"nothing held back", []string{"desktop", "email"}, cfg, nil, nil},
+		{"disabled", nil, config.EscalationConfig{Backends: []string{"webhook"}}, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now, held := splitEscalation(tt.backends, registered, tt.cfg)
+			if !reflect.DeepEqual(now, tt.now) || !reflect.DeepEqual(held, tt.held) {
+				t.Errorf("splitEscalation = %v, %v; want %v, %v", now, held, tt.now, tt.held)
+			}
+		})
+	}
+}
+
+// stubEscalation keeps escalations in a temporary store and records the
+// workers that would be started
+func stubEscalation(t *testing.T, h *Handler) *[]string {
+	t.Helper()
+	h.escalations = escalation.NewStore(t.TempDir())
+	var spawned []string
+	origSpawn, origSleep := spawnEscalation, sleep
+	spawnEscalation = func(sessionID, id string) error {
+		spawned = append(spawned, id)
+		return nil
+	}
+	sleep = func(time.Duration) { t.Fatal("the escalation should be due") }
+	t.Cleanup(func() { spawnEscalation, sleep = origSpawn, origSleep })
+	return &spawned
+}
+
+func escalationConfig() *config.Config {
+	return &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop:    config.DesktopConfig{Enabled: true},
+			Webhook:    config.WebhookConfig{Enabled: true, Preset: "ntfy"},
+			Escalation: config.EscalationConfig{Enabled: true, Backends: []string{"webhook"}},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete": {Title: "Task Complete"},
+		},
+	}
+}
+
+// makeDue moves the pending escalation of a session into the past
+func makeDue(t *testing.T, h *Handler, sessionID string) string {
+	t.Helper()
+	p, err := h.escalations.Get(sessionID)
+	if err != nil || p == nil {
+		t.Fatalf("no pending escalation: %v, %v", p, err)
+	}
+	p.Created = time.Now().Add(-10 * time.Minute)
+	p.Due = time.Now().Add(-5 * time.Minute)
+	if err := h.escalations.Schedule(sessionID, *p); err != nil {
+		t.Fatal(err)
+	}
+	return p.ID
+}
+
+func TestHandler_EscalatesUnacknowledged(t *testing.T) {
+	handler, mockNotif, mockWH := newTestHandler(t, escalationConfig())
+	spawned := stubEscalation(t, handler)
+
+	transcriptPath := createTempTranscript(t, buildTranscriptWithTools([]string{"Write"}, 300))
+	hookData := buildHookDataJSON(HookData{SessionID: "test-session-escalate", TranscriptPath: transcriptPath})
+	if err := handler.HandleHook("Stop", hookData); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mockNotif.callCount() != 1 || mockWH.wasCalled() {
+		t.Fatalf("desktop = %d, webhook = %v; want only the desktop at first", mockNotif.callCount(), mockWH.wasCalled())
+	}
+	if len(*spawned) != 1 {
+		t.Fatalf("spawned = %v, want one worker", *spawned)
+	}
+
+	id := makeDue(t, handler, "test-session-escalate")
+	if err := handler.Escalate("test-session-escalate", id); err != nil {
+		t.Fatalf("Escalate: %v", err)
+	}
+	if !mockWH.wasCalled() {
+		t.Error("an unacknowledged notification should escalate to the webhook")
+	}
+	if mockNotif.callCount() != 1 {
+		t.Errorf("desktop = %d, want no second desktop notification", mockNotif.callCount())
+	}
+	if p, _ := handler.escalations.Get("test-session-escalate"); p != nil {
+		t.Errorf("escalation still pending after it was sent: %+v", p)
+	}
+}
+
+func TestHandler_EscalationAcknowledged(t *testing.T) {
+	t.Run("clicked", func(t *testing.T) {
+		handler, mockNotif, mockWH := newTestHandler(t, escalationConfig())
+		stubEscalation(t, handler)
+		transcriptPath := createTempTranscript(t, buildTranscriptWithTools([]string{"Write"}, 300))
+		if err := handler.HandleHook("Stop", buildHookDataJSON(HookData{SessionID: "test-session-acked", TranscriptPath: transcriptPath})); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		id := makeDue(t, handler, "test-session-acked")
+		mockNotif.acked = time.Now()
+
+		if err := handler.Escalate("test-session-acked", id); err != nil {
+			t.Fatalf("Escalate: %v", err)
+		}
+		if mockWH.wasCalled() {
+			t.Error("a clicked notification should not escalate")
+		}
+	})
+
+	t.Run("user replied", func(t *testing.T) {
+		handler, _, mockWH := newTestHandler(t, escalationConfig())
+		stubEscalation(t, handler)
+		transcriptPath := createTempTranscript(t, buildTranscriptWithTools([]string{"Write"}, 300))
+		if err := handler.HandleHook("Stop", buildHookDataJSON(HookData{SessionID: "test-session-replied", TranscriptPath: transcriptPath})); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		id := makeDue(t, handler, "test-session-replied")
+		if err := handler.HandleHook("UserPromptSubmit", buildHookDataJSON(HookData{SessionID: "test-session-replied"})); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := handler.Escalate("test-session-replied", id); err != nil {
+			t.Fatalf("Escalate: %v", err)
+		}
+		if mockWH.wasCalled() {
+			t.Error("a prompt in the session should cancel the escalation")
+		}
+	})
+}
+
+func TestHandler_DigestBatchesSubagentStops(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop:              config.DesktopConfig{Enabled: true},
+			NotifyOnSubagentStop: true,
+			Digest:               config.DigestConfig{Enabled: true},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete": {Title: "Task Complete"},
+		},
+	}
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	handler.digestQ = digest.NewQueue(t.TempDir())
+
+	for i := 0; i < 3; i++ {
+		transcriptPath := createTempTranscript(t, buildTranscriptWithTools([]string{"Write"}, 300))
+		hookData := buildHookDataJSON(HookData{SessionID: fmt.Sprintf("test-session-digest-%d", i), TranscriptPath: transcriptPath, CWD: "/work/api"})
+		if err := handler.HandleHook("SubagentStop", hookData); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := mockNotif.callCount(); got != 0 {
+		t.Fatalf("desktop notifications = %d, want subagent stops batched", got)
+	}
+
+	transcriptPath := createTempTranscript(t, buildTranscriptWithTools([]string{"Write"}, 300))
+	hookData := buildHookDataJSON(HookData{SessionID: "test-session-digest-main", TranscriptPath: transcriptPath, CWD: "/work/api"})
+	if err := handler.HandleHook("Stop", hookData); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := mockNotif.callCount(); got != 2 {
+		t.Fatalf("desktop notifications = %d, want the summary and the stop", got)
+	}
+	summary := mockNotif.calls[0]
+	if summary.opts.Title != "📋 3 updates in api" {
+		t.Errorf("summary title = %q", summary.opts.Title)
+	}
+	if items, _ := handler.digestQ.Drain(); len(items) != 0 {
+		t.Errorf("%d notifications left in the digest after the summary", len(items))
+	}
+}
+
+func TestHandler_DigestDueAfterInterval(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+			Digest:  config.DigestConfig{Enabled: true, Interval: "10m"},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete": {Title: "Task Complete"},
+		},
+	}
+	cfg.Notifications.Rules = []config.Rule{{Name: "quiet", Actions: config.RuleActions{Urgency: "low"}}}
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	handler.digestQ = digest.NewQueue(t.TempDir())
+	if err := handler.digestQ.Add(digest.Item{Time: time.Now().Add(-15 * time.Minute), Status: "task_complete", Title: "Task Complete"}); err != nil {
+		t.Fatal(err)
+	}
+
+	transcriptPath := createTempTranscript(t, buildTranscriptWithTools([]string{"Write"}, 300))
+	hookData := buildHookDataJSON(HookData{SessionID: "test-session-digest-due", TranscriptPath: transcriptPath})
+	if err := handler.HandleHook("Stop", hookData); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := mockNotif.callCount(); got != 1 {
+		t.Fatalf("desktop notifications = %d, want one summary", got)
+	}
+	if title := mockNotif.lastCall().opts.Title; title != "📋 2 updates" {
+		t.Errorf("summary title = %q", title)
+	}
+}
+
+func TestHandler_RoutesBackendsByRule(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+			Webhook: config.WebhookConfig{
+				Enabled: true,
+				Preset:  "ntfy",
+				Route:   config.RouteConfig{Statuses: []string{"question"}},
+			},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete": {Title: "Task Complete"},
+		},
+	}
+
+	handler, mockNotif, mockWH := newTestHandler(t, cfg)
+	extraAPI := &mockWebhook{}
+	extraOther := &mockWebhook{}
+	handler.extraHooks = []extraWebhook{
+		{name: "webhooks[0]", route: config.RouteConfig{Projects: []string{"api"}}, svc: extraAPI},
+		{name: "team-slack", route: config.RouteConfig{Projects: []string{"web-*"}}, svc: extraOther},
+	}
+
+	transcriptPath := createTempTranscript(t,
+		buildTranscriptWithTools([]string{"Write"}, 300))
+
+	hookData := buildHookDataJSON(HookData{
+		SessionID:      "test-session-routes",
+		TranscriptPath: transcriptPath,
+		CWD:            "/work/api",
+	})
+
+	if err := handler.HandleHook("Stop", hookData); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !mockNotif.wasCalled() {
+		t.Error("desktop without a route should always be notified")
+	}
+	if mockWH.wasCalled() {
+		t.Error("webhook routed to question should not receive task_complete")
+	}
+	if !extraAPI.wasCalled() {
+		t.Error("extra webhook routed to project api should be notified")
+	}
+	if extraOther.wasCalled() {
+		t.Error("extra webhook routed to web-* should not be notified")
+	}
+	if !extraAPI.wasShutdownCalled() || !extraOther.wasShutdownCalled() {
+		t.Error("extra webhook senders should be shut down before the hook exits")
+	}
+}
+
+func TestHandler_RoutesMinElapsedOnSessionDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		started time.Duration // How long ago the session started (0 = not registered)
+		want    bool
+	}{
+		{"long session", 15 * time.Minute, true},
+		{"short session", 2 * time.Minute, false},
+		{"unregistered session", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Notifications: config.NotificationsConfig{
+					Desktop: config.DesktopConfig{Enabled: true},
+				},
+				Statuses: map[string]config.StatusInfo{
+					"task_complete": {Title: "Task Complete"},
+				},
+			}
+			handler, _, _ := newTestHandler(t, cfg)
+			handler.sessionReg = sessions.NewRegistry(t.TempDir())
+			slack := &mockWebhook{}
+			handler.extraHooks = []extraWebhook{
+				{name: "team-slack", route: config.RouteConfig{MinElapsed: "10m"}, svc: slack},
+			}
+			if tt.started > 0 {
+				if err := handler.sessionReg.Start("test-session-long", "/work/api", "", time.Now().Add(-tt.started)); err != nil {
+					t.Fatal(err)
+				}
+				// A recent prompt must not count: the route measures the whole session
+				if err := handler.sessionReg.Prompt("test-session-long", "", 0, time.Now().Add(-time.Minute)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			handler.sendNotifications(hookInfo{event: "Stop"}, analyzer.StatusTaskComplete, "Done", "test-session-long", "/work/api", "")
+			if slack.wasCalled() != tt.want {
+				t.Errorf("webhook called = %v, want %v", slack.wasCalled(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler_RedactsRemoteBackends(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Notifications.Desktop.Enabled = true
+	cfg.Notifications.Webhook.Enabled = true
+	cfg.Notifications.Redact.Patterns = []string{`INTERNAL-\d+`}
+
+	handler, mockNotif, mockWH := newTestHandler(t, cfg)
+	handler.redactor = newRedactor(cfg)
+
+	secret := "sk-ant-REDACTED"
+	handler.newDispatcher().Dispatch(notifier.Event{
+		Status:  analyzer.StatusTaskComplete,
+		Title:   "INTERNAL-42 done",
+		Message: "Rotated " + secret,
+		Content: &config.ContentData{Title: "INTERNAL-42 done", Message: "Rotated " + secret},
+	})
+
+	if len(mockWH.calls) != 1 {
+		t.Fatalf("webhook calls = %d, want 1", len(mockWH.calls))
+	}
+	if call := mockWH.calls[0]; call.message != "Rotated [REDACTED]" || call.meta.Title != "[REDACTED] done" {
+		t.Errorf("webhook got message %q, title %q; want secrets redacted", call.message, call.meta.Title)
+	}
+	if len(mockNotif.calls) != 1 || mockNotif.calls[0].message != "Rotated "+secret {
+		t.Errorf("local desktop notifications should not be redacted: %+v", mockNotif.calls)
+	}
+
+	handler.redactor = nil
+	mockWH.calls = nil
+	handler.newDispatcher().Dispatch(notifier.Event{Status: analyzer.StatusTaskComplete, Message: "Rotated " + secret})
+	if len(mockWH.calls) != 1 || mockWH.calls[0].message != "Rotated "+secret {
+		t.Errorf("disabled redaction should keep the message: %+v", mockWH.calls)
+	}
+}
+
+// fakePlugin records the events a plugin receives
+type fakePlugin struct {
+	name   string
+	mu     sync.Mutex
+	events []notifier.Event
+}
+
+func (p *fakePlugin) Name() string { return p.name }
+
+func (p *fakePlugin) Notify(ev notifier.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, ev)
+	return nil
+}
+
+func TestHandler_SendsToPlugins(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Notifications.Desktop.Enabled = false
+	cfg.Notifications.Plugins = config.PluginsConfig{
+		Enabled: true,
+		Settings: map[string]config.PluginSettings{
+			"led":  {Privacy: config.PrivacyTitleOnly},
+			"chat": {Route: config.RouteConfig{Statuses: []string{"question"}}},
+		},
+	}
+
+	handler, _, _ := newTestHandler(t, cfg)
+	handler.redactor = newRedactor(cfg)
+	led, chat := &fakePlugin{name: "plugin:led"}, &fakePlugin{name: "plugin:chat"}
+	handler.plugins = []plugin.Notifier{led, chat}
+	handler.pluginHost = &plugin.Host{}
+
+	sent := handler.newDispatcher().Dispatch(notifier.Event{
+		Status:  analyzer.StatusTaskComplete,
+		Message: "Rotated sk-ant-REDACTED",
+		Project: "api",
+	})
+	if err := handler.pluginHost.Shutdown(5 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(sent, ",") != "plugin:led" {
+		t.Errorf("sent to %v, want plugin:led only (chat is routed to questions)", sent)
+	}
+	if len(led.events) != 1 || led.events[0].Message != "api needs attention" {
+		t.Errorf("led got %+v, want the title-only message", led.events)
+	}
+	if len(chat.events) != 0 {
+		t.Errorf("chat got %+v, want nothing", chat.events)
+	}
+
+	handler.newDispatcher().Dispatch(notifier.Event{Status: analyzer.StatusQuestion, Message: "Use token sk-ant-REDACTED?"})
+	if err := handler.pluginHost.Shutdown(5 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if len(chat.events) != 1 || chat.events[0].Message != "Use token [REDACTED]?" {
+		t.Errorf("chat got %+v, want the redacted question", chat.events)
+	}
+}
+
+func TestHandler_AppliesScript(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notify.star")
+	src := `
+def transform(event):
+    if event["project"] == "scratch":
+        return False
+    event["message"] = "@channel " + event["message"]
+    event["priority"] = "critical"
+`
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+			Script:  config.ScriptConfig{File: path},
+		},
+		Statuses: map[string]config.StatusInfo{"task_complete": {Title: "Task Complete"}},
+	}
+
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	handler.script = newScript(cfg)
+	transcriptPath := createTempTranscript(t, buildTranscriptWithTools([]string{"Write"}, 300))
+
+	if err := handler.HandleHook("Stop", buildHookDataJSON(HookData{SessionID: "test-session-script", TranscriptPath: transcriptPath, CWD: "/work/api"})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	call := mockNotif.lastCall()
+	if call == nil {
+		t.Fatal("expected desktop notification")
+	}
+	if !strings.Contains(call.message, "api] @channel ") || call.opts.Urgency != "critical" {
+		t.Errorf("desktop got message %q, options %+v; want the script's changes", call.message, call.opts)
+	}
+
+	mockNotif.calls = nil
+	if err := handler.HandleHook("Stop", buildHookDataJSON(HookData{SessionID: "test-session-script-2", TranscriptPath: transcriptPath, CWD: "/work/scratch"})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mockNotif.calls) != 0 {
+		t.Errorf("script should suppress scratch notifications, got %+v", mockNotif.calls)
+	}
+}
+
+func TestHandler_EncryptsWebhook(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Notifications.Webhook.Enabled = true
+	cfg.Notifications.Webhook.Encrypt.Key = "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="
+
+	handler, _, mockWH := newTestHandler(t, cfg)
+	handler.newDispatcher().Dispatch(notifier.Event{
+		Status:  analyzer.StatusTaskComplete,
+		Message: "Deployed v2 to prod",
+		Project: "api",
+		Content: &config.ContentData{Title: "✅ Completed", Message: "Deployed v2 to prod", Project: "api"},
+	})
+
+	if len(mockWH.calls) != 1 {
+		t.Fatalf("webhook calls = %d, want 1", len(mockWH.calls))
+	}
+	call := mockWH.calls[0]
+	if !strings.HasPrefix(call.message, "cnseal1:") || call.meta.Project != "" || call.meta.Title != "🔒 Claude Code" {
+		t.Errorf("webhook got message %q, meta %+v; want the content encrypted", call.message, call.meta)
+	}
+}
+
+func TestHandler_AppliesRules(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+			Webhook: config.WebhookConfig{Enabled: true},
+			Rules: []config.Rule{
+				{
+					Name:    "api alerts",
+					Match:   config.RuleMatch{Projects: []string{"api"}},
+					Actions: config.RuleActions{Urgency: "critical", Sound: "none", Title: "🚨 {{.Title}}", Backends: []string{"desktop"}},
+				},
+			},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete": {Title: "Task Complete"},
+		},
+	}
+
+	handler, mockNotif, mockWH := newTestHandler(t, cfg)
+
+	transcriptPath := createTempTranscript(t,
+		buildTranscriptWithTools([]string{"Write"}, 300))
+	hookData := buildHookDataJSON(HookData{
+		SessionID:      "test-session-rules",
+		TranscriptPath: transcriptPath,
+		CWD:            "/work/api",
+	})
+
+	if err := handler.HandleHook("Stop", hookData); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	call := mockNotif.lastCall()
+	if call == nil {
+		t.Fatal("expected desktop notification")
+	}
+	want := notifier.Options{Title: "🚨 Task Complete", Sound: "none", Urgency: "critical"}
+	if call.opts != want {
+		t.Errorf("desktop options = %+v, want %+v", call.opts, want)
+	}
+	if mockWH.wasCalled() {
+		t.Error("rule routes to desktop only; webhook should not be called")
+	}
+}
+
+func TestHandler_RulePriorityReachesEveryBackend(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+			Webhook: config.WebhookConfig{Enabled: true},
+			Rules: []config.Rule{
+				{Match: config.RuleMatch{Projects: []string{"api"}}, Actions: config.RuleActions{Urgency: "critical"}},
+			},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete": {Title: "Task Complete"},
+		},
+	}
+
+	handler, mockNotif, mockWH := newTestHandler(t, cfg)
+	transcriptPath := createTempTranscript(t,
+		buildTranscriptWithTools([]string{"Write"}, 300))
+	hookData := buildHookDataJSON(HookData{
+		SessionID:      "test-session-rule-priority",
+		TranscriptPath: transcriptPath,
+		CWD:            "/work/api",
+	})
+	if err := handler.HandleHook("Stop", hookData); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if call := mockNotif.lastCall(); call == nil || call.opts.Urgency != "critical" {
+		t.Errorf("desktop call = %+v, want critical urgency", call)
+	}
+	if !mockWH.wasCalled() || mockWH.calls[0].meta.Priority != "critical" {
+		t.Errorf("webhook calls = %+v, want critical priority", mockWH.calls)
+	}
+}
+
+func TestHandler_RuleMatchesHookEvent(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+			Rules: []config.Rule{
+				{
+					Name:    "permission requests",
+					Match:   config.RuleMatch{Events: []string{"Notification"}},
+					Actions: config.RuleActions{Urgency: "critical", Title: "🔐 {{.Title}} ({{.Event}})"},
+				},
+			},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"question":      {Title: "Question"},
+			"task_complete": {Title: "Task Complete"},
+		},
+	}
+
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	hookData := buildHookDataJSON(HookData{
+		SessionID: "test-session-rule-event",
+		CWD:       "/work/api",
+	})
+	if err := handler.HandleHook("Notification", hookData); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	call := mockNotif.lastCall()
+	if call == nil {
+		t.Fatal("expected desktop notification")
+	}
+	want := notifier.Options{Title: "🔐 Question (Notification)", Urgency: "critical"}
+	if call.opts != want {
+		t.Errorf("desktop options = %+v, want %+v", call.opts, want)
+	}
+
+	// The rule does not apply to other events
+	handler.sendNotifications(hookInfo{event: "Stop"}, analyzer.StatusTaskComplete, "Done", "test-session-rule-event-2", "/work/api", "")
+	if call := mockNotif.lastCall(); call == nil || call.opts != (notifier.Options{}) {
+		t.Errorf("Stop notification options = %+v, want none", call)
+	}
+}
+
+func TestHandler_ContentTemplates(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{
+				Enabled: true,
+				Content: config.ContentConfig{Body: "{{.Message}} ({{.Event}}{{if .ToolName}}: {{.ToolName}}{{end}})"},
+			},
+			Webhook: config.WebhookConfig{Enabled: true},
+			Content: config.ContentConfig{Title: "{{.Project}} · {{.Title}}"},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"tool_use": {Title: "Tool Use"},
+		},
+	}
+
+	handler, mockNotif, mockWH := newTestHandler(t, cfg)
+	handler.sendNotifications(hookInfo{event: "PreToolUse", toolName: "Bash"}, analyzer.StatusToolUse, "Bash: ls", "test-session-content", "/work/api", "")
+
+	call := mockNotif.lastCall()
+	if call == nil {
+		t.Fatal("expected desktop notification")
+	}
+	if call.opts.Title != "api · Tool Use" {
+		t.Errorf("desktop title = %q, want the global title template", call.opts.Title)
+	}
+	if call.message != "Bash: ls (PreToolUse: Bash)" {
+		t.Errorf("desktop message = %q, want the desktop body template", call.message)
+	}
+
+	if len(mockWH.calls) != 1 {
+		t.Fatalf("webhook calls = %d, want 1", len(mockWH.calls))
+	}
+	wh := mockWH.calls[0]
+	if wh.meta.Title != "api · Tool Use" {
+		t.Errorf("webhook title = %q", wh.meta.Title)
+	}
+	if !strings.HasSuffix(wh.message, " api] Bash: ls") {
+		t.Errorf("webhook message = %q, want the built-in body", wh.message)
+	}
+}
+
+func TestHandler_GitContext(t *testing.T) {
+	repo := filepath.Join(t.TempDir(), "myrepo")
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(repo, "web"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, ".git", "HEAD"), []byte("ref: refs/heads/feature/auth\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+			Webhook: config.WebhookConfig{Enabled: true},
+			Content: config.ContentConfig{Title: "{{.Repo}} @ {{.Branch}}: {{.Title}}"},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete": {Title: "Claude finished"},
+		},
+	}
+
+	handler, mockNotif, mockWH := newTestHandler(t, cfg)
+	handler.sendNotifications(hookInfo{event: "Stop"}, analyzer.StatusTaskComplete, "Done", "test-session-git", filepath.Join(repo, "web"), "")
+
+	call := mockNotif.lastCall()
+	if call == nil {
+		t.Fatal("expected desktop notification")
+	}
+	if call.opts.Title != "myrepo @ feature/auth: Claude finished" {
+		t.Errorf("title = %q", call.opts.Title)
+	}
+	if !strings.HasSuffix(call.message, "|feature/auth myrepo/web] Done") {
+		t.Errorf("message = %q, want the branch and repo/folder prefix", call.message)
+	}
+	if len(mockWH.calls) != 1 || mockWH.calls[0].meta.Repo != "myrepo" || mockWH.calls[0].meta.Branch != "feature/auth" {
+		t.Errorf("webhook calls = %+v, want repo and branch in meta", mockWH.calls)
+	}
+}
+
+func TestHandler_RuleSuppresses(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+			Webhook: config.WebhookConfig{Enabled: true},
+			Rules: []config.Rule{
+				{Match: config.RuleMatch{Projects: []string{"scratch-*"}}, Actions: config.RuleActions{Suppress: true}},
+			},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete": {Title: "Task Complete"},
+		},
+	}
+
+	handler, mockNotif, mockWH := newTestHandler(t, cfg)
+
+	transcriptPath := createTempTranscript(t,
+		buildTranscriptWithTools([]string{"Write"}, 300))
+	hookData := buildHookDataJSON(HookData{
+		SessionID:      "test-session-rule-suppress",
+		TranscriptPath: transcriptPath,
+		CWD:            "/tmp/scratch-1",
+	})
+
+	if err := handler.HandleHook("Stop", hookData); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mockNotif.wasCalled() || mockWH.wasCalled() {
+		t.Error("suppressing rule should drop the notification on every backend")
+	}
+}
+
+func TestHandler_DNDQueuesAndSendsDigest(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+			Webhook: config.WebhookConfig{Enabled: true},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete": {Title: "✅ Completed"},
+		},
+	}
+	cfg.ApplyDefaults()
+
+	handler, mockNotif, mockWH := newTestHandler(t, cfg)
+	handler.dndMgr = dnd.NewManager(t.TempDir(), cfg.Notifications.DND)
+	if err := handler.dndMgr.On(time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	handler.sendNotifications(hookInfo{event: "Stop"}, analyzer.StatusTaskComplete, "Built the parser", "test-session-dnd", "/work/api", "")
+	if mockNotif.wasCalled() || mockWH.wasCalled() {
+		t.Fatal("notifications should be held back while do-not-disturb is on")
+	}
+
+	if err := handler.dndMgr.Off(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	handler.sendNotifications(hookInfo{event: "Stop"}, analyzer.StatusTaskComplete, "Added tests", "test-session-dnd", "/work/api", "")
+
+	if got := mockNotif.callCount(); got != 2 {
+		t.Fatalf("desktop calls = %d, want digest plus the new notification", got)
+	}
+	digest := mockNotif.calls[0]
+	if digest.opts.Title != "🔕 1 notification during do-not-disturb" {
+		t.Errorf("digest title = %q", digest.opts.Title)
+	}
+	if !strings.Contains(digest.message, "✅ Completed · api: Built the parser") {
+		t.Errorf("digest message = %q", digest.message)
+	}
+	if len(mockWH.calls) != 2 || mockWH.calls[0].meta.Title != digest.opts.Title {
+		t.Errorf("webhook should receive the digest first, got %+v", mockWH.calls)
+	}
+}
+
+func TestHandler_DNDDowngrade(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+			DND:     config.DNDConfig{Mode: "downgrade"},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete": {Title: "✅ Completed"},
+		},
+	}
+
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	handler.dndMgr = dnd.NewManager(t.TempDir(), cfg.Notifications.DND)
+	if err := handler.dndMgr.On(time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	handler.sendNotifications(hookInfo{event: "Stop"}, analyzer.StatusTaskComplete, "Done", "test-session-dnd-low", "/work/api", "")
+
+	call := mockNotif.lastCall()
+	if call == nil {
+		t.Fatal("downgrade mode should still deliver")
+	}
+	if call.opts.Urgency != "low" || call.opts.Sound != "none" {
+		t.Errorf("options = %+v, want low urgency and no sound", call.opts)
+	}
+}
+
+func TestHandler_MutedProjectAndRuleHits(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+			Rules:   []config.Rule{{Name: "quiet-api", Match: config.RuleMatch{Projects: []string{"api"}}, Actions: config.RuleActions{Sound: "none"}}},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete": {Title: "✅ Completed"},
+		},
+	}
+
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	dir := t.TempDir()
+	handler.dndMgr = dnd.NewManager(dir, cfg.Notifications.DND)
+	handler.ruleHits = rules.NewHitStore(dir)
+	if err := handler.dndMgr.MuteProject("web", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	handler.sendNotifications(hookInfo{event: "Stop"}, analyzer.StatusTaskComplete, "Done", "test-session-muted", "/work/web", "")
+	if mockNotif.wasCalled() {
+		t.Fatal("a muted project should not be notified")
+	}
+
+	handler.sendNotifications(hookInfo{event: "Stop"}, analyzer.StatusTaskComplete, "Done", "test-session-muted", "/work/api", "")
+	if !mockNotif.wasCalled() {
+		t.Fatal("other projects should still be notified")
+	}
+	hits, err := handler.ruleHits.Hits()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := hits["quiet-api"]; h.Count != 1 || h.LastStatus != "task_complete" || h.LastProject != "api" {
+		t.Errorf("rule hits = %+v, want one match for api", hits)
+	}
+}
+
+func TestHandler_MarksNotifiedSession(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{Desktop: config.DesktopConfig{Enabled: true}},
+		Statuses:      map[string]config.StatusInfo{"question": {Title: "❓ Question"}},
+	}
+	handler, _, _ := newTestHandler(t, cfg)
+	handler.notified = sessions.NewNotified(t.TempDir())
+
+	before := time.Now()
+	handler.sendNotifications(hookInfo{event: "Notification"}, analyzer.StatusQuestion, "Pick one", "test-session-marked", "/work/api", "")
+
+	marks, err := handler.notified.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if at := marks["test-session-marked"]; at.Before(before) {
+		t.Errorf("session marked at %v, want after %v", at, before)
+	}
+}
+
+// === NewHandler Constructor Tests ===
+
+func TestNewHandler_Success(t *testing.T) {
+	setTestHome(t, t.TempDir()) // isolate stable config path
+
+	// Create temp plugin root with valid config
+	tmpDir := t.TempDir()
+
+	// Create config directory and file (expected path: pluginRoot/config/config.json)
+	configDir := filepath.Join(tmpDir, "config")
+	err := os.MkdirAll(configDir, 0755)
+	if err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+
+	configPath := filepath.Join(configDir, "config.json")
+	configJSON := `{
+		"notifications": {
+			"desktop": {"enabled": true, "sound": true},
+			"webhook": {"enabled": false}
+		},
+		"statuses": {
+			"task_complete": {"title": "Task Complete"}
+		}
+	}`
+
+	err = os.WriteFile(configPath, []byte(configJSON), 0644)
+	if err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	// Create handler
+	handler, err := NewHandler(tmpDir)
+
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+
+	if handler == nil {
+		t.Fatal("handler is nil")
+	}
+
+	// Verify handler components
+	if handler.cfg == nil {
+		t.Error("handler.cfg is nil")
+	}
+	if handler.dedupMgr == nil {
+		t.Error("handler.dedupMgr is nil")
+	}
+	if handler.stateMgr == nil {
+		t.Error("handler.stateMgr is nil")
+	}
+	if handler.notifierSvc == nil {
+		t.Error("handler.notifierSvc is nil")
+	}
+	if handler.webhookSvc == nil {
+		t.Error("handler.webhookSvc is nil")
+	}
+	if handler.pluginRoot != tmpDir {
+		t.Errorf("handler.pluginRoot = %s, want %s", handler.pluginRoot, tmpDir)
+	}
+
+	// Verify the test config was actually loaded (not just defaults)
+	info, exists := handler.cfg.GetStatusInfo("task_complete")
+	if !exists {
+		t.Error("expected task_complete status to exist")
+	} else if info.Title != "Task Complete" {
+		t.Errorf("expected task_complete title 'Task Complete' from test config, got %q", info.Title)
+	}
+}
+
+func TestNewHandler_WithDefaultConfig(t *testing.T) {
+	setTestHome(t, t.TempDir()) // isolate stable config path
+
+	// Create empty plugin root (no config file)
+	tmpDir := t.TempDir()
+
+	// NewHandler should use default config
+	handler, err := NewHandler(tmpDir)
+
+	if err != nil {
+		t.Fatalf("NewHandler with defaults failed: %v", err)
+	}
+
+	if handler == nil {
+		t.Fatal("handler is nil")
 	}
 
 	// Verify default config was loaded
@@ -1932,3 +3333,541 @@ func TestHandler_SuppressFilter_NonMatchingFilter_SendsNotification(t *testing.T
 		t.Error("expected notification when suppress-filter does not match")
 	}
 }
+
+func TestHandler_RecordsHistory(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+			Webhook: config.WebhookConfig{Enabled: true},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete": {Title: "✅ Completed"},
+		},
+	}
+	cfg.ApplyDefaults()
+
+	handler, _, mockWH := newTestHandler(t, cfg)
+	handler.history = history.NewStore(t.TempDir(), 0)
+	mockWH.err = errors.New("HTTP 500")
+
+	handler.sendNotifications(hookInfo{event: "Stop"}, analyzer.StatusTaskComplete, "Built the parser", "test-session-history", "/work/api", "")
+
+	entries, err := handler.history.Query(history.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("history has %d entries, want one per backend: %+v", len(entries), entries)
+	}
+	desktop, hook := entries[0], entries[1]
+	if desktop.Backend != "desktop" || desktop.Result != history.ResultDelivered {
+		t.Errorf("desktop entry = %+v", desktop)
+	}
+	if desktop.Event != "task_complete" || desktop.Title != "✅ Completed" || desktop.Project != "api" || desktop.SessionID != "test-session-history" {
+		t.Errorf("desktop entry fields = %+v", desktop)
+	}
+	if hook.Backend != "webhook" || hook.Result != history.ResultFailed || hook.Error != "HTTP 500" {
+		t.Errorf("webhook entry = %+v", hook)
+	}
+}
+
+func TestHandler_CircuitBreakerSkipsFailingBackend(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+			Webhook: config.WebhookConfig{Enabled: true},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete": {Title: "✅ Completed"},
+		},
+	}
+	cfg.ApplyDefaults()
+
+	handler, mockNotif, mockWH := newTestHandler(t, cfg)
+	handler.history = history.NewStore(t.TempDir(), 0)
+	handler.breakers = breaker.NewStore(t.TempDir(), breaker.Settings{Failures: 2, Backoff: time.Hour, MaxBackoff: time.Hour})
+	mockWH.err = errors.New("HTTP 503")
+
+	for i := 0; i < 3; i++ {
+		sessionID := fmt.Sprintf("test-session-breaker-%d", i)
+		handler.sendNotifications(hookInfo{event: "Stop"}, analyzer.StatusTaskComplete, "Done", sessionID, "/work/api", "")
+	}
+
+	mockWH.mu.Lock()
+	calls := len(mockWH.calls)
+	mockWH.mu.Unlock()
+	if calls != 2 {
+		t.Errorf("webhook sent %d times, want 2 before the breaker opened", calls)
+	}
+	if mockNotif.callCount() != 3 {
+		t.Errorf("desktop sent %d times, want every notification", mockNotif.callCount())
+	}
+
+	entries, err := handler.history.Query(history.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := entries[len(entries)-1]
+	if last.Backend != "webhook" || last.Result != history.ResultSkipped || !strings.Contains(last.Error, "circuit breaker open") {
+		t.Errorf("last history entry = %+v, want a skipped webhook delivery", last)
+	}
+}
+
+func TestHandler_TracksSessions(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete": {Title: "✅ Completed"},
+		},
+	}
+
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	handler.sessionReg = sessions.NewRegistry(t.TempDir())
+	t.Setenv("TERM_PROGRAM", "WezTerm")
+
+	start := buildHookDataJSON(HookData{SessionID: "test-session-track", CWD: "/work/api"})
+	if err := handler.HandleHook("SessionStart", start); err != nil {
+		t.Fatalf("SessionStart: %v", err)
+	}
+	if mockNotif.wasCalled() {
+		t.Error("SessionStart should not notify")
+	}
+	s, err := handler.sessionReg.Get("test-session-track")
+	if err != nil || s == nil {
+		t.Fatalf("session not registered: %v", err)
+	}
+	if s.Project() != "api" || s.Terminal != "WezTerm" {
+		t.Errorf("session = %+v", s)
+	}
+
+	// Re-register as if the session started 12m34s ago
+	handler.sessionReg.End("test-session-track")
+	if err := handler.sessionReg.Start("test-session-track", "/work/api", "WezTerm", time.Now().Add(-12*time.Minute-34*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	handler.sendNotifications(hookInfo{event: "Stop"}, analyzer.StatusTaskComplete, "Built the parser", "test-session-track", "/work/api", "")
+	if got := mockNotif.callCount(); got != 1 {
+		t.Fatalf("desktop calls = %d, want 1", got)
+	}
+	if msg := mockNotif.calls[0].message; !strings.HasSuffix(msg, "Built the parser · session ran for 12m34s") {
+		t.Errorf("message = %q, want the session duration", msg)
+	}
+
+	end := buildHookDataJSON(HookData{SessionID: "test-session-track", CWD: "/work/api"})
+	if err := handler.HandleHook("SessionEnd", end); err != nil {
+		t.Fatalf("SessionEnd: %v", err)
+	}
+	if s, _ := handler.sessionReg.Get("test-session-track"); s != nil {
+		t.Error("session should be removed on SessionEnd")
+	}
+}
+
+func TestHandler_UpdatesDaemonSessionMap(t *testing.T) {
+	var tracked []string
+	var untracked []string
+	savedTrack, savedUntrack := trackSession, untrackSession
+	trackSession = func(s *sessions.Session) { tracked = append(tracked, s.ID+" "+s.Project()) }
+	untrackSession = func(sessionID string) { untracked = append(untracked, sessionID) }
+	t.Cleanup(func() { trackSession, untrackSession = savedTrack, savedUntrack })
+
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+		},
+	}
+	handler, _, _ := newTestHandler(t, cfg)
+	handler.sessionReg = sessions.NewRegistry(t.TempDir())
+
+	for _, event := range []string{"SessionStart", "UserPromptSubmit", "SessionEnd"} {
+		data := buildHookDataJSON(HookData{SessionID: "test-session-map", CWD: "/work/api"})
+		if err := handler.HandleHook(event, data); err != nil {
+			t.Fatalf("%s: %v", event, err)
+		}
+	}
+	if len(tracked) != 2 || tracked[0] != "test-session-map api" {
+		t.Errorf("tracked = %v, want the session on SessionStart and UserPromptSubmit", tracked)
+	}
+	if len(untracked) != 1 || untracked[0] != "test-session-map" {
+		t.Errorf("untracked = %v, want the session on SessionEnd", untracked)
+	}
+
+	// Sessions the registry does not know are not passed on
+	tracked = nil
+	if err := handler.HandleHook("UserPromptSubmit", buildHookDataJSON(HookData{SessionID: "unknown"})); err != nil {
+		t.Fatal(err)
+	}
+	if len(tracked) != 0 {
+		t.Errorf("tracked = %v, want nothing for an unregistered session", tracked)
+	}
+}
+
+func TestHandler_CompactKeepsPinnedWindow(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true, ClickToFocus: true},
+		},
+	}
+
+	handler, _, _ := newTestHandler(t, cfg)
+	handler.sessionReg = sessions.NewRegistry(t.TempDir())
+	if err := handler.sessionReg.Start("test-session-pin", "/work/api", "kitty", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	pinned := &sessions.Window{Backend: "sway", ID: "42", Class: "kitty"}
+	if err := handler.sessionReg.Pin("test-session-pin", pinned); err != nil {
+		t.Fatal(err)
+	}
+
+	compact := buildHookDataJSON(HookData{SessionID: "test-session-pin", CWD: "/work/api", Source: "compact"})
+	if err := handler.HandleHook("SessionStart", compact); err != nil {
+		t.Fatalf("SessionStart: %v", err)
+	}
+	s, err := handler.sessionReg.Get("test-session-pin")
+	if err != nil || s == nil {
+		t.Fatalf("session not registered: %v", err)
+	}
+	if s.Window == nil || *s.Window != *pinned {
+		t.Errorf("window = %+v, want the pin kept after compaction", s.Window)
+	}
+}
+
+func TestHandler_AppliesProjectConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	cfg := config.DefaultConfig()
+	handler, _, _ := newTestHandler(t, cfg)
+	handler.sessionReg = sessions.NewRegistry(t.TempDir())
+
+	project := t.TempDir()
+	sub := filepath.Join(project, "cmd", "tool")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	projectConfig := "[notifications.desktop]\nsound = false\nurgency = \"low\"\n\n[statuses.task_complete]\ntitle = \"Side project done\"\n"
+	if err := os.WriteFile(filepath.Join(project, config.ProjectConfigName), []byte(projectConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A hook from a subdirectory picks up the project root's config
+	if err := handler.HandleHook("SessionStart", buildHookDataJSON(HookData{SessionID: "test-session-project", CWD: sub})); err != nil {
+		t.Fatal(err)
+	}
+	if handler.cfg == cfg {
+		t.Fatal("handler should switch to the project config")
+	}
+	if handler.cfg.Notifications.Desktop.Sound || handler.cfg.Notifications.Desktop.Urgency != "low" {
+		t.Errorf("desktop config = %+v", handler.cfg.Notifications.Desktop)
+	}
+	if info, _ := handler.cfg.GetStatusInfo("task_complete"); info.Title != "Side project done" {
+		t.Errorf("task_complete title = %q", info.Title)
+	}
+	if info, _ := handler.cfg.GetStatusInfo("question"); info.Title != cfg.Statuses["question"].Title {
+		t.Errorf("statuses the project leaves out should keep their config, got %q", info.Title)
+	}
+}
+
+func TestHandler_IgnoresInvalidProjectConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	cfg := config.DefaultConfig()
+	handler, _, _ := newTestHandler(t, cfg)
+	handler.sessionReg = sessions.NewRegistry(t.TempDir())
+
+	project := t.TempDir()
+	os.WriteFile(filepath.Join(project, config.ProjectConfigName), []byte("[notifications.desktop]\nurgency = \"loud\"\n"), 0644)
+
+	if err := handler.HandleHook("SessionStart", buildHookDataJSON(HookData{SessionID: "test-session-project-invalid", CWD: project})); err != nil {
+		t.Fatal(err)
+	}
+	if handler.cfg != cfg {
+		t.Error("an invalid project config should be ignored")
+	}
+}
+
+func TestNewWebhookQueue(t *testing.T) {
+	home := t.TempDir()
+	setTestHome(t, home)
+
+	cfg := config.DefaultConfig()
+	if q := newWebhookQueue(cfg); q != nil {
+		t.Errorf("newWebhookQueue() = %v with no webhook enabled, want nil", q.Path())
+	}
+
+	cfg.Notifications.Webhooks = []config.WebhookConfig{{Enabled: true, URL: "https://example.com/hook"}}
+	q := newWebhookQueue(cfg)
+	if q == nil {
+		t.Fatal("newWebhookQueue() = nil with an extra webhook enabled")
+	}
+	want := filepath.Join(home, ".claude", "claude-notifications-go", webhook.QueueFileName)
+	if q.Path() != want {
+		t.Errorf("queue path = %q, want %q", q.Path(), want)
+	}
+
+	// Retrying an empty queue is a no-op
+	h := &Handler{webhookQ: q}
+	h.retryQueuedWebhooks()
+	h.webhookQWG.Wait()
+	if _, err := os.Stat(want); !os.IsNotExist(err) {
+		t.Errorf("retry created the queue file, stat error = %v", err)
+	}
+}
+
+func TestHandler_Heartbeat(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop:   config.DesktopConfig{Enabled: true},
+			Heartbeat: config.HeartbeatConfig{Enabled: true, Interval: "1m"},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"still_working": {Title: "Still Working"},
+		},
+	}
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	spawned := stubWatcher(t, handler)
+
+	// Sessions the registry does not know get no heartbeats
+	if err := handler.HandleHook("UserPromptSubmit", buildHookDataJSON(HookData{SessionID: "unknown"})); err != nil {
+		t.Fatal(err)
+	}
+	if len(*spawned) != 0 {
+		t.Fatalf("spawned = %v, want nothing for an unregistered session", *spawned)
+	}
+
+	transcript := createTempTranscript(t, buildTranscriptWithTools([]string{"Write"}, 300))
+	for _, event := range []string{"SessionStart", "UserPromptSubmit"} {
+		data := buildHookDataJSON(HookData{SessionID: "test-session-heartbeat", CWD: "/work/api", TranscriptPath: transcript})
+		if err := handler.HandleHook(event, data); err != nil {
+			t.Fatalf("%s: %v", event, err)
+		}
+	}
+	if len(*spawned) != 1 {
+		t.Fatalf("spawned = %v, want one worker", *spawned)
+	}
+
+	// Move the prompt 90 seconds into the past so the first heartbeat is due
+	s, _ := handler.sessionReg.Get("test-session-heartbeat")
+	s.PromptAt = time.Now().Add(-90 * time.Second)
+	if err := handler.sessionReg.Put(s); err != nil {
+		t.Fatal(err)
+	}
+	id := watchID(s.PromptAt)
+	sleep = func(time.Duration) { handler.finishPrompt("test-session-heartbeat") }
+
+	if err := handler.WatchPrompt("test-session-heartbeat", id); err != nil {
+		t.Fatalf("WatchPrompt: %v", err)
+	}
+	if n := mockNotif.callCount(); n != 1 {
+		t.Fatalf("got %d notifications, want one heartbeat", n)
+	}
+	call := mockNotif.lastCall()
+	if call.status != analyzer.StatusStillWorking || !strings.HasSuffix(call.message, "Claude still working on api, 1m elapsed") {
+		t.Errorf("got %v %q", call.status, call.message)
+	}
+	if call.opts.Replace != "working-test-session-heartbeat" {
+		t.Errorf("Replace = %q, want the session's heartbeat key", call.opts.Replace)
+	}
+	if len(mockNotif.dismissed) != 1 || mockNotif.dismissed[0] != "working-test-session-heartbeat" {
+		t.Errorf("dismissed = %v, want the last heartbeat closed when the prompt finished", mockNotif.dismissed)
+	}
+
+	// A worker of a replaced prompt exits without notifying
+	if err := handler.WatchPrompt("test-session-heartbeat", "old"); err != nil {
+		t.Fatal(err)
+	}
+	if n := mockNotif.callCount(); n != 1 {
+		t.Errorf("got %d notifications, want none from a replaced worker", n-1)
+	}
+}
+
+// stubWatcher keeps sessions in a temporary registry, pins the Claude Code
+// process to the test and records the prompt watchers that would be started
+func stubWatcher(t *testing.T, h *Handler) *[]string {
+	t.Helper()
+	h.sessionReg = sessions.NewRegistry(t.TempDir())
+	t.Setenv(daemon.ClaudeEnv, strconv.Itoa(os.Getpid()))
+	var spawned []string
+	origSpawn, origSleep := spawnWatcher, sleep
+	spawnWatcher = func(sessionID, id string) error {
+		spawned = append(spawned, id)
+		return nil
+	}
+	t.Cleanup(func() { spawnWatcher, sleep = origSpawn, origSleep })
+	return &spawned
+}
+
+// quietPrompt starts a prompt of a registered session whose last hook
+// event and transcript write lie quiet in the past, and returns the ID of
+// its watcher
+func quietPrompt(t *testing.T, h *Handler, sessionID string, quiet time.Duration) string {
+	t.Helper()
+	transcript := createTempTranscript(t, buildTranscriptWithTools([]string{"Bash"}, 300))
+	for _, event := range []string{"SessionStart", "UserPromptSubmit"} {
+		data := buildHookDataJSON(HookData{SessionID: sessionID, CWD: "/work/api", TranscriptPath: transcript})
+		if err := h.HandleHook(event, data); err != nil {
+			t.Fatalf("%s: %v", event, err)
+		}
+	}
+	past := time.Now().Add(-quiet)
+	if err := os.Chtimes(transcript, past, past); err != nil {
+		t.Fatal(err)
+	}
+	s, _ := h.sessionReg.Get(sessionID)
+	s.PromptAt, s.LastActivity = past, past
+	if err := h.sessionReg.Put(s); err != nil {
+		t.Fatal(err)
+	}
+	return watchID(past)
+}
+
+func TestHandler_WatchPromptStalled(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+			Stall:   config.StallConfig{Enabled: true, After: "10m"},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"session_stalled": {Title: "Session Stalled"},
+		},
+	}
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	spawned := stubWatcher(t, handler)
+
+	id := quietPrompt(t, handler, "test-session-stalled", 12*time.Minute)
+	if len(*spawned) != 1 {
+		t.Fatalf("spawned = %v, want a watcher with only stall detection enabled", *spawned)
+	}
+	polls := 0
+	sleep = func(time.Duration) {
+		if polls++; polls == 2 {
+			handler.finishPrompt("test-session-stalled")
+		}
+	}
+	if err := handler.WatchPrompt("test-session-stalled", id); err != nil {
+		t.Fatalf("WatchPrompt: %v", err)
+	}
+	if n := mockNotif.callCount(); n != 1 {
+		t.Fatalf("got %d notifications, want one stall alert for one stall", n)
+	}
+	call := mockNotif.lastCall()
+	if call.status != analyzer.StatusSessionStalled || !strings.HasSuffix(call.message, "Session may be stuck waiting for input: no activity for 12m") {
+		t.Errorf("got %v %q", call.status, call.message)
+	}
+
+	// No alert once Claude Code exited: the session did not stall, it is gone
+	if runtime.GOOS == "linux" || runtime.GOOS == "darwin" {
+		id = quietPrompt(t, handler, "test-session-exited", 12*time.Minute)
+		s, _ := handler.sessionReg.Get("test-session-exited")
+		s.ClaudePID = 1 << 30
+		if err := handler.sessionReg.Put(s); err != nil {
+			t.Fatal(err)
+		}
+		sleep = func(time.Duration) { t.Fatal("the watcher should stop") }
+		if err := handler.WatchPrompt("test-session-exited", id); err != nil {
+			t.Fatal(err)
+		}
+		if n := mockNotif.callCount(); n != 1 {
+			t.Errorf("got %d notifications, want no stall alert for an exited session", n-1)
+		}
+	}
+}
+
+func TestHandler_Progress(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop:  config.DesktopConfig{Enabled: true},
+			Progress: config.ProgressConfig{Enabled: true, Interval: "1h"},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"in_progress": {Title: "In Progress"},
+		},
+	}
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	spawned := stubWatcher(t, handler)
+
+	hook := func(event, tool, input string) {
+		t.Helper()
+		data := HookData{SessionID: "test-session-progress", CWD: "/work/api", ToolName: tool}
+		if input != "" {
+			data.ToolInput = json.RawMessage(input)
+		}
+		if err := handler.HandleHook(event, buildHookDataJSON(data)); err != nil {
+			t.Fatalf("%s: %v", event, err)
+		}
+	}
+	hook("SessionStart", "", "")
+	hook("PostToolUse", "Read", "")
+	if mockNotif.wasCalled() {
+		t.Fatal("progress is counted per prompt, none is running")
+	}
+
+	hook("UserPromptSubmit", "", "")
+	if len(*spawned) != 0 {
+		t.Errorf("spawned = %v, progress needs no watcher", *spawned)
+	}
+	hook("PostToolUse", "Read", "")
+	hook("PostToolUse", "Bash", "") // Within progress.interval: counted, not shown
+	hook("PostToolUse", "TodoWrite", `{"todos":[
+		{"content":"Write the migration","status":"completed","activeForm":"Writing the migration"},
+		{"content":"Run the tests","status":"in_progress","activeForm":"Running the tests"},
+		{"content":"Update the docs","status":"pending","activeForm":"Updating the docs"}]}`)
+
+	var got []string
+	for _, call := range mockNotif.calls {
+		if call.status != analyzer.StatusInProgress || call.opts.Replace != "working-test-session-progress" {
+			t.Errorf("got %v replacing %q, want in_progress replacing the working notification", call.status, call.opts.Replace)
+		}
+		got = append(got, call.message[strings.Index(call.message, "Claude"):])
+	}
+	want := []string{"Claude working… 1 tool call, last: Read", "Claude working… step 2/3: Running the tests"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("progress = %q, want %q", got, want)
+	}
+	if s, _ := handler.sessionReg.Get("test-session-progress"); s.Progress == nil || s.Progress.Tools != 3 {
+		t.Errorf("progress = %+v, want 3 tool calls", s.Progress)
+	}
+
+	// The next prompt counts from the start
+	hook("UserPromptSubmit", "", "")
+	if s, _ := handler.sessionReg.Get("test-session-progress"); s.Progress != nil {
+		t.Errorf("progress = %+v after a new prompt, want none", s.Progress)
+	}
+}
+
+func TestProgressMessage(t *testing.T) {
+	tests := []struct {
+		p    sessions.Progress
+		want string
+	}{
+		{sessions.Progress{Tools: 12, LastTool: "Bash"}, "Claude working… 12 tool calls, last: Bash"},
+		{sessions.Progress{Tools: 4, Done: 2, Total: 7, Step: "Running the tests"}, "Claude working… step 3/7: Running the tests"},
+		{sessions.Progress{Tools: 4, Done: 2, Total: 7}, "Claude working… 2/7 steps done"},
+		{sessions.Progress{Tools: 9, Done: 7, Total: 7}, "Claude working… all 7 steps done"},
+	}
+	for _, tt := range tests {
+		if got := progressMessage(&tt.p); got != tt.want {
+			t.Errorf("progressMessage(%+v) = %q, want %q", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestFormatMinutes(t *testing.T) {
+	tests := map[time.Duration]string{
+		25*time.Minute + 40*time.Second: "25m",
+		65 * time.Minute:                "1h05m",
+		30 * time.Second:                "0m",
+	}
+	for d, want := range tests {
+		if got := formatMinutes(d); got != want {
+			t.Errorf("formatMinutes(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
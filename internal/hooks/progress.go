@@ -0,0 +1,111 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/sessions"
+)
+
+// todoInput is the input of the TodoWrite tool
+type todoInput struct {
+	Todos []struct {
+		Content    string `json:"content"`
+		Status     string `json:"status"` // "pending", "in_progress" or "completed"
+		ActiveForm string `json:"activeForm"`
+	} `json:"todos"`
+}
+
+// readTodos sets the todo progress of p from the input of a TodoWrite call
+func readTodos(p *sessions.Progress, input json.RawMessage) {
+	var in todoInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		logging.Debug("Invalid TodoWrite input: %v", err)
+		return
+	}
+	p.Done, p.Total, p.Step = 0, len(in.Todos), ""
+	for _, todo := range in.Todos {
+		switch todo.Status {
+		case "completed":
+			p.Done++
+		case "in_progress":
+			if p.Step == "" {
+				p.Step = todo.ActiveForm
+				if p.Step == "" {
+					p.Step = todo.Content
+				}
+			}
+		}
+	}
+}
+
+// progressStep identifies the todo step of p ("" = no todo list), so an
+// update that advances it is shown without waiting for progress.interval
+func progressStep(p *sessions.Progress) string {
+	if p.Total == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d %s", p.Done, p.Total, p.Step)
+}
+
+// progressMessage describes p: "Claude working… step 3/7: Running the
+// tests", or "Claude working… 12 tool calls, last: Bash" without a todo list
+func progressMessage(p *sessions.Progress) string {
+	switch {
+	case p.Total > 0 && p.Done >= p.Total:
+		return fmt.Sprintf("Claude working… all %d steps done", p.Total)
+	case p.Total > 0 && p.Step != "":
+		return fmt.Sprintf("Claude working… step %d/%d: %s", p.Done+1, p.Total, p.Step)
+	case p.Total > 0:
+		return fmt.Sprintf("Claude working… %d/%d steps done", p.Done, p.Total)
+	case p.Tools == 1:
+		return fmt.Sprintf("Claude working… 1 tool call, last: %s", p.LastTool)
+	}
+	return fmt.Sprintf("Claude working… %d tool calls, last: %s", p.Tools, p.LastTool)
+}
+
+// updateProgress counts a tool call of the session's prompt and updates
+// its progress notification in place when a todo step advanced or
+// progress.interval passed since the last update
+func (h *Handler) updateProgress(hookData *HookData) {
+	cfg := h.cfg.Notifications.Progress
+	if !cfg.Enabled || h.sessionReg == nil || isSubagentTranscript(hookData.TranscriptPath) {
+		return
+	}
+	s, err := h.sessionReg.Get(hookData.SessionID)
+	if err != nil || s == nil || !s.Working() {
+		return // Progress is counted per prompt
+	}
+	p := s.Progress
+	if p == nil {
+		p = &sessions.Progress{}
+	}
+	p.Tools++
+	p.LastTool = hookData.ToolName
+	if hookData.ToolName == "TodoWrite" {
+		readTodos(p, hookData.ToolInput)
+	}
+
+	now := time.Now()
+	step := progressStep(p)
+	show := step != p.ShownStep || now.Sub(p.ShownAt) >= cfg.IntervalDuration()
+	if show {
+		p.ShownAt, p.ShownStep = now, step
+	}
+	s.Progress = p
+	if h.dryRun {
+		h.tracef("Progress: %s (update shown: %v)", progressMessage(p), show)
+		return
+	}
+	// Save before sending, so the next hook counts on from this one
+	if err := h.sessionReg.Put(s); err != nil {
+		logging.Debug("Failed to save progress: %v", err)
+		return
+	}
+	if show {
+		h.sendWatchEvent(s, "PostToolUse", analyzer.StatusInProgress, progressMessage(p), cfg.GetBackends(), workingKey(s.ID))
+	}
+}
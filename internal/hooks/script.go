@@ -0,0 +1,81 @@
+package hooks
+
+import (
+	"strings"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/notifier"
+	"github.com/777genius/claude-notifications/internal/script"
+)
+
+// newScript loads the notification script; nil when none is configured or
+// it cannot be loaded
+func newScript(cfg *config.Config) *script.Script {
+	sc := cfg.Notifications.Script
+	if sc.File == "" {
+		return nil
+	}
+	s, err := script.Load(sc.File, sc.MaxSteps)
+	if err != nil {
+		logging.Warn("Script unavailable: %v", err)
+		return nil
+	}
+	return s
+}
+
+// applyScript runs the notification script on ev, which must have its
+// content set, and returns false when the script suppresses it. A failing
+// script leaves ev unchanged.
+func (h *Handler) applyScript(ev *notifier.Event) bool {
+	in := script.Event{
+		Status:    string(ev.Status),
+		HookEvent: ev.Content.Event,
+		Title:     ev.Content.Title,
+		Message:   ev.Content.Message,
+		Priority:  ev.Priority,
+		Sound:     ev.Sound,
+		Backends:  ev.Backends,
+		Project:   ev.Project,
+		Repo:      ev.Content.Repo,
+		Branch:    ev.Content.Branch,
+		Session:   ev.Content.Session,
+		SessionID: ev.SessionID,
+		CWD:       ev.CWD,
+		Tool:      ev.Content.ToolName,
+		Elapsed:   ev.Elapsed,
+		Time:      time.Now(),
+	}
+	out, keep, err := h.script.Run(in)
+	if err != nil {
+		logging.Warn("Script failed, sending the notification unchanged: %v", err)
+		return true
+	}
+	if !keep {
+		return false
+	}
+
+	if out.Title != in.Title {
+		content := *ev.Content
+		content.Title = out.Title
+		ev.Content = &content
+		ev.Title = out.Title
+	}
+	if out.Message != in.Message {
+		// The message keeps its session/branch/folder prefix
+		prefix := ev.Message
+		if strings.HasSuffix(prefix, in.Message) {
+			prefix = strings.TrimSuffix(prefix, in.Message)
+		} else {
+			prefix = ""
+		}
+		content := *ev.Content
+		content.Message = out.Message
+		ev.Content = &content
+		ev.Message = prefix + out.Message
+	}
+	ev.Priority, ev.Sound, ev.Backends = out.Priority, out.Sound, out.Backends
+	h.tracef("Script set title=%q priority=%q sound=%q backends=%s", ev.Title, ev.Priority, ev.Sound, traceList(ev.Backends))
+	return true
+}
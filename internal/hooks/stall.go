@@ -0,0 +1,24 @@
+package hooks
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/sessions"
+)
+
+// sendStall alerts that the session showed no activity for quiet while
+// Claude Code still runs. A question or plan notification sent since the
+// last activity already told the user, so no alert follows it: the alert
+// is for questions whose Notification hook never fired.
+func (h *Handler) sendStall(s *sessions.Session, lastActive time.Time, quiet time.Duration) {
+	if h.waitingForUser(s.ID, lastActive) {
+		logging.Debug("Session %s waits for an answer it was notified about, no stall alert", s.ID)
+		return
+	}
+	logging.Info("Session %s stalled: no activity for %v", s.ID, quiet.Round(time.Second))
+	message := fmt.Sprintf("Session may be stuck waiting for input: no activity for %s", formatMinutes(quiet))
+	h.sendWatchEvent(s, "Stall", analyzer.StatusSessionStalled, message, nil, "")
+}
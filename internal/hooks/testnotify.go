@@ -0,0 +1,226 @@
+package hooks
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/notifier"
+	"github.com/777genius/claude-notifications/internal/sessionname"
+)
+
+// testSessionID identifies test notifications in session labels and templates
+const testSessionID = "test-notification"
+
+// testEvents maps the event names "claude-notifications test" accepts,
+// besides status names, to the status a real hook would produce
+var testEvents = map[string]analyzer.Status{
+	"stop":         analyzer.StatusTaskComplete,
+	"subagentstop": analyzer.StatusTaskComplete,
+	"notification": analyzer.StatusQuestion,
+	"permission":   analyzer.StatusQuestion,
+	"plan":         analyzer.StatusPlanReady,
+	"review":       analyzer.StatusReviewComplete,
+	"limit":        analyzer.StatusSessionLimitReached,
+	"error":        analyzer.StatusAPIError,
+	"tool":         analyzer.StatusToolUse,
+	"budget":       analyzer.StatusBudgetExceeded,
+	"heartbeat":    analyzer.StatusStillWorking,
+	"stall":        analyzer.StatusSessionStalled,
+	"progress":     analyzer.StatusInProgress,
+}
+
+// TestOptions describes a notification synthesized by "claude-notifications test"
+type TestOptions struct {
+	Event    string   // Status or hook event, e.g. "stop" or "question" (empty = stop)
+	Backends []string // Backend names, or a webhook preset such as "ntfy" (empty = every enabled backend)
+	CWD      string   // Project directory, matched by route project globs
+	Project  string   // Project name shown in the notification (empty = folder of CWD)
+	Message  string   // Body (empty = a sample message)
+}
+
+// TestResult is the outcome of a test notification for one backend
+type TestResult struct {
+	Backend  string
+	Duration time.Duration // Time to deliver or fail
+	Err      error
+	Skipped  string // Why the backend was not sent the notification ("" = sent)
+}
+
+// TestStatus returns the status a test event name stands for
+func TestStatus(event string) (analyzer.Status, error) {
+	name := strings.ToLower(strings.TrimSpace(event))
+	if name == "" {
+		return analyzer.StatusTaskComplete, nil
+	}
+	if status, ok := testEvents[name]; ok {
+		return status, nil
+	}
+	if _, ok := config.DefaultConfig().Statuses[name]; ok {
+		return analyzer.Status(name), nil
+	}
+	events := make([]string, 0, len(testEvents))
+	for e := range testEvents {
+		events = append(events, e)
+	}
+	slices.Sort(events)
+	return "", fmt.Errorf("unknown event: %s (use a status such as task_complete, or one of: %s)", event, strings.Join(events, ", "))
+}
+
+// SendTest sends a synthesized notification to the chosen backends right
+// away, bypassing rules, do-not-disturb, digests, escalation and circuit
+// breakers, and waits for every delivery. Test deliveries are reported to
+// the caller instead of the history and metrics; a successful one closes
+// the backend's circuit breaker.
+func (h *Handler) SendTest(opts TestOptions) ([]TestResult, error) {
+	// Closed early to wait for deliveries, or on the way out after an error
+	closed := false
+	closeServices := func() {
+		if !closed {
+			closed = true
+			h.closeServices()
+		}
+	}
+	defer closeServices()
+
+	status, err := TestStatus(opts.Event)
+	if err != nil {
+		return nil, err
+	}
+	h.applyProjectConfig(opts.CWD)
+
+	var mu sync.Mutex
+	results := map[string]TestResult{}
+	h.onDelivery = func(backend string, d time.Duration, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[backend] = TestResult{Backend: backend, Duration: d, Err: err}
+	}
+	defer func() { h.onDelivery = nil }()
+
+	dispatcher := h.newDispatcher()
+	registered := dispatcher.Names()
+	if len(registered) == 0 {
+		return nil, fmt.Errorf("no notification backend is enabled")
+	}
+	backends, err := h.resolveTestBackends(opts.Backends, registered)
+	if err != nil {
+		return nil, err
+	}
+
+	ev := h.testEvent(status, opts)
+	ev.Backends = backends
+	sent := dispatcher.Dispatch(ev)
+	closeServices()
+
+	mu.Lock()
+	defer mu.Unlock()
+	var list []TestResult
+	for _, name := range backends {
+		result, ok := results[name]
+		switch {
+		case ok:
+		case !slices.Contains(sent, name):
+			result = TestResult{Backend: name, Skipped: "its route does not match this event"}
+		default:
+			result = TestResult{Backend: name, Skipped: "the backend dropped it, e.g. desktop.whenFocused"}
+		}
+		list = append(list, result)
+	}
+	h.resetBreakers(list)
+	return list, nil
+}
+
+// resetBreakers closes the circuit breakers of the backends a test
+// notification reached
+func (h *Handler) resetBreakers(results []TestResult) {
+	if h.breakers == nil {
+		return
+	}
+	var delivered []string
+	for _, r := range results {
+		if r.Skipped == "" && r.Err == nil {
+			delivered = append(delivered, r.Backend)
+		}
+	}
+	if len(delivered) == 0 {
+		return
+	}
+	if err := h.breakers.Reset(delivered...); err != nil {
+		logging.Warn("Circuit breaker: %v", err)
+	}
+}
+
+// resolveTestBackends checks the requested backends against the registered
+// ones. A name that is not registered may be a webhook preset, e.g. "ntfy"
+// for the webhook using the ntfy preset.
+func (h *Handler) resolveTestBackends(names, registered []string) ([]string, error) {
+	if len(names) == 0 {
+		return registered, nil
+	}
+	var backends []string
+	for _, name := range names {
+		resolved := name
+		if !slices.Contains(registered, name) {
+			resolved = h.webhookByPreset(name)
+		}
+		if resolved == "" || !slices.Contains(registered, resolved) {
+			return nil, fmt.Errorf("unknown or disabled backend: %s (enabled: %s)", name, strings.Join(registered, ", "))
+		}
+		if !slices.Contains(backends, resolved) {
+			backends = append(backends, resolved)
+		}
+	}
+	return backends, nil
+}
+
+// webhookByPreset returns the backend name of the first enabled webhook
+// using a preset ("" = none)
+func (h *Handler) webhookByPreset(preset string) string {
+	if h.cfg.IsWebhookEnabled() && h.cfg.Notifications.Webhook.Preset == preset {
+		return "webhook"
+	}
+	for i, w := range h.cfg.Notifications.Webhooks {
+		if w.Enabled && w.Preset == preset {
+			return h.cfg.ExtraWebhookName(i)
+		}
+	}
+	return ""
+}
+
+// testEvent builds the notification a hook would send for status
+func (h *Handler) testEvent(status analyzer.Status, opts TestOptions) notifier.Event {
+	project := opts.Project
+	if project == "" {
+		project = filepath.Base(opts.CWD)
+	}
+	message := opts.Message
+	if message == "" {
+		message = "Test notification from claude-notifications"
+	}
+	sessionName := sessionname.GenerateSessionLabel(testSessionID)
+	statusInfo, _ := h.cfg.GetStatusInfo(string(status))
+
+	return notifier.Event{
+		Status:    status,
+		Message:   fmt.Sprintf("[%s %s] %s", sessionName, project, message),
+		SessionID: testSessionID,
+		CWD:       opts.CWD,
+		Project:   project,
+		Content: &config.ContentData{
+			Title:     statusInfo.Title,
+			Message:   message,
+			Status:    string(status),
+			Event:     "test",
+			Project:   project,
+			Session:   sessionName,
+			SessionID: testSessionID,
+		},
+	}
+}
@@ -0,0 +1,105 @@
+package hooks
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+func testNotifyConfig() *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.Notifications.Desktop.Enabled = true
+	cfg.Notifications.Webhook = config.WebhookConfig{Enabled: true, Preset: "ntfy", URL: "https://ntfy.sh/topic"}
+	return cfg
+}
+
+func TestTestStatus(t *testing.T) {
+	tests := []struct {
+		event string
+		want  analyzer.Status
+	}{
+		{"", analyzer.StatusTaskComplete},
+		{"stop", analyzer.StatusTaskComplete},
+		{"Plan", analyzer.StatusPlanReady},
+		{"question", analyzer.StatusQuestion},
+		{"api_error_overloaded", analyzer.StatusAPIErrorOverloaded},
+	}
+	for _, tt := range tests {
+		if got, err := TestStatus(tt.event); err != nil || got != tt.want {
+			t.Errorf("TestStatus(%q) = %q, %v; want %q", tt.event, got, err, tt.want)
+		}
+	}
+	if _, err := TestStatus("finished"); err == nil || !strings.Contains(err.Error(), "unknown event: finished") {
+		t.Errorf("unknown event error = %v", err)
+	}
+}
+
+func TestHandler_SendTest(t *testing.T) {
+	handler, mockNotif, mockWH := newTestHandler(t, testNotifyConfig())
+	mockWH.err = errors.New("HTTP 403")
+
+	results, err := handler.SendTest(TestOptions{Event: "question", CWD: "/work/api", Project: "foo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Backend != "desktop" || results[1].Backend != "webhook" {
+		t.Fatalf("results = %+v, want desktop and webhook", results)
+	}
+	if results[0].Err != nil || results[0].Skipped != "" {
+		t.Errorf("desktop result = %+v, want delivered", results[0])
+	}
+	if results[1].Err == nil || results[1].Err.Error() != "HTTP 403" {
+		t.Errorf("webhook result = %+v, want the delivery error", results[1])
+	}
+
+	if got := mockNotif.callCount(); got != 1 {
+		t.Fatalf("desktop calls = %d, want 1", got)
+	}
+	if call := mockNotif.calls[0]; call.status != analyzer.StatusQuestion || !strings.Contains(call.message, " foo] Test notification") {
+		t.Errorf("desktop call = %+v", call)
+	}
+	if handler.onDelivery != nil {
+		t.Error("delivery results should go back to the history after the test")
+	}
+}
+
+func TestHandler_SendTest_SelectsBackends(t *testing.T) {
+	handler, mockNotif, mockWH := newTestHandler(t, testNotifyConfig())
+
+	// "ntfy" is the preset of the webhook backend
+	results, err := handler.SendTest(TestOptions{Backends: []string{"ntfy"}, CWD: "/work/api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Backend != "webhook" || results[0].Err != nil {
+		t.Errorf("results = %+v, want the webhook only", results)
+	}
+	if mockNotif.wasCalled() || len(mockWH.calls) != 1 {
+		t.Errorf("desktop called = %v, webhook calls = %d", mockNotif.wasCalled(), len(mockWH.calls))
+	}
+
+	handler, _, _ = newTestHandler(t, testNotifyConfig())
+	if _, err := handler.SendTest(TestOptions{Backends: []string{"email"}}); err == nil || !strings.Contains(err.Error(), "unknown or disabled backend: email (enabled: desktop, webhook)") {
+		t.Errorf("disabled backend error = %v", err)
+	}
+}
+
+func TestHandler_SendTest_RouteSkips(t *testing.T) {
+	cfg := testNotifyConfig()
+	cfg.Notifications.Webhook.Route = config.RouteConfig{Statuses: []string{"question"}}
+	handler, _, mockWH := newTestHandler(t, cfg)
+
+	results, err := handler.SendTest(TestOptions{Event: "stop", CWD: "/work/api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[1].Skipped == "" {
+		t.Errorf("results = %+v, want the webhook skipped by its route", results)
+	}
+	if len(mockWH.calls) != 0 {
+		t.Errorf("webhook calls = %d, want 0", len(mockWH.calls))
+	}
+}
@@ -0,0 +1,86 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/notifier"
+	"github.com/777genius/claude-notifications/internal/priority"
+)
+
+// SetTrace writes every decision the handler takes on a hook to w, one
+// "[trace]" line each: the parsed payload, matched rules, chosen and
+// skipped backends and what each would render (nil = off)
+func (h *Handler) SetTrace(w io.Writer) {
+	h.trace = w
+}
+
+// SetDryRun makes the handler decide what it would send without
+// delivering anything or changing state: no dedup locks, cooldowns,
+// session registry, digests or escalations are written
+func (h *Handler) SetDryRun(dryRun bool) {
+	h.dryRun = dryRun
+}
+
+// tracef logs a decision at debug level and writes it to the trace
+func (h *Handler) tracef(format string, args ...interface{}) {
+	logging.Debug(format, args...)
+	if h.trace != nil {
+		fmt.Fprintf(h.trace, "[trace] "+format+"\n", args...)
+	}
+}
+
+// tracePayload writes the parsed hook payload to the trace
+func (h *Handler) tracePayload(hookData *HookData) {
+	if h.trace == nil {
+		return
+	}
+	data, err := json.MarshalIndent(hookData, "", "  ")
+	if err != nil {
+		h.tracef("Payload not printable: %v", err)
+		return
+	}
+	fmt.Fprintf(h.trace, "[trace] Payload:\n%s\n", data)
+}
+
+// traceBackends writes which backends receive an event, and why the
+// others do not, to the trace
+func (h *Handler) traceBackends(dispatcher *notifier.Dispatcher, ev notifier.Event) {
+	if h.trace == nil {
+		return
+	}
+	reasons := dispatcher.Explain(ev)
+	var chosen []string
+	for _, name := range dispatcher.Names() {
+		if reasons[name] == "" {
+			chosen = append(chosen, name)
+		} else {
+			h.tracef("Backend %s skipped: %s", name, reasons[name])
+		}
+	}
+	h.tracef("Backends chosen: %s", traceList(chosen))
+}
+
+// dryRunDelivery writes what a backend would send, after its content
+// templates, to the trace, and reports whether to stop there
+func (h *Handler) dryRunDelivery(backend string, ev notifier.Event) bool {
+	if h.trace != nil {
+		h.tracef("%s: title=%q message=%q priority=%s sound=%q",
+			backend, ev.Title, ev.Message, priority.Resolve(ev.Status, ev.Priority), ev.Sound)
+	}
+	if h.dryRun {
+		h.tracef("%s: not sent (dry run)", backend)
+	}
+	return h.dryRun
+}
+
+// traceList formats names for the trace ("none" = empty)
+func traceList(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
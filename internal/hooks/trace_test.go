@@ -0,0 +1,112 @@
+package hooks
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+func dryRunConfig() *config.Config {
+	return &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+			Webhook: config.WebhookConfig{Enabled: true},
+			Rules: []config.Rule{
+				{
+					Name:    "api alerts",
+					Match:   config.RuleMatch{Projects: []string{"api"}},
+					Actions: config.RuleActions{Urgency: "critical", Title: "🚨 {{.Title}}", Backends: []string{"desktop"}},
+				},
+			},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete": {Title: "Task Complete"},
+		},
+	}
+}
+
+func TestHandler_DryRun(t *testing.T) {
+	handler, mockNotif, mockWH := newTestHandler(t, dryRunConfig())
+	var trace bytes.Buffer
+	handler.SetTrace(&trace)
+	handler.SetDryRun(true)
+
+	transcriptPath := createTempTranscript(t, buildTranscriptWithTools([]string{"Write"}, 300))
+	hookData := HookData{SessionID: "test-session-dry-run", TranscriptPath: transcriptPath, CWD: "/work/api"}
+	if err := handler.HandleHook("Stop", buildHookDataJSON(hookData)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mockNotif.wasCalled() || mockWH.wasCalled() {
+		t.Error("dry run must not send notifications")
+	}
+	for _, want := range []string{
+		`"session_id": "test-session-dry-run"`,
+		"Status: task_complete",
+		"Rules matched: api alerts",
+		"Backend webhook skipped: Rules exclude webhook",
+		"Backends chosen: desktop",
+		`desktop: title="🚨 Task Complete"`,
+		"priority=critical",
+		"desktop: not sent (dry run)",
+	} {
+		if !strings.Contains(trace.String(), want) {
+			t.Errorf("trace lacks %q:\n%s", want, trace.String())
+		}
+	}
+
+	// No dedup lock or cooldown state is left behind: a real run still notifies
+	if s, _ := handler.stateMgr.Load(hookData.SessionID); s != nil {
+		t.Errorf("dry run wrote session state: %+v", s)
+	}
+	handler.SetTrace(nil)
+	handler.SetDryRun(false)
+	if err := handler.HandleHook("Stop", buildHookDataJSON(hookData)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !mockNotif.wasCalled() {
+		t.Error("expected a desktop notification after the dry run")
+	}
+}
+
+func TestHandler_TraceExplainsSuppression(t *testing.T) {
+	cfg := dryRunConfig()
+	cfg.Notifications.Rules[0].Actions = config.RuleActions{Suppress: true}
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	var trace bytes.Buffer
+	handler.SetTrace(&trace)
+
+	transcriptPath := createTempTranscript(t, buildTranscriptWithTools([]string{"Write"}, 300))
+	hookData := buildHookDataJSON(HookData{SessionID: "test-session-trace", TranscriptPath: transcriptPath, CWD: "/work/api"})
+	if err := handler.HandleHook("Stop", hookData); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mockNotif.wasCalled() {
+		t.Error("rule suppresses the notification")
+	}
+	if want := "[trace] Notification suppressed by rule: status=task_complete"; !strings.Contains(trace.String(), want) {
+		t.Errorf("trace lacks %q:\n%s", want, trace.String())
+	}
+}
+
+func TestHandler_TraceDelivers(t *testing.T) {
+	handler, mockNotif, _ := newTestHandler(t, dryRunConfig())
+	var trace bytes.Buffer
+	handler.SetTrace(&trace)
+
+	transcriptPath := createTempTranscript(t, buildTranscriptWithTools([]string{"Write"}, 300))
+	hookData := buildHookDataJSON(HookData{SessionID: "test-session-trace-send", TranscriptPath: transcriptPath, CWD: "/work/api"})
+	if err := handler.HandleHook("Stop", hookData); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !mockNotif.wasCalled() {
+		t.Error("trace alone must still send notifications")
+	}
+	if strings.Contains(trace.String(), "dry run") || !strings.Contains(trace.String(), `desktop: title="🚨 Task Complete"`) {
+		t.Errorf("unexpected trace:\n%s", trace.String())
+	}
+}
@@ -0,0 +1,234 @@
+package hooks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/daemon"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/notifier"
+	"github.com/777genius/claude-notifications/internal/platform"
+	"github.com/777genius/claude-notifications/internal/sessionname"
+	"github.com/777genius/claude-notifications/internal/sessions"
+)
+
+// watchID identifies the watcher of the prompt submitted at promptAt, so
+// a watcher notices when a newer prompt replaced its own
+func watchID(promptAt time.Time) string {
+	return strconv.FormatInt(promptAt.UnixNano(), 36)
+}
+
+// workingKey is the key the heartbeats and progress notifications of a
+// session replace each other by, so it shows one notification while working
+func workingKey(sessionID string) string {
+	return "working-" + sessionID
+}
+
+// watching reports whether prompts get a watcher: heartbeats or stall
+// detection is enabled
+func (h *Handler) watching() bool {
+	return h.cfg.Notifications.Heartbeat.Enabled || h.cfg.Notifications.Stall.Enabled
+}
+
+// promptSession records that the session works on a new prompt and
+// starts the prompt's watcher when heartbeats or stall detection is enabled
+func (h *Handler) promptSession(hookData *HookData) {
+	if h.dryRun {
+		if h.watching() {
+			h.tracef("Would watch the prompt for heartbeats and stalls")
+		}
+		return
+	}
+	if h.sessionReg == nil {
+		return
+	}
+	now := time.Now()
+	if err := h.sessionReg.Prompt(hookData.SessionID, hookData.TranscriptPath, daemon.ClaudePID(), now); err != nil {
+		logging.Debug("Failed to record the prompt: %v", err)
+		return
+	}
+	h.trackSession(hookData.SessionID)
+	if !h.watching() {
+		return
+	}
+	if s, err := h.sessionReg.Get(hookData.SessionID); err != nil || s == nil {
+		return // Not registered: nothing to watch
+	}
+	if err := spawnWatcher(hookData.SessionID, watchID(now)); err != nil {
+		logging.Warn("Failed to start the prompt watcher: %v", err)
+	}
+}
+
+// finishPrompt records that the session finished its prompt, which stops
+// its watcher, and closes its heartbeat or progress notification
+func (h *Handler) finishPrompt(sessionID string) {
+	if h.sessionReg == nil || h.dryRun {
+		return
+	}
+	if err := h.sessionReg.Done(sessionID, time.Now()); err != nil {
+		logging.Debug("Failed to record the finished prompt: %v", err)
+	}
+	working := h.cfg.Notifications.Heartbeat.Enabled || h.cfg.Notifications.Progress.Enabled
+	if working && h.cfg.IsDesktopEnabled() {
+		if err := h.notifierSvc.DismissSession(workingKey(sessionID)); err != nil {
+			logging.Debug("Failed to dismiss the working notification: %v", err)
+		}
+	}
+}
+
+// WatchPrompt runs the watcher started by the UserPromptSubmit hook: it
+// reports the session still working every heartbeat.interval and alerts
+// when the session stalls, until the session finishes the prompt, gets a
+// new one, ends, or shows no activity for watchMaxQuiet
+func (h *Handler) WatchPrompt(sessionID, id string) error {
+	defer h.closeServices()
+	logging.SetPrefix(fmt.Sprintf("PID:%d", os.Getpid()))
+
+	if h.sessionReg == nil {
+		return fmt.Errorf("session registry unavailable")
+	}
+	var nextBeat time.Time
+	var stalledAt time.Time // Last activity the stall alert was sent for
+	for {
+		s, err := h.sessionReg.Get(sessionID)
+		if err != nil {
+			return err
+		}
+		if s == nil || !s.Working() || watchID(s.PromptAt) != id {
+			logging.Debug("Watcher %s: prompt finished or replaced", id)
+			return nil
+		}
+		if s.ClaudePID != 0 && !daemon.ProcessAlive(s.ClaudePID) {
+			logging.Info("Claude Code of session %s exited, watcher stopped", sessionID)
+			return nil
+		}
+		if nextBeat.IsZero() {
+			h.applyProjectConfig(s.CWD)
+			nextBeat = s.PromptAt.Add(h.cfg.Notifications.Heartbeat.IntervalDuration())
+		}
+		heartbeat, stall := h.cfg.Notifications.Heartbeat, h.cfg.Notifications.Stall
+		if !h.watching() {
+			logging.Debug("Heartbeats and stall detection disabled since the prompt, watcher stopped")
+			return nil
+		}
+
+		now := time.Now()
+		lastActive := h.lastActivity(s)
+		quiet := now.Sub(lastActive)
+		stallDue := stall.Enabled && !lastActive.Equal(stalledAt)
+		if quiet > watchMaxQuiet && (!stallDue || quiet < stall.AfterDuration()) {
+			logging.Info("Session %s quiet for %v, watcher stopped", sessionID, quiet.Round(time.Minute))
+			return nil
+		}
+
+		if stallDue && quiet >= stall.AfterDuration() {
+			stalledAt = lastActive
+			h.sendStall(s, lastActive, quiet)
+		}
+		wait := escalationPollInterval
+		if heartbeat.Enabled {
+			if !nextBeat.After(now) {
+				// After a suspend, skip the heartbeats that were missed
+				interval := heartbeat.IntervalDuration()
+				for !nextBeat.After(now) {
+					nextBeat = nextBeat.Add(interval)
+				}
+				if h.waitingForUser(sessionID, lastActive) {
+					logging.Debug("Session %s waits for the user, heartbeat skipped", sessionID)
+				} else {
+					h.sendHeartbeat(s, quiet, interval)
+				}
+			}
+			wait = min(wait, time.Until(nextBeat))
+		}
+		sleep(wait)
+	}
+}
+
+// lastActivity returns the last sign of life of the session: its last hook
+// event or transcript write, whichever came later
+func (h *Handler) lastActivity(s *sessions.Session) time.Time {
+	last := s.PromptAt
+	if s.LastActivity.After(last) {
+		last = s.LastActivity
+	}
+	if s.Transcript == "" {
+		return last
+	}
+	if info, err := os.Stat(s.Transcript); err == nil && info.ModTime().After(last) {
+		return info.ModTime()
+	}
+	return last
+}
+
+// waitingForUser reports whether the session's last notification, sent
+// at or after its last activity, asked the user something
+func (h *Handler) waitingForUser(sessionID string, lastActive time.Time) bool {
+	st, err := h.stateMgr.Load(sessionID)
+	if err != nil || st == nil {
+		return false
+	}
+	switch analyzer.Status(st.LastNotificationStatus) {
+	case analyzer.StatusQuestion, analyzer.StatusPlanReady:
+		return st.LastNotificationTime >= lastActive.Unix()
+	}
+	return false
+}
+
+// sendWatchEvent sends a notification about a session's prompt — a
+// heartbeat, stall or progress — reported to templates as event, unless its status is disabled or do-not-disturb is
+// active: held back, it would be stale by the time it is shown
+func (h *Handler) sendWatchEvent(s *sessions.Session, event string, status analyzer.Status, message string, backends []string, replace string) {
+	if !h.cfg.IsStatusEnabled(string(status)) {
+		logging.Debug("Notifications disabled for status: %s", status)
+		return
+	}
+	if h.dndMgr != nil && h.dndMgr.Status(time.Now()).Active {
+		logging.Debug("Do-not-disturb active: %s skipped", status)
+		return
+	}
+
+	elapsed := time.Since(s.PromptAt)
+	sessionName := sessionname.GenerateSessionLabel(s.ID)
+	git := platform.GetGitContext(s.CWD)
+	statusInfo, _ := h.cfg.GetStatusInfo(string(status))
+	ev := notifier.Event{
+		Status:    status,
+		Message:   labelMessage(sessionName, git, s.CWD, message),
+		SessionID: s.ID,
+		CWD:       s.CWD,
+		Project:   filepath.Base(s.CWD),
+		Elapsed:   elapsed,
+		Running:   time.Since(s.StartedAt),
+		Backends:  backends,
+		Replace:   replace,
+		Content: &config.ContentData{
+			Title:     statusInfo.Title,
+			Message:   message,
+			Status:    string(status),
+			Event:     event,
+			Project:   filepath.Base(s.CWD),
+			Repo:      git.Repo,
+			Branch:    git.Branch,
+			Session:   sessionName,
+			SessionID: s.ID,
+			Elapsed:   elapsed.Round(time.Second).String(),
+		},
+	}
+	sent := h.newDispatcher().Dispatch(ev)
+	logging.Debug("%s dispatched to: %v", status, sent)
+}
+
+// formatMinutes formats a duration to the minute: "25m" or "1h05m"
+func formatMinutes(d time.Duration) string {
+	d = d.Truncate(time.Minute)
+	if d >= time.Hour {
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dm", int(d.Minutes()))
+}
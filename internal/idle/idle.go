@@ -0,0 +1,68 @@
+// Package idle reads how long the user has been away from the keyboard and
+// mouse, so notifications can be held back while they are at the terminal
+// and escalated once they are gone.
+package idle
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// queryTimeout bounds one idle time query
+const queryTimeout = time.Second
+
+// Time returns the time since the last keyboard or mouse input
+func Time() (time.Duration, error) {
+	return platformTime()
+}
+
+// screenSaverIdle converts org.freedesktop.ScreenSaver.GetSessionIdleTime,
+// which the spec gives in seconds but KDE answers in milliseconds
+func screenSaverIdle(v uint32, desktop string) time.Duration {
+	if strings.Contains(strings.ToUpper(desktop), "KDE") {
+		return time.Duration(v) * time.Millisecond
+	}
+	return time.Duration(v) * time.Second
+}
+
+// parseMilliseconds reads a number of milliseconds, as printed by xprintidle
+func parseMilliseconds(output string) (time.Duration, error) {
+	ms, err := strconv.ParseUint(strings.TrimSpace(output), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected idle time %q", strings.TrimSpace(output))
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// logindIdle returns the idle time from logind's IdleHint and
+// IdleSinceHint (microseconds since the epoch). logind only knows when the
+// desktop declared the session idle, so an active session reports 0.
+func logindIdle(idleHint bool, sinceMicros uint64, now time.Time) time.Duration {
+	if !idleHint || sinceMicros == 0 {
+		return 0
+	}
+	since := time.UnixMicro(int64(sinceMicros))
+	if since.After(now) {
+		return 0
+	}
+	return now.Sub(since)
+}
+
+// hidIdleTime matches the HIDIdleTime property (nanoseconds) in ioreg output
+var hidIdleTime = regexp.MustCompile(`"HIDIdleTime"\s*=\s*(\d+)`)
+
+// parseIoreg reads HIDIdleTime from "ioreg -c IOHIDSystem" output
+func parseIoreg(output string) (time.Duration, error) {
+	m := hidIdleTime.FindStringSubmatch(output)
+	if m == nil {
+		return 0, fmt.Errorf("HIDIdleTime not found in ioreg output")
+	}
+	ns, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid HIDIdleTime %q: %w", m[1], err)
+	}
+	return time.Duration(ns), nil
+}
@@ -0,0 +1,21 @@
+//go:build darwin
+
+package idle
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// platformTime reads HIDIdleTime from the IOHIDSystem registry entry
+func platformTime() (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "ioreg", "-c", "IOHIDSystem", "-d", "4").Output()
+	if err != nil {
+		return 0, fmt.Errorf("ioreg failed: %w", err)
+	}
+	return parseIoreg(string(output))
+}
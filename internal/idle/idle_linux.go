@@ -0,0 +1,87 @@
+//go:build linux
+
+package idle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// platformTime asks, in order: GNOME's idle monitor, the freedesktop
+// screensaver (KDE, Xfce, Cinnamon), xprintidle on X11, and logind
+func platformTime() (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	defer cancel()
+
+	var errs []error
+	if conn, err := dbus.ConnectSessionBus(); err == nil {
+		defer conn.Close()
+
+		var ms uint64
+		err := conn.Object("org.gnome.Mutter.IdleMonitor", "/org/gnome/Mutter/IdleMonitor/Core").
+			CallWithContext(ctx, "org.gnome.Mutter.IdleMonitor.GetIdletime", 0).Store(&ms)
+		if err == nil {
+			return time.Duration(ms) * time.Millisecond, nil
+		}
+		errs = append(errs, fmt.Errorf("Mutter idle monitor: %w", err))
+
+		var v uint32
+		err = conn.Object("org.freedesktop.ScreenSaver", "/org/freedesktop/ScreenSaver").
+			CallWithContext(ctx, "org.freedesktop.ScreenSaver.GetSessionIdleTime", 0).Store(&v)
+		if err == nil {
+			return screenSaverIdle(v, os.Getenv("XDG_CURRENT_DESKTOP")), nil
+		}
+		errs = append(errs, fmt.Errorf("ScreenSaver: %w", err))
+	} else {
+		errs = append(errs, fmt.Errorf("session bus: %w", err))
+	}
+
+	if os.Getenv("DISPLAY") != "" && os.Getenv("XDG_SESSION_TYPE") != "wayland" {
+		output, err := exec.CommandContext(ctx, "xprintidle").Output()
+		if err == nil {
+			return parseMilliseconds(string(output))
+		}
+		errs = append(errs, fmt.Errorf("xprintidle: %w", err))
+	}
+
+	d, err := logindTime(ctx)
+	if err == nil {
+		return d, nil
+	}
+	errs = append(errs, fmt.Errorf("logind: %w", err))
+	return 0, fmt.Errorf("cannot read idle time: %w", errors.Join(errs...))
+}
+
+// logindTime reads the idle hint of the caller's logind session
+func logindTime(ctx context.Context) (time.Duration, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	session := conn.Object("org.freedesktop.login1", "/org/freedesktop/login1/session/auto")
+	property := func(name string, value interface{}) error {
+		var v dbus.Variant
+		if err := session.CallWithContext(ctx, "org.freedesktop.DBus.Properties.Get", 0,
+			"org.freedesktop.login1.Session", name).Store(&v); err != nil {
+			return err
+		}
+		return v.Store(value)
+	}
+	var idleHint bool
+	var since uint64
+	if err := property("IdleHint", &idleHint); err != nil {
+		return 0, err
+	}
+	if err := property("IdleSinceHint", &since); err != nil {
+		return 0, err
+	}
+	return logindIdle(idleHint, since, time.Now()), nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !windows
+
+package idle
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// platformTime is not supported on this platform
+func platformTime() (time.Duration, error) {
+	return 0, fmt.Errorf("idle time is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,55 @@
+package idle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScreenSaverIdle(t *testing.T) {
+	if got := screenSaverIdle(90, "XFCE"); got != 90*time.Second {
+		t.Errorf("Xfce: got %v, want 1m30s", got)
+	}
+	if got := screenSaverIdle(1500, "KDE"); got != 1500*time.Millisecond {
+		t.Errorf("KDE: got %v, want 1.5s", got)
+	}
+}
+
+func TestParseMilliseconds(t *testing.T) {
+	got, err := parseMilliseconds("42000\n")
+	if err != nil || got != 42*time.Second {
+		t.Errorf("parseMilliseconds = %v, %v; want 42s", got, err)
+	}
+	if _, err := parseMilliseconds("couldn't open display"); err == nil {
+		t.Error("expected an error for non-numeric output")
+	}
+}
+
+func TestLogindIdle(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	since := uint64(now.Add(-10 * time.Minute).UnixMicro())
+
+	if got := logindIdle(true, since, now); got != 10*time.Minute {
+		t.Errorf("idle session: got %v, want 10m", got)
+	}
+	if got := logindIdle(false, since, now); got != 0 {
+		t.Errorf("active session: got %v, want 0", got)
+	}
+	if got := logindIdle(true, uint64(now.Add(time.Minute).UnixMicro()), now); got != 0 {
+		t.Errorf("idle since the future: got %v, want 0", got)
+	}
+}
+
+func TestParseIoreg(t *testing.T) {
+	output := `+-o IOHIDSystem  <class IOHIDSystem, id 0x100000454, registered, matched, active, busy 0 (0 ms), retain 32>
+    {
+      "HIDIdleTime" = 7250000000
+      "HIDParameters" = {"HIDMouseAcceleration"=45056}
+    }`
+	got, err := parseIoreg(output)
+	if err != nil || got != 7250*time.Millisecond {
+		t.Errorf("parseIoreg = %v, %v; want 7.25s", got, err)
+	}
+	if _, err := parseIoreg("+-o IOHIDSystem"); err == nil {
+		t.Error("expected an error without HIDIdleTime")
+	}
+}
@@ -0,0 +1,32 @@
+//go:build windows
+
+package idle
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	procGetLastInputInfo = syscall.NewLazyDLL("user32.dll").NewProc("GetLastInputInfo")
+	procGetTickCount     = syscall.NewLazyDLL("kernel32.dll").NewProc("GetTickCount")
+)
+
+// lastInputInfo is LASTINPUTINFO
+type lastInputInfo struct {
+	cbSize uint32
+	dwTime uint32
+}
+
+// platformTime compares the tick count of the last input with the current one
+func platformTime() (time.Duration, error) {
+	info := lastInputInfo{cbSize: uint32(unsafe.Sizeof(lastInputInfo{}))}
+	if ok, _, err := procGetLastInputInfo.Call(uintptr(unsafe.Pointer(&info))); ok == 0 {
+		return 0, fmt.Errorf("GetLastInputInfo failed: %w", err)
+	}
+	now, _, _ := procGetTickCount.Call()
+	// Tick counts wrap after 49.7 days; the difference stays correct
+	return time.Duration(uint32(now)-info.dwTime) * time.Millisecond, nil
+}
@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// prefixKey is the attribute holding the logger prefix
+const prefixKey = "component"
+
+// textHandler is a slog.Handler writing the plugin's classic log line:
+// [2006-01-02 15:04:05] [LEVEL] prefix: message key=value ...
+type textHandler struct {
+	w     io.Writer
+	attrs []slog.Attr
+	group string
+}
+
+func (h *textHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] [%s] ", r.Time.Format("2006-01-02 15:04:05"), r.Level)
+
+	var extra []slog.Attr
+	prefix := ""
+	for _, a := range h.attrs {
+		if a.Key == prefixKey {
+			prefix = a.Value.String()
+		} else {
+			extra = append(extra, a)
+		}
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		a = h.qualify(a)
+		if a.Key == prefixKey {
+			prefix = a.Value.String()
+		} else {
+			extra = append(extra, a)
+		}
+		return true
+	})
+
+	if prefix != "" {
+		b.WriteString(prefix)
+		b.WriteString(": ")
+	}
+	b.WriteString(r.Message)
+	for _, a := range extra {
+		fmt.Fprintf(&b, " %s=%v", a.Key, a.Value)
+	}
+	b.WriteByte('\n')
+
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = append([]slog.Attr{}, h.attrs...)
+	for _, a := range attrs {
+		h2.attrs = append(h2.attrs, h.qualify(a))
+	}
+	return &h2
+}
+
+func (h *textHandler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	if h2.group != "" {
+		name = h2.group + "." + name
+	}
+	h2.group = name
+	return &h2
+}
+
+// qualify prefixes the key of a with the current group
+func (h *textHandler) qualify(a slog.Attr) slog.Attr {
+	if h.group != "" {
+		a.Key = h.group + "." + a.Key
+	}
+	return a
+}
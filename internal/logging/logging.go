@@ -1,17 +1,66 @@
 package logging
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sync"
 	"time"
 )
 
-// Logger provides structured logging to a file
+// FileName is the log file shared by hooks, the daemon and the listener
+const FileName = "notification-debug.log"
+
+// Output formats
+const (
+	FormatText = "text" // [2006-01-02 15:04:05] [LEVEL] prefix: message
+	FormatJSON = "json" // One JSON object per line
+)
+
+// Rotation defaults: the log file is rotated at 5 MB, keeping 3 old files
+const (
+	DefaultMaxSize  = 5 * 1024 * 1024
+	DefaultMaxFiles = 3
+)
+
+// Options controls what the logger writes and when the file is rotated
+type Options struct {
+	Level    slog.Level // Messages below this level are dropped
+	Format   string     // FormatText (default) or FormatJSON
+	MaxSize  int64      // Rotate the file once it would grow past this many bytes; 0 = never
+	MaxFiles int        // Rotated files kept as path.1 ... path.N
+}
+
+// DefaultOptions logs every level as text with the default rotation
+func DefaultOptions() Options {
+	return Options{
+		Level:    slog.LevelDebug,
+		Format:   FormatText,
+		MaxSize:  DefaultMaxSize,
+		MaxFiles: DefaultMaxFiles,
+	}
+}
+
+// ParseLevel parses a level name: debug, info, warn (or warning), error
+func ParseLevel(s string) (slog.Level, error) {
+	if s == "warning" {
+		s = "warn"
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return slog.LevelDebug, fmt.Errorf("invalid log level %q (must be one of: debug, info, warn, error)", s)
+	}
+	return level, nil
+}
+
+// Logger provides leveled logging to a rotating file, as text or JSON
 type Logger struct {
-	file          *os.File
+	out           *rotatingFile
+	handler       slog.Handler
+	level         slog.Level
 	mu            sync.Mutex
 	prefix        string
 	consoleOutput bool // Enable output to console (stderr/stdout)
@@ -36,16 +85,35 @@ func InitLogger(pluginRoot string) (*Logger, error) {
 	return defaultLogger, err
 }
 
-// NewLogger creates a new logger that writes to the specified file
+// NewLogger creates a new logger that writes to the specified file with
+// DefaultOptions
 func NewLogger(path string) (*Logger, error) {
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	opts := DefaultOptions()
+	out, err := openRotatingFile(path, opts.MaxSize, opts.MaxFiles)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open log file: %w", err)
 	}
 
-	return &Logger{
-		file: f,
-	}, nil
+	l := &Logger{out: out}
+	l.configure(opts)
+	return l, nil
+}
+
+// Configure changes the level, format and rotation of the logger
+func (l *Logger) Configure(opts Options) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.configure(opts)
+}
+
+func (l *Logger) configure(opts Options) {
+	l.level = opts.Level
+	l.out.setLimits(opts.MaxSize, opts.MaxFiles)
+	if opts.Format == FormatJSON {
+		l.handler = slog.NewJSONHandler(l.out, &slog.HandlerOptions{Level: slog.LevelDebug})
+	} else {
+		l.handler = &textHandler{w: l.out}
+	}
 }
 
 // SetPrefix sets a prefix for all log messages
@@ -70,28 +138,32 @@ func (l *Logger) DisableConsoleOutput() {
 }
 
 // log writes a formatted log message with timestamp
-func (l *Logger) log(level, format string, args ...interface{}) {
+func (l *Logger) log(slogLevel slog.Level, format string, args ...interface{}) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
+	if slogLevel < l.level {
+		return
+	}
+
+	now := time.Now()
 	message := fmt.Sprintf(format, args...)
 
-	var logLine string
+	// Write to file
+	record := slog.NewRecord(now, slogLevel, message, 0)
 	if l.prefix != "" {
-		logLine = fmt.Sprintf("[%s] [%s] %s: %s\n", timestamp, level, l.prefix, message)
-	} else {
-		logLine = fmt.Sprintf("[%s] [%s] %s\n", timestamp, level, message)
+		record.AddAttrs(slog.String(prefixKey, l.prefix))
 	}
-
-	// Write to file
-	_, _ = l.file.WriteString(logLine)
+	_ = l.handler.Handle(context.Background(), record)
 
 	// Write to console if enabled
 	if l.consoleOutput {
+		timestamp := now.Format("2006-01-02 15:04:05")
+		level := slogLevel.String()
+
 		// Use stderr for errors and warnings, stdout for info and debug
 		var consoleOutput io.Writer
-		if level == "ERROR" || level == "WARN" {
+		if slogLevel >= slog.LevelWarn {
 			consoleOutput = os.Stderr
 		} else {
 			consoleOutput = os.Stdout
@@ -110,22 +182,22 @@ func (l *Logger) log(level, format string, args ...interface{}) {
 
 // Debug logs a debug message
 func (l *Logger) Debug(format string, args ...interface{}) {
-	l.log("DEBUG", format, args...)
+	l.log(slog.LevelDebug, format, args...)
 }
 
 // Info logs an info message
 func (l *Logger) Info(format string, args ...interface{}) {
-	l.log("INFO", format, args...)
+	l.log(slog.LevelInfo, format, args...)
 }
 
 // Warn logs a warning message
 func (l *Logger) Warn(format string, args ...interface{}) {
-	l.log("WARN", format, args...)
+	l.log(slog.LevelWarn, format, args...)
 }
 
 // Error logs an error message
 func (l *Logger) Error(format string, args ...interface{}) {
-	l.log("ERROR", format, args...)
+	l.log(slog.LevelError, format, args...)
 }
 
 // Close closes the log file
@@ -133,15 +205,15 @@ func (l *Logger) Close() error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	if l.file != nil {
-		return l.file.Close()
+	if l.out != nil {
+		return l.out.Close()
 	}
 	return nil
 }
 
 // GetWriter returns the underlying writer for the logger
 func (l *Logger) GetWriter() io.Writer {
-	return l.file
+	return l.out
 }
 
 // Global logger functions (use default logger)
@@ -174,6 +246,13 @@ func Error(format string, args ...interface{}) {
 	}
 }
 
+// Configure changes the level, format and rotation of the default logger
+func Configure(opts Options) {
+	if defaultLogger != nil {
+		defaultLogger.Configure(opts)
+	}
+}
+
 // SetPrefix sets a prefix for all log messages using the default logger
 func SetPrefix(prefix string) {
 	if defaultLogger != nil {
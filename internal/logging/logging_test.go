@@ -1,6 +1,9 @@
 package logging
 
 import (
+	"encoding/json"
+	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -516,3 +519,126 @@ func TestLogger_ConsoleOutput_InfoToStdout(t *testing.T) {
 		t.Error("Log should contain [DEBUG]")
 	}
 }
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"debug", slog.LevelDebug, false},
+		{"info", slog.LevelInfo, false},
+		{"WARN", slog.LevelWarn, false},
+		{"warning", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"verbose", slog.LevelDebug, true},
+		{"", slog.LevelDebug, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLogger_ConfigureLevel(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "level.log")
+	logger, err := NewLogger(logPath)
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Close()
+
+	opts := DefaultOptions()
+	opts.Level = slog.LevelWarn
+	logger.Configure(opts)
+
+	logger.Debug("debug message")
+	logger.Info("info message")
+	logger.Warn("warn message")
+	logger.Error("error message")
+
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	logContent := string(content)
+	if strings.Contains(logContent, "debug message") || strings.Contains(logContent, "info message") {
+		t.Errorf("messages below WARN were logged: %s", logContent)
+	}
+	if !strings.Contains(logContent, "[WARN] warn message") || !strings.Contains(logContent, "[ERROR] error message") {
+		t.Errorf("WARN and ERROR messages missing: %s", logContent)
+	}
+}
+
+func TestLogger_JSONFormat(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "json.log")
+	logger, err := NewLogger(logPath)
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Close()
+
+	opts := DefaultOptions()
+	opts.Format = FormatJSON
+	logger.Configure(opts)
+	logger.SetPrefix("daemon")
+	logger.Info("sent %d notifications", 3)
+
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(content, &entry); err != nil {
+		t.Fatalf("log line is not JSON: %v\n%s", err, content)
+	}
+	if entry["level"] != "INFO" || entry["msg"] != "sent 3 notifications" || entry["component"] != "daemon" {
+		t.Errorf("entry = %v", entry)
+	}
+	if entry["time"] == nil {
+		t.Errorf("entry should have a time: %v", entry)
+	}
+}
+
+func TestStdLogWriter(t *testing.T) {
+	tmpDir := t.TempDir()
+	defaultLogger = nil
+	once = sync.Once{}
+	logger, err := InitLogger(tmpDir)
+	if err != nil {
+		t.Fatalf("InitLogger() error = %v", err)
+	}
+	defer logger.Close()
+
+	std := log.New(StdLogWriter(), "", log.Ltime|log.Lmicroseconds)
+	std.Printf("[WARN] Failed to show notification: %s", "no bus")
+	std.Println("plain line")
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, FileName))
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), content)
+	}
+	if !strings.HasSuffix(lines[0], "[WARN] Failed to show notification: no bus") {
+		t.Errorf("line 1 = %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "[INFO]") || !strings.HasSuffix(lines[1], "plain line") {
+		t.Errorf("line 2 = %q", lines[1])
+	}
+}
+
+func TestTextHandlerAttrs(t *testing.T) {
+	var buf strings.Builder
+	logger := slog.New(&textHandler{w: &buf}).With(prefixKey, "hooks").WithGroup("webhook")
+	logger.Info("sent", "status", 200)
+
+	line := buf.String()
+	if !strings.Contains(line, "[INFO] hooks: sent webhook.status=200\n") {
+		t.Errorf("line = %q", line)
+	}
+}
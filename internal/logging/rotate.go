@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// rotatingFile is an append-only log file that is renamed to path.1 once
+// it would grow past maxSize; path.1 moves to path.2 and so on, and the
+// oldest file beyond maxFiles is removed. Hooks, the daemon and the
+// listener append to the same file, so a process that finds the file
+// already rotated by another one just reopens it.
+type rotatingFile struct {
+	mu       sync.Mutex
+	path     string
+	file     *os.File
+	maxSize  int64
+	maxFiles int
+}
+
+// openRotatingFile opens path for appending, creating it if needed
+func openRotatingFile(path string, maxSize int64, maxFiles int) (*rotatingFile, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &rotatingFile{path: path, file: f, maxSize: maxSize, maxFiles: maxFiles}, nil
+}
+
+// setLimits changes when the file is rotated
+func (r *rotatingFile) setLimits(maxSize int64, maxFiles int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxSize = maxSize
+	r.maxFiles = maxFiles
+}
+
+// Write appends p, rotating the file first if p would make it too large
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	if r.maxSize > 0 {
+		if info, err := r.file.Stat(); err == nil && info.Size() > 0 && info.Size()+int64(len(p)) > r.maxSize {
+			if err := r.rotate(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return r.file.Write(p)
+}
+
+// rotate moves the full file aside and opens a new one
+func (r *rotatingFile) rotate() error {
+	// Another process may have rotated it already
+	current, err := os.Stat(r.path)
+	own, ownErr := r.file.Stat()
+	if err == nil && ownErr == nil && !os.SameFile(current, own) {
+		return r.reopen()
+	}
+
+	// Windows cannot rename an open file. If another process still has it
+	// open there, the rename fails and writing continues in the same file.
+	r.file.Close()
+	if r.maxFiles > 0 {
+		_ = os.Remove(r.rotatedPath(r.maxFiles))
+		for i := r.maxFiles - 1; i >= 1; i-- {
+			_ = os.Rename(r.rotatedPath(i), r.rotatedPath(i+1))
+		}
+		_ = os.Rename(r.path, r.rotatedPath(1))
+	} else {
+		_ = os.Remove(r.path)
+	}
+	return r.reopen()
+}
+
+// reopen replaces the open file with the one now at path
+func (r *rotatingFile) reopen() error {
+	r.file.Close()
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		r.file = nil
+		return fmt.Errorf("failed to reopen log file: %w", err)
+	}
+	r.file = f
+	return nil
+}
+
+// rotatedPath returns the name of the n-th rotated file
+func (r *rotatingFile) rotatedPath(n int) string {
+	return fmt.Sprintf("%s.%d", r.path, n)
+}
+
+// Close closes the file; later writes fail
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return os.ErrClosed
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rotate.log")
+	r, err := openRotatingFile(path, 20, 2)
+	if err != nil {
+		t.Fatalf("openRotatingFile() error = %v", err)
+	}
+	defer r.Close()
+
+	for _, line := range []string{"line 1 .......\n", "line 2 .......\n", "line 3 .......\n", "line 4 .......\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	// Each line exceeds the remaining space, so every write rotates;
+	// only maxFiles rotated files are kept
+	for name, want := range map[string]string{
+		path:        "line 4",
+		path + ".1": "line 3",
+		path + ".2": "line 2",
+	} {
+		content, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error = %v", name, err)
+		}
+		if !strings.HasPrefix(string(content), want) {
+			t.Errorf("%s = %q, want %s", filepath.Base(name), content, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("%s.3 should not exist, stat error = %v", path, err)
+	}
+}
+
+func TestRotatingFileRotatedElsewhere(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shared.log")
+	first, err := openRotatingFile(path, 20, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, err := openRotatingFile(path, 20, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+
+	_, _ = first.Write([]byte("first ........\n"))
+	_, _ = first.Write([]byte("first again ..\n")) // rotates
+	_, _ = second.Write([]byte("second .......\n"))
+
+	// second noticed the rotation and wrote to the new file instead of
+	// rotating again, which would have dropped "first ..."
+	rotated, _ := os.ReadFile(path + ".1")
+	current, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(rotated), "first ....") {
+		t.Errorf("rotated file = %q", rotated)
+	}
+	if string(current) != "first again ..\nsecond .......\n" {
+		t.Errorf("current file = %q", current)
+	}
+}
+
+func TestRotatingFileNoLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "unlimited.log")
+	r, err := openRotatingFile(path, 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	for i := 0; i < 10; i++ {
+		_, _ = r.Write([]byte("a fairly long log line\n"))
+	}
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Errorf("file rotated without a size limit, stat error = %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// StdLogWriter returns a writer for the standard library log package that
+// forwards each line to the default logger. A level tag in the line, as
+// in log.Printf("[WARN] ..."), sets the level; untagged lines are INFO.
+func StdLogWriter() io.Writer {
+	return stdLogWriter{}
+}
+
+type stdLogWriter struct{}
+
+func (stdLogWriter) Write(p []byte) (int, error) {
+	if defaultLogger == nil {
+		return len(p), nil
+	}
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		level, message := parseStdLogLine(line)
+		defaultLogger.log(level, "%s", message)
+	}
+	return len(p), nil
+}
+
+// parseStdLogLine splits a standard log line into its level and message,
+// dropping the timestamp the log package puts before the tag
+func parseStdLogLine(line string) (slog.Level, string) {
+	for _, level := range []slog.Level{slog.LevelError, slog.LevelWarn, slog.LevelInfo, slog.LevelDebug} {
+		tag := "[" + level.String() + "] "
+		if i := strings.Index(line, tag); i >= 0 {
+			return level, line[i+len(tag):]
+		}
+	}
+	return slog.LevelInfo, line
+}
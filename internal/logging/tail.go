@@ -0,0 +1,117 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// Tail returns the last n lines of the file at path and the file size
+// they end at, to continue from with Follow. A missing file has no lines.
+func Tail(path string, n int) ([]string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, 0, nil
+		}
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	size := info.Size()
+	if n <= 0 {
+		return nil, size, nil
+	}
+
+	// Read backwards in chunks until n complete lines are in buf
+	const chunk = 64 * 1024
+	var buf []byte
+	offset := size
+	for offset > 0 && bytes.Count(bytes.TrimSuffix(buf, []byte("\n")), []byte("\n")) < n {
+		readSize := int64(chunk)
+		if offset < readSize {
+			readSize = offset
+		}
+		offset -= readSize
+		part := make([]byte, readSize)
+		if _, err := f.ReadAt(part, offset); err != nil && err != io.EOF {
+			return nil, 0, err
+		}
+		buf = append(part, buf...)
+	}
+
+	text := string(bytes.TrimSuffix(buf, []byte("\n")))
+	if text == "" {
+		return nil, size, nil
+	}
+	lines := bytes.Split([]byte(text), []byte("\n"))
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		result[i] = string(line)
+	}
+	return result, size, nil
+}
+
+// Follow copies what is appended to the file at path from offset on to
+// w, checking every interval until ctx is done. When the file is rotated
+// (replaced or truncated), it continues with the new file from its start.
+func Follow(ctx context.Context, path string, offset int64, w io.Writer, interval time.Duration) error {
+	var f *os.File
+	defer func() {
+		if f != nil {
+			f.Close()
+		}
+	}()
+
+	for {
+		if f == nil {
+			if opened, err := os.Open(path); err == nil {
+				f = opened
+				if _, err := f.Seek(offset, io.SeekStart); err != nil {
+					return err
+				}
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+
+		if f != nil {
+			n, err := io.Copy(w, f)
+			if err != nil {
+				return err
+			}
+			offset += n
+
+			// Rotated: finish the old file above, then switch to the new one
+			current, statErr := os.Stat(path)
+			own, ownErr := f.Stat()
+			switch {
+			case statErr != nil || ownErr != nil || !os.SameFile(current, own):
+				f.Close()
+				f = nil
+				offset = 0
+			case current.Size() < offset:
+				offset = 0
+				if _, err := f.Seek(0, io.SeekStart); err != nil {
+					return err
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tail.log")
+
+	lines, size, err := Tail(path, 5)
+	if err != nil || lines != nil || size != 0 {
+		t.Fatalf("Tail(missing) = %v, %d, %v", lines, size, err)
+	}
+
+	var content strings.Builder
+	for i := 1; i <= 10000; i++ {
+		fmt.Fprintf(&content, "line %d\n", i)
+	}
+	if err := os.WriteFile(path, []byte(content.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lines, size, err = Tail(path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(lines, ",") != "line 9998,line 9999,line 10000" {
+		t.Errorf("Tail(3) = %v", lines)
+	}
+	if size != int64(content.Len()) {
+		t.Errorf("size = %d, want %d", size, content.Len())
+	}
+
+	lines, _, _ = Tail(path, 20000)
+	if len(lines) != 10000 || lines[0] != "line 1" {
+		t.Errorf("Tail(more than the file) returned %d lines starting %q", len(lines), lines[0])
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe to read while Follow writes to it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestFollow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "follow.log")
+	if err := os.WriteFile(path, []byte("old line\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, size, _ := Tail(path, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var out syncBuffer
+	done := make(chan error)
+	go func() { done <- Follow(ctx, path, size, &out, 5*time.Millisecond) }()
+
+	waitFor := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !strings.Contains(out.String(), want) {
+			if time.Now().After(deadline) {
+				t.Fatalf("output %q never contained %q", out.String(), want)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	appendLine := func(line string) {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintln(f, line)
+		f.Close()
+	}
+
+	appendLine("new line")
+	waitFor("new line")
+
+	// Rotation: the file is renamed and a new one started
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendLine("after rotation")
+	waitFor("after rotation")
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Follow() error = %v", err)
+	}
+	if strings.Contains(out.String(), "old line") {
+		t.Errorf("Follow printed lines before offset: %q", out.String())
+	}
+}
@@ -0,0 +1,289 @@
+package mqtt
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"time"
+)
+
+// MQTT 3.1.1 control packet types (high nibble of the first byte)
+const (
+	packetConnect    = 1
+	packetConnack    = 2
+	packetPublish    = 3
+	packetPuback     = 4
+	packetPubrec     = 5
+	packetPubrel     = 6
+	packetPubcomp    = 7
+	packetDisconnect = 14
+)
+
+// maxStringLength is the longest UTF-8 string a packet field can hold: its
+// length is sent in two bytes
+const maxStringLength = 65535
+
+// keepAlive is announced in CONNECT; a connection only lives for one
+// publish, far shorter than this
+const keepAlive = 60
+
+// connackErrors explains the CONNACK return codes that refuse a connection
+var connackErrors = map[byte]string{
+	1: "unacceptable protocol version (the broker must support MQTT 3.1.1)",
+	2: "client ID rejected",
+	3: "server unavailable",
+	4: "bad username or password",
+	5: "not authorized",
+}
+
+// publication is one message published to a broker
+type publication struct {
+	Topic   string
+	Payload []byte
+	QoS     byte
+	Retain  bool
+}
+
+// session is the connection settings of a publish
+type session struct {
+	ClientID string
+	Username string
+	Password string
+	TLS      *tls.Config // Used for mqtts:// brokers
+	Timeout  time.Duration
+}
+
+// brokerAddress returns host:port of a broker URL and whether it uses
+// TLS. The port defaults to 1883, or 8883 with TLS.
+func brokerAddress(broker string) (string, bool, error) {
+	u, err := url.Parse(broker)
+	if err != nil {
+		return "", false, err
+	}
+	var useTLS bool
+	switch u.Scheme {
+	case "mqtt", "tcp":
+	case "mqtts", "ssl", "tls":
+		useTLS = true
+	default:
+		return "", false, fmt.Errorf("unsupported scheme %q (use mqtt:// or mqtts://)", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return "", false, fmt.Errorf("no host in %q", broker)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "1883"
+		if useTLS {
+			port = "8883"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port), useTLS, nil
+}
+
+// publish connects to broker, publishes msg and disconnects. With QoS 1
+// or 2 it returns once the broker has acknowledged the message.
+func publish(broker string, s session, msg publication) error {
+	addr, useTLS, err := brokerAddress(broker)
+	if err != nil {
+		return err
+	}
+	// Build both packets first, so an over-long field fails before dialing
+	connect, err := connectPacket(s)
+	if err != nil {
+		return err
+	}
+	const packetID = 1
+	pub, err := publishPacket(msg, packetID)
+	if err != nil {
+		return err
+	}
+
+	dialer := &net.Dialer{Timeout: s.Timeout}
+	var conn net.Conn
+	if useTLS {
+		cfg := s.TLS
+		if cfg == nil {
+			cfg = &tls.Config{}
+		}
+		if cfg.ServerName == "" && !cfg.InsecureSkipVerify {
+			cfg = cfg.Clone()
+			cfg.ServerName, _, _ = net.SplitHostPort(addr)
+		}
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, cfg)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(s.Timeout))
+
+	r := bufio.NewReader(conn)
+	if _, err := conn.Write(connect); err != nil {
+		return err
+	}
+	kind, body, err := readPacket(r)
+	if err != nil {
+		return fmt.Errorf("no CONNACK: %w", err)
+	}
+	if kind != packetConnack || len(body) != 2 {
+		return fmt.Errorf("expected CONNACK, got packet type %d", kind)
+	}
+	if code := body[1]; code != 0 {
+		if reason, ok := connackErrors[code]; ok {
+			return fmt.Errorf("connection refused: %s", reason)
+		}
+		return fmt.Errorf("connection refused (code %d)", code)
+	}
+
+	if _, err := conn.Write(pub); err != nil {
+		return err
+	}
+	switch msg.QoS {
+	case 1:
+		if err := awaitAck(r, packetPuback, packetID); err != nil {
+			return err
+		}
+	case 2:
+		if err := awaitAck(r, packetPubrec, packetID); err != nil {
+			return err
+		}
+		if _, err := conn.Write(ackPacket(packetPubrel<<4|0x02, packetID)); err != nil {
+			return err
+		}
+		if err := awaitAck(r, packetPubcomp, packetID); err != nil {
+			return err
+		}
+	}
+
+	_, err = conn.Write([]byte{packetDisconnect << 4, 0})
+	return err
+}
+
+// awaitAck reads packets until the acknowledgement kind for packetID
+func awaitAck(r *bufio.Reader, kind byte, packetID uint16) error {
+	for {
+		got, body, err := readPacket(r)
+		if err != nil {
+			return fmt.Errorf("publish not acknowledged: %w", err)
+		}
+		if got == kind && len(body) >= 2 && binary.BigEndian.Uint16(body) == packetID {
+			return nil
+		}
+	}
+}
+
+// connectPacket builds CONNECT with a clean session
+func connectPacket(s session) ([]byte, error) {
+	body, _ := appendString(nil, "protocol name", "MQTT")
+	body = append(body, 4) // Protocol level 3.1.1
+	flags := byte(0x02)    // Clean session
+	if s.Username != "" {
+		flags |= 0x80
+		if s.Password != "" {
+			flags |= 0x40
+		}
+	}
+	body = append(body, flags, keepAlive>>8, keepAlive&0xff)
+	body, err := appendString(body, "client ID", s.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if s.Username != "" {
+		if body, err = appendString(body, "username", s.Username); err != nil {
+			return nil, err
+		}
+		if s.Password != "" {
+			if body, err = appendString(body, "password", s.Password); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return packet(packetConnect<<4, body), nil
+}
+
+// publishPacket builds PUBLISH; packetID is only sent with QoS 1 and 2
+func publishPacket(msg publication, packetID uint16) ([]byte, error) {
+	header := byte(packetPublish<<4) | msg.QoS<<1
+	if msg.Retain {
+		header |= 0x01
+	}
+	body, err := appendString(nil, "topic", msg.Topic)
+	if err != nil {
+		return nil, err
+	}
+	if msg.QoS > 0 {
+		body = binary.BigEndian.AppendUint16(body, packetID)
+	}
+	body = append(body, msg.Payload...)
+	return packet(header, body), nil
+}
+
+// ackPacket builds PUBACK, PUBREC, PUBREL or PUBCOMP
+func ackPacket(header byte, packetID uint16) []byte {
+	return packet(header, binary.BigEndian.AppendUint16(nil, packetID))
+}
+
+// packet prefixes body with the fixed header: the first byte and the
+// remaining length as a variable-length integer
+func packet(header byte, body []byte) []byte {
+	buf := []byte{header}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		buf = append(buf, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(buf, body...)
+}
+
+// appendString appends a length-prefixed UTF-8 string; field names it in
+// the error when s is too long for the two-byte length
+func appendString(buf []byte, field, s string) ([]byte, error) {
+	if len(s) > maxStringLength {
+		return nil, fmt.Errorf("mqtt %s is longer than %d bytes", field, maxStringLength)
+	}
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(s)))
+	return append(buf, s...), nil
+}
+
+// readPacket reads one packet and returns its type and body
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, nil, errors.New("malformed remaining length")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		multiplier *= 128
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header >> 4, body, nil
+}
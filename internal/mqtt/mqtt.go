@@ -0,0 +1,227 @@
+// Package mqtt publishes notifications to an MQTT broker, so home
+// automation such as Home Assistant can react to them, e.g. by flashing a
+// light when Claude needs permission.
+package mqtt
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/errorhandler"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/platform"
+	"github.com/777genius/claude-notifications/internal/webhook"
+)
+
+// DefaultTopic is used when no topic template is configured. It uses the
+// same fields as custom webhook templates.
+const DefaultTopic = `claude-notifications/{{.Status}}`
+
+const defaultTimeout = 10 * time.Second
+
+// Payload is the JSON message published when no payload template is set
+type Payload struct {
+	Status         string `json:"status"`
+	Title          string `json:"title"`
+	Priority       string `json:"priority"`
+	Message        string `json:"message"`
+	SessionID      string `json:"session_id"`
+	Project        string `json:"project,omitempty"`
+	Repo           string `json:"repo,omitempty"`
+	Branch         string `json:"branch,omitempty"`
+	ElapsedSeconds int64  `json:"elapsed_seconds,omitempty"`
+	Timestamp      string `json:"timestamp"`
+	Source         string `json:"source"`
+}
+
+// Publisher publishes notifications to an MQTT broker
+type Publisher struct {
+	cfg         config.MQTTConfig
+	statuses    map[string]config.StatusInfo
+	topic       *template.Template
+	payload     *template.Template // nil = Payload as JSON
+	templateErr error
+	clientID    string
+	timeout     time.Duration
+
+	wg sync.WaitGroup
+}
+
+// New creates an MQTT publisher. Template errors are reported by Publish.
+func New(cfg *config.Config) *Publisher {
+	mqttCfg := cfg.Notifications.MQTT
+
+	timeout, _ := time.ParseDuration(mqttCfg.Timeout)
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	clientID := mqttCfg.ClientID
+	if clientID == "" {
+		// Brokers disconnect an older client when a new one connects with the same ID
+		clientID = "claude-notifications-" + platform.RandomSuffix()
+	}
+
+	p := &Publisher{cfg: mqttCfg, statuses: cfg.Statuses, clientID: clientID, timeout: timeout}
+
+	topicText := mqttCfg.Topic
+	if topicText == "" {
+		topicText = DefaultTopic
+	}
+	if p.topic, p.templateErr = webhook.ParseBodyTemplate(topicText); p.templateErr != nil {
+		p.templateErr = fmt.Errorf("invalid mqtt topic template: %w", p.templateErr)
+	} else if mqttCfg.Payload != "" {
+		if p.payload, p.templateErr = webhook.ParseBodyTemplate(mqttCfg.Payload); p.templateErr != nil {
+			p.templateErr = fmt.Errorf("invalid mqtt payload template: %w", p.templateErr)
+		}
+	}
+	if p.templateErr != nil {
+		logging.Error("%v", p.templateErr)
+	}
+
+	return p
+}
+
+// Publish renders and publishes a notification
+func (p *Publisher) Publish(status analyzer.Status, message, sessionID string, meta webhook.Meta) error {
+	if p.templateErr != nil {
+		return p.templateErr
+	}
+
+	title := p.statuses[string(status)].Title
+	if meta.Title != "" {
+		title = meta.Title
+	}
+	data := webhook.NewTemplateData(status, title, message, sessionID, meta, time.Now())
+
+	topic, err := webhook.Render(p.topic, data)
+	if err != nil {
+		return fmt.Errorf("failed to render mqtt topic: %w", err)
+	}
+	topic = strings.TrimSpace(topic)
+	if err := checkTopic(topic); err != nil {
+		return err
+	}
+	payload, err := p.render(data)
+	if err != nil {
+		return err
+	}
+
+	tlsConfig, err := p.tlsConfig()
+	if err != nil {
+		return err
+	}
+	s := session{ClientID: p.clientID, Username: p.cfg.Username, Password: p.cfg.Password, TLS: tlsConfig, Timeout: p.timeout}
+	msg := publication{Topic: topic, Payload: payload, QoS: byte(p.cfg.QoS), Retain: p.cfg.Retain}
+	if err := publish(p.cfg.Broker, s, msg); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", p.cfg.Broker, err)
+	}
+	logging.Info("MQTT message published to %s", topic)
+	return nil
+}
+
+// PublishAsyncWithResult publishes in the background and reports the
+// outcome to done (nil error = published); Shutdown waits for it. done
+// may be nil.
+func (p *Publisher) PublishAsyncWithResult(status analyzer.Status, message, sessionID string, meta webhook.Meta, done func(err error)) {
+	p.wg.Add(1)
+	errorhandler.SafeGo(func() {
+		defer p.wg.Done()
+
+		err := p.Publish(status, message, sessionID, meta)
+		if err != nil {
+			errorhandler.HandleError(err, "Async MQTT publish failed")
+		}
+		if done != nil {
+			done(err)
+		}
+	})
+}
+
+// Shutdown waits for in-flight messages to be published (with timeout)
+func (p *Publisher) Shutdown(timeout time.Duration) error {
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		logging.Warn("MQTT shutdown timeout, some messages may not be published")
+		return fmt.Errorf("shutdown timeout after %v", timeout)
+	}
+}
+
+// render returns the message body: the payload template's output, or
+// Payload as JSON
+func (p *Publisher) render(data webhook.TemplateData) ([]byte, error) {
+	if p.payload != nil {
+		text, err := webhook.Render(p.payload, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render mqtt payload: %w", err)
+		}
+		return []byte(text), nil
+	}
+	return json.Marshal(Payload{
+		Status:         data.Status,
+		Title:          data.Title,
+		Priority:       data.Priority,
+		Message:        data.Message,
+		SessionID:      data.SessionID,
+		Project:        data.Project,
+		Repo:           data.Repo,
+		Branch:         data.Branch,
+		ElapsedSeconds: data.ElapsedSeconds,
+		Timestamp:      data.Timestamp,
+		Source:         data.Source,
+	})
+}
+
+// tlsConfig loads the CA and client certificate of the tls settings
+func (p *Publisher) tlsConfig() (*tls.Config, error) {
+	t := p.cfg.TLS
+	cfg := &tls.Config{InsecureSkipVerify: t.InsecureSkipVerify}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read mqtt caFile: %w", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("mqtt caFile %s holds no PEM certificate", t.CAFile)
+		}
+	}
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load mqtt client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// checkTopic rejects topics a broker refuses to publish to: empty, with
+// wildcards, or too long
+func checkTopic(topic string) error {
+	switch {
+	case topic == "":
+		return fmt.Errorf("mqtt topic is empty")
+	case strings.ContainsAny(topic, "+#\x00"):
+		return fmt.Errorf("mqtt topic %q contains a wildcard (+ or #)", topic)
+	case len(topic) > 65535:
+		return fmt.Errorf("mqtt topic is longer than 65535 bytes")
+	}
+	return nil
+}
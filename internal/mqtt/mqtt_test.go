@@ -0,0 +1,326 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/webhook"
+)
+
+// fakeBroker is a minimal MQTT 3.1.1 broker that records one session
+type fakeBroker struct {
+	addr        string
+	connackCode byte
+
+	mu         sync.Mutex
+	clientID   string
+	username   string
+	password   string
+	topic      string
+	payload    []byte
+	header     byte // First byte of the PUBLISH packet
+	sawPubrel  bool
+	dropPubrel bool // Close the connection on PUBREL instead of sending PUBCOMP
+	disconnect bool
+	done       chan struct{}
+}
+
+func startFakeBroker(t *testing.T, connackCode byte) *fakeBroker {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	b := &fakeBroker{addr: ln.Addr().String(), connackCode: connackCode, done: make(chan struct{})}
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		defer close(b.done)
+		b.serve(conn)
+	}()
+	return b
+}
+
+func (b *fakeBroker) serve(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	for {
+		first, err := r.Peek(1)
+		if err != nil {
+			return
+		}
+		header := first[0]
+		kind, body, err := readPacket(r)
+		if err != nil {
+			return
+		}
+
+		b.mu.Lock()
+		switch kind {
+		case packetConnect:
+			b.parseConnect(body)
+			_, _ = conn.Write([]byte{packetConnack << 4, 2, 0, b.connackCode})
+		case packetPublish:
+			b.header = header
+			n := int(binary.BigEndian.Uint16(body))
+			b.topic = string(body[2 : 2+n])
+			rest := body[2+n:]
+			switch qos := header >> 1 & 0x03; qos {
+			case 1:
+				_, _ = conn.Write(ackPacket(packetPuback<<4, binary.BigEndian.Uint16(rest)))
+				rest = rest[2:]
+			case 2:
+				_, _ = conn.Write(ackPacket(packetPubrec<<4, binary.BigEndian.Uint16(rest)))
+				rest = rest[2:]
+			}
+			b.payload = rest
+		case packetPubrel:
+			b.sawPubrel = true
+			if b.dropPubrel {
+				b.mu.Unlock()
+				return
+			}
+			_, _ = conn.Write(ackPacket(packetPubcomp<<4, binary.BigEndian.Uint16(body)))
+		case packetDisconnect:
+			b.disconnect = true
+			b.mu.Unlock()
+			return
+		}
+		b.mu.Unlock()
+	}
+}
+
+// parseConnect records the client ID and credentials of CONNECT
+func (b *fakeBroker) parseConnect(body []byte) {
+	flags := body[7]
+	rest := body[10:]
+	next := func() string {
+		n := int(binary.BigEndian.Uint16(rest))
+		s := string(rest[2 : 2+n])
+		rest = rest[2+n:]
+		return s
+	}
+	b.clientID = next()
+	if flags&0x80 != 0 {
+		b.username = next()
+	}
+	if flags&0x40 != 0 {
+		b.password = next()
+	}
+}
+
+func (b *fakeBroker) wait(t *testing.T) {
+	t.Helper()
+	select {
+	case <-b.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("broker session did not finish")
+	}
+}
+
+func newPublisher(mqttCfg config.MQTTConfig) *Publisher {
+	cfg := config.DefaultConfig()
+	cfg.Notifications.MQTT = mqttCfg
+	return New(cfg)
+}
+
+func TestPublish_DefaultTopicAndPayload(t *testing.T) {
+	broker := startFakeBroker(t, 0)
+	p := newPublisher(config.MQTTConfig{Enabled: true, Broker: "mqtt://" + broker.addr, Username: "ha", Password: "secret"})
+
+	meta := webhook.Meta{Project: "api", Elapsed: 90 * time.Second}
+	if err := p.Publish(analyzer.StatusQuestion, "Which database?", "s1", meta); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	broker.wait(t)
+
+	if broker.topic != "claude-notifications/question" {
+		t.Errorf("topic = %q", broker.topic)
+	}
+	if broker.username != "ha" || broker.password != "secret" {
+		t.Errorf("credentials = %q/%q", broker.username, broker.password)
+	}
+	if !strings.HasPrefix(broker.clientID, "claude-notifications-") {
+		t.Errorf("clientID = %q", broker.clientID)
+	}
+	if qos, retain := broker.header>>1&0x03, broker.header&0x01; qos != 0 || retain != 0 {
+		t.Errorf("qos = %d, retain = %d; want 0, 0", qos, retain)
+	}
+	if !broker.disconnect {
+		t.Error("client did not send DISCONNECT")
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(broker.payload, &payload); err != nil {
+		t.Fatalf("payload is not JSON: %v: %s", err, broker.payload)
+	}
+	if payload.Status != "question" || payload.Message != "Which database?" || payload.Project != "api" ||
+		payload.ElapsedSeconds != 90 || payload.SessionID != "s1" || payload.Title == "" {
+		t.Errorf("payload = %+v", payload)
+	}
+}
+
+func TestPublish_TemplatesQoSAndRetain(t *testing.T) {
+	for _, qos := range []int{1, 2} {
+		broker := startFakeBroker(t, 0)
+		p := newPublisher(config.MQTTConfig{
+			Enabled:  true,
+			Broker:   "tcp://" + broker.addr,
+			Topic:    "home/claude/{{.Project}}/{{.Priority}}",
+			Payload:  "{{.Status}}",
+			QoS:      qos,
+			Retain:   true,
+			ClientID: "desk",
+		})
+
+		if err := p.Publish(analyzer.StatusPlanReady, "Plan ready", "s1", webhook.Meta{Project: "web"}); err != nil {
+			t.Fatalf("qos %d: Publish: %v", qos, err)
+		}
+		broker.wait(t)
+
+		if broker.topic != "home/claude/web/normal" || string(broker.payload) != "plan_ready" || broker.clientID != "desk" {
+			t.Errorf("qos %d: topic %q, payload %q, clientID %q", qos, broker.topic, broker.payload, broker.clientID)
+		}
+		if got := int(broker.header >> 1 & 0x03); got != qos || broker.header&0x01 == 0 {
+			t.Errorf("qos %d: header %08b", qos, broker.header)
+		}
+		if broker.sawPubrel != (qos == 2) {
+			t.Errorf("qos %d: PUBREL sent = %v", qos, broker.sawPubrel)
+		}
+	}
+}
+
+func TestPublish_ConnectionRefused(t *testing.T) {
+	broker := startFakeBroker(t, 4)
+	p := newPublisher(config.MQTTConfig{Enabled: true, Broker: "mqtt://" + broker.addr})
+
+	err := p.Publish(analyzer.StatusTaskComplete, "done", "s1", webhook.Meta{})
+	if err == nil || !strings.Contains(err.Error(), "bad username or password") {
+		t.Errorf("err = %v, want a bad credentials error", err)
+	}
+}
+
+func TestPublish_QoS2Refused(t *testing.T) {
+	broker := startFakeBroker(t, 5)
+	p := newPublisher(config.MQTTConfig{Enabled: true, Broker: "mqtt://" + broker.addr, QoS: 2})
+
+	err := p.Publish(analyzer.StatusTaskComplete, "done", "s1", webhook.Meta{})
+	if err == nil || !strings.Contains(err.Error(), "not authorized") {
+		t.Errorf("err = %v, want a not authorized error", err)
+	}
+	broker.wait(t)
+	if broker.topic != "" || broker.sawPubrel {
+		t.Errorf("refused client published: topic %q, PUBREL sent = %v", broker.topic, broker.sawPubrel)
+	}
+}
+
+func TestPublish_QoS2WithoutPubcomp(t *testing.T) {
+	broker := startFakeBroker(t, 0)
+	broker.mu.Lock()
+	broker.dropPubrel = true
+	broker.mu.Unlock()
+	p := newPublisher(config.MQTTConfig{Enabled: true, Broker: "mqtt://" + broker.addr, QoS: 2})
+
+	err := p.Publish(analyzer.StatusTaskComplete, "done", "s1", webhook.Meta{})
+	if err == nil || !strings.Contains(err.Error(), "not acknowledged") {
+		t.Errorf("err = %v, want an unacknowledged publish", err)
+	}
+}
+
+func TestPublish_RejectsLongFields(t *testing.T) {
+	long := strings.Repeat("x", 65536)
+	tests := []struct {
+		name string
+		cfg  config.MQTTConfig
+		want string
+	}{
+		{"client ID", config.MQTTConfig{ClientID: long}, "mqtt client ID is longer than 65535 bytes"},
+		{"username", config.MQTTConfig{Username: long}, "mqtt username is longer than 65535 bytes"},
+		{"password", config.MQTTConfig{Username: "ha", Password: long}, "mqtt password is longer than 65535 bytes"},
+		{"topic", config.MQTTConfig{Topic: long}, "mqtt topic is longer than 65535 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// No broker listens here: the error must come before dialing
+			tt.cfg.Enabled = true
+			tt.cfg.Broker = "mqtt://127.0.0.1:1"
+			err := newPublisher(tt.cfg).Publish(analyzer.StatusTaskComplete, "done", "s1", webhook.Meta{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+
+	// The longest allowed string still fits
+	if _, err := appendString(nil, "topic", long[1:]); err != nil {
+		t.Errorf("appendString(65535 bytes) error = %v", err)
+	}
+}
+
+func TestPublish_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.MQTTConfig
+		meta webhook.Meta
+		want string
+	}{
+		{"bad topic template", config.MQTTConfig{Broker: "mqtt://localhost", Topic: "{{.Nope"}, webhook.Meta{}, "invalid mqtt topic template"},
+		{"bad payload template", config.MQTTConfig{Broker: "mqtt://localhost", Payload: "{{"}, webhook.Meta{}, "invalid mqtt payload template"},
+		{"wildcard in topic", config.MQTTConfig{Broker: "mqtt://localhost", Topic: "claude/{{.Project}}"}, webhook.Meta{Project: "c++"}, "wildcard"},
+		{"empty topic", config.MQTTConfig{Broker: "mqtt://localhost", Topic: "{{.Project}}"}, webhook.Meta{}, "topic is empty"},
+		{"missing CA file", config.MQTTConfig{Broker: "mqtts://localhost", TLS: config.MQTTTLSConfig{CAFile: "/nonexistent/ca.pem"}}, webhook.Meta{}, "caFile"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newPublisher(tt.cfg).Publish(analyzer.StatusTaskComplete, "done", "s1", tt.meta)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBrokerAddress(t *testing.T) {
+	tests := []struct {
+		broker  string
+		addr    string
+		useTLS  bool
+		wantErr bool
+	}{
+		{"mqtt://broker.local", "broker.local:1883", false, false},
+		{"tcp://10.0.0.2:1884", "10.0.0.2:1884", false, false},
+		{"mqtts://broker.local", "broker.local:8883", true, false},
+		{"ssl://[::1]:8884", "[::1]:8884", true, false},
+		{"ws://broker.local", "", false, true},
+		{"mqtt://", "", false, true},
+	}
+	for _, tt := range tests {
+		addr, useTLS, err := brokerAddress(tt.broker)
+		if (err != nil) != tt.wantErr || addr != tt.addr || useTLS != tt.useTLS {
+			t.Errorf("brokerAddress(%q) = %q, %v, %v", tt.broker, addr, useTLS, err)
+		}
+	}
+}
+
+func TestPacket_RemainingLength(t *testing.T) {
+	for _, n := range []int{0, 127, 128, 16383, 16384, 300000} {
+		data := packet(packetPublish<<4, make([]byte, n))
+		kind, body, err := readPacket(bufio.NewReader(strings.NewReader(string(data))))
+		if err != nil || kind != packetPublish || len(body) != n {
+			t.Errorf("length %d: kind %d, body %d, err %v", n, kind, len(body), err)
+		}
+	}
+}
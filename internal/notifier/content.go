@@ -0,0 +1,41 @@
+package notifier
+
+import (
+	"strings"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/logging"
+)
+
+// ApplyContent returns ev with its title and message rendered by the
+// templates of content. Unset templates, events without content data and
+// templates that fail leave the title and message unchanged.
+func ApplyContent(ev Event, content config.ContentConfig) Event {
+	if ev.Content == nil {
+		return ev
+	}
+	if title, ok := renderContent("title", content.Title, *ev.Content); ok {
+		ev.Title = title
+	}
+	if body, ok := renderContent("body", content.Body, *ev.Content); ok {
+		ev.Message = body
+	}
+	return ev
+}
+
+// renderContent executes a content template; false when it is unset or fails
+func renderContent(name, text string, data config.ContentData) (string, bool) {
+	tmpl, err := config.ParseContentTemplate(name, text)
+	if tmpl == nil {
+		if err != nil {
+			logging.Warn("Invalid %s template: %v", name, err)
+		}
+		return "", false
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		logging.Warn("Failed to render %s template: %v", name, err)
+		return "", false
+	}
+	return b.String(), true
+}
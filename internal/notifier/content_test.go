@@ -0,0 +1,51 @@
+package notifier
+
+import (
+	"testing"
+
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+func TestApplyContent(t *testing.T) {
+	ev := Event{
+		Title:   "",
+		Message: "[bold-cat|main api] Built the parser",
+		Content: &config.ContentData{
+			Title:   "✅ Completed",
+			Message: "Built the parser",
+			Status:  "task_complete",
+			Event:   "Stop",
+			Project: "api",
+			Branch:  "main",
+			Session: "bold-cat",
+			Elapsed: "4m12s",
+		},
+	}
+
+	got := ApplyContent(ev, config.ContentConfig{
+		Title: "{{.Project}}{{if .Branch}} ({{.Branch}}){{end}}: {{.Title}}",
+		Body:  "{{truncate .Message 6}} in {{.Elapsed}}",
+	})
+	if got.Title != "api (main): ✅ Completed" {
+		t.Errorf("title = %q", got.Title)
+	}
+	if got.Message != "Built… in 4m12s" {
+		t.Errorf("message = %q", got.Message)
+	}
+
+	// Unset templates keep the title and message
+	if got := ApplyContent(ev, config.ContentConfig{}); got.Title != ev.Title || got.Message != ev.Message {
+		t.Errorf("without templates = %q, %q", got.Title, got.Message)
+	}
+
+	// A template that fails to render keeps the message
+	if got := ApplyContent(ev, config.ContentConfig{Body: "{{.Missing}}"}); got.Message != ev.Message {
+		t.Errorf("failed template message = %q", got.Message)
+	}
+
+	// Events without content data, such as digests, are sent as is
+	digest := Event{Title: "🌙 3 notifications", Message: "..."}
+	if got := ApplyContent(digest, config.ContentConfig{Title: "{{.Project}}"}); got.Title != digest.Title {
+		t.Errorf("digest title = %q", got.Title)
+	}
+}
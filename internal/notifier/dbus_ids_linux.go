@@ -0,0 +1,81 @@
+//go:build linux
+
+// ABOUTME: Remembers the IDs of notifications sent directly over D-Bus by replace key.
+// ABOUTME: Each hook is a new process, so an update finds the notification to replace here.
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/daemon"
+)
+
+// dbusIDMaxAge drops IDs of notifications long expired, so the file stays small
+const dbusIDMaxAge = 24 * time.Hour
+
+// dbusID is the last notification sent for a key
+type dbusID struct {
+	ID   uint32    `json:"id"`
+	Sent time.Time `json:"sent"`
+}
+
+// dbusIDsPath returns the file of D-Bus notification IDs in the private
+// runtime directory; a variable so tests can redirect it
+var dbusIDsPath = func() string {
+	return filepath.Join(daemon.GetRuntimeDir(), "dbus-ids.json")
+}
+
+// loadDBusIDs reads the IDs at path; a missing or corrupt file is empty
+func loadDBusIDs(path string) map[string]dbusID {
+	ids := make(map[string]dbusID)
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &ids)
+	}
+	return ids
+}
+
+// lastDBusID returns the ID of the notification last sent for key (0 = none)
+func lastDBusID(key string) uint32 {
+	if key == "" {
+		return 0
+	}
+	return loadDBusIDs(dbusIDsPath())[key].ID
+}
+
+// saveDBusID records id as the notification of key, dropping old entries.
+// Two hooks saving at once may lose one entry; its next update then shows
+// a new notification instead of replacing it.
+func saveDBusID(key string, id uint32, now time.Time) error {
+	if key == "" || id == 0 {
+		return nil
+	}
+	path := dbusIDsPath()
+	ids := loadDBusIDs(path)
+	for k, v := range ids {
+		if now.Sub(v.Sent) > dbusIDMaxAge {
+			delete(ids, k)
+		}
+	}
+	ids[key] = dbusID{ID: id, Sent: now}
+
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return fmt.Errorf("failed to serialize notification IDs: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create runtime directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write notification IDs: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write notification IDs: %w", err)
+	}
+	return nil
+}
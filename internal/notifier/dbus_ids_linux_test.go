@@ -0,0 +1,70 @@
+//go:build linux
+
+package notifier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+func TestDBusIDs_ReplaceByKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runtime", "dbus-ids.json")
+	orig := dbusIDsPath
+	dbusIDsPath = func() string { return path }
+	defer func() { dbusIDsPath = orig }()
+
+	if got := lastDBusID("heartbeat-abc"); got != 0 {
+		t.Errorf("lastDBusID() before any notification = %d, want 0", got)
+	}
+
+	now := time.Now()
+	if err := saveDBusID("old", 3, now.Add(-2*dbusIDMaxAge)); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveDBusID("heartbeat-abc", 41, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveDBusID("heartbeat-abc", 42, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveDBusID("", 7, now); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := lastDBusID("heartbeat-abc"); got != 42 {
+		t.Errorf("lastDBusID() = %d, want the latest ID 42", got)
+	}
+	if got := lastDBusID("old"); got != 0 {
+		t.Errorf("lastDBusID(old) = %d, want entries older than dbusIDMaxAge dropped", got)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("ID file = %v, %v; want mode 0600", info, err)
+	}
+}
+
+func TestDBusReplaceKey(t *testing.T) {
+	grouped := config.DesktopConfig{GroupBySession: true}
+	tests := []struct {
+		name      string
+		sessionID string
+		replace   string
+		desktop   config.DesktopConfig
+		want      string
+	}{
+		{"update", "abc", "heartbeat-abc", config.DesktopConfig{}, "heartbeat-abc"},
+		{"new notification", "abc", "", config.DesktopConfig{}, ""},
+		{"grouped by session", "abc", "", grouped, "abc"},
+		{"update wins over the session", "abc", "heartbeat-abc", grouped, "heartbeat-abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dbusReplaceKey(tt.sessionID, tt.replace, tt.desktop); got != tt.want {
+				t.Errorf("dbusReplaceKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+//go:build linux
+
+// ABOUTME: Direct freedesktop notifications over D-Bus, without shelling out to notify-send.
+// ABOUTME: Used when the click-to-focus daemon is disabled or unavailable.
+package notifier
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/esiqveland/notify"
+	"github.com/godbus/dbus/v5"
+
+	"github.com/777genius/claude-notifications/internal/daemon"
+)
+
+// sendViaDBus sends a notification to org.freedesktop.Notifications on the
+// session bus and returns the server-assigned notification ID. A non-zero
+// replacesID updates that notification in place.
+// A private connection is used so closing it never affects other bus users.
+func sendViaDBus(title, body, appIcon, urgency string, replacesID uint32) (uint32, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return 0, fmt.Errorf("failed to connect to D-Bus session bus: %w", err)
+	}
+	defer conn.Close()
+
+	n := buildDBusNotification(title, body, appIcon, urgency)
+	n.ReplacesID = replacesID
+	return notify.SendNotification(conn, n)
+}
+
+// buildDBusNotification builds the freedesktop notification payload.
+// Sound is suppressed because Go plays sounds via the audio player.
+func buildDBusNotification(title, body, appIcon, urgency string) notify.Notification {
+	// Notification servers resolve relative icon paths against their own cwd
+	if appIcon != "" && !filepath.IsAbs(appIcon) {
+		if abs, err := filepath.Abs(appIcon); err == nil {
+			appIcon = abs
+		}
+	}
+
+	n := notify.Notification{
+		AppName:       "claude-notifications",
+		AppIcon:       appIcon,
+		Summary:       title,
+		Body:          body,
+		ExpireTimeout: notify.ExpireTimeoutSetByNotificationServer,
+		Hints: map[string]dbus.Variant{
+			"suppress-sound": dbus.MakeVariant(true),
+		},
+	}
+	n.SetUrgency(daemon.ParseUrgency(urgency))
+	return n
+}
+
+// NotificationServerInfo returns the name and version of the freedesktop
+// notification server on the session bus, e.g. "gnome-shell 46.0".
+func NotificationServerInfo() (string, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to D-Bus session bus: %w", err)
+	}
+	defer conn.Close()
+
+	info, err := notify.GetServerInformation(conn)
+	if err != nil {
+		return "", fmt.Errorf("no notification server on the session bus: %w", err)
+	}
+	return info.Name + " " + info.Version, nil
+}
@@ -0,0 +1,58 @@
+//go:build linux
+
+package notifier
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/esiqveland/notify"
+)
+
+func TestBuildDBusNotification(t *testing.T) {
+	n := buildDBusNotification("✅ Completed", "Done", "/usr/share/icons/claude.png", "critical")
+
+	if n.AppName != "claude-notifications" {
+		t.Errorf("AppName = %q, want %q", n.AppName, "claude-notifications")
+	}
+	if n.Summary != "✅ Completed" || n.Body != "Done" {
+		t.Errorf("Summary/Body = %q/%q", n.Summary, n.Body)
+	}
+	if n.AppIcon != "/usr/share/icons/claude.png" {
+		t.Errorf("AppIcon = %q", n.AppIcon)
+	}
+	if n.ReplacesID != 0 {
+		t.Errorf("ReplacesID = %d, want 0", n.ReplacesID)
+	}
+	if len(n.Actions) != 0 {
+		t.Errorf("direct D-Bus notifications should have no actions, got %v", n.Actions)
+	}
+
+	urgency, ok := n.Hints["urgency"]
+	if !ok {
+		t.Fatal("urgency hint missing")
+	}
+	if got := urgency.Value().(byte); got != byte(notify.UrgencyCritical) {
+		t.Errorf("urgency hint = %d, want %d", got, notify.UrgencyCritical)
+	}
+	if suppress, ok := n.Hints["suppress-sound"]; !ok || suppress.Value() != true {
+		t.Error("suppress-sound hint should be true")
+	}
+}
+
+func TestBuildDBusNotification_RelativeIconMadeAbsolute(t *testing.T) {
+	n := buildDBusNotification("t", "b", "claude_icon.png", "normal")
+	if !filepath.IsAbs(n.AppIcon) {
+		t.Errorf("AppIcon = %q, want absolute path", n.AppIcon)
+	}
+}
+
+func TestBuildDBusNotification_EmptyIcon(t *testing.T) {
+	n := buildDBusNotification("t", "b", "", "")
+	if n.AppIcon != "" {
+		t.Errorf("AppIcon = %q, want empty", n.AppIcon)
+	}
+	if got := n.Hints["urgency"].Value().(byte); got != byte(notify.UrgencyNormal) {
+		t.Errorf("urgency hint = %d, want normal", got)
+	}
+}
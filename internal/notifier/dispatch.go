@@ -0,0 +1,129 @@
+package notifier
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/logging"
+)
+
+// Event is a notification about to be delivered to one or more backends
+type Event struct {
+	Status    analyzer.Status
+	Message   string // Message with session/branch/folder prefix
+	SessionID string
+	CWD       string        // Project directory (matched by route project globs)
+	Project   string        // Project folder name
+	Elapsed   time.Duration // Time since the last user prompt (0 = unknown)
+	Running   time.Duration // Time since the session started, matched by route minElapsed (0 = unknown)
+	Idle      time.Duration // Time since the last keyboard or mouse input (0 = unknown)
+
+	// Overrides from rules
+	Title    string   // Replaces the status title ("" = unchanged)
+	Sound    string   // Desktop sound ("" = status sound, "none" = silent)
+	Priority string   // "low", "normal" or "critical", mapped by each backend ("" = by status)
+	Backends []string // Deliver only to these backends (empty = all)
+
+	// Replace is the key of an earlier desktop notification this one
+	// updates in place, e.g. the session's last heartbeat ("" = new)
+	Replace string
+
+	// Content holds the fields of title and body templates (nil = sent as is,
+	// e.g. the do-not-disturb digest)
+	Content *config.ContentData
+}
+
+// Backend is a notification destination guarded by a route
+type Backend struct {
+	Name    string             // Used in logs and rule backends, e.g. "desktop" or "webhook"
+	Route   config.RouteConfig // Empty route = every event
+	Privacy string             // How much of the event the backend receives (see ApplyPrivacy; "" = all)
+	Send    func(ev Event)
+}
+
+// Dispatcher fans a notification event out to every backend whose route matches
+type Dispatcher struct {
+	backends []Backend
+}
+
+// NewDispatcher creates a dispatcher for the given backends
+func NewDispatcher(backends ...Backend) *Dispatcher {
+	return &Dispatcher{backends: backends}
+}
+
+// Add registers another backend
+func (d *Dispatcher) Add(b Backend) {
+	d.backends = append(d.backends, b)
+}
+
+// Names returns the names of the registered backends in registration order
+func (d *Dispatcher) Names() []string {
+	names := make([]string, 0, len(d.backends))
+	for _, b := range d.backends {
+		names = append(names, b.Name)
+	}
+	return names
+}
+
+// Dispatch sends the event to all matching backends in registration order
+// and returns the names of the backends that received it.
+// A panicking backend does not prevent delivery to the others.
+func (d *Dispatcher) Dispatch(ev Event) []string {
+	var sent []string
+	for _, b := range d.backends {
+		if reason := skipReason(b, ev); reason != "" {
+			logging.Debug("%s, skipping", reason)
+			continue
+		}
+		d.send(b, ev)
+		sent = append(sent, b.Name)
+	}
+	return sent
+}
+
+// Explain returns, for each registered backend in registration order, why
+// Dispatch would skip it ("" = the backend would receive the event)
+func (d *Dispatcher) Explain(ev Event) map[string]string {
+	reasons := make(map[string]string, len(d.backends))
+	for _, b := range d.backends {
+		reasons[b.Name] = skipReason(b, ev)
+	}
+	return reasons
+}
+
+// skipReason says why a backend does not receive an event ("" = it does)
+func skipReason(b Backend, ev Event) string {
+	if len(ev.Backends) > 0 && !containsName(ev.Backends, b.Name) {
+		return fmt.Sprintf("Rules exclude %s for %s event", b.Name, ev.Status)
+	}
+	if !b.Route.Matches(string(ev.Status), ev.CWD, ev.Running) {
+		return fmt.Sprintf("Route for %s does not match %s event", b.Name, ev.Status)
+	}
+	if !b.Route.MatchesIdle(ev.Idle) {
+		return fmt.Sprintf("User idle for %v, less than the minIdle of %s", ev.Idle.Round(time.Second), b.Name)
+	}
+	return ""
+}
+
+// containsName returns true if names contains name
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// send delivers to one backend, reduced to its privacy mode, recovering
+// from a panic in its Send
+func (d *Dispatcher) send(b Backend, ev Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.Error("Backend %s panicked: %v", b.Name, r)
+		}
+	}()
+	b.Send(ApplyPrivacy(ev, b.Privacy))
+}
@@ -0,0 +1,150 @@
+package notifier
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+func TestDispatcher_RoutesByRule(t *testing.T) {
+	var got []string
+	record := func(name string) func(Event) {
+		return func(ev Event) { got = append(got, name) }
+	}
+
+	d := NewDispatcher(
+		Backend{Name: "desktop", Send: record("desktop")},
+		Backend{Name: "webhook:ntfy", Route: config.RouteConfig{Statuses: []string{"question"}}, Send: record("webhook:ntfy")},
+		Backend{Name: "webhook:slack", Route: config.RouteConfig{MinElapsed: "10m"}, Send: record("webhook:slack")},
+	)
+	d.Add(Backend{Name: "email", Route: config.RouteConfig{Projects: []string{"billing-*"}}, Send: record("email")})
+	if names := d.Names(); !reflect.DeepEqual(names, []string{"desktop", "webhook:ntfy", "webhook:slack", "email"}) {
+		t.Errorf("Names() = %v", names)
+	}
+
+	tests := []struct {
+		name string
+		ev   Event
+		want []string
+	}{
+		{
+			name: "short task",
+			ev:   Event{Status: analyzer.StatusTaskComplete, CWD: "/home/dev/api", Running: 2 * time.Minute},
+			want: []string{"desktop"},
+		},
+		{
+			name: "question",
+			ev:   Event{Status: analyzer.StatusQuestion, CWD: "/home/dev/api", Running: time.Minute},
+			want: []string{"desktop", "webhook:ntfy"},
+		},
+		{
+			name: "long task in billing project",
+			ev:   Event{Status: analyzer.StatusTaskComplete, CWD: "/home/dev/billing-service", Running: 25 * time.Minute},
+			want: []string{"desktop", "webhook:slack", "email"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			sent := d.Dispatch(tt.ev)
+			if !reflect.DeepEqual(sent, tt.want) {
+				t.Errorf("Dispatch() = %v, want %v", sent, tt.want)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("backends called = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDispatcher_PanickingBackendDoesNotBlockOthers(t *testing.T) {
+	called := false
+	d := NewDispatcher(
+		Backend{Name: "broken", Send: func(Event) { panic("boom") }},
+		Backend{Name: "desktop", Send: func(Event) { called = true }},
+	)
+
+	sent := d.Dispatch(Event{Status: analyzer.StatusTaskComplete})
+
+	if !called {
+		t.Error("backend after a panicking backend should still be called")
+	}
+	if want := []string{"broken", "desktop"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("Dispatch() = %v, want %v", sent, want)
+	}
+}
+
+func TestDispatcher_EventBackendsRestrictDelivery(t *testing.T) {
+	d := NewDispatcher(
+		Backend{Name: "desktop", Send: func(Event) {}},
+		Backend{Name: "webhook", Send: func(Event) {}},
+		Backend{Name: "phone", Route: config.RouteConfig{Statuses: []string{"question"}}, Send: func(Event) {}},
+	)
+
+	sent := d.Dispatch(Event{Status: analyzer.StatusTaskComplete, Backends: []string{"desktop", "phone"}})
+
+	// phone is allowed by the event but its own route still applies
+	if want := []string{"desktop"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("Dispatch() = %v, want %v", sent, want)
+	}
+}
+
+func TestDispatcher_MinIdleEscalates(t *testing.T) {
+	d := NewDispatcher(
+		Backend{Name: "desktop", Send: func(Event) {}},
+		Backend{Name: "phone", Route: config.RouteConfig{MinIdle: "5m"}, Send: func(Event) {}},
+	)
+
+	tests := []struct {
+		name string
+		idle time.Duration
+		want []string
+	}{
+		{"at the computer", 20 * time.Second, []string{"desktop"}},
+		{"away", 12 * time.Minute, []string{"desktop", "phone"}},
+		{"idle time unknown", 0, []string{"desktop", "phone"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := d.Dispatch(Event{Status: analyzer.StatusQuestion, Idle: tt.idle})
+			if !reflect.DeepEqual(sent, tt.want) {
+				t.Errorf("Dispatch() = %v, want %v", sent, tt.want)
+			}
+		})
+	}
+}
+
+func TestDispatcher_NoBackends(t *testing.T) {
+	if sent := NewDispatcher().Dispatch(Event{Status: analyzer.StatusQuestion}); len(sent) != 0 {
+		t.Errorf("Dispatch() = %v, want none", sent)
+	}
+}
+
+func TestDispatcher_Explain(t *testing.T) {
+	d := NewDispatcher(
+		Backend{Name: "desktop", Send: func(Event) {}},
+		Backend{Name: "webhook", Send: func(Event) {}},
+		Backend{Name: "phone", Route: config.RouteConfig{Statuses: []string{"question"}}, Send: func(Event) {}},
+		Backend{Name: "pager", Route: config.RouteConfig{MinIdle: "5m"}, Send: func(Event) {}},
+	)
+
+	reasons := d.Explain(Event{Status: analyzer.StatusTaskComplete, Backends: []string{"desktop", "phone", "pager"}, Idle: time.Minute})
+
+	if reasons["desktop"] != "" {
+		t.Errorf("desktop: %q, want it chosen", reasons["desktop"])
+	}
+	for name, want := range map[string]string{
+		"webhook": "Rules exclude webhook",
+		"phone":   "Route for phone does not match",
+		"pager":   "less than the minIdle of pager",
+	} {
+		if !strings.Contains(reasons[name], want) {
+			t.Errorf("%s: %q, want it to contain %q", name, reasons[name], want)
+		}
+	}
+}
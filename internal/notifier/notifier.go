@@ -1,6 +1,7 @@
 package notifier
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"os"
@@ -18,6 +19,9 @@ import (
 	"github.com/777genius/claude-notifications/internal/errorhandler"
 	"github.com/777genius/claude-notifications/internal/logging"
 	"github.com/777genius/claude-notifications/internal/platform"
+	"github.com/777genius/claude-notifications/internal/priority"
+	"github.com/777genius/claude-notifications/internal/remote"
+	"github.com/777genius/claude-notifications/internal/sounds"
 )
 
 // Notifier sends desktop notifications
@@ -38,21 +42,41 @@ func New(cfg *config.Config) *Notifier {
 	}
 }
 
-// isTimeSensitiveStatus returns true for statuses that should break through Focus Mode
-func isTimeSensitiveStatus(status analyzer.Status) bool {
-	switch status {
-	case analyzer.StatusAPIError, analyzer.StatusAPIErrorOverloaded, analyzer.StatusSessionLimitReached:
-		return true
-	default:
-		return false
+// Options overrides how a single desktop notification is presented, e.g. by rules
+type Options struct {
+	Title   string // Replaces the status title ("" = status title)
+	Sound   string // Replaces the status sound ("" = status sound, "none" = silent)
+	Urgency string // Priority: "low", "normal" or "critical" ("" = by status)
+	Replace string // Key of an earlier notification to update in place, e.g. a heartbeat ("" = new)
+}
+
+// urgencyFor picks the urgency of a notification: a rule's priority, else
+// desktop.urgency from config, else the status default. Time-sensitive
+// statuses ignore desktop.urgency so errors stay critical.
+func (n *Notifier) urgencyFor(status analyzer.Status, opts Options) string {
+	if configured := n.cfg.Notifications.Desktop.Urgency; opts.Urgency == "" && configured != "" && !priority.IsTimeSensitive(status) {
+		return configured
 	}
+	return priority.Resolve(status, opts.Urgency)
 }
 
 // SendDesktop sends a desktop notification using beeep (cross-platform)
 // On macOS with clickToFocus enabled, uses terminal-notifier for click-to-focus support
-// On Linux with clickToFocus enabled, uses background daemon for click-to-focus support
+// On Linux with clickToFocus enabled, uses background daemon for click-to-focus support,
+// otherwise talks to the freedesktop notification server over D-Bus
+// On Windows, uses native toast notifications (click opens the project window when possible)
+// Under WSL, shows a toast on the Windows host through wsl-notify-send or PowerShell
+// Inside an SSH session or a container with remote forwarding enabled, forwards to the local listener instead
+// With desktop.terminalNotification set, writes an OSC escape sequence to the terminal instead
+// With desktop.tmuxMessage set, also or as a last resort shows it in the tmux status line
 // cwd is the working directory of the project; used for window-specific focus. May be empty.
 func (n *Notifier) SendDesktop(status analyzer.Status, message, sessionID, cwd string) error {
+	return n.SendDesktopWithOptions(status, message, sessionID, cwd, Options{})
+}
+
+// SendDesktopWithOptions sends a desktop notification like SendDesktop,
+// applying the title, sound and urgency overrides in opts
+func (n *Notifier) SendDesktopWithOptions(status analyzer.Status, message, sessionID, cwd string, opts Options) error {
 	// Send terminal bell for terminal tab indicators (e.g. Ghostty, tmux)
 	if n.cfg.IsTerminalBellEnabled() {
 		sendTerminalBell()
@@ -67,6 +91,34 @@ func (n *Notifier) SendDesktop(status analyzer.Status, message, sessionID, cwd s
 	if !exists {
 		return fmt.Errorf("unknown status: %s", status)
 	}
+	if opts.Title != "" {
+		statusInfo.Title = opts.Title
+	}
+	switch opts.Sound {
+	case "":
+	case "none":
+		statusInfo.Sound = ""
+	default:
+		statusInfo.Sound = opts.Sound
+	}
+	urgency := n.urgencyFor(status, opts)
+
+	// SSH session or container: forward to the listener on the user's machine
+	if n.cfg.IsRemoteForwardingActive() {
+		remoteCfg := n.cfg.Notifications.Remote
+		err := remote.Send(remoteCfg.Address, &remote.Request{
+			Token:     remoteCfg.Token,
+			Status:    string(status),
+			Message:   message,
+			SessionID: sessionID,
+			Source:    n.cfg.RemoteSource(),
+		})
+		if err == nil {
+			logging.Debug("Desktop notification forwarded to %s", remoteCfg.Address)
+			return nil
+		}
+		logging.Warn("Remote forwarding failed, sending locally: %v", err)
+	}
 
 	// Extract session name, git branch and folder name from message
 	// Format: "[session-name|branch folder] actual message" or "[session-name folder] actual message"
@@ -78,6 +130,10 @@ func (n *Notifier) SendDesktop(status analyzer.Status, message, sessionID, cwd s
 	if sessionName != "" {
 		title = fmt.Sprintf("%s [%s]", title, sessionName)
 	}
+	// With several sessions open, tell their notifications apart by project
+	if n.cfg.Notifications.Desktop.GroupBySession {
+		title = projectTitle(title, cwd)
+	}
 
 	// Build subtitle from branch and folder name
 	// Format: "main · notification_plugin_go" or just folder name
@@ -92,8 +148,6 @@ func (n *Notifier) SendDesktop(status analyzer.Status, message, sessionID, cwd s
 		}
 	}
 
-	timeSensitive := isTimeSensitiveStatus(status)
-
 	// Get app icon path if configured
 	appIcon := n.cfg.Notifications.Desktop.AppIcon
 	if appIcon != "" && !platform.FileExists(appIcon) {
@@ -101,10 +155,30 @@ func (n *Notifier) SendDesktop(status analyzer.Status, message, sessionID, cwd s
 		appIcon = ""
 	}
 
+	// tmux status line, next to whatever else shows the notification
+	tmuxMode := n.cfg.Notifications.Desktop.TmuxMessage
+	if tmuxMode == "always" && IsTmux() {
+		if err := sendTmuxMessage(title, cleanMessage, n.cfg.Notifications.Desktop.ExecTimeoutDuration()); err != nil {
+			logging.Warn("tmux message failed: %v", err)
+		}
+	}
+
+	// Terminal escape sequence (OSC 9/777/99): rendered by the terminal emulator itself,
+	// so it works over SSH and inside tmux without any external tool
+	if mode := n.cfg.Notifications.Desktop.TerminalNotification; mode != "" {
+		if err := sendOSCNotification(mode, title, cleanMessage); err != nil {
+			logging.Warn("Terminal notification failed, falling back to desktop notification: %v", err)
+		} else {
+			logging.Debug("Desktop notification sent via terminal escape sequence (%s): title=%s", mode, title)
+			n.playSoundAsync(statusInfo.Sound)
+			return nil
+		}
+	}
+
 	// macOS: Try terminal-notifier for click-to-focus support
 	if platform.IsMacOS() && n.cfg.Notifications.Desktop.ClickToFocus {
 		if IsTerminalNotifierAvailable() {
-			if err := n.sendWithTerminalNotifier(title, cleanMessage, subtitle, sessionID, timeSensitive, cwd); err != nil {
+			if err := n.sendWithTerminalNotifier(title, cleanMessage, subtitle, sessionID, urgency, cwd, opts.Replace); err != nil {
 				logging.Warn("terminal-notifier failed, falling back to beeep: %v", err)
 				// Fall through to beeep
 			} else {
@@ -117,25 +191,60 @@ func (n *Notifier) SendDesktop(status analyzer.Status, message, sessionID, cwd s
 		}
 	}
 
-	// Linux: Try daemon for click-to-focus support
-	if platform.IsLinux() && n.cfg.Notifications.Desktop.ClickToFocus {
-		if err := sendLinuxNotification(title, cleanMessage, appIcon, n.cfg, cwd); err != nil {
-			logging.Warn("Linux daemon notification failed, falling back to beeep: %v", err)
+	// WSL: toast on the Windows host, where the user is
+	if platform.IsWSL() && n.cfg.Notifications.Desktop.WSL != "off" {
+		if err := sendWSLNotification(title, cleanMessage, n.cfg.Notifications.Desktop.WSL, cwd, n.cfg.Notifications.Desktop.ExecTimeoutDuration()); err != nil {
+			logging.Warn("WSL toast notification failed, falling back to Linux notification: %v", err)
+			// Fall through to the Linux path (WSLg may run a notification server)
+		} else {
+			logging.Debug("Desktop notification sent via Windows host toast: title=%s", title)
+			n.playSoundAsync(statusInfo.Sound)
+			return nil
+		}
+	}
+
+	// Linux: Try daemon for click-to-focus support, then direct D-Bus
+	if platform.IsLinux() {
+		if id, err := sendLinuxNotification(title, cleanMessage, appIcon, urgency, n.cfg, sessionID, cwd, opts.Replace); err != nil {
+			logging.Warn("Linux notification failed, falling back to beeep: %v", err)
 			// Fall through to beeep
 		} else {
-			logging.Debug("Desktop notification sent via Linux daemon: title=%s", title)
+			logging.Debug("Desktop notification sent via Linux notifier: title=%s id=%d", title, id)
 			n.playSoundAsync(statusInfo.Sound)
 			return nil
 		}
 	}
 
-	// Standard path: beeep (Windows, macOS fallback, Linux fallback)
-	return n.sendWithBeeep(title, cleanMessage, appIcon, statusInfo.Sound)
+	// Windows: Native toast with click actions and BurntToast fallback
+	if platform.IsWindows() {
+		if err := sendWindowsNotification(title, cleanMessage, appIcon, n.cfg, cwd); err != nil {
+			logging.Warn("Windows toast notification failed, falling back to beeep: %v", err)
+			// Fall through to beeep
+		} else {
+			logging.Debug("Desktop notification sent via Windows toast: title=%s", title)
+			n.playSoundAsync(statusInfo.Sound)
+			return nil
+		}
+	}
+
+	// Standard path: beeep (Windows fallback, macOS fallback, Linux fallback)
+	err := n.sendWithBeeep(title, cleanMessage, appIcon, statusInfo.Sound)
+	if err != nil && tmuxMode == "fallback" && IsTmux() {
+		// No notification server, e.g. over SSH: the tmux status line still works
+		tmuxErr := sendTmuxMessage(title, cleanMessage, n.cfg.Notifications.Desktop.ExecTimeoutDuration())
+		if tmuxErr == nil {
+			logging.Debug("Desktop notification failed (%v), shown in tmux instead: title=%s", err, title)
+			return nil
+		}
+		logging.Warn("tmux message failed: %v", tmuxErr)
+	}
+	return err
 }
 
 // sendWithTerminalNotifier sends notification via terminal-notifier on macOS
-// with click-to-focus support (clicking notification activates the terminal)
-func (n *Notifier) sendWithTerminalNotifier(title, message, subtitle, sessionID string, timeSensitive bool, cwd string) error {
+// with click-to-focus support (clicking notification activates the terminal).
+// A replace key updates the notification last sent with it in place.
+func (n *Notifier) sendWithTerminalNotifier(title, message, subtitle, sessionID, urgency string, cwd, replace string) error {
 	notifierPath, err := GetTerminalNotifierPath()
 	if err != nil {
 		return fmt.Errorf("terminal-notifier not found: %w", err)
@@ -154,20 +263,28 @@ func (n *Notifier) sendWithTerminalNotifier(title, message, subtitle, sessionID
 		args = buildTerminalNotifierArgs(title, message, bundleID, cwd)
 	}
 
-	// Append shared options: subtitle, threadID, timeSensitive, nosound
+	// One notification per session: each one replaces the session's previous one
+	if n.cfg.Notifications.Desktop.GroupBySession && sessionID != "" {
+		args = withGroup(args, sessionGroup(sessionID))
+	}
+	if replace != "" {
+		args = withGroup(args, sessionGroup(replace))
+	}
+
+	// Append shared options: subtitle, threadID, interruption level, nosound
 	if subtitle != "" {
 		args = append(args, "-subtitle", subtitle)
 	}
 	if sessionID != "" {
 		args = append(args, "-threadID", sessionID)
 	}
-	if timeSensitive {
-		args = append(args, "-timeSensitive")
-	}
+	args = append(args, interruptionLevelArgs(urgency)...)
 	// Always suppress sound in Swift — Go manages sound via audio player
 	args = append(args, "-nosound")
 
-	cmd := exec.Command(notifierPath, args...)
+	ctx, cancel := context.WithTimeout(context.Background(), n.cfg.Notifications.Desktop.ExecTimeoutDuration())
+	defer cancel()
+	cmd := exec.CommandContext(ctx, notifierPath, args...)
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -178,6 +295,20 @@ func (n *Notifier) sendWithTerminalNotifier(title, message, subtitle, sessionID
 	return nil
 }
 
+// interruptionLevelArgs maps a priority to the macOS interruption level:
+// critical notifications are time-sensitive and break through Focus Mode,
+// low ones are passive and go to Notification Center without a banner
+func interruptionLevelArgs(urgency string) []string {
+	switch urgency {
+	case priority.Critical:
+		return []string{"-timeSensitive"}
+	case priority.Low:
+		return []string{"-passive"}
+	default:
+		return nil
+	}
+}
+
 // buildTerminalNotifierArgs constructs command-line arguments for terminal-notifier.
 // When cwd is provided, uses -execute with a focus script instead of -activate.
 // Exported for testing purposes.
@@ -205,10 +336,41 @@ func buildTerminalNotifierArgs(title, message, bundleID, cwd string) []string {
 	return args
 }
 
+// sessionGroup returns the terminal-notifier group shared by a session's
+// notifications, so a new one replaces the previous one in Notification Center
+func sessionGroup(sessionID string) string {
+	return "claude-session-" + sessionID
+}
+
+// withGroup sets the -group of terminal-notifier arguments, replacing the
+// unique group the argument builders use by default
+func withGroup(args []string, group string) []string {
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == "-group" {
+			args[i+1] = group
+			return args
+		}
+	}
+	return append(args, "-group", group)
+}
+
+// projectTitle appends the project folder to a title, e.g. "✅ Completed [peak] · api"
+func projectTitle(title, cwd string) string {
+	if cwd == "" {
+		return title
+	}
+	project := filepath.Base(filepath.Clean(cwd))
+	if project == "." || project == string(filepath.Separator) {
+		return title
+	}
+	return title + " \u00B7 " + project
+}
+
 // buildFocusScript returns the shell command for -execute in terminal-notifier.
 // For Ghostty: uses AXDocument attribute (OSC 7 CWD) via Accessibility API,
 // falling back to plain app activation.
-// For VS Code: invokes the binary's focus-window subcommand (CGo AXUIElement).
+// For VS Code, Cursor, Zed and JetBrains IDEs: invokes the binary's
+// focus-window subcommand (CGo AXUIElement), raising the window titled with the folder.
 // For all other apps: uses AppleScript title search by folder name.
 // Returns "" when cwd is empty or unusable (caller should use -activate instead).
 func buildFocusScript(bundleID, cwd string) string {
@@ -225,10 +387,11 @@ func buildFocusScript(bundleID, cwd string) string {
 		return ""
 	}
 
-	if isVSCodeBundleID(bundleID) {
+	if isVSCodeBundleID(bundleID) || isEditorBundleID(bundleID) {
 		// VS Code's AppleScript dictionary doesn't support window enumeration
-		// (-1708), so AppleScript is not a viable fallback. Return "" to use
-		// plain -activate if the binary path is unavailable.
+		// (-1708), and the other editors have none, so AppleScript is not a
+		// viable fallback. Return "" to use plain -activate if the binary
+		// path is unavailable.
 		return buildVSCodeFocusScript(bundleID, cwd)
 	}
 
@@ -241,6 +404,17 @@ func isVSCodeBundleID(bundleID string) bool {
 		bundleID == "com.microsoft.VSCodeInsiders"
 }
 
+// isEditorBundleID reports whether bundleID is an editor other than VS Code
+// whose window titles contain the project folder: VSCodium, Cursor, Zed or
+// a JetBrains IDE.
+func isEditorBundleID(bundleID string) bool {
+	switch bundleID {
+	case "com.vscodium", "com.todesktop.230313mzl4w4u92", "dev.zed.Zed":
+		return true
+	}
+	return strings.HasPrefix(bundleID, "com.jetbrains.")
+}
+
 // isGhosttyBundleID reports whether bundleID is Ghostty.
 func isGhosttyBundleID(bundleID string) bool {
 	return bundleID == "com.mitchellh.ghostty"
@@ -327,6 +501,9 @@ func sanitizeForAppleScript(s string) string {
 // Notifier instance. Fallback chain: terminal-notifier → osascript.
 // executeCmd is the shell command run when the user clicks the notification (may be empty).
 func SendQuickNotification(title, message, executeCmd string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), config.DefaultExecTimeout)
+	defer cancel()
+
 	if notifierPath, err := GetTerminalNotifierPath(); err == nil {
 		args := []string{
 			"-title", title,
@@ -339,7 +516,7 @@ func SendQuickNotification(title, message, executeCmd string) error {
 			"-group", fmt.Sprintf("claude-quick-%d", time.Now().UnixNano()),
 			"-nosound",
 		)
-		if output, err := exec.Command(notifierPath, args...).CombinedOutput(); err == nil {
+		if output, err := exec.CommandContext(ctx, notifierPath, args...).CombinedOutput(); err == nil {
 			return nil
 		} else {
 			logging.Debug("terminal-notifier failed: %v, output: %s", err, string(output))
@@ -348,7 +525,7 @@ func SendQuickNotification(title, message, executeCmd string) error {
 
 	// Fallback: osascript (no click action, just informational)
 	script := fmt.Sprintf(`display notification %q with title %q`, message, title)
-	if err := exec.Command("osascript", "-e", script).Run(); err != nil {
+	if err := exec.CommandContext(ctx, "osascript", "-e", script).Run(); err != nil {
 		return fmt.Errorf("all notification methods failed: %w", err)
 	}
 	return nil
@@ -431,27 +608,55 @@ func (n *Notifier) initPlayer() error {
 	return n.playerErr
 }
 
-// playSound plays a sound file using the audio module
-func (n *Notifier) playSound(soundPath string) {
-	if !platform.FileExists(soundPath) {
-		logging.Warn("Sound file not found: %s", soundPath)
+// playSound plays a sound file, or a built-in or system sound by name,
+// with the player chosen by desktop.soundPlayer. "auto" falls back to the
+// system player when the built-in one cannot play.
+func (n *Notifier) playSound(sound string) {
+	soundPath, ok := sounds.Resolve(sound, "")
+	if !ok {
+		logging.Warn("Sound file not found: %s", sound)
 		return
 	}
 
-	// Initialize player once
-	if err := n.initPlayer(); err != nil {
-		logging.Error("Failed to initialize audio player: %v", err)
-		return
+	volume := n.cfg.Notifications.Desktop.Volume
+	player := n.cfg.Notifications.Desktop.SoundPlayer
+	if player != "system" {
+		err := n.initPlayer()
+		if err == nil {
+			err = n.audioPlayer.Play(soundPath)
+		}
+		if err == nil {
+			logging.Debug("Sound played successfully: %s (volume: %.0f%%)", soundPath, volume*100)
+			return
+		}
+		if player == "builtin" {
+			logging.Error("Failed to play sound %s: %v", soundPath, err)
+			return
+		}
+		logging.Debug("Built-in player failed (%v), trying the system player", err)
 	}
 
-	// Play sound
-	if err := n.audioPlayer.Play(soundPath); err != nil {
+	ctx, cancel := context.WithTimeout(context.Background(), n.cfg.Notifications.Desktop.ExecTimeoutDuration())
+	defer cancel()
+	if err := audio.PlaySystem(ctx, soundPath, volume); err != nil {
 		logging.Error("Failed to play sound %s: %v", soundPath, err)
 		return
 	}
+	logging.Debug("Sound played with the system player: %s (volume: %.0f%%)", soundPath, volume*100)
+}
 
-	volume := n.cfg.Notifications.Desktop.Volume
-	logging.Debug("Sound played successfully: %s (volume: %.0f%%)", soundPath, volume*100)
+// DismissSession closes the desktop notifications still shown for a
+// session. Only the Linux daemon can close the notifications it sent;
+// elsewhere this is a no-op.
+func (n *Notifier) DismissSession(sessionID string) error {
+	return dismissSession(sessionID)
+}
+
+// Acknowledged returns when the user last clicked, dismissed or returned
+// to a desktop notification of a session (zero = unknown). Only the Linux
+// daemon tracks this; elsewhere it is always zero.
+func (n *Notifier) Acknowledged(sessionID string) time.Time {
+	return acknowledged(sessionID)
 }
 
 // Close waits for all sounds to finish playing and cleans up resources
@@ -478,12 +683,12 @@ func (n *Notifier) Close() error {
 	return nil
 }
 
-// sendTerminalBell writes a BEL character to /dev/tty to trigger terminal
-// tab indicators (e.g. Ghostty tab highlight, tmux window bell flag).
+// sendTerminalBell writes a BEL character to the terminal to trigger
+// terminal tab indicators (e.g. Ghostty tab highlight, tmux window bell flag).
 func sendTerminalBell() {
-	f, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	f, err := os.OpenFile(platform.TTYPath(), os.O_WRONLY, 0)
 	if err != nil {
-		logging.Debug("Could not open /dev/tty for bell: %v", err)
+		logging.Debug("Could not open %s for bell: %v", platform.TTYPath(), err)
 		return
 	}
 	defer f.Close()
@@ -33,7 +33,7 @@ func TestSendWithTerminalNotifier_Integration(t *testing.T) {
 	n := New(cfg)
 
 	// This will send a real notification - we just verify it doesn't error
-	err := n.sendWithTerminalNotifier("Integration Test", "This is a test notification", "", "", false, "")
+	err := n.sendWithTerminalNotifier("Integration Test", "This is a test notification", "", "", "", "", "")
 	if err != nil {
 		t.Errorf("sendWithTerminalNotifier failed: %v", err)
 	}
@@ -2,6 +2,7 @@ package notifier
 
 import (
 	"os"
+	"reflect"
 	"runtime"
 	"strings"
 	"testing"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/777genius/claude-notifications/internal/analyzer"
 	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/remote"
 )
 
 func TestExtractSessionInfo(t *testing.T) {
@@ -334,6 +336,43 @@ func TestSendDesktop_UnknownStatus(t *testing.T) {
 	}
 }
 
+func TestSendDesktop_ForwardsInSSHSession(t *testing.T) {
+	received := make(chan remote.Request, 1)
+	srv, err := remote.Listen("127.0.0.1:0", "tok", func(req *remote.Request) error {
+		received <- *req
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	go srv.Serve()
+	defer srv.Close()
+
+	t.Setenv("SSH_CONNECTION", "10.0.0.2 51234 10.0.0.1 22")
+
+	cfg := config.DefaultConfig()
+	cfg.Notifications.Desktop.Enabled = true
+	bell := false
+	cfg.Notifications.Desktop.TerminalBell = &bell
+	cfg.Notifications.Remote = config.RemoteConfig{Enabled: true, Address: srv.Addr().String(), Token: "tok", Name: "build-box"}
+
+	n := New(cfg)
+	defer n.Close()
+
+	if err := n.SendDesktop(analyzer.StatusQuestion, "[peak main app] Need input", "sess-1", "/tmp/app"); err != nil {
+		t.Fatalf("SendDesktop() error = %v", err)
+	}
+
+	select {
+	case req := <-received:
+		if req.Status != "question" || req.Message != "[peak main app] Need input" || req.SessionID != "sess-1" || req.Source != "build-box" {
+			t.Errorf("forwarded request = %+v", req)
+		}
+	default:
+		t.Fatal("notification was not forwarded")
+	}
+}
+
 func TestSendDesktop_WithSessionName(t *testing.T) {
 	cfg := config.DefaultConfig()
 	cfg.Notifications.Desktop.Enabled = true
@@ -625,6 +664,42 @@ func TestBuildTerminalNotifierArgs_UniqueGroupID(t *testing.T) {
 	}
 }
 
+func TestWithGroup_SessionGroup(t *testing.T) {
+	args := withGroup(buildTerminalNotifierArgs("Title", "Msg", "com.test", ""), sessionGroup("abc-123"))
+	if got := getArgValue(args, "-group"); got != "claude-session-abc-123" {
+		t.Errorf("-group = %q, want claude-session-abc-123", got)
+	}
+	count := 0
+	for _, arg := range args {
+		if arg == "-group" {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("-group appears %d times, want once", count)
+	}
+
+	if got := getArgValue(withGroup([]string{"-title", "T"}, "g"), "-group"); got != "g" {
+		t.Errorf("-group without a default = %q, want g", got)
+	}
+}
+
+func TestProjectTitle(t *testing.T) {
+	tests := []struct {
+		title, cwd, want string
+	}{
+		{"✅ Completed [peak]", "/home/me/api", "✅ Completed [peak] · api"},
+		{"✅ Completed", "/home/me/api/", "✅ Completed · api"},
+		{"✅ Completed", "", "✅ Completed"},
+		{"✅ Completed", "/", "✅ Completed"},
+	}
+	for _, tt := range tests {
+		if got := projectTitle(tt.title, tt.cwd); got != tt.want {
+			t.Errorf("projectTitle(%q, %q) = %q, want %q", tt.title, tt.cwd, got, tt.want)
+		}
+	}
+}
+
 // NOTE: TestSendWithTerminalNotifier_Integration and TestTerminalNotifier_CommandExecution
 // are in notifier_darwin_integration_test.go (require setupClaudeNotifierEnv which is darwin-only)
 
@@ -753,7 +828,7 @@ func TestSendWithTerminalNotifier_PathNotFound(t *testing.T) {
 
 	// This may succeed if terminal-notifier is installed system-wide
 	// or fail if not - both are valid outcomes
-	err := n.sendWithTerminalNotifier("Test", "Message", "", "", false, "")
+	err := n.sendWithTerminalNotifier("Test", "Message", "", "", "", "", "")
 	_ = err // We just want to exercise the code path
 }
 
@@ -934,29 +1009,35 @@ func TestBuildTerminalNotifierArgs_AllKnownBundleIDs(t *testing.T) {
 	}
 }
 
-// === Tests for isTimeSensitiveStatus ===
+func TestUrgencyFor(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Notifications.Desktop.Urgency = "low"
+	n := New(cfg)
 
-func TestIsTimeSensitiveStatus(t *testing.T) {
+	if got := n.urgencyFor(analyzer.StatusTaskComplete, Options{}); got != "low" {
+		t.Errorf("desktop.urgency should apply, got %q", got)
+	}
+	if got := n.urgencyFor(analyzer.StatusAPIError, Options{}); got != "critical" {
+		t.Errorf("errors should stay critical, got %q", got)
+	}
+	if got := n.urgencyFor(analyzer.StatusTaskComplete, Options{Urgency: "critical"}); got != "critical" {
+		t.Errorf("rule urgency should win, got %q", got)
+	}
+}
+
+func TestInterruptionLevelArgs(t *testing.T) {
 	tests := []struct {
-		status   analyzer.Status
-		expected bool
+		urgency string
+		want    []string
 	}{
-		{analyzer.StatusAPIError, true},
-		{analyzer.StatusAPIErrorOverloaded, true},
-		{analyzer.StatusSessionLimitReached, true},
-		{analyzer.StatusTaskComplete, false},
-		{analyzer.StatusReviewComplete, false},
-		{analyzer.StatusQuestion, false},
-		{analyzer.StatusPlanReady, false},
+		{"critical", []string{"-timeSensitive"}},
+		{"low", []string{"-passive"}},
+		{"normal", nil},
 	}
-
 	for _, tt := range tests {
-		t.Run(string(tt.status), func(t *testing.T) {
-			got := isTimeSensitiveStatus(tt.status)
-			if got != tt.expected {
-				t.Errorf("isTimeSensitiveStatus(%s) = %v, want %v", tt.status, got, tt.expected)
-			}
-		})
+		if got := interruptionLevelArgs(tt.urgency); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("interruptionLevelArgs(%q) = %v, want %v", tt.urgency, got, tt.want)
+		}
 	}
 }
 
@@ -1032,6 +1113,23 @@ func TestBuildFocusScript_VSCodeInsiders_UsesBinaryCallback(t *testing.T) {
 	}
 }
 
+func TestBuildFocusScript_Editors_UseBinaryCallback(t *testing.T) {
+	for _, bundleID := range []string{
+		"com.todesktop.230313mzl4w4u92", // Cursor
+		"dev.zed.Zed",
+		"com.jetbrains.goland",
+		"com.jetbrains.intellij",
+	} {
+		script := buildFocusScript(bundleID, "/home/user/my-project")
+		if !strings.Contains(script, "focus-window") {
+			t.Errorf("%s focus script should use focus-window subcommand, got: %s", bundleID, script)
+		}
+		if strings.Contains(script, "osascript") {
+			t.Errorf("%s focus script should not use osascript, got: %s", bundleID, script)
+		}
+	}
+}
+
 func TestBuildFocusScript_Ghostty_UsesFocusWindow(t *testing.T) {
 	script := buildFocusScript("com.mitchellh.ghostty", "/home/user/my-project")
 	if !strings.Contains(script, "focus-window") {
@@ -0,0 +1,126 @@
+package notifier
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/777genius/claude-notifications/internal/platform"
+)
+
+// OSC notification protocols (desktop.terminalNotification values)
+const (
+	oscModeAuto   = "auto"
+	oscMode9      = "osc9"   // iTerm2, WezTerm, Ghostty, Windows Terminal
+	oscMode777    = "osc777" // urxvt, foot, WezTerm, Ghostty
+	oscMode99     = "osc99"  // kitty
+	oscBEL        = "\a"
+	oscST         = "\x1b\\"
+	oscWriteLimit = 2 * time.Second
+)
+
+// oscTTYPath replaces the hook's terminal when set, so tests can redirect it
+var oscTTYPath string
+
+// resolveOSCMode maps "auto" to a concrete protocol for the current terminal.
+// Other values are returned unchanged.
+func resolveOSCMode(mode, termProgram, term, lcTerminal string) string {
+	if mode != oscModeAuto {
+		return mode
+	}
+
+	switch {
+	case strings.EqualFold(termProgram, "kitty") || strings.HasPrefix(term, "xterm-kitty"):
+		return oscMode99
+	case strings.HasPrefix(term, "foot"), strings.HasPrefix(term, "rxvt"):
+		return oscMode777
+	case strings.EqualFold(lcTerminal, "iTerm2"), strings.EqualFold(termProgram, "iTerm.app"):
+		return oscMode9
+	default:
+		// OSC 9 is the most widely supported (iTerm2, WezTerm, Ghostty, Windows Terminal)
+		return oscMode9
+	}
+}
+
+// sanitizeOSCText removes control characters that would terminate or corrupt
+// an OSC sequence (letting message text inject escape sequences), and flattens
+// newlines to spaces.
+func sanitizeOSCText(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\r' || r == '\t':
+			return ' '
+		case r < 0x20 || r == 0x7f || (r >= 0x80 && r <= 0x9f) || r == utf8.RuneError:
+			// C0/C1 controls (ESC, BEL, 8-bit ST) and invalid UTF-8 bytes
+			return -1
+		default:
+			return r
+		}
+	}, s)
+}
+
+// buildOSCNotification returns the escape sequence(s) that show a notification
+// using the given protocol.
+func buildOSCNotification(mode, title, body, id string) (string, error) {
+	title = sanitizeOSCText(title)
+	body = sanitizeOSCText(body)
+
+	switch mode {
+	case oscMode9:
+		text := title
+		if body != "" {
+			text = title + ": " + body
+		}
+		return "\x1b]9;" + text + oscBEL, nil
+	case oscMode777:
+		// Fields are separated by ';', so it must not appear inside the title
+		return "\x1b]777;notify;" + strings.ReplaceAll(title, ";", ",") + ";" + body + oscBEL, nil
+	case oscMode99:
+		// d=0: more payload follows (the body) under the same identifier
+		seq := fmt.Sprintf("\x1b]99;i=%s:d=0;%s%s", id, title, oscST)
+		seq += fmt.Sprintf("\x1b]99;i=%s:p=body;%s%s", id, body, oscST)
+		return seq, nil
+	default:
+		return "", fmt.Errorf("unknown terminal notification protocol: %q", mode)
+	}
+}
+
+// wrapTmuxPassthrough wraps seq in a DCS passthrough so tmux forwards it to the
+// outer terminal. Requires "set -g allow-passthrough on" (tmux 3.3+).
+func wrapTmuxPassthrough(seq string) string {
+	return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + oscST
+}
+
+// sendOSCNotification writes a terminal notification escape sequence to the
+// controlling terminal. Works over SSH, where the local terminal renders it.
+func sendOSCNotification(mode, title, body string) error {
+	mode = resolveOSCMode(mode, os.Getenv("TERM_PROGRAM"), os.Getenv("TERM"), os.Getenv("LC_TERMINAL"))
+	// Every hook is a new process, so a counter would repeat across hooks
+	// and kitty would merge notifications of different sessions
+	seq, err := buildOSCNotification(mode, title, body, platform.RandomSuffix())
+	if err != nil {
+		return err
+	}
+	if IsTmux() {
+		seq = wrapTmuxPassthrough(seq)
+	}
+
+	ttyPath := oscTTYPath
+	if ttyPath == "" {
+		ttyPath = platform.TTYPath()
+	}
+	f, err := os.OpenFile(ttyPath, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("no controlling terminal: %w", err)
+	}
+	defer f.Close()
+
+	// A stuck terminal must not block the hook
+	_ = f.SetWriteDeadline(time.Now().Add(oscWriteLimit))
+	if _, err := f.WriteString(seq); err != nil {
+		return fmt.Errorf("failed to write to %s: %w", ttyPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package notifier
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveOSCMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        string
+		termProgram string
+		term        string
+		lcTerminal  string
+		want        string
+	}{
+		{"explicit mode unchanged", "osc777", "kitty", "", "", "osc777"},
+		{"kitty by TERM_PROGRAM", "auto", "kitty", "", "", "osc99"},
+		{"kitty by TERM", "auto", "", "xterm-kitty", "", "osc99"},
+		{"foot", "auto", "", "foot", "", "osc777"},
+		{"foot-extra", "auto", "", "foot-extra", "", "osc777"},
+		{"urxvt", "auto", "", "rxvt-unicode-256color", "", "osc777"},
+		{"iTerm2 over SSH", "auto", "", "xterm-256color", "iTerm2", "osc9"},
+		{"WezTerm", "auto", "WezTerm", "xterm-256color", "", "osc9"},
+		{"unknown", "auto", "", "xterm-256color", "", "osc9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveOSCMode(tt.mode, tt.termProgram, tt.term, tt.lcTerminal); got != tt.want {
+				t.Errorf("resolveOSCMode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildOSCNotification(t *testing.T) {
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{"osc9", "\x1b]9;✅ Completed: Done\a"},
+		{"osc777", "\x1b]777;notify;✅ Completed;Done\a"},
+		{"osc99", "\x1b]99;i=7:d=0;✅ Completed\x1b\\\x1b]99;i=7:p=body;Done\x1b\\"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			got, err := buildOSCNotification(tt.mode, "✅ Completed", "Done", "7")
+			if err != nil {
+				t.Fatalf("buildOSCNotification() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("buildOSCNotification() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildOSCNotification_OSC9WithoutBody(t *testing.T) {
+	got, _ := buildOSCNotification("osc9", "Title", "", "1")
+	if got != "\x1b]9;Title\a" {
+		t.Errorf("buildOSCNotification() = %q", got)
+	}
+}
+
+func TestBuildOSCNotification_OSC777EscapesSemicolonInTitle(t *testing.T) {
+	got, _ := buildOSCNotification("osc777", "a;b", "c;d", "1")
+	if got != "\x1b]777;notify;a,b;c;d\a" {
+		t.Errorf("buildOSCNotification() = %q", got)
+	}
+}
+
+func TestBuildOSCNotification_UnknownMode(t *testing.T) {
+	if _, err := buildOSCNotification("osc1337", "t", "b", "1"); err == nil {
+		t.Error("buildOSCNotification() should fail for unknown mode")
+	}
+}
+
+func TestSanitizeOSCText(t *testing.T) {
+	got := sanitizeOSCText("line1\nline2\x1b]0;pwned\a\x9c\u009c end")
+	if strings.ContainsAny(got, "\x1b\a\n\u009c\ufffd") {
+		t.Errorf("sanitizeOSCText() left control characters: %q", got)
+	}
+	if got != "line1 line2]0;pwned end" {
+		t.Errorf("sanitizeOSCText() = %q", got)
+	}
+}
+
+func TestWrapTmuxPassthrough(t *testing.T) {
+	got := wrapTmuxPassthrough("\x1b]9;hi\a")
+	want := "\x1bPtmux;\x1b\x1b]9;hi\a\x1b\\"
+	if got != want {
+		t.Errorf("wrapTmuxPassthrough() = %q, want %q", got, want)
+	}
+}
+
+func TestSendOSCNotification_WritesToTTY(t *testing.T) {
+	tty := filepath.Join(t.TempDir(), "tty")
+	if err := os.WriteFile(tty, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	orig := oscTTYPath
+	oscTTYPath = tty
+	defer func() { oscTTYPath = orig }()
+	t.Setenv("TMUX", "")
+
+	if err := sendOSCNotification("osc777", "Title", "Body"); err != nil {
+		t.Fatalf("sendOSCNotification() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(tty)
+	if string(data) != "\x1b]777;notify;Title;Body\a" {
+		t.Errorf("tty received %q", data)
+	}
+}
+
+func TestSendOSCNotification_OSC99IdentifiersDiffer(t *testing.T) {
+	tty := filepath.Join(t.TempDir(), "tty")
+	if err := os.WriteFile(tty, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	orig := oscTTYPath
+	oscTTYPath = tty
+	defer func() { oscTTYPath = orig }()
+	t.Setenv("TMUX", "")
+
+	id := func() string {
+		t.Helper()
+		if err := sendOSCNotification("osc99", "Title", "Body"); err != nil {
+			t.Fatalf("sendOSCNotification() error = %v", err)
+		}
+		data, _ := os.ReadFile(tty)
+		rest, ok := strings.CutPrefix(string(data), "\x1b]99;i=")
+		id, _, found := strings.Cut(rest, ":")
+		if !ok || !found || id == "" {
+			t.Fatalf("tty received %q", data)
+		}
+		return id
+	}
+	// kitty merges notifications that share an identifier
+	if first, second := id(), id(); first == second {
+		t.Errorf("two notifications share the identifier %q", first)
+	}
+}
+
+func TestSendOSCNotification_TmuxPassthrough(t *testing.T) {
+	tty := filepath.Join(t.TempDir(), "tty")
+	if err := os.WriteFile(tty, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	orig := oscTTYPath
+	oscTTYPath = tty
+	defer func() { oscTTYPath = orig }()
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,123,0")
+
+	if err := sendOSCNotification("osc9", "Title", ""); err != nil {
+		t.Fatalf("sendOSCNotification() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(tty)
+	if !strings.HasPrefix(string(data), "\x1bPtmux;") {
+		t.Errorf("tty received %q, want tmux passthrough", data)
+	}
+}
+
+func TestSendOSCNotification_NoTTY(t *testing.T) {
+	orig := oscTTYPath
+	oscTTYPath = filepath.Join(t.TempDir(), "missing", "tty")
+	defer func() { oscTTYPath = orig }()
+
+	if err := sendOSCNotification("osc9", "Title", "Body"); err == nil {
+		t.Error("sendOSCNotification() should fail without a terminal")
+	}
+}
@@ -0,0 +1,47 @@
+package notifier
+
+import (
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+// presenceTitle is the title of presence-only notifications
+const presenceTitle = "Claude Code"
+
+// ApplyPrivacy reduces an event to what a backend with the given privacy
+// mode may receive. title-only replaces the message with "<project> needs
+// attention"; presence-only also replaces the title and drops the project,
+// repository, branch, session and elapsed time. The status is kept for
+// backends that format by it.
+func ApplyPrivacy(ev Event, privacy string) Event {
+	switch privacy {
+	case config.PrivacyTitleOnly:
+		ev.Message = attentionMessage(ev.Project)
+		if ev.Content != nil {
+			data := *ev.Content
+			data.Message, data.ToolName = ev.Message, ""
+			ev.Content = &data
+		}
+	case config.PrivacyPresenceOnly:
+		ev.Title = presenceTitle
+		ev.Message = attentionMessage("")
+		ev.Project = ""
+		ev.Elapsed = 0
+		if ev.Content != nil {
+			ev.Content = &config.ContentData{
+				Title:   ev.Title,
+				Message: ev.Message,
+				Status:  ev.Content.Status,
+				Event:   ev.Content.Event,
+			}
+		}
+	}
+	return ev
+}
+
+// attentionMessage is the message of a reduced notification
+func attentionMessage(project string) string {
+	if project == "" {
+		return presenceTitle + " needs attention"
+	}
+	return project + " needs attention"
+}
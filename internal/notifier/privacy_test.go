@@ -0,0 +1,77 @@
+package notifier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+func TestApplyPrivacy(t *testing.T) {
+	ev := Event{
+		Status:  analyzer.StatusQuestion,
+		Title:   "❓ Question",
+		Message: "Run migration with DB_URL=postgres://prod?",
+		Project: "billing",
+		Elapsed: 12 * time.Minute,
+		Content: &config.ContentData{
+			Title: "❓ Question", Message: "Run migration with DB_URL=postgres://prod?", Status: "question",
+			Event: "Notification", Project: "billing", Branch: "fix/ledger", ToolName: "Bash", Session: "bold-fox",
+		},
+	}
+
+	if got := ApplyPrivacy(ev, ""); got.Message != ev.Message || got.Content != ev.Content {
+		t.Errorf("default privacy changed the event: %+v", got)
+	}
+	if got := ApplyPrivacy(ev, config.PrivacyFull); got.Message != ev.Message {
+		t.Errorf("full privacy changed the message: %q", got.Message)
+	}
+
+	got := ApplyPrivacy(ev, config.PrivacyTitleOnly)
+	if got.Title != "❓ Question" || got.Message != "billing needs attention" || got.Project != "billing" {
+		t.Errorf("title-only = %q / %q / %q", got.Title, got.Message, got.Project)
+	}
+	if got.Content.Message != "billing needs attention" || got.Content.ToolName != "" || got.Content.Branch != "fix/ledger" {
+		t.Errorf("title-only content = %+v", *got.Content)
+	}
+	if ev.Content.Message != "Run migration with DB_URL=postgres://prod?" {
+		t.Error("ApplyPrivacy modified the caller's content")
+	}
+
+	got = ApplyPrivacy(ev, config.PrivacyPresenceOnly)
+	if got.Title != "Claude Code" || got.Message != "Claude Code needs attention" || got.Project != "" || got.Elapsed != 0 {
+		t.Errorf("presence-only = %q / %q / %q / %v", got.Title, got.Message, got.Project, got.Elapsed)
+	}
+	want := config.ContentData{Title: "Claude Code", Message: "Claude Code needs attention", Status: "question", Event: "Notification"}
+	if *got.Content != want {
+		t.Errorf("presence-only content = %+v, want %+v", *got.Content, want)
+	}
+	if got.Status != analyzer.StatusQuestion {
+		t.Errorf("presence-only status = %q, want the status kept", got.Status)
+	}
+
+	if got := ApplyPrivacy(Event{Message: "3 notifications held"}, config.PrivacyTitleOnly); got.Message != "Claude Code needs attention" || got.Content != nil {
+		t.Errorf("title-only without content = %+v", got)
+	}
+}
+
+func TestDispatcher_AppliesPrivacy(t *testing.T) {
+	got := map[string]string{}
+	record := func(name string) func(Event) {
+		return func(ev Event) { got[name] = ev.Message }
+	}
+	d := NewDispatcher(
+		Backend{Name: "desktop", Send: record("desktop")},
+		Backend{Name: "ntfy", Privacy: config.PrivacyTitleOnly, Send: record("ntfy")},
+		Backend{Name: "slack", Privacy: config.PrivacyPresenceOnly, Send: record("slack")},
+	)
+	d.Dispatch(Event{Status: analyzer.StatusTaskComplete, Message: "Deployed v2 to prod", Project: "api"})
+
+	want := map[string]string{"desktop": "Deployed v2 to prod", "ntfy": "api needs attention", "slack": "Claude Code needs attention"}
+	for name, msg := range want {
+		if got[name] != msg {
+			t.Errorf("%s got %q, want %q", name, got[name], msg)
+		}
+	}
+}
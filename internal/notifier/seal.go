@@ -0,0 +1,47 @@
+package notifier
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/seal"
+)
+
+// sealedTitle is the title of encrypted notifications
+const sealedTitle = "🔒 " + presenceTitle
+
+// SealEvent encrypts the title, message and project details of an event
+// with key (see config.EncryptConfig) into one envelope, which becomes the
+// message. Only the status is left readable, for backends that format by
+// it. An empty key leaves the event unchanged.
+func SealEvent(ev Event, key string) (Event, error) {
+	if key == "" {
+		return ev, nil
+	}
+	raw, err := seal.ParseKey(key)
+	if err != nil {
+		return ev, fmt.Errorf("invalid encryption key: %w", err)
+	}
+
+	n := seal.Notification{Title: ev.Title, Message: ev.Message, Status: string(ev.Status), Project: ev.Project, Time: time.Now()}
+	if ev.Content != nil {
+		if n.Title == "" {
+			n.Title = ev.Content.Title // The status title, which the backend would have used
+		}
+		n.Repo, n.Branch, n.Session, n.Elapsed = ev.Content.Repo, ev.Content.Branch, ev.Content.Session, ev.Content.Elapsed
+	}
+	envelope, err := seal.Seal(raw, n)
+	if err != nil {
+		return ev, err
+	}
+
+	ev.Title = sealedTitle
+	ev.Message = envelope
+	ev.Project = ""
+	ev.Elapsed = 0
+	if ev.Content != nil {
+		ev.Content = &config.ContentData{Title: ev.Title, Message: envelope, Status: ev.Content.Status, Event: ev.Content.Event}
+	}
+	return ev, nil
+}
@@ -0,0 +1,50 @@
+package notifier
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/seal"
+)
+
+func TestSealEvent(t *testing.T) {
+	const key = "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="
+	ev := Event{
+		Status:  analyzer.StatusTaskComplete,
+		Message: "[bold-fox] Deployed v2 to prod",
+		Project: "api",
+		Elapsed: 5 * time.Minute,
+		Content: &config.ContentData{Title: "✅ Completed", Message: "Deployed v2 to prod", Status: "task_complete", Event: "Stop", Project: "api", Branch: "main", Session: "bold-fox"},
+	}
+
+	if got, err := SealEvent(ev, ""); err != nil || got.Message != ev.Message {
+		t.Errorf("SealEvent(no key) = %+v, %v", got, err)
+	}
+
+	got, err := SealEvent(ev, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "🔒 Claude Code" || got.Project != "" || got.Elapsed != 0 || got.Status != analyzer.StatusTaskComplete {
+		t.Errorf("sealed event = %+v", got)
+	}
+	if !strings.HasPrefix(got.Message, seal.Prefix) || got.Content.Message != got.Message || got.Content.Branch != "" {
+		t.Errorf("sealed message = %q, content = %+v", got.Message, *got.Content)
+	}
+
+	raw, _ := seal.ParseKey(key)
+	n, err := seal.Open(raw, got.Message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Title != "✅ Completed" || n.Message != "[bold-fox] Deployed v2 to prod" || n.Project != "api" || n.Branch != "main" || n.Status != "task_complete" {
+		t.Errorf("opened notification = %+v", n)
+	}
+
+	if _, err := SealEvent(ev, "short"); err == nil {
+		t.Error("SealEvent(invalid key) should fail")
+	}
+}
@@ -3,14 +3,17 @@
 package notifier
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/777genius/claude-notifications/internal/config"
 	"github.com/777genius/claude-notifications/internal/platform"
+	"github.com/777genius/claude-notifications/internal/sessions"
 )
 
 // terminalBundleIDMap maps TERM_PROGRAM values to macOS bundle identifiers
@@ -24,6 +27,7 @@ var terminalBundleIDMap = map[string]string{
 	"Alacritty":      "org.alacritty",
 	"Hyper":          "co.zeit.hyper",
 	"vscode":         "com.microsoft.VSCode",
+	"zed":            "dev.zed.Zed",
 }
 
 // GetTerminalBundleID determines the bundle ID of the current terminal.
@@ -74,8 +78,10 @@ func getBundleIDFromTmuxEnv() string {
 		}
 		args = append(args, "TERM_PROGRAM")
 
-		cmd := exec.Command("tmux", args...)
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultExecTimeout)
+		cmd := exec.CommandContext(ctx, "tmux", args...)
 		output, err := cmd.Output()
+		cancel()
 		if err != nil {
 			continue
 		}
@@ -235,8 +241,14 @@ func EnsureClaudeNotificationsApp() error {
 
 // sendLinuxNotification is a stub for macOS.
 // On macOS, click-to-focus is handled via terminal-notifier.
-func sendLinuxNotification(title, body, appIcon string, cfg *config.Config, cwd string) error {
-	return fmt.Errorf("Linux notifications not available on macOS")
+func sendLinuxNotification(title, body, appIcon, urgency string, cfg *config.Config, sessionID, cwd, replace string) (uint32, error) {
+	return 0, fmt.Errorf("Linux notifications not available on macOS")
+}
+
+// sendWindowsNotification is a stub for macOS.
+// Windows toast notifications are only available on Windows.
+func sendWindowsNotification(title, body, appIcon string, cfg *config.Config, cwd string) error {
+	return fmt.Errorf("Windows toast notifications not available on macOS")
 }
 
 // IsDaemonAvailable returns false on macOS (Linux daemon is not applicable).
@@ -253,3 +265,27 @@ func StartDaemon() bool {
 func StopDaemon() error {
 	return nil
 }
+
+// dismissSession is a no-op on macOS (only the Linux daemon closes its notifications).
+func dismissSession(sessionID string) error {
+	return nil
+}
+
+// acknowledged is unknown on macOS (only the Linux daemon tracks clicks).
+func acknowledged(sessionID string) time.Time {
+	return time.Time{}
+}
+
+// ReportDelivery is a no-op on macOS (the metrics endpoint is served by the Linux daemon).
+func ReportDelivery(backend string, success bool, d time.Duration) {}
+
+// ResolveSecret reads a command or keyring secret in this process (only the Linux daemon caches them).
+func ResolveSecret(ref string) (string, error) {
+	return platform.ResolveSecret(ref)
+}
+
+// TrackSession is a no-op on macOS (only the Linux daemon keeps a live session map).
+func TrackSession(s *sessions.Session) {}
+
+// UntrackSession is a no-op on macOS.
+func UntrackSession(sessionID string) {}
@@ -6,11 +6,15 @@ package notifier
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/777genius/claude-notifications/internal/config"
 	"github.com/777genius/claude-notifications/internal/daemon"
 	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/platform"
+	"github.com/777genius/claude-notifications/internal/sessions"
 	"github.com/gen2brain/beeep"
 )
 
@@ -40,51 +44,152 @@ func EnsureClaudeNotificationsApp() error {
 
 // sendLinuxNotification sends a notification on Linux.
 // When clickToFocus is enabled, uses the daemon for click-to-focus support.
-// Falls back to beeep when daemon is unavailable.
+// Otherwise (or when the daemon is unavailable) talks to org.freedesktop.Notifications
+// directly over D-Bus, and only then falls back to beeep. When nothing can
+// show it, the notification is spooled for the daemon to show when it starts.
+// urgency is one of "low", "normal" or "critical".
+// sessionID lets the daemon coalesce bursts of notifications from one session. May be empty.
 // cwd is the working directory of the project; used for window-specific focus. May be empty.
-func sendLinuxNotification(title, body, appIcon string, cfg *config.Config, cwd string) error {
-	// If click-to-focus is disabled, use beeep directly
-	if !cfg.Notifications.Desktop.ClickToFocus {
-		logging.Debug("Click-to-focus disabled, using beeep directly")
-		return beeep.Notify(title, body, appIcon)
+// replace is the key of a notification to update in place, e.g. a heartbeat (empty = new).
+// Returns the ID the notification server assigned (0 = unknown, e.g. shown by beeep).
+func sendLinuxNotification(title, body, appIcon, urgency string, cfg *config.Config, sessionID, cwd, replace string) (uint32, error) {
+	if cfg.Notifications.Desktop.ClickToFocus {
+		// Try to use daemon for click-to-focus
+		if id, err := sendViaDaemon(title, body, urgency, sessionID, cwd, replace, cfg.Notifications.Desktop); err == nil {
+			logging.Debug("Notification sent via daemon with click-to-focus support: id=%d", id)
+			return id, nil
+		} else {
+			logging.Debug("Daemon not available (%v), falling back to D-Bus", err)
+		}
+	} else {
+		logging.Debug("Click-to-focus disabled, using D-Bus directly")
 	}
 
-	// Try to use daemon for click-to-focus
-	if err := sendViaDaemon(title, body, cwd); err == nil {
-		logging.Debug("Notification sent via daemon with click-to-focus support")
-		return nil
+	// Direct D-Bus (no click-to-focus: the action signal needs a long-lived listener).
+	// Without the daemon, the ID of the key's last notification is read from
+	// the runtime directory, so an update replaces it instead of stacking.
+	key := dbusReplaceKey(sessionID, replace, cfg.Notifications.Desktop)
+	if id, err := sendViaDBus(title, body, appIcon, urgency, lastDBusID(key)); err == nil {
+		logging.Debug("Notification sent via D-Bus: id=%d", id)
+		if err := saveDBusID(key, id, time.Now()); err != nil {
+			logging.Debug("Failed to record notification ID: %v", err)
+		}
+		return id, nil
 	} else {
-		logging.Debug("Daemon not available (%v), falling back to beeep", err)
+		logging.Debug("D-Bus notification failed (%v), falling back to beeep", err)
 	}
 
-	// Fallback to beeep (no click-to-focus)
-	return beeep.Notify(title, body, appIcon)
+	// Fallback to beeep
+	err := beeep.Notify(title, body, appIcon)
+	if err == nil || replace != "" {
+		// An update shown later would be stale
+		return 0, err
+	}
+	if spoolErr := daemon.Spool(daemonRequest(title, body, urgency, sessionID, cwd, "", cfg.Notifications.Desktop)); spoolErr != nil {
+		return 0, fmt.Errorf("%w (failed to spool it: %v)", err, spoolErr)
+	}
+	logging.Warn("No notification service available (%v); spooled the notification for the daemon to show when it starts", err)
+	return 0, nil
+}
+
+// dbusReplaceKey returns the key whose last notification a direct D-Bus
+// notification replaces: the replace key of an update, or the session
+// with desktop.groupBySession, as the daemon does ("" = a new one)
+func dbusReplaceKey(sessionID, replace string, desktop config.DesktopConfig) string {
+	if replace != "" {
+		return replace
+	}
+	if desktop.GroupBySession {
+		return sessionID
+	}
+	return ""
 }
 
 // sendViaDaemon sends a notification via the background daemon.
-// Returns an error if daemon is not available or fails.
+// Returns the daemon-assigned notification ID, or an error if the daemon is not available or fails.
 // cwd is used to extract the project folder name for window-specific focus.
-func sendViaDaemon(title, body, cwd string) error {
-	// Start daemon on-demand (no-op if already running)
-	if !daemon.StartDaemonOnDemand() {
-		return daemon.ErrDaemonNotAvailable
-	}
+// desktop sets how the daemon coalesces, rate-limits and dismisses notifications.
+func sendViaDaemon(title, body, urgency, sessionID, cwd, replace string, desktop config.DesktopConfig) (uint32, error) {
+	req := daemonRequest(title, body, urgency, sessionID, cwd, replace, desktop)
 
-	// Create client and send notification
-	client, err := daemon.NewClient()
-	if err != nil {
-		return err
-	}
+	// Start the daemon on demand and send with a 30 second timeout; a
+	// daemon that exited in between is started again
+	var id uint32
+	err := daemon.WithDaemon(func(client *daemon.Client) error {
+		resp, err := client.Send(req)
+		if err != nil {
+			return err
+		}
+		id = resp.NotificationID
+		return nil
+	})
+	return id, err
+}
 
+// daemonRequest builds the daemon request for a notification, with what
+// the daemon needs to focus the terminal, window, tmux pane or Zellij tab.
+// A replace key makes it an update of that key's notification instead.
+func daemonRequest(title, body, urgency, sessionID, cwd, replace string, desktop config.DesktopConfig) *daemon.NotifyRequest {
 	// Extract folder name from cwd for title-based window focus
 	folderName := ""
 	if cwd != "" {
 		folderName = filepath.Base(cwd)
 	}
 
-	// Send notification with 30 second timeout, auto-detect terminal
-	_, err = client.SendNotification(title, body, "", folderName, 30)
-	return err
+	req := &daemon.NotifyRequest{
+		Title:       title,
+		Body:        body,
+		FocusTarget: daemon.GetTerminalName(),
+		FocusFolder: folderName,
+		Timeout:     30,
+		Urgency:     urgency,
+
+		CoalesceKey:     sessionID,
+		CoalesceSeconds: desktop.Throttle.CoalesceSeconds,
+		MaxPerMinute:    desktop.Throttle.MaxPerMinute,
+		DismissOnFocus:  desktop.AutoDismiss,
+		CoalesceOpen:    desktop.GroupBySession && sessionID != "",
+	}
+	if replace != "" {
+		req.CoalesceKey, req.CoalesceSeconds, req.CoalesceOpen = replace, 0, false
+		req.Update = true
+	}
+
+	// Focus the window the session started in rather than any window of the terminal
+	if sessionID != "" {
+		if dir, err := config.GetStableConfigDir(); err == nil {
+			if session, err := sessions.NewRegistry(dir).Get(sessionID); err == nil && session != nil {
+				req.Window = session.Window
+			}
+		}
+	}
+
+	// Inside tmux, record the pane so the daemon can switch back to it on click
+	if IsTmux() {
+		req.TmuxPane = os.Getenv("TMUX_PANE")
+		if req.TmuxPane == "" {
+			req.TmuxPane, _ = GetTmuxPaneTarget()
+		}
+		req.TmuxSocket = getTmuxSocketPath()
+	}
+
+	// Inside Zellij, record the active tab so the daemon can switch back to it on click
+	if IsZellij() {
+		if tabName, sessionName, err := GetZellijTabTarget(); err == nil {
+			req.ZellijTab = tabName
+			req.ZellijSession = sessionName
+		} else {
+			logging.Debug("Could not determine zellij tab: %v", err)
+		}
+	}
+
+	return req
+}
+
+// sendWindowsNotification is a stub for Linux.
+// Windows toast notifications are only available on Windows.
+func sendWindowsNotification(title, body, appIcon string, cfg *config.Config, cwd string) error {
+	return fmt.Errorf("Windows toast notifications not available on Linux")
 }
 
 // IsDaemonAvailable checks if the notification daemon is available and running.
@@ -103,3 +208,106 @@ func StartDaemon() bool {
 func StopDaemon() error {
 	return daemon.StopDaemon()
 }
+
+// dismissSession closes the notifications a running daemon still shows for
+// a session. The daemon is never started: without it, none are shown.
+func dismissSession(sessionID string) error {
+	if !daemon.IsDaemonRunning() {
+		return nil
+	}
+	client, err := daemon.NewClient()
+	if err != nil {
+		return err
+	}
+	closed, err := client.Dismiss(sessionID)
+	if err != nil {
+		return err
+	}
+	if closed > 0 {
+		logging.Debug("Dismissed %d earlier notification(s) of session %s", closed, sessionID)
+	}
+	return nil
+}
+
+// acknowledged asks a running daemon when the session's notifications were
+// last acknowledged. The daemon is never started: without it, none are shown.
+func acknowledged(sessionID string) time.Time {
+	if !daemon.IsDaemonRunning() {
+		return time.Time{}
+	}
+	client, err := daemon.NewClient()
+	if err != nil {
+		return time.Time{}
+	}
+	at, err := client.Acknowledged(sessionID)
+	if err != nil {
+		logging.Debug("Failed to ask daemon for acknowledgements: %v", err)
+		return time.Time{}
+	}
+	return at
+}
+
+// ReportDelivery tells a running daemon the outcome of a delivery, for its
+// metrics endpoint. Errors are ignored and the daemon is never started.
+func ReportDelivery(backend string, success bool, d time.Duration) {
+	client, err := daemon.NewClient()
+	if err != nil {
+		return
+	}
+	if err := client.ReportDelivery(backend, success, d); err != nil {
+		logging.Debug("Failed to report delivery to daemon: %v", err)
+	}
+}
+
+// ResolveSecret returns the secret a command or keyring reference names.
+// A running daemon reads each secret once for all hooks; without one, or
+// when it cannot read it, the secret is read here. The daemon is never
+// started.
+func ResolveSecret(ref string) (string, error) {
+	if client, err := daemon.NewClient(); err == nil {
+		value, err := client.ResolveSecret(ref)
+		if err == nil {
+			return value, nil
+		}
+		logging.Debug("Daemon could not resolve a secret, reading it here: %v", err)
+	}
+	return platform.ResolveSecret(ref)
+}
+
+// TrackSession tells a running daemon where a session runs: its registered
+// project, terminal and window, plus the terminal process and tmux pane of
+// this hook. Errors are ignored and the daemon is never started.
+func TrackSession(s *sessions.Session) {
+	client, err := daemon.NewClient()
+	if err != nil {
+		return
+	}
+	update := &daemon.SessionUpdate{
+		SessionID:   s.ID,
+		CWD:         s.CWD,
+		Terminal:    s.Terminal,
+		TerminalPID: daemon.TerminalPID(),
+		Window:      s.Window,
+	}
+	if IsTmux() {
+		update.TmuxPane = os.Getenv("TMUX_PANE")
+		if update.TmuxPane == "" {
+			update.TmuxPane, _ = GetTmuxPaneTarget()
+		}
+		update.TmuxSocket = getTmuxSocketPath()
+	}
+	if err := client.UpdateSession(update); err != nil {
+		logging.Debug("Failed to update session in daemon: %v", err)
+	}
+}
+
+// UntrackSession tells a running daemon that a session ended
+func UntrackSession(sessionID string) {
+	client, err := daemon.NewClient()
+	if err != nil {
+		return
+	}
+	if err := client.UpdateSession(&daemon.SessionUpdate{SessionID: sessionID, Ended: true}); err != nil {
+		logging.Debug("Failed to remove session from daemon: %v", err)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build linux
+
+package notifier
+
+import (
+	"os"
+	"testing"
+)
+
+// TestMain gives the tests a home directory of their own: without a
+// notification service, sendLinuxNotification spools into it
+func TestMain(m *testing.M) {
+	home, err := os.MkdirTemp("", "notifier-test-home")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("HOME", home)
+	code := m.Run()
+	os.RemoveAll(home)
+	os.Exit(code)
+}
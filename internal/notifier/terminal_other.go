@@ -1,11 +1,14 @@
-//go:build !darwin && !linux
+//go:build !darwin && !linux && !windows
 
 package notifier
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/platform"
+	"github.com/777genius/claude-notifications/internal/sessions"
 	"github.com/gen2brain/beeep"
 )
 
@@ -32,8 +35,14 @@ func EnsureClaudeNotificationsApp() error {
 }
 
 // sendLinuxNotification is a stub for non-Linux platforms.
-// On Windows, this falls back to beeep directly.
-func sendLinuxNotification(title, body, appIcon string, cfg *config.Config, cwd string) error {
+// Falls back to beeep directly.
+func sendLinuxNotification(title, body, appIcon, urgency string, cfg *config.Config, sessionID, cwd, replace string) (uint32, error) {
+	return 0, beeep.Notify(title, body, appIcon)
+}
+
+// sendWindowsNotification is a stub for non-Windows platforms.
+// Falls back to beeep directly.
+func sendWindowsNotification(title, body, appIcon string, cfg *config.Config, cwd string) error {
 	return beeep.Notify(title, body, appIcon)
 }
 
@@ -51,3 +60,27 @@ func StartDaemon() bool {
 func StopDaemon() error {
 	return nil
 }
+
+// dismissSession is a no-op on non-Linux platforms (only the Linux daemon closes its notifications).
+func dismissSession(sessionID string) error {
+	return nil
+}
+
+// acknowledged is unknown on non-Linux platforms.
+func acknowledged(sessionID string) time.Time {
+	return time.Time{}
+}
+
+// ReportDelivery is a no-op on non-Linux platforms.
+func ReportDelivery(backend string, success bool, d time.Duration) {}
+
+// ResolveSecret reads a command or keyring secret in this process.
+func ResolveSecret(ref string) (string, error) {
+	return platform.ResolveSecret(ref)
+}
+
+// TrackSession is a no-op on non-Linux platforms.
+func TrackSession(s *sessions.Session) {}
+
+// UntrackSession is a no-op on non-Linux platforms.
+func UntrackSession(sessionID string) {}
@@ -0,0 +1,153 @@
+//go:build windows
+
+// ABOUTME: Windows-specific notification handling via WinRT toast notifications.
+// ABOUTME: Falls back to the BurntToast PowerShell module when the toast COM API is unavailable.
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	toast "git.sr.ht/~jackmordaunt/go-toast"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/platform"
+	"github.com/777genius/claude-notifications/internal/sessions"
+	"github.com/gen2brain/beeep"
+)
+
+// GetTerminalBundleID returns empty string on Windows
+// as terminal bundle IDs are a macOS-specific concept.
+func GetTerminalBundleID(configOverride string) string {
+	return ""
+}
+
+// GetTerminalNotifierPath returns an error on Windows
+// as terminal-notifier is macOS-only.
+func GetTerminalNotifierPath() (string, error) {
+	return "", fmt.Errorf("terminal-notifier is only available on macOS")
+}
+
+// IsTerminalNotifierAvailable returns false on Windows.
+func IsTerminalNotifierAvailable() bool {
+	return false
+}
+
+// EnsureClaudeNotificationsApp is a no-op on Windows.
+func EnsureClaudeNotificationsApp() error {
+	return nil
+}
+
+// sendLinuxNotification is a stub for Windows.
+// Falls back to beeep directly.
+func sendLinuxNotification(title, body, appIcon, urgency string, cfg *config.Config, sessionID, cwd, replace string) (uint32, error) {
+	return 0, beeep.Notify(title, body, appIcon)
+}
+
+// sendWindowsNotification sends a native toast notification.
+// When clickToFocus is enabled and the terminal exposes a protocol handler
+// (VS Code), clicking the toast or its "Focus" button brings that window forward.
+// Tries the WinRT COM API first, then the BurntToast PowerShell module.
+// cwd is the working directory of the project; used for window-specific focus. May be empty.
+func sendWindowsNotification(title, body, appIcon string, cfg *config.Config, cwd string) error {
+	var activationURI string
+	if cfg.Notifications.Desktop.ClickToFocus {
+		activationURI = buildToastActivationURI(os.Getenv("TERM_PROGRAM"), cwd)
+	}
+
+	// The toast is rendered by another process, so the icon path must be absolute
+	if appIcon != "" {
+		if abs, err := filepath.Abs(appIcon); err == nil {
+			appIcon = abs
+		}
+	}
+
+	n := toast.Notification{
+		AppID: windowsToastAppID,
+		Title: title,
+		Body:  body,
+		Icon:  appIcon,
+		Audio: toast.Silent, // Go manages sound via audio player
+	}
+	if activationURI != "" {
+		n.ActivationType = toast.Protocol
+		n.ActivationArguments = activationURI
+		n.Actions = []toast.Action{
+			{Type: toast.Protocol, Content: "Focus", Arguments: activationURI},
+		}
+	}
+
+	toastErr := n.Push()
+	if toastErr == nil {
+		logging.Debug("Windows toast sent: activation=%q", activationURI)
+		return nil
+	}
+	logging.Debug("WinRT toast failed (%v), trying BurntToast", toastErr)
+
+	if err := sendWithBurntToast(title, body, appIcon, activationURI, cfg.Notifications.Desktop.ExecTimeoutDuration()); err != nil {
+		return fmt.Errorf("toast failed: %v; BurntToast fallback failed: %w", toastErr, err)
+	}
+	logging.Debug("Windows toast sent via BurntToast")
+	return nil
+}
+
+// sendWithBurntToast shows a toast through the BurntToast PowerShell module,
+// stopping PowerShell after timeout.
+func sendWithBurntToast(title, body, appIcon, activationURI string, timeout time.Duration) error {
+	script := buildBurntToastScript(title, body, appIcon, activationURI)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "powershell.exe", "-NoProfile", "-NonInteractive", "-Command", script)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("powershell error: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
+// IsDaemonAvailable returns false on Windows (Linux daemon is not applicable).
+func IsDaemonAvailable() bool {
+	return false
+}
+
+// StartDaemon is a no-op on Windows.
+func StartDaemon() bool {
+	return false
+}
+
+// StopDaemon is a no-op on Windows.
+func StopDaemon() error {
+	return nil
+}
+
+// dismissSession is a no-op on Windows (only the Linux daemon closes its notifications).
+func dismissSession(sessionID string) error {
+	return nil
+}
+
+// acknowledged is unknown on Windows (only the Linux daemon tracks clicks).
+func acknowledged(sessionID string) time.Time {
+	return time.Time{}
+}
+
+// ReportDelivery is a no-op on Windows (the metrics endpoint is served by the Linux daemon).
+func ReportDelivery(backend string, success bool, d time.Duration) {}
+
+// ResolveSecret reads a command or keyring secret in this process (only the Linux daemon caches them).
+func ResolveSecret(ref string) (string, error) {
+	return platform.ResolveSecret(ref)
+}
+
+// TrackSession is a no-op on Windows (only the Linux daemon keeps a live session map).
+func TrackSession(s *sessions.Session) {}
+
+// UntrackSession is a no-op on Windows.
+func UntrackSession(sessionID string) {}
@@ -1,11 +1,14 @@
 package notifier
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
 	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
 )
 
 // IsTmux returns true if the current process is running inside a tmux session.
@@ -13,6 +16,47 @@ func IsTmux() bool {
 	return os.Getenv("TMUX") != ""
 }
 
+// tmuxMessageDuration is how long a tmux display-message notification stays
+const tmuxMessageDuration = 5 * time.Second
+
+// sendTmuxMessage shows a notification in the status line of the tmux
+// clients attached to the hook's session (desktop.tmuxMessage)
+func sendTmuxMessage(title, body string, timeout time.Duration) error {
+	if !IsTmux() {
+		return fmt.Errorf("not running inside tmux")
+	}
+	args := []string{"display-message"}
+	if pane := os.Getenv("TMUX_PANE"); pane != "" {
+		args = append(args, "-t", pane)
+	}
+	text := tmuxMessageText(title, body)
+
+	run := func(args ...string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if out, err := exec.CommandContext(ctx, getTmuxPath(), args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("tmux display-message failed: %w: %s", err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+	duration := fmt.Sprint(tmuxMessageDuration.Milliseconds())
+	if err := run(append(args, "-d", duration, text)...); err != nil {
+		// tmux before 3.2 has no -d: shown for its display-time
+		return run(append(args, text)...)
+	}
+	return nil
+}
+
+// tmuxMessageText joins title and body on one line. Control characters are
+// dropped and "#" doubled, since tmux expands formats in the message.
+func tmuxMessageText(title, body string) string {
+	text := sanitizeOSCText(title)
+	if body = sanitizeOSCText(body); body != "" {
+		text += ": " + body
+	}
+	return strings.ReplaceAll(text, "#", "##")
+}
+
 // getTmuxSocketPath extracts the tmux socket path from the TMUX env var.
 // TMUX format: "/private/tmp/tmux-501/default,12345,0"
 func getTmuxSocketPath() string {
@@ -39,7 +83,9 @@ func getTmuxPath() string {
 // GetTmuxPaneTarget returns the current tmux pane target (e.g. "%42")
 // for use with tmux select-pane / select-window commands.
 func GetTmuxPaneTarget() (string, error) {
-	cmd := exec.Command("tmux", "display-message", "-p", "#{pane_id}")
+	ctx, cancel := context.WithTimeout(context.Background(), config.DefaultExecTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "tmux", "display-message", "-p", "#{pane_id}")
 	output, err := cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("failed to get tmux pane target: %w", err)
@@ -0,0 +1,73 @@
+package notifier
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTmuxMessageText(t *testing.T) {
+	got := tmuxMessageText("✅ Completed [bold]", "Fixed #42\nin api\x1b[31m")
+	if want := "✅ Completed [bold]: Fixed ##42 in api[31m"; got != want {
+		t.Errorf("tmuxMessageText() = %q, want %q", got, want)
+	}
+	if got := tmuxMessageText("Title", ""); got != "Title" {
+		t.Errorf("tmuxMessageText(no body) = %q", got)
+	}
+}
+
+// fakeTmux puts a tmux script first in PATH that logs its arguments, one
+// call per line, and fails on -d when old is set
+func fakeTmux(t *testing.T, old bool) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script stand-in for tmux")
+	}
+	dir := t.TempDir()
+	log := filepath.Join(dir, "calls")
+	script := "#!/bin/sh\necho \"$*\" >> " + log + "\n"
+	if old {
+		script += "case \"$*\" in *' -d '*) echo 'unknown flag -d' >&2; exit 1;; esac\n"
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tmux"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return log
+}
+
+func TestSendTmuxMessage(t *testing.T) {
+	log := fakeTmux(t, false)
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,123,0")
+	t.Setenv("TMUX_PANE", "%3")
+
+	if err := sendTmuxMessage("Question", "Pick one", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	calls, _ := os.ReadFile(log)
+	if want := "display-message -t %3 -d 5000 Question: Pick one\n"; string(calls) != want {
+		t.Errorf("tmux called with %q, want %q", calls, want)
+	}
+
+	t.Setenv("TMUX", "")
+	if err := sendTmuxMessage("Question", "", time.Second); err == nil {
+		t.Error("sendTmuxMessage outside tmux should fail")
+	}
+}
+
+func TestSendTmuxMessage_OldTmux(t *testing.T) {
+	log := fakeTmux(t, true)
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,123,0")
+	t.Setenv("TMUX_PANE", "")
+
+	if err := sendTmuxMessage("Done", "", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	calls, _ := os.ReadFile(log)
+	if lines := strings.Split(strings.TrimSpace(string(calls)), "\n"); len(lines) != 2 || lines[1] != "display-message Done" {
+		t.Errorf("tmux calls = %q, want a retry without -d", lines)
+	}
+}
@@ -0,0 +1,51 @@
+package notifier
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/777genius/claude-notifications/internal/platform"
+)
+
+// windowsToastAppID is the AppID used for every Windows toast. It must stay fixed:
+// each unique AppID creates a persistent registry entry under
+// HKCU\SOFTWARE\Microsoft\Windows\CurrentVersion\Notifications\Settings\.
+const windowsToastAppID = "Claude Code Notifications"
+
+// buildToastActivationURI returns the protocol URI launched when a Windows toast
+// (or its "Focus" button) is clicked. VS Code registers the vscode:// protocol,
+// and opening vscode://file/<cwd> focuses the window that has that folder open.
+// Returns "" when no protocol target is known; clicking then just dismisses the toast.
+func buildToastActivationURI(termProgram, cwd string) string {
+	if cwd == "" || !strings.EqualFold(termProgram, "vscode") {
+		return ""
+	}
+
+	// Normalize Windows separators; URI paths must start with a slash
+	// (C:\src\app -> /C:/src/app).
+	p := strings.ReplaceAll(cwd, `\`, "/")
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+
+	u := url.URL{Scheme: "vscode", Host: "file", Path: p}
+	return u.String()
+}
+
+// buildBurntToastScript builds the PowerShell command used when the WinRT toast
+// API is unavailable. Requires the BurntToast module
+// (Install-Module -Name BurntToast -Scope CurrentUser).
+// appIcon and activationURI may be empty.
+func buildBurntToastScript(title, message, appIcon, activationURI string) string {
+	var b strings.Builder
+	b.WriteString("Import-Module BurntToast -ErrorAction Stop; ")
+	fmt.Fprintf(&b, "New-BurntToastNotification -Text %s, %s -Silent", platform.PSQuote(title), platform.PSQuote(message))
+	if appIcon != "" {
+		fmt.Fprintf(&b, " -AppLogo %s", platform.PSQuote(appIcon))
+	}
+	if activationURI != "" {
+		fmt.Fprintf(&b, " -Button (New-BTButton -Content 'Focus' -Arguments %s)", platform.PSQuote(activationURI))
+	}
+	return b.String()
+}
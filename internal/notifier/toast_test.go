@@ -0,0 +1,86 @@
+package notifier
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildToastActivationURI(t *testing.T) {
+	tests := []struct {
+		name        string
+		termProgram string
+		cwd         string
+		want        string
+	}{
+		{"vscode windows path", "vscode", `C:\Users\dev\my-app`, "vscode://file/C:/Users/dev/my-app"},
+		{"vscode unix path", "vscode", "/home/dev/my-app", "vscode://file/home/dev/my-app"},
+		{"vscode case-insensitive", "VSCode", `D:\src`, "vscode://file/D:/src"},
+		{"vscode path with space", "vscode", `C:\My Projects\app`, "vscode://file/C:/My%20Projects/app"},
+		{"vscode empty cwd", "vscode", "", ""},
+		{"windows terminal", "", `C:\src`, ""},
+		{"other terminal", "WezTerm", `C:\src`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildToastActivationURI(tt.termProgram, tt.cwd)
+			if got != tt.want {
+				t.Errorf("buildToastActivationURI(%q, %q) = %q, want %q", tt.termProgram, tt.cwd, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildBurntToastScript_Minimal(t *testing.T) {
+	script := buildBurntToastScript("✅ Completed", "Done", "", "")
+
+	if !strings.HasPrefix(script, "Import-Module BurntToast -ErrorAction Stop; ") {
+		t.Errorf("script should import BurntToast first, got: %s", script)
+	}
+	if !strings.Contains(script, "-Text '✅ Completed', 'Done'") {
+		t.Errorf("script should contain title and message, got: %s", script)
+	}
+	if !strings.Contains(script, "-Silent") {
+		t.Errorf("script should be silent (sound is played by Go), got: %s", script)
+	}
+	if strings.Contains(script, "-AppLogo") {
+		t.Errorf("script should not contain -AppLogo without icon, got: %s", script)
+	}
+	if strings.Contains(script, "New-BTButton") {
+		t.Errorf("script should not contain a button without activation URI, got: %s", script)
+	}
+}
+
+func TestBuildBurntToastScript_WithIconAndActivation(t *testing.T) {
+	script := buildBurntToastScript("Title", "Msg", `C:\icons\claude.png`, "vscode://file/C:/src")
+
+	if !strings.Contains(script, `-AppLogo 'C:\icons\claude.png'`) {
+		t.Errorf("script should contain app logo, got: %s", script)
+	}
+	if !strings.Contains(script, "-Button (New-BTButton -Content 'Focus' -Arguments 'vscode://file/C:/src')") {
+		t.Errorf("script should contain focus button, got: %s", script)
+	}
+}
+
+func TestBuildBurntToastScript_EscapesQuotes(t *testing.T) {
+	script := buildBurntToastScript("Claude's task", "it's done'; Remove-Item *", "", "")
+
+	if !strings.Contains(script, "'Claude''s task'") {
+		t.Errorf("title quote not escaped, got: %s", script)
+	}
+	if !strings.Contains(script, "'it''s done''; Remove-Item *'") {
+		t.Errorf("message quote not escaped, got: %s", script)
+	}
+}
+
+func TestBuildBurntToastScript_EscapesTypographicQuotes(t *testing.T) {
+	// PowerShell ends a single-quoted string at U+2019 as well
+	script := buildBurntToastScript("Claude’s task’; Start-Process calc; ’", "don’t", "", "")
+
+	if !strings.Contains(script, "'Claude’’s task’’; Start-Process calc; ’’'") {
+		t.Errorf("title quote not escaped, got: %s", script)
+	}
+	if !strings.Contains(script, "'don’’t'") {
+		t.Errorf("message quote not escaped, got: %s", script)
+	}
+}
@@ -0,0 +1,95 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/platform"
+)
+
+// wslToastAppID is the AppID of toasts sent through PowerShell from WSL.
+// Windows only shows toasts of registered apps; PowerShell's own AppID is
+// registered on every Windows 10 and 11 install.
+const wslToastAppID = `{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe`
+
+// sendWSLNotification shows a notification as a toast on the Windows host
+// (desktop.wsl): through wsl-notify-send when installed, else through
+// PowerShell, which needs no extra tool. In VS Code, clicking the toast
+// focuses the WSL window of the project.
+func sendWSLNotification(title, body, mode, cwd string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if mode != "powershell" {
+		path, err := exec.LookPath("wsl-notify-send.exe")
+		switch {
+		case err == nil:
+			out, err := exec.CommandContext(ctx, path, "--appId", windowsToastAppID, "--category", title, body).CombinedOutput()
+			if err == nil || mode == "wsl-notify-send" {
+				return wrapWSLError("wsl-notify-send", err, out)
+			}
+			logging.Debug("wsl-notify-send failed (%v), trying PowerShell", err)
+		case mode == "wsl-notify-send":
+			return fmt.Errorf("wsl-notify-send.exe not found on PATH")
+		}
+	}
+
+	activationURI := buildWSLActivationURI(os.Getenv("TERM_PROGRAM"), platform.WSLDistro(), cwd)
+	args := platform.WSLPowerShell(buildWSLToastScript(title, body, activationURI))
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	return wrapWSLError("PowerShell toast", err, out)
+}
+
+// wrapWSLError adds the output of a failed Windows command to its error
+func wrapWSLError(what string, err error, out []byte) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%s failed: %w: %s", what, err, strings.TrimSpace(string(out)))
+}
+
+// buildWSLActivationURI returns the URI opened when the toast is clicked:
+// VS Code's Remote - WSL window of cwd, e.g.
+// vscode://vscode-remote/wsl+Ubuntu/home/me/api. Returns "" outside VS
+// Code or when the distribution is unknown; clicking then just dismisses
+// the toast.
+func buildWSLActivationURI(termProgram, distro, cwd string) string {
+	if cwd == "" || distro == "" || !strings.EqualFold(termProgram, "vscode") {
+		return ""
+	}
+	u := url.URL{Scheme: "vscode", Host: "vscode-remote", Path: "/wsl+" + distro + cwd}
+	return u.String()
+}
+
+// buildWSLToastScript builds the PowerShell script showing a toast through
+// the WinRT API, which Windows PowerShell loads without extra modules.
+// activationURI may be empty.
+func buildWSLToastScript(title, message, activationURI string) string {
+	var xml strings.Builder
+	xml.WriteString("<toast")
+	if activationURI != "" {
+		fmt.Fprintf(&xml, ` activationType="protocol" launch="%s"`, platform.XMLEscape(activationURI))
+	}
+	fmt.Fprintf(&xml, `><visual><binding template="ToastGeneric"><text>%s</text><text>%s</text></binding></visual>`,
+		platform.XMLEscape(sanitizeOSCText(title)), platform.XMLEscape(sanitizeOSCText(message)))
+	if activationURI != "" {
+		fmt.Fprintf(&xml, `<actions><action content="Focus" activationType="protocol" arguments="%s"/></actions>`, platform.XMLEscape(activationURI))
+	}
+	xml.WriteString(`<audio silent="true"/></toast>`)
+
+	return strings.Join([]string{
+		"$ErrorActionPreference = 'Stop'",
+		"[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null",
+		"[Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] > $null",
+		"$xml = New-Object Windows.Data.Xml.Dom.XmlDocument",
+		"$xml.LoadXml(" + platform.PSQuote(xml.String()) + ")",
+		"$toast = New-Object Windows.UI.Notifications.ToastNotification $xml",
+		"[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier(" + platform.PSQuote(wslToastAppID) + ").Show($toast)",
+	}, "; ")
+}
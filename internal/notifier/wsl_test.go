@@ -0,0 +1,70 @@
+package notifier
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildWSLActivationURI(t *testing.T) {
+	tests := []struct {
+		termProgram, distro, cwd string
+		want                     string
+	}{
+		{"vscode", "Ubuntu", "/home/me/my api", "vscode://vscode-remote/wsl+Ubuntu/home/me/my%20api"},
+		{"vscode", "", "/home/me/api", ""},
+		{"", "Ubuntu", "/home/me/api", ""},
+		{"vscode", "Ubuntu", "", ""},
+	}
+	for _, tt := range tests {
+		if got := buildWSLActivationURI(tt.termProgram, tt.distro, tt.cwd); got != tt.want {
+			t.Errorf("buildWSLActivationURI(%q, %q, %q) = %q, want %q", tt.termProgram, tt.distro, tt.cwd, got, tt.want)
+		}
+	}
+}
+
+func TestBuildWSLToastScript(t *testing.T) {
+	script := buildWSLToastScript("✅ Completed", "Fixed <b> & 'quotes'\n", "vscode://vscode-remote/wsl+Ubuntu/home/me/api")
+	for _, want := range []string{
+		"<text>✅ Completed</text><text>Fixed &lt;b&gt; &amp; &#39;quotes&#39; </text>",
+		`activationType="protocol" launch="vscode://vscode-remote/wsl+Ubuntu/home/me/api"`,
+		`<action content="Focus"`,
+		"CreateToastNotifier('" + wslToastAppID + "')",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+	if script := buildWSLToastScript("Title", "Body", ""); strings.Contains(script, "activationType") {
+		t.Errorf("script without activation URI = %s", script)
+	}
+}
+
+func TestSendWSLNotification_WSLNotifySend(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script stand-in for wsl-notify-send")
+	}
+	dir := t.TempDir()
+	log := filepath.Join(dir, "calls")
+	script := "#!/bin/sh\necho \"$*\" >> " + log + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "wsl-notify-send.exe"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	if err := sendWSLNotification("Question", "Pick one", "", "/home/me/api", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	calls, _ := os.ReadFile(log)
+	if want := "--appId " + windowsToastAppID + " --category Question Pick one\n"; string(calls) != want {
+		t.Errorf("wsl-notify-send called with %q, want %q", calls, want)
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	if err := sendWSLNotification("Question", "Pick one", "wsl-notify-send", "", time.Second); err == nil {
+		t.Error("wsl-notify-send mode without wsl-notify-send.exe should fail")
+	}
+}
@@ -1,11 +1,14 @@
 package notifier
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
 	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
 )
 
 // IsZellij returns true if the current process is running inside a zellij session.
@@ -31,7 +34,9 @@ func GetZellijTabTarget() (tabName, sessionName string, err error) {
 
 	zellijPath := getZellijPath()
 
-	cmd := exec.Command(zellijPath, "action", "dump-layout")
+	ctx, cancel := context.WithTimeout(context.Background(), config.DefaultExecTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, zellijPath, "action", "dump-layout")
 	output, err := cmd.Output()
 	if err != nil {
 		return "", "", fmt.Errorf("failed to run zellij dump-layout: %w", err)
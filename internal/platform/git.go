@@ -1,18 +1,155 @@
 package platform
 
 import (
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 )
 
+// GitContext is the git repository a directory belongs to
+type GitContext struct {
+	Repo   string // Repository name: its top-level folder, or the main checkout's for worktrees ("" = not in a repository)
+	Root   string // Top-level directory of the working tree
+	Branch string // Current branch ("" = detached HEAD or unknown)
+}
+
+// gitCacheTTL is how long a directory's git context is reused; hooks look
+// it up more than once per event
+const gitCacheTTL = 10 * time.Second
+
+type gitCacheEntry struct {
+	ctx     GitContext
+	expires time.Time
+}
+
+var (
+	gitCacheMu sync.Mutex
+	gitCache   = map[string]gitCacheEntry{}
+)
+
+// GetGitContext returns the repository and branch of the given directory.
+// It reads .git directly instead of running git, which it only falls back
+// to for repository layouts it does not understand, and caches the result
+// for a few seconds.
+func GetGitContext(cwd string) GitContext {
+	if cwd == "" {
+		return GitContext{}
+	}
+
+	gitCacheMu.Lock()
+	entry, ok := gitCache[cwd]
+	gitCacheMu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.ctx
+	}
+
+	ctx := readGitContext(cwd)
+	gitCacheMu.Lock()
+	gitCache[cwd] = gitCacheEntry{ctx: ctx, expires: time.Now().Add(gitCacheTTL)}
+	gitCacheMu.Unlock()
+	return ctx
+}
+
 // GetGitBranch returns the current git branch name for the given directory.
 // Returns empty string if not in a git repository or on error.
 func GetGitBranch(cwd string) string {
-	if cwd == "" {
-		return ""
+	return GetGitContext(cwd).Branch
+}
+
+// readGitContext finds the .git of cwd or a parent directory and reads HEAD
+func readGitContext(cwd string) GitContext {
+	dir, err := filepath.Abs(cwd)
+	if err != nil {
+		return GitContext{}
 	}
+	for {
+		if gitDir, ok := resolveGitDir(filepath.Join(dir, ".git")); ok {
+			ctx := GitContext{Repo: repoName(dir, gitDir), Root: dir}
+			branch, ok := readHeadBranch(gitDir)
+			if !ok {
+				branch = gitBranchFromCommand(dir)
+			}
+			ctx.Branch = branch
+			return ctx
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return GitContext{}
+		}
+		dir = parent
+	}
+}
+
+// resolveGitDir returns the git directory for a .git entry: the directory
+// itself, or the one a worktree's or submodule's .git file points to
+func resolveGitDir(dotGit string) (string, bool) {
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return "", false
+	}
+	if info.IsDir() {
+		return dotGit, true
+	}
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", false
+	}
+	target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return "", false
+	}
+	target = strings.TrimSpace(target)
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(dotGit), target)
+	}
+	return filepath.Clean(target), true
+}
+
+// repoName returns the repository name for a working tree at root. A
+// linked worktree is named after the main checkout, found via commondir.
+func repoName(root, gitDir string) string {
+	data, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return filepath.Base(root)
+	}
+	common := strings.TrimSpace(string(data))
+	if !filepath.IsAbs(common) {
+		common = filepath.Join(gitDir, common)
+	}
+	common = filepath.Clean(common)
+	if filepath.Base(common) == ".git" {
+		return filepath.Base(filepath.Dir(common))
+	}
+	// Bare repository, e.g. myrepo.git
+	return strings.TrimSuffix(filepath.Base(common), ".git")
+}
+
+// readHeadBranch reads the branch from HEAD; false when HEAD cannot be
+// read or uses a format other than a plain ref or commit ID
+func readHeadBranch(gitDir string) (string, bool) {
+	data, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return "", false
+	}
+	head := strings.TrimSpace(string(data))
+	if ref, ok := strings.CutPrefix(head, "ref: "); ok {
+		branch, ok := strings.CutPrefix(ref, "refs/heads/")
+		// reftable repositories keep a placeholder HEAD
+		if !ok || branch == ".invalid" {
+			return "", false
+		}
+		return branch, true
+	}
+	// A commit ID: detached HEAD
+	return "", len(head) >= 40
+}
 
-	cmd := exec.Command("git", "-C", cwd, "rev-parse", "--abbrev-ref", "HEAD")
+// gitBranchFromCommand asks git for the branch of dir
+func gitBranchFromCommand(dir string) string {
+	cmd := exec.Command("git", "-C", dir, "rev-parse", "--abbrev-ref", "HEAD")
 	output, err := cmd.Output()
 	if err != nil {
 		return ""
@@ -119,6 +119,74 @@ func TestGetGitBranch_DetachedHead(t *testing.T) {
 	}
 }
 
+// writeFiles creates files under root, making parent directories
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGetGitContext(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		// A checkout with a subdirectory
+		"myrepo/.git/HEAD":     "ref: refs/heads/feature/auth\n",
+		"myrepo/web/README.md": "",
+		// A linked worktree of it
+		"myrepo/.git/worktrees/hotfix/HEAD":      "ref: refs/heads/hotfix\n",
+		"myrepo/.git/worktrees/hotfix/commondir": "../..\n",
+		"hotfix-tree/.git":                       "gitdir: " + filepath.Join(root, "myrepo/.git/worktrees/hotfix") + "\n",
+		// A detached HEAD
+		"detached/.git/HEAD": "0123456789abcdef0123456789abcdef01234567\n",
+		"plain/file.txt":     "",
+	})
+
+	tests := []struct {
+		cwd  string
+		want GitContext
+	}{
+		{"myrepo", GitContext{Repo: "myrepo", Root: filepath.Join(root, "myrepo"), Branch: "feature/auth"}},
+		{"myrepo/web", GitContext{Repo: "myrepo", Root: filepath.Join(root, "myrepo"), Branch: "feature/auth"}},
+		{"hotfix-tree", GitContext{Repo: "myrepo", Root: filepath.Join(root, "hotfix-tree"), Branch: "hotfix"}},
+		{"detached", GitContext{Repo: "detached", Root: filepath.Join(root, "detached")}},
+		{"plain", GitContext{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cwd, func(t *testing.T) {
+			if got := GetGitContext(filepath.Join(root, tt.cwd)); got != tt.want {
+				t.Errorf("GetGitContext(%s) = %+v, want %+v", tt.cwd, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetGitContext_Cached(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{".git/HEAD": "ref: refs/heads/main\n"})
+	if got := GetGitBranch(root); got != "main" {
+		t.Fatalf("GetGitBranch() = %q, want main", got)
+	}
+
+	// Switching branches is picked up once the cached entry expires
+	writeFiles(t, root, map[string]string{".git/HEAD": "ref: refs/heads/other\n"})
+	if got := GetGitBranch(root); got != "main" {
+		t.Errorf("GetGitBranch() = %q, want the cached main", got)
+	}
+	gitCacheMu.Lock()
+	delete(gitCache, root)
+	gitCacheMu.Unlock()
+	if got := GetGitBranch(root); got != "other" {
+		t.Errorf("GetGitBranch() = %q, want other after expiry", got)
+	}
+}
+
 // runGitCommand executes a git command in the specified directory
 func runGitCommand(dir string, args ...string) error {
 	cmd := exec.Command("git", args...)
@@ -0,0 +1,11 @@
+//go:build darwin
+
+package platform
+
+import "context"
+
+// keyringLookup reads a generic password from the login Keychain, stored
+// with "security add-generic-password -s <service> -a <account> -w"
+func keyringLookup(ctx context.Context, service, account string) (string, error) {
+	return commandSecret(ctx, "security", "find-generic-password", "-s", service, "-a", account, "-w")
+}
@@ -0,0 +1,12 @@
+//go:build !darwin && !windows
+
+package platform
+
+import "context"
+
+// keyringLookup reads a secret from the Secret Service (GNOME Keyring,
+// KWallet) through secret-tool, stored with
+// "secret-tool store --label=... service <service> account <account>"
+func keyringLookup(ctx context.Context, service, account string) (string, error) {
+	return commandSecret(ctx, "secret-tool", "lookup", "service", service, "account", account)
+}
@@ -1,11 +1,18 @@
 package platform
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
+	"unicode/utf16"
 )
 
 // OS returns the current operating system
@@ -114,3 +121,157 @@ func IsMacOS() bool {
 func IsLinux() bool {
 	return runtime.GOOS == "linux"
 }
+
+// IsSSHSession returns true if the process is running inside an SSH session
+func IsSSHSession() bool {
+	return os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_CLIENT") != "" || os.Getenv("SSH_TTY") != ""
+}
+
+// procVersion is read to detect WSL when its environment variables are unset
+var procVersion = "/proc/version"
+
+// IsWSL returns true if running in the Windows Subsystem for Linux, where
+// notifications and windows belong to the Windows host. WSL sets
+// WSL_DISTRO_NAME in shells; processes started without it (e.g. by
+// systemd) are recognized by the Microsoft kernel.
+func IsWSL() bool {
+	if !IsLinux() {
+		return false
+	}
+	if os.Getenv("WSL_DISTRO_NAME") != "" || os.Getenv("WSL_INTEROP") != "" {
+		return true
+	}
+	data, err := os.ReadFile(procVersion)
+	if err != nil {
+		return false
+	}
+	version := strings.ToLower(string(data))
+	return strings.Contains(version, "microsoft") || strings.Contains(version, "wsl")
+}
+
+// WSLDistro returns the name of the WSL distribution, e.g. "Ubuntu"
+// ("" = unknown or not in WSL)
+func WSLDistro() string {
+	return os.Getenv("WSL_DISTRO_NAME")
+}
+
+// Files container runtimes create in every container
+var (
+	dockerEnv    = "/.dockerenv"
+	containerEnv = "/run/.containerenv" // Podman; holds name="..."
+)
+
+// ContainerEnv names the container the hook runs in, e.g. in the
+// containerEnv of devcontainer.json, or with "0" tells it runs on a host
+// that merely looks like a container (detection off)
+const ContainerEnv = "CLAUDE_NOTIFICATIONS_CONTAINER"
+
+// IsContainer returns true if running inside a container: Docker, Podman,
+// a VS Code dev container or a GitHub Codespace
+func IsContainer() bool {
+	if !IsLinux() {
+		return false
+	}
+	if name := os.Getenv(ContainerEnv); name != "" {
+		return name != "0"
+	}
+	for _, env := range []string{"REMOTE_CONTAINERS", "DEVCONTAINER", "CODESPACES"} {
+		if os.Getenv(env) == "true" {
+			return true
+		}
+	}
+	return FileExists(dockerEnv) || FileExists(containerEnv)
+}
+
+// ContainerName returns a name telling the container apart from others:
+// CLAUDE_NOTIFICATIONS_CONTAINER, the Codespace, the Podman container, the dev container's workspace
+// folder on the host (LOCAL_WORKSPACE_FOLDER, set in devcontainer.json),
+// else the hostname, which Docker sets to the container ID
+func ContainerName() string {
+	for _, env := range []string{ContainerEnv, "CODESPACE_NAME"} {
+		if name := os.Getenv(env); name != "" && name != "0" {
+			return name
+		}
+	}
+	if data, err := os.ReadFile(containerEnv); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if name, ok := strings.CutPrefix(line, "name="); ok {
+				if name = strings.Trim(name, `"`); name != "" {
+					return name
+				}
+			}
+		}
+	}
+	if folder := os.Getenv("LOCAL_WORKSPACE_FOLDER"); folder != "" {
+		// The host may be Windows: C:\src\api
+		return filepath.Base(strings.ReplaceAll(folder, `\`, "/"))
+	}
+	name, _ := os.Hostname()
+	return name
+}
+
+// wslPowerShell is where Windows PowerShell is mounted in WSL, for when
+// interop does not put the Windows directories on PATH
+const wslPowerShell = "/mnt/c/Windows/System32/WindowsPowerShell/v1.0/powershell.exe"
+
+// WSLPowerShell returns the command line that runs a PowerShell script on
+// the Windows host from WSL. The script is passed base64-encoded, since WSL
+// quotes arguments for Windows on its own.
+func WSLPowerShell(script string) []string {
+	path, err := exec.LookPath("powershell.exe")
+	if err != nil {
+		path = wslPowerShell
+	}
+	var encoded []byte
+	for _, u := range utf16.Encode([]rune(script)) {
+		encoded = binary.LittleEndian.AppendUint16(encoded, u)
+	}
+	return []string{path, "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass",
+		"-EncodedCommand", base64.StdEncoding.EncodeToString(encoded)}
+}
+
+// PSQuote wraps s in PowerShell single quotes. Inside single-quoted strings
+// PowerShell only interprets the quote itself, which is escaped by doubling.
+// PowerShell also ends such a string at the typographic quotes U+2018 to
+// U+201B, as in "don’t", so those are doubled too.
+func PSQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\'', '\u2018', '\u2019', '\u201a', '\u201b':
+			b.WriteRune(r)
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+// XMLEscape escapes s for an XML element or attribute
+func XMLEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// RandomSuffix returns 8 random hex digits, for names that must neither
+// collide nor be guessed
+func RandomSuffix() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// TTYEnv carries the controlling terminal of a hook (e.g. /dev/pts/3) to
+// the worker process the daemon handles the hook in, which has none
+const TTYEnv = "CLAUDE_NOTIFICATIONS_TTY"
+
+// TTYPath returns the terminal to write bells and escape sequences to:
+// the hook's terminal in a worker of the daemon, else /dev/tty
+func TTYPath() string {
+	if path := os.Getenv(TTYEnv); path != "" {
+		return path
+	}
+	return "/dev/tty"
+}
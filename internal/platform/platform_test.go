@@ -1,6 +1,7 @@
 package platform
 
 import (
+	"encoding/base64"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -184,3 +185,122 @@ func TestAtomicCreateFile_PermissionDenied(t *testing.T) {
 	assert.False(t, created)
 	assert.Error(t, err, "Creating file in read-only directory should fail")
 }
+
+func TestIsSSHSession(t *testing.T) {
+	for _, key := range []string{"SSH_CONNECTION", "SSH_CLIENT", "SSH_TTY"} {
+		t.Setenv(key, "")
+	}
+	assert.False(t, IsSSHSession())
+
+	t.Setenv("SSH_CONNECTION", "10.0.0.2 51234 10.0.0.1 22")
+	assert.True(t, IsSSHSession())
+}
+
+func TestIsSSHSession_TTYOnly(t *testing.T) {
+	for _, key := range []string{"SSH_CONNECTION", "SSH_CLIENT"} {
+		t.Setenv(key, "")
+	}
+	t.Setenv("SSH_TTY", "/dev/pts/3")
+	assert.True(t, IsSSHSession())
+}
+
+func TestIsWSL(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		assert.False(t, IsWSL())
+		return
+	}
+	t.Setenv("WSL_DISTRO_NAME", "")
+	t.Setenv("WSL_INTEROP", "")
+	dir := t.TempDir()
+	version := func(content string) {
+		procVersion = filepath.Join(dir, "version")
+		require.NoError(t, os.WriteFile(procVersion, []byte(content), 0644))
+	}
+	defer func(path string) { procVersion = path }(procVersion)
+
+	version("Linux version 6.8.0-45-generic (buildd@lcy02-amd64-075) #45-Ubuntu SMP")
+	assert.False(t, IsWSL())
+	version("Linux version 5.15.153.1-microsoft-standard-WSL2 (root@941d701f84f1)")
+	assert.True(t, IsWSL())
+	version("Linux version 4.4.0-19041-Microsoft (Microsoft@Microsoft.com)")
+	assert.True(t, IsWSL(), "WSL 1 kernel")
+
+	procVersion = filepath.Join(dir, "missing")
+	assert.False(t, IsWSL())
+	t.Setenv("WSL_DISTRO_NAME", "Ubuntu")
+	assert.True(t, IsWSL())
+	assert.Equal(t, "Ubuntu", WSLDistro())
+}
+
+func TestWSLPowerShell(t *testing.T) {
+	args := WSLPowerShell("Write-Output 'é'")
+	require.Greater(t, len(args), 2)
+	assert.Equal(t, "-EncodedCommand", args[len(args)-2])
+	// UTF-16LE, as powershell.exe expects
+	decoded, err := base64.StdEncoding.DecodeString(args[len(args)-1])
+	require.NoError(t, err)
+	assert.Equal(t, []byte{'W', 0, 'r', 0}, decoded[:4])
+	assert.Equal(t, []byte{0xe9, 0, '\'', 0}, decoded[len(decoded)-4:])
+}
+
+func TestPSQuote(t *testing.T) {
+	assert.Equal(t, "'hello'", PSQuote("hello"))
+	assert.Equal(t, "'it''s'", PSQuote("it's"))
+	assert.Equal(t, "'$env:USERNAME'", PSQuote("$env:USERNAME"))
+	assert.Equal(t, "''", PSQuote(""))
+	assert.Equal(t, "'don’’t'", PSQuote("don’t"))
+	assert.Equal(t, "'‘‘a‚‚b‛‛'", PSQuote("‘a‚b‛"))
+}
+
+func TestXMLEscape(t *testing.T) {
+	assert.Equal(t, "Fixed &lt;b&gt; &amp; &#39;quotes&#39; &#34;here&#34;", XMLEscape(`Fixed <b> & 'quotes' "here"`))
+	assert.Equal(t, "plain", XMLEscape("plain"))
+}
+
+func TestIsContainer(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		assert.False(t, IsContainer())
+		return
+	}
+	for _, env := range []string{ContainerEnv, "REMOTE_CONTAINERS", "DEVCONTAINER", "CODESPACES"} {
+		t.Setenv(env, "")
+	}
+	dir := t.TempDir()
+	defer func(docker, podman string) { dockerEnv, containerEnv = docker, podman }(dockerEnv, containerEnv)
+	dockerEnv, containerEnv = filepath.Join(dir, ".dockerenv"), filepath.Join(dir, ".containerenv")
+
+	assert.False(t, IsContainer())
+	require.NoError(t, os.WriteFile(dockerEnv, nil, 0644))
+	assert.True(t, IsContainer())
+
+	require.NoError(t, os.Remove(dockerEnv))
+	t.Setenv("REMOTE_CONTAINERS", "true")
+	assert.True(t, IsContainer())
+	t.Setenv(ContainerEnv, "0")
+	assert.False(t, IsContainer(), "detection off")
+	t.Setenv(ContainerEnv, "api")
+	assert.True(t, IsContainer())
+}
+
+func TestContainerName(t *testing.T) {
+	dir := t.TempDir()
+	defer func(path string) { containerEnv = path }(containerEnv)
+	containerEnv = filepath.Join(dir, ".containerenv")
+	t.Setenv(ContainerEnv, "")
+	t.Setenv("CODESPACE_NAME", "")
+	t.Setenv("LOCAL_WORKSPACE_FOLDER", "")
+
+	hostname, _ := os.Hostname()
+	assert.Equal(t, hostname, ContainerName())
+
+	t.Setenv("LOCAL_WORKSPACE_FOLDER", `C:\src\api`)
+	assert.Equal(t, "api", ContainerName())
+
+	require.NoError(t, os.WriteFile(containerEnv, []byte("engine=\"podman-4.9.3\"\nname=\"web-dev\"\nid=\"8f2c\"\n"), 0644))
+	assert.Equal(t, "web-dev", ContainerName())
+
+	t.Setenv("CODESPACE_NAME", "fluffy-space-9x7")
+	assert.Equal(t, "fluffy-space-9x7", ContainerName())
+	t.Setenv(ContainerEnv, "api-devcontainer")
+	assert.Equal(t, "api-devcontainer", ContainerName())
+}
@@ -0,0 +1,98 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// KeyringPrefix starts a secret read from the OS keyring:
+// "keyring:<service>/<account>"
+const KeyringPrefix = "keyring:"
+
+// SecretTimeout bounds a secret command or keyring lookup
+const SecretTimeout = 10 * time.Second
+
+// IsSecretRef reports whether s is read from a command ("$(command)") or
+// the OS keyring ("keyring:service/account")
+func IsSecretRef(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(s, KeyringPrefix) || (strings.HasPrefix(s, "$(") && strings.HasSuffix(s, ")"))
+}
+
+// ResolveSecret returns the value of a config secret: the output of
+// "$(command)", the keyring item "keyring:service/account", or s with
+// environment variables expanded
+func ResolveSecret(s string) (string, error) {
+	trimmed := strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(trimmed, KeyringPrefix):
+		service, account, ok := strings.Cut(strings.TrimPrefix(trimmed, KeyringPrefix), "/")
+		if !ok || service == "" || account == "" {
+			return "", fmt.Errorf("invalid keyring reference %q: want keyring:<service>/<account>", trimmed)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), SecretTimeout)
+		defer cancel()
+		value, err := keyringLookup(ctx, service, account)
+		if err != nil {
+			return "", fmt.Errorf("keyring %s/%s: %w", service, account, err)
+		}
+		return value, nil
+	case IsSecretRef(trimmed):
+		return runSecretCommand(trimmed[2 : len(trimmed)-1])
+	default:
+		return ExpandEnv(s), nil
+	}
+}
+
+// runSecretCommand runs command through the shell and returns its first
+// line of output, as "pass show" prints the password first
+func runSecretCommand(command string) (string, error) {
+	if strings.TrimSpace(command) == "" {
+		return "", fmt.Errorf("empty secret command")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), SecretTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	if IsWindows() {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("secret command %q failed: %w: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+	value, _, _ := strings.Cut(stdout.String(), "\n")
+	value = strings.TrimRight(value, "\r")
+	if value == "" {
+		return "", fmt.Errorf("secret command %q printed nothing", command)
+	}
+	return value, nil
+}
+
+// commandSecret runs a keyring tool and returns its output without the
+// trailing newline
+func commandSecret(ctx context.Context, name string, args ...string) (string, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("%s not found on PATH", name)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return "", fmt.Errorf("%s: %w (item not found?)", name, err)
+	}
+	value := strings.TrimRight(stdout.String(), "\r\n")
+	if value == "" {
+		return "", fmt.Errorf("item not found")
+	}
+	return value, nil
+}
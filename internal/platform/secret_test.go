@@ -0,0 +1,61 @@
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSecretRef(t *testing.T) {
+	assert.True(t, IsSecretRef("$(pass show slack)"))
+	assert.True(t, IsSecretRef(" keyring:claude-notifications/slack "))
+	assert.False(t, IsSecretRef("${SLACK_TOKEN}"))
+	assert.False(t, IsSecretRef("$(unterminated"))
+	assert.False(t, IsSecretRef("xoxb-literal"))
+}
+
+func TestResolveSecret_Command(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	t.Setenv("TEST_SECRET", "from-env")
+
+	got, err := ResolveSecret("$(printf 'tok3n\\nlogin: me\\n')")
+	require.NoError(t, err)
+	assert.Equal(t, "tok3n", got)
+
+	got, err = ResolveSecret("${TEST_SECRET}")
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", got)
+
+	_, err = ResolveSecret("$(echo denied >&2; exit 3)")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "denied")
+
+	_, err = ResolveSecret("$(true)")
+	assert.ErrorContains(t, err, "printed nothing")
+}
+
+func TestResolveSecret_Keyring(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("fakes secret-tool")
+	}
+	bin := t.TempDir()
+	script := "#!/bin/sh\n[ \"$*\" = \"lookup service claude-notifications account slack\" ] && printf 'xoxb-123\\n' && exit 0\nexit 1\n"
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "secret-tool"), []byte(script), 0755))
+	t.Setenv("PATH", bin)
+
+	got, err := ResolveSecret("keyring:claude-notifications/slack")
+	require.NoError(t, err)
+	assert.Equal(t, "xoxb-123", got)
+
+	_, err = ResolveSecret("keyring:claude-notifications/missing")
+	assert.ErrorContains(t, err, "keyring claude-notifications/missing")
+
+	_, err = ResolveSecret("keyring:no-account")
+	assert.ErrorContains(t, err, "invalid keyring reference")
+}
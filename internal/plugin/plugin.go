@@ -0,0 +1,281 @@
+// Package plugin runs the executables of the plugins directory as
+// notification backends. Each one receives the notification as JSON on
+// stdin, so a bespoke backend (company chat, a serial-port LED) is a
+// script, not a fork.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/errorhandler"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/notifier"
+	"github.com/777genius/claude-notifications/internal/priority"
+)
+
+// PayloadVersion is the version of the JSON sent to plugins. It changes
+// only when a field is removed or changes meaning.
+const PayloadVersion = 1
+
+// maxOutput caps the plugin output quoted in errors
+const maxOutput = 500
+
+// validName matches plugin names usable as backend names
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// windowsExecutables are the extensions Windows runs directly
+var windowsExecutables = map[string]bool{".exe": true, ".bat": true, ".cmd": true, ".com": true}
+
+// Payload is the JSON a plugin reads from stdin
+type Payload struct {
+	Version        int    `json:"version"`                   // PayloadVersion
+	Status         string `json:"status"`                    // e.g. "task_complete"
+	Event          string `json:"event,omitempty"`           // Hook event, e.g. "Stop"
+	Title          string `json:"title"`                     // Status title after rules, e.g. "✅ Completed"
+	Message        string `json:"message"`                   // Message with the session/branch/folder prefix
+	Priority       string `json:"priority"`                  // "low", "normal" or "critical"
+	Project        string `json:"project,omitempty"`         // Project folder name
+	Repo           string `json:"repo,omitempty"`            // Git repository name
+	Branch         string `json:"branch,omitempty"`          // Git branch
+	Session        string `json:"session,omitempty"`         // Session name, e.g. "bold-cat"
+	SessionID      string `json:"session_id,omitempty"`      // Claude Code session ID
+	CWD            string `json:"cwd,omitempty"`             // Project directory (omitted with the project)
+	Tool           string `json:"tool,omitempty"`            // Tool of tool_use events
+	Elapsed        string `json:"elapsed,omitempty"`         // Time since the prompt, e.g. "4m12s"
+	ElapsedSeconds int64  `json:"elapsed_seconds,omitempty"` // Same in seconds
+	Timestamp      string `json:"timestamp"`                 // RFC 3339
+	Source         string `json:"source"`                    // Always "claude-notifications"
+}
+
+// Notifier is a backend outside the built-in ones, such as a Plugin. The
+// hook handler wraps each one in a notifier.Backend.
+type Notifier interface {
+	Name() string                   // Backend name, e.g. "plugin:led"
+	Notify(ev notifier.Event) error // Delivers ev, returning once it is delivered or failed
+}
+
+// Plugin is an executable of the plugins directory
+type Plugin struct {
+	name     string // File name without extension
+	path     string
+	timeout  time.Duration
+	statuses map[string]config.StatusInfo
+}
+
+// Name returns the backend name, "plugin:<name>"
+func (p *Plugin) Name() string {
+	return config.PluginBackendPrefix + p.name
+}
+
+// Path returns the executable's path
+func (p *Plugin) Path() string {
+	return p.path
+}
+
+// Notify runs the plugin with ev as JSON on stdin. A plugin fails by
+// exiting non-zero; what it printed is logged at debug level.
+func (p *Plugin) Notify(ev notifier.Event) error {
+	data, err := json.Marshal(p.payload(ev, time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to encode plugin payload: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, p.path)
+	cmd.Dir = filepath.Dir(p.path)
+	// Children of a killed plugin may hold its output open
+	cmd.WaitDelay = time.Second
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(),
+		"CLAUDE_NOTIFICATIONS_STATUS="+string(ev.Status),
+		"CLAUDE_NOTIFICATIONS_PLUGIN_VERSION="+fmt.Sprint(PayloadVersion),
+	)
+	output, err := cmd.CombinedOutput()
+	out := strings.TrimSpace(string(output))
+	if len(out) > maxOutput {
+		out = out[:maxOutput] + "…"
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("plugin %s timed out after %v", p.name, p.timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("plugin %s failed: %w, output: %s", p.name, err, out)
+	}
+	if out != "" {
+		logging.Debug("Plugin %s: %s", p.name, out)
+	}
+	return nil
+}
+
+// payload builds the JSON fields for ev
+func (p *Plugin) payload(ev notifier.Event, now time.Time) Payload {
+	payload := Payload{
+		Version:   PayloadVersion,
+		Status:    string(ev.Status),
+		Title:     ev.Title,
+		Message:   ev.Message,
+		Priority:  priority.Resolve(ev.Status, ev.Priority),
+		Project:   ev.Project,
+		SessionID: ev.SessionID,
+		Timestamp: now.Format(time.RFC3339),
+		Source:    "claude-notifications",
+	}
+	if payload.Title == "" {
+		payload.Title = p.statuses[string(ev.Status)].Title
+	}
+	// Privacy modes drop the project; its directory goes with it
+	if ev.Project != "" {
+		payload.CWD = ev.CWD
+	}
+	if ev.Elapsed > 0 {
+		elapsed := ev.Elapsed.Round(time.Second)
+		payload.Elapsed = elapsed.String()
+		payload.ElapsedSeconds = int64(elapsed.Seconds())
+	}
+	if c := ev.Content; c != nil {
+		payload.Event, payload.Repo, payload.Branch, payload.Tool = c.Event, c.Repo, c.Branch, c.ToolName
+		payload.Session = c.Session
+	}
+	return payload
+}
+
+// DefaultDir returns the plugins directory used when plugins.dir is empty:
+// ~/.config/claude-notifications/plugins
+func DefaultDir() (string, error) {
+	dir, err := config.GetUserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "plugins"), nil
+}
+
+// Discover returns the plugins of cfg's plugins directory, sorted by name,
+// leaving out those turned off in plugins.settings. A missing directory
+// has no plugins.
+func Discover(cfg *config.Config) ([]*Plugin, error) {
+	dir := cfg.Notifications.Plugins.Dir
+	if dir == "" {
+		var err error
+		if dir, err = DefaultDir(); err != nil {
+			return nil, err
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugins directory: %w", err)
+	}
+
+	var plugins []*Plugin
+	seen := make(map[string]string)
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		name, ok := executableName(path)
+		if !ok {
+			continue
+		}
+		if other, dup := seen[name]; dup {
+			logging.Warn("Plugin %s: ignored, %s has the same name", path, other)
+			continue
+		}
+		seen[name] = path
+		if !cfg.PluginSettings(name).IsEnabled() {
+			continue
+		}
+		plugins = append(plugins, &Plugin{name: name, path: path, timeout: cfg.PluginTimeout(), statuses: cfg.Statuses})
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].name < plugins[j].name })
+	return plugins, nil
+}
+
+// executableName returns the plugin name of the file at path, or false
+// when it is not a plugin: hidden, a directory, not executable, or
+// writable by other users, who could then run code as you
+func executableName(path string) (string, bool) {
+	base := filepath.Base(path)
+	if strings.HasPrefix(base, ".") {
+		return "", false
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", false
+	}
+	ext := filepath.Ext(base)
+	if runtime.GOOS == "windows" {
+		if !windowsExecutables[strings.ToLower(ext)] {
+			return "", false
+		}
+	} else {
+		if info.Mode().Perm()&0o111 == 0 {
+			return "", false
+		}
+		if info.Mode().Perm()&0o002 != 0 {
+			logging.Warn("Plugin %s: ignored, it is writable by other users", path)
+			return "", false
+		}
+	}
+	name := strings.TrimSuffix(base, ext)
+	if !validName.MatchString(name) {
+		logging.Warn("Plugin %s: ignored, the name must be letters, digits, '.', '_' or '-'", path)
+		return "", false
+	}
+	return name, true
+}
+
+// Host delivers to notifiers in the background
+type Host struct {
+	wg sync.WaitGroup
+}
+
+// NotifyAsyncWithResult delivers ev to n in the background and reports the
+// outcome to done (nil error = delivered); Shutdown waits for it. done may
+// be nil.
+func (h *Host) NotifyAsyncWithResult(n Notifier, ev notifier.Event, done func(err error)) {
+	h.wg.Add(1)
+	errorhandler.SafeGo(func() {
+		defer h.wg.Done()
+
+		err := n.Notify(ev)
+		if err != nil {
+			errorhandler.HandleError(err, "Plugin failed")
+		} else {
+			logging.Info("Plugin %s notified", n.Name())
+		}
+		if done != nil {
+			done(err)
+		}
+	})
+}
+
+// Shutdown waits for plugins in progress to finish (with timeout)
+func (h *Host) Shutdown(timeout time.Duration) error {
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		logging.Warn("Plugin shutdown timeout, a plugin is still running")
+		return fmt.Errorf("shutdown timeout after %v", timeout)
+	}
+}
@@ -0,0 +1,151 @@
+package plugin
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/notifier"
+)
+
+// writePlugin writes a shell script plugin to dir
+func writePlugin(t *testing.T, dir, name, script string, mode os.FileMode) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(dir, name), mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func pluginConfig(dir string) *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.Notifications.Plugins = config.PluginsConfig{Enabled: true, Dir: dir}
+	return cfg
+}
+
+func TestDiscover(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts")
+	}
+	dir := t.TempDir()
+	writePlugin(t, dir, "led.sh", "exit 0\n", 0o755)
+	writePlugin(t, dir, "chat", "exit 0\n", 0o700)
+	writePlugin(t, dir, "notes.txt", "", 0o644)
+	writePlugin(t, dir, ".hidden", "exit 0\n", 0o755)
+	writePlugin(t, dir, "shared", "exit 0\n", 0o777)
+	writePlugin(t, dir, "off", "exit 0\n", 0o755)
+	writePlugin(t, dir, "led.py", "exit 0\n", 0o755)
+	if err := os.Mkdir(filepath.Join(dir, "lib"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := pluginConfig(dir)
+	disabled := false
+	cfg.Notifications.Plugins.Settings = map[string]config.PluginSettings{"off": {Enabled: &disabled}}
+
+	plugins, err := Discover(cfg)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	var names []string
+	for _, p := range plugins {
+		names = append(names, p.Name())
+	}
+	// led.py is ignored: led.sh, read first, has the same name
+	if got, want := strings.Join(names, ","), "plugin:chat,plugin:led"; got != want {
+		t.Errorf("plugins = %s, want %s", got, want)
+	}
+
+	cfg.Notifications.Plugins.Dir = filepath.Join(dir, "missing")
+	if plugins, err := Discover(cfg); err != nil || len(plugins) != 0 {
+		t.Errorf("Discover(missing dir) = %v, %v; want none", plugins, err)
+	}
+}
+
+func TestNotify(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts")
+	}
+	dir := t.TempDir()
+	out := filepath.Join(t.TempDir(), "payload.json")
+	writePlugin(t, dir, "record", `cat > "`+out+`"; echo "$CLAUDE_NOTIFICATIONS_STATUS" >> "`+out+`.status"`+"\n", 0o755)
+
+	plugins, err := Discover(pluginConfig(dir))
+	if err != nil || len(plugins) != 1 {
+		t.Fatalf("Discover() = %v, %v", plugins, err)
+	}
+	err = plugins[0].Notify(notifier.Event{
+		Status:    analyzer.StatusTaskComplete,
+		Message:   "[bold-cat] Deployed v2",
+		SessionID: "abc",
+		CWD:       "/work/api",
+		Project:   "api",
+		Elapsed:   252 * time.Second,
+		Content:   &config.ContentData{Event: "Stop", Branch: "main", Session: "bold-cat"},
+	})
+	if err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Payload
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("payload %s: %v", data, err)
+	}
+	if got.Version != PayloadVersion || got.Title != "✅ Completed" || got.Message != "[bold-cat] Deployed v2" ||
+		got.Priority != "normal" || got.CWD != "/work/api" || got.Branch != "main" || got.Event != "Stop" ||
+		got.Elapsed != "4m12s" || got.ElapsedSeconds != 252 || got.Source != "claude-notifications" {
+		t.Errorf("payload = %+v", got)
+	}
+	if status, _ := os.ReadFile(out + ".status"); strings.TrimSpace(string(status)) != "task_complete" {
+		t.Errorf("CLAUDE_NOTIFICATIONS_STATUS = %q", status)
+	}
+}
+
+func TestNotify_Errors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts")
+	}
+	dir := t.TempDir()
+	writePlugin(t, dir, "broken", "echo 'port /dev/ttyUSB0 busy' >&2; exit 2\n", 0o755)
+	writePlugin(t, dir, "slow", "sleep 5\n", 0o755)
+
+	cfg := pluginConfig(dir)
+	cfg.Notifications.Plugins.Timeout = "200ms"
+	plugins, err := Discover(cfg)
+	if err != nil || len(plugins) != 2 {
+		t.Fatalf("Discover() = %v, %v", plugins, err)
+	}
+
+	ev := notifier.Event{Status: analyzer.StatusQuestion, Message: "Proceed?"}
+	if err := plugins[0].Notify(ev); err == nil || !strings.Contains(err.Error(), "port /dev/ttyUSB0 busy") {
+		t.Errorf("broken Notify() error = %v, want its output", err)
+	}
+	if err := plugins[1].Notify(ev); err == nil || !strings.Contains(err.Error(), "timed out after 200ms") {
+		t.Errorf("slow Notify() error = %v, want a timeout", err)
+	}
+}
+
+func TestPayload_Privacy(t *testing.T) {
+	p := &Plugin{statuses: map[string]config.StatusInfo{"question": {Title: "❓ Question"}}}
+	got := p.payload(notifier.ApplyPrivacy(notifier.Event{
+		Status:  analyzer.StatusQuestion,
+		Message: "Proceed?",
+		Project: "api",
+		CWD:     "/work/api",
+	}, config.PrivacyPresenceOnly), time.Now())
+	if got.Project != "" || got.CWD != "" || got.Message != "Claude Code needs attention" {
+		t.Errorf("presence-only payload = %+v, want no project or directory", got)
+	}
+}
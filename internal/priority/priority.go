@@ -0,0 +1,49 @@
+// Package priority is the importance of a notification, normalized across
+// backends: low, normal or critical. Each backend maps it to its own
+// notion — freedesktop urgency, macOS interruption level, ntfy and
+// Pushover priority, email importance, a Slack mention.
+package priority
+
+import "github.com/777genius/claude-notifications/internal/analyzer"
+
+// Priority levels
+const (
+	Low      = "low"
+	Normal   = "normal"
+	Critical = "critical"
+)
+
+// IsValid reports whether p is a priority level
+func IsValid(p string) bool {
+	return p == Low || p == Normal || p == Critical
+}
+
+// IsTimeSensitive returns true for statuses that should break through
+// Focus Mode and do-not-disturb
+func IsTimeSensitive(status analyzer.Status) bool {
+	switch status {
+	case analyzer.StatusAPIError, analyzer.StatusAPIErrorOverloaded, analyzer.StatusSessionLimitReached,
+		analyzer.StatusBudgetExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// ForStatus returns the priority of a status: time-sensitive statuses are
+// critical so they persist and bypass do-not-disturb, the rest normal
+func ForStatus(status analyzer.Status) string {
+	if IsTimeSensitive(status) {
+		return Critical
+	}
+	return Normal
+}
+
+// Resolve returns the priority of a notification: p, set by rules, or the
+// status default when p is empty
+func Resolve(status analyzer.Status, p string) string {
+	if p != "" {
+		return p
+	}
+	return ForStatus(status)
+}
@@ -0,0 +1,66 @@
+package priority
+
+import (
+	"testing"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+)
+
+func TestIsTimeSensitive(t *testing.T) {
+	tests := []struct {
+		status   analyzer.Status
+		expected bool
+	}{
+		{analyzer.StatusAPIError, true},
+		{analyzer.StatusAPIErrorOverloaded, true},
+		{analyzer.StatusSessionLimitReached, true},
+		{analyzer.StatusTaskComplete, false},
+		{analyzer.StatusReviewComplete, false},
+		{analyzer.StatusQuestion, false},
+		{analyzer.StatusPlanReady, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			if got := IsTimeSensitive(tt.status); got != tt.expected {
+				t.Errorf("IsTimeSensitive(%s) = %v, want %v", tt.status, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		status   analyzer.Status
+		p        string
+		expected string
+	}{
+		{analyzer.StatusAPIError, "", Critical},
+		{analyzer.StatusSessionLimitReached, "", Critical},
+		{analyzer.StatusTaskComplete, "", Normal},
+		{analyzer.StatusQuestion, "", Normal},
+		{analyzer.StatusTaskComplete, Critical, Critical},
+		{analyzer.StatusAPIError, Low, Low},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status)+"/"+tt.p, func(t *testing.T) {
+			if got := Resolve(tt.status, tt.p); got != tt.expected {
+				t.Errorf("Resolve(%s, %q) = %q, want %q", tt.status, tt.p, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsValid(t *testing.T) {
+	for _, p := range []string{Low, Normal, Critical} {
+		if !IsValid(p) {
+			t.Errorf("IsValid(%q) = false", p)
+		}
+	}
+	for _, p := range []string{"", "high", "Critical"} {
+		if IsValid(p) {
+			t.Errorf("IsValid(%q) = true", p)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Package redact scrubs secrets from notification text before it leaves
+// the machine. Messages quote tool output and Claude's replies, which can
+// hold API keys, tokens and passwords; webhooks, email and MQTT would
+// carry them to third-party servers.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultReplacement replaces each secret found
+const DefaultReplacement = "[REDACTED]"
+
+// Builtin are the built-in patterns by name. A pattern with a capture
+// group only replaces the group, keeping e.g. the "Bearer " before a token.
+var Builtin = map[string]string{
+	"anthropic":    `sk-ant-[A-Za-z0-9_-]{20,}`,
+	"openai":       `sk-(?:proj-|svcacct-)?[A-Za-z0-9_-]{32,}`,
+	"github":       `(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`,
+	"gitlab":       `glpat-[A-Za-z0-9_-]{20,}`,
+	"aws":          `(?:AKIA|ASIA)[0-9A-Z]{16}`,
+	"google":       `AIza[0-9A-Za-z_-]{35}`,
+	"slack":        `xox[abposr]-[A-Za-z0-9-]{10,}`,
+	"stripe":       `(?:sk|rk)_(?:live|test)_[A-Za-z0-9]{16,}`,
+	"jwt":          `eyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`,
+	"bearer":       `(?i)\bbearer\s+([A-Za-z0-9._~+/=-]{12,})`,
+	"private-key":  `-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?(?:-----END [A-Z ]*PRIVATE KEY-----|$)`,
+	"url-password": `\b[a-z][a-z0-9+.-]*://[^\s:/@]+:([^\s@/]+)@`,
+	"assignment":   `(?i)\b(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|client[_-]?secret)\b["']?\s*[:=]\s*["']?([^\s"',;]{6,})`,
+}
+
+// Options choose what a Redactor replaces
+type Options struct {
+	Patterns    []string // Regular expressions added to the built-in ones
+	Disable     []string // Names of built-in patterns not to use
+	Replacement string   // Replaces each secret ("" = DefaultReplacement)
+	Home        string   // Home directory to shorten to ~ in paths ("" = kept)
+}
+
+// Redactor replaces secrets in text
+type Redactor struct {
+	patterns    []*regexp.Regexp
+	replacement string
+	home        string
+}
+
+// New compiles the built-in patterns not disabled and the added ones
+func New(opts Options) (*Redactor, error) {
+	disabled := make(map[string]bool, len(opts.Disable))
+	for _, name := range opts.Disable {
+		if _, ok := Builtin[name]; !ok {
+			return nil, fmt.Errorf("unknown built-in pattern %q (one of: %s)", name, strings.Join(BuiltinNames(), ", "))
+		}
+		disabled[name] = true
+	}
+
+	r := &Redactor{replacement: opts.Replacement, home: strings.TrimRight(opts.Home, "/")}
+	if r.replacement == "" {
+		r.replacement = DefaultReplacement
+	}
+	for _, name := range BuiltinNames() {
+		if !disabled[name] {
+			r.patterns = append(r.patterns, regexp.MustCompile(Builtin[name]))
+		}
+	}
+	for _, pattern := range opts.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// BuiltinNames returns the names of the built-in patterns, sorted
+func BuiltinNames() []string {
+	names := make([]string, 0, len(Builtin))
+	for name := range Builtin {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// String replaces the secrets in s
+func (r *Redactor) String(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	for _, re := range r.patterns {
+		s = r.replace(re, s)
+	}
+	if r.home != "" && r.home != "/" {
+		s = strings.ReplaceAll(s, r.home+"/", "~/")
+	}
+	return s
+}
+
+// replace replaces the matches of re in s, or only their first group
+func (r *Redactor) replace(re *regexp.Regexp, s string) string {
+	if re.NumSubexp() == 0 {
+		return re.ReplaceAllLiteralString(s, r.replacement)
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(s, -1) {
+		start, end := m[2], m[3]
+		if start < 0 {
+			start, end = m[0], m[1]
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(r.replacement)
+		last = end
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
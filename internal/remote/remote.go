@@ -0,0 +1,209 @@
+// Package remote forwards notifications from a remote (SSH) session or a
+// container to a listener on the local machine.
+//
+// The hook on the remote host connects to a TCP address that is reverse-forwarded
+// over SSH (ssh -R 9876:127.0.0.1:9876 host), or from a container to the
+// host's address or a Unix socket mounted into it, and writes one JSON
+// request per connection. The local "claude-notifications listen" process
+// shows it as a desktop notification and replies with a JSON response.
+package remote
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProtocolVersion is the current forwarding protocol version
+const ProtocolVersion = 1
+
+// unixPrefix marks an address as the path of a Unix socket, e.g.
+// "unix:/run/claude-notifications.sock"
+const unixPrefix = "unix:"
+
+const (
+	// dialTimeout bounds connecting to the forwarded port
+	dialTimeout = 2 * time.Second
+	// ioTimeout bounds a full request/response exchange
+	ioTimeout = 5 * time.Second
+	// maxRequestSize limits a single request line
+	maxRequestSize = 64 * 1024
+)
+
+// Request is a forwarded notification
+type Request struct {
+	Version   int    `json:"version"`
+	Token     string `json:"token,omitempty"`
+	Status    string `json:"status"`
+	Message   string `json:"message"` // "[session|branch folder] text", as passed to SendDesktop
+	SessionID string `json:"session_id,omitempty"`
+	Source    string `json:"source,omitempty"` // Container or machine the notification comes from, e.g. a dev container name
+}
+
+// Response is the listener's reply
+type Response struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Handler displays a forwarded notification
+type Handler func(req *Request) error
+
+// Network splits address into the network and address to dial or listen
+// on: "unix:<path>" is a Unix socket, anything else host:port over TCP
+func Network(address string) (network, addr string) {
+	if path, ok := strings.CutPrefix(address, unixPrefix); ok {
+		return "unix", path
+	}
+	return "tcp", address
+}
+
+// Send forwards a notification to the listener at address.
+func Send(address string, req *Request) error {
+	network, addr := Network(address)
+	conn, err := net.DialTimeout(network, addr, dialTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to notification listener at %s: %w", address, err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(ioTimeout)); err != nil {
+		return fmt.Errorf("failed to set deadline: %w", err)
+	}
+
+	req.Version = ProtocolVersion
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&resp); err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if !resp.Success {
+		return fmt.Errorf("listener error: %s", resp.Error)
+	}
+	return nil
+}
+
+// Server accepts forwarded notifications
+type Server struct {
+	listener net.Listener
+	token    string
+	handler  Handler
+	wg       sync.WaitGroup
+}
+
+// Listen starts listening on address. Requests must carry token unless it
+// is empty, which only a Unix socket allows: every local user, and anyone
+// who can reach a non-loopback address, can connect to a TCP port. A Unix
+// socket left behind by a listener that crashed is replaced.
+func Listen(address, token string, handler Handler) (*Server, error) {
+	network, addr := Network(address)
+	if network == "tcp" && token == "" {
+		return nil, fmt.Errorf("a token is required to listen on %s: any user who can reach a TCP port could send notifications", address)
+	}
+	if network == "unix" {
+		if info, err := os.Lstat(addr); err == nil && info.Mode()&os.ModeSocket != 0 {
+			_ = os.Remove(addr)
+		}
+	}
+	ln, err := net.Listen(network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+	return &Server{listener: ln, token: token, handler: handler}, nil
+}
+
+// Addr returns the address the server is listening on
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Serve accepts connections until Close is called
+func (s *Server) Serve() error {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("accept failed: %w", err)
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handleConnection(conn)
+		}()
+	}
+}
+
+// Close stops accepting connections and waits for in-flight requests
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+// handleConnection processes a single request
+func (s *Server) handleConnection(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(ioTimeout))
+
+	resp := Response{Success: true}
+	if err := s.process(conn); err != nil {
+		resp = Response{Success: false, Error: err.Error()}
+	}
+	_ = json.NewEncoder(conn).Encode(resp)
+}
+
+// process decodes, authenticates and dispatches a request
+func (s *Server) process(conn net.Conn) error {
+	reader := bufio.NewReaderSize(conn, 4096)
+	line, err := readLine(reader, maxRequestSize)
+	if err != nil {
+		return fmt.Errorf("invalid request: %w", err)
+	}
+
+	var req Request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return fmt.Errorf("invalid request: %w", err)
+	}
+	if req.Version != ProtocolVersion {
+		return fmt.Errorf("unsupported protocol version %d (want %d)", req.Version, ProtocolVersion)
+	}
+	if s.token != "" && subtle.ConstantTimeCompare([]byte(req.Token), []byte(s.token)) != 1 {
+		return fmt.Errorf("invalid token")
+	}
+	if req.Status == "" {
+		return fmt.Errorf("missing status")
+	}
+
+	return s.handler(&req)
+}
+
+// readLine reads a newline-terminated line of at most limit bytes
+func readLine(r *bufio.Reader, limit int) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, isPrefix, err := r.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, chunk...)
+		if len(line) > limit {
+			return nil, fmt.Errorf("request exceeds %d bytes", limit)
+		}
+		if !isPrefix {
+			return line, nil
+		}
+	}
+}
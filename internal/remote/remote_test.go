@@ -0,0 +1,221 @@
+package remote
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testToken is the token of the listeners started by startServer
+const testToken = "test-token"
+
+// startServer starts a listener on a random port and returns it with the received requests.
+func startServer(t *testing.T, handlerErr error) (*Server, *[]Request, *sync.Mutex) {
+	t.Helper()
+
+	var (
+		mu       sync.Mutex
+		received []Request
+	)
+	srv, err := Listen("127.0.0.1:0", testToken, func(req *Request) error {
+		mu.Lock()
+		received = append(received, *req)
+		mu.Unlock()
+		return handlerErr
+	})
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	go srv.Serve()
+	t.Cleanup(func() { srv.Close() })
+
+	return srv, &received, &mu
+}
+
+func TestSend_Delivered(t *testing.T) {
+	srv, received, mu := startServer(t, nil)
+
+	err := Send(srv.Addr().String(), &Request{
+		Token:     testToken,
+		Status:    "task_complete",
+		Message:   "[peak main app] Done",
+		SessionID: "abc",
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(*received) != 1 {
+		t.Fatalf("received %d requests, want 1", len(*received))
+	}
+	got := (*received)[0]
+	if got.Status != "task_complete" || got.Message != "[peak main app] Done" || got.SessionID != "abc" {
+		t.Errorf("received %+v", got)
+	}
+	if got.Version != ProtocolVersion {
+		t.Errorf("Version = %d, want %d", got.Version, ProtocolVersion)
+	}
+}
+
+func TestSend_Token(t *testing.T) {
+	srv, received, mu := startServer(t, nil)
+
+	if err := Send(srv.Addr().String(), &Request{Status: "question", Token: "wrong"}); err == nil || !strings.Contains(err.Error(), "invalid token") {
+		t.Errorf("Send() with wrong token error = %v, want invalid token", err)
+	}
+	if err := Send(srv.Addr().String(), &Request{Status: "question", Token: testToken}); err != nil {
+		t.Errorf("Send() with correct token error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(*received) != 1 {
+		t.Errorf("handler called %d times, want 1", len(*received))
+	}
+}
+
+func TestListen_RequiresTokenOnTCP(t *testing.T) {
+	for _, address := range []string{"127.0.0.1:0", "0.0.0.0:0"} {
+		if srv, err := Listen(address, "", func(*Request) error { return nil }); err == nil {
+			srv.Close()
+			t.Errorf("Listen(%s) without a token should fail", address)
+		}
+	}
+}
+
+func TestSend_HandlerError(t *testing.T) {
+	srv, _, _ := startServer(t, fmt.Errorf("unknown status: bogus"))
+
+	err := Send(srv.Addr().String(), &Request{Token: testToken, Status: "bogus"})
+	if err == nil || !strings.Contains(err.Error(), "unknown status") {
+		t.Errorf("Send() error = %v, want handler error", err)
+	}
+}
+
+func TestSend_MissingStatus(t *testing.T) {
+	srv, _, _ := startServer(t, nil)
+
+	if err := Send(srv.Addr().String(), &Request{Token: testToken, Message: "x"}); err == nil {
+		t.Error("Send() without status should fail")
+	}
+}
+
+func TestSend_NoListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	if err := Send(addr, &Request{Status: "task_complete"}); err == nil {
+		t.Error("Send() should fail when nothing is listening")
+	}
+}
+
+func TestServer_RejectsWrongVersion(t *testing.T) {
+	srv, _, _ := startServer(t, nil)
+
+	conn, err := net.Dial("tcp", srv.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	fmt.Fprintln(conn, `{"version":99,"status":"task_complete"}`)
+	buf := make([]byte, 256)
+	n, _ := conn.Read(buf)
+	if !strings.Contains(string(buf[:n]), "unsupported protocol version") {
+		t.Errorf("response = %s, want version error", buf[:n])
+	}
+}
+
+func TestServer_RejectsOversizedRequest(t *testing.T) {
+	srv, _, _ := startServer(t, nil)
+
+	conn, err := net.Dial("tcp", srv.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	fmt.Fprintln(conn, `{"version":1,"status":"task_complete","message":"`+strings.Repeat("x", maxRequestSize)+`"}`)
+	buf := make([]byte, 256)
+	n, _ := conn.Read(buf)
+	if !strings.Contains(string(buf[:n]), "exceeds") {
+		t.Errorf("response = %s, want size error", buf[:n])
+	}
+}
+
+func TestServer_CloseStopsServe(t *testing.T) {
+	srv, err := Listen("127.0.0.1:0", testToken, func(*Request) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve() }()
+
+	if err := srv.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Serve() after Close = %v, want nil", err)
+	}
+}
+
+func TestNetwork(t *testing.T) {
+	if network, addr := Network("unix:/run/claude.sock"); network != "unix" || addr != "/run/claude.sock" {
+		t.Errorf("Network(unix) = %s %s", network, addr)
+	}
+	if network, addr := Network("host.docker.internal:9876"); network != "tcp" || addr != "host.docker.internal:9876" {
+		t.Errorf("Network(tcp) = %s %s", network, addr)
+	}
+}
+
+func TestSend_UnixSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix sockets")
+	}
+	// Socket paths are limited to about 100 bytes, too short for t.TempDir on macOS
+	dir, err := os.MkdirTemp("", "remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	address := "unix:" + filepath.Join(dir, "listen.sock")
+
+	received := make(chan Request, 2)
+	listen := func() *Server {
+		srv, err := Listen(address, "", func(req *Request) error {
+			received <- *req
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Listen() error = %v", err)
+		}
+		go srv.Serve()
+		return srv
+	}
+
+	// A socket left behind by a listener that did not close is replaced
+	srv := listen()
+	srv.listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	srv.Close()
+	srv = listen()
+	defer srv.Close()
+
+	if err := Send(address, &Request{Status: "question", Source: "api-devcontainer"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if got := <-received; got.Source != "api-devcontainer" {
+		t.Errorf("received %+v", got)
+	}
+}
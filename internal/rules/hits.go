@@ -0,0 +1,102 @@
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const hitsFileName = "rule-hits.json"
+
+// Hit counts how often a rule matched and what it matched last
+type Hit struct {
+	Count       int       `json:"count"`
+	Last        time.Time `json:"last"`
+	LastStatus  string    `json:"last_status,omitempty"`
+	LastProject string    `json:"last_project,omitempty"`
+}
+
+// HitStore counts the matches of each rule in a file in a directory, so
+// what rules did across hooks can be looked at later
+type HitStore struct {
+	dir string
+}
+
+// NewHitStore creates a store keeping its file in dir
+func NewHitStore(dir string) *HitStore {
+	return &HitStore{dir: dir}
+}
+
+// Path returns the file holding the rule hits
+func (s *HitStore) Path() string {
+	return filepath.Join(s.dir, hitsFileName)
+}
+
+// Record counts a match of each named rule for an event
+func (s *HitStore) Record(names []string, status, project string, now time.Time) error {
+	if len(names) == 0 {
+		return nil
+	}
+	hits, err := s.Hits()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		h := hits[name]
+		h.Count++
+		h.Last, h.LastStatus, h.LastProject = now, status, project
+		hits[name] = h
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create rule hits directory: %w", err)
+	}
+	data, err := json.MarshalIndent(hits, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize rule hits: %w", err)
+	}
+	tmp := fmt.Sprintf("%s.%d.tmp", s.Path(), os.Getpid())
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write rule hits: %w", err)
+	}
+	if err := os.Rename(tmp, s.Path()); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write rule hits: %w", err)
+	}
+	return nil
+}
+
+// Hits returns the matches counted per rule name
+func (s *HitStore) Hits() (map[string]Hit, error) {
+	hits := map[string]Hit{}
+	data, err := os.ReadFile(s.Path())
+	if errors.Is(err, os.ErrNotExist) {
+		return hits, nil
+	}
+	if err != nil {
+		return hits, fmt.Errorf("failed to read rule hits: %w", err)
+	}
+	if err := json.Unmarshal(data, &hits); err != nil {
+		return map[string]Hit{}, fmt.Errorf("failed to parse rule hits: %w", err)
+	}
+	return hits, nil
+}
+
+// HitNames returns the rules in hits, most recently matched first
+func HitNames(hits map[string]Hit) []string {
+	names := make([]string, 0, len(hits))
+	for name := range hits {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := hits[names[i]], hits[names[j]]
+		if !a.Last.Equal(b.Last) {
+			return a.Last.After(b.Last)
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
@@ -0,0 +1,186 @@
+// Package rules evaluates notifications.rules against notification events.
+// Match conditions select events; actions suppress them or change their
+// urgency, sound, title and target backends. A HitStore counts how often
+// each rule matched, for the dashboard.
+package rules
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+// Event describes a notification before rules are applied
+type Event struct {
+	HookEvent   string // Hook event that produced it: "Stop", "Notification", ...
+	Status      string
+	Title       string        // Status title from config
+	ProjectPath string        // Project directory (may be empty)
+	Message     string        // Notification message without the session prefix
+	Elapsed     time.Duration // Time since the last user prompt (0 = unknown)
+	Time        time.Time     // When the event happened (local time is used)
+}
+
+// Result is the combined effect of all matching rules
+type Result struct {
+	Suppress bool
+	Title    string   // Rewritten title ("" = unchanged)
+	Sound    string   // Sound override ("" = unchanged, "none" = silent)
+	Urgency  string   // Urgency override ("" = by status)
+	Backends []string // Allowed backends (nil = all)
+	Matched  []string // Names of the rules that matched
+}
+
+// titleData is the data available to title templates
+type titleData struct {
+	Title   string
+	Status  string
+	Event   string
+	Project string
+}
+
+// compiledRule is a config rule with its regex, time window and template parsed
+type compiledRule struct {
+	rule       config.Rule
+	name       string
+	message    *regexp.Regexp
+	window     *config.TimeWindow
+	minElapsed time.Duration
+	maxElapsed time.Duration
+	title      *template.Template
+}
+
+// Engine evaluates a list of rules
+type Engine struct {
+	rules []compiledRule
+}
+
+// New compiles the configured rules. Rules are normally validated by
+// config.Validate already, so errors here indicate an unvalidated config.
+func New(rules []config.Rule) (*Engine, error) {
+	e := &Engine{}
+	for i, r := range rules {
+		c := compiledRule{rule: r, name: r.Name}
+		if c.name == "" {
+			c.name = fmt.Sprintf("rules[%d]", i)
+		}
+
+		var err error
+		if r.Match.Message != "" {
+			if c.message, err = regexp.Compile(r.Match.Message); err != nil {
+				return nil, fmt.Errorf("%s: invalid message regex: %w", c.name, err)
+			}
+		}
+		if r.Match.Time != "" {
+			window, err := config.ParseTimeWindow(r.Match.Time)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", c.name, err)
+			}
+			c.window = &window
+		}
+		if r.Match.MinElapsed != "" {
+			if c.minElapsed, err = time.ParseDuration(r.Match.MinElapsed); err != nil {
+				return nil, fmt.Errorf("%s: invalid minElapsed: %w", c.name, err)
+			}
+		}
+		if r.Match.MaxElapsed != "" {
+			if c.maxElapsed, err = time.ParseDuration(r.Match.MaxElapsed); err != nil {
+				return nil, fmt.Errorf("%s: invalid maxElapsed: %w", c.name, err)
+			}
+		}
+		if r.Actions.Title != "" {
+			if c.title, err = template.New(c.name).Option("missingkey=error").Parse(r.Actions.Title); err != nil {
+				return nil, fmt.Errorf("%s: invalid title template: %w", c.name, err)
+			}
+		}
+		e.rules = append(e.rules, c)
+	}
+	return e, nil
+}
+
+// UsesElapsed returns true if any rule has a session duration condition,
+// so callers only read the transcript when needed
+func (e *Engine) UsesElapsed() bool {
+	for _, c := range e.rules {
+		if c.rule.Match.MinElapsed != "" || c.rule.Match.MaxElapsed != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate applies every matching rule in order. Later rules override the
+// actions of earlier ones; the first suppressing rule ends evaluation.
+func (e *Engine) Evaluate(ev Event) Result {
+	var res Result
+	title := ev.Title
+	for _, c := range e.rules {
+		if !c.matches(ev) {
+			continue
+		}
+		res.Matched = append(res.Matched, c.name)
+
+		a := c.rule.Actions
+		if a.Suppress {
+			res.Suppress = true
+			return res
+		}
+		if a.Urgency != "" {
+			res.Urgency = a.Urgency
+		}
+		if a.Sound != "" {
+			res.Sound = a.Sound
+		}
+		if len(a.Backends) > 0 {
+			res.Backends = a.Backends
+		}
+		if c.title != nil {
+			var b strings.Builder
+			data := titleData{Title: title, Status: ev.Status, Event: ev.HookEvent, Project: projectName(ev.ProjectPath)}
+			if err := c.title.Execute(&b, data); err == nil {
+				title = b.String()
+				res.Title = title
+			}
+		}
+	}
+	return res
+}
+
+// matches reports whether the event satisfies every condition of the rule
+func (c *compiledRule) matches(ev Event) bool {
+	m := c.rule.Match
+	if len(m.Events) > 0 && !slices.Contains(m.Events, ev.HookEvent) {
+		return false
+	}
+	route := config.RouteConfig{Statuses: m.Statuses, Projects: m.Projects}
+	if !route.Matches(ev.Status, ev.ProjectPath, 0) {
+		return false
+	}
+	if c.message != nil && !c.message.MatchString(ev.Message) {
+		return false
+	}
+	if c.window != nil && !c.window.Contains(ev.Time) {
+		return false
+	}
+	if m.MinElapsed != "" && ev.Elapsed < c.minElapsed {
+		return false
+	}
+	if m.MaxElapsed != "" && (ev.Elapsed == 0 || ev.Elapsed > c.maxElapsed) {
+		return false
+	}
+	return true
+}
+
+// projectName returns the folder name of a project path
+func projectName(projectPath string) string {
+	if projectPath == "" {
+		return ""
+	}
+	return filepath.Base(projectPath)
+}
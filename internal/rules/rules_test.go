@@ -0,0 +1,165 @@
+package rules
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+func mustEngine(t *testing.T, rules ...config.Rule) *Engine {
+	t.Helper()
+	e, err := New(rules)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	return e
+}
+
+func at(hour, minute int) time.Time {
+	return time.Date(2026, 3, 14, hour, minute, 0, 0, time.Local)
+}
+
+func TestEvaluate_Conditions(t *testing.T) {
+	tests := []struct {
+		name  string
+		match config.RuleMatch
+		ev    Event
+		want  bool
+	}{
+		{"empty match", config.RuleMatch{}, Event{Status: "task_complete"}, true},
+		{"event", config.RuleMatch{Events: []string{"Notification", "PreToolUse"}}, Event{HookEvent: "Notification"}, true},
+		{"event mismatch", config.RuleMatch{Events: []string{"SubagentStop"}}, Event{HookEvent: "Stop"}, false},
+		{"status", config.RuleMatch{Statuses: []string{"question"}}, Event{Status: "question"}, true},
+		{"status mismatch", config.RuleMatch{Statuses: []string{"question"}}, Event{Status: "task_complete"}, false},
+		{"project glob", config.RuleMatch{Projects: []string{"/work/client-*"}}, Event{ProjectPath: "/work/client-acme"}, true},
+		{"project mismatch", config.RuleMatch{Projects: []string{"client-*"}}, Event{ProjectPath: "/work/internal"}, false},
+		{"message regex", config.RuleMatch{Message: `(?i)tests? (failed|failing)`}, Event{Message: "3 Tests failed in auth"}, true},
+		{"message mismatch", config.RuleMatch{Message: `deploy`}, Event{Message: "Refactored parser"}, false},
+		{"time window overnight", config.RuleMatch{Time: "22:00-08:00"}, Event{Time: at(23, 30)}, true},
+		{"time window early morning", config.RuleMatch{Time: "22:00-08:00"}, Event{Time: at(7, 59)}, true},
+		{"time window outside", config.RuleMatch{Time: "22:00-08:00"}, Event{Time: at(8, 0)}, false},
+		{"min elapsed", config.RuleMatch{MinElapsed: "10m"}, Event{Elapsed: 15 * time.Minute}, true},
+		{"min elapsed short", config.RuleMatch{MinElapsed: "10m"}, Event{Elapsed: 5 * time.Minute}, false},
+		{"max elapsed", config.RuleMatch{MaxElapsed: "30s"}, Event{Elapsed: 10 * time.Second}, true},
+		{"max elapsed unknown", config.RuleMatch{MaxElapsed: "30s"}, Event{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := mustEngine(t, config.Rule{Name: "r", Match: tt.match, Actions: config.RuleActions{Urgency: "low"}})
+			res := e.Evaluate(tt.ev)
+			if got := len(res.Matched) == 1; got != tt.want {
+				t.Errorf("matched = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluate_ActionsMergeInOrder(t *testing.T) {
+	e := mustEngine(t,
+		config.Rule{
+			Name:    "client projects",
+			Match:   config.RuleMatch{Projects: []string{"client-*"}},
+			Actions: config.RuleActions{Urgency: "critical", Sound: "/sounds/ping.mp3", Title: "{{.Title}} · {{.Project}}"},
+		},
+		config.Rule{
+			Name:    "questions to phone",
+			Match:   config.RuleMatch{Statuses: []string{"question"}},
+			Actions: config.RuleActions{Backends: []string{"desktop", "phone"}, Title: "❗ {{.Title}}"},
+		},
+		config.Rule{
+			Actions: config.RuleActions{Urgency: "normal"},
+			Match:   config.RuleMatch{Statuses: []string{"plan_ready"}},
+		},
+	)
+
+	res := e.Evaluate(Event{Status: "question", Title: "❓ Question", ProjectPath: "/src/client-acme"})
+
+	if res.Suppress {
+		t.Fatal("should not suppress")
+	}
+	if want := []string{"client projects", "questions to phone"}; !reflect.DeepEqual(res.Matched, want) {
+		t.Errorf("Matched = %v, want %v", res.Matched, want)
+	}
+	if res.Urgency != "critical" || res.Sound != "/sounds/ping.mp3" {
+		t.Errorf("urgency/sound = %q/%q, want critical and ping", res.Urgency, res.Sound)
+	}
+	if want := []string{"desktop", "phone"}; !reflect.DeepEqual(res.Backends, want) {
+		t.Errorf("Backends = %v, want %v", res.Backends, want)
+	}
+	// Title rewrites chain: the second rule sees the first rule's title
+	if want := "❗ ❓ Question · client-acme"; res.Title != want {
+		t.Errorf("Title = %q, want %q", res.Title, want)
+	}
+}
+
+func TestEvaluate_SuppressStopsEvaluation(t *testing.T) {
+	e := mustEngine(t,
+		config.Rule{Match: config.RuleMatch{Message: "^Done$"}, Actions: config.RuleActions{Suppress: true}},
+		config.Rule{Name: "later", Actions: config.RuleActions{Urgency: "critical"}},
+	)
+
+	res := e.Evaluate(Event{Status: "task_complete", Message: "Done"})
+	if !res.Suppress {
+		t.Fatal("expected suppress")
+	}
+	if want := []string{"rules[0]"}; !reflect.DeepEqual(res.Matched, want) {
+		t.Errorf("Matched = %v, want %v (unnamed rules use their index)", res.Matched, want)
+	}
+	if res.Urgency != "" {
+		t.Errorf("rules after a suppressing rule should not apply, got urgency %q", res.Urgency)
+	}
+
+	if res := e.Evaluate(Event{Status: "task_complete", Message: "Done with auth"}); res.Suppress {
+		t.Error("non-matching message should not be suppressed")
+	}
+}
+
+func TestNew_InvalidRule(t *testing.T) {
+	_, err := New([]config.Rule{{Match: config.RuleMatch{Message: "("}, Actions: config.RuleActions{Suppress: true}}})
+	if err == nil {
+		t.Fatal("expected error for invalid regex")
+	}
+}
+
+func TestUsesElapsed(t *testing.T) {
+	if mustEngine(t, config.Rule{Actions: config.RuleActions{Suppress: true}}).UsesElapsed() {
+		t.Error("rules without duration conditions should not need elapsed time")
+	}
+	if !mustEngine(t, config.Rule{Match: config.RuleMatch{MaxElapsed: "1m"}, Actions: config.RuleActions{Suppress: true}}).UsesElapsed() {
+		t.Error("maxElapsed rule should need elapsed time")
+	}
+}
+
+func TestHitStore(t *testing.T) {
+	s := NewHitStore(t.TempDir())
+	now := at(12, 0)
+
+	hits, err := s.Hits()
+	if err != nil || len(hits) != 0 {
+		t.Fatalf("Hits() = %v, %v, want none", hits, err)
+	}
+	if err := s.Record([]string{"quiet-docs", "urgent-api"}, "task_complete", "docs", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Record([]string{"urgent-api"}, "question", "api", now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Record(nil, "question", "api", now); err != nil {
+		t.Fatal(err)
+	}
+
+	hits, err = s.Hits()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Hit{Count: 2, Last: now.Add(time.Minute), LastStatus: "question", LastProject: "api"}
+	if got := hits["urgent-api"]; !got.Last.Equal(want.Last) || got.Count != want.Count || got.LastStatus != want.LastStatus || got.LastProject != want.LastProject {
+		t.Errorf("hits[urgent-api] = %+v, want %+v", got, want)
+	}
+	if got := HitNames(hits); !reflect.DeepEqual(got, []string{"urgent-api", "quiet-docs"}) {
+		t.Errorf("HitNames() = %v, want most recent first", got)
+	}
+}
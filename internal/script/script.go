@@ -0,0 +1,208 @@
+// Package script runs the user's Starlark script on notifications before
+// they are dispatched. The script's transform function reads an event and
+// can change its title, message, priority, sound and backends, or suppress
+// it: programmable rules for what notifications.rules cannot express.
+package script
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/logging"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// DefaultMaxSteps bounds one run of the script, so a runaway loop cannot
+// hang a hook
+const DefaultMaxSteps = 1000000
+
+// transformName is the function a script must define
+const transformName = "transform"
+
+// validPriorities are the priorities a script may set
+var validPriorities = map[string]bool{"": true, "low": true, "normal": true, "critical": true}
+
+// Event is a notification as a script sees it. Title, Message, Priority,
+// Sound and Backends may be changed; the other fields are read-only.
+type Event struct {
+	Status    string // e.g. "task_complete"
+	HookEvent string // e.g. "Stop"
+	Title     string // Status title after rules
+	Message   string // Message without the session prefix
+	Priority  string // "low", "normal" or "critical" ("" = by status)
+	Sound     string // Desktop sound ("" = status sound, "none" = silent)
+	Backends  []string
+	Project   string
+	Repo      string
+	Branch    string
+	Session   string // Session name, e.g. "bold-cat"
+	SessionID string
+	CWD       string
+	Tool      string
+	Elapsed   time.Duration
+	Time      time.Time // When the event happened (local time)
+}
+
+// Script is a loaded script
+type Script struct {
+	path      string
+	transform starlark.Callable
+	maxSteps  uint64
+}
+
+// Load runs the top level of the script at path and returns it. The
+// script must define transform(event). maxSteps <= 0 uses DefaultMaxSteps.
+func Load(path string, maxSteps int) (*Script, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read script: %w", err)
+	}
+	if maxSteps <= 0 {
+		maxSteps = DefaultMaxSteps
+	}
+	s := &Script{path: path, maxSteps: uint64(maxSteps)}
+
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, s.thread(), path, src, nil)
+	if err != nil {
+		return nil, describe(err)
+	}
+	fn, ok := globals[transformName].(starlark.Callable)
+	if !ok {
+		return nil, fmt.Errorf("%s: script must define %s(event)", path, transformName)
+	}
+	s.transform = fn
+	return s, nil
+}
+
+// Run calls transform with ev and returns the event it leaves. keep is
+// false when the script suppresses the notification by returning False.
+func (s *Script) Run(ev Event) (out Event, keep bool, err error) {
+	event := toDict(ev)
+	result, err := starlark.Call(s.thread(), s.transform, starlark.Tuple{event}, nil)
+	if err != nil {
+		return ev, true, describe(err)
+	}
+
+	switch r := result.(type) {
+	case starlark.NoneType:
+	case starlark.Bool:
+		if !r {
+			return ev, false, nil
+		}
+	case *starlark.Dict:
+		event = r
+	default:
+		return ev, true, fmt.Errorf("%s must return None, False or the event, got %s", transformName, result.Type())
+	}
+
+	out, err = fromDict(ev, event)
+	if err != nil {
+		return ev, true, fmt.Errorf("%s: %w", transformName, err)
+	}
+	return out, true, nil
+}
+
+// thread creates a thread with the step limit whose print logs at debug level
+func (s *Script) thread() *starlark.Thread {
+	thread := &starlark.Thread{
+		Name: s.path,
+		Print: func(_ *starlark.Thread, msg string) {
+			logging.Debug("Script: %s", msg)
+		},
+	}
+	thread.SetMaxExecutionSteps(s.maxSteps)
+	return thread
+}
+
+// describe adds the Starlark backtrace to an evaluation error
+func describe(err error) error {
+	if evalErr, ok := err.(*starlark.EvalError); ok {
+		return fmt.Errorf("%s", evalErr.Backtrace())
+	}
+	return err
+}
+
+// toDict returns the event as the dict passed to transform
+func toDict(ev Event) *starlark.Dict {
+	backends := make([]starlark.Value, len(ev.Backends))
+	for i, b := range ev.Backends {
+		backends[i] = starlark.String(b)
+	}
+	d := starlark.NewDict(20)
+	for _, kv := range []struct {
+		key   string
+		value starlark.Value
+	}{
+		{"status", starlark.String(ev.Status)},
+		{"event", starlark.String(ev.HookEvent)},
+		{"title", starlark.String(ev.Title)},
+		{"message", starlark.String(ev.Message)},
+		{"priority", starlark.String(ev.Priority)},
+		{"sound", starlark.String(ev.Sound)},
+		{"backends", starlark.NewList(backends)},
+		{"project", starlark.String(ev.Project)},
+		{"repo", starlark.String(ev.Repo)},
+		{"branch", starlark.String(ev.Branch)},
+		{"session", starlark.String(ev.Session)},
+		{"session_id", starlark.String(ev.SessionID)},
+		{"cwd", starlark.String(ev.CWD)},
+		{"tool", starlark.String(ev.Tool)},
+		{"elapsed", starlark.MakeInt64(int64(ev.Elapsed / time.Second))},
+		{"hour", starlark.MakeInt(ev.Time.Hour())},
+		{"weekday", starlark.String(ev.Time.Weekday().String()[:3])},
+	} {
+		_ = d.SetKey(starlark.String(kv.key), kv.value)
+	}
+	return d
+}
+
+// fromDict reads the changeable fields of the event dict back into ev
+func fromDict(ev Event, d *starlark.Dict) (Event, error) {
+	for _, field := range []struct {
+		key string
+		dst *string
+	}{
+		{"title", &ev.Title},
+		{"message", &ev.Message},
+		{"priority", &ev.Priority},
+		{"sound", &ev.Sound},
+	} {
+		v, found, _ := d.Get(starlark.String(field.key))
+		if !found || v == starlark.None {
+			*field.dst = ""
+			continue
+		}
+		s, ok := starlark.AsString(v)
+		if !ok {
+			return ev, fmt.Errorf("event[%q] must be a string, got %s", field.key, v.Type())
+		}
+		*field.dst = s
+	}
+	if !validPriorities[ev.Priority] {
+		return ev, fmt.Errorf("invalid priority %q (must be low, normal or critical)", ev.Priority)
+	}
+
+	v, found, _ := d.Get(starlark.String("backends"))
+	if !found || v == starlark.None {
+		ev.Backends = nil
+		return ev, nil
+	}
+	list, ok := v.(starlark.Iterable)
+	if !ok {
+		return ev, fmt.Errorf("event[\"backends\"] must be a list, got %s", v.Type())
+	}
+	ev.Backends = nil
+	iter := list.Iterate()
+	defer iter.Done()
+	var item starlark.Value
+	for iter.Next(&item) {
+		name, ok := starlark.AsString(item)
+		if !ok {
+			return ev, fmt.Errorf("event[\"backends\"] must hold strings, got %s", item.Type())
+		}
+		ev.Backends = append(ev.Backends, name)
+	}
+	return ev, nil
+}
@@ -0,0 +1,113 @@
+package script
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeScript writes src to a script file and returns its path
+func writeScript(t *testing.T, src string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "notify.star")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func loadScript(t *testing.T, src string) *Script {
+	t.Helper()
+	s, err := Load(writeScript(t, src), 0)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return s
+}
+
+func TestRun_Modifies(t *testing.T) {
+	s := loadScript(t, `
+QUIET = ["docs", "scratch"]
+
+def transform(event):
+    if event["branch"] == "main" and event["event"] == "Stop":
+        event["message"] = "@channel " + event["message"]
+        event["priority"] = "critical"
+        event["backends"] = ["desktop", "slack"]
+    if event["project"] in QUIET:
+        event["sound"] = "none"
+`)
+	out, keep, err := s.Run(Event{Status: "task_complete", HookEvent: "Stop", Branch: "main", Project: "docs", Message: "Deployed", Title: "✅ Completed"})
+	if err != nil || !keep {
+		t.Fatalf("Run() = %v, %v", keep, err)
+	}
+	want := Event{Status: "task_complete", HookEvent: "Stop", Branch: "main", Project: "docs", Message: "@channel Deployed", Title: "✅ Completed",
+		Priority: "critical", Sound: "none", Backends: []string{"desktop", "slack"}}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("Run() = %+v, want %+v", out, want)
+	}
+
+	in := Event{Status: "question", HookEvent: "Notification", Branch: "dev", Message: "Proceed?", Backends: []string{"desktop"}}
+	if out, _, _ := s.Run(in); !reflect.DeepEqual(out, in) {
+		t.Errorf("Run() changed an unmatched event: %+v", out)
+	}
+}
+
+func TestRun_SuppressAndReturn(t *testing.T) {
+	s := loadScript(t, `
+def transform(event):
+    if event["weekday"] in ("Sat", "Sun") and event["status"] == "task_complete":
+        return False
+    if event["elapsed"] > 600:
+        return dict(event, title = "🐢 " + event["title"])
+`)
+	saturday := time.Date(2026, 3, 14, 10, 0, 0, 0, time.Local)
+	if _, keep, err := s.Run(Event{Status: "task_complete", Time: saturday}); err != nil || keep {
+		t.Errorf("Run(weekend) keep = %v, err = %v; want suppressed", keep, err)
+	}
+	monday := saturday.AddDate(0, 0, 2)
+	out, keep, err := s.Run(Event{Status: "task_complete", Title: "Done", Elapsed: 11 * time.Minute, Time: monday})
+	if err != nil || !keep || out.Title != "🐢 Done" {
+		t.Errorf("Run(long) = %+v, %v, %v; want the returned title", out, keep, err)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"runtime error", "def transform(event):\n    return event[\"missing\"]\n", `key "missing" not in dict`},
+		{"bad priority", "def transform(event):\n    event[\"priority\"] = \"urgent\"\n", `invalid priority "urgent"`},
+		{"bad type", "def transform(event):\n    event[\"title\"] = 3\n", `event["title"] must be a string`},
+		{"bad result", "def transform(event):\n    return 1\n", "must return None, False or the event"},
+		{"endless loop", "def transform(event):\n    for i in range(100000000):\n        pass\n", "too many steps"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := loadScript(t, tt.src)
+			in := Event{Status: "question", Title: "Question"}
+			out, keep, err := s.Run(in)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Run() error = %v, want %q", err, tt.want)
+			}
+			if !keep || !reflect.DeepEqual(out, in) {
+				t.Errorf("Run() = %+v, %v; want the event unchanged", out, keep)
+			}
+		})
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	if _, err := Load(writeScript(t, "x = 1\n"), 0); err == nil || !strings.Contains(err.Error(), "must define transform(event)") {
+		t.Errorf("Load(no transform) error = %v", err)
+	}
+	if _, err := Load(writeScript(t, "def transform(event)\n"), 0); err == nil || !strings.Contains(err.Error(), "got newline, want ':'") {
+		t.Errorf("Load(syntax error) error = %v", err)
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.star"), 0); err == nil || !strings.Contains(err.Error(), "failed to read script") {
+		t.Errorf("Load(missing) error = %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+// Package seal encrypts notification content with a key shared between the
+// sending machine and the receiving side, so that a push relay (an ntfy
+// server, an MQTT broker, a webhook service) only carries ciphertext.
+// Notifications are sealed with NaCl secretbox (XSalsa20-Poly1305) into a
+// text envelope that fits in any message field.
+package seal
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// Prefix starts every envelope; the digit is the format version
+const Prefix = "cnseal1:"
+
+// KeySize is the length of a key in bytes
+const KeySize = 32
+
+// nonceSize is the length of the random nonce leading each sealed box
+const nonceSize = 24
+
+// envelopePattern finds envelopes in a larger text, e.g. a JSON payload
+var envelopePattern = regexp.MustCompile(regexp.QuoteMeta(Prefix) + `[A-Za-z0-9_-]+`)
+
+// Notification is the content sealed in an envelope
+type Notification struct {
+	Title   string    `json:"title"`
+	Message string    `json:"message"`
+	Status  string    `json:"status"`
+	Project string    `json:"project,omitempty"`
+	Repo    string    `json:"repo,omitempty"`
+	Branch  string    `json:"branch,omitempty"`
+	Session string    `json:"session,omitempty"`
+	Elapsed string    `json:"elapsed,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// NewKey returns a new random key, base64-encoded
+func NewKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// ParseKey decodes a base64 key (standard or URL alphabet)
+func ParseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if key, err := enc.DecodeString(s); err == nil {
+			if len(key) != KeySize {
+				return nil, fmt.Errorf("key must be %d bytes, got %d", KeySize, len(key))
+			}
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("key is not base64")
+}
+
+// Seal encrypts n into an envelope: the prefix, then the nonce and the
+// secretbox in unpadded base64url
+func Seal(key []byte, n Notification) (string, error) {
+	k, err := secretKey(key)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := json.Marshal(n)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode notification: %w", err)
+	}
+	var nonce [nonceSize]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := secretbox.Seal(nonce[:], plaintext, &nonce, k)
+	return Prefix + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts an envelope made by Seal
+func Open(key []byte, envelope string) (Notification, error) {
+	var n Notification
+	k, err := secretKey(key)
+	if err != nil {
+		return n, err
+	}
+	encoded, ok := strings.CutPrefix(strings.TrimSpace(envelope), Prefix)
+	if !ok {
+		return n, fmt.Errorf("not an encrypted notification (missing %q)", Prefix)
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < nonceSize+secretbox.Overhead {
+		return n, fmt.Errorf("malformed encrypted notification")
+	}
+	var nonce [nonceSize]byte
+	copy(nonce[:], sealed)
+	plaintext, ok := secretbox.Open(nil, sealed[nonceSize:], &nonce, k)
+	if !ok {
+		return n, fmt.Errorf("cannot decrypt notification: wrong key or tampered message")
+	}
+	if err := json.Unmarshal(plaintext, &n); err != nil {
+		return n, fmt.Errorf("failed to decode notification: %w", err)
+	}
+	return n, nil
+}
+
+// Find returns the envelopes in text, e.g. in a webhook body or an MQTT
+// payload
+func Find(text string) []string {
+	return envelopePattern.FindAllString(text, -1)
+}
+
+// secretKey checks the length of key and returns it as a secretbox key
+func secretKey(key []byte) (*[KeySize]byte, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", KeySize, len(key))
+	}
+	var k [KeySize]byte
+	copy(k[:], key)
+	return &k, nil
+}
@@ -0,0 +1,98 @@
+package seal
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+func TestSealOpen(t *testing.T) {
+	encoded, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParseKey(encoded)
+	if err != nil {
+		t.Fatalf("ParseKey(NewKey()) error = %v", err)
+	}
+
+	n := Notification{Title: "✅ Completed", Message: "Deployed v2", Status: "task_complete", Project: "api", Branch: "main", Time: time.Date(2026, 3, 14, 14, 32, 0, 0, time.UTC)}
+	envelope, err := Seal(key, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(envelope, Prefix) || strings.Contains(envelope, "Deployed") {
+		t.Fatalf("envelope = %q", envelope)
+	}
+	if again, _ := Seal(key, n); again == envelope {
+		t.Error("sealing twice should use a new nonce")
+	}
+
+	got, err := Open(key, envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != n {
+		t.Errorf("Open() = %+v, want %+v", got, n)
+	}
+
+	other, _ := NewKey()
+	otherKey, _ := ParseKey(other)
+	if _, err := Open(otherKey, envelope); err == nil || !strings.Contains(err.Error(), "wrong key") {
+		t.Errorf("Open(other key) error = %v", err)
+	}
+	tampered := envelope[:len(envelope)-2] + "AA"
+	if _, err := Open(key, tampered); err == nil {
+		t.Error("Open(tampered) should fail")
+	}
+	if _, err := Open(key, "hello"); err == nil || !strings.Contains(err.Error(), "not an encrypted notification") {
+		t.Errorf("Open(plain text) error = %v", err)
+	}
+}
+
+func TestSeal_IsSecretbox(t *testing.T) {
+	key := make([]byte, KeySize)
+	envelope, err := Seal(key, Notification{Title: "Done"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Receivers in other languages open the box with any secretbox library
+	sealed, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(envelope, Prefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nonce [24]byte
+	var k [KeySize]byte
+	copy(nonce[:], sealed)
+	plaintext, ok := secretbox.Open(nil, sealed[24:], &nonce, &k)
+	if !ok || !strings.Contains(string(plaintext), `"title":"Done"`) {
+		t.Errorf("secretbox.Open() = %q, %v", plaintext, ok)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	if _, err := ParseKey("c2hvcnQ="); err == nil || !strings.Contains(err.Error(), "must be 32 bytes") {
+		t.Errorf("ParseKey(short) error = %v", err)
+	}
+	if _, err := ParseKey("not base64!"); err == nil {
+		t.Error("ParseKey(not base64) should fail")
+	}
+	if _, err := ParseKey("  AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=\n"); err != nil {
+		t.Errorf("ParseKey(padded with spaces) error = %v", err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	body := `{"topic":"claude","title":"Claude Code","message":"cnseal1:abc-_123"} and cnseal1:XYZ`
+	got := Find(body)
+	if len(got) != 2 || got[0] != "cnseal1:abc-_123" || got[1] != "cnseal1:XYZ" {
+		t.Errorf("Find() = %v", got)
+	}
+	if got := Find("nothing sealed here"); len(got) != 0 {
+		t.Errorf("Find(plain) = %v", got)
+	}
+}
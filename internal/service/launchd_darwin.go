@@ -0,0 +1,260 @@
+//go:build darwin
+
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/777genius/claude-notifications/internal/platform"
+)
+
+// Label is the launchd job label of the agent
+const Label = "com.claude.notifications"
+
+// launchctl runs launchctl with args; replaced in tests
+var launchctl = func(args ...string) (string, error) {
+	cmd := exec.Command("launchctl", args...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	return strings.TrimSpace(out.String()), err
+}
+
+// AgentDir returns the per-user LaunchAgents directory
+func AgentDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	return filepath.Join(home, "Library", "LaunchAgents"), nil
+}
+
+// LogPath returns where the agent's output goes
+func LogPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	return filepath.Join(home, "Library", "Logs", Name+".log"), nil
+}
+
+// Plist returns the LaunchAgent property list. macOS has no notification
+// daemon (click-to-focus goes through terminal-notifier), so the agent
+// keeps the listener for forwarded remote notifications running: it starts
+// on login and launchd restarts it when it exits abnormally.
+func Plist(opts Options, logPath string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+`)
+	b.WriteString("\t<key>Label</key>\n\t<string>" + Label + "</string>\n")
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	b.WriteString("\t\t<string>" + platform.XMLEscape(opts.Binary) + "</string>\n")
+	b.WriteString("\t\t<string>listen</string>\n")
+	b.WriteString("\t</array>\n")
+	if opts.PluginRoot != "" {
+		b.WriteString("\t<key>EnvironmentVariables</key>\n\t<dict>\n")
+		b.WriteString("\t\t<key>CLAUDE_PLUGIN_ROOT</key>\n")
+		b.WriteString("\t\t<string>" + platform.XMLEscape(opts.PluginRoot) + "</string>\n")
+		b.WriteString("\t</dict>\n")
+	}
+	b.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	b.WriteString("\t<key>KeepAlive</key>\n\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>\n")
+	b.WriteString("\t<key>ThrottleInterval</key>\n\t<integer>10</integer>\n")
+	b.WriteString("\t<key>ProcessType</key>\n\t<string>Interactive</string>\n")
+	if logPath != "" {
+		b.WriteString("\t<key>StandardOutPath</key>\n\t<string>" + platform.XMLEscape(logPath) + "</string>\n")
+		b.WriteString("\t<key>StandardErrorPath</key>\n\t<string>" + platform.XMLEscape(logPath) + "</string>\n")
+	}
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+// domain is the launchd domain of the user's GUI session
+func domain() string {
+	return fmt.Sprintf("gui/%d", os.Getuid())
+}
+
+// target is the launchd service target of the agent
+func target() string {
+	return domain() + "/" + Label
+}
+
+// plistPath returns the path of the agent's plist
+func plistPath() (string, error) {
+	dir, err := AgentDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, Label+".plist"), nil
+}
+
+// Install writes the agent plist and loads it, which starts the listener.
+// Installing again replaces a loaded agent.
+func Install(opts Options) error {
+	if opts.Socket {
+		return fmt.Errorf("socket activation is only available with systemd on Linux")
+	}
+	path, err := plistPath()
+	if err != nil {
+		return err
+	}
+	logPath, err := LogPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(logPath), err)
+	}
+
+	if loaded() {
+		_, _ = launchctl("bootout", target())
+	}
+	if err := os.WriteFile(path, []byte(Plist(opts, logPath)), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if out, err := launchctl("bootstrap", domain(), path); err != nil {
+		return launchctlError("bootstrap", out, err)
+	}
+	return nil
+}
+
+// Uninstall unloads the agent and removes its plist
+func Uninstall() error {
+	path, err := plistPath()
+	if err != nil {
+		return err
+	}
+	if loaded() {
+		if out, err := launchctl("bootout", target()); err != nil {
+			return launchctlError("bootout", out, err)
+		}
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return nil
+}
+
+// Start loads the agent, or starts it again when it is loaded but stopped
+func Start() error {
+	path, err := installedPlist()
+	if err != nil {
+		return err
+	}
+	if loaded() {
+		if out, err := launchctl("kickstart", target()); err != nil {
+			return launchctlError("kickstart", out, err)
+		}
+		return nil
+	}
+	if out, err := launchctl("bootstrap", domain(), path); err != nil {
+		return launchctlError("bootstrap", out, err)
+	}
+	return nil
+}
+
+// Stop unloads the agent until the next login or Start
+func Stop() error {
+	if _, err := installedPlist(); err != nil {
+		return err
+	}
+	if !loaded() {
+		return nil
+	}
+	if out, err := launchctl("bootout", target()); err != nil {
+		return launchctlError("bootout", out, err)
+	}
+	return nil
+}
+
+// Restart kills and restarts the agent, picking up a new binary after an update
+func Restart() error {
+	if _, err := installedPlist(); err != nil {
+		return err
+	}
+	if !loaded() {
+		return Start()
+	}
+	if out, err := launchctl("kickstart", "-k", target()); err != nil {
+		return launchctlError("kickstart", out, err)
+	}
+	return nil
+}
+
+// pidPattern and statePattern pick fields out of "launchctl print"
+var (
+	pidPattern   = regexp.MustCompile(`(?m)^\s*pid = (\d+)`)
+	statePattern = regexp.MustCompile(`(?m)^\s*state = (\S+)`)
+)
+
+// GetStatus reports whether the agent is installed, loaded and running
+func GetStatus() (Status, error) {
+	st := Status{Manager: "launchd (LaunchAgent)"}
+	path, err := plistPath()
+	if err != nil {
+		return st, err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return st, nil
+	}
+	st.Installed = true
+	st.Files = []string{path}
+
+	out, err := launchctl("print", target())
+	if err != nil {
+		st.Detail = "not loaded"
+		return st, nil
+	}
+	// A loaded agent with RunAtLoad starts on every login
+	st.Enabled = true
+	state := "unknown"
+	if m := statePattern.FindStringSubmatch(out); m != nil {
+		state = m[1]
+	}
+	st.Active = state == "running"
+	st.Detail = state
+	if m := pidPattern.FindStringSubmatch(out); m != nil {
+		st.Detail += ", pid " + m[1]
+	}
+	return st, nil
+}
+
+// loaded reports whether launchd knows the agent
+func loaded() bool {
+	_, err := launchctl("print", target())
+	return err == nil
+}
+
+// installedPlist returns the plist path, or an error when not installed
+func installedPlist() (string, error) {
+	path, err := plistPath()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("service is not installed (run: claude-notifications service install)")
+	}
+	return path, nil
+}
+
+// launchctlError wraps a failed launchctl call with its output
+func launchctlError(command, out string, err error) error {
+	if out != "" {
+		return fmt.Errorf("launchctl %s failed: %s", command, out)
+	}
+	return fmt.Errorf("launchctl %s failed: %w", command, err)
+}
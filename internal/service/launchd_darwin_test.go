@@ -0,0 +1,137 @@
+//go:build darwin
+
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeLaunchctl records launchctl calls; print succeeds with printOut when loaded
+type fakeLaunchctl struct {
+	calls    []string
+	loaded   bool
+	printOut string
+}
+
+func (f *fakeLaunchctl) run(args ...string) (string, error) {
+	f.calls = append(f.calls, strings.Join(args, " "))
+	switch args[0] {
+	case "print":
+		if !f.loaded {
+			return "Could not find service", errors.New("exit status 113")
+		}
+		return f.printOut, nil
+	case "bootstrap":
+		f.loaded = true
+	case "bootout":
+		f.loaded = false
+	}
+	return "", nil
+}
+
+func setupLaunchd(t *testing.T) (*fakeLaunchctl, string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	fake := &fakeLaunchctl{}
+	orig := launchctl
+	launchctl = fake.run
+	t.Cleanup(func() { launchctl = orig })
+	return fake, filepath.Join(home, "Library", "LaunchAgents", Label+".plist")
+}
+
+func TestPlist(t *testing.T) {
+	plist := Plist(Options{Binary: "/Apps/A & B/claude-notifications", PluginRoot: "/opt/cn"}, "/Users/me/Library/Logs/claude-notifications.log")
+	for _, want := range []string{
+		"<string>" + Label + "</string>",
+		"<string>/Apps/A &amp; B/claude-notifications</string>\n\t\t<string>listen</string>",
+		"<key>CLAUDE_PLUGIN_ROOT</key>\n\t\t<string>/opt/cn</string>",
+		"<key>RunAtLoad</key>\n\t<true/>",
+		"<key>SuccessfulExit</key>\n\t\t<false/>",
+		"<key>StandardErrorPath</key>",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Errorf("plist missing %q:\n%s", want, plist)
+		}
+	}
+	if strings.Contains(Plist(Options{Binary: "/bin/cn"}, ""), "EnvironmentVariables") {
+		t.Error("plist without plugin root should not set EnvironmentVariables")
+	}
+}
+
+func TestInstallLaunchd(t *testing.T) {
+	fake, path := setupLaunchd(t)
+
+	if err := Install(Options{Binary: "/usr/local/bin/claude-notifications"}); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("plist not written: %v", err)
+	}
+	if !fake.loaded {
+		t.Error("agent not bootstrapped")
+	}
+
+	// Installing again boots out the loaded agent first
+	fake.calls = nil
+	if err := Install(Options{Binary: "/usr/local/bin/claude-notifications"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(strings.Join(fake.calls, "|"), "bootout "+target()) {
+		t.Errorf("loaded agent not booted out, calls = %q", fake.calls)
+	}
+
+	if err := Install(Options{Binary: "/bin/cn", Socket: true}); err == nil {
+		t.Error("Install(Socket) should fail on macOS")
+	}
+}
+
+func TestUninstallLaunchd(t *testing.T) {
+	fake, path := setupLaunchd(t)
+	if err := Install(Options{Binary: "/bin/cn"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Uninstall(); err != nil {
+		t.Fatalf("Uninstall() error = %v", err)
+	}
+	if fake.loaded {
+		t.Error("agent still loaded")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("plist should be removed, stat error = %v", err)
+	}
+}
+
+func TestGetStatusLaunchd(t *testing.T) {
+	fake, _ := setupLaunchd(t)
+	if st, _ := GetStatus(); st.Installed {
+		t.Error("Installed = true before install")
+	}
+	if err := Stop(); err == nil {
+		t.Error("Stop() should fail when not installed")
+	}
+
+	if err := Install(Options{Binary: "/bin/cn"}); err != nil {
+		t.Fatal(err)
+	}
+	fake.printOut = "gui/501/com.claude.notifications = {\n\tstate = running\n\tpid = 4242\n}"
+	st, err := GetStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.Installed || !st.Enabled || !st.Active || st.Detail != "running, pid 4242" {
+		t.Errorf("GetStatus() = %+v", st)
+	}
+
+	if err := Stop(); err != nil {
+		t.Fatal(err)
+	}
+	st, _ = GetStatus()
+	if st.Active || st.Detail != "not loaded" {
+		t.Errorf("after Stop: %+v", st)
+	}
+}
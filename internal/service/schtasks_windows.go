@@ -0,0 +1,222 @@
+//go:build windows
+
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/777genius/claude-notifications/internal/platform"
+)
+
+// taskName is the Task Scheduler task, in the root folder
+const taskName = `\` + Name
+
+// schtasks runs schtasks.exe with args; replaced in tests
+var schtasks = func(args ...string) (string, error) {
+	cmd := exec.Command("schtasks", args...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	return strings.TrimSpace(out.String()), err
+}
+
+// TaskXML returns the Task Scheduler definition. Windows has no
+// notification daemon (toasts need no background process), so the task
+// keeps the listener for forwarded remote notifications running: it starts
+// at logon for userID, runs without a time limit and is restarted when it
+// fails. Tasks have no environment of their own; the listener finds the
+// plugin root from the binary's location.
+func TaskXML(opts Options, userID string) string {
+	return `<?xml version="1.0" encoding="UTF-16"?>
+<Task version="1.2" xmlns="http://schemas.microsoft.com/windows/2004/02/mit/task">
+  <RegistrationInfo>
+    <Description>Claude Code notifications: shows notifications forwarded from remote (SSH) sessions</Description>
+    <URI>` + taskName + `</URI>
+  </RegistrationInfo>
+  <Triggers>
+    <LogonTrigger>
+      <Enabled>true</Enabled>
+      <UserId>` + platform.XMLEscape(userID) + `</UserId>
+    </LogonTrigger>
+  </Triggers>
+  <Principals>
+    <Principal id="Author">
+      <UserId>` + platform.XMLEscape(userID) + `</UserId>
+      <LogonType>InteractiveToken</LogonType>
+      <RunLevel>LeastPrivilege</RunLevel>
+    </Principal>
+  </Principals>
+  <Settings>
+    <MultipleInstancesPolicy>IgnoreNew</MultipleInstancesPolicy>
+    <DisallowStartIfOnBatteries>false</DisallowStartIfOnBatteries>
+    <StopIfGoingOnBatteries>false</StopIfGoingOnBatteries>
+    <ExecutionTimeLimit>PT0S</ExecutionTimeLimit>
+    <RestartOnFailure>
+      <Interval>PT1M</Interval>
+      <Count>999</Count>
+    </RestartOnFailure>
+    <Enabled>true</Enabled>
+  </Settings>
+  <Actions Context="Author">
+    <Exec>
+      <Command>` + platform.XMLEscape(opts.Binary) + `</Command>
+      <Arguments>listen --background</Arguments>
+      <WorkingDirectory>` + platform.XMLEscape(filepath.Dir(opts.Binary)) + `</WorkingDirectory>
+    </Exec>
+  </Actions>
+</Task>
+`
+}
+
+// utf16File encodes s as UTF-16LE with a byte order mark, which schtasks
+// expects for /XML files
+func utf16File(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	buf := make([]byte, 2, 2+2*len(units))
+	buf[0], buf[1] = 0xFF, 0xFE
+	for _, u := range units {
+		buf = append(buf, byte(u), byte(u>>8))
+	}
+	return buf
+}
+
+// Install registers the logon task, replacing an existing one, and starts it
+func Install(opts Options) error {
+	if opts.Socket {
+		return fmt.Errorf("socket activation is only available with systemd on Linux")
+	}
+	u, err := user.Current()
+	if err != nil {
+		return fmt.Errorf("cannot determine current user: %w", err)
+	}
+
+	f, err := os.CreateTemp("", Name+"-*.xml")
+	if err != nil {
+		return fmt.Errorf("failed to create task definition: %w", err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(utf16File(TaskXML(opts, u.Username)))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write task definition: %w", err)
+	}
+
+	if installed() {
+		_, _ = schtasks("/End", "/TN", taskName)
+	}
+	if out, err := schtasks("/Create", "/TN", taskName, "/XML", f.Name(), "/F"); err != nil {
+		return schtasksError("/Create", out, err)
+	}
+	if out, err := schtasks("/Run", "/TN", taskName); err != nil {
+		return schtasksError("/Run", out, err)
+	}
+	return nil
+}
+
+// Uninstall stops and deletes the task
+func Uninstall() error {
+	if !installed() {
+		return nil
+	}
+	_, _ = schtasks("/End", "/TN", taskName)
+	if out, err := schtasks("/Delete", "/TN", taskName, "/F"); err != nil {
+		return schtasksError("/Delete", out, err)
+	}
+	return nil
+}
+
+// Start runs the task now
+func Start() error {
+	if !installed() {
+		return errNotInstalled()
+	}
+	if out, err := schtasks("/Run", "/TN", taskName); err != nil {
+		return schtasksError("/Run", out, err)
+	}
+	return nil
+}
+
+// Stop ends the running task; it starts again at the next logon
+func Stop() error {
+	if !installed() {
+		return errNotInstalled()
+	}
+	if out, err := schtasks("/End", "/TN", taskName); err != nil {
+		return schtasksError("/End", out, err)
+	}
+	return nil
+}
+
+// Restart ends and runs the task, picking up a new binary after an update
+func Restart() error {
+	if !installed() {
+		return errNotInstalled()
+	}
+	// Ending a task that is not running fails; run it either way
+	_, _ = schtasks("/End", "/TN", taskName)
+	return Start()
+}
+
+// GetStatus reports whether the task is registered, enabled and running.
+// The status column is in the system language, e.g. "Running" or "Ready".
+func GetStatus() (Status, error) {
+	st := Status{Manager: "Task Scheduler"}
+	out, err := schtasks("/Query", "/TN", taskName, "/FO", "CSV", "/NH")
+	if err != nil {
+		return st, nil
+	}
+	st.Installed = true
+	st.Files = []string{taskName}
+
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err == nil && len(records) > 0 && len(records[0]) >= 3 {
+		st.Detail = records[0][2]
+	}
+	st.Active = st.Detail == "Running"
+	st.Enabled = true
+	if def, err := schtasks("/Query", "/TN", taskName, "/XML"); err == nil {
+		st.Enabled = !strings.Contains(taskSettings(def), "<Enabled>false</Enabled>")
+	}
+	return st, nil
+}
+
+// taskSettings returns the <Settings> element of a task definition, whose
+// <Enabled> tells whether the task is disabled
+func taskSettings(def string) string {
+	start := strings.Index(def, "<Settings>")
+	end := strings.Index(def, "</Settings>")
+	if start < 0 || end < start {
+		return ""
+	}
+	return def[start:end]
+}
+
+// installed reports whether the task is registered
+func installed() bool {
+	_, err := schtasks("/Query", "/TN", taskName)
+	return err == nil
+}
+
+// errNotInstalled is returned by commands that need the task
+func errNotInstalled() error {
+	return fmt.Errorf("service is not installed (run: claude-notifications service install)")
+}
+
+// schtasksError wraps a failed schtasks call with its output
+func schtasksError(command, out string, err error) error {
+	if out != "" {
+		return fmt.Errorf("schtasks %s failed: %s", command, out)
+	}
+	return fmt.Errorf("schtasks %s failed: %w", command, err)
+}
@@ -0,0 +1,114 @@
+//go:build windows
+
+package service
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeSchtasks records schtasks calls; /Query succeeds once the task exists
+type fakeSchtasks struct {
+	calls   []string
+	created bool
+	csv     string
+	xml     string
+}
+
+func (f *fakeSchtasks) run(args ...string) (string, error) {
+	f.calls = append(f.calls, strings.Join(args, " "))
+	switch args[0] {
+	case "/Create":
+		f.created = true
+	case "/Delete":
+		f.created = false
+	case "/Query":
+		if !f.created {
+			return "ERROR: The system cannot find the file specified.", errors.New("exit status 1")
+		}
+		if len(args) > 3 && args[3] == "/FO" {
+			return f.csv, nil
+		}
+		if len(args) > 3 && args[3] == "/XML" {
+			return f.xml, nil
+		}
+	}
+	return "", nil
+}
+
+func setupSchtasks(t *testing.T) *fakeSchtasks {
+	t.Helper()
+	fake := &fakeSchtasks{}
+	orig := schtasks
+	schtasks = fake.run
+	t.Cleanup(func() { schtasks = orig })
+	return fake
+}
+
+func TestTaskXML(t *testing.T) {
+	def := TaskXML(Options{Binary: `C:\Tools\A&B\claude-notifications.exe`}, `PC\me`)
+	for _, want := range []string{
+		`<Command>C:\Tools\A&amp;B\claude-notifications.exe</Command>`,
+		`<Arguments>listen --background</Arguments>`,
+		`<WorkingDirectory>C:\Tools\A&amp;B</WorkingDirectory>`,
+		`<UserId>PC\me</UserId>`,
+		`<ExecutionTimeLimit>PT0S</ExecutionTimeLimit>`,
+		`<RestartOnFailure>`,
+	} {
+		if !strings.Contains(def, want) {
+			t.Errorf("task XML missing %q:\n%s", want, def)
+		}
+	}
+}
+
+func TestUTF16File(t *testing.T) {
+	got := utf16File("aé")
+	want := []byte{0xFF, 0xFE, 'a', 0, 0xE9, 0}
+	if !bytes.Equal(got, want) {
+		t.Errorf("utf16File() = %v, want %v", got, want)
+	}
+}
+
+func TestInstallSchtasks(t *testing.T) {
+	fake := setupSchtasks(t)
+	if err := Install(Options{Binary: `C:\cn.exe`}); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	calls := strings.Join(fake.calls, "|")
+	if !strings.Contains(calls, `/Create /TN \claude-notifications /XML `) || !strings.HasSuffix(calls, `/Run /TN \claude-notifications`) {
+		t.Errorf("calls = %q", fake.calls)
+	}
+	if err := Install(Options{Binary: `C:\cn.exe`, Socket: true}); err == nil {
+		t.Error("Install(Socket) should fail on Windows")
+	}
+}
+
+func TestGetStatusSchtasks(t *testing.T) {
+	fake := setupSchtasks(t)
+	if st, _ := GetStatus(); st.Installed {
+		t.Error("Installed = true before install")
+	}
+	if err := Start(); err == nil {
+		t.Error("Start() should fail when not installed")
+	}
+
+	fake.created = true
+	fake.csv = `"\claude-notifications","N/A","Running"`
+	fake.xml = "<Task><Triggers><LogonTrigger><Enabled>true</Enabled></LogonTrigger></Triggers><Settings><Enabled>false</Enabled></Settings></Task>"
+	st, err := GetStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.Installed || !st.Active || st.Enabled || st.Detail != "Running" {
+		t.Errorf("GetStatus() = %+v, want installed, running, disabled", st)
+	}
+
+	if err := Uninstall(); err != nil {
+		t.Fatal(err)
+	}
+	if fake.created {
+		t.Error("task not deleted")
+	}
+}
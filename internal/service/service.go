@@ -0,0 +1,68 @@
+// Package service installs the long-running process as a per-user service
+// of the platform's service manager for "claude-notifications service",
+// so it starts on login and restarts after a crash. On Linux that is the
+// notification daemon (a systemd user unit), otherwise started on demand
+// by the first hook; on macOS and Windows, which have no daemon, it is the
+// listener for forwarded remote notifications (a launchd LaunchAgent or a
+// Task Scheduler logon task).
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Name is the service name used for unit files and labels
+const Name = "claude-notifications"
+
+// ErrUnsupported is returned on platforms without service support
+var ErrUnsupported = errors.New("service management is not supported on this platform")
+
+// Options describe the service to install
+type Options struct {
+	Binary     string // Absolute path of the claude-notifications binary
+	PluginRoot string // Passed to the daemon as CLAUDE_PLUGIN_ROOT (empty = not set)
+	Socket     bool   // Start the daemon on the first connection to its socket (systemd socket activation)
+}
+
+// Status describes the installed service
+type Status struct {
+	Manager   string   // Service manager, e.g. "systemd (user)"
+	Installed bool     // Service files exist
+	Enabled   bool     // Starts on login
+	Active    bool     // Running, or listening for socket activation
+	Socket    bool     // Installed with socket activation
+	Files     []string // Service files that exist
+	Detail    string   // Service manager's own state, e.g. "daemon running"
+}
+
+// Print writes a status summary
+func (s Status) Print(w io.Writer) {
+	fmt.Fprintf(w, "Manager:   %s\n", s.Manager)
+	if !s.Installed {
+		fmt.Fprintln(w, "Installed: no (run: claude-notifications service install)")
+		return
+	}
+	mode := "always running"
+	if s.Socket {
+		mode = "socket-activated"
+	}
+	fmt.Fprintf(w, "Installed: yes (%s)\n", mode)
+	fmt.Fprintf(w, "Enabled:   %s\n", yesNo(s.Enabled))
+	active := yesNo(s.Active)
+	if s.Detail != "" {
+		active += " (" + s.Detail + ")"
+	}
+	fmt.Fprintf(w, "Active:    %s\n", active)
+	fmt.Fprintf(w, "Files:     %s\n", strings.Join(s.Files, ", "))
+}
+
+// yesNo formats a flag for Print
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
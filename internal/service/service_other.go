@@ -0,0 +1,33 @@
+//go:build !linux && !darwin && !windows
+
+package service
+
+// Install is not supported on this platform
+func Install(opts Options) error {
+	return ErrUnsupported
+}
+
+// Uninstall is not supported on this platform
+func Uninstall() error {
+	return ErrUnsupported
+}
+
+// Start is not supported on this platform
+func Start() error {
+	return ErrUnsupported
+}
+
+// Stop is not supported on this platform
+func Stop() error {
+	return ErrUnsupported
+}
+
+// Restart is not supported on this platform
+func Restart() error {
+	return ErrUnsupported
+}
+
+// GetStatus is not supported on this platform
+func GetStatus() (Status, error) {
+	return Status{}, ErrUnsupported
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStatusPrint(t *testing.T) {
+	var buf bytes.Buffer
+	Status{Manager: "systemd (user)"}.Print(&buf)
+	if !strings.Contains(buf.String(), "Installed: no (run: claude-notifications service install)") {
+		t.Errorf("not installed output:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	Status{
+		Manager:   "systemd (user)",
+		Installed: true,
+		Enabled:   true,
+		Active:    true,
+		Socket:    true,
+		Files:     []string{"/u/claude-notifications.service", "/u/claude-notifications.socket"},
+		Detail:    "daemon running",
+	}.Print(&buf)
+	out := buf.String()
+	for _, want := range []string{
+		"Installed: yes (socket-activated)",
+		"Enabled:   yes",
+		"Active:    yes (daemon running)",
+		"Files:     /u/claude-notifications.service, /u/claude-notifications.socket",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+//go:build linux
+
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	serviceUnit = Name + ".service"
+	socketUnit  = Name + ".socket"
+)
+
+// systemctl runs "systemctl --user" with args; replaced in tests
+var systemctl = func(args ...string) (string, error) {
+	cmd := exec.Command("systemctl", append([]string{"--user"}, args...)...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	return strings.TrimSpace(out.String()), err
+}
+
+// UnitDir returns the systemd user unit directory:
+// $XDG_CONFIG_HOME/systemd/user, or ~/.config/systemd/user
+func UnitDir() (string, error) {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "systemd", "user"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "systemd", "user"), nil
+}
+
+// ServiceUnit returns the systemd service unit. An always-running daemon
+// has no idle timeout and restarts when it exits abnormally; a
+// socket-activated one exits when idle and systemd starts it again on
+// the next connection.
+func ServiceUnit(opts Options) string {
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=Claude Code notification daemon (click-to-focus)\n")
+	b.WriteString("Documentation=https://github.com/777genius/claude-notifications-go\n")
+	if opts.Socket {
+		b.WriteString("Requires=" + socketUnit + "\n")
+		b.WriteString("After=" + socketUnit + "\n")
+	}
+	b.WriteString("\n[Service]\n")
+	b.WriteString("Type=simple\n")
+	if opts.Socket {
+		b.WriteString("ExecStart=" + quoteArg(opts.Binary) + " daemon\n")
+	} else {
+		b.WriteString("ExecStart=" + quoteArg(opts.Binary) + " daemon --idle-timeout 0\n")
+	}
+	if opts.PluginRoot != "" {
+		b.WriteString("Environment=" + quoteArg("CLAUDE_PLUGIN_ROOT="+opts.PluginRoot) + "\n")
+	}
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=2\n")
+	if !opts.Socket {
+		b.WriteString("\n[Install]\n")
+		b.WriteString("WantedBy=default.target\n")
+	}
+	return b.String()
+}
+
+// SocketUnit returns the systemd socket unit for socket activation. %t is
+// $XDG_RUNTIME_DIR, where clients look for the socket in the private
+// claude-notifications directory.
+func SocketUnit() string {
+	return `[Unit]
+Description=Claude Code notification daemon socket
+Documentation=https://github.com/777genius/claude-notifications-go
+
+[Socket]
+ListenStream=%t/claude-notifications/daemon.sock
+SocketMode=0600
+DirectoryMode=0700
+RemoveOnStop=true
+
+[Install]
+WantedBy=sockets.target
+`
+}
+
+// quoteArg quotes a systemd command line word containing spaces or quotes
+func quoteArg(s string) string {
+	if !strings.ContainsAny(s, " \t\"'\\") {
+		return s
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// mainUnit is the unit enabled and started: the socket when socket-activated
+func mainUnit(socket bool) string {
+	if socket {
+		return socketUnit
+	}
+	return serviceUnit
+}
+
+// Install writes the units and enables and starts the service. Installing
+// again replaces the units, switching between socket activation and an
+// always-running daemon.
+func Install(opts Options) error {
+	dir, err := UnitDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	// Switching modes: stop and disable what the previous install enabled
+	if st, err := GetStatus(); err == nil && st.Installed {
+		_, _ = systemctl("disable", "--now", socketUnit, serviceUnit)
+	}
+
+	if err := writeUnit(filepath.Join(dir, serviceUnit), ServiceUnit(opts)); err != nil {
+		return err
+	}
+	socketPath := filepath.Join(dir, socketUnit)
+	if opts.Socket {
+		if err := writeUnit(socketPath, SocketUnit()); err != nil {
+			return err
+		}
+	} else if err := os.Remove(socketPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove %s: %w", socketPath, err)
+	}
+
+	if out, err := systemctl("daemon-reload"); err != nil {
+		return systemctlError("daemon-reload", out, err)
+	}
+	if out, err := systemctl("enable", "--now", mainUnit(opts.Socket)); err != nil {
+		return systemctlError("enable", out, err)
+	}
+	return nil
+}
+
+// Uninstall stops and disables the service and removes its units
+func Uninstall() error {
+	dir, err := UnitDir()
+	if err != nil {
+		return err
+	}
+	_, _ = systemctl("disable", "--now", socketUnit, serviceUnit)
+	for _, unit := range []string{socketUnit, serviceUnit} {
+		path := filepath.Join(dir, unit)
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+	if out, err := systemctl("daemon-reload"); err != nil {
+		return systemctlError("daemon-reload", out, err)
+	}
+	return nil
+}
+
+// Start starts the installed service
+func Start() error {
+	return runUnitCommand("start")
+}
+
+// Stop stops the daemon and, when socket-activated, its socket
+func Stop() error {
+	st, err := GetStatus()
+	if err != nil {
+		return err
+	}
+	if !st.Installed {
+		return fmt.Errorf("service is not installed (run: claude-notifications service install)")
+	}
+	units := []string{serviceUnit}
+	if st.Socket {
+		units = []string{socketUnit, serviceUnit}
+	}
+	if out, err := systemctl(append([]string{"stop"}, units...)...); err != nil {
+		return systemctlError("stop", out, err)
+	}
+	return nil
+}
+
+// Restart restarts the daemon, picking up a new binary after an update
+func Restart() error {
+	return runUnitCommand("restart")
+}
+
+// runUnitCommand runs a systemctl command on the main unit
+func runUnitCommand(command string) error {
+	st, err := GetStatus()
+	if err != nil {
+		return err
+	}
+	if !st.Installed {
+		return fmt.Errorf("service is not installed (run: claude-notifications service install)")
+	}
+	units := []string{serviceUnit}
+	if st.Socket && command == "start" {
+		units = []string{socketUnit}
+	}
+	if out, err := systemctl(append([]string{command}, units...)...); err != nil {
+		return systemctlError(command, out, err)
+	}
+	return nil
+}
+
+// Installed reports whether the units are installed and with socket
+// activation, without asking systemd
+func Installed() (installed, socket bool) {
+	files, err := unitFiles()
+	if err != nil {
+		return false, false
+	}
+	for _, path := range files {
+		installed = true
+		socket = socket || filepath.Base(path) == socketUnit
+	}
+	return installed, socket
+}
+
+// unitFiles returns the paths of the installed units
+func unitFiles() ([]string, error) {
+	dir, err := UnitDir()
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, unit := range []string{serviceUnit, socketUnit} {
+		path := filepath.Join(dir, unit)
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	return files, nil
+}
+
+// GetStatus reports whether the service is installed, enabled and running
+func GetStatus() (Status, error) {
+	st := Status{Manager: "systemd (user)"}
+	files, err := unitFiles()
+	if err != nil {
+		return st, err
+	}
+	for _, path := range files {
+		st.Files = append(st.Files, path)
+		st.Installed = true
+		if filepath.Base(path) == socketUnit {
+			st.Socket = true
+		}
+	}
+	if !st.Installed {
+		return st, nil
+	}
+
+	main := mainUnit(st.Socket)
+	enabled, _ := systemctl("is-enabled", main)
+	st.Enabled = enabled == "enabled"
+	mainActive, _ := systemctl("is-active", main)
+	st.Active = mainActive == "active"
+	daemonActive, _ := systemctl("is-active", serviceUnit)
+	switch {
+	case st.Socket && daemonActive == "active":
+		st.Detail = "daemon running"
+	case st.Socket && st.Active:
+		st.Detail = "listening, daemon starts on first notification"
+	default:
+		st.Detail = daemonActive
+	}
+	return st, nil
+}
+
+// writeUnit writes a unit file
+func writeUnit(path, content string) error {
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// systemctlError wraps a failed systemctl call with its output
+func systemctlError(command, out string, err error) error {
+	if out != "" {
+		return fmt.Errorf("systemctl --user %s failed: %s", command, out)
+	}
+	return fmt.Errorf("systemctl --user %s failed: %w", command, err)
+}
@@ -0,0 +1,236 @@
+//go:build linux
+
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeSystemctl records systemctl calls and answers is-enabled/is-active
+type fakeSystemctl struct {
+	calls  []string
+	states map[string]string // "is-active claude-notifications.service" -> "active"
+}
+
+func (f *fakeSystemctl) run(args ...string) (string, error) {
+	call := strings.Join(args, " ")
+	f.calls = append(f.calls, call)
+	return f.states[call], nil
+}
+
+func setupSystemd(t *testing.T) (*fakeSystemctl, string) {
+	t.Helper()
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	fake := &fakeSystemctl{states: map[string]string{}}
+	orig := systemctl
+	systemctl = fake.run
+	t.Cleanup(func() { systemctl = orig })
+	return fake, filepath.Join(xdg, "systemd", "user")
+}
+
+func TestUnitDir(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	dir, err := UnitDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir != "/xdg/systemd/user" {
+		t.Errorf("UnitDir() = %q, want /xdg/systemd/user", dir)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("HOME", "/home/me")
+	dir, _ = UnitDir()
+	if dir != "/home/me/.config/systemd/user" {
+		t.Errorf("UnitDir() = %q, want /home/me/.config/systemd/user", dir)
+	}
+}
+
+func TestServiceUnit(t *testing.T) {
+	unit := ServiceUnit(Options{Binary: "/opt/cn/bin/claude-notifications", PluginRoot: "/opt/cn"})
+	for _, want := range []string{
+		"ExecStart=/opt/cn/bin/claude-notifications daemon --idle-timeout 0\n",
+		"Environment=CLAUDE_PLUGIN_ROOT=/opt/cn\n",
+		"Restart=on-failure\n",
+		"WantedBy=default.target\n",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("service unit missing %q:\n%s", want, unit)
+		}
+	}
+	if strings.Contains(unit, "Requires=") {
+		t.Errorf("always-running unit should not require the socket:\n%s", unit)
+	}
+
+	unit = ServiceUnit(Options{Binary: "/usr/bin/claude-notifications", Socket: true})
+	for _, want := range []string{
+		"Requires=claude-notifications.socket\n",
+		"ExecStart=/usr/bin/claude-notifications daemon\n",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("socket-activated unit missing %q:\n%s", want, unit)
+		}
+	}
+	for _, unwanted := range []string{"Environment=", "[Install]"} {
+		if strings.Contains(unit, unwanted) {
+			t.Errorf("socket-activated unit should not contain %q:\n%s", unwanted, unit)
+		}
+	}
+}
+
+func TestSocketUnit(t *testing.T) {
+	unit := SocketUnit()
+	for _, want := range []string{"ListenStream=%t/claude-notifications/daemon.sock\n", "SocketMode=0600\n", "DirectoryMode=0700\n", "WantedBy=sockets.target\n"} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("socket unit missing %q:\n%s", want, unit)
+		}
+	}
+}
+
+func TestQuoteArg(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"/usr/bin/claude-notifications", "/usr/bin/claude-notifications"},
+		{"/home/me/My Apps/claude-notifications", `"/home/me/My Apps/claude-notifications"`},
+		{`/a "b"`, `"/a \"b\""`},
+		{`C:\x y`, `"C:\\x y"`},
+	}
+	for _, tt := range tests {
+		if got := quoteArg(tt.in); got != tt.want {
+			t.Errorf("quoteArg(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestInstallAlwaysRunning(t *testing.T) {
+	fake, dir := setupSystemd(t)
+
+	if err := Install(Options{Binary: "/usr/bin/claude-notifications"}); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "claude-notifications.service")); err != nil {
+		t.Errorf("service unit not written: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "claude-notifications.socket")); !os.IsNotExist(err) {
+		t.Errorf("socket unit should not exist, stat error = %v", err)
+	}
+	want := []string{"daemon-reload", "enable --now claude-notifications.service"}
+	if strings.Join(fake.calls, "|") != strings.Join(want, "|") {
+		t.Errorf("systemctl calls = %q, want %q", fake.calls, want)
+	}
+}
+
+func TestInstallSwitchesToSocket(t *testing.T) {
+	fake, dir := setupSystemd(t)
+	if err := Install(Options{Binary: "/usr/bin/claude-notifications"}); err != nil {
+		t.Fatal(err)
+	}
+	fake.calls = nil
+
+	if err := Install(Options{Binary: "/usr/bin/claude-notifications", Socket: true}); err != nil {
+		t.Fatalf("Install(socket) error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "claude-notifications.socket")); err != nil {
+		t.Errorf("socket unit not written: %v", err)
+	}
+	calls := strings.Join(fake.calls, "|")
+	if !strings.Contains(calls, "disable --now claude-notifications.socket claude-notifications.service") {
+		t.Errorf("previous install not disabled, calls = %q", fake.calls)
+	}
+	if !strings.HasSuffix(calls, "enable --now claude-notifications.socket") {
+		t.Errorf("socket not enabled, calls = %q", fake.calls)
+	}
+
+	// Switching back removes the socket unit
+	if err := Install(Options{Binary: "/usr/bin/claude-notifications"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "claude-notifications.socket")); !os.IsNotExist(err) {
+		t.Errorf("socket unit should be removed, stat error = %v", err)
+	}
+}
+
+func TestUninstall(t *testing.T) {
+	_, dir := setupSystemd(t)
+	if err := Install(Options{Binary: "/usr/bin/claude-notifications", Socket: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Uninstall(); err != nil {
+		t.Fatalf("Uninstall() error = %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("unit files left after uninstall: %v", entries)
+	}
+	// Uninstalling again is fine
+	if err := Uninstall(); err != nil {
+		t.Errorf("second Uninstall() error = %v", err)
+	}
+}
+
+func TestGetStatus(t *testing.T) {
+	fake, _ := setupSystemd(t)
+
+	st, err := GetStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Installed {
+		t.Error("Installed = true before install")
+	}
+
+	if err := Install(Options{Binary: "/usr/bin/claude-notifications", Socket: true}); err != nil {
+		t.Fatal(err)
+	}
+	fake.states["is-enabled claude-notifications.socket"] = "enabled"
+	fake.states["is-active claude-notifications.socket"] = "active"
+	fake.states["is-active claude-notifications.service"] = "inactive"
+
+	st, err = GetStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.Installed || !st.Socket || !st.Enabled || !st.Active {
+		t.Errorf("GetStatus() = %+v, want installed, socket, enabled, active", st)
+	}
+	if st.Detail != "listening, daemon starts on first notification" {
+		t.Errorf("Detail = %q", st.Detail)
+	}
+	if len(st.Files) != 2 {
+		t.Errorf("Files = %v, want both units", st.Files)
+	}
+}
+
+func TestInstalled(t *testing.T) {
+	fake, _ := setupSystemd(t)
+
+	if installed, _ := Installed(); installed {
+		t.Error("Installed() = true before install")
+	}
+	if err := Install(Options{Binary: "/usr/bin/claude-notifications"}); err != nil {
+		t.Fatal(err)
+	}
+	if installed, socket := Installed(); !installed || socket {
+		t.Errorf("Installed() = %v, %v, want true, false", installed, socket)
+	}
+	if err := Install(Options{Binary: "/usr/bin/claude-notifications", Socket: true}); err != nil {
+		t.Fatal(err)
+	}
+	fake.calls = nil
+	if installed, socket := Installed(); !installed || !socket {
+		t.Errorf("Installed() = %v, %v, want true, true", installed, socket)
+	}
+	if len(fake.calls) != 0 {
+		t.Errorf("Installed() ran systemctl %v", fake.calls)
+	}
+}
+
+func TestStopNotInstalled(t *testing.T) {
+	setupSystemd(t)
+	if err := Stop(); err == nil || !strings.Contains(err.Error(), "not installed") {
+		t.Errorf("Stop() error = %v, want not installed", err)
+	}
+}
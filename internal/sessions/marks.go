@@ -0,0 +1,111 @@
+package sessions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Marks records the last time of something per session in one small file
+// next to the sessions directory: when it last notified the user
+// (NewNotified) and when the user last acknowledged it (NewAcks). The
+// session files are rewritten by the hooks and the daemon, so these are
+// kept apart, and they are small enough for a shell prompt to read.
+type Marks struct {
+	path string
+}
+
+// NewNotified creates the marks of the last notification of each session,
+// kept in dir
+func NewNotified(dir string) *Marks {
+	return &Marks{path: filepath.Join(dir, "session-notified.json")}
+}
+
+// NewAcks creates the marks of the last acknowledgement of each session's
+// notifications (focus, ack, or a click on Linux), kept in dir
+func NewAcks(dir string) *Marks {
+	return &Marks{path: filepath.Join(dir, "session-acks.json")}
+}
+
+// Mark records the time of a session. Marks of sessions gone stale are
+// dropped on the way.
+func (m *Marks) Mark(id string, now time.Time) error {
+	marks, err := m.Load()
+	if err != nil {
+		return err
+	}
+	for other, at := range marks {
+		if now.Sub(at) > StaleAfter {
+			delete(marks, other)
+		}
+	}
+	marks[id] = now
+
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return fmt.Errorf("failed to create session marks directory: %w", err)
+	}
+	data, err := json.Marshal(marks)
+	if err != nil {
+		return fmt.Errorf("failed to serialize session marks: %w", err)
+	}
+	tmp := fmt.Sprintf("%s.%d.tmp", m.path, os.Getpid())
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write session marks: %w", err)
+	}
+	if err := os.Rename(tmp, m.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write session marks: %w", err)
+	}
+	return nil
+}
+
+// Load returns the time marked per session ID
+func (m *Marks) Load() (map[string]time.Time, error) {
+	marks := make(map[string]time.Time)
+	data, err := os.ReadFile(m.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return marks, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session marks: %w", err)
+	}
+	if err := json.Unmarshal(data, &marks); err != nil {
+		return nil, fmt.Errorf("failed to parse session marks: %w", err)
+	}
+	return marks, nil
+}
+
+// Pending returns the idle sessions notified (notified holds the last
+// notification per session ID) since they were last acknowledged (acked,
+// likewise), oldest notification first. A session the user replied to is
+// working again, so it is no longer pending.
+func Pending(active []Session, notified, acked map[string]time.Time) []Session {
+	var pending []Session
+	for _, s := range active {
+		if n := notified[s.ID]; !s.Working() && !n.IsZero() && n.After(acked[s.ID]) {
+			pending = append(pending, s)
+		}
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		return notified[pending[i].ID].Before(notified[pending[j].ID])
+	})
+	return pending
+}
+
+// Next returns the pending session after the one with ID last, wrapping
+// around, or the first when last is not pending (false = none pending)
+func Next(pending []Session, last string) (Session, bool) {
+	if len(pending) == 0 {
+		return Session{}, false
+	}
+	for i, s := range pending {
+		if s.ID == last {
+			return pending[(i+1)%len(pending)], true
+		}
+	}
+	return pending[0], true
+}
@@ -0,0 +1,68 @@
+package sessions
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMarks(t *testing.T) {
+	dir := t.TempDir()
+	m := NewAcks(dir)
+
+	marks, err := m.Load()
+	if err != nil || len(marks) != 0 {
+		t.Fatalf("Load(new) = %v, %v, want empty", marks, err)
+	}
+	if err := m.Mark("old", base); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Mark("new", base.Add(StaleAfter+time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	marks, err = m.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(marks) != 1 || !marks["new"].Equal(base.Add(StaleAfter+time.Hour)) {
+		t.Errorf("Load() = %v, want only the new mark", marks)
+	}
+	if marks, _ := NewNotified(dir).Load(); len(marks) != 0 {
+		t.Errorf("NewNotified().Load() = %v, want its own empty file", marks)
+	}
+}
+
+func TestPendingAndNext(t *testing.T) {
+	active := []Session{
+		{ID: "later"},
+		{ID: "working", PromptAt: base},
+		{ID: "acked"},
+		{ID: "first"},
+		{ID: "quiet"},
+	}
+	notified := map[string]time.Time{
+		"later":   base.Add(2 * time.Minute),
+		"working": base,
+		"acked":   base,
+		"first":   base.Add(time.Minute),
+	}
+	acked := map[string]time.Time{"acked": base.Add(time.Second), "first": base}
+
+	pending := Pending(active, notified, acked)
+	if len(pending) != 2 || pending[0].ID != "first" || pending[1].ID != "later" {
+		t.Fatalf("Pending() = %+v, want first then later", pending)
+	}
+
+	for _, tt := range []struct{ last, want string }{
+		{"", "first"},
+		{"first", "later"},
+		{"later", "first"},
+		{"gone", "first"},
+	} {
+		if s, ok := Next(pending, tt.last); !ok || s.ID != tt.want {
+			t.Errorf("Next(%q) = %s, %v, want %s", tt.last, s.ID, ok, tt.want)
+		}
+	}
+	if _, ok := Next(nil, ""); ok {
+		t.Error("Next(none pending) = true")
+	}
+}
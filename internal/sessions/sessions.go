@@ -0,0 +1,285 @@
+// Package sessions tracks running Claude Code sessions between the
+// SessionStart and SessionEnd hooks, one JSON file per session, so
+// notifications can say how long a session ran and
+// "claude-notifications sessions" can list the active ones.
+package sessions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	dirName = "sessions"
+
+	// StaleAfter is how long a session may go without hook activity before
+	// it is treated as ended (Claude Code exited without SessionEnd)
+	StaleAfter = 24 * time.Hour
+)
+
+// Session is a running Claude Code session
+type Session struct {
+	ID           string    `json:"id"`
+	CWD          string    `json:"cwd"`                   // Project directory
+	Terminal     string    `json:"terminal,omitempty"`    // Terminal the session runs in, e.g. "iTerm.app"
+	Window       *Window   `json:"window,omitempty"`      // Window the session started in (nil = not pinned)
+	TerminalPID  int       `json:"terminalPid,omitempty"` // Process of the terminal emulator or IDE (0 = unknown)
+	TmuxPane     string    `json:"tmuxPane,omitempty"`    // tmux pane the session runs in, e.g. "%42"
+	TmuxSocket   string    `json:"tmuxSocket,omitempty"`  // Socket of that pane's tmux server
+	StartedAt    time.Time `json:"startedAt"`
+	LastActivity time.Time `json:"lastActivity"`         // Last hook event from the session
+	PromptAt     time.Time `json:"promptAt,omitempty"`   // When the prompt being worked on was submitted (zero = idle)
+	Transcript   string    `json:"transcript,omitempty"` // Transcript of the session, as of the last prompt
+	ClaudePID    int       `json:"claudePid,omitempty"`  // Claude Code process, as of the last prompt (0 = unknown)
+	Progress     *Progress `json:"progress,omitempty"`   // Progress on the prompt (nil = none reported yet)
+}
+
+// Progress is how far a session got with its prompt, from its tool calls
+// and todo list
+type Progress struct {
+	Tools     int       `json:"tools"`               // Tool calls since the prompt
+	LastTool  string    `json:"lastTool,omitempty"`  // Name of the last tool called
+	Done      int       `json:"done,omitempty"`      // Todo items completed
+	Total     int       `json:"total,omitempty"`     // Todo items (0 = no todo list)
+	Step      string    `json:"step,omitempty"`      // Todo item in progress
+	ShownAt   time.Time `json:"shownAt,omitempty"`   // Last progress notification (zero = none)
+	ShownStep string    `json:"shownStep,omitempty"` // Step of the last progress notification
+}
+
+// Window identifies the desktop window a session runs in, so a click can
+// focus that window rather than any window of the same terminal
+type Window struct {
+	Backend string `json:"backend"`         // Where ID is valid: "hyprland", "sway", "kde", "gnome" or "x11"
+	ID      string `json:"id"`              // Window ID in that backend
+	Title   string `json:"title,omitempty"` // Title when captured
+	Class   string `json:"class,omitempty"` // WM_CLASS or Wayland app_id
+}
+
+// Project returns the project folder name
+func (s *Session) Project() string {
+	if s.CWD == "" {
+		return ""
+	}
+	return filepath.Base(s.CWD)
+}
+
+// Working reports whether the session is working on a prompt
+func (s *Session) Working() bool {
+	return !s.PromptAt.IsZero()
+}
+
+// Registry stores sessions as files in a directory
+type Registry struct {
+	dir string
+}
+
+// NewRegistry creates a registry storing sessions under dir/sessions
+func NewRegistry(dir string) *Registry {
+	return &Registry{dir: filepath.Join(dir, dirName)}
+}
+
+// Start records a session start. A session that is already registered
+// (resumed, cleared or compacted) keeps its original start time.
+func (r *Registry) Start(id, cwd, terminal string, now time.Time) error {
+	s, err := r.Get(id)
+	if err != nil {
+		return err
+	}
+	if s == nil || now.Sub(s.LastActivity) > StaleAfter {
+		s = &Session{ID: id, StartedAt: now}
+	}
+	s.CWD = cwd
+	s.Terminal = terminal
+	s.LastActivity = now
+	return r.save(s)
+}
+
+// Touch updates the last activity of a registered session; unknown
+// sessions are ignored
+func (r *Registry) Touch(id string, now time.Time) error {
+	s, err := r.Get(id)
+	if err != nil || s == nil {
+		return err
+	}
+	s.LastActivity = now
+	return r.save(s)
+}
+
+// Prompt records that a registered session started working on a prompt;
+// unknown sessions are ignored
+func (r *Registry) Prompt(id, transcript string, claudePID int, now time.Time) error {
+	s, err := r.Get(id)
+	if err != nil || s == nil {
+		return err
+	}
+	if transcript != "" {
+		s.Transcript = transcript
+	}
+	if claudePID != 0 {
+		s.ClaudePID = claudePID
+	}
+	s.Progress = nil
+	s.PromptAt = now
+	s.LastActivity = now
+	return r.save(s)
+}
+
+// Done records that a registered session finished its prompt; unknown
+// sessions are ignored
+func (r *Registry) Done(id string, now time.Time) error {
+	s, err := r.Get(id)
+	if err != nil || s == nil {
+		return err
+	}
+	s.PromptAt = time.Time{}
+	s.Progress = nil
+	s.LastActivity = now
+	return r.save(s)
+}
+
+// Pin records the window a registered session runs in (nil = unpin);
+// unknown sessions are ignored
+func (r *Registry) Pin(id string, w *Window) error {
+	s, err := r.Get(id)
+	if err != nil || s == nil {
+		return err
+	}
+	s.Window = w
+	return r.save(s)
+}
+
+// Put stores a session as given, replacing any registered one
+func (r *Registry) Put(s *Session) error {
+	return r.save(s)
+}
+
+// End removes a session
+func (r *Registry) End(id string) error {
+	err := os.Remove(r.path(id))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove session: %w", err)
+	}
+	return nil
+}
+
+// Get returns a session, or nil if it is not registered
+func (r *Registry) Get(id string) (*Session, error) {
+	data, err := os.ReadFile(r.path(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse session: %w", err)
+	}
+	return &s, nil
+}
+
+// Active returns sessions with hook activity within StaleAfter, oldest
+// first, and removes stale ones
+func (r *Registry) Active(now time.Time) ([]Session, error) {
+	files, err := filepath.Glob(filepath.Join(r.dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	var active []Session
+	for _, file := range files {
+		id := strings.TrimSuffix(filepath.Base(file), ".json")
+		s, err := r.Get(id)
+		if err != nil || s == nil {
+			continue
+		}
+		if now.Sub(s.LastActivity) > StaleAfter {
+			_ = r.End(id)
+			continue
+		}
+		active = append(active, *s)
+	}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].StartedAt.Before(active[j].StartedAt)
+	})
+	return active, nil
+}
+
+// save writes a session file
+func (r *Registry) save(s *Session) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize session: %w", err)
+	}
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create sessions directory: %w", err)
+	}
+	if err := os.WriteFile(r.path(s.ID), data, 0644); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return nil
+}
+
+// path returns the file for a session. Path separators in the ID are
+// replaced so a malformed ID cannot escape the directory.
+func (r *Registry) path(id string) string {
+	safe := strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(id)
+	return filepath.Join(r.dir, safe+".json")
+}
+
+// ByAttention orders sessions by how much they need the user: idle sessions
+// before working ones, then the most recently notified (notified holds the
+// last notification per session ID), then the most recently active
+func ByAttention(active []Session, notified map[string]time.Time) []Session {
+	sorted := append([]Session(nil), active...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.Working() != b.Working() {
+			return !a.Working()
+		}
+		if na, nb := notified[a.ID], notified[b.ID]; !na.Equal(nb) {
+			return na.After(nb)
+		}
+		return a.LastActivity.After(b.LastActivity)
+	})
+	return sorted
+}
+
+// Within returns the sessions working in root or a directory below it
+func Within(active []Session, root string) []Session {
+	root = filepath.Clean(root)
+	var within []Session
+	for _, s := range active {
+		if s.CWD == "" {
+			continue
+		}
+		rel, err := filepath.Rel(root, filepath.Clean(s.CWD))
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			within = append(within, s)
+		}
+	}
+	return within
+}
+
+// FormatDuration formats a session duration compactly, e.g. "45s",
+// "12m34s" or "1h05m"
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	s := int(d.Seconds()) % 60
+	switch {
+	case h > 0:
+		return fmt.Sprintf("%dh%02dm", h, m)
+	case m > 0:
+		return fmt.Sprintf("%dm%02ds", m, s)
+	default:
+		return fmt.Sprintf("%ds", s)
+	}
+}
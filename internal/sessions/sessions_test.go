@@ -0,0 +1,255 @@
+package sessions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var base = time.Date(2026, 3, 13, 9, 0, 0, 0, time.Local)
+
+func TestStartAndEnd(t *testing.T) {
+	r := NewRegistry(t.TempDir())
+
+	if err := r.Start("abc", "/work/api", "iTerm.app", base); err != nil {
+		t.Fatal(err)
+	}
+	s, err := r.Get("abc")
+	if err != nil || s == nil {
+		t.Fatalf("Get after Start = %v, %v", s, err)
+	}
+	if s.Project() != "api" || s.Terminal != "iTerm.app" || !s.StartedAt.Equal(base) {
+		t.Errorf("session = %+v", s)
+	}
+
+	if err := r.End("abc"); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := r.Get("abc"); s != nil {
+		t.Error("session should be removed after End")
+	}
+	if err := r.End("abc"); err != nil {
+		t.Errorf("ending an unknown session should not fail: %v", err)
+	}
+}
+
+func TestStartKeepsOriginalStartOnResume(t *testing.T) {
+	r := NewRegistry(t.TempDir())
+
+	r.Start("abc", "/work/api", "kitty", base)
+	r.Start("abc", "/work/api", "kitty", base.Add(time.Hour))
+
+	s, _ := r.Get("abc")
+	if !s.StartedAt.Equal(base) {
+		t.Errorf("StartedAt = %v, want original start %v", s.StartedAt, base)
+	}
+	if !s.LastActivity.Equal(base.Add(time.Hour)) {
+		t.Errorf("LastActivity = %v", s.LastActivity)
+	}
+
+	// A stale entry is replaced by a fresh session
+	r.Start("abc", "/work/api", "kitty", base.Add(StaleAfter+2*time.Hour))
+	s, _ = r.Get("abc")
+	if !s.StartedAt.Equal(base.Add(StaleAfter + 2*time.Hour)) {
+		t.Errorf("stale session should restart, StartedAt = %v", s.StartedAt)
+	}
+}
+
+func TestTouch(t *testing.T) {
+	r := NewRegistry(t.TempDir())
+
+	if err := r.Touch("unknown", base); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := r.Get("unknown"); s != nil {
+		t.Error("Touch should not register unknown sessions")
+	}
+
+	r.Start("abc", "/work/api", "", base)
+	r.Touch("abc", base.Add(5*time.Minute))
+	if s, _ := r.Get("abc"); !s.LastActivity.Equal(base.Add(5 * time.Minute)) {
+		t.Errorf("LastActivity = %v", s.LastActivity)
+	}
+}
+
+func TestPromptAndDone(t *testing.T) {
+	r := NewRegistry(t.TempDir())
+
+	if err := r.Prompt("abc", "/t/abc.jsonl", 4242, base); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := r.Get("abc"); s != nil {
+		t.Fatal("Prompt should not register an unknown session")
+	}
+
+	r.Start("abc", "/work/api", "code", base)
+	if err := r.Prompt("abc", "/t/abc.jsonl", 4242, base); err != nil {
+		t.Fatal(err)
+	}
+	s, _ := r.Get("abc")
+	if s == nil || !s.Working() || s.Transcript != "/t/abc.jsonl" || s.ClaudePID != 4242 {
+		t.Fatalf("after Prompt: %+v", s)
+	}
+
+	later := base.Add(10 * time.Minute)
+	if err := r.Prompt("abc", "", 0, later); err != nil {
+		t.Fatal(err)
+	}
+	s, _ = r.Get("abc")
+	if !s.PromptAt.Equal(later) || !s.StartedAt.Equal(base) || s.Transcript != "/t/abc.jsonl" || s.ClaudePID != 4242 {
+		t.Errorf("after second Prompt: %+v", s)
+	}
+
+	if err := r.Done("abc", later.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	s, _ = r.Get("abc")
+	if s.Working() || !s.LastActivity.Equal(later.Add(time.Minute)) {
+		t.Errorf("after Done: %+v", s)
+	}
+	if err := r.Done("unknown", later); err != nil {
+		t.Errorf("Done(unknown) = %v", err)
+	}
+}
+
+func TestPin(t *testing.T) {
+	r := NewRegistry(t.TempDir())
+	w := &Window{Backend: "sway", ID: "42", Title: "api — Visual Studio Code", Class: "code"}
+
+	if err := r.Pin("abc", w); err != nil {
+		t.Errorf("pinning an unknown session should not fail: %v", err)
+	}
+	if s, _ := r.Get("abc"); s != nil {
+		t.Error("Pin should not register an unknown session")
+	}
+
+	r.Start("abc", "/work/api", "code", base)
+	if err := r.Pin("abc", w); err != nil {
+		t.Fatal(err)
+	}
+	s, _ := r.Get("abc")
+	if s.Window == nil || *s.Window != *w {
+		t.Errorf("window = %+v, want %+v", s.Window, w)
+	}
+
+	// A resumed session keeps its window until it is pinned again
+	r.Start("abc", "/work/api", "code", base.Add(time.Minute))
+	if s, _ := r.Get("abc"); s.Window == nil {
+		t.Error("Start should keep the pinned window")
+	}
+	r.Pin("abc", nil)
+	if s, _ := r.Get("abc"); s.Window != nil {
+		t.Errorf("window = %+v after unpinning", s.Window)
+	}
+}
+
+func TestPut(t *testing.T) {
+	r := NewRegistry(t.TempDir())
+	want := &Session{ID: "abc", CWD: "/work/api", TerminalPID: 4242, TmuxPane: "%3", StartedAt: base, LastActivity: base}
+	if err := r.Put(want); err != nil {
+		t.Fatal(err)
+	}
+	s, err := r.Get("abc")
+	if err != nil || s == nil || s.TerminalPID != 4242 || s.TmuxPane != "%3" {
+		t.Errorf("Get after Put = %+v, %v", s, err)
+	}
+
+	// Start on resume keeps what the daemon recorded
+	r.Start("abc", "/work/api", "kitty", base.Add(time.Minute))
+	if s, _ := r.Get("abc"); s.TerminalPID != 4242 {
+		t.Errorf("Start should keep the terminal process, got %+v", s)
+	}
+}
+
+func TestActive(t *testing.T) {
+	dir := t.TempDir()
+	r := NewRegistry(dir)
+
+	r.Start("new", "/work/web", "", base.Add(time.Hour))
+	r.Start("old", "/work/api", "", base)
+	r.Start("stale", "/work/cli", "", base.Add(-48*time.Hour))
+
+	active, err := r.Active(base.Add(2 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(active) != 2 || active[0].ID != "old" || active[1].ID != "new" {
+		t.Fatalf("Active = %+v, want old then new", active)
+	}
+	if _, err := os.Stat(filepath.Join(dir, dirName, "stale.json")); !os.IsNotExist(err) {
+		t.Error("stale session file should be removed")
+	}
+}
+
+func TestWithin(t *testing.T) {
+	active := []Session{
+		{ID: "root", CWD: "/work/api"},
+		{ID: "sub", CWD: "/work/api/internal/db"},
+		{ID: "sibling", CWD: "/work/api-v2"},
+		{ID: "other", CWD: "/work/web"},
+		{ID: "unknown"},
+	}
+	var ids []string
+	for _, s := range Within(active, "/work/api/") {
+		ids = append(ids, s.ID)
+	}
+	if got := strings.Join(ids, ","); got != "root,sub" {
+		t.Errorf("Within(/work/api) = %s, want root,sub", got)
+	}
+}
+
+func TestPathCannotEscapeDirectory(t *testing.T) {
+	dir := t.TempDir()
+	r := NewRegistry(dir)
+
+	if err := r.Start("../../evil", "/work", "", base); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, dirName, "____evil.json")); err != nil {
+		t.Errorf("session file should stay in the sessions directory: %v", err)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{45 * time.Second, "45s"},
+		{12*time.Minute + 34*time.Second, "12m34s"},
+		{time.Hour + 5*time.Minute + 20*time.Second, "1h05m"},
+		{1500 * time.Millisecond, "2s"},
+	}
+	for _, tt := range tests {
+		if got := FormatDuration(tt.d); got != tt.want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestByAttention(t *testing.T) {
+	active := []Session{
+		{ID: "working", PromptAt: base, LastActivity: base.Add(5 * time.Minute)},
+		{ID: "quiet", LastActivity: base.Add(4 * time.Minute)},
+		{ID: "asked", LastActivity: base.Add(time.Minute)},
+		{ID: "done", LastActivity: base.Add(2 * time.Minute)},
+	}
+	notified := map[string]time.Time{
+		"working": base.Add(5 * time.Minute),
+		"asked":   base.Add(time.Minute),
+		"done":    base.Add(2 * time.Minute),
+	}
+
+	var got []string
+	for _, s := range ByAttention(active, notified) {
+		got = append(got, s.ID)
+	}
+	if want := "done asked quiet working"; strings.Join(got, " ") != want {
+		t.Errorf("ByAttention() = %v, want %s", got, want)
+	}
+	if active[0].ID != "working" {
+		t.Error("ByAttention() reordered its argument")
+	}
+}
@@ -0,0 +1,336 @@
+// Package setup is the first-run wizard of "claude-notifications init": it
+// detects where it runs, asks which backends to enable and writes the
+// answers to the user config file.
+package setup
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/daemon"
+	"github.com/777genius/claude-notifications/internal/doctor"
+	"github.com/777genius/claude-notifications/internal/platform"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Environment is what the wizard detects before asking anything
+type Environment struct {
+	OS         string          // runtime.GOOS
+	Desktop    string          // Desktop environment or compositor ("" = unknown)
+	Display    string          // Linux display server: "wayland" or "x11" ("" = unknown)
+	Terminal   string          // Terminal running the wizard
+	FocusTools map[string]bool // Focus tools and whether they are installed
+}
+
+// Detect inspects the platform, desktop, terminal and focus tools
+func Detect() Environment {
+	env := Environment{
+		OS:         runtime.GOOS,
+		Terminal:   daemon.GetTerminalName(),
+		FocusTools: daemon.DetectFocusTools(),
+	}
+	if env.OS != "linux" {
+		return env
+	}
+	switch {
+	case os.Getenv("HYPRLAND_INSTANCE_SIGNATURE") != "":
+		env.Desktop = "Hyprland"
+	case os.Getenv("SWAYSOCK") != "":
+		env.Desktop = "Sway"
+	default:
+		env.Desktop = os.Getenv("XDG_CURRENT_DESKTOP")
+	}
+	switch {
+	case os.Getenv("XDG_SESSION_TYPE") == "wayland" || os.Getenv("WAYLAND_DISPLAY") != "":
+		env.Display = "wayland"
+	case os.Getenv("DISPLAY") != "":
+		env.Display = "x11"
+	}
+	return env
+}
+
+// Describe names the platform, e.g. "Linux, GNOME on wayland" or "macOS"
+func (e Environment) Describe() string {
+	switch e.OS {
+	case "darwin":
+		return "macOS"
+	case "windows":
+		return "Windows"
+	case "linux":
+	default:
+		return e.OS
+	}
+	desktop := e.Desktop
+	if desktop == "" {
+		desktop = "no desktop detected"
+	}
+	if e.Display != "" {
+		desktop += " on " + e.Display
+	}
+	return "Linux, " + desktop
+}
+
+// CanFocus reports whether a focus tool is installed, so clicking a
+// notification can bring the terminal back
+func (e Environment) CanFocus() bool {
+	return doctor.CheckFocusTools(e.FocusTools).Status == doctor.StatusOK
+}
+
+// Prompter asks questions on a terminal. With Defaults set it asks nothing
+// and takes every default, for scripted setups.
+type Prompter struct {
+	in       *bufio.Reader
+	out      io.Writer
+	Defaults bool
+}
+
+// NewPrompter creates a prompter reading answers from in
+func NewPrompter(in io.Reader, out io.Writer, defaults bool) *Prompter {
+	return &Prompter{in: bufio.NewReader(in), out: out, Defaults: defaults}
+}
+
+// readLine returns the next answer, trimmed; ok is false at the end of input
+func (p *Prompter) readLine() (string, bool) {
+	line, err := p.in.ReadString('\n')
+	if err != nil && line == "" {
+		return "", false
+	}
+	return strings.TrimSpace(line), true
+}
+
+// Ask returns the answer to a question, or def when it is left empty
+func (p *Prompter) Ask(question, def string) string {
+	if p.Defaults {
+		return def
+	}
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+	answer, ok := p.readLine()
+	if !ok {
+		fmt.Fprintln(p.out)
+	}
+	if answer == "" {
+		return def
+	}
+	return answer
+}
+
+// Confirm asks a yes/no question until it gets one of them
+func (p *Prompter) Confirm(question string, def bool) bool {
+	if p.Defaults {
+		return def
+	}
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	for {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, hint)
+		answer, ok := p.readLine()
+		if !ok {
+			fmt.Fprintln(p.out)
+			return def
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		}
+		fmt.Fprintln(p.out, "  Please answer y or n.")
+	}
+}
+
+// Choose asks for one of options until it gets one, or a unique prefix of one
+func (p *Prompter) Choose(question string, options []string, def string) string {
+	if p.Defaults {
+		return def
+	}
+	for {
+		fmt.Fprintf(p.out, "%s (%s) [%s]: ", question, strings.Join(options, ", "), def)
+		answer, ok := p.readLine()
+		if !ok {
+			fmt.Fprintln(p.out)
+			return def
+		}
+		if answer == "" {
+			return def
+		}
+		var matches []string
+		for _, o := range options {
+			if o == strings.ToLower(answer) {
+				return o
+			}
+			if strings.HasPrefix(o, strings.ToLower(answer)) {
+				matches = append(matches, o)
+			}
+		}
+		if len(matches) == 1 {
+			return matches[0]
+		}
+		fmt.Fprintf(p.out, "  Please choose one of: %s\n", strings.Join(options, ", "))
+	}
+}
+
+// webhookPresets are the webhook services offered, "none" first
+var webhookPresets = []string{"none", "ntfy", "gotify", "matrix", "slack", "discord", "telegram", "pushover", "lark", "custom"}
+
+// Answers are the choices made in the wizard
+type Answers struct {
+	Desktop      bool
+	Sound        bool
+	ClickToFocus bool
+	Webhook      *config.WebhookConfig // nil = no webhook
+}
+
+// Interview asks which backends to enable. Click-to-focus is only offered
+// where a focus tool is installed, or on macOS, where terminal-notifier
+// focuses the terminal itself.
+func Interview(p *Prompter, env Environment) Answers {
+	var a Answers
+	a.Desktop = p.Confirm("Show desktop notifications?", true)
+	if a.Desktop {
+		a.Sound = p.Confirm("Play a sound with them?", true)
+		if env.OS == "darwin" || env.CanFocus() {
+			a.ClickToFocus = p.Confirm("Focus the terminal when a notification is clicked?", true)
+		}
+	}
+
+	preset := p.Choose("Also send notifications to your phone or chat", webhookPresets, "none")
+	if preset != "none" {
+		w := askWebhook(p, preset)
+		if w == nil {
+			fmt.Fprintf(p.out, "  Skipping %s: its settings are required.\n", preset)
+		}
+		a.Webhook = w
+	}
+	return a
+}
+
+// askWebhook asks for the settings a webhook preset requires (nil = left out)
+func askWebhook(p *Prompter, preset string) *config.WebhookConfig {
+	w := &config.WebhookConfig{Enabled: true, Preset: preset}
+	switch preset {
+	case "ntfy":
+		server := strings.TrimRight(p.Ask("ntfy server", "https://ntfy.sh"), "/")
+		// Topics on ntfy.sh are public: anyone knowing the name can read them
+		topic := p.Ask("ntfy topic (subscribe to it in the ntfy app)", "claude-"+platform.RandomSuffix())
+		w.URL = server + "/" + topic
+	case "telegram":
+		w.Telegram.BotToken = p.Ask("Telegram bot token (from @BotFather)", "")
+		w.ChatID = p.Ask("Telegram chat ID", "")
+		if w.Telegram.BotToken == "" || w.ChatID == "" {
+			return nil
+		}
+		return w
+	case "gotify":
+		w.URL = p.Ask("Gotify server URL, e.g. https://gotify.example.com", "")
+		w.Gotify.Token = p.Ask("Gotify application token (Apps → Create application)", "")
+		if w.URL == "" || w.Gotify.Token == "" {
+			return nil
+		}
+		return w
+	case "matrix":
+		w.URL = p.Ask("Matrix homeserver URL", "https://matrix.org")
+		w.Matrix.AccessToken = p.Ask("Access token of the posting account (Settings → Help & About)", "")
+		w.Matrix.RoomID = p.Ask("Room ID, e.g. !abc:example.org (Room settings → Advanced)", "")
+		if w.Matrix.AccessToken == "" || !strings.HasPrefix(w.Matrix.RoomID, "!") {
+			return nil
+		}
+		return w
+	case "pushover":
+		w.Pushover.UserKey = p.Ask("Pushover user key", "")
+		w.Pushover.AppToken = p.Ask("Pushover application token", "")
+		if w.Pushover.UserKey == "" || w.Pushover.AppToken == "" {
+			return nil
+		}
+		return w
+	default:
+		w.URL = p.Ask(fmt.Sprintf("%s webhook URL", preset), "")
+	}
+	if w.URL == "" {
+		return nil
+	}
+	return w
+}
+
+// Values returns the config keys the answers set, nested like config.json
+func (a Answers) Values() map[string]interface{} {
+	desktop := map[string]interface{}{"enabled": a.Desktop}
+	if a.Desktop {
+		desktop["sound"] = a.Sound
+		desktop["clickToFocus"] = a.ClickToFocus
+	}
+	notifications := map[string]interface{}{"desktop": desktop}
+
+	if w := a.Webhook; w != nil {
+		webhook := map[string]interface{}{"enabled": true, "preset": w.Preset}
+		if w.URL != "" {
+			webhook["url"] = w.URL
+		}
+		if w.ChatID != "" {
+			webhook["chat_id"] = w.ChatID
+		}
+		if w.Telegram.BotToken != "" {
+			webhook["telegram"] = map[string]interface{}{"botToken": w.Telegram.BotToken}
+		}
+		if w.Gotify.Token != "" {
+			webhook["gotify"] = map[string]interface{}{"token": w.Gotify.Token}
+		}
+		if w.Matrix.AccessToken != "" {
+			webhook["matrix"] = map[string]interface{}{"accessToken": w.Matrix.AccessToken, "roomId": w.Matrix.RoomID}
+		}
+		if w.Pushover.UserKey != "" {
+			webhook["pushover"] = map[string]interface{}{"userKey": w.Pushover.UserKey, "appToken": w.Pushover.AppToken}
+		}
+		notifications["webhook"] = webhook
+	}
+	return map[string]interface{}{"notifications": notifications}
+}
+
+// WriteConfig writes values to path, as YAML for a .yaml or .yml file and
+// TOML otherwise. An existing file is kept as path.bak. The file may hold
+// tokens, so only its owner can read it.
+func WriteConfig(path string, values map[string]interface{}) error {
+	var buf bytes.Buffer
+	buf.WriteString("# Written by claude-notifications init; see README: Configuration\n")
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(values); err != nil {
+			return fmt.Errorf("failed to encode config: %w", err)
+		}
+	default:
+		if err := toml.NewEncoder(&buf).Encode(values); err != nil {
+			return fmt.Errorf("failed to encode config: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if old, err := os.ReadFile(path); err == nil {
+		if err := os.WriteFile(path+".bak", old, 0600); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	// WriteFile keeps the mode of a file that already existed
+	return os.Chmod(path, 0600)
+}
@@ -0,0 +1,215 @@
+package setup
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+func TestPrompter_Confirm(t *testing.T) {
+	tests := []struct {
+		input string
+		def   bool
+		want  bool
+	}{
+		{"y\n", false, true},
+		{"No\n", true, false},
+		{"\n", true, true},
+		{"maybe\nyes\n", false, true},
+		{"", true, true}, // end of input takes the default
+	}
+	for _, tt := range tests {
+		p := NewPrompter(strings.NewReader(tt.input), io.Discard, false)
+		if got := p.Confirm("Continue?", tt.def); got != tt.want {
+			t.Errorf("Confirm(%q, %v) = %v, want %v", tt.input, tt.def, got, tt.want)
+		}
+	}
+}
+
+func TestPrompter_Choose(t *testing.T) {
+	options := []string{"none", "ntfy", "slack"}
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"slack\n", "slack"},
+		{"SL\n", "slack"},        // unique prefix, any case
+		{"n\nnt\n", "ntfy"},      // "n" is ambiguous and asked again
+		{"teams\n\n", "none"},    // unknown, then the default
+		{"ntfy extra\n", "none"}, // unknown, then end of input
+	}
+	for _, tt := range tests {
+		p := NewPrompter(strings.NewReader(tt.input), io.Discard, false)
+		if got := p.Choose("Service", options, "none"); got != tt.want {
+			t.Errorf("Choose(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestPrompter_Defaults(t *testing.T) {
+	var out strings.Builder
+	p := NewPrompter(strings.NewReader("n\n"), &out, true)
+	if !p.Confirm("Continue?", true) || p.Ask("Name", "x") != "x" || p.Choose("Service", []string{"a", "b"}, "b") != "b" {
+		t.Error("Defaults should take every default")
+	}
+	if out.Len() != 0 {
+		t.Errorf("Defaults should not ask anything, wrote %q", out.String())
+	}
+}
+
+func TestInterview(t *testing.T) {
+	noFocus := Environment{OS: "linux", FocusTools: map[string]bool{"xdotool": false, "gdbus": true}}
+	withFocus := Environment{OS: "linux", FocusTools: map[string]bool{"xdotool": true}}
+
+	tests := []struct {
+		name  string
+		env   Environment
+		input string
+		want  Answers
+	}{
+		{
+			name:  "defaults",
+			env:   withFocus,
+			input: "",
+			want:  Answers{Desktop: true, Sound: true, ClickToFocus: true},
+		},
+		{
+			name:  "no focus tools skips click-to-focus",
+			env:   noFocus,
+			input: "y\nn\n\n",
+			want:  Answers{Desktop: true},
+		},
+		{
+			name:  "desktop off",
+			env:   withFocus,
+			input: "n\nnone\n",
+			want:  Answers{},
+		},
+		{
+			name:  "ntfy",
+			env:   noFocus,
+			input: "n\nntfy\nhttps://ntfy.example.com/\nalerts\n",
+			want: Answers{Webhook: &config.WebhookConfig{
+				Enabled: true, Preset: "ntfy", URL: "https://ntfy.example.com/alerts",
+			}},
+		},
+		{
+			name:  "slack without URL is skipped",
+			env:   noFocus,
+			input: "n\nslack\n\n",
+			want:  Answers{},
+		},
+		{
+			name:  "gotify",
+			env:   noFocus,
+			input: "n\ngotify\nhttps://push.example.com\nAbc\n",
+			want: Answers{Webhook: &config.WebhookConfig{
+				Enabled: true, Preset: "gotify", URL: "https://push.example.com",
+				Gotify: config.GotifyConfig{Token: "Abc"},
+			}},
+		},
+		{
+			name:  "matrix alias is skipped",
+			env:   noFocus,
+			input: "n\nmatrix\n\nsyt_x\n#claude:matrix.org\n",
+			want:  Answers{},
+		},
+		{
+			name:  "telegram",
+			env:   noFocus,
+			input: "n\ntelegram\n123:abc\n-100\n",
+			want: Answers{Webhook: &config.WebhookConfig{
+				Enabled: true, Preset: "telegram", ChatID: "-100",
+				Telegram: config.TelegramConfig{BotToken: "123:abc"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Interview(NewPrompter(strings.NewReader(tt.input), io.Discard, false), tt.env)
+			if got.Desktop != tt.want.Desktop || got.Sound != tt.want.Sound || got.ClickToFocus != tt.want.ClickToFocus {
+				t.Errorf("desktop answers = %+v, want %+v", got, tt.want)
+			}
+			switch {
+			case (got.Webhook == nil) != (tt.want.Webhook == nil):
+				t.Fatalf("Webhook = %+v, want %+v", got.Webhook, tt.want.Webhook)
+			case got.Webhook == nil:
+			case got.Webhook.Preset != tt.want.Webhook.Preset || got.Webhook.URL != tt.want.Webhook.URL ||
+				got.Webhook.ChatID != tt.want.Webhook.ChatID || got.Webhook.Telegram != tt.want.Webhook.Telegram ||
+				got.Webhook.Gotify.Token != tt.want.Webhook.Gotify.Token:
+				t.Errorf("Webhook = %+v, want %+v", got.Webhook, tt.want.Webhook)
+			}
+		})
+	}
+}
+
+func TestInterview_NtfyTopicDefault(t *testing.T) {
+	p := NewPrompter(strings.NewReader("n\nntfy\n"), io.Discard, false)
+	a := Interview(p, Environment{OS: "linux"})
+	if a.Webhook == nil || !strings.HasPrefix(a.Webhook.URL, "https://ntfy.sh/claude-") || len(a.Webhook.URL) != len("https://ntfy.sh/claude-")+8 {
+		t.Errorf("Webhook = %+v, want a random topic on ntfy.sh", a.Webhook)
+	}
+}
+
+func TestWriteConfig(t *testing.T) {
+	answers := Answers{
+		Desktop: true,
+		Sound:   true,
+		Webhook: &config.WebhookConfig{
+			Enabled: true, Preset: "pushover",
+			Pushover: config.PushoverConfig{UserKey: "u", AppToken: "a"},
+		},
+	}
+	for _, name := range []string{"config.toml", "config.yaml"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "claude-notifications", name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := WriteConfig(path, answers.Values()); err != nil {
+				t.Fatalf("WriteConfig: %v", err)
+			}
+			if errs := config.CheckFile(path); len(errs) != 0 {
+				t.Errorf("CheckFile: %v", errs)
+			}
+			data, _ := os.ReadFile(path)
+			for _, want := range []string{"pushover", "userKey", "appToken", "sound"} {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("config lacks %q:\n%s", want, data)
+				}
+			}
+			if old, err := os.ReadFile(path + ".bak"); err != nil || string(old) != "old" {
+				t.Errorf("backup = %q, %v; want the old file", old, err)
+			}
+			if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0600 {
+				t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+			}
+		})
+	}
+}
+
+func TestEnvironment_Describe(t *testing.T) {
+	tests := []struct {
+		env  Environment
+		want string
+	}{
+		{Environment{OS: "darwin"}, "macOS"},
+		{Environment{OS: "windows"}, "Windows"},
+		{Environment{OS: "linux", Desktop: "GNOME", Display: "wayland"}, "Linux, GNOME on wayland"},
+		{Environment{OS: "linux"}, "Linux, no desktop detected"},
+		{Environment{OS: "freebsd"}, "freebsd"},
+	}
+	for _, tt := range tests {
+		if got := tt.env.Describe(); got != tt.want {
+			t.Errorf("Describe(%+v) = %q, want %q", tt.env, got, tt.want)
+		}
+	}
+}
@@ -114,6 +114,27 @@ func FindByName(name string, available []SoundInfo) (SoundInfo, bool) {
 	return SoundInfo{}, false
 }
 
+// Resolve returns the file for a configured sound: an existing path as is,
+// or a name such as "Glass" or "message-new-instant" matched exactly (case
+// aside) against the built-in and system sounds. false when it names no
+// sound.
+func Resolve(sound, pluginRoot string) (string, bool) {
+	if _, err := os.Stat(sound); err == nil {
+		return sound, true
+	}
+	if sound == "" || strings.ContainsAny(sound, `/\`) {
+		return "", false
+	}
+	name := strings.ToLower(strings.TrimSuffix(sound, filepath.Ext(sound)))
+	available := Discover(DiscoverOptions{PluginRoot: pluginRoot, IncludeBuiltIn: true, IncludeSystem: true})
+	if s, ok := findPreferBuiltIn(available, func(s SoundInfo) bool {
+		return strings.ToLower(s.Name) == name
+	}); ok {
+		return s.Path, true
+	}
+	return "", false
+}
+
 // findPreferBuiltIn finds the first match, preferring built-in over system sources.
 func findPreferBuiltIn(available []SoundInfo, match func(SoundInfo) bool) (SoundInfo, bool) {
 	var firstNonBuiltIn *SoundInfo
@@ -254,7 +275,7 @@ func discoverLinuxSounds(maxDepth int) []SoundInfo {
 		}
 
 		ext := strings.ToLower(filepath.Ext(path))
-		if ext != ".ogg" && ext != ".wav" {
+		if ext != ".ogg" && ext != ".oga" && ext != ".wav" {
 			return nil
 		}
 
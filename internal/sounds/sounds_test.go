@@ -1,6 +1,8 @@
 package sounds
 
 import (
+	"os"
+	"path/filepath"
 	"runtime"
 	"testing"
 )
@@ -290,3 +292,25 @@ func TestDescriptions(t *testing.T) {
 		}
 	}
 }
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	custom := filepath.Join(dir, "ding.wav")
+	if err := os.WriteFile(custom, []byte("RIFF"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := Resolve(custom, ""); !ok || got != custom {
+		t.Errorf("Resolve(path) = %q, %v", got, ok)
+	}
+
+	// Built-in sounds by name, case aside
+	if got, ok := Resolve("Task-Complete", ""); !ok || filepath.Base(got) != "task-complete.mp3" {
+		t.Errorf("Resolve(name) = %q, %v", got, ok)
+	}
+
+	for _, missing := range []string{"", "/nope/ding.wav", "no-such-sound-anywhere"} {
+		if got, ok := Resolve(missing, dir); ok {
+			t.Errorf("Resolve(%q) = %q, want not found", missing, got)
+		}
+	}
+}
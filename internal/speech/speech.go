@@ -0,0 +1,214 @@
+// Package speech announces notifications aloud with the platform's
+// text-to-speech, for when the screen is out of sight or read by a screen
+// reader.
+package speech
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/errorhandler"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/platform"
+)
+
+// DefaultPhrase is spoken when no phrase is configured
+const DefaultPhrase = `{{.Title}}{{if .Project}} in {{.Project}}{{end}}`
+
+// speakTimeout bounds one announcement
+const speakTimeout = 30 * time.Second
+
+// lookPath finds a speech engine; replaced in tests
+var lookPath = exec.LookPath
+
+// runCommand runs a speech engine until it has finished speaking; replaced
+// in tests
+var runCommand = func(ctx context.Context, args []string) error {
+	output, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed: %w, output: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Speaker speaks notifications, one at a time
+type Speaker struct {
+	cfg         config.SpeechConfig
+	phrase      *template.Template
+	templateErr error
+
+	mu sync.Mutex // Announcements do not talk over each other
+	wg sync.WaitGroup
+}
+
+// New creates a speaker. Template errors are reported by Speak.
+func New(cfg *config.Config) *Speaker {
+	s := &Speaker{cfg: cfg.Notifications.Speech}
+	phrase := s.cfg.Phrase
+	if phrase == "" {
+		phrase = DefaultPhrase
+	}
+	if s.phrase, s.templateErr = config.ParseContentTemplate("phrase", phrase); s.templateErr != nil {
+		s.templateErr = fmt.Errorf("invalid speech phrase template: %w", s.templateErr)
+		logging.Error("%v", s.templateErr)
+	}
+	return s
+}
+
+// Speak renders the phrase for data and speaks it with the first speech
+// engine that is installed, returning when it has finished
+func (s *Speaker) Speak(data config.ContentData) error {
+	if s.templateErr != nil {
+		return s.templateErr
+	}
+	var b strings.Builder
+	if err := s.phrase.Execute(&b, data); err != nil {
+		return fmt.Errorf("failed to render speech phrase: %w", err)
+	}
+	text := speakable(b.String())
+	if text == "" {
+		return nil
+	}
+
+	args, err := installedCommand(speechCommands(runtime.GOOS, text, s.cfg.Voice, s.cfg.Rate))
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), speakTimeout)
+	defer cancel()
+	if err := runCommand(ctx, args); err != nil {
+		return err
+	}
+	logging.Debug("Spoke %q with %s", text, args[0])
+	return nil
+}
+
+// installedCommand returns the first command whose engine is installed
+func installedCommand(commands [][]string) ([]string, error) {
+	names := make([]string, len(commands))
+	for i, args := range commands {
+		if _, err := lookPath(args[0]); err == nil {
+			return args, nil
+		}
+		names[i] = args[0]
+	}
+	return nil, fmt.Errorf("no speech engine installed (tried %s)", strings.Join(names, ", "))
+}
+
+// SpeakAsyncWithResult speaks in the background and reports the outcome to
+// done (nil error = spoken); Shutdown waits for it. done may be nil.
+func (s *Speaker) SpeakAsyncWithResult(data config.ContentData, done func(err error)) {
+	s.wg.Add(1)
+	errorhandler.SafeGo(func() {
+		defer s.wg.Done()
+
+		err := s.Speak(data)
+		if err != nil {
+			errorhandler.HandleError(err, "Speech failed")
+		}
+		if done != nil {
+			done(err)
+		}
+	})
+}
+
+// Shutdown waits for announcements in progress to finish (with timeout)
+func (s *Speaker) Shutdown(timeout time.Duration) error {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		logging.Warn("Speech shutdown timeout, an announcement was cut short")
+		return fmt.Errorf("shutdown timeout after %v", timeout)
+	}
+}
+
+// speakable drops emoji and other symbols that engines read out by name,
+// joins lines and trims leading dashes so the text is not taken for an option
+func speakable(text string) string {
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsPunct(r) || unicode.IsSpace(r) {
+			return r
+		}
+		return -1
+	}, text)
+	text = strings.Join(strings.Fields(text), " ")
+	return strings.TrimLeft(text, "- ")
+}
+
+// speechCommands returns the commands that can speak text on goos, best
+// first. rate is in words per minute (0 = engine default).
+func speechCommands(goos, text, voice string, rate int) [][]string {
+	switch goos {
+	case "darwin":
+		args := []string{"say"}
+		if voice != "" {
+			args = append(args, "-v", voice)
+		}
+		if rate > 0 {
+			args = append(args, "-r", strconv.Itoa(rate))
+		}
+		return [][]string{append(args, text)}
+	case "windows":
+		// SAPI rate runs from -10 to 10; 0 is about 180 words per minute
+		script := "Add-Type -AssemblyName System.Speech; $s = New-Object System.Speech.Synthesis.SpeechSynthesizer; "
+		if voice != "" {
+			script += "$s.SelectVoice(" + platform.PSQuote(voice) + "); "
+		}
+		if rate > 0 {
+			script += "$s.Rate = " + strconv.Itoa(clamp((rate-180)/18, -10, 10)) + "; "
+		}
+		script += "$s.Speak(" + platform.PSQuote(text) + ")"
+		return [][]string{{"powershell", "-NoProfile", "-NonInteractive", "-Command", script}}
+	default:
+		var commands [][]string
+		for _, engine := range []string{"espeak-ng", "espeak"} {
+			args := []string{engine}
+			if voice != "" {
+				args = append(args, "-v", voice)
+			}
+			if rate > 0 {
+				args = append(args, "-s", strconv.Itoa(rate))
+			}
+			commands = append(commands, append(args, text))
+		}
+		// speech-dispatcher picks the synthesizer; its rate runs from -100 to 100
+		args := []string{"spd-say", "-w"}
+		if voice != "" {
+			args = append(args, "-y", voice)
+		}
+		if rate > 0 {
+			args = append(args, "-r", strconv.Itoa(clamp((rate-180)/2, -100, 100)))
+		}
+		return append(commands, append(args, text))
+	}
+}
+
+// clamp limits n to [lo, hi]
+func clamp(n, lo, hi int) int {
+	if n < lo {
+		return lo
+	}
+	if n > hi {
+		return hi
+	}
+	return n
+}
@@ -0,0 +1,169 @@
+package speech
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+// fakeEngines makes installed the only engines on PATH and records the
+// commands run
+func fakeEngines(t *testing.T, installed ...string) *[][]string {
+	t.Helper()
+	var ran [][]string
+	origLookPath, origRun := lookPath, runCommand
+	t.Cleanup(func() { lookPath, runCommand = origLookPath, origRun })
+	lookPath = func(name string) (string, error) {
+		for _, n := range installed {
+			if n == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+	runCommand = func(ctx context.Context, args []string) error {
+		ran = append(ran, args)
+		return nil
+	}
+	return &ran
+}
+
+func newSpeaker(speech config.SpeechConfig) *Speaker {
+	return New(&config.Config{Notifications: config.NotificationsConfig{Speech: speech}})
+}
+
+func TestSpeak_DefaultPhrase(t *testing.T) {
+	ran := fakeEngines(t, "espeak-ng", "spd-say", "say", "powershell")
+	s := newSpeaker(config.SpeechConfig{Enabled: true})
+
+	if err := s.Speak(config.ContentData{Title: "✅ Completed", Project: "foo"}); err != nil {
+		t.Fatalf("Speak() error = %v", err)
+	}
+	if len(*ran) != 1 {
+		t.Fatalf("ran %d commands, want 1", len(*ran))
+	}
+	args := (*ran)[0]
+	if got := args[len(args)-1]; got != "Completed in foo" {
+		t.Errorf("spoken text = %q, want %q", got, "Completed in foo")
+	}
+}
+
+func TestSpeak_CustomPhrase(t *testing.T) {
+	ran := fakeEngines(t, "espeak-ng", "spd-say", "say", "powershell")
+	s := newSpeaker(config.SpeechConfig{Enabled: true, Phrase: "Claude {{lower .Title}} in project {{.Project}}"})
+
+	if err := s.Speak(config.ContentData{Title: "Finished", Project: "foo"}); err != nil {
+		t.Fatalf("Speak() error = %v", err)
+	}
+	args := (*ran)[0]
+	if !strings.Contains(strings.Join(args, " "), "Claude finished in project foo") {
+		t.Errorf("command = %v, want the rendered phrase", args)
+	}
+}
+
+func TestSpeak_NoEngine(t *testing.T) {
+	ran := fakeEngines(t)
+	s := newSpeaker(config.SpeechConfig{Enabled: true})
+
+	err := s.Speak(config.ContentData{Title: "Done"})
+	if err == nil || !strings.Contains(err.Error(), "no speech engine installed") {
+		t.Errorf("Speak() error = %v, want no speech engine installed", err)
+	}
+	if len(*ran) != 0 {
+		t.Errorf("ran %v, want nothing", *ran)
+	}
+}
+
+func TestSpeak_InvalidPhrase(t *testing.T) {
+	fakeEngines(t, "espeak-ng", "spd-say", "say", "powershell")
+	s := newSpeaker(config.SpeechConfig{Enabled: true, Phrase: "{{.Title"})
+
+	if err := s.Speak(config.ContentData{Title: "Done"}); err == nil || !strings.Contains(err.Error(), "invalid speech phrase template") {
+		t.Errorf("Speak() error = %v, want invalid speech phrase template", err)
+	}
+}
+
+func TestSpeakAsyncWithResult(t *testing.T) {
+	ran := fakeEngines(t, "espeak-ng", "spd-say", "say", "powershell")
+	s := newSpeaker(config.SpeechConfig{Enabled: true})
+
+	var result error = errors.New("not called")
+	s.SpeakAsyncWithResult(config.ContentData{Title: "Done"}, func(err error) { result = err })
+	if err := s.Shutdown(time.Second); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if result != nil {
+		t.Errorf("result = %v, want nil", result)
+	}
+	if len(*ran) != 1 {
+		t.Errorf("ran %d commands, want 1", len(*ran))
+	}
+}
+
+func TestSpeakable(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"✅ Completed in foo", "Completed in foo"},
+		{"⏱️ Session Limit Reached", "Session Limit Reached"},
+		{"Line one\n  line two", "Line one line two"},
+		{"--help", "help"},
+		{"🔴", ""},
+	}
+	for _, tt := range tests {
+		if got := speakable(tt.in); got != tt.want {
+			t.Errorf("speakable(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSpeechCommands(t *testing.T) {
+	tests := []struct {
+		name  string
+		goos  string
+		voice string
+		rate  int
+		want  [][]string
+	}{
+		{"linux", "linux", "", 0, [][]string{
+			{"espeak-ng", "hi"},
+			{"espeak", "hi"},
+			{"spd-say", "-w", "hi"},
+		}},
+		{"linux voice and rate", "linux", "en-us", 200, [][]string{
+			{"espeak-ng", "-v", "en-us", "-s", "200", "hi"},
+			{"espeak", "-v", "en-us", "-s", "200", "hi"},
+			{"spd-say", "-w", "-y", "en-us", "-r", "10", "hi"},
+		}},
+		{"macOS", "darwin", "Samantha", 220, [][]string{
+			{"say", "-v", "Samantha", "-r", "220", "hi"},
+		}},
+		{"windows", "windows", "", 0, [][]string{
+			{"powershell", "-NoProfile", "-NonInteractive", "-Command",
+				"Add-Type -AssemblyName System.Speech; $s = New-Object System.Speech.Synthesis.SpeechSynthesizer; $s.Speak('hi')"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := speechCommands(tt.goos, "hi", tt.voice, tt.rate); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("speechCommands() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpeechCommands_WindowsQuoting(t *testing.T) {
+	cmds := speechCommands("windows", "it's done", "Microsoft Zira Desktop", 400)
+	script := cmds[0][len(cmds[0])-1]
+	for _, want := range []string{"$s.SelectVoice('Microsoft Zira Desktop')", "$s.Rate = 10", "$s.Speak('it''s done')"} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script %q does not contain %q", script, want)
+		}
+	}
+}
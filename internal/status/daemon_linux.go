@@ -0,0 +1,31 @@
+//go:build linux
+
+// ABOUTME: Queries the running click-to-focus daemon for the status report.
+// ABOUTME: A daemon that is not running is not started.
+package status
+
+import "github.com/777genius/claude-notifications/internal/daemon"
+
+// queryDaemon asks the running daemon for its status
+func queryDaemon() Daemon {
+	d := Daemon{Supported: true, Running: daemon.IsDaemonRunning(), Spooled: daemon.Spooled()}
+	if !d.Running {
+		return d
+	}
+	client, err := daemon.NewClient()
+	if err != nil {
+		d.Error = err.Error()
+		return d
+	}
+	st, err := client.Status()
+	if err != nil {
+		d.Error = err.Error()
+		return d
+	}
+	d.PID = st.PID
+	d.Version = st.Version
+	d.Uptime = st.Uptime
+	d.NotificationsSent = st.NotificationsSent
+	d.ActiveNotifications = st.ActiveNotifications
+	return d
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+// ABOUTME: The click-to-focus daemon only runs on Linux;
+// ABOUTME: other platforms report it as unsupported.
+package status
+
+// queryDaemon reports that there is no daemon on this platform
+func queryDaemon() Daemon {
+	return Daemon{}
+}
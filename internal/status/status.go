@@ -0,0 +1,312 @@
+// Package status summarizes the notification setup for
+// "claude-notifications status": the daemon, enabled backends, the last
+// notification, do-not-disturb, focus tools, queues and hook
+// installation. The report is printed for people or, with --json, for
+// scripts and status bars.
+package status
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/breaker"
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/daemon"
+	"github.com/777genius/claude-notifications/internal/dnd"
+	"github.com/777genius/claude-notifications/internal/doctor"
+	"github.com/777genius/claude-notifications/internal/history"
+	"github.com/777genius/claude-notifications/internal/plugin"
+	"github.com/777genius/claude-notifications/internal/sessions"
+	"github.com/777genius/claude-notifications/internal/webhook"
+)
+
+// Report is the state of the notification setup
+type Report struct {
+	Version          string          `json:"version"`
+	Healthy          bool            `json:"healthy"`  // No problems found
+	Problems         []string        `json:"problems"` // What needs attention, empty when healthy
+	Daemon           Daemon          `json:"daemon"`
+	Backends         []string        `json:"backends"` // Enabled backends: desktop, webhook, email, speech, mqtt, remote and webhooks entry names
+	LastNotification *Notification   `json:"last_notification"`
+	DND              DND             `json:"dnd"`
+	FocusTools       map[string]bool `json:"focus_tools"` // Tool name -> available (empty = no click-to-focus on this platform)
+	Queues           Queues          `json:"queues"`
+	Degraded         []Degraded      `json:"degraded"` // Backends failing since their last success
+	Hooks            Hooks           `json:"hooks"`
+}
+
+// Degraded is a backend whose recent deliveries failed
+type Degraded struct {
+	Backend   string     `json:"backend"`
+	Failures  int        `json:"failures"` // Consecutive failures
+	LastError string     `json:"last_error"`
+	Paused    bool       `json:"paused"`          // The circuit breaker is open and deliveries are skipped
+	Until     *time.Time `json:"until,omitempty"` // End of the pause; the next delivery then probes the backend
+}
+
+// Daemon describes the Linux click-to-focus daemon
+type Daemon struct {
+	Supported           bool   `json:"supported"` // The daemon runs on this platform
+	Running             bool   `json:"running"`
+	PID                 int    `json:"pid,omitempty"`
+	Version             string `json:"version,omitempty"` // Protocol version
+	Uptime              int64  `json:"uptime,omitempty"`  // Seconds
+	NotificationsSent   int    `json:"notifications_sent,omitempty"`
+	ActiveNotifications int    `json:"active_notifications,omitempty"`
+	Spooled             int    `json:"spooled,omitempty"` // Notifications nothing could show, delivered when the daemon starts
+	Error               string `json:"error,omitempty"`   // Why a running daemon could not be queried
+}
+
+// Notification is the latest entry in the notification history
+type Notification struct {
+	Time    time.Time `json:"time"`
+	Event   string    `json:"event"`
+	Project string    `json:"project,omitempty"`
+	Backend string    `json:"backend"`
+	Result  string    `json:"result"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// DND is the do-not-disturb state
+type DND struct {
+	Active bool       `json:"active"`
+	Reason string     `json:"reason,omitempty"` // "manual" or "schedule"
+	Until  *time.Time `json:"until,omitempty"`
+}
+
+// Queues counts notifications waiting to be delivered
+type Queues struct {
+	WebhookRetry int `json:"webhook_retry"` // Failed webhook deliveries to retry
+	DNDHeld      int `json:"dnd_held"`      // Held back for the do-not-disturb digest
+}
+
+// Hooks tells whether Claude Code runs the notification hooks
+type Hooks struct {
+	Installed bool   `json:"installed"`
+	Detail    string `json:"detail"`
+}
+
+// Options tell Gather where to look
+type Options struct {
+	Version    string // Binary version
+	PluginRoot string
+	Home       string // Home directory holding ~/.claude
+	CWD        string // Project directory for the project config and settings
+}
+
+// Gather collects the report. Nothing is sent and the daemon is not started.
+func Gather(opts Options, now time.Time) Report {
+	r := Report{Version: opts.Version, Problems: []string{}, Backends: []string{}, Degraded: []Degraded{}}
+
+	cfg, _ := config.LoadForProject(opts.PluginRoot, opts.CWD)
+	if err := cfg.Validate(); err != nil {
+		r.problem("config is invalid: %v", err)
+	}
+	r.Backends = enabledBackends(cfg)
+	if len(r.Backends) == 0 {
+		r.problem("every notification method is disabled")
+	}
+
+	hooks := doctor.CheckHooks(doctor.SettingsPaths(opts.Home, opts.CWD))
+	r.Hooks = Hooks{Installed: hooks.Status == doctor.StatusOK, Detail: hooks.Detail}
+	if !r.Hooks.Installed {
+		r.problem("hooks: %s", hooks.Detail)
+	}
+
+	r.Daemon = queryDaemon()
+	if r.Daemon.Spooled > 0 {
+		r.problem("%d notification(s) could not be shown and wait for the daemon to start (claude-notifications daemon)", r.Daemon.Spooled)
+	}
+
+	r.FocusTools = daemon.DetectFocusTools()
+	if focus := doctor.CheckFocusTools(r.FocusTools); focus.Status == doctor.StatusWarn && cfg.Notifications.Desktop.ClickToFocus {
+		r.problem("no focus tool found: clicking a notification cannot focus the terminal")
+	}
+
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		r.problem("cannot locate state directory: %v", err)
+		return r
+	}
+
+	mgr := dnd.NewManager(dir, cfg.Notifications.DND)
+	st := mgr.Status(now)
+	r.DND = DND{Active: st.Active, Reason: st.Reason}
+	if !st.Until.IsZero() {
+		until := st.Until
+		r.DND.Until = &until
+	}
+	r.Queues.DNDHeld, _ = mgr.Held()
+
+	if n, err := webhook.NewQueue(dir).Len(); err == nil {
+		r.Queues.WebhookRetry = n
+		if n > 0 {
+			r.problem("%d webhook deliveries failed and are waiting for a retry", n)
+		}
+	}
+
+	if cfg.IsBreakerEnabled() {
+		r.Degraded = degradedBackends(breaker.NewStore(dir, cfg.Notifications.Breaker.Settings()), r.Backends, now)
+		for _, d := range r.Degraded {
+			if d.Paused {
+				r.problem("%s is paused after %d failures in a row, until %s: %s", d.Backend, d.Failures, d.Until.Local().Format("15:04"), d.LastError)
+			}
+		}
+	}
+
+	if cfg.IsHistoryEnabled() {
+		if entries, err := history.NewStore(dir, 0).Query(history.Filter{Limit: 1}); err == nil && len(entries) > 0 {
+			e := entries[0]
+			r.LastNotification = &Notification{Time: e.Time, Event: e.Event, Project: e.Project, Backend: e.Backend, Result: e.Result, Error: e.Error}
+			if e.Result == history.ResultFailed {
+				r.problem("last delivery via %s failed: %s", e.Backend, e.Error)
+			}
+		}
+	}
+
+	r.Healthy = len(r.Problems) == 0
+	return r
+}
+
+// degradedBackends lists the enabled backends with failures recorded by
+// their circuit breakers
+func degradedBackends(store *breaker.Store, enabled []string, now time.Time) []Degraded {
+	degraded := []Degraded{}
+	states, err := store.States()
+	if err != nil {
+		return degraded
+	}
+	for _, name := range breaker.Names(states) {
+		st := states[name]
+		if !slices.Contains(enabled, name) {
+			continue
+		}
+		d := Degraded{Backend: name, Failures: st.Failures, LastError: st.LastError, Paused: st.Open(now)}
+		if d.Paused {
+			until := st.OpenUntil
+			d.Until = &until
+		}
+		degraded = append(degraded, d)
+	}
+	return degraded
+}
+
+// problem records something that needs attention
+func (r *Report) problem(format string, args ...interface{}) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+}
+
+// enabledBackends lists the backends notifications are delivered through
+func enabledBackends(cfg *config.Config) []string {
+	var backends []string
+	if cfg.IsDesktopEnabled() {
+		backends = append(backends, "desktop")
+	}
+	if cfg.IsWebhookEnabled() {
+		backends = append(backends, "webhook")
+	}
+	for i, w := range cfg.Notifications.Webhooks {
+		if w.Enabled {
+			backends = append(backends, cfg.ExtraWebhookName(i))
+		}
+	}
+	if cfg.IsEmailEnabled() {
+		backends = append(backends, "email")
+	}
+	if cfg.IsSpeechEnabled() {
+		backends = append(backends, "speech")
+	}
+	if cfg.IsMQTTEnabled() {
+		backends = append(backends, "mqtt")
+	}
+	if cfg.ArePluginsEnabled() {
+		plugins, _ := plugin.Discover(cfg)
+		for _, p := range plugins {
+			backends = append(backends, p.Name())
+		}
+	}
+	if cfg.Notifications.Remote.Enabled {
+		backends = append(backends, "remote")
+	}
+	if backends == nil {
+		return []string{}
+	}
+	return backends
+}
+
+// Print writes the report for people
+func Print(w io.Writer, r Report, now time.Time) {
+	fmt.Fprintf(w, "Version:       %s\n", r.Version)
+	fmt.Fprintf(w, "Daemon:        %s\n", describeDaemon(r.Daemon))
+	fmt.Fprintf(w, "Backends:      %s\n", orNone(strings.Join(r.Backends, ", ")))
+
+	last := "none recorded"
+	if n := r.LastNotification; n != nil {
+		last = fmt.Sprintf("%s ago, %s via %s (%s)", sessions.FormatDuration(now.Sub(n.Time)), n.Event, n.Backend, n.Result)
+		if n.Project != "" {
+			last += " in " + n.Project
+		}
+	}
+	fmt.Fprintf(w, "Last:          %s\n", last)
+
+	muted := "no"
+	if r.DND.Active {
+		muted = "yes (" + r.DND.Reason
+		if r.DND.Until != nil {
+			muted += ", until " + r.DND.Until.Local().Format("15:04")
+		}
+		muted += ")"
+	}
+	fmt.Fprintf(w, "Muted:         %s\n", muted)
+
+	fmt.Fprintf(w, "Focus tools:   %s\n", doctor.CheckFocusTools(r.FocusTools).Detail)
+	fmt.Fprintf(w, "Queues:        %d webhook retries, %d held by do-not-disturb\n", r.Queues.WebhookRetry, r.Queues.DNDHeld)
+	for i, d := range r.Degraded {
+		label := ""
+		if i == 0 {
+			label = "Degraded:"
+		}
+		state := fmt.Sprintf("%d failure(s)", d.Failures)
+		if d.Paused {
+			state += ", paused until " + d.Until.Local().Format("15:04")
+		}
+		fmt.Fprintf(w, "%-14s %s: %s, last: %s\n", label, d.Backend, state, d.LastError)
+	}
+	fmt.Fprintf(w, "Hooks:         %s\n", r.Hooks.Detail)
+
+	if r.Healthy {
+		fmt.Fprintln(w, "Health:        ok")
+		return
+	}
+	fmt.Fprintf(w, "Health:        %d problem(s)\n", len(r.Problems))
+	for _, p := range r.Problems {
+		fmt.Fprintf(w, "  ! %s\n", p)
+	}
+}
+
+// describeDaemon summarizes the daemon in one line
+func describeDaemon(d Daemon) string {
+	switch {
+	case !d.Supported:
+		return "not used on this platform"
+	case !d.Running && d.Spooled > 0:
+		return fmt.Sprintf("not running (starts with the next notification, %d spooled)", d.Spooled)
+	case !d.Running:
+		return "not running (starts with the next notification)"
+	case d.Error != "":
+		return "running, not answering: " + d.Error
+	}
+	return fmt.Sprintf("running (pid %d, up %s, %d sent, %d clickable)",
+		d.PID, sessions.FormatDuration(time.Duration(d.Uptime)*time.Second), d.NotificationsSent, d.ActiveNotifications)
+}
+
+// orNone shows an empty list
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
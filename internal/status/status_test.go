@@ -0,0 +1,202 @@
+package status
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/breaker"
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/history"
+	"github.com/777genius/claude-notifications/internal/webhook"
+)
+
+// setupHome isolates the config and state in a temporary home directory
+func setupHome(t *testing.T) (home, dir string) {
+	t.Helper()
+	home = t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	dir, err := config.GetStableConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return home, dir
+}
+
+func enablePlugin(t *testing.T, home string) {
+	t.Helper()
+	path := filepath.Join(home, ".claude", "settings.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	settings := `{"enabledPlugins":{"claude-notifications-go@claude-notifications-go":true}}`
+	if err := os.WriteFile(path, []byte(settings), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGather_Healthy(t *testing.T) {
+	home, dir := setupHome(t)
+	enablePlugin(t, home)
+	now := time.Now()
+	entry := history.Entry{Time: now.Add(-5 * time.Minute), Event: "task_complete", Project: "api", Backend: "desktop", Result: history.ResultDelivered}
+	if err := history.NewStore(dir, 0).Append(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	r := Gather(Options{Version: "1.2.3", PluginRoot: t.TempDir(), Home: home}, now)
+	if !r.Hooks.Installed {
+		t.Errorf("hooks = %+v, want installed", r.Hooks)
+	}
+	if len(r.Backends) == 0 || r.Backends[0] != "desktop" {
+		t.Errorf("backends = %v, want desktop first", r.Backends)
+	}
+	if r.LastNotification == nil || r.LastNotification.Event != "task_complete" || r.LastNotification.Project != "api" {
+		t.Errorf("last notification = %+v", r.LastNotification)
+	}
+	for _, p := range r.Problems {
+		// Focus tools depend on the machine running the tests
+		if !strings.Contains(p, "focus tool") {
+			t.Errorf("unexpected problem: %s", p)
+		}
+	}
+
+	var buf bytes.Buffer
+	Print(&buf, r, now)
+	out := buf.String()
+	for _, want := range []string{"Version:       1.2.3", "Last:          5m", "task_complete via desktop (delivered) in api", "Hooks:         plugin enabled"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestGather_Problems(t *testing.T) {
+	home, dir := setupHome(t)
+	now := time.Now()
+
+	q := webhook.NewQueue(dir)
+	if err := q.Add(webhook.Delivery{Backend: "webhook", URL: "http://127.0.0.1:1"}, errors.New("offline"), 0, now); err != nil {
+		t.Fatal(err)
+	}
+	failed := history.Entry{Time: now, Event: "question", Backend: "slack", Result: history.ResultFailed, Error: "HTTP 500"}
+	if err := history.NewStore(dir, 0).Append(failed); err != nil {
+		t.Fatal(err)
+	}
+
+	r := Gather(Options{PluginRoot: t.TempDir(), Home: home}, now)
+	if r.Healthy {
+		t.Fatal("report should not be healthy")
+	}
+	if r.Queues.WebhookRetry != 1 {
+		t.Errorf("webhook retry queue = %d, want 1", r.Queues.WebhookRetry)
+	}
+	problems := strings.Join(r.Problems, "\n")
+	for _, want := range []string{"hooks: plugin not enabled", "1 webhook deliveries failed", "last delivery via slack failed: HTTP 500"} {
+		if !strings.Contains(problems, want) {
+			t.Errorf("problems missing %q:\n%s", want, problems)
+		}
+	}
+
+	var buf bytes.Buffer
+	Print(&buf, r, now)
+	if !strings.Contains(buf.String(), "problem(s)\n  ! hooks:") {
+		t.Errorf("problems not listed:\n%s", buf.String())
+	}
+}
+
+func TestGather_Degraded(t *testing.T) {
+	home, dir := setupHome(t)
+	now := time.Now()
+	cfg := `{"notifications": {"webhook": {"enabled": true, "preset": "ntfy", "url": "https://ntfy.sh/claude-test"}}}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(cfg), 0600); err != nil {
+		t.Fatal(err)
+	}
+	store := breaker.NewStore(dir, breaker.Settings{Failures: 2, Backoff: time.Minute, MaxBackoff: time.Hour})
+	for _, backend := range []string{"webhook", "webhook", "mqtt"} {
+		if _, _, err := store.Record(backend, errors.New("HTTP 503"), now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := Gather(Options{PluginRoot: t.TempDir(), Home: home}, now)
+	if len(r.Degraded) != 1 {
+		t.Fatalf("degraded = %+v, want only the enabled webhook", r.Degraded)
+	}
+	if d := r.Degraded[0]; d.Backend != "webhook" || d.Failures != 2 || !d.Paused || d.Until == nil || d.LastError != "HTTP 503" {
+		t.Errorf("degraded = %+v", d)
+	}
+	if problems := strings.Join(r.Problems, "\n"); !strings.Contains(problems, "webhook is paused after 2 failures in a row") {
+		t.Errorf("problems = %s", problems)
+	}
+
+	var buf bytes.Buffer
+	Print(&buf, r, now)
+	if !strings.Contains(buf.String(), "Degraded:      webhook: 2 failure(s), paused until") {
+		t.Errorf("degraded backend not listed:\n%s", buf.String())
+	}
+}
+
+func TestReportJSON(t *testing.T) {
+	home, _ := setupHome(t)
+	r := Gather(Options{Version: "1.2.3", PluginRoot: t.TempDir(), Home: home}, time.Now())
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"version", "healthy", "problems", "daemon", "backends", "last_notification", "dnd", "focus_tools", "queues", "degraded", "hooks"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("JSON is missing %q: %s", key, data)
+		}
+	}
+	if _, ok := decoded["problems"].([]interface{}); !ok {
+		t.Errorf("problems should be a list, got %v", decoded["problems"])
+	}
+}
+
+func TestEnabledBackends(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Notifications.Desktop.Enabled = false
+	if got := enabledBackends(cfg); got == nil || len(got) != 0 {
+		t.Errorf("enabledBackends() = %#v, want empty list", got)
+	}
+
+	cfg.Notifications.Desktop.Enabled = true
+	cfg.Notifications.Email.Enabled = true
+	cfg.Notifications.Webhooks = []config.WebhookConfig{{Name: "ntfy", Enabled: true}, {Name: "off"}}
+	got := strings.Join(enabledBackends(cfg), ",")
+	if got != "desktop,ntfy,email" {
+		t.Errorf("enabledBackends() = %s, want desktop,ntfy,email", got)
+	}
+}
+
+func TestDescribeDaemon(t *testing.T) {
+	tests := []struct {
+		d    Daemon
+		want string
+	}{
+		{Daemon{}, "not used on this platform"},
+		{Daemon{Supported: true}, "not running"},
+		{Daemon{Supported: true, Spooled: 2}, "not running (starts with the next notification, 2 spooled)"},
+		{Daemon{Supported: true, Running: true, PID: 42, Uptime: 3600, NotificationsSent: 3}, "running (pid 42, up 1h"},
+	}
+	for _, tt := range tests {
+		if got := describeDaemon(tt.d); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("describeDaemon(%+v) = %q, want prefix %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Package statusbar renders the state of the running sessions for status
+// bars: a JSON line for a Waybar custom module, a Polybar line with click
+// actions, a tmux status-line segment, or plain text for i3blocks and the like.
+package statusbar
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/sessions"
+)
+
+// Formats are the output formats of Render
+var Formats = []string{"text", "waybar", "polybar", "tmux"}
+
+// spinner frames shown while sessions are working, one per refresh
+var spinner = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Session is a running session as the bar shows it
+type Session struct {
+	Project string
+	Working bool      // Working on a prompt
+	Pending bool      // Idle with a notification the user has not acknowledged
+	Since   time.Time // Prompt submitted (working), last notification (pending) or last activity
+}
+
+// State is what the bar shows
+type State struct {
+	Sessions []Session
+	Muted    bool // Do-not-disturb is on
+}
+
+// Counts returns the number of working and pending sessions
+func (s State) Counts() (working, pending int) {
+	for _, sess := range s.Sessions {
+		if sess.Working {
+			working++
+		}
+		if sess.Pending {
+			pending++
+		}
+	}
+	return working, pending
+}
+
+// Class names the state for styling: "pending" when a session waits for
+// the user, else "working", else "idle" ("muted" is added during do-not-disturb)
+func (s State) Class() string {
+	working, pending := s.Counts()
+	class := "idle"
+	switch {
+	case pending > 0:
+		class = "pending"
+	case working > 0:
+		class = "working"
+	}
+	return class
+}
+
+// Text is the bar label: "🔔 2 ⠋ 1" for two sessions waiting and one
+// working, the spinner showing frame; empty without sessions so bars can
+// hide the module
+func (s State) Text(frame int) string {
+	if len(s.Sessions) == 0 {
+		return ""
+	}
+	working, pending := s.Counts()
+	var parts []string
+	if s.Muted {
+		parts = append(parts, "🔕")
+	}
+	if pending > 0 {
+		parts = append(parts, fmt.Sprintf("🔔 %d", pending))
+	}
+	if working > 0 {
+		parts = append(parts, fmt.Sprintf("%s %d", spinner[frame%len(spinner)], working))
+	}
+	if working == 0 && pending == 0 {
+		parts = append(parts, fmt.Sprintf("✓ %d", len(s.Sessions)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Tmux is a compact segment for the tmux status line: "3 running / 1
+// waiting", the waiting count highlighted; empty without sessions
+func (s State) Tmux() string {
+	if len(s.Sessions) == 0 {
+		return ""
+	}
+	working, pending := s.Counts()
+	var parts []string
+	if working > 0 {
+		parts = append(parts, fmt.Sprintf("%d running", working))
+	}
+	if pending > 0 {
+		parts = append(parts, fmt.Sprintf("#[fg=yellow,bold]%d waiting#[default]", pending))
+	}
+	if working == 0 && pending == 0 {
+		parts = append(parts, fmt.Sprintf("%d idle", len(s.Sessions)))
+	}
+	segment := strings.Join(parts, " / ")
+	if s.Muted {
+		segment = "🔕 " + segment
+	}
+	return segment
+}
+
+// Tooltip lists the sessions, one per line: "api: waiting for you, 3m02s"
+func (s State) Tooltip(now time.Time) string {
+	var lines []string
+	for _, sess := range s.Sessions {
+		state := "idle"
+		switch {
+		case sess.Working:
+			state = "working"
+		case sess.Pending:
+			state = "waiting for you"
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s, %s", sess.Project, state, sessions.FormatDuration(now.Sub(sess.Since))))
+	}
+	if s.Muted {
+		lines = append(lines, "Do not disturb")
+	}
+	if len(lines) == 0 {
+		return "No active sessions"
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Render formats the state in one of Formats as a single line. Polybar
+// gets click actions running command: left click focus-pending, right
+// click ack.
+func Render(format string, s State, now time.Time, frame int, command string) (string, error) {
+	switch format {
+	case "text":
+		return s.Text(frame), nil
+	case "waybar":
+		class := []string{s.Class()}
+		if s.Muted {
+			class = append(class, "muted")
+		}
+		data, err := json.Marshal(struct {
+			Text    string   `json:"text"`
+			Tooltip string   `json:"tooltip"`
+			Alt     string   `json:"alt"`
+			Class   []string `json:"class"`
+		}{s.Text(frame), s.Tooltip(now), s.Class(), class})
+		return string(data), err
+	case "polybar":
+		text := s.Text(frame)
+		if text == "" {
+			return "", nil
+		}
+		action := func(button int, args, label string) string {
+			cmd := strings.NewReplacer(":", `\:`).Replace(command + " " + args)
+			return fmt.Sprintf("%%{A%d:%s:}%s%%{A}", button, cmd, label)
+		}
+		return action(1, "focus-pending", action(3, "ack", text)), nil
+	case "tmux":
+		return s.Tmux(), nil
+	}
+	return "", fmt.Errorf("unknown status bar format %q (use %s)", format, strings.Join(Formats, ", "))
+}
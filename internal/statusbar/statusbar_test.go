@@ -0,0 +1,96 @@
+package statusbar
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2026, 10, 17, 14, 0, 0, 0, time.Local)
+
+func sample() State {
+	return State{Sessions: []Session{
+		{Project: "api", Pending: true, Since: now.Add(-3 * time.Minute)},
+		{Project: "web", Working: true, Since: now.Add(-12 * time.Minute)},
+		{Project: "cli", Since: now.Add(-time.Hour)},
+	}}
+}
+
+func TestText(t *testing.T) {
+	s := sample()
+	if got := s.Text(1); got != "🔔 1 ⠙ 1" {
+		t.Errorf("Text() = %q", got)
+	}
+	s.Muted = true
+	s.Sessions = s.Sessions[2:]
+	if got := s.Text(0); got != "🔕 ✓ 1" {
+		t.Errorf("Text(idle, muted) = %q", got)
+	}
+	if got := (State{}).Text(0); got != "" {
+		t.Errorf("Text(no sessions) = %q, want empty", got)
+	}
+}
+
+func TestTmux(t *testing.T) {
+	s := sample()
+	if got := s.Tmux(); got != "1 running / #[fg=yellow,bold]1 waiting#[default]" {
+		t.Errorf("Tmux() = %q", got)
+	}
+	s.Sessions = s.Sessions[2:]
+	s.Muted = true
+	if got := s.Tmux(); got != "🔕 1 idle" {
+		t.Errorf("Tmux(idle, muted) = %q", got)
+	}
+	if got := (State{}).Tmux(); got != "" {
+		t.Errorf("Tmux(no sessions) = %q, want empty", got)
+	}
+}
+
+func TestTooltipAndClass(t *testing.T) {
+	s := sample()
+	want := "api: waiting for you, 3m00s\nweb: working, 12m00s\ncli: idle, 1h00m"
+	if got := s.Tooltip(now); got != want {
+		t.Errorf("Tooltip() = %q, want %q", got, want)
+	}
+	if s.Class() != "pending" {
+		t.Errorf("Class() = %q, want pending", s.Class())
+	}
+	s.Sessions = s.Sessions[1:]
+	if s.Class() != "working" {
+		t.Errorf("Class() = %q, want working", s.Class())
+	}
+}
+
+func TestRender(t *testing.T) {
+	s := sample()
+	s.Muted = true
+
+	out, err := Render("waybar", s, now, 0, "claude-notifications")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wb struct {
+		Text  string   `json:"text"`
+		Alt   string   `json:"alt"`
+		Class []string `json:"class"`
+	}
+	if err := json.Unmarshal([]byte(out), &wb); err != nil {
+		t.Fatalf("waybar output %q: %v", out, err)
+	}
+	if wb.Text != "🔕 🔔 1 ⠋ 1" || wb.Alt != "pending" || len(wb.Class) != 2 || wb.Class[1] != "muted" {
+		t.Errorf("waybar output = %+v", wb)
+	}
+
+	out, _ = Render("polybar", sample(), now, 0, `C:\bin\claude-notifications`)
+	want := `%{A1:C\:\bin\claude-notifications focus-pending:}%{A3:C\:\bin\claude-notifications ack:}🔔 1 ⠋ 1%{A}%{A}`
+	if out != want {
+		t.Errorf("polybar output = %q, want %q", out, want)
+	}
+	if out, _ := Render("polybar", State{}, now, 0, "x"); out != "" {
+		t.Errorf("polybar output without sessions = %q, want empty", out)
+	}
+
+	if _, err := Render("i3bar", s, now, 0, ""); err == nil {
+		t.Error("Render(unknown format) should fail")
+	}
+}
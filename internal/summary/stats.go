@@ -0,0 +1,117 @@
+package summary
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/777genius/claude-notifications/pkg/jsonl"
+)
+
+// editTools are the tools that write files, with the input naming the file
+var editTools = map[string]string{
+	"Write":        "file_path",
+	"Edit":         "file_path",
+	"MultiEdit":    "file_path",
+	"NotebookEdit": "notebook_path",
+}
+
+// Stats describes the work of a whole session, read from its transcript
+type Stats struct {
+	Active    time.Duration // From each prompt to the last response to it, summed over the prompts
+	Prompts   int
+	ToolCalls int
+	Files     int     // Distinct files written or edited
+	Tokens    int     // Input, output and cache tokens of all responses (0 = not recorded)
+	CostUSD   float64 // Cost recorded in the transcript (0 = not recorded)
+}
+
+// ComputeStats adds up the prompts, tool calls, edited files, tokens and
+// working time of a transcript. Idle time between a response and the next
+// prompt does not count, so a session left open overnight is not "10h".
+func ComputeStats(messages []jsonl.Message) Stats {
+	var s Stats
+	files := make(map[string]bool)
+	usage := make(map[string]jsonl.Usage) // Per message ID: streamed lines repeat it
+	var turnStart, lastReply time.Time
+
+	endTurn := func() {
+		if !turnStart.IsZero() && lastReply.After(turnStart) {
+			s.Active += lastReply.Sub(turnStart)
+		}
+	}
+	for i, msg := range messages {
+		ts, _ := time.Parse(time.RFC3339, msg.Timestamp)
+		if msg.IsUserPrompt() {
+			endTurn()
+			s.Prompts++
+			turnStart, lastReply = ts, time.Time{}
+			continue
+		}
+		if msg.Type != "assistant" {
+			continue
+		}
+		if !ts.IsZero() {
+			lastReply = ts
+		}
+		s.CostUSD += msg.CostUSD
+		if u := msg.Message.Usage; u != nil {
+			id := msg.Message.ID
+			if id == "" {
+				id = fmt.Sprintf("line %d", i)
+			}
+			usage[id] = *u
+		}
+		for _, c := range msg.Message.Content {
+			if c.Type != "tool_use" {
+				continue
+			}
+			s.ToolCalls++
+			if key, ok := editTools[c.Name]; ok {
+				if path, _ := c.Input[key].(string); path != "" {
+					files[path] = true
+				}
+			}
+		}
+	}
+	endTurn()
+
+	s.Files = len(files)
+	for _, u := range usage {
+		s.Tokens += u.Total()
+	}
+	return s
+}
+
+// String formats the stats in the style of the actions suffix:
+// "⏱ 1h 4m  🔧 87 tools  ✏️ 12 files  🪙 1.2M tokens  $3.41"
+func (s Stats) String() string {
+	var parts []string
+	if s.Active > 0 {
+		parts = append(parts, formatDuration(s.Active))
+	}
+	if s.ToolCalls > 0 {
+		parts = append(parts, fmt.Sprintf("🔧 %d tools", s.ToolCalls))
+	}
+	if s.Files > 0 {
+		parts = append(parts, fmt.Sprintf("✏️ %d files", s.Files))
+	}
+	if s.Tokens > 0 {
+		parts = append(parts, "🪙 "+formatCount(s.Tokens)+" tokens")
+	}
+	if s.CostUSD > 0 {
+		parts = append(parts, fmt.Sprintf("$%.2f", s.CostUSD))
+	}
+	return strings.Join(parts, "  ")
+}
+
+// formatCount shortens a count: 950, 12.3k, 1.2M
+func formatCount(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/1_000_000), ".0") + "M"
+	case n >= 1_000:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/1_000), ".0") + "k"
+	}
+	return fmt.Sprint(n)
+}
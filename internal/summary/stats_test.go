@@ -0,0 +1,96 @@
+package summary
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/pkg/jsonl"
+)
+
+// statsTranscript is two prompts an hour apart: 2 minutes of work, then 10
+func statsTranscript(start time.Time) []jsonl.Message {
+	at := func(d time.Duration) string { return start.Add(d).Format(time.RFC3339) }
+	usage := &jsonl.Usage{InputTokens: 1000, OutputTokens: 500, CacheReadInputTokens: 20000}
+	return []jsonl.Message{
+		{Type: "user", Timestamp: at(0), Message: jsonl.MessageContent{ContentString: "Fix the typo"}},
+		{Type: "assistant", Timestamp: at(time.Minute), Message: jsonl.MessageContent{ID: "msg_1", Usage: usage, Content: []jsonl.Content{
+			{Type: "tool_use", Name: "Edit", Input: map[string]interface{}{"file_path": "/p/README.md"}},
+		}}},
+		{Type: "user", Timestamp: at(time.Minute), Message: jsonl.MessageContent{Content: []jsonl.Content{{Type: "tool_result"}}}},
+		{Type: "assistant", Timestamp: at(2 * time.Minute), Message: jsonl.MessageContent{ID: "msg_2", Usage: usage, Content: []jsonl.Content{{Type: "text", Text: "Fixed."}}}},
+
+		{Type: "user", Timestamp: at(time.Hour), Message: jsonl.MessageContent{ContentString: "Refactor the parser"}},
+		// One streamed response: both lines carry the same usage
+		{Type: "assistant", Timestamp: at(time.Hour + time.Minute), Message: jsonl.MessageContent{ID: "msg_3", Usage: usage, Content: []jsonl.Content{
+			{Type: "tool_use", Name: "Edit", Input: map[string]interface{}{"file_path": "/p/parser.go"}},
+		}}},
+		{Type: "assistant", Timestamp: at(time.Hour + time.Minute), Message: jsonl.MessageContent{ID: "msg_3", Usage: usage, Content: []jsonl.Content{
+			{Type: "tool_use", Name: "Write", Input: map[string]interface{}{"file_path": "/p/README.md"}},
+			{Type: "tool_use", Name: "Bash", Input: map[string]interface{}{"command": "go test ./..."}},
+		}}},
+		{Type: "assistant", Timestamp: at(time.Hour + 10*time.Minute), CostUSD: 0.25, Message: jsonl.MessageContent{Content: []jsonl.Content{{Type: "text", Text: "Done."}}}},
+	}
+}
+
+func TestComputeStats(t *testing.T) {
+	s := ComputeStats(statsTranscript(time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)))
+
+	if s.Active != 12*time.Minute {
+		t.Errorf("Active = %v, want 12m (idle hour not counted)", s.Active)
+	}
+	if s.Prompts != 2 {
+		t.Errorf("Prompts = %d, want 2", s.Prompts)
+	}
+	if s.ToolCalls != 4 {
+		t.Errorf("ToolCalls = %d, want 4", s.ToolCalls)
+	}
+	if s.Files != 2 {
+		t.Errorf("Files = %d, want 2 (README.md edited twice)", s.Files)
+	}
+	if s.Tokens != 3*21500 {
+		t.Errorf("Tokens = %d, want %d (streamed response counted once)", s.Tokens, 3*21500)
+	}
+	if s.CostUSD != 0.25 {
+		t.Errorf("CostUSD = %v, want 0.25", s.CostUSD)
+	}
+}
+
+func TestComputeStats_Empty(t *testing.T) {
+	if s := ComputeStats(nil); s != (Stats{}) {
+		t.Errorf("ComputeStats(nil) = %+v, want zero", s)
+	}
+}
+
+func TestStatsString(t *testing.T) {
+	tests := []struct {
+		stats Stats
+		want  string
+	}{
+		{Stats{}, ""},
+		{Stats{Active: 64 * time.Minute, ToolCalls: 87, Files: 12, Tokens: 1_234_567, CostUSD: 3.41}, "⏱ 1h 4m  🔧 87 tools  ✏️ 12 files  🪙 1.2M tokens  $3.41"},
+		{Stats{Active: 2 * time.Minute, ToolCalls: 3, Tokens: 950}, "⏱ 2m  🔧 3 tools  🪙 950 tokens"},
+		{Stats{Tokens: 12_000}, "🪙 12k tokens"},
+	}
+	for _, tt := range tests {
+		if got := tt.stats.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestGenerateTaskSummary_SessionSummary(t *testing.T) {
+	messages := statsTranscript(time.Now().Add(-2 * time.Hour))
+
+	cfg := config.DefaultConfig()
+	if got := generateTaskSummary(messages, cfg); strings.Contains(got, "tokens") {
+		t.Errorf("generateTaskSummary() = %q, want the last response's actions by default", got)
+	}
+
+	cfg.Notifications.SessionSummary.Enabled = true
+	got := generateTaskSummary(messages, cfg)
+	if !strings.HasPrefix(got, "Done. ") || !strings.Contains(got, "⏱ 12m  🔧 4 tools  ✏️ 2 files") || !strings.Contains(got, "$0.25") {
+		t.Errorf("generateTaskSummary() = %q, want the session's stats", got)
+	}
+}
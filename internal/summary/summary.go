@@ -170,7 +170,7 @@ func generatePlanSummary(messages []jsonl.Message, cfg *config.Config) string {
 // generateReviewSummary generates summary for review_complete status
 // Matches bash: lib/summarizer.sh lines 494-521
 func generateReviewSummary(messages []jsonl.Message, cfg *config.Config) string {
-	actions := getActionsString(messages)
+	actions := getDoneActionsString(messages, cfg)
 
 	// Look for review-related messages from current response only
 	recentMessages := getRecentAssistantMessages(messages, ReviewMessagesWindow)
@@ -227,7 +227,7 @@ func generateTaskSummary(messages []jsonl.Message, cfg *config.Config) string {
 		lastMessage = texts[len(texts)-1]
 	}
 
-	actions := getActionsString(messages)
+	actions := getDoneActionsString(messages, cfg)
 
 	if lastMessage != "" {
 		cleaned := CleanMarkdown(lastMessage)
@@ -340,26 +340,46 @@ func extractExitPlanModePlan(messages []jsonl.Message) string {
 
 // calculateDuration calculates duration between last user and last assistant messages
 func calculateDuration(messages []jsonl.Message) string {
+	duration, ok := elapsedSinceLastPrompt(messages)
+	if !ok {
+		return ""
+	}
+	return formatDuration(duration)
+}
+
+// elapsedSinceLastPrompt returns the time between the last user and last assistant messages
+func elapsedSinceLastPrompt(messages []jsonl.Message) (time.Duration, bool) {
 	userTS := jsonl.GetLastUserTimestamp(messages)
 	assistantTS := jsonl.GetLastAssistantTimestamp(messages)
 
 	if userTS == "" || assistantTS == "" {
-		return ""
+		return 0, false
 	}
 
 	userTime, err1 := time.Parse(time.RFC3339, userTS)
 	assistantTime, err2 := time.Parse(time.RFC3339, assistantTS)
 
 	if err1 != nil || err2 != nil {
-		return ""
+		return 0, false
 	}
 
 	duration := assistantTime.Sub(userTime)
 	if duration < 0 {
-		return ""
+		return 0, false
 	}
 
-	return formatDuration(duration)
+	return duration, true
+}
+
+// ElapsedFromTranscript returns how long Claude has been working since the last
+// user prompt, or 0 when the transcript has no usable timestamps
+func ElapsedFromTranscript(transcriptPath string) time.Duration {
+	messages, err := jsonl.ParseFile(transcriptPath)
+	if err != nil {
+		return 0
+	}
+	duration, _ := elapsedSinceLastPrompt(messages)
+	return duration
 }
 
 // formatDuration formats duration into human-readable string
@@ -432,6 +452,18 @@ func getActionsString(messages []jsonl.Message) string {
 	return buildActionsString(countToolsByType(messages), calculateDuration(messages))
 }
 
+// getDoneActionsString returns the actions string of a finished task: the
+// session's stats when sessionSummary is enabled, else the last response's
+// actions
+func getDoneActionsString(messages []jsonl.Message, cfg *config.Config) string {
+	if cfg != nil && cfg.Notifications.SessionSummary.Enabled {
+		if stats := ComputeStats(messages).String(); stats != "" {
+			return stats
+		}
+	}
+	return getActionsString(messages)
+}
+
 // appendActions appends actions suffix to message if non-empty
 func appendActions(message, actions string) string {
 	if actions == "" {
@@ -686,6 +718,55 @@ func GetDefaultMessage(status analyzer.Status, cfg *config.Config) string {
 	return title
 }
 
+// GenerateToolUse generates the message for a tool_use notification:
+// "Bash: go test ./..." before the tool runs, "Bash finished: ..." after.
+// The argument is cut to its first line and at most maxLen characters.
+func GenerateToolUse(toolName, argument string, after bool, maxLen int) string {
+	msg := toolName
+	if after {
+		msg += " finished"
+	}
+	if line, _, _ := strings.Cut(strings.TrimSpace(argument), "\n"); line != "" {
+		line = strings.Join(strings.Fields(line), " ")
+		if runes := []rune(line); len(runes) > maxLen {
+			line = string(runes[:maxLen]) + "…"
+		}
+		msg += ": " + line
+	}
+	return msg
+}
+
+// LastAssistantSummary returns the first sentence of Claude's last text in
+// the current response, as one line of at most maxLen characters ("" = no
+// text or unreadable transcript)
+func LastAssistantSummary(transcriptPath string, maxLen int) string {
+	messages, err := jsonl.ParseFile(transcriptPath)
+	if err != nil {
+		return ""
+	}
+	texts := jsonl.ExtractTextFromMessages(getRecentAssistantMessages(messages, QuestionMessagesWindow))
+	for i := len(texts) - 1; i >= 0; i-- {
+		line := strings.Join(strings.Fields(CleanMarkdown(texts[i])), " ")
+		if line != "" {
+			return truncateText(extractFirstSentence(line), maxLen)
+		}
+	}
+	return ""
+}
+
+// WithTranscriptSummary appends the summary of Claude's last message to a
+// hook message: "Claude needs your permission to use Bash — I'll run the
+// migration." The message is returned unchanged when there is no summary.
+func WithTranscriptSummary(message, transcriptPath string, maxLen int) string {
+	if transcriptPath == "" {
+		return message
+	}
+	if s := LastAssistantSummary(transcriptPath, maxLen); s != "" {
+		return message + " — " + s
+	}
+	return message
+}
+
 // GenerateSimple generates a simple message based on status
 func GenerateSimple(status analyzer.Status, cfg *config.Config) string {
 	return GetDefaultMessage(status, cfg)
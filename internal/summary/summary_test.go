@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -718,6 +719,36 @@ func TestGenerateSimple(t *testing.T) {
 	}
 }
 
+func TestLastAssistantSummary(t *testing.T) {
+	transcriptPath := filepath.Join(t.TempDir(), "transcript.jsonl")
+	text := "I'll run the **migration** against\nthe staging database. Then I will seed it with fixtures."
+	writeTranscript(t, transcriptPath, buildTestTranscript([]string{"Bash"}, text, time.Now()))
+
+	want := "I'll run the migration against the staging database."
+	if got := LastAssistantSummary(transcriptPath, 120); got != want {
+		t.Errorf("LastAssistantSummary() = %q, want %q", got, want)
+	}
+	if got := LastAssistantSummary(transcriptPath, 20); len([]rune(got)) > 20 || !strings.HasSuffix(got, "...") {
+		t.Errorf("LastAssistantSummary(maxLen 20) = %q, want it truncated", got)
+	}
+	if got := LastAssistantSummary(filepath.Join(t.TempDir(), "missing.jsonl"), 120); got != "" {
+		t.Errorf("LastAssistantSummary(missing) = %q, want empty", got)
+	}
+}
+
+func TestWithTranscriptSummary(t *testing.T) {
+	transcriptPath := filepath.Join(t.TempDir(), "transcript.jsonl")
+	writeTranscript(t, transcriptPath, buildTestTranscript(nil, "Should I drop the old table first?", time.Now()))
+
+	got := WithTranscriptSummary("Claude needs your permission to use Bash", transcriptPath, 120)
+	if want := "Claude needs your permission to use Bash — Should I drop the old table first?"; got != want {
+		t.Errorf("WithTranscriptSummary() = %q, want %q", got, want)
+	}
+	if got := WithTranscriptSummary("Claude is waiting for your input", "", 120); got != "Claude is waiting for your input" {
+		t.Errorf("WithTranscriptSummary() without transcript = %q", got)
+	}
+}
+
 // === Helper functions ===
 
 func buildTestTranscript(tools []string, responseText string, timestamp time.Time) []jsonl.Message {
@@ -1175,6 +1206,30 @@ func TestCalculateDuration(t *testing.T) {
 	}
 }
 
+func TestElapsedFromTranscript(t *testing.T) {
+	now := time.Now()
+	transcriptPath := filepath.Join(t.TempDir(), "transcript.jsonl")
+	writeTranscript(t, transcriptPath, []jsonl.Message{
+		{
+			Type:      "user",
+			Timestamp: now.Add(-95 * time.Second).Format(time.RFC3339),
+			Message:   jsonl.MessageContent{Content: []jsonl.Content{{Type: "text", Text: "Refactor"}}},
+		},
+		{
+			Type:      "assistant",
+			Timestamp: now.Format(time.RFC3339),
+			Message:   jsonl.MessageContent{Content: []jsonl.Content{{Type: "text", Text: "Done"}}},
+		},
+	})
+
+	if got := ElapsedFromTranscript(transcriptPath); got != 95*time.Second {
+		t.Errorf("ElapsedFromTranscript() = %v, want 1m35s", got)
+	}
+	if got := ElapsedFromTranscript(filepath.Join(t.TempDir(), "missing.jsonl")); got != 0 {
+		t.Errorf("ElapsedFromTranscript(missing) = %v, want 0", got)
+	}
+}
+
 func TestExtractExitPlanModePlan(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -1743,3 +1798,28 @@ func TestGenerateQuestionSummary_VeryShortText(t *testing.T) {
 		t.Logf("Result: %q (should use fallback for short text)", result)
 	}
 }
+
+func TestGenerateToolUse(t *testing.T) {
+	tests := []struct {
+		name     string
+		tool     string
+		argument string
+		after    bool
+		maxLen   int
+		want     string
+	}{
+		{"before", "Bash", "go test ./...", false, 80, "Bash: go test ./..."},
+		{"after", "Bash", "go test ./...", true, 80, "Bash finished: go test ./..."},
+		{"no argument", "ExitPlanMode", "", false, 80, "ExitPlanMode"},
+		{"first line only", "Bash", "cat <<EOF\nsecret\nEOF", false, 80, "Bash: cat <<EOF"},
+		{"whitespace collapsed", "Bash", "  ls   -la  ", false, 80, "Bash: ls -la"},
+		{"truncated", "WebFetch", "https://example.com/a/long/path", false, 19, "WebFetch: https://example.com…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GenerateToolUse(tt.tool, tt.argument, tt.after, tt.maxLen); got != tt.want {
+				t.Errorf("GenerateToolUse() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
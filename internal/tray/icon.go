@@ -0,0 +1,66 @@
+package tray
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"runtime"
+)
+
+// Icon returns the tray icon for this platform from a PNG image: Windows
+// wants it in an ICO container. Without an image, a generated one is used.
+func Icon(pngData []byte) []byte {
+	if len(pngData) == 0 {
+		pngData = fallbackIcon()
+	}
+	if runtime.GOOS == "windows" {
+		return wrapICO(pngData)
+	}
+	return pngData
+}
+
+// wrapICO wraps a PNG image in an ICO file with that single image, which
+// Windows Vista and later read as is
+func wrapICO(pngData []byte) []byte {
+	width, height := 0, 0
+	if cfg, err := png.DecodeConfig(bytes.NewReader(pngData)); err == nil {
+		width, height = cfg.Width, cfg.Height
+	}
+	// A size of 0 in the directory entry means 256 or more
+	dim := func(n int) byte {
+		if n <= 0 || n >= 256 {
+			return 0
+		}
+		return byte(n)
+	}
+
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+	_ = binary.Write(&buf, le, [3]uint16{0, 1, 1}) // Reserved, type icon, one image
+	buf.Write([]byte{dim(width), dim(height), 0, 0})
+	_ = binary.Write(&buf, le, [2]uint16{1, 32})                        // Color planes, bits per pixel
+	_ = binary.Write(&buf, le, [2]uint32{uint32(len(pngData)), 6 + 16}) // Image size and offset
+	buf.Write(pngData)
+	return buf.Bytes()
+}
+
+// fallbackIcon draws a filled orange circle, for when the plugin icon
+// cannot be read
+func fallbackIcon() []byte {
+	const size = 32
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	orange := color.NRGBA{R: 0xd9, G: 0x77, B: 0x57, A: 0xff}
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dx, dy := x-size/2, y-size/2
+			if dx*dx+dy*dy <= (size/2-2)*(size/2-2) {
+				img.Set(x, y, orange)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	return buf.Bytes()
+}
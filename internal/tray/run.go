@@ -0,0 +1,170 @@
+//go:build linux || darwin || windows
+
+package tray
+
+import (
+	"sync"
+	"time"
+
+	"fyne.io/systray"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/sessions"
+)
+
+// Run shows the tray icon and blocks until Quit is clicked. On macOS it
+// must be called from the main goroutine.
+func Run(opts Options) error {
+	if opts.Refresh <= 0 {
+		opts.Refresh = DefaultRefresh
+	}
+	t := &tray{opts: opts}
+	systray.Run(t.ready, nil)
+	return nil
+}
+
+// tray is the menu of a running tray icon
+type tray struct {
+	opts Options
+
+	mu       sync.Mutex
+	state    State
+	last     *systray.MenuItem
+	count    *systray.MenuItem
+	daemon   *systray.MenuItem
+	dnd      *systray.MenuItem
+	focus    *systray.MenuItem
+	slots    []*systray.MenuItem
+	history  *systray.MenuItem
+	quit     *systray.MenuItem
+	sessions []sessions.Session // Sessions shown in the focus slots
+}
+
+// ready builds the menu once the tray is up and keeps it current
+func (t *tray) ready() {
+	systray.SetIcon(Icon(t.opts.Icon))
+	systray.SetTooltip("Claude Code")
+
+	t.count = systray.AddMenuItem("", "Active Claude Code sessions")
+	t.count.Disable()
+	t.last = systray.AddMenuItem("", "The last notification delivered")
+	t.last.Disable()
+	t.daemon = systray.AddMenuItem("", "The notification daemon")
+	t.daemon.Disable()
+	systray.AddSeparator()
+	t.dnd = systray.AddMenuItemCheckbox("Do Not Disturb", "Hold notifications back until turned off", false)
+	t.focus = systray.AddMenuItem("Focus Session", "Bring the terminal of a session to the front")
+	for i := 0; i < focusSlots; i++ {
+		slot := t.focus.AddSubMenuItem("", "")
+		slot.Hide()
+		t.slots = append(t.slots, slot)
+		go t.onFocus(i, slot)
+	}
+	t.history = systray.AddMenuItem("Open History", "Open the notification history file")
+	if t.opts.Actions.OpenHistory == nil {
+		t.history.Hide()
+	}
+	systray.AddSeparator()
+	t.quit = systray.AddMenuItem("Quit", "Close the tray icon")
+
+	t.refresh()
+	go t.loop()
+}
+
+// loop handles the menu clicks and refreshes the state periodically
+func (t *tray) loop() {
+	ticker := time.NewTicker(t.opts.Refresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.refresh()
+		case <-t.dnd.ClickedCh:
+			t.mu.Lock()
+			on := !t.state.DND.Active
+			t.mu.Unlock()
+			if err := t.opts.Actions.ToggleDND(on); err != nil {
+				logging.Error("Tray: failed to turn do-not-disturb %s: %v", onOff(on), err)
+			}
+			t.refresh()
+		case <-t.history.ClickedCh:
+			if err := t.opts.Actions.OpenHistory(); err != nil {
+				logging.Error("Tray: failed to open history: %v", err)
+			}
+		case <-t.quit.ClickedCh:
+			systray.Quit()
+			return
+		}
+	}
+}
+
+// onFocus focuses the session shown in slot i whenever it is clicked
+func (t *tray) onFocus(i int, slot *systray.MenuItem) {
+	for range slot.ClickedCh {
+		t.mu.Lock()
+		shown := i < len(t.sessions)
+		var s sessions.Session
+		if shown {
+			s = t.sessions[i]
+		}
+		t.mu.Unlock()
+		if !shown {
+			continue
+		}
+		if err := t.opts.Actions.Focus(s); err != nil {
+			logging.Error("Tray: failed to focus session %s: %v", s.ID, err)
+		}
+	}
+}
+
+// refresh reloads the state and updates the menu
+func (t *tray) refresh() {
+	now := time.Now()
+	st := t.opts.Source.Load(now)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.state = st
+
+	systray.SetTitle(st.Title())
+	systray.SetTooltip(st.Tooltip())
+	t.count.SetTitle(st.SessionsLabel())
+	t.last.SetTitle(st.LastLabel(now))
+	if label := st.DaemonLabel(); label != "" {
+		t.daemon.SetTitle(label)
+		t.daemon.Show()
+	} else {
+		t.daemon.Hide()
+	}
+	t.dnd.SetTitle(st.DNDLabel())
+	if st.DND.Active {
+		t.dnd.Check()
+	} else {
+		t.dnd.Uncheck()
+	}
+
+	// The newest sessions fill the fixed slots; the rest are left out
+	t.sessions = t.sessions[:0]
+	for i := len(st.Sessions) - 1; i >= 0 && len(t.sessions) < focusSlots; i-- {
+		t.sessions = append(t.sessions, st.Sessions[i])
+	}
+	for i, slot := range t.slots {
+		if i < len(t.sessions) {
+			slot.SetTitle(SessionLabel(t.sessions[i], now))
+			slot.Show()
+		} else {
+			slot.Hide()
+		}
+	}
+	if len(t.sessions) == 0 {
+		t.focus.Disable()
+	} else {
+		t.focus.Enable()
+	}
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !windows
+
+package tray
+
+import "fmt"
+
+// Run is not supported outside Linux, macOS and Windows
+func Run(opts Options) error {
+	return fmt.Errorf("the tray icon is only available on Linux, macOS and Windows")
+}
@@ -0,0 +1,172 @@
+// Package tray shows claude-notifications in the menu bar or system tray:
+// the number of active sessions, the last notification and the
+// do-not-disturb state, with menu items to toggle do-not-disturb, open the
+// history, focus a session and quit. It reads the same files as the
+// sessions, history and dnd commands, so it works with or without the
+// daemon.
+package tray
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/dnd"
+	"github.com/777genius/claude-notifications/internal/history"
+	"github.com/777genius/claude-notifications/internal/sessionname"
+	"github.com/777genius/claude-notifications/internal/sessions"
+)
+
+const (
+	// DefaultRefresh is how often the tray reloads its state
+	DefaultRefresh = 5 * time.Second
+
+	// historyScan is how many of the newest history entries are searched
+	// for the last delivered notification
+	historyScan = 20
+
+	// focusSlots is how many sessions the focus menu lists, newest first
+	focusSlots = 10
+)
+
+// Options configure the tray
+type Options struct {
+	Source  Source
+	Actions Actions
+	Icon    []byte        // PNG image (nil = a generated icon)
+	Refresh time.Duration // How often the state is reloaded (0 = DefaultRefresh)
+}
+
+// Actions are run by the menu items
+type Actions struct {
+	ToggleDND   func(on bool) error
+	OpenHistory func() error // nil = history disabled, the item is hidden
+	Focus       func(s sessions.Session) error
+}
+
+// Source is where the tray reads its state from
+type Source struct {
+	Sessions *sessions.Registry
+	History  *history.Store // nil = history disabled
+	DND      *dnd.Manager
+	Daemon   func() bool // Reports whether the daemon runs (nil = no daemon on this platform)
+}
+
+// State is what the tray shows
+type State struct {
+	Sessions []sessions.Session // Active sessions, oldest first
+	Last     *history.Entry     // Last delivered notification (nil = none)
+	DND      dnd.Status
+	Daemon   *bool // Whether the daemon runs (nil = no daemon on this platform)
+}
+
+// Load reads the state at now. What cannot be read is left out, so the
+// tray keeps showing the rest.
+func (src Source) Load(now time.Time) State {
+	var st State
+	if src.Sessions != nil {
+		st.Sessions, _ = src.Sessions.Active(now)
+	}
+	if src.History != nil {
+		entries, _ := src.History.Query(history.Filter{Limit: historyScan})
+		for i := len(entries) - 1; i >= 0; i-- {
+			if entries[i].Result == history.ResultDelivered {
+				st.Last = &entries[i]
+				break
+			}
+		}
+	}
+	if src.DND != nil {
+		st.DND = src.DND.Status(now)
+	}
+	if src.Daemon != nil {
+		running := src.Daemon()
+		st.Daemon = &running
+	}
+	return st
+}
+
+// Title is shown next to the icon where the tray allows text (macOS): the
+// number of active sessions, or nothing when there are none
+func (st State) Title() string {
+	if len(st.Sessions) == 0 {
+		return ""
+	}
+	return fmt.Sprint(len(st.Sessions))
+}
+
+// Tooltip summarizes the state: "Claude Code: 2 active sessions, do-not-disturb on"
+func (st State) Tooltip() string {
+	tip := "Claude Code: " + st.SessionsLabel()
+	if st.DND.Active {
+		tip += ", do-not-disturb on"
+	}
+	return tip
+}
+
+// SessionsLabel counts the active sessions: "No active sessions", "1 active session"
+func (st State) SessionsLabel() string {
+	switch len(st.Sessions) {
+	case 0:
+		return "No active sessions"
+	case 1:
+		return "1 active session"
+	}
+	return fmt.Sprintf("%d active sessions", len(st.Sessions))
+}
+
+// LastLabel describes the last notification: "Last: Task Complete in api, 3m ago"
+func (st State) LastLabel(now time.Time) string {
+	if st.Last == nil {
+		return "No notifications yet"
+	}
+	label := "Last: " + st.Last.Title
+	if st.Last.Project != "" {
+		label += " in " + st.Last.Project
+	}
+	return label + ", " + ago(now.Sub(st.Last.Time))
+}
+
+// DNDLabel describes the do-not-disturb state for its menu item
+func (st State) DNDLabel() string {
+	switch {
+	case !st.DND.Active:
+		return "Do Not Disturb"
+	case st.DND.Until.IsZero():
+		return "Do Not Disturb (on)"
+	}
+	return "Do Not Disturb (until " + st.DND.Until.Format("15:04") + ")"
+}
+
+// DaemonLabel describes the daemon for its menu item ("" = no daemon on
+// this platform)
+func (st State) DaemonLabel() string {
+	switch {
+	case st.Daemon == nil:
+		return ""
+	case *st.Daemon:
+		return "Daemon: running"
+	}
+	return "Daemon: stopped"
+}
+
+// SessionLabel names a session in the focus menu: "api — bold-cat, running 1h05m"
+func SessionLabel(s sessions.Session, now time.Time) string {
+	project := s.Project()
+	if project == "" {
+		project = "unknown project"
+	}
+	return fmt.Sprintf("%s — %s, running %s", project, sessionname.GenerateSessionLabel(s.ID), sessions.FormatDuration(now.Sub(s.StartedAt)))
+}
+
+// ago formats how long ago something happened: "just now", "3m ago", "2h ago"
+func ago(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+}
@@ -0,0 +1,118 @@
+package tray
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/dnd"
+	"github.com/777genius/claude-notifications/internal/history"
+	"github.com/777genius/claude-notifications/internal/sessions"
+)
+
+func TestSourceLoad(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	src := Source{
+		Sessions: sessions.NewRegistry(dir),
+		History:  history.NewStore(dir, 0),
+		DND:      dnd.NewManager(dir, config.DNDConfig{}),
+	}
+
+	st := src.Load(now)
+	if len(st.Sessions) != 0 || st.Last != nil || st.DND.Active || st.Daemon != nil {
+		t.Fatalf("Load(empty) = %+v, want nothing", st)
+	}
+	if st.Title() != "" || st.Tooltip() != "Claude Code: No active sessions" || st.LastLabel(now) != "No notifications yet" {
+		t.Errorf("empty labels: %q, %q, %q", st.Title(), st.Tooltip(), st.LastLabel(now))
+	}
+
+	for _, id := range []string{"s1", "s2"} {
+		if err := src.Sessions.Start(id, "/work/api", "kitty", now.Add(-time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries := []history.Entry{
+		{Time: now.Add(-3 * time.Minute), Title: "✅ Task Complete", Project: "api", Result: history.ResultDelivered},
+		{Time: now.Add(-time.Minute), Title: "❓ Question", Project: "api", Result: history.ResultFailed},
+	}
+	for _, e := range entries {
+		if err := src.History.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := src.DND.On(time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	src.Daemon = func() bool { return true }
+
+	st = src.Load(now)
+	if got := st.Title(); got != "2" {
+		t.Errorf("Title() = %q, want 2", got)
+	}
+	if got := st.Tooltip(); got != "Claude Code: 2 active sessions, do-not-disturb on" {
+		t.Errorf("Tooltip() = %q", got)
+	}
+	// The failed delivery is not the last notification
+	if got := st.LastLabel(now); got != "Last: ✅ Task Complete in api, 3m ago" {
+		t.Errorf("LastLabel() = %q", got)
+	}
+	if got := st.DNDLabel(); got != "Do Not Disturb (on)" {
+		t.Errorf("DNDLabel() = %q", got)
+	}
+	if got := st.DaemonLabel(); got != "Daemon: running" {
+		t.Errorf("DaemonLabel() = %q", got)
+	}
+}
+
+func TestSessionLabel(t *testing.T) {
+	now := time.Now()
+	s := sessions.Session{ID: "abc", CWD: "/work/api", StartedAt: now.Add(-65 * time.Minute)}
+	got := SessionLabel(s, now)
+	if !strings.HasPrefix(got, "api — ") || !strings.HasSuffix(got, ", running "+sessions.FormatDuration(65*time.Minute)) {
+		t.Errorf("SessionLabel() = %q", got)
+	}
+}
+
+func TestAgo(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{10 * time.Second, "just now"},
+		{3 * time.Minute, "3m ago"},
+		{2*time.Hour + 30*time.Minute, "2h ago"},
+		{50 * time.Hour, "2d ago"},
+	}
+	for _, tt := range tests {
+		if got := ago(tt.d); got != tt.want {
+			t.Errorf("ago(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestWrapICO(t *testing.T) {
+	img := fallbackIcon()
+	if _, err := png.Decode(bytes.NewReader(img)); err != nil {
+		t.Fatalf("fallback icon is not a PNG: %v", err)
+	}
+
+	ico := wrapICO(img)
+	var header [3]uint16
+	if err := binary.Read(bytes.NewReader(ico), binary.LittleEndian, &header); err != nil {
+		t.Fatal(err)
+	}
+	if header != [3]uint16{0, 1, 1} {
+		t.Errorf("ICO header = %v, want one icon image", header)
+	}
+	if ico[6] != 32 || ico[7] != 32 {
+		t.Errorf("ICO size = %dx%d, want 32x32", ico[6], ico[7])
+	}
+	if !bytes.Equal(ico[22:], img) {
+		t.Error("ICO does not hold the PNG after its directory")
+	}
+}
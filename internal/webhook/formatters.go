@@ -2,42 +2,99 @@ package webhook
 
 import (
 	"fmt"
+	"html"
+	"strings"
 	"time"
 
 	"github.com/777genius/claude-notifications/internal/analyzer"
 	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/priority"
 )
 
 // Formatter interface for different webhook formats
 type Formatter interface {
-	Format(status analyzer.Status, message, sessionID string, statusInfo config.StatusInfo) (interface{}, error)
+	Format(status analyzer.Status, message, sessionID string, statusInfo config.StatusInfo, meta Meta) (interface{}, error)
 }
 
-// SlackFormatter formats messages for Slack
-type SlackFormatter struct{}
+// Meta carries per-notification details that presets may render
+// separately from the message text
+type Meta struct {
+	Project string        // Folder name of the session's working directory (may be empty)
+	Elapsed time.Duration // Time Claude worked since the last prompt (0 = unknown)
+	Title   string        // Overrides the status title, e.g. from a rule ("" = status title)
+	Repo    string        // Git repository name ("" outside git repos)
+	Branch  string        // Git branch ("" outside git repos or on a detached HEAD)
 
-func (f *SlackFormatter) Format(status analyzer.Status, message, sessionID string, statusInfo config.StatusInfo) (interface{}, error) {
+	// Priority is "low", "normal" or "critical" from rules or do-not-disturb
+	// ("" = by status); formatters map it to their service's priority
+	Priority string
+}
+
+// SlackFormatter formats messages for Slack with Block Kit inside a colored attachment
+type SlackFormatter struct {
+	Channel  string            // Default channel (chat.postMessage, legacy webhooks)
+	Channels map[string]string // Project folder name -> channel
+	Mention  string            // Mentioned in critical notifications: "here", "channel", or a user or group ID ("" = nobody)
+}
+
+func (f *SlackFormatter) Format(status analyzer.Status, message, sessionID string, statusInfo config.StatusInfo, meta Meta) (interface{}, error) {
 	color := getColorForStatus(status)
 
-	return map[string]interface{}{
+	contextElements := make([]map[string]interface{}, 0, 4)
+	if meta.Project != "" {
+		contextElements = append(contextElements, slackMrkdwn(fmt.Sprintf("*Project:* %s", slackEscape(meta.Project))))
+	}
+	contextElements = append(contextElements, slackMrkdwn(fmt.Sprintf("*Event:* `%s`", status)))
+	if meta.Elapsed > 0 {
+		contextElements = append(contextElements, slackMrkdwn(fmt.Sprintf("*Elapsed:* %s", meta.Elapsed.Round(time.Second))))
+	}
+	contextElements = append(contextElements, slackMrkdwn(fmt.Sprintf("Session: %s", slackEscape(sessionID))))
+
+	blocks := []map[string]interface{}{
+		{
+			"type": "header",
+			"text": map[string]interface{}{
+				"type":  "plain_text",
+				"text":  truncateRunes(statusInfo.Title, slackHeaderMaxLength),
+				"emoji": true,
+			},
+		},
+		{
+			"type": "section",
+			"text": slackMrkdwn(slackEscape(truncateRunes(message, slackSectionMaxLength))),
+		},
+		{
+			"type":     "context",
+			"elements": contextElements,
+		},
+	}
+
+	// Top-level text is the fallback shown in push notifications and screen
+	// readers; mentions only notify from there
+	text := fmt.Sprintf("%s: %s", statusInfo.Title, slackEscape(message))
+	if mention := slackMention(f.Mention); mention != "" && priority.Resolve(status, meta.Priority) == priority.Critical {
+		text = mention + " " + text
+	}
+
+	payload := map[string]interface{}{
+		"text": text,
 		"attachments": []map[string]interface{}{
 			{
-				"color":       color,
-				"title":       statusInfo.Title,
-				"text":        message,
-				"footer":      fmt.Sprintf("Session: %s | Claude Notifications", sessionID),
-				"footer_icon": "https://claude.ai/favicon.ico",
-				"ts":          time.Now().Unix(),
-				"mrkdwn_in":   []string{"text"},
+				"color":  color,
+				"blocks": blocks,
 			},
 		},
-	}, nil
+	}
+	if channel := slackChannel(f.Channel, f.Channels, meta.Project); channel != "" {
+		payload["channel"] = channel
+	}
+	return payload, nil
 }
 
 // DiscordFormatter formats messages for Discord with embeds
 type DiscordFormatter struct{}
 
-func (f *DiscordFormatter) Format(status analyzer.Status, message, sessionID string, statusInfo config.StatusInfo) (interface{}, error) {
+func (f *DiscordFormatter) Format(status analyzer.Status, message, sessionID string, statusInfo config.StatusInfo, meta Meta) (interface{}, error) {
 	colorInt := getDiscordColorInt(status)
 
 	return map[string]interface{}{
@@ -56,22 +113,56 @@ func (f *DiscordFormatter) Format(status analyzer.Status, message, sessionID str
 	}, nil
 }
 
+// defaultTelegramPreviewLength is the message preview length when not configured
+const defaultTelegramPreviewLength = 300
+
 // TelegramFormatter formats messages for Telegram with HTML
 type TelegramFormatter struct {
-	ChatID string
+	ChatID        string
+	PreviewLength int // Max runes of the message preview (0 = defaultTelegramPreviewLength)
 }
 
-func (f *TelegramFormatter) Format(status analyzer.Status, message, sessionID string, statusInfo config.StatusInfo) (interface{}, error) {
-	// HTML formatting for Telegram
-	emoji := getEmojiForStatus(status)
-	text := fmt.Sprintf("<b>%s %s</b>\n\n%s\n\n<i>Session: %s</i>",
-		emoji, statusInfo.Title, message, sessionID)
+func (f *TelegramFormatter) Format(status analyzer.Status, message, sessionID string, statusInfo config.StatusInfo, meta Meta) (interface{}, error) {
+	previewLength := f.PreviewLength
+	if previewLength <= 0 {
+		previewLength = defaultTelegramPreviewLength
+	}
 
-	return map[string]interface{}{
-		"chat_id":    f.ChatID,
-		"text":       text,
-		"parse_mode": "HTML",
-	}, nil
+	// HTML formatting for Telegram; user text is escaped so "<" in a message
+	// doesn't make the Bot API reject the whole request
+	title := statusInfo.Title
+	if emoji := getEmojiForStatus(status); !strings.HasPrefix(title, emoji) {
+		title = emoji + " " + title
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "<b>%s</b>\n", html.EscapeString(title))
+	if meta.Project != "" {
+		fmt.Fprintf(&b, "📁 <b>%s</b> · ", html.EscapeString(meta.Project))
+	}
+	fmt.Fprintf(&b, "<code>%s</code>\n\n", html.EscapeString(string(status)))
+	fmt.Fprintf(&b, "%s\n\n", html.EscapeString(truncateRunes(message, previewLength)))
+	fmt.Fprintf(&b, "<i>Session: %s</i>", html.EscapeString(sessionID))
+
+	payload := map[string]interface{}{
+		"chat_id":                  f.ChatID,
+		"text":                     b.String(),
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	}
+	// Low priority arrives without a sound
+	if meta.Priority == priority.Low {
+		payload["disable_notification"] = true
+	}
+	return payload, nil
+}
+
+// truncateRunes shortens s to at most limit runes, ending with "…" when cut
+func truncateRunes(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return strings.TrimRight(string(runes[:limit-1]), " \n") + "…"
 }
 
 // getColorForStatus returns color hex code for status (Slack)
@@ -125,7 +216,7 @@ func getEmojiForStatus(status analyzer.Status) string {
 // LarkFormatter formats messages for Feishu/Lark with interactive cards
 type LarkFormatter struct{}
 
-func (f *LarkFormatter) Format(status analyzer.Status, message, sessionID string, statusInfo config.StatusInfo) (interface{}, error) {
+func (f *LarkFormatter) Format(status analyzer.Status, message, sessionID string, statusInfo config.StatusInfo, meta Meta) (interface{}, error) {
 	return map[string]interface{}{
 		"msg_type": "interactive",
 		"card": map[string]interface{}{
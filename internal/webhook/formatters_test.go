@@ -20,6 +20,7 @@ func TestSlackFormatterFormat(t *testing.T) {
 		"The task has been completed successfully",
 		"session-123",
 		statusInfo,
+		Meta{},
 	)
 
 	if err != nil {
@@ -45,22 +46,38 @@ func TestSlackFormatterFormat(t *testing.T) {
 		t.Errorf("Expected green color #28a745, got %v", color)
 	}
 
-	// Check title
-	title, ok := attachment["title"].(string)
-	if !ok || title != "Task Complete" {
-		t.Errorf("Expected title 'Task Complete', got %v", title)
+	// Check fallback text
+	if text := resultMap["text"]; text != "Task Complete: The task has been completed successfully" {
+		t.Errorf("Expected fallback text with title and message, got %v", text)
+	}
+
+	// Check Block Kit layout: header, message section, context
+	blocks, ok := attachment["blocks"].([]map[string]interface{})
+	if !ok || len(blocks) != 3 {
+		t.Fatalf("Expected 3 blocks, got %v", attachment["blocks"])
+	}
+
+	header := blocks[0]["text"].(map[string]interface{})
+	if blocks[0]["type"] != "header" || header["text"] != "Task Complete" {
+		t.Errorf("Expected header block with title, got %v", blocks[0])
+	}
+
+	section := blocks[1]["text"].(map[string]interface{})
+	if blocks[1]["type"] != "section" || section["text"] != "The task has been completed successfully" {
+		t.Errorf("Expected section block with message, got %v", blocks[1])
 	}
 
-	// Check text
-	text, ok := attachment["text"].(string)
-	if !ok || text != "The task has been completed successfully" {
-		t.Errorf("Expected message text, got %v", text)
+	elements := blocks[2]["elements"].([]map[string]interface{})
+	var contextText []string
+	for _, e := range elements {
+		contextText = append(contextText, e["text"].(string))
+	}
+	if !strings.Contains(strings.Join(contextText, " "), "session-123") {
+		t.Errorf("Context should contain session ID, got %v", contextText)
 	}
 
-	// Check footer contains session ID
-	footer, ok := attachment["footer"].(string)
-	if !ok || !strings.Contains(footer, "session-123") {
-		t.Errorf("Footer should contain session ID, got %v", footer)
+	if _, ok := resultMap["channel"]; ok {
+		t.Error("Channel should be omitted when not configured")
 	}
 
 	// Verify it's valid JSON
@@ -89,7 +106,7 @@ func TestSlackFormatterColors(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(string(tt.status), func(t *testing.T) {
-			result, err := formatter.Format(tt.status, "test", "session-1", statusInfo)
+			result, err := formatter.Format(tt.status, "test", "session-1", statusInfo, Meta{})
 			if err != nil {
 				t.Fatalf("Unexpected error: %v", err)
 			}
@@ -116,6 +133,7 @@ func TestDiscordFormatterFormat(t *testing.T) {
 		"What should we do next?",
 		"session-456",
 		statusInfo,
+		Meta{},
 	)
 
 	if err != nil {
@@ -200,7 +218,7 @@ func TestDiscordFormatterColors(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(string(tt.status), func(t *testing.T) {
-			result, err := formatter.Format(tt.status, "test", "session-1", statusInfo)
+			result, err := formatter.Format(tt.status, "test", "session-1", statusInfo, Meta{})
 			if err != nil {
 				t.Fatalf("Unexpected error: %v", err)
 			}
@@ -227,6 +245,7 @@ func TestTelegramFormatterFormat(t *testing.T) {
 		"Code review finished",
 		"session-789",
 		statusInfo,
+		Meta{},
 	)
 
 	if err != nil {
@@ -303,7 +322,7 @@ func TestTelegramFormatterEmojis(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(string(tt.status), func(t *testing.T) {
-			result, err := formatter.Format(tt.status, "test", "session-1", statusInfo)
+			result, err := formatter.Format(tt.status, "test", "session-1", statusInfo, Meta{})
 			if err != nil {
 				t.Fatalf("Unexpected error: %v", err)
 			}
@@ -318,6 +337,95 @@ func TestTelegramFormatterEmojis(t *testing.T) {
 	}
 }
 
+func TestTelegramFormatterProjectAndEvent(t *testing.T) {
+	formatter := &TelegramFormatter{ChatID: "42"}
+
+	result, err := formatter.Format(analyzer.StatusQuestion, "Use <b>Postgres</b> & Redis?", "s-1",
+		config.StatusInfo{Title: "Question"}, Meta{Project: "my-app"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	resultMap := result.(map[string]interface{})
+	text := resultMap["text"].(string)
+
+	if !strings.Contains(text, "📁 <b>my-app</b>") {
+		t.Errorf("Text should contain project name, got: %s", text)
+	}
+	if !strings.Contains(text, "<code>question</code>") {
+		t.Errorf("Text should contain event type, got: %s", text)
+	}
+	if !strings.Contains(text, "Use &lt;b&gt;Postgres&lt;/b&gt; &amp; Redis?") {
+		t.Errorf("Message should be HTML-escaped, got: %s", text)
+	}
+	if resultMap["disable_web_page_preview"] != true {
+		t.Error("Link previews should be disabled")
+	}
+}
+
+func TestTelegramFormatterTruncatesPreview(t *testing.T) {
+	formatter := &TelegramFormatter{ChatID: "42", PreviewLength: 10}
+
+	result, _ := formatter.Format(analyzer.StatusTaskComplete, "Привет мир, это длинное сообщение", "s-1",
+		config.StatusInfo{Title: "Done"}, Meta{})
+	text := result.(map[string]interface{})["text"].(string)
+
+	if !strings.Contains(text, "Привет ми…") {
+		t.Errorf("Preview should be truncated to 10 runes including the ellipsis, got: %s", text)
+	}
+	if strings.Contains(text, "длинное") {
+		t.Errorf("Preview should not contain the cut text, got: %s", text)
+	}
+	if strings.Contains(text, "📁") {
+		t.Errorf("Project line should be omitted without project, got: %s", text)
+	}
+}
+
+func TestTelegramFormatterNoDuplicateEmoji(t *testing.T) {
+	formatter := &TelegramFormatter{ChatID: "42"}
+
+	result, _ := formatter.Format(analyzer.StatusTaskComplete, "done", "s-1",
+		config.StatusInfo{Title: "✅ Completed"}, Meta{})
+	text := result.(map[string]interface{})["text"].(string)
+
+	if !strings.HasPrefix(text, "<b>✅ Completed</b>") {
+		t.Errorf("Title emoji should not be repeated, got: %s", text)
+	}
+}
+
+func TestTelegramFormatterLowPriorityIsSilent(t *testing.T) {
+	formatter := &TelegramFormatter{ChatID: "42"}
+
+	result, _ := formatter.Format(analyzer.StatusTaskComplete, "done", "s-1", config.StatusInfo{}, Meta{Priority: "low"})
+	if silent := result.(map[string]interface{})["disable_notification"]; silent != true {
+		t.Errorf("disable_notification = %v, want true for low priority", silent)
+	}
+
+	result, _ = formatter.Format(analyzer.StatusTaskComplete, "done", "s-1", config.StatusInfo{}, Meta{})
+	if _, ok := result.(map[string]interface{})["disable_notification"]; ok {
+		t.Error("disable_notification should be omitted by default")
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		input string
+		limit int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"hello world", 6, "hello…"},
+		{"日本語のテキスト", 4, "日本語…"},
+	}
+
+	for _, tt := range tests {
+		if got := truncateRunes(tt.input, tt.limit); got != tt.want {
+			t.Errorf("truncateRunes(%q, %d) = %q, want %q", tt.input, tt.limit, got, tt.want)
+		}
+	}
+}
+
 func TestGetColorForStatus(t *testing.T) {
 	tests := []struct {
 		status   analyzer.Status
@@ -395,6 +503,7 @@ func TestLarkFormatterFormat(t *testing.T) {
 		"The task has been completed successfully",
 		"session-123",
 		statusInfo,
+		Meta{},
 	)
 
 	if err != nil {
@@ -505,7 +614,7 @@ func TestLarkFormatterColors(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(string(tt.status), func(t *testing.T) {
-			result, err := formatter.Format(tt.status, "test", "session-1", statusInfo)
+			result, err := formatter.Format(tt.status, "test", "session-1", statusInfo, Meta{})
 			if err != nil {
 				t.Fatalf("Unexpected error: %v", err)
 			}
@@ -531,6 +640,7 @@ func TestLarkFormatterUnknownStatus(t *testing.T) {
 		"Unknown status",
 		"session-999",
 		statusInfo,
+		Meta{},
 	)
 
 	if err != nil {
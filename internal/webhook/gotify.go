@@ -0,0 +1,125 @@
+package webhook
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/priority"
+)
+
+// Gotify priorities as the Android app treats them
+// (https://github.com/gotify/android#message-priorities)
+const (
+	gotifyPriorityLow    = 2 // Icon in the status bar only
+	gotifyPriorityNormal = 5 // Notification with sound
+	gotifyPriorityHigh   = 8 // Heads-up notification
+)
+
+// GotifyFormatter formats messages for the Gotify message API
+type GotifyFormatter struct {
+	Priorities map[string]int // Per-status overrides of getGotifyPriority
+	Markdown   bool
+
+	clickURL    *template.Template // Opened when the notification is clicked (nil = none)
+	clickURLErr error              // Parse error of the clickUrl template, reported by Format
+}
+
+// NewGotifyFormatter creates a Gotify formatter from the preset settings
+func NewGotifyFormatter(cfg config.GotifyConfig) *GotifyFormatter {
+	f := &GotifyFormatter{Priorities: cfg.Priorities, Markdown: cfg.Markdown}
+	if cfg.ClickURL != "" {
+		f.clickURL, f.clickURLErr = ParseBodyTemplate(cfg.ClickURL)
+	}
+	return f
+}
+
+func (f *GotifyFormatter) Format(status analyzer.Status, message, sessionID string, statusInfo config.StatusInfo, meta Meta) (interface{}, error) {
+	if f.clickURLErr != nil {
+		return nil, fmt.Errorf("invalid gotify clickUrl template: %w", f.clickURLErr)
+	}
+
+	priority, ok := f.Priorities[string(status)]
+	if !ok {
+		priority = getGotifyPriority(status)
+	}
+	priority = applyGotifyPriority(priority, meta.Priority)
+
+	payload := map[string]interface{}{
+		"title":    statusInfo.Title,
+		"message":  message,
+		"priority": priority,
+	}
+
+	extras := map[string]interface{}{}
+	if f.Markdown {
+		extras["client::display"] = map[string]string{"contentType": "text/markdown"}
+	}
+	if f.clickURL != nil {
+		data := NewTemplateData(status, statusInfo.Title, message, sessionID, meta, time.Now())
+		clickURL, err := Render(f.clickURL, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render gotify clickUrl: %w", err)
+		}
+		if clickURL = strings.TrimSpace(clickURL); clickURL != "" {
+			extras["client::notification"] = map[string]interface{}{
+				"click": map[string]string{"url": clickURL},
+			}
+		}
+	}
+	if len(extras) > 0 {
+		payload["extras"] = extras
+	}
+	return payload, nil
+}
+
+// getGotifyPriority returns the default Gotify priority for status:
+// anything that blocks the session pops up, the rest makes a sound
+func getGotifyPriority(status analyzer.Status) int {
+	switch status {
+	case analyzer.StatusQuestion, analyzer.StatusPlanReady, analyzer.StatusSessionStalled,
+		analyzer.StatusSessionLimitReached, analyzer.StatusAPIError, analyzer.StatusAPIErrorOverloaded,
+		analyzer.StatusBudgetExceeded:
+		return gotifyPriorityHigh
+	default:
+		return gotifyPriorityNormal
+	}
+}
+
+// applyGotifyPriority applies a priority set by rules to the Gotify
+// priority of the status: low stays in the status bar, normal makes a
+// sound, and critical pops up unless the status is already higher
+func applyGotifyPriority(statusPriority int, p string) int {
+	switch p {
+	case priority.Low:
+		return gotifyPriorityLow
+	case priority.Normal:
+		return gotifyPriorityNormal
+	case priority.Critical:
+		if statusPriority < gotifyPriorityHigh {
+			return gotifyPriorityHigh
+		}
+	}
+	return statusPriority
+}
+
+// gotifyTarget returns the message endpoint of a Gotify server URL:
+// "https://gotify.example.com" becomes "https://gotify.example.com/message".
+// A URL already ending in /message is kept.
+func gotifyTarget(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	if !strings.HasSuffix(path, "/message") {
+		path += "/message"
+	}
+	u.Path = path
+	u.RawPath = ""
+	return u.String()
+}
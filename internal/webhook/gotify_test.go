@@ -0,0 +1,155 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+func TestGotifyFormatterFormat(t *testing.T) {
+	formatter := NewGotifyFormatter(config.GotifyConfig{})
+
+	result, err := formatter.Format(analyzer.StatusTaskComplete, "All done", "session-1", config.StatusInfo{Title: "✅ Completed"}, Meta{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	payload := result.(map[string]interface{})
+	if payload["title"] != "✅ Completed" || payload["message"] != "All done" {
+		t.Errorf("title/message = %v/%v", payload["title"], payload["message"])
+	}
+	if payload["priority"] != gotifyPriorityNormal {
+		t.Errorf("priority = %v, want %d", payload["priority"], gotifyPriorityNormal)
+	}
+	if _, ok := payload["extras"]; ok {
+		t.Error("extras should be omitted without clickUrl or markdown")
+	}
+}
+
+func TestGotifyFormatterExtras(t *testing.T) {
+	formatter := NewGotifyFormatter(config.GotifyConfig{
+		ClickURL: "https://git.example.com/{{.Repo}}/tree/{{.Branch}}",
+		Markdown: true,
+	})
+
+	result, err := formatter.Format(analyzer.StatusQuestion, "Which DB?", "s1", config.StatusInfo{Title: "❓ Question"}, Meta{Repo: "api", Branch: "main"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, _ := json.Marshal(result)
+	var payload struct {
+		Priority int `json:"priority"`
+		Extras   struct {
+			Display struct {
+				ContentType string `json:"contentType"`
+			} `json:"client::display"`
+			Notification struct {
+				Click struct {
+					URL string `json:"url"`
+				} `json:"click"`
+			} `json:"client::notification"`
+		} `json:"extras"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatalf("payload is not JSON: %v", err)
+	}
+	if payload.Priority != gotifyPriorityHigh {
+		t.Errorf("priority = %d, want %d", payload.Priority, gotifyPriorityHigh)
+	}
+	if payload.Extras.Display.ContentType != "text/markdown" {
+		t.Errorf("contentType = %q", payload.Extras.Display.ContentType)
+	}
+	if got := payload.Extras.Notification.Click.URL; got != "https://git.example.com/api/tree/main" {
+		t.Errorf("click url = %q", got)
+	}
+}
+
+func TestGotifyFormatterBadClickURL(t *testing.T) {
+	formatter := NewGotifyFormatter(config.GotifyConfig{ClickURL: "{{.Nope"})
+	if _, err := formatter.Format(analyzer.StatusTaskComplete, "m", "s", config.StatusInfo{}, Meta{}); err == nil || !strings.Contains(err.Error(), "clickUrl") {
+		t.Errorf("err = %v, want a clickUrl template error", err)
+	}
+}
+
+func TestGotifyPriority(t *testing.T) {
+	formatter := NewGotifyFormatter(config.GotifyConfig{Priorities: map[string]int{"task_complete": 0}})
+	tests := []struct {
+		status analyzer.Status
+		prio   string
+		want   int
+	}{
+		{analyzer.StatusTaskComplete, "", 0}, // configured: silent
+		{analyzer.StatusAPIError, "", gotifyPriorityHigh},
+		{analyzer.StatusQuestion, "low", gotifyPriorityLow},
+		{analyzer.StatusQuestion, "normal", gotifyPriorityNormal},
+		{analyzer.StatusReviewComplete, "critical", gotifyPriorityHigh},
+	}
+	for _, tt := range tests {
+		result, err := formatter.Format(tt.status, "m", "s", config.StatusInfo{}, Meta{Priority: tt.prio})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got := result.(map[string]interface{})["priority"]; got != tt.want {
+			t.Errorf("priority(%s, %q) = %v, want %d", tt.status, tt.prio, got, tt.want)
+		}
+	}
+}
+
+func TestGotifyTarget(t *testing.T) {
+	tests := map[string]string{
+		"https://gotify.example.com":              "https://gotify.example.com/message",
+		"https://gotify.example.com/":             "https://gotify.example.com/message",
+		"https://example.com/gotify":              "https://example.com/gotify/message",
+		"https://gotify.example.com/message":      "https://gotify.example.com/message",
+		"http://localhost:8080/message?priority=": "http://localhost:8080/message?priority=",
+	}
+	for in, want := range tests {
+		if got := gotifyTarget(in); got != want {
+			t.Errorf("gotifyTarget(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSender_Gotify(t *testing.T) {
+	var mu sync.Mutex
+	var path, key string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		path, key = r.URL.Path, r.Header.Get("X-Gotify-Key")
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Notifications.Webhook = config.WebhookConfig{
+		Enabled: true,
+		Preset:  "gotify",
+		URL:     server.URL,
+		Gotify:  config.GotifyConfig{Token: "A1b2"},
+	}
+	cfg.ApplyDefaults()
+	sender := New(cfg)
+
+	if err := sender.Send(analyzer.StatusPlanReady, "Plan ready", "s1", Meta{}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if path != "/message" || key != "A1b2" {
+		t.Errorf("request path %q, key %q; want /message and the app token", path, key)
+	}
+	if !strings.Contains(string(body), `"priority":8`) {
+		t.Errorf("body = %s, want priority 8", body)
+	}
+}
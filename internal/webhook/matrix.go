@@ -0,0 +1,60 @@
+package webhook
+
+import (
+	"html"
+	"net/url"
+	"strings"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/priority"
+)
+
+// MatrixFormatter formats m.room.message events for the Matrix client-server API
+type MatrixFormatter struct {
+	MsgType string // "m.notice" or "m.text" ("" = m.notice)
+	Mention string // Mentioned in critical notifications: "room" or a user ID ("" = nobody)
+}
+
+func (f *MatrixFormatter) Format(status analyzer.Status, message, sessionID string, statusInfo config.StatusInfo, meta Meta) (interface{}, error) {
+	msgType := f.MsgType
+	if msgType == "" {
+		msgType = "m.notice"
+	}
+
+	body := statusInfo.Title + "\n\n" + message
+	formatted := "<b>" + html.EscapeString(statusInfo.Title) + "</b><br>" +
+		strings.ReplaceAll(html.EscapeString(message), "\n", "<br>")
+
+	// An explicit, empty m.mentions keeps names in the message from pinging
+	// anyone; only the configured mention notifies
+	mentions := map[string]interface{}{}
+	if f.Mention != "" && priority.Resolve(status, meta.Priority) == priority.Critical {
+		if f.Mention == "room" {
+			body = "@room " + body
+			formatted = "@room " + formatted
+			mentions["room"] = true
+		} else {
+			link := `<a href="https://matrix.to/#/` + html.EscapeString(f.Mention) + `">` + html.EscapeString(f.Mention) + `</a>`
+			body = f.Mention + ": " + body
+			formatted = link + ": " + formatted
+			mentions["user_ids"] = []string{f.Mention}
+		}
+	}
+
+	return map[string]interface{}{
+		"msgtype":        msgType,
+		"body":           body,
+		"format":         "org.matrix.custom.html",
+		"formatted_body": formatted,
+		"m.mentions":     mentions,
+	}, nil
+}
+
+// matrixTarget returns the send endpoint of a homeserver URL for roomID.
+// Matrix expects a PUT with a transaction ID that makes retries of the
+// same event idempotent.
+func matrixTarget(homeserver, roomID, txnID string) string {
+	return strings.TrimSuffix(homeserver, "/") + "/_matrix/client/v3/rooms/" +
+		url.PathEscape(roomID) + "/send/m.room.message/" + url.PathEscape(txnID)
+}
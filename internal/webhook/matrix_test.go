@@ -0,0 +1,130 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+func TestMatrixFormatterFormat(t *testing.T) {
+	formatter := &MatrixFormatter{}
+
+	result, err := formatter.Format(analyzer.StatusTaskComplete, "Fixed <div>\nand tests", "s1", config.StatusInfo{Title: "✅ Completed"}, Meta{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	payload := result.(map[string]interface{})
+	if payload["msgtype"] != "m.notice" {
+		t.Errorf("msgtype = %v, want m.notice", payload["msgtype"])
+	}
+	if payload["body"] != "✅ Completed\n\nFixed <div>\nand tests" {
+		t.Errorf("body = %q", payload["body"])
+	}
+	if payload["formatted_body"] != "<b>✅ Completed</b><br>Fixed &lt;div&gt;<br>and tests" {
+		t.Errorf("formatted_body = %q", payload["formatted_body"])
+	}
+	if mentions := payload["m.mentions"].(map[string]interface{}); len(mentions) != 0 {
+		t.Errorf("m.mentions = %v, want none", mentions)
+	}
+}
+
+func TestMatrixFormatterMention(t *testing.T) {
+	tests := []struct {
+		mention  string
+		status   analyzer.Status
+		prio     string
+		wantBody string
+		wantKey  string
+	}{
+		{"room", analyzer.StatusAPIError, "", "@room ", "room"},
+		{"@me:example.org", analyzer.StatusQuestion, "critical", "@me:example.org: ", "user_ids"},
+		{"room", analyzer.StatusQuestion, "", "", ""}, // not critical
+	}
+	for _, tt := range tests {
+		formatter := &MatrixFormatter{MsgType: "m.text", Mention: tt.mention}
+		result, err := formatter.Format(tt.status, "m", "s", config.StatusInfo{Title: "T"}, Meta{Priority: tt.prio})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		payload := result.(map[string]interface{})
+		body := payload["body"].(string)
+		mentions := payload["m.mentions"].(map[string]interface{})
+		if tt.wantBody == "" {
+			if body != "T\n\nm" || len(mentions) != 0 {
+				t.Errorf("%s/%s: body %q, mentions %v; want no mention", tt.mention, tt.status, body, mentions)
+			}
+			continue
+		}
+		if !strings.HasPrefix(body, tt.wantBody) {
+			t.Errorf("%s: body = %q, want prefix %q", tt.mention, body, tt.wantBody)
+		}
+		if _, ok := mentions[tt.wantKey]; !ok {
+			t.Errorf("%s: m.mentions = %v, want %s", tt.mention, mentions, tt.wantKey)
+		}
+	}
+}
+
+func TestMatrixTarget(t *testing.T) {
+	got := matrixTarget("https://matrix.example.org/", "!abc:example.org", "t1")
+	want := "https://matrix.example.org/_matrix/client/v3/rooms/%21abc:example.org/send/m.room.message/t1"
+	if got != want {
+		t.Errorf("matrixTarget = %q, want %q", got, want)
+	}
+}
+
+func TestSender_Matrix(t *testing.T) {
+	var mu sync.Mutex
+	var methods, paths []string
+	var auth string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		methods = append(methods, r.Method)
+		paths = append(paths, r.URL.Path)
+		auth = r.Header.Get("Authorization")
+		body, _ = io.ReadAll(r.Body)
+		if len(paths) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"event_id":"$e"}`))
+	}))
+	defer server.Close()
+
+	cfg := newTestConfig(server.URL)
+	cfg.Notifications.Webhook.Preset = "matrix"
+	cfg.Notifications.Webhook.Matrix = config.MatrixConfig{AccessToken: "syt_abc", RoomID: "!room:example.org"}
+	sender := New(cfg)
+
+	if err := sender.Send(analyzer.StatusQuestion, "Which DB?", "s1", Meta{}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(paths) != 2 {
+		t.Fatalf("got %d requests, want a failure and a retry", len(paths))
+	}
+	if methods[1] != http.MethodPut || auth != "Bearer syt_abc" {
+		t.Errorf("method %s, auth %q; want PUT with the access token", methods[1], auth)
+	}
+	if !strings.HasPrefix(paths[0], "/_matrix/client/v3/rooms/!room:example.org/send/m.room.message/") {
+		t.Errorf("path = %q", paths[0])
+	}
+	if paths[0] != paths[1] {
+		t.Errorf("retry used transaction %q, first attempt %q; want the same", paths[1], paths[0])
+	}
+	var event map[string]interface{}
+	if err := json.Unmarshal(body, &event); err != nil || event["msgtype"] != "m.notice" {
+		t.Errorf("event = %s, err %v", body, err)
+	}
+}
@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/priority"
+)
+
+// ntfy priorities (https://docs.ntfy.sh/publish/#message-priority)
+const (
+	ntfyPriorityLow     = 2
+	ntfyPriorityDefault = 3
+	ntfyPriorityHigh    = 4
+	ntfyPriorityMax     = 5
+)
+
+// NtfyFormatter formats messages for ntfy JSON publishing
+type NtfyFormatter struct {
+	Topic    string
+	Priority int // 0 = derive from status
+	Tags     []string
+}
+
+func (f *NtfyFormatter) Format(status analyzer.Status, message, sessionID string, statusInfo config.StatusInfo, meta Meta) (interface{}, error) {
+	priority := f.Priority
+	switch {
+	case meta.Priority != "":
+		priority = ntfyPriorityFor(meta.Priority)
+	case priority == 0:
+		priority = getNtfyPriority(status)
+	}
+
+	payload := map[string]interface{}{
+		"topic":    f.Topic,
+		"title":    statusInfo.Title,
+		"message":  message,
+		"priority": priority,
+	}
+	if len(f.Tags) > 0 {
+		payload["tags"] = f.Tags
+	}
+	return payload, nil
+}
+
+// getNtfyPriority returns the ntfy priority for status: errors and session and
+// budget limits are max (long vibration burst), questions and plans need input and are high.
+func getNtfyPriority(status analyzer.Status) int {
+	switch status {
+	case analyzer.StatusAPIError, analyzer.StatusAPIErrorOverloaded, analyzer.StatusSessionLimitReached,
+		analyzer.StatusBudgetExceeded:
+		return ntfyPriorityMax
+	case analyzer.StatusQuestion, analyzer.StatusPlanReady, analyzer.StatusSessionStalled:
+		return ntfyPriorityHigh
+	default:
+		return ntfyPriorityDefault
+	}
+}
+
+// ntfyPriorityFor maps a priority set by rules to ntfy: low is delivered
+// without vibration or sound, critical as max priority
+func ntfyPriorityFor(p string) int {
+	switch p {
+	case priority.Low:
+		return ntfyPriorityLow
+	case priority.Critical:
+		return ntfyPriorityMax
+	default:
+		return ntfyPriorityDefault
+	}
+}
+
+// ntfyTarget returns the server URL that accepts JSON publishes and the topic.
+// JSON messages must be POSTed to the server root, so a topic given as the last
+// path segment of rawURL ("https://ntfy.sh/my-topic") is split off.
+// An explicit topic takes precedence and leaves rawURL unchanged.
+func ntfyTarget(rawURL, topic string) (serverURL, resolvedTopic string) {
+	if topic != "" {
+		return rawURL, topic
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL, ""
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	idx := strings.LastIndex(path, "/")
+	if idx < 0 || idx == len(path)-1 {
+		return rawURL, ""
+	}
+
+	resolvedTopic = path[idx+1:]
+	u.Path = path[:idx]
+	u.RawPath = ""
+	return u.String(), resolvedTopic
+}
@@ -0,0 +1,120 @@
+package webhook
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+func TestNtfyFormatterFormat(t *testing.T) {
+	formatter := &NtfyFormatter{Topic: "claude", Tags: []string{"robot", "work"}}
+
+	result, err := formatter.Format(analyzer.StatusQuestion, "Need input", "session-1", config.StatusInfo{Title: "❓ Question"}, Meta{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	payload := result.(map[string]interface{})
+	if payload["topic"] != "claude" {
+		t.Errorf("topic = %v, want claude", payload["topic"])
+	}
+	if payload["title"] != "❓ Question" {
+		t.Errorf("title = %v", payload["title"])
+	}
+	if payload["message"] != "Need input" {
+		t.Errorf("message = %v", payload["message"])
+	}
+	if payload["priority"] != ntfyPriorityHigh {
+		t.Errorf("priority = %v, want %d", payload["priority"], ntfyPriorityHigh)
+	}
+	if !reflect.DeepEqual(payload["tags"], []string{"robot", "work"}) {
+		t.Errorf("tags = %v", payload["tags"])
+	}
+
+	if _, err := json.Marshal(result); err != nil {
+		t.Errorf("Result should be JSON-serializable: %v", err)
+	}
+}
+
+func TestNtfyFormatterExplicitPriority(t *testing.T) {
+	formatter := &NtfyFormatter{Topic: "claude", Priority: 1}
+
+	result, _ := formatter.Format(analyzer.StatusAPIError, "boom", "s", config.StatusInfo{}, Meta{})
+	payload := result.(map[string]interface{})
+	if payload["priority"] != 1 {
+		t.Errorf("priority = %v, want 1", payload["priority"])
+	}
+	if _, ok := payload["tags"]; ok {
+		t.Error("tags should be omitted when empty")
+	}
+}
+
+func TestNtfyFormatterRulePriority(t *testing.T) {
+	tests := []struct {
+		priority string
+		want     int
+	}{
+		{"low", ntfyPriorityLow},
+		{"normal", ntfyPriorityDefault},
+		{"critical", ntfyPriorityMax},
+	}
+	// A rule's priority wins over both the status and the configured priority
+	formatter := &NtfyFormatter{Topic: "claude", Priority: 4}
+	for _, tt := range tests {
+		result, _ := formatter.Format(analyzer.StatusQuestion, "q", "s", config.StatusInfo{}, Meta{Priority: tt.priority})
+		if got := result.(map[string]interface{})["priority"]; got != tt.want {
+			t.Errorf("priority %s: ntfy priority = %v, want %d", tt.priority, got, tt.want)
+		}
+	}
+}
+
+func TestGetNtfyPriority(t *testing.T) {
+	tests := []struct {
+		status analyzer.Status
+		want   int
+	}{
+		{analyzer.StatusTaskComplete, ntfyPriorityDefault},
+		{analyzer.StatusReviewComplete, ntfyPriorityDefault},
+		{analyzer.StatusQuestion, ntfyPriorityHigh},
+		{analyzer.StatusPlanReady, ntfyPriorityHigh},
+		{analyzer.StatusSessionLimitReached, ntfyPriorityMax},
+		{analyzer.StatusAPIError, ntfyPriorityMax},
+		{analyzer.StatusAPIErrorOverloaded, ntfyPriorityMax},
+	}
+
+	for _, tt := range tests {
+		if got := getNtfyPriority(tt.status); got != tt.want {
+			t.Errorf("getNtfyPriority(%s) = %d, want %d", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestNtfyTarget(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		topic      string
+		wantServer string
+		wantTopic  string
+	}{
+		{"topic in URL", "https://ntfy.sh/my-topic", "", "https://ntfy.sh", "my-topic"},
+		{"trailing slash", "https://ntfy.sh/my-topic/", "", "https://ntfy.sh", "my-topic"},
+		{"self-hosted subpath", "https://example.com/ntfy/alerts", "", "https://example.com/ntfy", "alerts"},
+		{"explicit topic", "https://ntfy.example.com", "claude", "https://ntfy.example.com", "claude"},
+		{"explicit topic wins", "https://ntfy.sh/other", "claude", "https://ntfy.sh/other", "claude"},
+		{"no topic", "https://ntfy.sh", "", "https://ntfy.sh", ""},
+		{"no topic trailing slash", "https://ntfy.sh/", "", "https://ntfy.sh/", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, topic := ntfyTarget(tt.url, tt.topic)
+			if server != tt.wantServer || topic != tt.wantTopic {
+				t.Errorf("ntfyTarget(%q, %q) = %q, %q; want %q, %q", tt.url, tt.topic, server, topic, tt.wantServer, tt.wantTopic)
+			}
+		})
+	}
+}
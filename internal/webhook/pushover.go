@@ -0,0 +1,91 @@
+package webhook
+
+import (
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/priority"
+)
+
+// Pushover priorities (https://pushover.net/api#priority)
+const (
+	pushoverPriorityLow       = -1
+	pushoverPriorityNormal    = 0
+	pushoverPriorityHigh      = 1
+	pushoverPriorityEmergency = 2
+
+	// Emergency notifications repeat every retry seconds until acknowledged or expire elapses
+	pushoverDefaultRetry  = 60
+	pushoverDefaultExpire = 3600
+)
+
+// PushoverFormatter formats messages for the Pushover messages API
+type PushoverFormatter struct {
+	UserKey    string
+	AppToken   string
+	Priorities map[string]int // Per-status overrides of getPushoverPriority
+	Retry      int
+	Expire     int
+	Device     string
+}
+
+func (f *PushoverFormatter) Format(status analyzer.Status, message, sessionID string, statusInfo config.StatusInfo, meta Meta) (interface{}, error) {
+	priority, ok := f.Priorities[string(status)]
+	if !ok {
+		priority = getPushoverPriority(status)
+	}
+	priority = applyPushoverPriority(priority, meta.Priority)
+
+	payload := map[string]interface{}{
+		"token":    f.AppToken,
+		"user":     f.UserKey,
+		"title":    statusInfo.Title,
+		"message":  message,
+		"priority": priority,
+	}
+	if priority == pushoverPriorityEmergency {
+		retry, expire := f.Retry, f.Expire
+		if retry == 0 {
+			retry = pushoverDefaultRetry
+		}
+		if expire == 0 {
+			expire = pushoverDefaultExpire
+		}
+		payload["retry"] = retry
+		payload["expire"] = expire
+	}
+	if f.Device != "" {
+		payload["device"] = f.Device
+	}
+	return payload, nil
+}
+
+// applyPushoverPriority applies a priority set by rules to the Pushover
+// priority of the status: low is quiet, normal is normal, and critical is
+// high unless the priorities config already escalates the status further
+func applyPushoverPriority(statusPriority int, p string) int {
+	switch p {
+	case priority.Low:
+		return pushoverPriorityLow
+	case priority.Normal:
+		return pushoverPriorityNormal
+	case priority.Critical:
+		if statusPriority < pushoverPriorityHigh {
+			return pushoverPriorityHigh
+		}
+	}
+	return statusPriority
+}
+
+// getPushoverPriority returns the default Pushover priority for status.
+// Anything that blocks the session is high priority (bypasses quiet hours);
+// emergency priority is opt-in through the priorities config.
+func getPushoverPriority(status analyzer.Status) int {
+	switch status {
+	case analyzer.StatusQuestion, analyzer.StatusPlanReady, analyzer.StatusSessionStalled,
+		analyzer.StatusSessionLimitReached, analyzer.StatusAPIError, analyzer.StatusAPIErrorOverloaded,
+		analyzer.StatusBudgetExceeded:
+		return pushoverPriorityHigh
+	default:
+		return pushoverPriorityNormal
+	}
+}
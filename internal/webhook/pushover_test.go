@@ -0,0 +1,117 @@
+package webhook
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+func TestPushoverFormatterFormat(t *testing.T) {
+	formatter := &PushoverFormatter{UserKey: "u123", AppToken: "a456"}
+
+	result, err := formatter.Format(analyzer.StatusTaskComplete, "All done", "session-1", config.StatusInfo{Title: "✅ Completed"}, Meta{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	payload := result.(map[string]interface{})
+	if payload["token"] != "a456" || payload["user"] != "u123" {
+		t.Errorf("token/user = %v/%v, want a456/u123", payload["token"], payload["user"])
+	}
+	if payload["title"] != "✅ Completed" {
+		t.Errorf("title = %v", payload["title"])
+	}
+	if payload["message"] != "All done" {
+		t.Errorf("message = %v", payload["message"])
+	}
+	if payload["priority"] != pushoverPriorityNormal {
+		t.Errorf("priority = %v, want %d", payload["priority"], pushoverPriorityNormal)
+	}
+	for _, key := range []string{"retry", "expire", "device"} {
+		if _, ok := payload[key]; ok {
+			t.Errorf("%s should be omitted", key)
+		}
+	}
+
+	if _, err := json.Marshal(result); err != nil {
+		t.Errorf("Result should be JSON-serializable: %v", err)
+	}
+}
+
+func TestApplyPushoverPriority(t *testing.T) {
+	tests := []struct {
+		statusPriority int
+		priority       string
+		want           int
+	}{
+		{pushoverPriorityHigh, "", pushoverPriorityHigh},
+		{pushoverPriorityHigh, "low", pushoverPriorityLow},
+		{pushoverPriorityHigh, "normal", pushoverPriorityNormal},
+		{pushoverPriorityNormal, "critical", pushoverPriorityHigh},
+		{pushoverPriorityEmergency, "critical", pushoverPriorityEmergency},
+	}
+	for _, tt := range tests {
+		if got := applyPushoverPriority(tt.statusPriority, tt.priority); got != tt.want {
+			t.Errorf("applyPushoverPriority(%d, %q) = %d, want %d", tt.statusPriority, tt.priority, got, tt.want)
+		}
+	}
+}
+
+func TestPushoverFormatterEmergency(t *testing.T) {
+	formatter := &PushoverFormatter{
+		UserKey:    "u",
+		AppToken:   "a",
+		Priorities: map[string]int{"task_complete": 2},
+		Device:     "phone",
+	}
+
+	result, _ := formatter.Format(analyzer.StatusTaskComplete, "done", "s", config.StatusInfo{}, Meta{})
+	payload := result.(map[string]interface{})
+	if payload["priority"] != pushoverPriorityEmergency {
+		t.Errorf("priority = %v, want %d", payload["priority"], pushoverPriorityEmergency)
+	}
+	if payload["retry"] != pushoverDefaultRetry || payload["expire"] != pushoverDefaultExpire {
+		t.Errorf("retry/expire = %v/%v, want defaults", payload["retry"], payload["expire"])
+	}
+	if payload["device"] != "phone" {
+		t.Errorf("device = %v, want phone", payload["device"])
+	}
+
+	formatter.Retry, formatter.Expire = 30, 600
+	result, _ = formatter.Format(analyzer.StatusTaskComplete, "done", "s", config.StatusInfo{}, Meta{})
+	payload = result.(map[string]interface{})
+	if payload["retry"] != 30 || payload["expire"] != 600 {
+		t.Errorf("retry/expire = %v/%v, want 30/600", payload["retry"], payload["expire"])
+	}
+}
+
+func TestPushoverFormatterPriorityOverride(t *testing.T) {
+	formatter := &PushoverFormatter{Priorities: map[string]int{"question": -1}}
+
+	result, _ := formatter.Format(analyzer.StatusQuestion, "?", "s", config.StatusInfo{}, Meta{})
+	if p := result.(map[string]interface{})["priority"]; p != -1 {
+		t.Errorf("priority = %v, want -1", p)
+	}
+}
+
+func TestGetPushoverPriority(t *testing.T) {
+	tests := []struct {
+		status analyzer.Status
+		want   int
+	}{
+		{analyzer.StatusTaskComplete, pushoverPriorityNormal},
+		{analyzer.StatusReviewComplete, pushoverPriorityNormal},
+		{analyzer.StatusQuestion, pushoverPriorityHigh},
+		{analyzer.StatusPlanReady, pushoverPriorityHigh},
+		{analyzer.StatusSessionLimitReached, pushoverPriorityHigh},
+		{analyzer.StatusAPIError, pushoverPriorityHigh},
+	}
+
+	for _, tt := range tests {
+		if got := getPushoverPriority(tt.status); got != tt.want {
+			t.Errorf("getPushoverPriority(%s) = %d, want %d", tt.status, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package webhook
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// QueueFileName is the retry queue file in the stable config directory
+	QueueFileName = "webhook-queue.jsonl"
+
+	// DefaultQueueMaxAge is how long a failed delivery is retried
+	DefaultQueueMaxAge = 24 * time.Hour
+
+	// queueMaxEntries caps the queue; the oldest deliveries are dropped first
+	queueMaxEntries = 100
+
+	// Backoff between retries of a queued delivery: 30s, 1m, 2m, ... 1h
+	queueInitialBackoff = 30 * time.Second
+	queueMaxBackoff     = time.Hour
+)
+
+// Delivery is one HTTP request to a webhook backend, as sent and as
+// kept in the retry queue. Queued deliveries are self-contained, so any
+// hook can retry them whatever config it runs with.
+type Delivery struct {
+	Backend     string            `json:"backend"`
+	Method      string            `json:"method,omitempty"` // HTTP method ("" = POST)
+	URL         string            `json:"url"`
+	ContentType string            `json:"content_type"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Payload     []byte            `json:"payload"`
+	SlackAPI    bool              `json:"slack_api,omitempty"` // Errors come in the body of a 200 response
+
+	// Retry queue bookkeeping
+	Attempts    int       `json:"attempts,omitempty"`
+	Queued      time.Time `json:"queued,omitempty"`
+	Expires     time.Time `json:"expires,omitempty"`
+	NextAttempt time.Time `json:"next_attempt,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// RetryResult summarizes one pass over the retry queue
+type RetryResult struct {
+	Delivered int // Sent successfully
+	Dropped   int // Expired or failed permanently
+	Pending   int // Still queued for a later retry
+}
+
+// Queue keeps webhook deliveries that failed with a temporary error (no
+// network, server down, rate limited) on disk and retries them with
+// exponential backoff. It is shared by all webhook backends.
+type Queue struct {
+	path   string
+	client *http.Client
+}
+
+// NewQueue creates the retry queue stored in dir
+func NewQueue(dir string) *Queue {
+	return &Queue{
+		path:   filepath.Join(dir, QueueFileName),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Path returns the queue file
+func (q *Queue) Path() string {
+	return q.path
+}
+
+// Len returns the number of queued deliveries
+func (q *Queue) Len() (int, error) {
+	list, err := readDeliveries(q.path)
+	return len(list), err
+}
+
+// Add queues a delivery that failed with lastErr; it is retried after the
+// first backoff until maxAge has passed
+func (q *Queue) Add(d Delivery, lastErr error, maxAge time.Duration, now time.Time) error {
+	if maxAge <= 0 {
+		maxAge = DefaultQueueMaxAge
+	}
+	d.Attempts = 1
+	d.Queued = now
+	d.Expires = now.Add(maxAge)
+	d.NextAttempt = now.Add(queueBackoff(d.Attempts))
+	if lastErr != nil {
+		d.LastError = lastErr.Error()
+	}
+	return q.append([]Delivery{d})
+}
+
+// Retry sends the queued deliveries that are due. Deliveries that fail
+// with a temporary error are queued again with a longer backoff; once one
+// fails without reaching the server, the remaining ones wait too, since
+// the network is likely still down. The queue file is claimed by
+// renaming it, so concurrent hooks never send a delivery twice.
+func (q *Queue) Retry(ctx context.Context, now time.Time) (RetryResult, error) {
+	var result RetryResult
+	if _, err := os.Stat(q.path); err != nil {
+		return result, nil
+	}
+	claimed := fmt.Sprintf("%s.%d", q.path, os.Getpid())
+	if err := os.Rename(q.path, claimed); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return result, nil
+		}
+		return result, fmt.Errorf("failed to claim webhook queue: %w", err)
+	}
+	defer os.Remove(claimed)
+
+	entries, err := readDeliveries(claimed)
+	if err != nil {
+		return result, err
+	}
+
+	var keep []Delivery
+	offline := false
+	for _, d := range entries {
+		if now.After(d.Expires) {
+			result.Dropped++
+			continue
+		}
+		if offline || now.Before(d.NextAttempt) {
+			keep = append(keep, d)
+			continue
+		}
+
+		err := postDelivery(ctx, q.client, uuid.New().String(), d)
+		if err == nil {
+			result.Delivered++
+			continue
+		}
+		if !isRetryableError(err) {
+			result.Dropped++
+			continue
+		}
+		var httpErr *HTTPError
+		offline = !errors.As(err, &httpErr)
+		d.Attempts++
+		d.NextAttempt = now.Add(queueBackoff(d.Attempts))
+		d.LastError = err.Error()
+		keep = append(keep, d)
+	}
+
+	result.Pending = len(keep)
+	if err := q.append(keep); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// append adds deliveries to the queue file, keeping the newest
+// queueMaxEntries
+func (q *Queue) append(list []Delivery) error {
+	if len(list) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(q.path), 0755); err != nil {
+		return fmt.Errorf("failed to create webhook queue directory: %w", err)
+	}
+	existing, err := readDeliveries(q.path)
+	if err != nil {
+		return err
+	}
+	all := append(existing, list...)
+	if len(all) <= queueMaxEntries {
+		return writeDeliveries(q.path, list, os.O_APPEND)
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Queued.Before(all[j].Queued) })
+	return writeDeliveries(q.path, all[len(all)-queueMaxEntries:], os.O_TRUNC)
+}
+
+// readDeliveries reads a queue file, skipping corrupted lines
+func readDeliveries(path string) ([]Delivery, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read webhook queue: %w", err)
+	}
+	defer f.Close()
+
+	var list []Delivery
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var d Delivery
+		if err := json.Unmarshal(scanner.Bytes(), &d); err != nil {
+			continue // skip corrupted lines
+		}
+		list = append(list, d)
+	}
+	return list, scanner.Err()
+}
+
+// writeDeliveries writes deliveries to path, appending or replacing
+// (mode). The file holds auth headers, so only the owner can read it.
+func writeDeliveries(path string, list []Delivery, mode int) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|mode, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open webhook queue: %w", err)
+	}
+	defer f.Close()
+	for _, d := range list {
+		data, err := json.Marshal(d)
+		if err != nil {
+			return fmt.Errorf("failed to serialize queued webhook: %w", err)
+		}
+		if _, err := f.Write(append(data, '\n')); err != nil {
+			return fmt.Errorf("failed to write webhook queue: %w", err)
+		}
+	}
+	return nil
+}
+
+// queueBackoff returns the wait before retry number attempts
+func queueBackoff(attempts int) time.Duration {
+	backoff := queueInitialBackoff
+	for i := 1; i < attempts && backoff < queueMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > queueMaxBackoff {
+		backoff = queueMaxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,224 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+)
+
+// queueServer counts requests and answers with the current status code
+func queueServer(t *testing.T, status *int32, hits *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		w.WriteHeader(int(atomic.LoadInt32(status)))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func queuedDelivery(url string) Delivery {
+	return Delivery{
+		Backend:     "test",
+		URL:         url,
+		ContentType: "application/json",
+		Headers:     map[string]string{"Authorization": "Bearer secret"},
+		Payload:     []byte(`{"text":"hi"}`),
+	}
+}
+
+func TestQueueRetryDelivers(t *testing.T) {
+	status, hits := int32(http.StatusOK), int32(0)
+	server := queueServer(t, &status, &hits)
+	q := NewQueue(t.TempDir())
+	now := time.Now()
+
+	if err := q.Add(queuedDelivery(server.URL), errors.New("offline"), 0, now); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	info, err := os.Stat(q.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("queue file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	// Not due before the first backoff
+	result, err := q.Retry(context.Background(), now.Add(time.Second))
+	if err != nil || result.Pending != 1 || atomic.LoadInt32(&hits) != 0 {
+		t.Fatalf("Retry(early) = %+v, %v, hits %d; want 1 pending, no request", result, err, atomic.LoadInt32(&hits))
+	}
+
+	result, err = q.Retry(context.Background(), now.Add(queueInitialBackoff+time.Second))
+	if err != nil || result.Delivered != 1 || result.Pending != 0 {
+		t.Fatalf("Retry(due) = %+v, %v; want 1 delivered", result, err)
+	}
+	if _, err := os.Stat(q.Path()); !os.IsNotExist(err) {
+		t.Errorf("queue file should be gone after delivery, stat error = %v", err)
+	}
+}
+
+func TestQueueRetryExpired(t *testing.T) {
+	status, hits := int32(http.StatusOK), int32(0)
+	server := queueServer(t, &status, &hits)
+	q := NewQueue(t.TempDir())
+	now := time.Now()
+
+	if err := q.Add(queuedDelivery(server.URL), nil, time.Minute, now); err != nil {
+		t.Fatal(err)
+	}
+	result, err := q.Retry(context.Background(), now.Add(2*time.Minute))
+	if err != nil || result.Dropped != 1 || atomic.LoadInt32(&hits) != 0 {
+		t.Errorf("Retry() = %+v, %v, hits %d; want 1 dropped, no request", result, err, atomic.LoadInt32(&hits))
+	}
+}
+
+func TestQueueRetryFailures(t *testing.T) {
+	status, hits := int32(http.StatusInternalServerError), int32(0)
+	server := queueServer(t, &status, &hits)
+	q := NewQueue(t.TempDir())
+	now := time.Now()
+
+	if err := q.Add(queuedDelivery(server.URL), nil, 0, now); err != nil {
+		t.Fatal(err)
+	}
+
+	// A server error is retried later with a longer backoff
+	due := now.Add(queueInitialBackoff)
+	result, err := q.Retry(context.Background(), due)
+	if err != nil || result.Pending != 1 {
+		t.Fatalf("Retry(500) = %+v, %v; want 1 pending", result, err)
+	}
+	list, err := readDeliveries(q.Path())
+	if err != nil || len(list) != 1 {
+		t.Fatalf("readDeliveries() = %v, %v", list, err)
+	}
+	if list[0].Attempts != 2 || !list[0].NextAttempt.Equal(due.Add(2*queueInitialBackoff)) {
+		t.Errorf("after 500: attempts %d, next %v", list[0].Attempts, list[0].NextAttempt)
+	}
+	if !strings.Contains(list[0].LastError, "500") {
+		t.Errorf("LastError = %q", list[0].LastError)
+	}
+
+	// A client error is permanent
+	atomic.StoreInt32(&status, http.StatusNotFound)
+	result, err = q.Retry(context.Background(), list[0].NextAttempt)
+	if err != nil || result.Dropped != 1 || result.Pending != 0 {
+		t.Errorf("Retry(404) = %+v, %v; want 1 dropped", result, err)
+	}
+}
+
+func TestQueueRetryOffline(t *testing.T) {
+	status, hits := int32(http.StatusOK), int32(0)
+	server := queueServer(t, &status, &hits)
+	q := NewQueue(t.TempDir())
+	now := time.Now()
+
+	// Nothing listens on the first URL; the second delivery must wait
+	down := httptest.NewServer(http.NotFoundHandler())
+	downURL := down.URL
+	down.Close()
+	if err := q.Add(queuedDelivery(downURL), nil, 0, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Add(queuedDelivery(server.URL), nil, 0, now); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := q.Retry(context.Background(), now.Add(queueInitialBackoff))
+	if err != nil || result.Pending != 2 || atomic.LoadInt32(&hits) != 0 {
+		t.Errorf("Retry() = %+v, %v, hits %d; want 2 pending, no request", result, err, atomic.LoadInt32(&hits))
+	}
+}
+
+func TestQueueCap(t *testing.T) {
+	q := NewQueue(t.TempDir())
+	start := time.Now()
+	for i := 0; i < queueMaxEntries+5; i++ {
+		if err := q.Add(queuedDelivery("http://127.0.0.1:1"), nil, 0, start.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, err := readDeliveries(q.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != queueMaxEntries {
+		t.Fatalf("queue has %d entries, want %d", len(list), queueMaxEntries)
+	}
+	if n, err := q.Len(); err != nil || n != queueMaxEntries {
+		t.Errorf("Len() = %d, %v; want %d", n, err, queueMaxEntries)
+	}
+	if !list[0].Queued.Equal(start.Add(5 * time.Second)) {
+		t.Errorf("oldest kept entry queued at %v, want the 6th", list[0].Queued)
+	}
+}
+
+func TestQueueBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{20, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := queueBackoff(tt.attempts); got != tt.want {
+			t.Errorf("queueBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestSenderQueuesFailedDelivery(t *testing.T) {
+	status, hits := int32(http.StatusServiceUnavailable), int32(0)
+	server := queueServer(t, &status, &hits)
+	q := NewQueue(t.TempDir())
+
+	sender := New(newTestConfig(server.URL))
+	sender.SetQueue(q)
+	err := sender.Send(analyzer.StatusTaskComplete, "Test message", "session-123", Meta{})
+	if err == nil || !strings.Contains(err.Error(), "queued for retry") {
+		t.Fatalf("Send() error = %v, want queued for retry", err)
+	}
+
+	list, err := readDeliveries(q.Path())
+	if err != nil || len(list) != 1 {
+		t.Fatalf("readDeliveries() = %v, %v; want 1 entry", list, err)
+	}
+	if list[0].URL != server.URL || !strings.Contains(string(list[0].Payload), "Test message") {
+		t.Errorf("queued delivery = %+v", list[0])
+	}
+
+	// Once the server is back, the queued delivery goes through
+	atomic.StoreInt32(&status, http.StatusOK)
+	result, err := q.Retry(context.Background(), list[0].NextAttempt)
+	if err != nil || result.Delivered != 1 {
+		t.Errorf("Retry() = %+v, %v; want 1 delivered", result, err)
+	}
+}
+
+func TestSenderDoesNotQueuePermanentFailure(t *testing.T) {
+	status, hits := int32(http.StatusBadRequest), int32(0)
+	server := queueServer(t, &status, &hits)
+	q := NewQueue(t.TempDir())
+
+	sender := New(newTestConfig(server.URL))
+	sender.SetQueue(q)
+	if err := sender.Send(analyzer.StatusTaskComplete, "Test", "session-123", Meta{}); err == nil {
+		t.Fatal("Send() should fail on 400")
+	}
+	if _, err := os.Stat(q.Path()); !os.IsNotExist(err) {
+		t.Errorf("permanent failure was queued, stat error = %v", err)
+	}
+}
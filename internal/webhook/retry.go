@@ -2,6 +2,7 @@ package webhook
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
@@ -117,12 +118,19 @@ func (r *Retryer) calculateBackoff(attempt int) time.Duration {
 // Permanent errors (4xx except 429) should not be retried
 // Temporary errors (5xx, network errors, timeouts) should be retried
 func (r *Retryer) isRetryable(err error) bool {
+	return isRetryableError(err)
+}
+
+// isRetryableError reports whether err is temporary; it also decides
+// whether a failed delivery goes to the retry queue
+func isRetryableError(err error) bool {
 	if err == nil {
 		return false
 	}
 
 	// Check for HTTPError
-	if httpErr, ok := err.(*HTTPError); ok {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
 		// 4xx Client Errors (except 429 Too Many Requests) are permanent
 		if httpErr.StatusCode >= 400 && httpErr.StatusCode < 500 {
 			return httpErr.StatusCode == 429 // Only 429 is retryable
@@ -0,0 +1,73 @@
+package webhook
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Block Kit text limits
+const (
+	slackHeaderMaxLength  = 150
+	slackSectionMaxLength = 3000
+)
+
+// slackEscapeReplacer escapes the characters Slack treats as control sequences
+var slackEscapeReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackEscape escapes text for Slack mrkdwn so "<" in code isn't parsed as a link
+func slackEscape(s string) string {
+	return slackEscapeReplacer.Replace(s)
+}
+
+// slackMrkdwn returns a Block Kit mrkdwn text object
+func slackMrkdwn(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "mrkdwn",
+		"text": text,
+	}
+}
+
+// slackChannel returns the channel for project: a per-project override if configured,
+// otherwise the default channel (may be empty for incoming webhooks)
+func slackChannel(defaultChannel string, channels map[string]string, project string) string {
+	if channel, ok := channels[project]; ok && project != "" {
+		return channel
+	}
+	return defaultChannel
+}
+
+// slackMention returns the mrkdwn for a mention: "here" and "channel"
+// notify a channel's members, IDs starting with S are user groups and other
+// IDs users ("" = no mention)
+func slackMention(mention string) string {
+	mention = strings.TrimPrefix(mention, "@")
+	switch {
+	case mention == "":
+		return ""
+	case mention == "here" || mention == "channel":
+		return "<!" + mention + ">"
+	case strings.HasPrefix(mention, "S"):
+		return "<!subteam^" + mention + ">"
+	default:
+		return "<@" + mention + ">"
+	}
+}
+
+// checkSlackAPIResponse reports Web API failures, which Slack returns as
+// HTTP 200 with {"ok": false, "error": "..."}. They are treated as permanent.
+func checkSlackAPIResponse(body []byte) error {
+	var resp struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return &HTTPError{StatusCode: 400, Status: "Slack API error", Body: "invalid response: " + string(body)}
+	}
+	if !resp.OK {
+		if resp.Error == "ratelimited" {
+			return &HTTPError{StatusCode: 429, Status: "Slack API error", Body: resp.Error}
+		}
+		return &HTTPError{StatusCode: 400, Status: "Slack API error", Body: resp.Error}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package webhook
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+func slackContextText(t *testing.T, payload map[string]interface{}) string {
+	t.Helper()
+	blocks := payload["attachments"].([]map[string]interface{})[0]["blocks"].([]map[string]interface{})
+	var parts []string
+	for _, e := range blocks[2]["elements"].([]map[string]interface{}) {
+		parts = append(parts, e["text"].(string))
+	}
+	return strings.Join(parts, " | ")
+}
+
+func TestSlackFormatterContext(t *testing.T) {
+	formatter := &SlackFormatter{}
+
+	result, err := formatter.Format(analyzer.StatusTaskComplete, "done", "s-1",
+		config.StatusInfo{Title: "✅ Completed"}, Meta{Project: "my-app", Elapsed: 125*time.Second + 300*time.Millisecond})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ctxText := slackContextText(t, result.(map[string]interface{}))
+	for _, want := range []string{"*Project:* my-app", "*Event:* `task_complete`", "*Elapsed:* 2m5s", "Session: s-1"} {
+		if !strings.Contains(ctxText, want) {
+			t.Errorf("Context should contain %q, got: %s", want, ctxText)
+		}
+	}
+}
+
+func TestSlackFormatterOmitsUnknownContext(t *testing.T) {
+	formatter := &SlackFormatter{}
+
+	result, _ := formatter.Format(analyzer.StatusQuestion, "?", "s-1", config.StatusInfo{Title: "Q"}, Meta{})
+
+	ctxText := slackContextText(t, result.(map[string]interface{}))
+	if strings.Contains(ctxText, "Project") || strings.Contains(ctxText, "Elapsed") {
+		t.Errorf("Context should omit unknown project and elapsed time, got: %s", ctxText)
+	}
+}
+
+func TestSlackFormatterEscapesMessage(t *testing.T) {
+	formatter := &SlackFormatter{}
+
+	result, _ := formatter.Format(analyzer.StatusTaskComplete, "if a < b && c > d", "s-1", config.StatusInfo{Title: "Done"}, Meta{})
+	payload := result.(map[string]interface{})
+
+	section := payload["attachments"].([]map[string]interface{})[0]["blocks"].([]map[string]interface{})[1]["text"].(map[string]interface{})
+	if section["text"] != "if a &lt; b &amp;&amp; c &gt; d" {
+		t.Errorf("Message should be escaped, got: %v", section["text"])
+	}
+}
+
+func TestSlackFormatterChannel(t *testing.T) {
+	formatter := &SlackFormatter{
+		Channel:  "#claude",
+		Channels: map[string]string{"billing": "#team-billing"},
+	}
+
+	result, _ := formatter.Format(analyzer.StatusTaskComplete, "done", "s", config.StatusInfo{}, Meta{Project: "billing"})
+	if ch := result.(map[string]interface{})["channel"]; ch != "#team-billing" {
+		t.Errorf("channel = %v, want #team-billing", ch)
+	}
+
+	result, _ = formatter.Format(analyzer.StatusTaskComplete, "done", "s", config.StatusInfo{}, Meta{Project: "other"})
+	if ch := result.(map[string]interface{})["channel"]; ch != "#claude" {
+		t.Errorf("channel = %v, want #claude", ch)
+	}
+}
+
+func TestSlackFormatterMentionsOnCritical(t *testing.T) {
+	formatter := &SlackFormatter{Mention: "here"}
+
+	result, _ := formatter.Format(analyzer.StatusAPIError, "boom", "s", config.StatusInfo{Title: "API Error"}, Meta{})
+	if text := result.(map[string]interface{})["text"]; text != "<!here> API Error: boom" {
+		t.Errorf("text = %v, want mention for critical status", text)
+	}
+
+	result, _ = formatter.Format(analyzer.StatusTaskComplete, "done", "s", config.StatusInfo{Title: "Done"}, Meta{})
+	if text := result.(map[string]interface{})["text"]; text != "Done: done" {
+		t.Errorf("text = %v, want no mention for normal priority", text)
+	}
+
+	result, _ = formatter.Format(analyzer.StatusTaskComplete, "done", "s", config.StatusInfo{Title: "Done"}, Meta{Priority: "critical"})
+	if text := result.(map[string]interface{})["text"]; text != "<!here> Done: done" {
+		t.Errorf("text = %v, want mention when a rule makes it critical", text)
+	}
+
+	result, _ = (&SlackFormatter{}).Format(analyzer.StatusAPIError, "boom", "s", config.StatusInfo{Title: "API Error"}, Meta{})
+	if text := result.(map[string]interface{})["text"]; text != "API Error: boom" {
+		t.Errorf("text = %v, want no mention without slack.mention", text)
+	}
+}
+
+func TestSlackMention(t *testing.T) {
+	tests := []struct {
+		mention string
+		want    string
+	}{
+		{"", ""},
+		{"here", "<!here>"},
+		{"@channel", "<!channel>"},
+		{"U024BE7LH", "<@U024BE7LH>"},
+		{"S0614TZR7", "<!subteam^S0614TZR7>"},
+	}
+	for _, tt := range tests {
+		if got := slackMention(tt.mention); got != tt.want {
+			t.Errorf("slackMention(%q) = %q, want %q", tt.mention, got, tt.want)
+		}
+	}
+}
+
+func TestSlackChannel(t *testing.T) {
+	channels := map[string]string{"api": "C123", "": "#never"}
+
+	tests := []struct {
+		project string
+		want    string
+	}{
+		{"api", "C123"},
+		{"web", "#default"},
+		{"", "#default"},
+	}
+	for _, tt := range tests {
+		if got := slackChannel("#default", channels, tt.project); got != tt.want {
+			t.Errorf("slackChannel(%q) = %q, want %q", tt.project, got, tt.want)
+		}
+	}
+}
+
+func TestCheckSlackAPIResponse(t *testing.T) {
+	if err := checkSlackAPIResponse([]byte(`{"ok":true,"ts":"1.2"}`)); err != nil {
+		t.Errorf("ok response should succeed, got %v", err)
+	}
+
+	err := checkSlackAPIResponse([]byte(`{"ok":false,"error":"channel_not_found"}`))
+	httpErr, ok := err.(*HTTPError)
+	if !ok || httpErr.StatusCode != 400 || !strings.Contains(err.Error(), "channel_not_found") {
+		t.Errorf("expected permanent error with Slack error code, got %v", err)
+	}
+
+	err = checkSlackAPIResponse([]byte(`{"ok":false,"error":"ratelimited"}`))
+	if httpErr, ok := err.(*HTTPError); !ok || httpErr.StatusCode != 429 {
+		t.Errorf("ratelimited should map to 429, got %v", err)
+	}
+
+	if err := checkSlackAPIResponse([]byte(`ok`)); err == nil {
+		t.Error("non-JSON response should fail")
+	}
+}
@@ -0,0 +1,96 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/priority"
+)
+
+// TemplateData holds the notification fields available to a custom body template
+type TemplateData struct {
+	Status         string // e.g. "task_complete"
+	Title          string // Status title from config, e.g. "✅ Completed"
+	Priority       string // "low", "normal" or "critical"
+	Message        string
+	SessionID      string
+	Project        string // Folder name of the working directory (may be empty)
+	Repo           string // Git repository name (empty outside git repos)
+	Branch         string // Git branch (empty outside git repos)
+	Elapsed        string // e.g. "4m12s" (empty when unknown)
+	ElapsedSeconds int64
+	Timestamp      string // RFC 3339
+	Unix           int64
+	Source         string // Always "claude-notifications"
+}
+
+// templateFuncs are the helpers available in body templates.
+// json is the one to use for string values: it quotes and escapes them.
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"truncate": truncateRunes,
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+}
+
+// ParseBodyTemplate parses a custom webhook body template
+func ParseBodyTemplate(text string) (*template.Template, error) {
+	return template.New("webhook").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// NewTemplateData builds the template fields for a notification
+func NewTemplateData(status analyzer.Status, title, message, sessionID string, meta Meta, now time.Time) TemplateData {
+	data := TemplateData{
+		Status:    string(status),
+		Title:     title,
+		Priority:  priority.Resolve(status, meta.Priority),
+		Message:   message,
+		SessionID: sessionID,
+		Project:   meta.Project,
+		Repo:      meta.Repo,
+		Branch:    meta.Branch,
+		Timestamp: now.Format(time.RFC3339),
+		Unix:      now.Unix(),
+		Source:    "claude-notifications",
+	}
+	if meta.Elapsed > 0 {
+		elapsed := meta.Elapsed.Round(time.Second)
+		data.Elapsed = elapsed.String()
+		data.ElapsedSeconds = int64(elapsed.Seconds())
+	}
+	return data
+}
+
+// Render executes tmpl with data and returns the text
+func Render(tmpl *template.Template, data TemplateData) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// renderBodyTemplate executes tmpl. With format "json" the result must be valid
+// JSON, so a quoting mistake fails loudly instead of being rejected by the server.
+func renderBodyTemplate(tmpl *template.Template, format string, data TemplateData) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, "", fmt.Errorf("failed to render webhook template: %w", err)
+	}
+
+	if format == "text" {
+		return buf.Bytes(), "text/plain", nil
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, "", fmt.Errorf("webhook template produced invalid JSON (use {{json .Message}} to quote strings): %s", truncateRunes(buf.String(), 200))
+	}
+	return buf.Bytes(), "application/json", nil
+}
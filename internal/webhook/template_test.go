@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+)
+
+func TestNewTemplateData(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	data := NewTemplateData(analyzer.StatusTaskComplete, "✅ Completed", "done", "s-1",
+		Meta{Project: "my-app", Elapsed: 252*time.Second + 400*time.Millisecond}, now)
+
+	if data.Status != "task_complete" || data.Title != "✅ Completed" || data.Project != "my-app" {
+		t.Errorf("unexpected data: %+v", data)
+	}
+	if data.Elapsed != "4m12s" || data.ElapsedSeconds != 252 {
+		t.Errorf("Elapsed = %q/%d, want 4m12s/252", data.Elapsed, data.ElapsedSeconds)
+	}
+	if data.Timestamp != "2025-03-01T12:00:00Z" || data.Unix != now.Unix() {
+		t.Errorf("Timestamp = %q/%d", data.Timestamp, data.Unix)
+	}
+
+	empty := NewTemplateData(analyzer.StatusQuestion, "", "", "", Meta{}, now)
+	if empty.Elapsed != "" || empty.ElapsedSeconds != 0 {
+		t.Errorf("unknown elapsed should be empty, got %q/%d", empty.Elapsed, empty.ElapsedSeconds)
+	}
+}
+
+func TestRenderBodyTemplate_JSON(t *testing.T) {
+	tmpl, err := ParseBodyTemplate(`{"title": {{json .Title}}, "message": {{json (truncate .Message 8)}}, "priority": {{if eq .Status "question"}}8{{else}}5{{end}}}`)
+	if err != nil {
+		t.Fatalf("ParseBodyTemplate: %v", err)
+	}
+
+	body, contentType, err := renderBodyTemplate(tmpl, "json", TemplateData{
+		Status:  "question",
+		Title:   `Say "hi"`,
+		Message: "line1\nline2 and more",
+	})
+	if err != nil {
+		t.Fatalf("renderBodyTemplate: %v", err)
+	}
+	if contentType != "application/json" {
+		t.Errorf("contentType = %q, want application/json", contentType)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("body is not JSON: %v (%s)", err, body)
+	}
+	if got["title"] != `Say "hi"` || got["message"] != "line1\nl…" || got["priority"] != float64(8) {
+		t.Errorf("unexpected body: %s", body)
+	}
+}
+
+func TestRenderBodyTemplate_InvalidJSON(t *testing.T) {
+	tmpl, _ := ParseBodyTemplate(`{"message": "{{.Message}}"}`)
+
+	_, _, err := renderBodyTemplate(tmpl, "json", TemplateData{Message: `has "quotes"`})
+	if err == nil || !strings.Contains(err.Error(), "invalid JSON") {
+		t.Errorf("expected invalid JSON error, got %v", err)
+	}
+}
+
+func TestRenderBodyTemplate_Text(t *testing.T) {
+	tmpl, _ := ParseBodyTemplate(`{{upper .Status}}: {{.Message}}`)
+
+	body, contentType, err := renderBodyTemplate(tmpl, "text", TemplateData{Status: "plan_ready", Message: "Plan <ready>"})
+	if err != nil {
+		t.Fatalf("renderBodyTemplate: %v", err)
+	}
+	if contentType != "text/plain" || string(body) != "PLAN_READY: Plan <ready>" {
+		t.Errorf("got %q (%s)", body, contentType)
+	}
+}
+
+func TestParseBodyTemplate_Errors(t *testing.T) {
+	if _, err := ParseBodyTemplate(`{{.Message`); err == nil {
+		t.Error("expected parse error for unclosed action")
+	}
+
+	tmpl, err := ParseBodyTemplate(`{{.Unknown}}`)
+	if err != nil {
+		t.Fatalf("ParseBodyTemplate: %v", err)
+	}
+	if _, _, err := renderBodyTemplate(tmpl, "text", TemplateData{}); err == nil {
+		t.Error("expected execution error for unknown field")
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
+	"text/template"
 	"time"
 
 	"github.com/777genius/claude-notifications/internal/analyzer"
@@ -21,12 +23,17 @@ import (
 // Sender sends webhook notifications with professional patterns
 type Sender struct {
 	cfg            *config.Config
+	webhookCfg     config.WebhookConfig
 	client         *http.Client
 	retry          *Retryer
 	circuitBreaker *CircuitBreaker
 	rateLimiter    *RateLimiter
 	metrics        *Metrics
 	formatters     map[string]Formatter
+	bodyTemplate   *template.Template // Custom preset body template (nil = built-in payload)
+	templateErr    error              // Set when the configured template fails to parse
+	queue          *Queue             // Keeps deliveries that failed temporarily (nil = drop them)
+	queueMaxAge    time.Duration
 
 	// Graceful shutdown
 	wg     sync.WaitGroup
@@ -34,19 +41,39 @@ type Sender struct {
 	cancel context.CancelFunc
 }
 
-// New creates a new professional webhook sender
+// New creates a new professional webhook sender for the primary webhook config
 func New(cfg *config.Config) *Sender {
+	return NewForWebhook(cfg, cfg.Notifications.Webhook)
+}
+
+// NewForWebhook creates a sender for one webhook backend, e.g. an entry of
+// notifications.webhooks. cfg supplies the status titles.
+func NewForWebhook(cfg *config.Config, webhookCfg config.WebhookConfig) *Sender {
 	// Create base HTTP client with timeout
+	timeout, _ := time.ParseDuration(webhookCfg.Timeout)
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
 	client := &http.Client{
-		Timeout: 10 * time.Second,
+		Timeout: timeout,
+	}
+
+	// Parse custom body template
+	var bodyTemplate *template.Template
+	var templateErr error
+	if webhookCfg.Template != "" {
+		bodyTemplate, templateErr = ParseBodyTemplate(webhookCfg.Template)
+		if templateErr != nil {
+			logging.Error("Invalid webhook template: %v", templateErr)
+		}
 	}
 
 	// Parse retry config
-	retryConfig := parseRetryConfig(cfg.Notifications.Webhook.Retry)
+	retryConfig := parseRetryConfig(webhookCfg.Retry)
 	retry := NewRetryer(retryConfig)
 
 	// Parse circuit breaker config
-	cbCfg := cfg.Notifications.Webhook.CircuitBreaker
+	cbCfg := webhookCfg.CircuitBreaker
 	var circuitBreaker *CircuitBreaker
 	if cbCfg.Enabled {
 		timeout, _ := time.ParseDuration(cbCfg.Timeout)
@@ -58,37 +85,65 @@ func New(cfg *config.Config) *Sender {
 
 	// Create rate limiter
 	var rateLimiter *RateLimiter
-	if cfg.Notifications.Webhook.RateLimit.Enabled {
-		rateLimiter = NewRateLimiter(cfg.Notifications.Webhook.RateLimit.RequestsPerMinute)
+	if webhookCfg.RateLimit.Enabled {
+		rateLimiter = NewRateLimiter(webhookCfg.RateLimit.RequestsPerMinute)
 	}
 
 	// Create formatters
+	ntfyCfg := webhookCfg.Ntfy
+	pushoverCfg := webhookCfg.Pushover
+	telegramCfg := webhookCfg.Telegram
+	slackCfg := webhookCfg.Slack
+	_, ntfyTopic := ntfyTarget(webhookCfg.URL, ntfyCfg.Topic)
 	formatters := map[string]Formatter{
-		"slack":    &SlackFormatter{},
+		"slack":    &SlackFormatter{Channel: slackCfg.Channel, Channels: slackCfg.Channels, Mention: slackCfg.Mention},
 		"discord":  &DiscordFormatter{},
-		"telegram": &TelegramFormatter{ChatID: cfg.Notifications.Webhook.ChatID},
+		"telegram": &TelegramFormatter{ChatID: webhookCfg.ChatID, PreviewLength: telegramCfg.PreviewLength},
 		"lark":     &LarkFormatter{},
+		"ntfy":     &NtfyFormatter{Topic: ntfyTopic, Priority: ntfyCfg.Priority, Tags: ntfyCfg.Tags},
+		"pushover": &PushoverFormatter{
+			UserKey:    pushoverCfg.UserKey,
+			AppToken:   pushoverCfg.AppToken,
+			Priorities: pushoverCfg.Priorities,
+			Retry:      pushoverCfg.Retry,
+			Expire:     pushoverCfg.Expire,
+			Device:     pushoverCfg.Device,
+		},
+		"gotify": NewGotifyFormatter(webhookCfg.Gotify),
+		"matrix": &MatrixFormatter{MsgType: webhookCfg.Matrix.MsgType, Mention: webhookCfg.Matrix.Mention},
 	}
 
+	queueMaxAge, _ := time.ParseDuration(webhookCfg.Retry.QueueMaxAge)
+
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &Sender{
 		cfg:            cfg,
+		webhookCfg:     webhookCfg,
 		client:         client,
 		retry:          retry,
 		circuitBreaker: circuitBreaker,
 		rateLimiter:    rateLimiter,
 		metrics:        NewMetrics(),
 		formatters:     formatters,
+		bodyTemplate:   bodyTemplate,
+		templateErr:    templateErr,
+		queueMaxAge:    queueMaxAge,
 		ctx:            ctx,
 		cancel:         cancel,
 	}
 }
 
+// SetQueue makes the sender keep deliveries that fail with a temporary
+// error in q for a later retry, instead of dropping them
+func (s *Sender) SetQueue(q *Queue) {
+	s.queue = q
+}
+
 // Send sends a webhook notification with full professional stack
-func (s *Sender) Send(status analyzer.Status, message, sessionID string) error {
-	if !s.cfg.IsWebhookEnabled() {
+func (s *Sender) Send(status analyzer.Status, message, sessionID string, meta Meta) error {
+	if !s.webhookCfg.Enabled {
 		logging.Debug("Webhooks disabled, skipping")
 		return nil
 	}
@@ -104,7 +159,7 @@ func (s *Sender) Send(status analyzer.Status, message, sessionID string) error {
 	if s.circuitBreaker != nil && s.circuitBreaker.GetState() == StateOpen {
 		s.metrics.RecordCircuitOpen()
 		logging.Warn("Circuit breaker is open, skipping webhook")
-		return ErrCircuitOpen
+		return s.enqueue("", status, message, sessionID, meta, ErrCircuitOpen)
 	}
 
 	// Generate request ID for tracing
@@ -115,13 +170,14 @@ func (s *Sender) Send(status analyzer.Status, message, sessionID string) error {
 	start := time.Now()
 
 	// Execute with retry and circuit breaker
-	err := s.sendWithRetryAndCircuitBreaker(requestID, status, message, sessionID)
+	err := s.sendWithRetryAndCircuitBreaker(requestID, status, message, sessionID, meta)
 
 	// Record result
 	latency := time.Since(start)
 	if err != nil {
 		s.metrics.RecordFailure()
 		logging.Error("[%s] Webhook failed after retries: %v (latency: %v)", requestID, err, latency)
+		err = s.enqueue(requestID, status, message, sessionID, meta, err)
 	} else {
 		s.metrics.RecordSuccess(status, latency)
 		logging.Info("[%s] Webhook sent successfully (latency: %v)", requestID, latency)
@@ -135,24 +191,86 @@ func (s *Sender) Send(status analyzer.Status, message, sessionID string) error {
 	return err
 }
 
-// sendWithRetryAndCircuitBreaker executes the webhook with retry and circuit breaker
-func (s *Sender) sendWithRetryAndCircuitBreaker(requestID string, status analyzer.Status, message, sessionID string) error {
-	webhookCfg := s.cfg.Notifications.Webhook
+// enqueue keeps a delivery that failed with a temporary error in the
+// retry queue and returns sendErr, noting that it was queued
+func (s *Sender) enqueue(requestID string, status analyzer.Status, message, sessionID string, meta Meta, sendErr error) error {
+	if s.queue == nil || !isRetryableError(sendErr) {
+		return sendErr
+	}
+	d, err := s.buildDelivery(status, message, sessionID, meta)
+	if err != nil {
+		return sendErr
+	}
+	if err := s.queue.Add(d, sendErr, s.queueMaxAge, time.Now()); err != nil {
+		logging.Warn("[%s] Failed to queue webhook for retry: %v", requestID, err)
+		return sendErr
+	}
+	logging.Info("[%s] Webhook queued for retry", requestID)
+	return fmt.Errorf("%w (queued for retry)", sendErr)
+}
+
+// buildDelivery builds the HTTP request for a notification
+func (s *Sender) buildDelivery(status analyzer.Status, message, sessionID string, meta Meta) (Delivery, error) {
+	webhookCfg := s.webhookCfg
 
 	// Build payload
-	payload, contentType, err := s.buildPayload(status, message, sessionID)
+	payload, contentType, err := s.buildPayload(status, message, sessionID, meta)
 	if err != nil {
-		return fmt.Errorf("failed to build payload: %w", err)
+		return Delivery{}, fmt.Errorf("failed to build payload: %w", err)
 	}
 
 	// Validate URL
 	if err := validateURL(webhookCfg.URL); err != nil {
-		return fmt.Errorf("invalid webhook URL: %w", err)
+		return Delivery{}, fmt.Errorf("invalid webhook URL: %w", err)
+	}
+
+	// ntfy accepts JSON only at the server root and Gotify at /message; ntfy,
+	// Matrix and the Slack Web API authenticate with a Bearer token, Gotify
+	// with its own header
+	requestURL, headers, method := webhookCfg.URL, webhookCfg.Headers, ""
+	switch webhookCfg.Preset {
+	case "ntfy":
+		requestURL, _ = ntfyTarget(webhookCfg.URL, webhookCfg.Ntfy.Topic)
+		headers = withBearerAuth(webhookCfg.Headers, webhookCfg.Ntfy.Token)
+	case "slack":
+		headers = withBearerAuth(webhookCfg.Headers, webhookCfg.Slack.BotToken)
+	case "gotify":
+		requestURL = gotifyTarget(webhookCfg.URL)
+		headers = withHeader(webhookCfg.Headers, "X-Gotify-Key", webhookCfg.Gotify.Token)
+	case "matrix":
+		// One transaction ID per notification, so the homeserver drops
+		// retries of an event it already stored
+		requestURL = matrixTarget(webhookCfg.URL, webhookCfg.Matrix.RoomID, uuid.New().String())
+		headers = withBearerAuth(webhookCfg.Headers, webhookCfg.Matrix.AccessToken)
+		method = http.MethodPut
+	}
+
+	backend := webhookCfg.Name
+	if backend == "" {
+		backend = webhookCfg.Preset
+	}
+	return Delivery{
+		Backend:     backend,
+		Method:      method,
+		URL:         requestURL,
+		ContentType: contentType,
+		Headers:     headers,
+		Payload:     payload,
+		// The Slack Web API reports errors in the body of a 200 response
+		SlackAPI: webhookCfg.Preset == "slack" && webhookCfg.Slack.BotToken != "",
+	}, nil
+}
+
+// sendWithRetryAndCircuitBreaker executes the webhook with retry and circuit breaker
+func (s *Sender) sendWithRetryAndCircuitBreaker(requestID string, status analyzer.Status, message, sessionID string, meta Meta) error {
+	d, err := s.buildDelivery(status, message, sessionID, meta)
+	if err != nil {
+		return err
 	}
 
 	// Create request function for retry
 	sendFn := func(ctx context.Context) error {
-		return s.sendHTTPRequest(ctx, requestID, webhookCfg.URL, payload, contentType, webhookCfg.Headers)
+		return postDelivery(ctx, s.client, requestID, d)
 	}
 
 	// Execute with circuit breaker and retry
@@ -172,13 +290,16 @@ func (s *Sender) sendWithRetryAndCircuitBreaker(requestID string, status analyze
 }
 
 // buildPayload builds the webhook payload based on preset
-func (s *Sender) buildPayload(status analyzer.Status, message, sessionID string) ([]byte, string, error) {
-	webhookCfg := s.cfg.Notifications.Webhook
+func (s *Sender) buildPayload(status analyzer.Status, message, sessionID string, meta Meta) ([]byte, string, error) {
+	webhookCfg := s.webhookCfg
 	statusInfo, _ := s.cfg.GetStatusInfo(string(status))
+	if meta.Title != "" {
+		statusInfo.Title = meta.Title
+	}
 
 	// Use formatter if available
 	if formatter, ok := s.formatters[webhookCfg.Preset]; ok {
-		payload, err := formatter.Format(status, message, sessionID, statusInfo)
+		payload, err := formatter.Format(status, message, sessionID, statusInfo, meta)
 		if err != nil {
 			return nil, "", err
 		}
@@ -186,6 +307,15 @@ func (s *Sender) buildPayload(status analyzer.Status, message, sessionID string)
 		return data, "application/json", err
 	}
 
+	// User-defined body template
+	if s.templateErr != nil {
+		return nil, "", fmt.Errorf("invalid webhook template: %w", s.templateErr)
+	}
+	if s.bodyTemplate != nil {
+		data := NewTemplateData(status, statusInfo.Title, message, sessionID, meta, time.Now())
+		return renderBodyTemplate(s.bodyTemplate, webhookCfg.Format, data)
+	}
+
 	// Fallback to custom format
 	return s.buildCustomPayload(status, message, sessionID, webhookCfg.Format, statusInfo)
 }
@@ -211,25 +341,29 @@ func (s *Sender) buildCustomPayload(status analyzer.Status, message, sessionID,
 	return data, "application/json", err
 }
 
-// sendHTTPRequest sends the actual HTTP request
-func (s *Sender) sendHTTPRequest(ctx context.Context, requestID, url string, payload []byte, contentType string, headers map[string]string) error {
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
+// postDelivery sends the actual HTTP request
+func postDelivery(ctx context.Context, client *http.Client, requestID string, d Delivery) error {
+	method := d.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequestWithContext(ctx, method, d.URL, bytes.NewReader(d.Payload))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set headers
-	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Content-Type", d.ContentType)
 	req.Header.Set("User-Agent", "claude-notifications/1.0")
 	req.Header.Set("X-Request-ID", requestID)
 
 	// Set custom headers
-	for key, value := range headers {
+	for key, value := range d.Headers {
 		req.Header.Set(key, value)
 	}
 
 	// Send request
-	resp, err := s.client.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("HTTP request failed: %w", err)
 	}
@@ -243,19 +377,61 @@ func (s *Sender) sendHTTPRequest(ctx context.Context, requestID, url string, pay
 		return NewHTTPError(resp, string(body))
 	}
 
+	if d.SlackAPI {
+		return checkSlackAPIResponse(body)
+	}
+
 	return nil
 }
 
+// withBearerAuth returns headers with Bearer auth for token added.
+// An explicit Authorization header in the webhook config wins.
+func withBearerAuth(headers map[string]string, token string) map[string]string {
+	if token == "" {
+		return withHeader(headers, "Authorization", "")
+	}
+	return withHeader(headers, "Authorization", "Bearer "+token)
+}
+
+// withHeader returns a copy of headers with name set to value, unless
+// headers already set name or value is empty
+func withHeader(headers map[string]string, name, value string) map[string]string {
+	result := make(map[string]string, len(headers)+1)
+	for k, v := range headers {
+		result[k] = v
+	}
+	if value == "" {
+		return result
+	}
+	for k := range result {
+		if strings.EqualFold(k, name) {
+			return result
+		}
+	}
+	result[name] = value
+	return result
+}
+
 // SendAsync sends a webhook asynchronously with graceful shutdown support
-func (s *Sender) SendAsync(status analyzer.Status, message, sessionID string) {
+func (s *Sender) SendAsync(status analyzer.Status, message, sessionID string, meta Meta) {
+	s.SendAsyncWithResult(status, message, sessionID, meta, nil)
+}
+
+// SendAsyncWithResult is SendAsync that reports the outcome to done
+// (nil error = delivered). done runs on the sending goroutine and may be nil.
+func (s *Sender) SendAsyncWithResult(status analyzer.Status, message, sessionID string, meta Meta, done func(err error)) {
 	s.wg.Add(1)
 	// Use SafeGo to protect against panics in async webhook sending
 	errorhandler.SafeGo(func() {
 		defer s.wg.Done()
 
-		if err := s.Send(status, message, sessionID); err != nil {
+		err := s.Send(status, message, sessionID, meta)
+		if err != nil {
 			errorhandler.HandleError(err, "Async webhook send failed")
 		}
+		if done != nil {
+			done(err)
+		}
 	})
 }
 
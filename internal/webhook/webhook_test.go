@@ -58,7 +58,7 @@ func TestSenderSendSuccess(t *testing.T) {
 	cfg := newTestConfig(server.URL)
 	sender := New(cfg)
 
-	err := sender.Send(analyzer.StatusTaskComplete, "Test message", "session-123")
+	err := sender.Send(analyzer.StatusTaskComplete, "Test message", "session-123", Meta{})
 	if err != nil {
 		t.Errorf("Expected success, got error: %v", err)
 	}
@@ -73,6 +73,60 @@ func TestSenderSendSuccess(t *testing.T) {
 	}
 }
 
+func TestNewForWebhookUsesOwnConfig(t *testing.T) {
+	var receivedPayload map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &receivedPayload)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// Primary webhook disabled; the additional webhook has its own URL and preset
+	cfg := newTestConfig("http://127.0.0.1:1/unused")
+	cfg.Notifications.Webhook.Enabled = false
+	sender := NewForWebhook(cfg, config.WebhookConfig{
+		Enabled: true,
+		Preset:  "ntfy",
+		URL:     server.URL,
+		Format:  "json",
+		Ntfy:    config.NtfyConfig{Topic: "extra"},
+	})
+
+	if err := sender.Send(analyzer.StatusTaskComplete, "Done", "session-1", Meta{}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if receivedPayload["topic"] != "extra" {
+		t.Errorf("topic = %v, want extra", receivedPayload["topic"])
+	}
+	if receivedPayload["title"] != "Task Complete" {
+		t.Errorf("title = %v, want Task Complete (from shared statuses)", receivedPayload["title"])
+	}
+
+	// The primary sender stays disabled
+	if err := New(cfg).Send(analyzer.StatusTaskComplete, "Done", "session-1", Meta{}); err != nil {
+		t.Errorf("disabled primary sender should be a no-op, got %v", err)
+	}
+}
+
+func TestSenderMetaTitleOverride(t *testing.T) {
+	var receivedPayload map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &receivedPayload)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sender := New(newTestConfig(server.URL))
+	if err := sender.Send(analyzer.StatusTaskComplete, "Done", "session-1", Meta{Title: "🚨 Task Complete"}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if receivedPayload["title"] != "🚨 Task Complete" {
+		t.Errorf("title = %v, want rule title", receivedPayload["title"])
+	}
+}
+
 func TestSenderSendWithRetry(t *testing.T) {
 	attempts := atomic.Int32{}
 
@@ -89,7 +143,7 @@ func TestSenderSendWithRetry(t *testing.T) {
 	cfg := newTestConfig(server.URL)
 	sender := New(cfg)
 
-	err := sender.Send(analyzer.StatusTaskComplete, "Test message", "session-123")
+	err := sender.Send(analyzer.StatusTaskComplete, "Test message", "session-123", Meta{})
 	if err != nil {
 		t.Errorf("Expected success after retry, got error: %v", err)
 	}
@@ -113,7 +167,7 @@ func TestSenderSendMaxRetriesExceeded(t *testing.T) {
 	cfg := newTestConfig(server.URL)
 	sender := New(cfg)
 
-	err := sender.Send(analyzer.StatusTaskComplete, "Test message", "session-123")
+	err := sender.Send(analyzer.StatusTaskComplete, "Test message", "session-123", Meta{})
 	if err == nil {
 		t.Error("Expected error after max retries, got nil")
 	}
@@ -135,11 +189,11 @@ func TestSenderSendCircuitBreaker(t *testing.T) {
 
 	// Trigger circuit breaker by failing threshold times
 	for i := 0; i < 3; i++ {
-		_ = sender.Send(analyzer.StatusTaskComplete, "Test", "session-123")
+		_ = sender.Send(analyzer.StatusTaskComplete, "Test", "session-123", Meta{})
 	}
 
 	// Next request should fail with circuit open
-	err := sender.Send(analyzer.StatusTaskComplete, "Test", "session-123")
+	err := sender.Send(analyzer.StatusTaskComplete, "Test", "session-123", Meta{})
 	if err != ErrCircuitOpen {
 		t.Errorf("Expected ErrCircuitOpen, got: %v", err)
 	}
@@ -163,11 +217,11 @@ func TestSenderSendRateLimit(t *testing.T) {
 
 	// Exhaust the rate limiter bucket (starts with 60 tokens)
 	for i := 0; i < 70; i++ {
-		_ = sender.Send(analyzer.StatusTaskComplete, "Test", "session-123")
+		_ = sender.Send(analyzer.StatusTaskComplete, "Test", "session-123", Meta{})
 	}
 
 	// Next request should be rate limited
-	err := sender.Send(analyzer.StatusTaskComplete, "Test", "session-123")
+	err := sender.Send(analyzer.StatusTaskComplete, "Test", "session-123", Meta{})
 	if err != ErrRateLimitExceeded {
 		t.Errorf("Expected ErrRateLimitExceeded, got: %v", err)
 	}
@@ -192,7 +246,7 @@ func TestSenderSendSlackFormat(t *testing.T) {
 	cfg.Notifications.Webhook.Preset = "slack"
 	sender := New(cfg)
 
-	err := sender.Send(analyzer.StatusTaskComplete, "Test message", "session-123")
+	err := sender.Send(analyzer.StatusTaskComplete, "Test message", "session-123", Meta{})
 	if err != nil {
 		t.Fatalf("Send failed: %v", err)
 	}
@@ -223,7 +277,7 @@ func TestSenderSendDiscordFormat(t *testing.T) {
 	cfg.Notifications.Webhook.Preset = "discord"
 	sender := New(cfg)
 
-	err := sender.Send(analyzer.StatusQuestion, "What should we do?", "session-456")
+	err := sender.Send(analyzer.StatusQuestion, "What should we do?", "session-456", Meta{})
 	if err != nil {
 		t.Fatalf("Send failed: %v", err)
 	}
@@ -256,7 +310,7 @@ func TestSenderSendTelegramFormat(t *testing.T) {
 	cfg.Notifications.Webhook.ChatID = "123456789"
 	sender := New(cfg)
 
-	err := sender.Send(analyzer.StatusTaskComplete, "Done!", "session-789")
+	err := sender.Send(analyzer.StatusTaskComplete, "Done!", "session-789", Meta{})
 	if err != nil {
 		t.Fatalf("Send failed: %v", err)
 	}
@@ -275,6 +329,191 @@ func TestSenderSendTelegramFormat(t *testing.T) {
 	}
 }
 
+func TestSenderSendNtfyFormat(t *testing.T) {
+	var (
+		receivedPayload map[string]interface{}
+		receivedPath    string
+		receivedAuth    string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		receivedAuth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &receivedPayload)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := newTestConfig(server.URL + "/claude-alerts")
+	cfg.Notifications.Webhook.Preset = "ntfy"
+	cfg.Notifications.Webhook.Ntfy = config.NtfyConfig{Token: "tk_secret", Tags: []string{"robot"}}
+	sender := New(cfg)
+
+	if err := sender.Send(analyzer.StatusQuestion, "Need input", "session-1", Meta{}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	// JSON publishes go to the server root, with the topic in the body
+	if receivedPath != "" && receivedPath != "/" {
+		t.Errorf("request path = %q, want server root", receivedPath)
+	}
+	if receivedPayload["topic"] != "claude-alerts" {
+		t.Errorf("topic = %v, want claude-alerts", receivedPayload["topic"])
+	}
+	if receivedPayload["title"] != "Question" {
+		t.Errorf("title = %v, want Question", receivedPayload["title"])
+	}
+	if receivedPayload["priority"] != float64(ntfyPriorityHigh) {
+		t.Errorf("priority = %v, want %d", receivedPayload["priority"], ntfyPriorityHigh)
+	}
+	if receivedAuth != "Bearer tk_secret" {
+		t.Errorf("Authorization = %q, want Bearer tk_secret", receivedAuth)
+	}
+}
+
+func TestSenderSendPushoverFormat(t *testing.T) {
+	var receivedPayload map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &receivedPayload)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"status":1,"request":"abc"}`))
+	}))
+	defer server.Close()
+
+	cfg := newTestConfig(server.URL)
+	cfg.Notifications.Webhook.Preset = "pushover"
+	cfg.Notifications.Webhook.Pushover = config.PushoverConfig{
+		UserKey:    "user-key",
+		AppToken:   "app-token",
+		Priorities: map[string]int{"session_limit_reached": 2},
+	}
+	sender := New(cfg)
+
+	if err := sender.Send(analyzer.StatusSessionLimitReached, "Limit reached", "session-1", Meta{}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	if receivedPayload["token"] != "app-token" || receivedPayload["user"] != "user-key" {
+		t.Errorf("token/user = %v/%v", receivedPayload["token"], receivedPayload["user"])
+	}
+	if receivedPayload["priority"] != float64(pushoverPriorityEmergency) {
+		t.Errorf("priority = %v, want %d", receivedPayload["priority"], pushoverPriorityEmergency)
+	}
+	if receivedPayload["retry"] != float64(pushoverDefaultRetry) {
+		t.Errorf("retry = %v, want %d", receivedPayload["retry"], pushoverDefaultRetry)
+	}
+}
+
+func TestSenderSendSlackWebAPI(t *testing.T) {
+	var (
+		receivedPayload map[string]interface{}
+		receivedAuth    string
+		reply           = `{"ok":true}`
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedAuth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &receivedPayload)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(reply))
+	}))
+	defer server.Close()
+
+	cfg := newTestConfig(server.URL)
+	cfg.Notifications.Webhook.Preset = "slack"
+	cfg.Notifications.Webhook.Slack = config.SlackConfig{
+		BotToken: "xoxb-test",
+		Channel:  "#claude",
+		Channels: map[string]string{"api": "#api-team"},
+	}
+	sender := New(cfg)
+
+	if err := sender.Send(analyzer.StatusTaskComplete, "Done", "session-1", Meta{Project: "api"}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if receivedAuth != "Bearer xoxb-test" {
+		t.Errorf("Authorization = %q, want Bearer xoxb-test", receivedAuth)
+	}
+	if receivedPayload["channel"] != "#api-team" {
+		t.Errorf("channel = %v, want #api-team", receivedPayload["channel"])
+	}
+
+	// Web API errors arrive as HTTP 200 with ok=false
+	reply = `{"ok":false,"error":"not_in_channel"}`
+	err := sender.Send(analyzer.StatusTaskComplete, "Done", "session-1", Meta{})
+	if err == nil || !strings.Contains(err.Error(), "not_in_channel") {
+		t.Errorf("expected not_in_channel error, got %v", err)
+	}
+}
+
+func TestSenderSendTemplate(t *testing.T) {
+	var (
+		receivedBody        map[string]interface{}
+		receivedContentType string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedContentType = r.Header.Get("Content-Type")
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &receivedBody)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := newTestConfig(server.URL)
+	cfg.Notifications.Webhook.Template = `{"title": {{json .Title}}, "message": {{json .Message}}, "project": {{json .Project}}, "priority": 5}`
+	sender := New(cfg)
+
+	if err := sender.Send(analyzer.StatusTaskComplete, "Done", "session-1", Meta{Project: "api"}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if receivedContentType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", receivedContentType)
+	}
+	if receivedBody["title"] != "Task Complete" || receivedBody["message"] != "Done" ||
+		receivedBody["project"] != "api" || receivedBody["priority"] != float64(5) {
+		t.Errorf("unexpected body: %v", receivedBody)
+	}
+}
+
+func TestSenderSendInvalidTemplate(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := newTestConfig(server.URL)
+	cfg.Notifications.Webhook.Template = `{"message": {{json .Message}`
+	sender := New(cfg)
+
+	err := sender.Send(analyzer.StatusTaskComplete, "Done", "session-1", Meta{})
+	if err == nil || !strings.Contains(err.Error(), "invalid webhook template") {
+		t.Errorf("expected invalid template error, got %v", err)
+	}
+	if requests != 0 {
+		t.Errorf("no request should be sent with an invalid template, got %d", requests)
+	}
+}
+
+func TestSenderTimeout(t *testing.T) {
+	cfg := newTestConfig("http://example.com")
+	cfg.Notifications.Webhook.Timeout = "250ms"
+	if got := New(cfg).client.Timeout; got != 250*time.Millisecond {
+		t.Errorf("client timeout = %v, want 250ms", got)
+	}
+
+	cfg.Notifications.Webhook.Timeout = ""
+	if got := New(cfg).client.Timeout; got != 10*time.Second {
+		t.Errorf("default client timeout = %v, want 10s", got)
+	}
+}
+
 func TestSenderSendCustomHeaders(t *testing.T) {
 	var receivedHeaders http.Header
 
@@ -291,7 +530,7 @@ func TestSenderSendCustomHeaders(t *testing.T) {
 	}
 	sender := New(cfg)
 
-	err := sender.Send(analyzer.StatusTaskComplete, "Test", "session-123")
+	err := sender.Send(analyzer.StatusTaskComplete, "Test", "session-123", Meta{})
 	if err != nil {
 		t.Fatalf("Send failed: %v", err)
 	}
@@ -327,12 +566,40 @@ func TestSenderSendDisabled(t *testing.T) {
 	cfg.Notifications.Webhook.Enabled = false
 	sender := New(cfg)
 
-	err := sender.Send(analyzer.StatusTaskComplete, "Test", "session-123")
+	err := sender.Send(analyzer.StatusTaskComplete, "Test", "session-123", Meta{})
 	if err != nil {
 		t.Errorf("Send should succeed (skipped), got error: %v", err)
 	}
 }
 
+func TestSenderSendAsyncWithResult(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	cfg := newTestConfig(server.URL)
+	cfg.Notifications.Webhook.Retry.Enabled = false
+	sender := New(cfg)
+
+	results := make(chan error, 1)
+	sender.SendAsyncWithResult(analyzer.StatusTaskComplete, "Test", "session-123", Meta{}, func(err error) {
+		results <- err
+	})
+
+	select {
+	case err := <-results:
+		if err == nil {
+			t.Error("expected delivery error for HTTP 400")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("result callback was not called")
+	}
+	if err := sender.Shutdown(time.Second); err != nil {
+		t.Errorf("Shutdown: %v", err)
+	}
+}
+
 func TestSenderSendAsync(t *testing.T) {
 	completed := make(chan bool)
 
@@ -348,7 +615,7 @@ func TestSenderSendAsync(t *testing.T) {
 
 	// Send async - should not block
 	start := time.Now()
-	sender.SendAsync(analyzer.StatusTaskComplete, "Test", "session-123")
+	sender.SendAsync(analyzer.StatusTaskComplete, "Test", "session-123", Meta{})
 	elapsed := time.Since(start)
 
 	// Should return immediately
@@ -378,7 +645,7 @@ func TestSenderShutdown(t *testing.T) {
 	sender := New(cfg)
 
 	// Start async send
-	sender.SendAsync(analyzer.StatusTaskComplete, "Test", "session-123")
+	sender.SendAsync(analyzer.StatusTaskComplete, "Test", "session-123", Meta{})
 
 	// Give it time to start
 	time.Sleep(50 * time.Millisecond)
@@ -415,7 +682,7 @@ func TestSenderShutdownCancelsRequests(t *testing.T) {
 
 	// Start multiple async sends
 	for i := 0; i < 5; i++ {
-		sender.SendAsync(analyzer.StatusTaskComplete, "Test", "session-123")
+		sender.SendAsync(analyzer.StatusTaskComplete, "Test", "session-123", Meta{})
 	}
 
 	// Give requests time to start
@@ -486,7 +753,7 @@ func TestSenderMetricsTracking(t *testing.T) {
 
 	// Send multiple requests
 	for i := 0; i < 10; i++ {
-		_ = sender.Send(analyzer.StatusTaskComplete, "Test", "session-123")
+		_ = sender.Send(analyzer.StatusTaskComplete, "Test", "session-123", Meta{})
 	}
 
 	stats := sender.GetMetrics()
@@ -516,7 +783,7 @@ func TestSenderContextCancellation(t *testing.T) {
 	sender.cancel()
 
 	// Send should fail with context canceled
-	err := sender.Send(analyzer.StatusTaskComplete, "Test", "session-123")
+	err := sender.Send(analyzer.StatusTaskComplete, "Test", "session-123", Meta{})
 	if err == nil {
 		t.Error("Expected error with canceled context, got nil")
 	}
@@ -562,7 +829,7 @@ func TestSenderSendAsyncWithShutdown(t *testing.T) {
 	// Send multiple async requests
 	numRequests := 3
 	for i := 0; i < numRequests; i++ {
-		sender.SendAsync(analyzer.StatusTaskComplete, "Test message", "session-123")
+		sender.SendAsync(analyzer.StatusTaskComplete, "Test message", "session-123", Meta{})
 	}
 
 	// Immediately call shutdown - it should wait for all requests
@@ -595,7 +862,7 @@ func TestWebhookShutdownWaitsForRequests(t *testing.T) {
 	sender := New(cfg)
 
 	// Start async send
-	sender.SendAsync(analyzer.StatusTaskComplete, "Test", "session-123")
+	sender.SendAsync(analyzer.StatusTaskComplete, "Test", "session-123", Meta{})
 
 	// Give request time to start
 	time.Sleep(50 * time.Millisecond)
@@ -620,3 +887,22 @@ func TestWebhookShutdownWaitsForRequests(t *testing.T) {
 		t.Error("Request should have completed before Shutdown returned")
 	}
 }
+
+func TestWithBearerAuth(t *testing.T) {
+	headers := withBearerAuth(map[string]string{"X-Custom": "1"}, "tk_abc")
+	if headers["Authorization"] != "Bearer tk_abc" {
+		t.Errorf("Authorization = %q, want Bearer tk_abc", headers["Authorization"])
+	}
+	if headers["X-Custom"] != "1" {
+		t.Error("custom header should be preserved")
+	}
+
+	explicit := withBearerAuth(map[string]string{"authorization": "Basic xyz"}, "tk_abc")
+	if _, ok := explicit["Authorization"]; ok {
+		t.Error("explicit authorization header should win over token")
+	}
+
+	if _, ok := withBearerAuth(nil, "")["Authorization"]; ok {
+		t.Error("no Authorization header without token")
+	}
+}
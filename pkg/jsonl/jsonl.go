@@ -16,14 +16,31 @@ type Message struct {
 	Timestamp         string         `json:"timestamp"`
 	IsApiErrorMessage bool           `json:"isApiErrorMessage,omitempty"`
 	Error             string         `json:"error,omitempty"`
+	CostUSD           float64        `json:"costUSD,omitempty"` // Recorded by some Claude Code versions
 }
 
 // MessageContent represents the content of a message
 // Content can be either a string (user text messages) or an array (tool results, assistant messages)
 type MessageContent struct {
+	ID            string    `json:"id,omitempty"`    // API message ID, shared by the lines of one streamed response
+	Model         string    `json:"model,omitempty"` // Model of assistant messages, e.g. "claude-sonnet-4-5-20250929"
 	Role          string    `json:"role"`
-	Content       []Content `json:"-"` // Array content (tool_result, assistant messages)
-	ContentString string    `json:"-"` // String content (user text messages)
+	Content       []Content `json:"-"`               // Array content (tool_result, assistant messages)
+	ContentString string    `json:"-"`               // String content (user text messages)
+	Usage         *Usage    `json:"usage,omitempty"` // Token usage of assistant messages
+}
+
+// Usage is the token usage the API reported for a response
+type Usage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+// Total returns all tokens of the response, including cache reads and writes
+func (u Usage) Total() int {
+	return u.InputTokens + u.OutputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
 }
 
 // Content represents a content block in a message
@@ -74,10 +91,16 @@ func (m *MessageContent) UnmarshalJSON(data []byte) error {
 func (m MessageContent) MarshalJSON() ([]byte, error) {
 	// Create auxiliary struct with content as interface{}
 	aux := &struct {
+		ID      string      `json:"id,omitempty"`
+		Model   string      `json:"model,omitempty"`
 		Role    string      `json:"role"`
 		Content interface{} `json:"content,omitempty"`
+		Usage   *Usage      `json:"usage,omitempty"`
 	}{
-		Role: m.Role,
+		ID:    m.ID,
+		Model: m.Model,
+		Role:  m.Role,
+		Usage: m.Usage,
 	}
 
 	// Choose content format based on which field is set
@@ -282,21 +305,22 @@ func ExtractToolInput(messages []Message, toolName string) map[string]interface{
 	return tool.Input
 }
 
+// IsUserPrompt reports whether a message is a user prompt: text the user
+// typed, or an interruption, but not a tool result
+func (m Message) IsUserPrompt() bool {
+	if m.Type != "user" {
+		return false
+	}
+	return m.Message.ContentString != "" || (len(m.Message.Content) > 0 && m.Message.Content[0].Type == "text")
+}
+
 // GetLastUserTimestamp returns the timestamp of the last user message with text content
 // Includes both string content (normal user messages) and array content with type="text" (interrupted tool use)
 // Excludes tool_result messages
 func GetLastUserTimestamp(messages []Message) string {
 	for i := len(messages) - 1; i >= 0; i-- {
-		msg := messages[i]
-		if msg.Type == "user" {
-			// Check for string content (normal user text messages)
-			if msg.Message.ContentString != "" {
-				return msg.Timestamp
-			}
-			// Check for array content with type="text" (interrupted tool use: "[Request interrupted by user for tool use]")
-			if len(msg.Message.Content) > 0 && msg.Message.Content[0].Type == "text" {
-				return msg.Timestamp
-			}
+		if messages[i].IsUserPrompt() {
+			return messages[i].Timestamp
 		}
 	}
 	return ""
@@ -948,3 +948,25 @@ func TestMessageContent_MarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestParse_Usage(t *testing.T) {
+	input := `{"type":"assistant","costUSD":0.12,"message":{"id":"msg_1","role":"assistant","content":[{"type":"text","text":"Hi"}],"usage":{"input_tokens":10,"output_tokens":20,"cache_creation_input_tokens":30,"cache_read_input_tokens":40}}}
+{"type":"user","message":{"role":"user","content":"Hello"}}`
+
+	messages, err := Parse(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Len(t, messages, 2)
+	assert.Equal(t, "msg_1", messages[0].Message.ID)
+	assert.Equal(t, 0.12, messages[0].CostUSD)
+	if assert.NotNil(t, messages[0].Message.Usage) {
+		assert.Equal(t, 100, messages[0].Message.Usage.Total())
+	}
+	assert.Nil(t, messages[1].Message.Usage)
+}
+
+func TestMessage_IsUserPrompt(t *testing.T) {
+	assert.True(t, Message{Type: "user", Message: MessageContent{ContentString: "Hello"}}.IsUserPrompt())
+	assert.True(t, Message{Type: "user", Message: MessageContent{Content: []Content{{Type: "text", Text: "[Request interrupted by user for tool use]"}}}}.IsUserPrompt())
+	assert.False(t, Message{Type: "user", Message: MessageContent{Content: []Content{{Type: "tool_result"}}}}.IsUserPrompt())
+	assert.False(t, Message{Type: "assistant", Message: MessageContent{Content: []Content{{Type: "text", Text: "Hi"}}}}.IsUserPrompt())
+}
@@ -48,6 +48,8 @@ final class UNNotificationService: NotificationSending {
         if #available(macOS 12.0, *) {
             if config.timeSensitive {
                 content.interruptionLevel = .timeSensitive
+            } else if config.passive {
+                content.interruptionLevel = .passive
             }
         }
 
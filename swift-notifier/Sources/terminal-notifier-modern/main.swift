@@ -15,6 +15,7 @@ if arguments.contains("-help") || arguments.contains("--help") {
     print("  -group          Group ID (replaces notifications with same group)")
     print("  -threadID       Thread ID for grouping notifications in a stack")
     print("  -timeSensitive  Mark as time-sensitive (breaks through Focus Mode)")
+    print("  -passive        Deliver quietly to Notification Center, without a banner")
     print("  -nosound        Suppress notification sound")
     exit(ExitCode.success)
 } else if ArgumentParser.isSendMode(arguments) {
@@ -163,6 +163,17 @@ final class ArgumentParserTests: XCTestCase {
         XCTAssertFalse(config.timeSensitive)
     }
 
+    func testParsePassive() throws {
+        let config = try ArgumentParser.parse([
+            "-title", "Completed",
+            "-message", "Body",
+            "-passive"
+        ])
+
+        XCTAssertTrue(config.passive)
+        XCTAssertFalse(config.timeSensitive)
+    }
+
     func testParseNosound() throws {
         let config = try ArgumentParser.parse([
             "-title", "Test",